@@ -0,0 +1,280 @@
+// Package client is a Go client for the HTTP API served by `docod serve`,
+// whose contract is published as docs/openapi.json.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"docod/internal/generator"
+	"docod/internal/graph"
+	"docod/internal/server"
+)
+
+// Response types shared with the server, so the client cannot drift from
+// the handlers.
+type (
+	Health            = server.Health
+	NodeResponse      = server.NodeResponse
+	NodeSummary       = server.NodeSummary
+	NeighborsResponse = server.NeighborsResponse
+	PathResponse      = server.PathResponse
+	SearchResponse    = server.SearchResponse
+	SearchResult      = server.SearchResult
+	AskRequest        = server.AskRequest
+	AskResponse       = server.AskResponse
+	SectionSummary    = server.SectionSummary
+	Section           = generator.ModelSect
+	SourceRef         = generator.SourceRef
+	SyncJob           = server.SyncJob
+	Symbol            = graph.Symbol
+	Edge              = graph.Edge
+)
+
+// Sync job states.
+const (
+	JobQueued    = server.JobQueued
+	JobRunning   = server.JobRunning
+	JobSucceeded = server.JobSucceeded
+	JobFailed    = server.JobFailed
+)
+
+// APIError is a non-2xx response.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("docod API: HTTP %d: %s", e.StatusCode, e.Message)
+}
+
+// Client calls one docod server.
+type Client struct {
+	BaseURL string
+	// APIKey is sent as a bearer token when set.
+	APIKey string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// New returns a client for the server at baseURL, e.g.
+// "http://127.0.0.1:8765".
+func New(baseURL, apiKey string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), APIKey: apiKey}
+}
+
+// NeighborsOptions filters Neighbors. Zero values use the server defaults.
+type NeighborsOptions struct {
+	Depth int
+	// Direction is "out", "in" or "both".
+	Direction string
+	// Kinds limits the relation kinds followed, e.g. "calls".
+	Kinds []string
+}
+
+// PathOptions filters Path. Zero values use the server defaults.
+type PathOptions struct {
+	MaxDepth int
+	Directed bool
+	Kinds    []string
+}
+
+// Health reports liveness and graph size.
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	return call[Health](c, ctx, http.MethodGet, "/healthz", nil, nil)
+}
+
+// Node returns a node with its edges.
+func (c *Client) Node(ctx context.Context, id string) (*NodeResponse, error) {
+	return call[NodeResponse](c, ctx, http.MethodGet, "/graph/node/"+escapeNodeID(id), nil, nil)
+}
+
+// Neighbors returns the nodes around id.
+func (c *Client) Neighbors(ctx context.Context, id string, opts NeighborsOptions) (*NeighborsResponse, error) {
+	q := url.Values{"id": {id}}
+	if opts.Depth > 0 {
+		q.Set("depth", strconv.Itoa(opts.Depth))
+	}
+	if opts.Direction != "" {
+		q.Set("direction", opts.Direction)
+	}
+	if len(opts.Kinds) > 0 {
+		q.Set("kind", strings.Join(opts.Kinds, ","))
+	}
+	return call[NeighborsResponse](c, ctx, http.MethodGet, "/graph/neighbors", q, nil)
+}
+
+// Path returns the shortest path between two nodes; Found is false when
+// there is none within MaxDepth.
+func (c *Client) Path(ctx context.Context, from, to string, opts PathOptions) (*PathResponse, error) {
+	q := url.Values{"from": {from}, "to": {to}}
+	if opts.MaxDepth > 0 {
+		q.Set("max_depth", strconv.Itoa(opts.MaxDepth))
+	}
+	if opts.Directed {
+		q.Set("directed", "true")
+	}
+	if len(opts.Kinds) > 0 {
+		q.Set("kind", strings.Join(opts.Kinds, ","))
+	}
+	return call[PathResponse](c, ctx, http.MethodGet, "/graph/path", q, nil)
+}
+
+// Search runs semantic code search; topK <= 0 uses the server default.
+func (c *Client) Search(ctx context.Context, query string, topK int) (*SearchResponse, error) {
+	q := url.Values{"q": {query}}
+	if topK > 0 {
+		q.Set("top_k", strconv.Itoa(topK))
+	}
+	return call[SearchResponse](c, ctx, http.MethodGet, "/api/search", q, nil)
+}
+
+// Ask answers question from the code with cited sources.
+func (c *Client) Ask(ctx context.Context, req AskRequest) (*AskResponse, error) {
+	return call[AskResponse](c, ctx, http.MethodPost, "/api/ask", nil, req)
+}
+
+// Sections lists the generated documentation sections.
+func (c *Client) Sections(ctx context.Context) ([]SectionSummary, error) {
+	out, err := call[[]SectionSummary](c, ctx, http.MethodGet, "/api/sections", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return *out, nil
+}
+
+// Section returns one section with its content and sources.
+func (c *Client) Section(ctx context.Context, id string) (*Section, error) {
+	return call[Section](c, ctx, http.MethodGet, "/api/sections/"+url.PathEscape(id), nil, nil)
+}
+
+// Report returns the pipeline report of the last full generation as raw
+// JSON; its layout is not part of the API contract.
+func (c *Client) Report(ctx context.Context) (json.RawMessage, error) {
+	out, err := call[json.RawMessage](c, ctx, http.MethodGet, "/api/report", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return *out, nil
+}
+
+// StartSync starts a sync. When one is already running it returns that job
+// and an *APIError with status 409.
+func (c *Client) StartSync(ctx context.Context) (*SyncJob, error) {
+	var out SyncJob
+	err := c.do(ctx, http.MethodPost, "/api/sync", nil, nil, &out)
+	if err != nil && out.ID == "" {
+		return nil, err
+	}
+	return &out, err
+}
+
+// SyncStatus returns the status of a sync job.
+func (c *Client) SyncStatus(ctx context.Context, id string) (*SyncJob, error) {
+	return call[SyncJob](c, ctx, http.MethodGet, "/api/sync/"+url.PathEscape(id), nil, nil)
+}
+
+// WaitSync polls a sync job every interval until it finishes or ctx ends.
+func (c *Client) WaitSync(ctx context.Context, id string, interval time.Duration) (*SyncJob, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		job, err := c.SyncStatus(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if job.Status == JobSucceeded || job.Status == JobFailed {
+			return job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// call decodes the response of one request into a new T.
+func call[T any](c *Client, ctx context.Context, method, path string, query url.Values, body any) (*T, error) {
+	var out T
+	if err := c.do(ctx, method, path, query, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// do sends the request and decodes the JSON response into out. A 409 body
+// is decoded into out as well, since /api/sync answers it with the running
+// job.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+		var e server.ErrorResponse
+		if json.Unmarshal(data, &e) == nil && e.Error != "" {
+			apiErr.Message = e.Error
+		} else if resp.StatusCode == http.StatusConflict {
+			_ = json.Unmarshal(data, out)
+			apiErr.Message = "a sync is already running"
+		}
+		return apiErr
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// escapeNodeID escapes each segment of a node ID; the server matches the
+// rest of the path, slashes included.
+func escapeNodeID(id string) string {
+	parts := strings.Split(id, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
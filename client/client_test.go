@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"docod/internal/graph"
+	"docod/internal/knowledge"
+	"docod/internal/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubSearcher []knowledge.SearchChunk
+
+func (s stubSearcher) SearchByText(_ context.Context, _ string, topK int, _ string) ([]knowledge.SearchChunk, error) {
+	return s[:min(topK, len(s))], nil
+}
+
+type stubAnswerer struct{ knowledge.Summarizer }
+
+func (stubAnswerer) AnswerQuestion(_ context.Context, _ string, evidence []knowledge.SearchChunk) (string, error) {
+	return "Open connects [" + knowledge.ChunkLocation(evidence[0]) + "].", nil
+}
+
+func newTestServer(t *testing.T, opts server.APIOptions) *httptest.Server {
+	t.Helper()
+	g := graph.NewGraph()
+	for _, id := range []string{"go/cli:function:Run:1", "go/store:function:Open:2"} {
+		g.AddSymbol(&graph.Symbol{ID: id, Name: id, UnitType: "function"})
+	}
+	g.Edges = []graph.Edge{{From: "go/cli:function:Run:1", To: "go/store:function:Open:2", Kind: graph.RelationCalls, Confidence: 0.9}}
+	chunks := stubSearcher{{
+		ID:       "go/store:function:Open:2",
+		Name:     "Open",
+		UnitType: "function",
+		Sources:  []knowledge.ChunkSource{{SymbolID: "go/store:function:Open:2", FilePath: "store/db.go", StartLine: 10, EndLine: 20, Relation: "primary"}},
+	}}
+	api := server.NewRESTAPI(&server.Backend{Search: chunks, Summarizer: stubAnswerer{}}, opts)
+	mux := server.NewMux(server.NewGraphAPI(g), api.Auth)
+	api.Register(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_GraphQueries(t *testing.T) {
+	c := New(newTestServer(t, server.APIOptions{}).URL+"/", "")
+	ctx := context.Background()
+
+	health, err := c.Health(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Health{Status: "ok", Nodes: 2, Edges: 1}, *health)
+
+	node, err := c.Node(ctx, "go/cli:function:Run:1")
+	require.NoError(t, err)
+	assert.Equal(t, "go/cli:function:Run:1", node.Node.ID)
+	require.Len(t, node.Outgoing, 1)
+
+	neighbors, err := c.Neighbors(ctx, "go/cli:function:Run:1", NeighborsOptions{Direction: "out", Kinds: []string{"calls"}})
+	require.NoError(t, err)
+	require.Len(t, neighbors.Nodes, 1)
+	assert.Equal(t, "go/store:function:Open:2", neighbors.Nodes[0].ID)
+
+	path, err := c.Path(ctx, "go/cli:function:Run:1", "go/store:function:Open:2", PathOptions{Directed: true})
+	require.NoError(t, err)
+	assert.True(t, path.Found)
+
+	_, err = c.Node(ctx, "go/missing:function:X:9")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "node not found: go/missing:function:X:9", apiErr.Message)
+}
+
+func TestClient_SearchAskAndDocs(t *testing.T) {
+	dir := t.TempDir()
+	opts := server.APIOptions{
+		ModelPath:  filepath.Join(dir, "doc_model.json"),
+		ReportPath: filepath.Join(dir, "pipeline_report.json"),
+		APIKeys:    func() []string { return []string{"secret"} },
+	}
+	srv := newTestServer(t, opts)
+	ctx := context.Background()
+
+	_, err := New(srv.URL, "").Search(ctx, "open", 0)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+
+	c := New(srv.URL, "secret")
+	results, err := c.Search(ctx, "open", 5)
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.Equal(t, "store/db.go:10-20", results.Results[0].Location)
+
+	answer, err := c.Ask(ctx, AskRequest{Question: "How is the store opened?"})
+	require.NoError(t, err)
+	assert.Equal(t, "Open connects [store/db.go:10-20].", answer.Answer)
+	require.NotEmpty(t, answer.Sources)
+
+	require.NoError(t, os.WriteFile(opts.ModelPath, []byte(`{"sections":[{"id":"overview","title":"Overview","content_md":"# Overview"}]}`), 0o644))
+	require.NoError(t, os.WriteFile(opts.ReportPath, []byte(`{"mode":"full_generate"}`), 0o644))
+	sections, err := c.Sections(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []SectionSummary{{ID: "overview", Title: "Overview"}}, sections)
+	sec, err := c.Section(ctx, "overview")
+	require.NoError(t, err)
+	assert.Equal(t, "# Overview", sec.ContentMD)
+	report, err := c.Report(ctx)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"mode":"full_generate"}`, string(report))
+}
+
+func TestClient_SyncJobs(t *testing.T) {
+	release := make(chan struct{})
+	c := New(newTestServer(t, server.APIOptions{Sync: func(ctx context.Context) (*server.Backend, error) {
+		<-release
+		return nil, errors.New("git not available")
+	}}).URL, "")
+	ctx := context.Background()
+
+	job, err := c.StartSync(ctx)
+	require.NoError(t, err)
+	running, err := c.StartSync(ctx)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+	assert.Equal(t, job.ID, running.ID, "409 carries the running job")
+	close(release)
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	done, err := c.WaitSync(ctx, job.ID, 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, JobFailed, done.Status)
+	assert.Equal(t, "git not available", done.Error)
+}
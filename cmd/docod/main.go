@@ -2,21 +2,38 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"docod/internal/config"
 	"docod/internal/crawler"
+	"docod/internal/editor"
 	"docod/internal/extractor"
 	"docod/internal/generator"
+	"docod/internal/git"
 	"docod/internal/graph"
 	"docod/internal/index"
 	"docod/internal/knowledge"
+	"docod/internal/lsp"
+	"docod/internal/mcp"
 	"docod/internal/pipeline"
+	"docod/internal/profiling"
+	"docod/internal/publish"
+	"docod/internal/resolver"
+	"docod/internal/server"
+	"docod/internal/site"
 	"docod/internal/storage"
+	"docod/internal/watch"
 
 	"github.com/spf13/cobra"
 )
@@ -25,14 +42,77 @@ var (
 	rootCmd = &cobra.Command{
 		Use:   "docod",
 		Short: "AI-powered Documentation Agent",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := enterProjectRoot(cmd, args); err != nil {
+				return err
+			}
+			if pprofDir == "" {
+				return nil
+			}
+			if err := profiling.Start(pprofDir); err != nil {
+				return fmt.Errorf("failed to start profiling: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Profiling stages into %s\n", pprofDir)
+			return nil
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			return profiling.Stop()
+		},
 	}
-	dbPath      string
-	syncForce   bool
-	updateForce bool
+	dbPath          string
+	syncForce       bool
+	scanFull        bool
+	updateForce     bool
+	preciseCalls    bool
+	strictEdges     bool
+	planOnly        bool
+	dryRun          bool
+	restartGenerate bool
+	updateSince     string
+	updateRange     string
+	graphDiffFormat string
+	graphDiffOutput string
+	serveAddr       string
+	exportFormat    string
+	exportOutput    string
+	siteFormat      string
+	siteOutput      string
+	siteName        string
+	htmlOutput      string
+	htmlSourceURL   string
+	htmlMermaid     string
+	seqDepth        int
+	seqMessages     int
+	seqMayCall      bool
+	queryDepth      int
+	queryFormat     string
+	// invokedFrom is the original working directory when enterProjectRoot
+	// moved to the project root, else empty.
+	invokedFrom  string
+	profileName  string
+	configStrict bool
+	schemaOutput string
+	indexOutput  string
+	specOutput   string
+	pprofDir     string
+	sharded      bool
+	askTopK      int
+	askJSON      bool
+	watchQuiet   time.Duration
+	ciBase       string
+	ciHead       string
+	ciFormat     string
+	ciOutput     string
+	ciComment    bool
+	ciPR         int
+	costRuns     int
+	costRunID    int64
+	costJSON     bool
 )
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
+		_ = profiling.Stop()
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -41,17 +121,154 @@ func main() {
 func init() {
 	// Default DB path is local to the project
 	rootCmd.PersistentFlags().StringVarP(&dbPath, "db", "d", "docod.db", "Path to the local knowledge graph database (SQLite)")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "Config profile to apply over config.yaml (overrides DOCOD_PROFILE)")
+	rootCmd.PersistentFlags().StringVar(&pprofDir, "pprof", "", "Write CPU and heap profiles of each pipeline stage into this directory")
 
 	rootCmd.AddCommand(syncCmd)
 	rootCmd.AddCommand(scanCmd)
 	rootCmd.AddCommand(updateCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(ciCmd)
 	rootCmd.AddCommand(generateCmd)
+	rootCmd.AddCommand(graphCmd)
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.AddCommand(serveOpenAPICmd)
+	rootCmd.AddCommand(lspCmd)
+	rootCmd.AddCommand(rpcCmd)
+	rootCmd.AddCommand(askCmd)
+	rootCmd.AddCommand(mcpCmd)
+	rootCmd.AddCommand(publishCmd)
+	publishCmd.AddCommand(publishConfluenceCmd)
+	publishCmd.AddCommand(publishNotionCmd)
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.AddCommand(exportSearchIndexCmd)
+	exportCmd.AddCommand(exportHTMLCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportCostCmd)
+	rootCmd.AddCommand(configCmd)
+	graphCmd.AddCommand(graphDiffCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	graphCmd.AddCommand(graphExportCmd)
+	graphCmd.AddCommand(graphSequenceCmd)
+	graphCmd.AddCommand(graphQueryCmd)
+	graphQueryCmd.AddCommand(queryWhoCallsCmd)
+	graphQueryCmd.AddCommand(queryDependenciesCmd)
+	graphQueryCmd.AddCommand(queryPathCmd)
 
 	// Prefer `sync` as the primary command; keep generate for compatibility.
 	generateCmd.Hidden = true
 
+	scanCmd.Flags().BoolVar(&scanFull, "full", false, "Parse every file again instead of reusing files whose content hash is unchanged")
 	syncCmd.Flags().BoolVarP(&syncForce, "force", "f", false, "Sync current codebase even when git reports no changes")
 	updateCmd.Flags().BoolVarP(&updateForce, "force", "f", false, "Update docs from current codebase even when git reports no changes")
+	syncCmd.Flags().BoolVar(&preciseCalls, "precise-calls", false, "Resolve calls with a whole-module SSA call graph (slower)")
+	updateCmd.Flags().BoolVar(&preciseCalls, "precise-calls", false, "Resolve calls with a whole-module SSA call graph (slower)")
+	syncCmd.Flags().BoolVar(&strictEdges, "strict-edges", false, "Keep only high-confidence edges (precision over recall)")
+	updateCmd.Flags().BoolVar(&strictEdges, "strict-edges", false, "Keep only high-confidence edges (precision over recall)")
+	watchCmd.Flags().BoolVar(&preciseCalls, "precise-calls", false, "Resolve calls with a whole-module SSA call graph (slower)")
+	watchCmd.Flags().BoolVar(&strictEdges, "strict-edges", false, "Keep only high-confidence edges (precision over recall)")
+	ciCmd.Flags().BoolVar(&preciseCalls, "precise-calls", false, "Resolve calls with a whole-module SSA call graph (slower)")
+	ciCmd.Flags().BoolVar(&strictEdges, "strict-edges", false, "Keep only high-confidence edges (precision over recall)")
+	ciCmd.Flags().StringVar(&ciBase, "base", "", "Base revision of the range (default: origin/$GITHUB_BASE_REF in pull request workflows)")
+	ciCmd.Flags().StringVar(&ciHead, "head", "HEAD", "Head revision of the range")
+	ciCmd.Flags().StringVar(&ciFormat, "format", "markdown", "Report format: markdown or sarif")
+	ciCmd.Flags().StringVarP(&ciOutput, "output", "o", "", "Write the report to a file instead of stdout")
+	ciCmd.Flags().BoolVar(&ciComment, "comment", false, "Post the Markdown report as a pull request comment (needs GITHUB_TOKEN and GITHUB_REPOSITORY)")
+	ciCmd.Flags().IntVar(&ciPR, "pr", 0, "Pull request number to comment on (default: read from GITHUB_EVENT_PATH)")
+	watchCmd.Flags().DurationVar(&watchQuiet, "debounce", watch.DefaultDebounce, "How long the project must stay quiet after a change before a sync runs")
+	generateCmd.Flags().BoolVar(&sharded, "sharded", false, "Document each top-level directory or module as its own shard, then compose a global overview (see sharding in config.yaml)")
+	updateCmd.Flags().StringVar(&updateSince, "since", "", "Update for everything changed since this revision; \"last\" uses the commit the docs were last updated at")
+	updateCmd.Flags().StringVar(&updateRange, "range", "", "Update for a revision range (A..B, or A...B from the merge base) instead of the working tree")
+	updateCmd.MarkFlagsMutuallyExclusive("since", "range")
+	updateCmd.Flags().BoolVar(&planOnly, "plan-only", false, "Print which sections would be rewritten and the estimated token cost, then exit without writing")
+	dryRunUsage := "Plan and retrieve without writing or calling the LLM or embedding models (search queries are still embedded); print the sections that would change and the estimated calls, tokens and cost"
+	generateCmd.Flags().BoolVar(&dryRun, "dry-run", false, dryRunUsage)
+	generateCmd.Flags().BoolVar(&restartGenerate, "restart", false, "Generate every section again instead of resuming an interrupted run from its checkpoint")
+	updateCmd.Flags().BoolVar(&dryRun, "dry-run", false, dryRunUsage)
+	syncCmd.Flags().BoolVar(&dryRun, "dry-run", false, dryRunUsage)
+	askCmd.Flags().IntVarP(&askTopK, "top-k", "k", 8, "Number of retrieved chunks the answer draws on")
+	askCmd.Flags().BoolVar(&askJSON, "json", false, "Print the answer and its sources as JSON")
+	configValidateCmd.Flags().BoolVar(&configStrict, "strict", false, "Fail on warnings such as missing provider keys, not only on errors")
+	configSchemaCmd.Flags().StringVarP(&schemaOutput, "output", "o", "", "Write the schema to a file instead of stdout")
+	graphSequenceCmd.Flags().IntVar(&seqDepth, "depth", 4, "How many calls deep to follow the chain")
+	graphSequenceCmd.Flags().IntVar(&seqMessages, "max-messages", 40, "Maximum number of calls to draw")
+	graphSequenceCmd.Flags().BoolVar(&seqMayCall, "may-call", false, "Also follow interface calls to their possible implementations")
+	graphQueryCmd.PersistentFlags().StringVar(&queryFormat, "format", "tree", "Output format: tree or json")
+	queryWhoCallsCmd.Flags().IntVar(&queryDepth, "depth", 1, "How many levels of callers to follow")
+	queryDependenciesCmd.Flags().IntVar(&queryDepth, "depth", 1, "How many levels of dependencies to follow")
+	graphExportCmd.Flags().StringVar(&exportFormat, "format", "cypher", "Export format: cypher|dot|graphml|json")
+	graphExportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "Write the export to a file instead of stdout")
+	exportCmd.Flags().StringVar(&siteFormat, "format", "", "Static site generator: mkdocs or docusaurus")
+	exportCmd.Flags().StringVarP(&siteOutput, "output", "o", "site", "Directory to write the site source to")
+	exportCmd.Flags().StringVar(&siteName, "site-name", "", "Site title (default: the document title)")
+	exportHTMLCmd.Flags().StringVarP(&htmlOutput, "output", "o", "", "HTML file (default: documentation.html in the output dir)")
+	exportHTMLCmd.Flags().StringVar(&htmlSourceURL, "source-url", "", "Base URL of the source files, e.g. https://github.com/owner/repo/blob/main (default: paths relative to the HTML file)")
+	exportHTMLCmd.Flags().StringVar(&htmlMermaid, "mermaid-script", publish.DefaultMermaidScript, "Mermaid ES module that renders the diagrams")
+	exportSearchIndexCmd.Flags().StringVarP(&indexOutput, "output", "o", "", "Index file (default: search_index.json in the output dir)")
+	serveOpenAPICmd.Flags().StringVarP(&specOutput, "output", "o", "", "Write the document to a file instead of stdout")
+	reportCostCmd.Flags().IntVar(&costRuns, "runs", 10, "How many recent runs to list (0 lists all)")
+	reportCostCmd.Flags().Int64Var(&costRunID, "run", 0, "Run to break down by section and model (default: the latest)")
+	reportCostCmd.Flags().BoolVar(&costJSON, "json", false, "Print the runs and the breakdown as JSON")
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "127.0.0.1:8765", "Address to listen on")
+	graphDiffCmd.Flags().StringVar(&graphDiffFormat, "format", "json", "Output format: json or text")
+	graphDiffCmd.Flags().StringVarP(&graphDiffOutput, "output", "o", "", "Write the diff to a file instead of stdout")
+}
+
+// enterProjectRoot switches to the directory holding the nearest project
+// config, so docod run from a subdirectory sees the same project root, docs
+// and database as from the top. Explicit relative --db and --output paths stay
+// relative to where the command was run.
+func enterProjectRoot(cmd *cobra.Command, _ []string) error {
+	config.SetProfile(profileName)
+	path, ok := config.FindProjectConfig(".")
+	if !ok {
+		return nil
+	}
+	root := filepath.Dir(path)
+	cwd, err := os.Getwd()
+	if err != nil || cwd == root {
+		return nil
+	}
+	invokedFrom = cwd
+	for _, name := range []string{"db", "output", "pprof"} {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || !flag.Changed || flag.Value.String() == "" || filepath.IsAbs(flag.Value.String()) {
+			continue
+		}
+		if err := flag.Value.Set(filepath.Join(cwd, flag.Value.String())); err != nil {
+			return err
+		}
+	}
+	if err := os.Chdir(root); err != nil {
+		return fmt.Errorf("failed to enter project root %s: %w", root, err)
+	}
+	fmt.Fprintf(os.Stderr, "Using project config %s\n", path)
+	return nil
+}
+
+// configReloadInterval is how often long-running commands check the config
+// files for edits.
+const configReloadInterval = 2 * time.Second
+
+// watchConfig reloads the config in the background for long-running
+// commands, so provider, budget and doc plan edits apply without a restart.
+func watchConfig(ctx context.Context) *config.Live {
+	live, err := config.NewLive(config.DefaultPath)
+	if err != nil {
+		log.Printf("Warning: config hot-reload disabled: %v", err)
+		return nil
+	}
+	live.Extra = func(cfg *config.Config) []string {
+		if cfg.Docs.PlanPath != "" {
+			return []string{cfg.Docs.PlanPath}
+		}
+		return []string{generator.DefaultDocPlanPath}
+	}
+	go live.Watch(ctx, configReloadInterval)
+	fmt.Println("  -> Watching config for changes")
+	return live
 }
 
 // initStore initializes the SQLite store.
@@ -75,21 +292,34 @@ func initEngine(ctx context.Context, g *graph.Graph, store *storage.SQLiteStore)
 	switch embeddingProvider {
 	case "openai":
 		baseURL = cfg.AI.OpenAIBaseURL
+	case "azure-openai":
+		baseURL = cfg.AI.Azure.Endpoint
 	case "ollama":
 		embedKey = ""
 		baseURL = cfg.AI.OllamaBaseURL
+	case "local":
+		embedKey = ""
 	}
-	if embeddingProvider != "ollama" && strings.TrimSpace(embedKey) == "" {
+	if embeddingProvider != "ollama" && embeddingProvider != "local" && strings.TrimSpace(embedKey) == "" {
 		return nil, nil, fmt.Errorf("embedding API key not configured for provider=%s", cfg.AI.EmbeddingProvider)
 	}
 
+	// One limiter spaces embedding and LLM requests alike.
+	limiter := knowledge.NewRateLimiter(cfg.AI.RequestsPerMinute)
+
 	// 1. Setup Embedder
 	embedder, err := knowledge.NewEmbedder(ctx, knowledge.EmbedderOptions{
-		Provider:  cfg.AI.EmbeddingProvider,
-		APIKey:    embedKey,
-		Model:     cfg.AI.EmbeddingModel,
-		Dimension: cfg.AI.EmbeddingDim,
-		BaseURL:   baseURL,
+		Provider:          cfg.AI.EmbeddingProvider,
+		APIKey:            embedKey,
+		Model:             cfg.AI.EmbeddingModel,
+		Dimension:         cfg.AI.EmbeddingDim,
+		BaseURL:           baseURL,
+		Deployment:        cfg.AI.Azure.EmbeddingDeployment,
+		APIVersion:        cfg.AI.Azure.APIVersion,
+		RuntimeLibrary:    cfg.AI.Local.RuntimeLibrary,
+		MaxSequenceLength: cfg.AI.Local.MaxSequenceLength,
+		Tokenizer:         cfg.AI.EmbeddingTokenizer,
+		RateLimiter:       limiter,
 	})
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create embedder: %w", err)
@@ -99,22 +329,95 @@ func initEngine(ctx context.Context, g *graph.Graph, store *storage.SQLiteStore)
 	llmProvider := strings.ToLower(strings.TrimSpace(cfg.AI.LLMProvider))
 	llmKey := strings.TrimSpace(cfg.AI.LLMAPIKey)
 	llmBaseURL := strings.TrimSpace(cfg.AI.LLMBaseURL)
-	if (llmProvider == "gemini" || llmProvider == "openai") && llmKey == "" {
+	if llmProvider == "azure-openai" {
+		llmBaseURL = strings.TrimSpace(cfg.AI.Azure.Endpoint)
+	}
+	if (llmProvider == "gemini" || llmProvider == "openai" || llmProvider == "azure-openai" || llmProvider == "anthropic") && llmKey == "" && !dryRun {
 		return nil, nil, fmt.Errorf("LLM API key not configured for provider=%s", cfg.AI.LLMProvider)
 	}
 	summarizer, err := knowledge.NewSummarizer(ctx, knowledge.SummarizerOptions{
-		Provider: cfg.AI.LLMProvider,
-		APIKey:   llmKey,
-		Model:    cfg.AI.LLMModel,
-		BaseURL:  llmBaseURL,
+		Provider:        cfg.AI.LLMProvider,
+		APIKey:          llmKey,
+		Model:           cfg.AI.LLMModel,
+		BaseURL:         llmBaseURL,
+		ContextWindow:   cfg.AI.LLMContextWindow,
+		MaxOutputTokens: cfg.AI.LLMMaxOutput,
+		Deployment:      cfg.AI.Azure.LLMDeployment,
+		APIVersion:      cfg.AI.Azure.APIVersion,
+		Tokenizer:       cfg.AI.Tokenizer,
+		DryRun:          dryRun,
+		RateLimiter:     limiter,
 	})
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create llm summarizer: %w", err)
 	}
+	index, err := storage.OpenVectorIndex(ctx, cfg.VectorStore.Driver, storage.VectorIndexConfig{
+		SQLite: store,
+		Search: storage.SearchOptions{
+			PageSize:         cfg.VectorSearch.PageSize,
+			MemoryLimitBytes: int64(cfg.VectorSearch.MemoryLimitMB) << 20,
+			Workers:          cfg.VectorSearch.Workers,
+			Mmap:             cfg.VectorSearch.Mmap,
+			VectorFile:       cfg.VectorSearch.VectorFile,
+			Index:            cfg.VectorSearch.Index,
+			HNSWM:            cfg.VectorSearch.HNSWM,
+			EfSearch:         cfg.VectorSearch.EfSearch,
+		},
+		URL:        cfg.VectorStore.URL,
+		Collection: cfg.VectorStore.Collection,
+		APIKey:     strings.TrimSpace(cfg.VectorStore.APIKey),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open vector store: %w", err)
+	}
 
 	// 3. Create Engine
-	// Store implements Indexer via our adapter methods
-	engine := knowledge.NewEngine(g, embedder, store)
+	engine := knowledge.NewEngine(g, embedder, index)
+	engine.SetDryRun(dryRun)
+	reranker, err := knowledge.NewReranker(knowledge.RerankerOptions{
+		Provider: cfg.AI.RerankProvider,
+		APIKey:   strings.TrimSpace(cfg.AI.RerankAPIKey),
+		Model:    cfg.AI.RerankModel,
+		BaseURL:  cfg.AI.RerankBaseURL,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create reranker: %w", err)
+	}
+	engine.SetReranker(reranker)
+	engine.SetDualEmbeddings(cfg.AI.DualEmbeddings)
+	engine.SetQueryVectorCache(store, knowledge.QueryCacheOptions{
+		Model:      fmt.Sprintf("%s/%s/%d", embeddingProvider, cfg.AI.EmbeddingModel, cfg.AI.EmbeddingDim),
+		MaxEntries: cfg.AI.QueryCacheMax,
+		TTL:        time.Duration(cfg.AI.QueryCacheTTLHrs) * time.Hour,
+	})
+	engine.SetEmbeddingCache(store, embeddingProvider, fmt.Sprintf("%s/%d", cfg.AI.EmbeddingModel, cfg.AI.EmbeddingDim))
+	embedTokenizer := cfg.AI.EmbeddingTokenizer
+	if strings.TrimSpace(embedTokenizer) == "" {
+		embedTokenizer = knowledge.TokenizerFor(embeddingProvider, cfg.AI.EmbeddingModel)
+	}
+	tokenizer, err := knowledge.NewTokenizer(embedTokenizer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create embedding tokenizer: %w", err)
+	}
+	engine.SetTokenizer(tokenizer, cfg.AI.EmbeddingMaxTokens)
+	if cfg.Docs.HierarchicalSearch {
+		if us, ok := summarizer.(knowledge.UnitSummarizer); ok {
+			engine.SetCoarseLayer(us, store, knowledge.CoarseOptions{MaxSummariesPerRun: cfg.Docs.MaxSummariesPerRun})
+		}
+	}
+	engine.SetScopeFilter(knowledge.SearchFilter{
+		ExcludeTests: cfg.Scope.ExcludeTests,
+		ExcludePaths: cfg.Scope.ExcludePaths,
+	})
+	engine.SetChunkingOptions(knowledge.ChunkingOptions{
+		Strategy:      cfg.Chunking.Strategy,
+		WindowLines:   cfg.Chunking.WindowLines,
+		OverlapLines:  cfg.Chunking.OverlapLines,
+		WindowTokens:  cfg.Chunking.WindowTokens,
+		OverlapTokens: cfg.Chunking.OverlapTokens,
+		MaxSegments:   cfg.Chunking.MaxSegments,
+		Languages:     cfg.Chunking.Languages,
+	})
 
 	return engine, summarizer, nil
 }
@@ -136,6 +439,9 @@ var scanCmd = &cobra.Command{
 		if path != "." {
 			// Basic path handling, in real app use filepath.Abs
 			absPath = path
+			if invokedFrom != "" && !filepath.IsAbs(path) {
+				absPath = filepath.Join(invokedFrom, path)
+			}
 		}
 
 		fmt.Printf("📂 Scanning directory: %s\n", absPath)
@@ -155,23 +461,49 @@ var scanCmd = &cobra.Command{
 		}
 
 		cr := crawler.NewCrawler(ext)
+		if cfg, err := config.LoadConfig("config.yaml"); err == nil {
+			cr.SetSymlinkPolicy(crawler.ParseSymlinkPolicy(cfg.Project.SymlinkPolicy))
+			cr.SetConcurrency(cfg.Project.ScanConcurrency)
+		}
 		idx := index.NewIndexer(cr)
 
 		// 3. Build Graph
 		fmt.Println("🚀 Building dependency graph...")
+		ctx := context.Background()
 		start := time.Now()
-		g, err := idx.BuildGraph(absPath)
+		endStage := profiling.Stage("build_graph")
+		g, stats, err := idx.BuildGraphCached(ctx, absPath, store, scanFull)
+		endStage()
 		if err != nil {
 			log.Fatalf("Build failed: %v", err)
 		}
 		fmt.Printf("✅ Graph built in %v. Found %d nodes.\n", time.Since(start), len(g.Nodes))
+		if stats.Reused > 0 {
+			fmt.Printf("  -> Parsed %d files, reused %d unchanged.\n", stats.Parsed, stats.Reused)
+		}
 
 		// 4. Save to DB
-		ctx := context.Background()
+		if version, err := store.GetMeta(ctx, storage.MetaSymbolIDVersion); err == nil && version != extractor.SymbolIDVersion {
+			if old, err := store.LoadGraph(ctx); err == nil && len(old.Nodes) > 0 {
+				mapping := graph.MatchSymbolIDs(old, g)
+				if n, err := store.RemapSymbolIDs(ctx, mapping); err != nil {
+					log.Printf("⚠️ Failed to remap indexed chunks: %v", err)
+				} else {
+					fmt.Printf("🪪 Migrated %d symbol IDs to scheme v%s (%d chunks rewritten).\n", len(mapping), extractor.SymbolIDVersion, n)
+				}
+			}
+		}
+
 		fmt.Println("💾 Saving to local database...")
-		if err := store.SaveGraph(ctx, g); err != nil {
+		endStage = profiling.Stage("save_graph")
+		err = store.SaveGraph(ctx, g)
+		endStage()
+		if err != nil {
 			log.Fatalf("Failed to save graph: %v", err)
 		}
+		if err := store.SetMeta(ctx, storage.MetaSymbolIDVersion, extractor.SymbolIDVersion); err != nil {
+			log.Fatalf("Failed to record symbol ID version: %v", err)
+		}
 
 		// 5. Index Embeddings (Optional/Future: could be done here if API key exists)
 		// For now, we leave it to explicit 'generate' or 'update' to avoid cost on every scan.
@@ -186,6 +518,10 @@ var syncCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		// Bootstrap if db does not exist.
 		if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+			if dryRun {
+				fmt.Println("🧪 Dry run: no local graph database found; a bootstrap would scan the project and generate all documentation.")
+				return
+			}
 			fmt.Println("🆕 No local graph database found. Running initial bootstrap...")
 			scanCmd.Run(scanCmd, []string{"."})
 			generateCmd.Run(generateCmd, []string{})
@@ -194,6 +530,9 @@ var syncCmd = &cobra.Command{
 
 		// Otherwise, run incremental update flow.
 		runner := pipeline.NewIncrementalSync(dbPath)
+		runner.PreciseCalls = preciseCalls
+		runner.StrictEdges = strictEdges
+		runner.DryRun = dryRun
 		if err := runner.Run(context.Background(), syncForce); err != nil {
 			log.Fatalf("Sync failed: %v", err)
 		}
@@ -205,162 +544,1440 @@ var updateCmd = &cobra.Command{
 	Short: "Incrementally update the knowledge graph and documentation based on git changes",
 	Run: func(cmd *cobra.Command, args []string) {
 		runner := pipeline.NewIncrementalSync(dbPath)
+		runner.PreciseCalls = preciseCalls
+		runner.StrictEdges = strictEdges
+		runner.PlanOnly = planOnly
+		runner.DryRun = dryRun
+		runner.Since = updateSince
+		runner.Range = updateRange
 		if err := runner.Run(context.Background(), updateForce); err != nil {
 			log.Fatalf("Update failed: %v", err)
 		}
 	},
 }
 
-var generateCmd = &cobra.Command{
-	Use:   "generate",
-	Short: "Generate documentation from the knowledge graph",
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch the project and run the incremental update after each save",
 	Run: func(cmd *cobra.Command, args []string) {
-		ctx := context.Background()
-		report := generator.NewPipelineReport("full_generate", "docs")
-		reportPath := "docs/pipeline_report.json"
+		if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+			log.Fatalf("No local graph database at %s; run 'docod sync' first", dbPath)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
 
-		// 1. Initialize Store
-		stage := report.BeginStage("init_store")
-		store, err := initStore()
+		// The crawler decides which files the graph covers; its extractor is
+		// only needed for parsing, so none is built here.
+		filter := crawler.NewCrawler(nil)
+		// Each sync loads the config afresh; watching it reports edits as
+		// they happen, including ones that fail to load.
+		if live := watchConfig(ctx); live != nil {
+			live.OnChange(func(_, _ *config.Config, changes []config.Change) {
+				fmt.Printf("⚙️  Config changed (%d value(s)); the next sync uses it.\n", len(changes))
+			})
+		}
+		w := watch.New(".", watch.Options{
+			Debounce: watchQuiet,
+			Accept:   filter.Accepts,
+		})
+		fmt.Printf("👀 Watching for changes (debounce %s). Press Ctrl+C to stop.\n", watchQuiet)
+		err := w.Run(ctx, func(ctx context.Context, paths []string) error {
+			fmt.Printf("\n🔄 %d file(s) changed: %s\n", len(paths), strings.Join(paths, ", "))
+			runner := pipeline.NewIncrementalSync(dbPath)
+			runner.PreciseCalls = preciseCalls
+			runner.StrictEdges = strictEdges
+			modelPath := generator.ModelPathFor(runner.DocPath)
+			before, _ := generator.LoadDocModel(modelPath)
+			if err := runner.RunForPaths(ctx, paths); err != nil {
+				// Keep watching: the next save may fix what broke this run.
+				log.Printf("Sync failed: %v", err)
+				return nil
+			}
+			after, err := generator.LoadDocModel(modelPath)
+			if err != nil {
+				return nil
+			}
+			changes := generator.DiffSections(before, after)
+			if len(changes) == 0 {
+				fmt.Println("📄 No documentation sections changed.")
+				return nil
+			}
+			fmt.Printf("📄 %d section(s) touched:\n", len(changes))
+			for _, c := range changes {
+				fmt.Printf("  - [%s] %s\n", c.Kind, c.Title)
+			}
+			return nil
+		})
 		if err != nil {
-			report.EndStage(stage, "error", nil, nil, err)
-			_ = report.Save(reportPath)
-			log.Fatalf("Failed to initialize database: %v", err)
+			log.Fatalf("Watch stopped: %v", err)
 		}
-		report.EndStage(stage, "ok", nil, nil, nil)
-		defer store.Close()
+		fmt.Println("👋 Stopped watching.")
+	},
+}
 
-		fmt.Println("🔄 Loading knowledge graph...")
-		stage = report.BeginStage("load_graph")
-		g, err := store.LoadGraph(ctx)
+var ciCmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Update docs for a commit range and report the changed sections for review",
+	Long: `Run the incremental update for the files changed between --base and
+--head (git diff base...head), then report which documentation sections
+were added, updated or removed: as Markdown, as a SARIF log for code
+scanning, or as a pull request comment that later runs edit in place.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		if ciFormat != "markdown" && ciFormat != "sarif" {
+			log.Fatalf("Unknown report format %q (want markdown or sarif)", ciFormat)
+		}
+		base := ciBase
+		if base == "" && os.Getenv("GITHUB_BASE_REF") != "" {
+			base = "origin/" + os.Getenv("GITHUB_BASE_REF")
+		}
+		if base == "" {
+			log.Fatalf("--base is required outside a pull request workflow")
+		}
+		if _, err := os.Stat(dbPath); os.IsNotExist(err) {
+			log.Fatalf("No local graph database at %s; restore it from cache or run 'docod sync' on the base first", dbPath)
+		}
+		changes, err := git.GetChangedFilesInRange(base, ciHead)
 		if err != nil {
-			report.EndStage(stage, "error", nil, nil, err)
-			_ = report.Save(reportPath)
-			log.Fatalf("Failed to load graph: %v", err)
+			log.Fatalf("Failed to diff %s...%s: %v", base, ciHead, err)
+		}
+		paths := make([]string, len(changes))
+		for i, c := range changes {
+			paths[i] = c.Path
 		}
-		report.EndStage(stage, "ok", map[string]float64{
-			"nodes_total": float64(len(g.Nodes)),
-			"edges_total": float64(len(g.Edges)),
-		}, nil, nil)
 
-		// 2. Initialize Engine & Summarizer
-		stage = report.BeginStage("init_engine")
-		engine, summarizer, err := initEngine(ctx, g, store)
-		if err != nil {
-			report.EndStage(stage, "error", nil, nil, err)
-			report.AddSignal("engine_init_failed", "init_engine", "critical", "Failed to initialize embedder/summarizer.", 1)
-			_ = report.Save(reportPath)
-			log.Fatalf("Setup failed: %v\nCheck your config.yaml and API keys.", err)
+		runner := pipeline.NewIncrementalSync(dbPath)
+		runner.PreciseCalls = preciseCalls
+		runner.StrictEdges = strictEdges
+		modelPath := generator.ModelPathFor(runner.DocPath)
+		before, _ := generator.LoadDocModel(modelPath)
+		if err := runner.RunForChanges(ctx, changes); err != nil {
+			log.Fatalf("Update failed: %v", err)
 		}
-		report.EndStage(stage, "ok", nil, nil, nil)
+		after, err := generator.LoadDocModel(modelPath)
+		if err != nil && !os.IsNotExist(err) {
+			log.Fatalf("Failed to load doc model: %v", err)
+		}
+		report := generator.DriftReport{
+			Base:         base,
+			Head:         ciHead,
+			Root:         ".",
+			DocPath:      filepath.ToSlash(runner.DocPath),
+			ChangedFiles: paths,
+			Changes:      generator.DiffSections(before, after),
+			Model:        after,
+		}
+		fmt.Printf("📄 %d documentation section(s) changed in %s...%s\n", len(report.Changes), base, ciHead)
 
-		stage = report.BeginStage("index_health")
-		indexMode := "reuse"
-		indexRebuildError := ""
-		expectedIDs, healthBefore, err := assessIndexHealth(ctx, engine, store)
-		if err != nil {
-			report.EndStage(stage, "error", nil, nil, err)
-			report.AddSignal("index_health_assess_failed", "index_health", "warning", "Failed to assess vector index health.", 1)
-		} else {
-			if healthBefore.IndexedChunks == 0 {
-				report.AddSignal("index_empty_before_generate", "index_health", "warning", "Vector index is empty before generation.", 0)
+		markdown := report.Markdown()
+		out := []byte(markdown)
+		if ciFormat == "sarif" {
+			if out, err = report.SARIF(); err != nil {
+				log.Fatalf("Failed to render SARIF: %v", err)
+			}
+		}
+		if ciOutput != "" {
+			if err := os.WriteFile(ciOutput, out, 0644); err != nil {
+				log.Fatalf("Failed to write report: %v", err)
 			}
+			fmt.Printf("  -> Report written to %s\n", ciOutput)
+		} else {
+			fmt.Println(string(out))
+		}
 
-			if shouldRebuildIndex(healthBefore) {
-				indexMode = "rebuild_full"
-				fmt.Println("🧠 Rebuilding vector index for full generation...")
-				if err := engine.IndexAllWithOptions(ctx, knowledge.IndexingOptions{
-					// Full generation prioritizes retrieval quality over runtime cap.
-					MaxChunksPerRun: 0,
-				}); err != nil {
-					indexRebuildError = err.Error()
-					report.AddSignal("index_rebuild_failed", "index_health", "critical", fmt.Sprintf("Vector index rebuild failed: %v", err), 1)
-				}
+		if !ciComment {
+			return
+		}
+		number := ciPR
+		if number == 0 && os.Getenv("GITHUB_EVENT_PATH") != "" {
+			if number, err = publish.PullRequestFromEvent(os.Getenv("GITHUB_EVENT_PATH")); err != nil {
+				log.Fatalf("Failed to read the workflow event: %v", err)
 			}
+		}
+		commenter, err := publish.NewGitHubCommenter(publish.GitHubCommentOptions{
+			BaseURL: os.Getenv("GITHUB_API_URL"),
+			Token:   os.Getenv("GITHUB_TOKEN"),
+			Repo:    os.Getenv("GITHUB_REPOSITORY"),
+			Number:  number,
+		}, nil)
+		if err != nil {
+			log.Fatalf("Cannot comment on the pull request: %v", err)
+		}
+		url, err := commenter.Upsert(ctx, markdown)
+		if err != nil {
+			log.Fatalf("Failed to comment on the pull request: %v", err)
+		}
+		fmt.Printf("  -> Commented on %s\n", url)
+	},
+}
 
-			healthAfter, staleAfter, err := reassessIndexHealth(ctx, store, expectedIDs)
-			if err != nil {
-				report.EndStage(stage, "error", nil, []string{"mode=" + indexMode}, err)
-				report.AddSignal("index_health_reassess_failed", "index_health", "warning", "Failed to reassess index health after maintenance.", 1)
-			} else {
-				if len(staleAfter) > 0 {
-					if err := store.Delete(ctx, staleAfter); err != nil {
-						report.AddSignal("stale_chunk_cleanup_failed", "index_health", "warning", "Failed to clean stale chunks after health check.", float64(len(staleAfter)))
-					} else {
-						healthAfter.StaleChunks = 0
-						healthAfter.StaleRatio = 0
-					}
-				}
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve graph queries, search, Q&A and docs over HTTP from the local knowledge graph",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		store, err := initStore()
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer store.Close()
+		// Symbol bodies stay in the database and are read when a chunk or
+		// node response needs them.
+		g, err := store.LoadGraphMetadata(ctx)
+		if err != nil {
+			log.Fatalf("Failed to load graph: %v", err)
+		}
 
-				if healthAfter.IndexedChunks == 0 {
-					report.AddSignal("index_empty_after_health", "index_health", "critical", "Vector index remains empty after health maintenance.", 0)
-				}
-				if healthAfter.Coverage < 0.70 {
-					report.AddSignal("index_low_coverage", "index_health", "warning", "Indexed chunk coverage is below threshold.", healthAfter.Coverage)
+		fmt.Printf("🌐 Serving %d nodes and %d edges on http://%s\n", len(g.Nodes), len(g.Edges), serveAddr)
+		fmt.Println("  -> GET /graph/node/{id}, /graph/neighbors?id=, /graph/path?from=&to=")
+		fmt.Println("  -> GET /api/search?q=, /api/sections/{id}, /api/report; POST /api/ask, /api/sync")
+		fmt.Println("  -> aliases: /search, /docs/sections/{id}, /health")
+		live := watchConfig(ctx)
+		apiKeys := func() []string {
+			if live != nil {
+				return live.Current().Server.APIKeys
+			}
+			return nil
+		}
+		// Off loopback the API and graph routes need a key, also when a
+		// reload removes the last one.
+		requireKey := !server.IsLoopback(serveAddr)
+		if len(apiKeys()) == 0 {
+			if requireKey {
+				log.Fatalf("Refusing to serve /api and /graph on %s without a key: set server.api_keys or listen on a loopback address", serveAddr)
+			}
+			fmt.Println("  ⚠️  /api and /graph are unauthenticated; set server.api_keys to require a key")
+		}
+		paths := config.ResolveOutputPaths()
+		// Syncs and config reloads swap in a backend built from the reloaded
+		// graph, which moves the graph routes along with it.
+		graphs := server.NewGraphAPI(g)
+		api := server.NewRESTAPI(serveBackend(ctx, g, store), server.APIOptions{
+			ModelPath:  paths.Model(),
+			ReportPath: paths.Report(),
+			APIKeys:    apiKeys,
+			RequireKey: requireKey,
+			Graphs:     graphs,
+			Sync: func(ctx context.Context) (*server.Backend, error) {
+				if err := pipeline.NewIncrementalSync(dbPath).Run(ctx, false); err != nil {
+					return nil, err
 				}
-				if healthAfter.Freshness < 0.85 {
-					report.AddSignal("index_low_freshness", "index_health", "warning", "Index freshness is below threshold.", healthAfter.Freshness)
+				g, err := store.LoadGraphMetadata(ctx)
+				if err != nil {
+					return nil, err
 				}
-				if healthAfter.StaleChunks > 0 {
-					report.AddSignal("index_stale_chunks_remaining", "index_health", "warning", "Stale chunks remain after cleanup.", float64(healthAfter.StaleChunks))
+				return serveBackend(ctx, g, store), nil
+			},
+		})
+		if live != nil {
+			// Provider, model and budget edits take effect by swapping in a
+			// backend built from the new config; requests in flight finish on
+			// the old one.
+			live.OnChange(func(_, _ *config.Config, _ []config.Change) {
+				g, err := store.LoadGraphMetadata(ctx)
+				if err != nil {
+					log.Printf("Warning: keeping the previous backend: %v", err)
+					return
 				}
-
-				notes := []string{"mode=" + indexMode}
-				if strings.TrimSpace(indexRebuildError) != "" {
-					notes = append(notes, "index_rebuild_error="+strings.TrimSpace(indexRebuildError))
+				api.SetBackend(serveBackend(ctx, g, store))
+				fmt.Println("  -> Rebuilt the search and ask backend from the reloaded config")
+			})
+		}
+		mux := server.NewMux(graphs, api.Auth)
+		api.Register(mux)
+		if live != nil && live.Current().Server.Slack.SigningSecret != "" {
+			repo := live.Current().Server.Slack.Repo
+			if repo == "" {
+				if wd, err := os.Getwd(); err == nil {
+					repo = filepath.Base(wd)
 				}
-				report.EndStage(stage, "ok", map[string]float64{
-					"expected_chunks":       float64(healthBefore.ExpectedChunks),
-					"indexed_chunks_before": float64(healthBefore.IndexedChunks),
-					"indexed_chunks_after":  float64(healthAfter.IndexedChunks),
-					"missing_chunks_before": float64(healthBefore.MissingChunks),
-					"stale_chunks_before":   float64(healthBefore.StaleChunks),
-					"missing_chunks_after":  float64(healthAfter.MissingChunks),
-					"stale_chunks_after":    float64(healthAfter.StaleChunks),
-					"coverage_before":       healthBefore.Coverage,
-					"coverage_after":        healthAfter.Coverage,
-					"freshness_before":      healthBefore.Freshness,
-					"freshness_after":       healthAfter.Freshness,
-					"stale_ratio_before":    healthBefore.StaleRatio,
-					"stale_ratio_after":     healthAfter.StaleRatio,
-					"chunk_files_before":    float64(healthBefore.ChunkFiles),
-					"chunk_files_after":     float64(healthAfter.ChunkFiles),
-				}, notes, nil)
 			}
+			server.NewSlackBot(server.SlackOptions{
+				SigningSecret: func() string { return live.Current().Server.Slack.SigningSecret },
+				Repo:          repo,
+				Channels:      func() map[string]string { return live.Current().Server.Slack.Channels },
+				Backend:       api.Backend,
+			}).Register(mux)
+			fmt.Printf("  -> POST /slack/commands answers /docod ask for %s\n", repo)
 		}
-
-		// 3. Generate
-		fmt.Println("🚀 Generating documentation...")
-		gen := generator.NewMarkdownGenerator(engine, summarizer)
-		if err := gen.GenerateDocsWithReport(ctx, "docs", report); err != nil {
-			report.AddSignal("generate_docs_failed", "generate_docs", "critical", "Failed while generating docs.", 1)
-			_ = report.Save(reportPath)
-			log.Fatalf("Failed to generate docs: %v", err)
+		if err := http.ListenAndServe(serveAddr, mux); err != nil {
+			log.Fatalf("Server stopped: %v", err)
 		}
+	},
+}
 
-		fmt.Println("✅ Documentation generated in 'docs/'.")
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "Run a language server on stdio that shows generated docs and graph context on hover",
+	Long: `Run a Language Server Protocol server on stdin/stdout. Hover shows the
+symbol's doc comment, the generated sections that cite it, its package summary
+and its callers, callees and tests; go-to-definition jumps to the graph node.
+Point your editor's generic LSP client at "docod lsp" for Go files.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		store, err := initStore()
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer store.Close()
+		g, err := store.LoadGraphMetadata(ctx)
+		if err != nil {
+			log.Fatalf("Failed to load graph: %v", err)
+		}
+		root, err := os.Getwd()
+		if err != nil {
+			log.Fatalf("Failed to get current directory: %v", err)
+		}
+		opts := lsp.Options{Root: root}
+		if model, err := generator.LoadDocModel(config.ResolveOutputPaths().Model()); err == nil {
+			opts.Model = model
+		}
+		// stdout carries the protocol; diagnostics go to stderr.
+		log.Printf("docod lsp: serving %d nodes from %s", len(g.Nodes), root)
+		if err := lsp.NewServer(g, opts).Serve(ctx, os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("Language server stopped: %v", err)
+		}
 	},
 }
 
-type indexHealthMetrics struct {
-	ExpectedChunks int
-	IndexedChunks  int
-	MissingChunks  int
-	StaleChunks    int
-	Coverage       float64
-	Freshness      float64
-	StaleRatio     float64
-	ChunkFiles     int
+var rpcCmd = &cobra.Command{
+	Use:   "rpc",
+	Short: "Run the JSON-RPC backend for editor extensions on stdio",
+	Long: `Run the JSON-RPC 2.0 backend used by editor extensions on stdin/stdout.
+Methods: docod/search, docod/explain and docod/impact. Requests can be
+cancelled with $/cancelRequest, and a partial_result_token streams
+intermediate results as $/progress notifications.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		store, err := initStore()
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer store.Close()
+		g, err := store.LoadGraphMetadata(ctx)
+		if err != nil {
+			log.Fatalf("Failed to load graph: %v", err)
+		}
+		// stdout carries the protocol; diagnostics go to stderr.
+		log.Printf("docod rpc: serving %d nodes (protocol %s)", len(g.Nodes), editor.ProtocolVersion)
+		if err := editor.NewServer(g, serveBackend(ctx, g, store)).Serve(ctx, os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("RPC server stopped: %v", err)
+		}
+	},
 }
 
-func assessIndexHealth(ctx context.Context, engine *knowledge.Engine, store *storage.SQLiteStore) (map[string]bool, indexHealthMetrics, error) {
-	expectedSet := make(map[string]bool)
-	for _, c := range engine.PrepareSearchChunks() {
-		id := strings.TrimSpace(c.ID)
-		if id == "" {
-			continue
+var askCmd = &cobra.Command{
+	Use:   "ask <question>",
+	Short: "Answer a question about the codebase with cited sources",
+	Long: `Answer a natural-language question from the local index: the question is
+embedded, the closest chunks are retrieved and the LLM answers from them,
+citing each source as file:line.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		question := strings.TrimSpace(strings.Join(args, " "))
+		if question == "" {
+			log.Fatal("Question is empty")
+		}
+		store, err := initStore()
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer store.Close()
+		g, err := store.LoadGraphMetadata(ctx)
+		if err != nil {
+			log.Fatalf("Failed to load graph: %v", err)
+		}
+		engine, summarizer, err := initEngine(ctx, g, store)
+		if err != nil {
+			log.Fatalf("Setup failed: %v\nCheck your config.yaml and API keys.", err)
+		}
+		answerer, ok := summarizer.(knowledge.QuestionAnswerer)
+		if !ok {
+			log.Fatalf("The configured LLM provider cannot answer questions")
+		}
+		chunks, err := engine.SearchByText(ctx, question, max(askTopK, 1), "")
+		if err != nil {
+			log.Fatalf("Search failed: %v", err)
+		}
+		if len(chunks) == 0 {
+			log.Fatalf("No indexed code matches the question; run `docod sync` first")
+		}
+		answer, err := answerer.AnswerQuestion(ctx, question, chunks)
+		if err != nil {
+			log.Fatalf("Answer failed: %v", err)
+		}
+		sources := generator.MergeSources(nil, chunks)
+		if askJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(server.AskResponse{Question: question, Answer: answer, Sources: sources}); err != nil {
+				log.Fatalf("Failed to write answer: %v", err)
+			}
+			return
+		}
+		fmt.Println(strings.TrimSpace(answer))
+		if len(sources) > 0 {
+			fmt.Println("\nSources:")
+			for _, src := range sources {
+				fmt.Printf("  - %s\n", sourceLocation(src))
+			}
+		}
+	},
+}
+
+// sourceLocation renders a cited source as file:start-end, relative to the
+// project root when possible.
+func sourceLocation(src generator.SourceRef) string {
+	loc := src.FilePath
+	if filepath.IsAbs(loc) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, loc); err == nil && !strings.HasPrefix(rel, "..") {
+				loc = rel
+			}
+		}
+	}
+	if loc == "" {
+		loc = src.SymbolID
+	}
+	switch {
+	case src.StartLine > 0 && src.EndLine > src.StartLine:
+		return fmt.Sprintf("%s:%d-%d", loc, src.StartLine, src.EndLine)
+	case src.StartLine > 0:
+		return fmt.Sprintf("%s:%d", loc, src.StartLine)
+	}
+	return loc
+}
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Run a Model Context Protocol server on stdio for AI agents",
+	Long: `Run a Model Context Protocol server on stdin/stdout so agents can ground
+answers in the knowledge graph. Tools: search_code, get_symbol, list_sections
+and get_section_sources. Register it with an MCP client as the command
+"docod mcp", run from the project or with --db pointing at its database.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		store, err := initStore()
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer store.Close()
+		g, err := store.LoadGraphMetadata(ctx)
+		if err != nil {
+			log.Fatalf("Failed to load graph: %v", err)
+		}
+		// stdout carries the protocol; diagnostics go to stderr.
+		log.Printf("docod mcp: serving %d nodes (MCP %s)", len(g.Nodes), mcp.ProtocolVersion)
+		srv := mcp.NewServer(g, serveBackend(ctx, g, store), mcp.Options{ModelPath: config.ResolveOutputPaths().Model()})
+		if err := srv.Serve(ctx, os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("MCP server stopped: %v", err)
+		}
+	},
+}
+
+var publishCmd = &cobra.Command{
+	Use:   "publish",
+	Short: "Publish the generated documentation to an external docs system",
+}
+
+var publishConfluenceCmd = &cobra.Command{
+	Use:   "confluence",
+	Short: "Create or update one Confluence page per section (publish.confluence)",
+	Run: func(cmd *cobra.Command, args []string) {
+		runPublish("Confluence", func(cfg *config.Config, store *storage.SQLiteStore) (publish.Publisher, error) {
+			c := cfg.Publish.Confluence
+			return publish.NewConfluencePublisher(publish.ConfluenceOptions{
+				BaseURL:      c.BaseURL,
+				SpaceKey:     c.SpaceKey,
+				ParentPageID: c.ParentPageID,
+				User:         c.User,
+				APIToken:     c.APIToken,
+				TitlePrefix:  c.TitlePrefix,
+			}, store, nil)
+		})
+	},
+}
+
+var publishNotionCmd = &cobra.Command{
+	Use:   "notion",
+	Short: "Create or update one Notion page per section (publish.notion)",
+	Run: func(cmd *cobra.Command, args []string) {
+		runPublish("Notion", func(cfg *config.Config, store *storage.SQLiteStore) (publish.Publisher, error) {
+			n := cfg.Publish.Notion
+			return publish.NewNotionPublisher(publish.NotionOptions{
+				Token:           n.Token,
+				DatabaseID:      n.DatabaseID,
+				ParentPageID:    n.ParentPageID,
+				TitleProperty:   n.TitleProperty,
+				TitlePrefix:     n.TitlePrefix,
+				DiagramImageURL: n.DiagramImageURL,
+			}, store, nil)
+		})
+	},
+}
+
+// runPublish publishes the generated doc model with the publisher built by
+// newPublisher and prints what changed.
+func runPublish(target string, newPublisher func(cfg *config.Config, store *storage.SQLiteStore) (publish.Publisher, error)) {
+	ctx := context.Background()
+	cfg, err := config.LoadConfig(config.DefaultPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	modelPath := config.ResolveOutputPaths().Model()
+	model, err := generator.LoadDocModel(modelPath)
+	if err != nil {
+		log.Fatalf("Failed to load %s (run `docod generate` first): %v", modelPath, err)
+	}
+	store, err := initStore()
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer store.Close()
+	publisher, err := newPublisher(cfg, store)
+	if err != nil {
+		log.Fatalf("Failed to configure %s: %v", target, err)
+	}
+	fmt.Printf("📤 Publishing %d sections to %s...\n", len(model.Sections), target)
+	report, err := publisher.Publish(ctx, model)
+	if report != nil {
+		fmt.Printf("  -> %d created, %d updated, %d unchanged\n", len(report.Created), len(report.Updated), len(report.Unchanged))
+	}
+	if err != nil {
+		log.Fatalf("Publish failed: %v", err)
+	}
+}
+
+// serveBackend builds the search and ask backend for serve. Without provider
+// keys the API still serves docs, reports and syncs.
+func serveBackend(ctx context.Context, g *graph.Graph, store *storage.SQLiteStore) *server.Backend {
+	engine, summarizer, err := initEngine(ctx, g, store)
+	if err != nil {
+		log.Printf("Warning: /api/search and /api/ask disabled: %v", err)
+		return &server.Backend{Err: err, Graph: g}
+	}
+	return &server.Backend{Search: engine, Summarizer: summarizer, Graph: g}
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the generated documentation for static sites",
+	Long: `Convert the doc model into the source tree of a static site: one page
+with front matter per section under <output>/docs, nested by subsection, and
+mkdocs.yml (--format mkdocs) or sidebars.js (--format docusaurus) describing
+the navigation. The site also gets a search_index.json (see export
+search-index) linking to its pages, under docs/ for MkDocs and static/ for
+Docusaurus.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if siteFormat == "" {
+			_ = cmd.Help()
+			return
+		}
+		paths := config.ResolveOutputPaths()
+		model, err := generator.LoadDocModel(paths.Model())
+		if err != nil {
+			log.Fatalf("Failed to load %s (run `docod generate` first): %v", paths.Model(), err)
+		}
+		export, err := site.ExportSite(model, siteOutput, site.SiteExportOptions{Format: siteFormat, SiteName: siteName, Graph: searchIndexGraph()})
+		if err != nil {
+			log.Fatalf("Failed to export site: %v", err)
+		}
+		fmt.Printf("✅ Exported %d pages to %s (%s)\n", export.Files, filepath.Join(siteOutput, "docs"), export.Config)
+		fmt.Printf("  -> Search index: %s\n", export.SearchIndex)
+	},
+}
+
+// searchIndexGraph loads the graph whose exported symbols an export's search
+// index lists, or returns nil, indexing sections only, when it cannot.
+func searchIndexGraph() *graph.Graph {
+	store, err := initStore()
+	if err != nil {
+		log.Printf("Warning: the search index lists sections only: %v", err)
+		return nil
+	}
+	defer store.Close()
+	g, err := store.LoadGraphMetadata(context.Background())
+	if err != nil {
+		log.Printf("Warning: the search index lists sections only: %v", err)
+		return nil
+	}
+	return g
+}
+
+var exportSearchIndexCmd = &cobra.Command{
+	Use:   "search-index",
+	Short: "Write a prebuilt lunr.js search index over sections and exported symbols",
+	Long: `Write a client-side search index so published docs can search without a
+backend. The file holds a serialized lunr.js 2.x index under "index", to load
+with lunr.Index.load, and the title, URL and excerpt of every result under
+"documents", keyed by the refs the index returns.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		paths := config.ResolveOutputPaths()
+		model, err := generator.LoadDocModel(paths.Model())
+		if err != nil {
+			log.Fatalf("Failed to load %s (run `docod generate` first): %v", paths.Model(), err)
+		}
+		store, err := initStore()
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer store.Close()
+		g, err := store.LoadGraphMetadata(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to load graph: %v", err)
+		}
+		out := indexOutput
+		if out == "" {
+			out = filepath.Join(paths.Dir, site.SearchIndexFile)
+		}
+		idx := site.BuildSearchIndex(model, g, site.SearchIndexOptions{})
+		if err := site.WriteSearchIndex(out, idx); err != nil {
+			log.Fatalf("Failed to write search index: %v", err)
+		}
+		fmt.Printf("✅ Indexed %d documents in %s\n", len(idx.Documents), out)
+	},
+}
+
+var exportHTMLCmd = &cobra.Command{
+	Use:   "html",
+	Short: "Write the documentation as one standalone HTML page",
+	Long: `Render the doc model as a single HTML file with a table of contents,
+anchor links on every heading, links to the source files each section cites,
+and Mermaid diagrams drawn in the browser. A search_index.json (see export
+search-index) linking into the page is written next to it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		paths := config.ResolveOutputPaths()
+		model, err := generator.LoadDocModel(paths.Model())
+		if err != nil {
+			log.Fatalf("Failed to load %s (run `docod generate` first): %v", paths.Model(), err)
+		}
+		out := htmlOutput
+		if out == "" {
+			out = filepath.Join(paths.Dir, "documentation.html")
+		}
+		sourceURL := htmlSourceURL
+		if sourceURL == "" {
+			absOut, err := filepath.Abs(filepath.Dir(out))
+			if err != nil {
+				log.Fatalf("Failed to resolve %s: %v", out, err)
+			}
+			root, _ := os.Getwd()
+			if rel, err := filepath.Rel(absOut, root); err == nil {
+				sourceURL = filepath.ToSlash(rel)
+			}
+		}
+		page := publish.RenderHTML(model, publish.HTMLOptions{Root: ".", SourceBaseURL: sourceURL, MermaidScript: htmlMermaid})
+		if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+			log.Fatalf("Failed to create %s: %v", filepath.Dir(out), err)
+		}
+		if err := os.WriteFile(out, []byte(page), 0644); err != nil {
+			log.Fatalf("Failed to write %s: %v", out, err)
+		}
+		pageName := filepath.Base(out)
+		idx := site.BuildSearchIndex(model, searchIndexGraph(), site.SearchIndexOptions{
+			SectionURL: func(sec generator.ModelSect) string { return pageName + "#" + sec.ID },
+			SymbolURL: func(sym *graph.Symbol) string {
+				return publish.SourceURL(sourceURL, filepath.ToSlash(sym.Filepath), sym.StartLine, sym.EndLine)
+			},
+		})
+		indexPath := filepath.Join(filepath.Dir(out), site.SearchIndexFile)
+		if err := site.WriteSearchIndex(indexPath, idx); err != nil {
+			log.Fatalf("Failed to write search index: %v", err)
+		}
+		fmt.Printf("✅ Wrote %s and %s\n", out, indexPath)
+	},
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show knowledge graph statistics and per-package summaries",
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := initStore()
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer store.Close()
+		g, err := store.LoadGraphMetadata(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to load graph: %v", err)
+		}
+		fmt.Print(formatGraphStats(g))
+	},
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Report on past pipeline runs",
+}
+
+var reportCostCmd = &cobra.Command{
+	Use:   "cost",
+	Short: "Show model requests, tokens and estimated spend per sync and per section",
+	Long: `Show the model usage recorded by generate, sync and update runs: requests,
+tokens and the estimated cost of each run, then the breakdown of one run by
+documentation section and by model. Costs use ai.pricing over built-in list
+prices; models without a known price count as free.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := initStore()
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer store.Close()
+		ctx := context.Background()
+		runs, err := store.UsageRuns(ctx, 0)
+		if err != nil {
+			log.Fatalf("Failed to load usage: %v", err)
+		}
+		if len(runs) == 0 {
+			fmt.Println("No model usage recorded yet; it is recorded by generate, sync and update.")
+			return
+		}
+		selected := runs[0]
+		if costRunID != 0 {
+			found := false
+			for _, run := range runs {
+				if run.ID == costRunID {
+					selected, found = run, true
+					break
+				}
+			}
+			if !found {
+				log.Fatalf("No usage recorded for run #%d", costRunID)
+			}
+		}
+		if costRuns > 0 && len(runs) > costRuns {
+			runs = runs[:costRuns]
+		}
+		if costJSON {
+			printCostJSON(runs, selected)
+			return
+		}
+		fmt.Print(formatCostReport(runs, selected))
+	},
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the docod configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config for unknown keys, invalid values and missing provider keys",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, issues, err := config.ValidateFile(config.DefaultPath)
+		if err != nil {
+			log.Fatalf("Failed to load config: %v", err)
+		}
+		lines := make([]string, 0, len(issues))
+		seen := make(map[string]bool)
+		report := func(prefix string, issues []config.Issue) {
+			for _, issue := range issues {
+				key := string(issue.Severity) + issue.String()
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				mark := "⚠️ "
+				if issue.Severity == config.SeverityError {
+					mark = "❌"
+				}
+				lines = append(lines, fmt.Sprintf("%s %s%s", mark, prefix, issue))
+			}
+		}
+		report("", issues)
+		// Without --profile, check that every profile also yields a valid config.
+		if profileName == "" {
+			for _, name := range cfg.ProfileNames() {
+				config.SetProfile(name)
+				_, profileIssues, err := config.ValidateFile(config.DefaultPath)
+				if err != nil {
+					lines = append(lines, fmt.Sprintf("❌ [profile %s] %v", name, err))
+					continue
+				}
+				report("[profile "+name+"] ", profileIssues)
+			}
+			config.SetProfile("")
+		}
+
+		errCount := 0
+		for _, line := range lines {
+			if strings.HasPrefix(line, "❌") {
+				errCount++
+			}
+			fmt.Println(line)
+		}
+		if len(lines) == 0 {
+			fmt.Println("✅ Config is valid")
+			return
+		}
+		fmt.Printf("%d error(s), %d warning(s)\n", errCount, len(lines)-errCount)
+		if errCount > 0 || configStrict {
+			os.Exit(1)
+		}
+	},
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of config.yaml (published as docs/config.schema.json)",
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := config.SchemaJSON()
+		if err != nil {
+			log.Fatalf("Failed to render config schema: %v", err)
+		}
+		if schemaOutput == "" {
+			os.Stdout.Write(data)
+			return
+		}
+		if err := os.WriteFile(schemaOutput, data, 0644); err != nil {
+			log.Fatalf("Failed to write %s: %v", schemaOutput, err)
+		}
+		fmt.Fprintf(os.Stderr, "✅ Config schema written to %s\n", schemaOutput)
+	},
+}
+
+var serveOpenAPICmd = &cobra.Command{
+	Use:   "openapi",
+	Short: "Print the OpenAPI document of the serve API (published as docs/openapi.json)",
+	Run: func(cmd *cobra.Command, args []string) {
+		data, err := server.OpenAPIJSON()
+		if err != nil {
+			log.Fatalf("Failed to render OpenAPI document: %v", err)
+		}
+		if specOutput == "" {
+			os.Stdout.Write(data)
+			return
+		}
+		if err := os.WriteFile(specOutput, data, 0644); err != nil {
+			log.Fatalf("Failed to write %s: %v", specOutput, err)
+		}
+		fmt.Fprintf(os.Stderr, "✅ OpenAPI document written to %s\n", specOutput)
+	},
+}
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Inspect the knowledge graph",
+}
+
+var graphDiffCmd = &cobra.Command{
+	Use:   "diff <sha1> <sha2>",
+	Short: "Show added, removed and changed symbols and edges between two commits",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		format := strings.ToLower(strings.TrimSpace(graphDiffFormat))
+		if format != "json" && format != "text" {
+			log.Fatalf("Unsupported format %q (use json or text)", graphDiffFormat)
+		}
+
+		graphs := make([]*graph.Graph, 0, 2)
+		for _, ref := range args {
+			fmt.Fprintf(os.Stderr, "🚀 Building graph at %s...\n", ref)
+			g, err := pipeline.BuildGraphAtCommit(ref)
+			if err != nil {
+				log.Fatalf("Failed to build graph at %s: %v", ref, err)
+			}
+			graphs = append(graphs, g)
+		}
+		diff := graph.Diff(graphs[0], graphs[1])
+
+		var out []byte
+		if format == "json" {
+			data, err := json.MarshalIndent(diff, "", "  ")
+			if err != nil {
+				log.Fatalf("Failed to encode diff: %v", err)
+			}
+			out = append(data, '\n')
+		} else {
+			out = []byte(formatGraphDiff(args[0], args[1], diff))
+		}
+
+		if graphDiffOutput == "" {
+			_, _ = os.Stdout.Write(out)
+			return
+		}
+		if err := os.WriteFile(graphDiffOutput, out, 0644); err != nil {
+			log.Fatalf("Failed to write diff: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "✅ Graph diff written to %s\n", graphDiffOutput)
+	},
+}
+
+var graphExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the local knowledge graph for external graph tools",
+	Long: `Dump every node and edge of the local knowledge graph, with edge kinds,
+resolvers and confidence:
+
+  cypher   idempotent Cypher script for Neo4j
+  dot      Graphviz digraph
+  graphml  GraphML for Gephi or yEd
+  json     {"nodes": [...], "edges": [...]} for scripts`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !slices.Contains(graph.ExportFormats, strings.ToLower(strings.TrimSpace(exportFormat))) {
+			log.Fatalf("Unsupported export format %q (use %s)", exportFormat, strings.Join(graph.ExportFormats, ", "))
+		}
+		store, err := initStore()
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		g, err := store.LoadGraph(context.Background())
+		store.Close()
+		if err != nil {
+			log.Fatalf("Failed to load graph: %v", err)
+		}
+
+		w := os.Stdout
+		if exportOutput != "" {
+			f, err := os.Create(exportOutput)
+			if err != nil {
+				log.Fatalf("Failed to create %s: %v", exportOutput, err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := graph.WriteExport(w, g, exportFormat); err != nil {
+			log.Fatalf("Export failed: %v", err)
+		}
+		if exportOutput != "" {
+			fmt.Fprintf(os.Stderr, "✅ Exported %d nodes and %d edges to %s\n", len(g.Nodes), len(g.Edges), exportOutput)
+		}
+	},
+}
+
+var graphSequenceCmd = &cobra.Command{
+	Use:   "sequence <entrypoint>",
+	Short: "Print a Mermaid sequence diagram of the calls made from an entrypoint",
+	Long: `Walk the resolved calls edges from an entrypoint and print the call chain
+as a Mermaid sequenceDiagram with one participant per package. The
+entrypoint is a symbol ID or a name such as main, Run, pkg.Func or
+Type.Method.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		store, err := initStore()
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		defer store.Close()
+		g, err := store.LoadGraphMetadata(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to load graph: %v", err)
+		}
+		entry, err := generator.FindEntrypoint(g, args[0])
+		if err != nil {
+			log.Fatalf("Failed to find entrypoint: %v", err)
+		}
+		mermaid := &generator.MermaidGenerator{}
+		diagram := mermaid.GenerateSequenceDiagram(g, entry, generator.SequenceOptions{
+			MaxDepth:    seqDepth,
+			MaxMessages: seqMessages,
+			MayCall:     seqMayCall,
+		})
+		if diagram == "" {
+			log.Fatalf("%s calls no other symbol in the graph", entry)
+		}
+		fmt.Print(diagram)
+	},
+}
+
+var graphQueryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Answer traversal questions about the graph",
+	Long: `Walk the knowledge graph from a symbol. Symbols are given as an ID or a
+name such as Open, store.Open or DB.Close; an ambiguous name lists the
+matching IDs.`,
+}
+
+var queryWhoCallsCmd = &cobra.Command{
+	Use:   "who-calls <symbol>",
+	Short: "List the callers of a symbol",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		g := loadQueryGraph()
+		sym := findQuerySymbol(g, args[0])
+		printQueryResult(g.WhoCalls(sym.ID, queryDepth))
+	},
+}
+
+var queryDependenciesCmd = &cobra.Command{
+	Use:   "dependencies-of <symbol>",
+	Short: "List what a symbol calls, uses or embeds",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		g := loadQueryGraph()
+		sym := findQuerySymbol(g, args[0])
+		printQueryResult(g.DependenciesOf(sym.ID, queryDepth))
+	},
+}
+
+var queryPathCmd = &cobra.Command{
+	Use:   "path-between <from> <to>",
+	Short: "Print a shortest dependency path from one symbol to another",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		g := loadQueryGraph()
+		from := findQuerySymbol(g, args[0])
+		to := findQuerySymbol(g, args[1])
+		path := g.PathBetween(from.ID, to.ID)
+		if path == nil {
+			log.Fatalf("No path from %s to %s", from.ID, to.ID)
+		}
+		if queryFormat == "json" {
+			printQueryResult(path)
+			return
+		}
+		if err := graph.WritePath(os.Stdout, path); err != nil {
+			log.Fatalf("Failed to write path: %v", err)
+		}
+	},
+}
+
+func loadQueryGraph() *graph.Graph {
+	if queryFormat != "tree" && queryFormat != "json" {
+		log.Fatalf("Unknown format %q: use tree or json", queryFormat)
+	}
+	store, err := initStore()
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer store.Close()
+	g, err := store.LoadGraphMetadata(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to load graph: %v", err)
+	}
+	return g
+}
+
+func findQuerySymbol(g *graph.Graph, query string) *graph.Symbol {
+	sym, err := g.FindSymbol(query)
+	if err != nil {
+		log.Fatalf("Failed to find symbol: %v", err)
+	}
+	return sym
+}
+
+// printQueryResult prints a query tree in the chosen format, or any result
+// as JSON.
+func printQueryResult(result any) {
+	if tree, ok := result.(*graph.QueryNode); ok && queryFormat == "tree" {
+		if err := tree.WriteTree(os.Stdout); err != nil {
+			log.Fatalf("Failed to write tree: %v", err)
+		}
+		return
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode result: %v", err)
+	}
+	fmt.Println(string(data))
+}
+
+func formatGraphDiff(from, to string, d *graph.GraphDiff) string {
+	var sb strings.Builder
+	s := d.Summary
+	sb.WriteString(fmt.Sprintf("Graph diff %s..%s\n", from, to))
+	sb.WriteString(fmt.Sprintf("  nodes: +%d -%d ~%d, edges: +%d -%d\n", s.NodesAdded, s.NodesRemoved, s.NodesChanged, s.EdgesAdded, s.EdgesRemoved))
+	sb.WriteString(fmt.Sprintf("  api: +%d -%d ~%d, types removed: %d, dependencies: +%d -%d\n", s.APIAdded, s.APIRemoved, s.APIChanged, s.TypesRemoved, s.DependenciesAdded, s.DependenciesRemoved))
+	for _, n := range d.AddedNodes {
+		sb.WriteString(fmt.Sprintf("+ %s %s.%s (%s)\n", n.UnitType, n.Package, n.Name, n.Filepath))
+	}
+	for _, n := range d.RemovedNodes {
+		sb.WriteString(fmt.Sprintf("- %s %s.%s (%s)\n", n.UnitType, n.Package, n.Name, n.Filepath))
+	}
+	for _, n := range d.ChangedNodes {
+		sb.WriteString(fmt.Sprintf("~ %s %s.%s [%s] (%s)\n", n.UnitType, n.Package, n.Name, n.Change, n.Filepath))
+	}
+	for _, e := range d.AddedEdges {
+		if e.CrossPackage {
+			sb.WriteString(fmt.Sprintf("+ %s -%s-> %s\n", e.FromName, e.Kind, e.ToName))
+		}
+	}
+	for _, e := range d.RemovedEdges {
+		if e.CrossPackage {
+			sb.WriteString(fmt.Sprintf("- %s -%s-> %s\n", e.FromName, e.Kind, e.ToName))
+		}
+	}
+	return sb.String()
+}
+
+func formatGraphStats(g *graph.Graph) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📊 Graph: %d nodes, %d edges, %d unresolved relations\n", len(g.Nodes), len(g.Edges), len(g.Unresolved)))
+
+	kinds := make(map[string]int)
+	for _, e := range g.Edges {
+		kinds[string(e.Kind)]++
+	}
+	units := make(map[string]int)
+	for _, n := range g.Nodes {
+		if n != nil && n.Unit != nil {
+			units[n.Unit.UnitType]++
+		}
+	}
+	sb.WriteString("  node types: " + formatCounts(units) + "\n")
+	sb.WriteString("  edge kinds: " + formatCounts(kinds) + "\n")
+	for _, line := range pipeline.FormatEdgeConfidence(g) {
+		sb.WriteString("  " + line + "\n")
+	}
+
+	summaries := g.PackageSummaries()
+	sb.WriteString(fmt.Sprintf("📦 Packages: %d\n", len(summaries)))
+	for _, ps := range summaries {
+		sb.WriteString(fmt.Sprintf("- %s (%s): %d files, %d symbols, %d exported, %d tests, deps %d, used by %d\n",
+			ps.Package, ps.Dir, ps.Files, ps.Symbols, len(ps.Exported), ps.Tests, len(ps.DependsOn), len(ps.UsedBy)))
+		if len(ps.Responsibilities) > 0 {
+			sb.WriteString("    " + ps.Responsibilities[0] + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// costRun is a usage run in docod report cost --json.
+type costRun struct {
+	ID        int64                      `json:"id"`
+	Mode      string                     `json:"mode"`
+	StartedAt time.Time                  `json:"started_at"`
+	Usage     knowledge.Usage            `json:"usage"`
+	Sections  map[string]knowledge.Usage `json:"sections,omitempty"`
+	Records   []knowledge.UsageRecord    `json:"records,omitempty"`
+}
+
+func printCostJSON(runs []knowledge.UsageRun, selected knowledge.UsageRun) {
+	out := struct {
+		Runs     []costRun `json:"runs"`
+		Selected costRun   `json:"selected"`
+	}{
+		Selected: costRun{
+			ID:        selected.ID,
+			Mode:      selected.Mode,
+			StartedAt: selected.StartedAt,
+			Usage:     selected.Total(),
+			Sections:  selected.Sections(),
+			Records:   selected.Records,
+		},
+	}
+	for _, run := range runs {
+		out.Runs = append(out.Runs, costRun{ID: run.ID, Mode: run.Mode, StartedAt: run.StartedAt, Usage: run.Total()})
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode usage: %v", err)
+	}
+	fmt.Println(string(data))
+}
+
+func formatCostReport(runs []knowledge.UsageRun, selected knowledge.UsageRun) string {
+	var sb strings.Builder
+	var total knowledge.Usage
+	for _, run := range runs {
+		total.Add(run.Total())
+	}
+	sb.WriteString(fmt.Sprintf("💰 Model usage of the last %d run(s): %s\n", len(runs), formatUsage(total)))
+	for _, run := range runs {
+		sb.WriteString(fmt.Sprintf("- #%d %s %s: %s\n", run.ID, run.StartedAt.Local().Format("2006-01-02 15:04"), run.Mode, formatUsage(run.Total())))
+	}
+
+	sb.WriteString(fmt.Sprintf("\nRun #%d (%s) by section:\n", selected.ID, selected.Mode))
+	sections := selected.Sections()
+	ids := make([]string, 0, len(sections))
+	for id := range sections {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if sections[ids[i]].Cost != sections[ids[j]].Cost {
+			return sections[ids[i]].Cost > sections[ids[j]].Cost
+		}
+		return ids[i] < ids[j]
+	})
+	for _, id := range ids {
+		name := id
+		if name == "" {
+			name = "(outside sections)"
+		}
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", name, formatUsage(sections[id])))
+	}
+
+	sb.WriteString(fmt.Sprintf("\nRun #%d by model:\n", selected.ID))
+	models := make(map[string]knowledge.Usage)
+	var keys []string
+	for _, rec := range selected.Records {
+		key := fmt.Sprintf("%s %s/%s", rec.Kind, rec.Provider, rec.Model)
+		if _, ok := models[key]; !ok {
+			keys = append(keys, key)
+		}
+		u := models[key]
+		u.Add(rec.Usage)
+		models[key] = u
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", key, formatUsage(models[key])))
+	}
+	return sb.String()
+}
+
+func formatUsage(u knowledge.Usage) string {
+	return fmt.Sprintf("%d requests, %d input / %d output tokens, ~$%.4f", u.Requests, u.InputTokens, u.OutputTokens, u.Cost)
+}
+
+func formatCounts(counts map[string]int) string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%d", k, counts[k]))
+	}
+	return strings.Join(parts, " ")
+}
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate documentation from the knowledge graph",
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		paths := config.ResolveOutputPaths()
+		modelPath := paths.Model()
+		if dryRun {
+			// Docs and the pipeline report go to a scratch directory; the
+			// generated model is compared with the current one at the end.
+			tmp, err := os.MkdirTemp("", "docod-dry-run-")
+			if err != nil {
+				log.Fatalf("Failed to create dry-run directory: %v", err)
+			}
+			defer os.RemoveAll(tmp)
+			paths = paths.WithDir(tmp)
+		}
+		report := generator.NewPipelineReport("full_generate", paths.Dir)
+		reportPath := paths.Report()
+
+		// 1. Initialize Store
+		stage := report.BeginStage("init_store")
+		store, err := initStore()
+		if err != nil {
+			report.EndStage(stage, "error", nil, nil, err)
+			_ = report.Save(reportPath)
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		report.EndStage(stage, "ok", nil, nil, nil)
+		defer store.Close()
+
+		cfg, _ := config.LoadConfig("config.yaml")
+		meter := pipeline.NewUsageMeter(cfg)
+		ctx = knowledge.WithUsageMeter(ctx, meter)
+		report.SetUsageMeter(meter)
+		recordUsage := func(mode string) {
+			if dryRun {
+				return
+			}
+			if err := pipeline.RecordUsageRun(ctx, store, meter, mode); err != nil {
+				log.Printf("Warning: failed to record model usage: %v", err)
+			}
+		}
+
+		fmt.Println("🔄 Loading knowledge graph...")
+		stage = report.BeginStage("load_graph")
+		g, err := store.LoadGraph(ctx)
+		if err != nil {
+			report.EndStage(stage, "error", nil, nil, err)
+			_ = report.Save(reportPath)
+			log.Fatalf("Failed to load graph: %v", err)
+		}
+		report.EndStage(stage, "ok", map[string]float64{
+			"nodes_total": float64(len(g.Nodes)),
+			"edges_total": float64(len(g.Edges)),
+		}, nil, nil)
+		if cfg, err := config.LoadConfig("config.yaml"); err == nil {
+			chain, err := pipeline.NewResolverChain(cfg, resolver.ChainOptions{Dir: ".", Precise: preciseCalls})
+			if err != nil {
+				report.AddSignal("resolver_chain_invalid", "load_graph", "warning", err.Error(), 1)
+			} else {
+				for _, name := range chain.Names() {
+					report.AddResolver(name, chain.MinConfidence(name))
+				}
+			}
+		}
+
+		if cfg, err := config.LoadConfig("config.yaml"); err == nil && (sharded || cfg.Sharding.Enabled) {
+			if dryRun {
+				log.Fatalf("--dry-run does not support sharded generation")
+			}
+			stage = report.BeginStage("sharded_generate")
+			manifest, err := pipeline.NewShardedGenerate(store, cfg).Run(ctx, g)
+			if err != nil {
+				report.EndStage(stage, "error", nil, nil, err)
+				_ = report.Save(reportPath)
+				recordUsage("sharded_generate")
+				log.Fatalf("Sharded generation failed: %v", err)
+			}
+			failed := manifest.Failed()
+			report.EndStage(stage, "ok", map[string]float64{
+				"shards_total":  float64(len(manifest.Shards)),
+				"shards_failed": float64(failed),
+			}, nil, nil)
+			if failed > 0 {
+				report.AddSignal("shard_generate_failed", "sharded_generate", "warning", "Some shards could not be documented.", float64(failed))
+			}
+			_ = report.Save(reportPath)
+			recordUsage("sharded_generate")
+			if failed == len(manifest.Shards) {
+				log.Fatalf("Every shard failed; see %s", filepath.Join(paths.Dir, pipeline.ShardManifestFile))
+			}
+			if err := pipeline.RecordDocumentedCommit(ctx, store, "HEAD"); err != nil {
+				log.Printf("Warning: failed to record documented commit: %v", err)
+			}
+			fmt.Printf("✅ Documented %d of %d shards; overview in '%s'.\n", len(manifest.Shards)-failed, len(manifest.Shards), paths.Doc())
+			return
+		}
+
+		// 2. Initialize Engine & Summarizer
+		stage = report.BeginStage("init_engine")
+		engine, summarizer, err := initEngine(ctx, g, store)
+		if err != nil {
+			report.EndStage(stage, "error", nil, nil, err)
+			report.AddSignal("engine_init_failed", "init_engine", "critical", "Failed to initialize embedder/summarizer.", 1)
+			_ = report.Save(reportPath)
+			log.Fatalf("Setup failed: %v\nCheck your config.yaml and API keys.", err)
+		}
+		report.EndStage(stage, "ok", nil, nil, nil)
+
+		stage = report.BeginStage("index_health")
+		indexMode := "reuse"
+		indexRebuildError := ""
+		expectedIDs, healthBefore, err := assessIndexHealth(ctx, engine, store)
+		if err != nil {
+			report.EndStage(stage, "error", nil, nil, err)
+			report.AddSignal("index_health_assess_failed", "index_health", "warning", "Failed to assess vector index health.", 1)
+		} else {
+			if healthBefore.IndexedChunks == 0 {
+				report.AddSignal("index_empty_before_generate", "index_health", "warning", "Vector index is empty before generation.", 0)
+			}
+
+			if shouldRebuildIndex(healthBefore) {
+				indexMode = "rebuild_full"
+				fmt.Println("🧠 Rebuilding vector index for full generation...")
+				if err := engine.IndexAllWithOptions(ctx, knowledge.IndexingOptions{
+					// Full generation prioritizes retrieval quality over runtime cap.
+					MaxChunksPerRun: 0,
+				}); err != nil {
+					indexRebuildError = err.Error()
+					report.AddSignal("index_rebuild_failed", "index_health", "critical", fmt.Sprintf("Vector index rebuild failed: %v", err), 1)
+				}
+			}
+
+			healthAfter, staleAfter, err := reassessIndexHealth(ctx, store, expectedIDs)
+			if err != nil {
+				report.EndStage(stage, "error", nil, []string{"mode=" + indexMode}, err)
+				report.AddSignal("index_health_reassess_failed", "index_health", "warning", "Failed to reassess index health after maintenance.", 1)
+			} else {
+				if len(staleAfter) > 0 && !dryRun {
+					if err := store.Delete(ctx, staleAfter); err != nil {
+						report.AddSignal("stale_chunk_cleanup_failed", "index_health", "warning", "Failed to clean stale chunks after health check.", float64(len(staleAfter)))
+					} else {
+						healthAfter.StaleChunks = 0
+						healthAfter.StaleRatio = 0
+					}
+				}
+
+				if healthAfter.IndexedChunks == 0 {
+					report.AddSignal("index_empty_after_health", "index_health", "critical", "Vector index remains empty after health maintenance.", 0)
+				}
+				if healthAfter.Coverage < 0.70 {
+					report.AddSignal("index_low_coverage", "index_health", "warning", "Indexed chunk coverage is below threshold.", healthAfter.Coverage)
+				}
+				if healthAfter.Freshness < 0.85 {
+					report.AddSignal("index_low_freshness", "index_health", "warning", "Index freshness is below threshold.", healthAfter.Freshness)
+				}
+				if healthAfter.StaleChunks > 0 {
+					report.AddSignal("index_stale_chunks_remaining", "index_health", "warning", "Stale chunks remain after cleanup.", float64(healthAfter.StaleChunks))
+				}
+
+				notes := []string{"mode=" + indexMode}
+				if strings.TrimSpace(indexRebuildError) != "" {
+					notes = append(notes, "index_rebuild_error="+strings.TrimSpace(indexRebuildError))
+				}
+				report.EndStage(stage, "ok", map[string]float64{
+					"expected_chunks":       float64(healthBefore.ExpectedChunks),
+					"indexed_chunks_before": float64(healthBefore.IndexedChunks),
+					"indexed_chunks_after":  float64(healthAfter.IndexedChunks),
+					"missing_chunks_before": float64(healthBefore.MissingChunks),
+					"stale_chunks_before":   float64(healthBefore.StaleChunks),
+					"missing_chunks_after":  float64(healthAfter.MissingChunks),
+					"stale_chunks_after":    float64(healthAfter.StaleChunks),
+					"coverage_before":       healthBefore.Coverage,
+					"coverage_after":        healthAfter.Coverage,
+					"freshness_before":      healthBefore.Freshness,
+					"freshness_after":       healthAfter.Freshness,
+					"stale_ratio_before":    healthBefore.StaleRatio,
+					"stale_ratio_after":     healthAfter.StaleRatio,
+					"chunk_files_before":    float64(healthBefore.ChunkFiles),
+					"chunk_files_after":     float64(healthAfter.ChunkFiles),
+					"embedded_chunks":       float64(engine.IndexStats().Embedded),
+					"dedupe_reused_chunks":  float64(engine.IndexStats().Deduplicated),
+					"embedding_cache_hits":  float64(engine.IndexStats().Cached),
+				}, notes, nil)
+				if deduped := engine.IndexStats().Deduplicated; deduped > 0 {
+					report.AddSignal("embedding_dedupe", "index_health", "info", "Embeddings reused for chunks with identical content.", float64(deduped))
+				}
+			}
+		}
+
+		// 3. Generate
+		fmt.Println("🚀 Generating documentation...")
+		gen := generator.NewMarkdownGenerator(engine, summarizer)
+		gen.SetRestart(restartGenerate)
+		if err := gen.GenerateDocsWithReport(ctx, paths.Dir, report); err != nil {
+			report.AddSignal("generate_docs_failed", "generate_docs", "critical", "Failed while generating docs.", 1)
+			_ = report.Save(reportPath)
+			recordUsage("full_generate")
+			log.Fatalf("Failed to generate docs: %v", err)
+		}
+		recordUsage("full_generate")
+		if dryRun {
+			before, _ := generator.LoadDocModel(modelPath)
+			after, err := generator.LoadDocModel(paths.Model())
+			if err != nil {
+				log.Fatalf("Failed to read dry-run doc model: %v", err)
+			}
+			pipeline.PrintDryRun(pipeline.CompareDocModels(before, after, meter), meter)
+			return
+		}
+
+		if err := pipeline.RecordDocumentedCommit(ctx, store, "HEAD"); err != nil {
+			log.Printf("Warning: failed to record documented commit: %v", err)
+		}
+
+		fmt.Printf("✅ Documentation generated in '%s/'.\n", paths.Dir)
+	},
+}
+
+type indexHealthMetrics struct {
+	ExpectedChunks int
+	IndexedChunks  int
+	MissingChunks  int
+	StaleChunks    int
+	Coverage       float64
+	Freshness      float64
+	StaleRatio     float64
+	ChunkFiles     int
+}
+
+func assessIndexHealth(ctx context.Context, engine *knowledge.Engine, store *storage.SQLiteStore) (map[string]bool, indexHealthMetrics, error) {
+	expectedSet := make(map[string]bool)
+	for _, c := range engine.PrepareSearchChunks() {
+		id := strings.TrimSpace(c.ID)
+		if id == "" {
+			continue
+		}
+		expectedSet[id] = true
+		if engine.HasCoarseLayer() && knowledge.IsCoarseSource(c) {
+			expectedSet[knowledge.CoarseChunkID(id)] = true
 		}
-		expectedSet[id] = true
 	}
 	metrics, _, err := reassessIndexHealth(ctx, store, expectedSet)
 	return expectedSet, metrics, err
@@ -0,0 +1,178 @@
+package analysis
+
+import (
+	"path/filepath"
+	"sort"
+
+	"docod/internal/graph"
+)
+
+// Cycle is a strongly connected group of packages or symbols.
+type Cycle struct {
+	Level   string   `json:"level"` // "package" or "symbol"
+	Members []string `json:"members"`
+	IDs     []string `json:"ids,omitempty"`
+}
+
+// CycleReport lists dependency cycles found in the graph.
+type CycleReport struct {
+	PackageCycles []Cycle `json:"package_cycles,omitempty"`
+	SymbolCycles  []Cycle `json:"symbol_cycles,omitempty"`
+}
+
+// Total returns the number of detected cycles across both levels.
+func (r *CycleReport) Total() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.PackageCycles) + len(r.SymbolCycles)
+}
+
+// cycleKinds are the dependency edges considered for cycle detection.
+// belongs_to and implements are structural and would produce trivial loops.
+var cycleKinds = map[graph.RelationKind]bool{
+	graph.RelationCalls:        true,
+	graph.RelationUsesType:     true,
+	graph.RelationInstantiates: true,
+	graph.RelationEmbeds:       true,
+}
+
+// DetectCycles finds package-level and symbol-level dependency cycles.
+// Direct self-recursion is not reported.
+func DetectCycles(g *graph.Graph) *CycleReport {
+	report := &CycleReport{}
+	if g == nil {
+		return report
+	}
+
+	symAdj := make(map[string][]string)
+	pkgAdj := make(map[string][]string)
+	pkgOf := make(map[string]string, len(g.Nodes))
+	for id, n := range g.Nodes {
+		if n == nil || n.Unit == nil {
+			continue
+		}
+		pkgOf[id] = packageKey(n.Unit)
+		symAdj[id] = nil
+		pkgAdj[pkgOf[id]] = pkgAdj[pkgOf[id]]
+	}
+	symSeen := make(map[string]bool)
+	pkgSeen := make(map[string]bool)
+	for _, e := range g.Edges {
+		if !cycleKinds[e.Kind] || e.From == e.To {
+			continue
+		}
+		fromPkg, okFrom := pkgOf[e.From]
+		toPkg, okTo := pkgOf[e.To]
+		if !okFrom || !okTo {
+			continue
+		}
+		if k := e.From + "|" + e.To; !symSeen[k] {
+			symSeen[k] = true
+			symAdj[e.From] = append(symAdj[e.From], e.To)
+		}
+		if fromPkg != toPkg {
+			if k := fromPkg + "|" + toPkg; !pkgSeen[k] {
+				pkgSeen[k] = true
+				pkgAdj[fromPkg] = append(pkgAdj[fromPkg], toPkg)
+			}
+		}
+	}
+
+	for _, scc := range stronglyConnected(pkgAdj) {
+		report.PackageCycles = append(report.PackageCycles, Cycle{Level: "package", Members: scc})
+	}
+	for _, scc := range stronglyConnected(symAdj) {
+		members := make([]string, 0, len(scc))
+		for _, id := range scc {
+			u := g.Nodes[id].Unit
+			members = append(members, u.Package+"."+u.Name)
+		}
+		report.SymbolCycles = append(report.SymbolCycles, Cycle{Level: "symbol", Members: members, IDs: scc})
+	}
+	return report
+}
+
+func packageKey(u *graph.Symbol) string {
+	dir := filepath.ToSlash(filepath.Dir(u.Filepath))
+	if u.Package == "" {
+		return dir
+	}
+	return dir + " (" + u.Package + ")"
+}
+
+// stronglyConnected returns the non-trivial SCCs (size > 1) of adj using
+// Tarjan's algorithm, with deterministic ordering.
+func stronglyConnected(adj map[string][]string) [][]string {
+	nodes := make([]string, 0, len(adj))
+	for n := range adj {
+		nodes = append(nodes, n)
+	}
+	sort.Strings(nodes)
+	for _, n := range nodes {
+		sort.Strings(adj[n])
+	}
+
+	index := 0
+	indices := make(map[string]int, len(nodes))
+	lowlink := make(map[string]int, len(nodes))
+	onStack := make(map[string]bool)
+	var stack []string
+	var out [][]string
+
+	var visit func(v string)
+	visit = func(v string) {
+		indices[v] = index
+		lowlink[v] = index
+		index++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range adj[v] {
+			if _, seen := indices[w]; !seen {
+				visit(w)
+				lowlink[v] = minInt(lowlink[v], lowlink[w])
+			} else if onStack[w] {
+				lowlink[v] = minInt(lowlink[v], indices[w])
+			}
+		}
+
+		if lowlink[v] != indices[v] {
+			return
+		}
+		var scc []string
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			scc = append(scc, w)
+			if w == v {
+				break
+			}
+		}
+		if len(scc) > 1 {
+			sort.Strings(scc)
+			out = append(out, scc)
+		}
+	}
+
+	for _, n := range nodes {
+		if _, seen := indices[n]; !seen {
+			visit(n)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i]) != len(out[j]) {
+			return len(out[i]) > len(out[j])
+		}
+		return out[i][0] < out[j][0]
+	})
+	return out
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
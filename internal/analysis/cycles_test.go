@@ -0,0 +1,38 @@
+package analysis
+
+import (
+	"testing"
+
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectCycles(t *testing.T) {
+	g := graph.NewGraph()
+	add := func(id, pkg, file string) {
+		g.AddSymbol(&graph.Symbol{ID: id, Name: id, Package: pkg, Filepath: file, UnitType: "function"})
+	}
+	add("A", "alpha", "alpha/a.go")
+	add("B", "alpha", "alpha/b.go")
+	add("C", "beta", "beta/c.go")
+	add("D", "beta", "beta/d.go")
+	g.Edges = []graph.Edge{
+		{From: "A", To: "B", Kind: graph.RelationCalls},
+		{From: "B", To: "A", Kind: graph.RelationCalls},
+		{From: "A", To: "A", Kind: graph.RelationCalls}, // self-recursion is ignored
+		{From: "B", To: "C", Kind: graph.RelationUsesType},
+		{From: "D", To: "A", Kind: graph.RelationCalls},
+		{From: "C", To: "D", Kind: graph.RelationImplements}, // structural, ignored
+	}
+
+	report := DetectCycles(g)
+	require.Len(t, report.SymbolCycles, 1)
+	assert.Equal(t, []string{"A", "B"}, report.SymbolCycles[0].IDs)
+	assert.Equal(t, []string{"alpha.A", "alpha.B"}, report.SymbolCycles[0].Members)
+
+	require.Len(t, report.PackageCycles, 1)
+	assert.Equal(t, []string{"alpha (alpha)", "beta (beta)"}, report.PackageCycles[0].Members)
+	assert.Equal(t, 2, report.Total())
+}
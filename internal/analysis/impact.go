@@ -9,6 +9,14 @@ import (
 type ImpactReport struct {
 	DirectlyAffected   []*graph.Node
 	IndirectlyAffected []*graph.Node
+	// Tests exercise a directly affected symbol or were changed themselves.
+	Tests []*graph.Node
+	// Untested lists directly affected functions and methods that no test
+	// exercises.
+	Untested []*graph.Node
+	// Paths maps each indirectly affected symbol ID to the symbol IDs leading
+	// from it to the changed symbol it depends on.
+	Paths map[string][]string
 }
 
 // Analyzer performs impact analysis on the dependency graph.
@@ -26,17 +34,31 @@ func (a *Analyzer) AnalyzeImpact(changes []git.ChangedFile) (*ImpactReport, erro
 	report := &ImpactReport{
 		DirectlyAffected:   []*graph.Node{},
 		IndirectlyAffected: []*graph.Node{},
+		Tests:              []*graph.Node{},
+		Untested:           []*graph.Node{},
+		Paths:              make(map[string][]string),
 	}
 
 	seenDirect := make(map[string]bool)
 	seenIndirect := make(map[string]bool)
+	seenTests := make(map[string]bool)
+	addTest := func(node *graph.Node) {
+		if !seenTests[node.Unit.ID] {
+			report.Tests = append(report.Tests, node)
+			seenTests[node.Unit.ID] = true
+		}
+	}
 
 	// 1. Find Direct Impacts
 	// Optimization: Index nodes by filepath on the fly if this becomes slow.
 	for _, change := range changes {
 		for _, node := range a.g.Nodes {
 			if node.Unit.Filepath == change.Path {
-				if isAffected(node, change.ChangedLines) {
+				if change.Touches(node.Unit.StartLine, node.Unit.EndLine) {
+					if node.Unit.UnitType == graph.UnitTypeTest {
+						addTest(node)
+						continue
+					}
 					if !seenDirect[node.Unit.ID] {
 						report.DirectlyAffected = append(report.DirectlyAffected, node)
 						seenDirect[node.Unit.ID] = true
@@ -52,20 +74,35 @@ func (a *Analyzer) AnalyzeImpact(changes []git.ChangedFile) (*ImpactReport, erro
 		for _, dep := range dependents {
 			if !seenDirect[dep.Unit.ID] && !seenIndirect[dep.Unit.ID] {
 				report.IndirectlyAffected = append(report.IndirectlyAffected, dep)
+				report.Paths[dep.Unit.ID] = []string{dep.Unit.ID, node.Unit.ID}
 				seenIndirect[dep.Unit.ID] = true
 			}
 		}
 	}
 
+	// 3. Find covering tests and untested changes
+	for _, node := range report.DirectlyAffected {
+		tests := a.g.TestsOf(node.Unit.ID)
+		if len(tests) == 0 {
+			if isTestable(node) {
+				report.Untested = append(report.Untested, node)
+			}
+			continue
+		}
+		for _, t := range tests {
+			addTest(t)
+		}
+	}
+
 	return report, nil
 }
 
-func isAffected(node *graph.Node, lines []int) bool {
-	// Simple overlap check
-	for _, line := range lines {
-		if line >= node.Unit.StartLine && line <= node.Unit.EndLine {
-			return true
-		}
+// isTestable reports whether tests are expected to exercise the node
+// directly. Types and values are covered through their functions.
+func isTestable(node *graph.Node) bool {
+	switch node.Unit.UnitType {
+	case "function", "method":
+		return true
 	}
 	return false
 }
@@ -0,0 +1,183 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"docod/internal/git"
+	"docod/internal/graph"
+)
+
+// ImpactArtifact is the machine-readable form of an ImpactReport, written per
+// sync for PR reviewers and other tools.
+type ImpactArtifact struct {
+	GeneratedAt        string           `json:"generated_at"`
+	Changes            []ArtifactChange `json:"changes"`
+	DirectlyAffected   []ArtifactSymbol `json:"directly_affected"`
+	IndirectlyAffected []ArtifactSymbol `json:"indirectly_affected"`
+	Tests              []ArtifactSymbol `json:"tests"`
+	// Untested lists changed functions and methods without tests, with a
+	// suggested test for each.
+	Untested []ArtifactSymbol  `json:"untested"`
+	Sections []ArtifactSection `json:"affected_sections"`
+}
+
+type ArtifactChange struct {
+	Path   string   `json:"path"`
+	Lines  []int    `json:"lines,omitempty"`
+	Owners []string `json:"owners,omitempty"`
+}
+
+type ArtifactSymbol struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Package   string `json:"package"`
+	Kind      string `json:"kind"`
+	Filepath  string `json:"filepath"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	// PathToSeed lists the symbol IDs from this symbol to the changed one.
+	PathToSeed []string `json:"path_to_seed,omitempty"`
+	// SuggestedTest names a test to add for an untested symbol.
+	SuggestedTest string `json:"suggested_test,omitempty"`
+}
+
+// ArtifactSection is a documentation section the change is expected to affect.
+type ArtifactSection struct {
+	ID             string   `json:"id"`
+	Score          float64  `json:"score"`
+	Confidence     float64  `json:"confidence"`
+	Reasons        []string `json:"reasons,omitempty"`
+	TriggerSymbols []string `json:"trigger_symbols,omitempty"`
+	// LowEvidence marks sections the updater could not back with enough
+	// evidence.
+	LowEvidence bool `json:"low_evidence,omitempty"`
+	// Owners are the CODEOWNERS of the files backing the section.
+	Owners []string `json:"owners,omitempty"`
+}
+
+// NewImpactArtifact converts a report and the changes it was computed from.
+// Sections are attached by the caller once documentation planning has run.
+func NewImpactArtifact(report *ImpactReport, changes []git.ChangedFile) *ImpactArtifact {
+	a := &ImpactArtifact{
+		GeneratedAt:        time.Now().UTC().Format(time.RFC3339),
+		Changes:            make([]ArtifactChange, 0, len(changes)),
+		DirectlyAffected:   []ArtifactSymbol{},
+		IndirectlyAffected: []ArtifactSymbol{},
+		Tests:              []ArtifactSymbol{},
+		Untested:           []ArtifactSymbol{},
+		Sections:           []ArtifactSection{},
+	}
+	for _, c := range changes {
+		a.Changes = append(a.Changes, ArtifactChange{Path: c.Path, Lines: c.ChangedLines})
+	}
+	if report == nil {
+		return a
+	}
+	a.DirectlyAffected = artifactSymbols(report.DirectlyAffected, nil)
+	a.IndirectlyAffected = artifactSymbols(report.IndirectlyAffected, report.Paths)
+	a.Tests = artifactSymbols(report.Tests, nil)
+	a.Untested = artifactSymbols(report.Untested, nil)
+	for i := range a.Untested {
+		a.Untested[i].SuggestedTest = suggestTest(report.Untested[i].Unit)
+	}
+	return a
+}
+
+// suggestTest follows Go naming: TestName or TestReceiver_Method in the
+// file's _test.go companion.
+func suggestTest(u *graph.Symbol) string {
+	name := "Test" + strings.ToUpper(u.Name[:1]) + u.Name[1:]
+	if recv := receiverType(u.Metadata.Receiver); recv != "" {
+		name = "Test" + recv + "_" + u.Name
+	}
+	file := strings.TrimSuffix(filepath.Base(u.Filepath), filepath.Ext(u.Filepath)) + "_test.go"
+	return name + " in " + file
+}
+
+// receiverType extracts the type name from a receiver such as "(c *Config)".
+func receiverType(recv string) string {
+	fields := strings.Fields(strings.Trim(strings.TrimSpace(recv), "()"))
+	if len(fields) == 0 {
+		return ""
+	}
+	t := strings.TrimPrefix(fields[len(fields)-1], "*")
+	if i := strings.Index(t, "["); i >= 0 {
+		t = t[:i]
+	}
+	return t
+}
+
+// RenderImpactMarkdown renders the artifact as a short Markdown summary for
+// PR comments: affected documentation sections, breaking impact counts and
+// changed code that should get tests.
+func RenderImpactMarkdown(a *ImpactArtifact) string {
+	var sb strings.Builder
+	sb.WriteString("## docod impact report\n\n")
+	sb.WriteString(fmt.Sprintf("%d symbols changed, %d callers affected, %d tests exercise the change.\n",
+		len(a.DirectlyAffected), len(a.IndirectlyAffected), len(a.Tests)))
+
+	if len(a.Sections) > 0 {
+		sb.WriteString("\n### Documentation sections to review\n\n")
+		for _, sec := range a.Sections {
+			line := fmt.Sprintf("- `%s` (score %.2f, confidence %.2f)", sec.ID, sec.Score, sec.Confidence)
+			if len(sec.Reasons) > 0 {
+				line += ": " + strings.Join(sec.Reasons, ", ")
+			}
+			if sec.LowEvidence {
+				line += " ⚠️ low evidence"
+			}
+			if len(sec.Owners) > 0 {
+				line += " — owners: " + strings.Join(sec.Owners, " ")
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+
+	if len(a.Untested) > 0 {
+		sb.WriteString("\n### Consider adding tests\n\n")
+		for _, s := range a.Untested {
+			sb.WriteString(fmt.Sprintf("- `%s.%s` (%s:%d): %s\n", s.Package, s.Name, s.Filepath, s.StartLine, s.SuggestedTest))
+		}
+	}
+	return sb.String()
+}
+
+func artifactSymbols(nodes []*graph.Node, paths map[string][]string) []ArtifactSymbol {
+	out := make([]ArtifactSymbol, 0, len(nodes))
+	for _, n := range nodes {
+		if n == nil || n.Unit == nil {
+			continue
+		}
+		u := n.Unit
+		out = append(out, ArtifactSymbol{
+			ID:         u.ID,
+			Name:       u.Name,
+			Package:    u.Package,
+			Kind:       u.UnitType,
+			Filepath:   u.Filepath,
+			StartLine:  u.StartLine,
+			EndLine:    u.EndLine,
+			PathToSeed: paths[u.ID],
+		})
+	}
+	return out
+}
+
+// WriteImpactArtifact writes the artifact as indented JSON, creating the
+// parent directory if needed.
+func WriteImpactArtifact(path string, a *ImpactArtifact) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	return os.WriteFile(path, b, 0644)
+}
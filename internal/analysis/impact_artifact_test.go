@@ -0,0 +1,77 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/git"
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImpactArtifact(t *testing.T) {
+	g := graph.NewGraph()
+	g.AddSymbol(&graph.Symbol{ID: "A", Name: "A", Package: "p", Filepath: "p/a.go", UnitType: "function", StartLine: 1, EndLine: 5})
+	g.AddSymbol(&graph.Symbol{ID: "B", Name: "B", Package: "p", Filepath: "p/b.go", UnitType: "function", StartLine: 1, EndLine: 5})
+	g.Edges = []graph.Edge{{From: "B", To: "A", Kind: graph.RelationCalls}}
+
+	changes := []git.ChangedFile{{Path: "p/a.go", ChangedLines: []int{2}}}
+	report, err := NewAnalyzer(g).AnalyzeImpact(changes)
+	require.NoError(t, err)
+
+	artifact := NewImpactArtifact(report, changes)
+	artifact.Sections = append(artifact.Sections, ArtifactSection{ID: "overview", Score: 1.2, LowEvidence: true, Owners: []string{"@org/core"}})
+	require.Len(t, artifact.IndirectlyAffected, 1)
+	assert.Equal(t, []string{"B", "A"}, artifact.IndirectlyAffected[0].PathToSeed)
+
+	path := filepath.Join(t.TempDir(), "docs", "impact_report.json")
+	require.NoError(t, WriteImpactArtifact(path, artifact))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var got ImpactArtifact
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, "A", got.DirectlyAffected[0].ID)
+	assert.Equal(t, []int{2}, got.Changes[0].Lines)
+	assert.Equal(t, "overview", got.Sections[0].ID)
+	if assert.Len(t, got.Untested, 1) {
+		assert.Equal(t, "TestA in a_test.go", got.Untested[0].SuggestedTest)
+	}
+
+	md := RenderImpactMarkdown(artifact)
+	assert.Contains(t, md, "### Consider adding tests")
+	assert.Contains(t, md, "- `p.A` (p/a.go:1): TestA in a_test.go")
+	assert.Contains(t, md, "- `overview` (score 1.20")
+	assert.Contains(t, md, "⚠️ low evidence — owners: @org/core")
+}
+
+func TestAnalyzeImpact_TestGapsOnlyForFunctions(t *testing.T) {
+	g := graph.NewGraph()
+	g.AddSymbol(&graph.Symbol{ID: "T", Name: "Config", Package: "p", Filepath: "p/a.go", UnitType: "struct", StartLine: 1, EndLine: 3})
+	g.AddSymbol(&graph.Symbol{ID: "M", Name: "Load", Package: "p", Filepath: "p/a.go", UnitType: "method", StartLine: 5, EndLine: 9, Metadata: graph.SymbolMetadata{Receiver: "(c *Config)"}})
+
+	report, err := NewAnalyzer(g).AnalyzeImpact([]git.ChangedFile{{Path: "p/a.go", WholeFile: true}})
+	require.NoError(t, err)
+	require.Len(t, report.Untested, 1)
+	assert.Equal(t, "M", report.Untested[0].Unit.ID)
+	assert.Equal(t, "TestConfig_Load in a_test.go", suggestTest(report.Untested[0].Unit))
+}
+
+func TestAnalyzeImpact_LinesWithoutWholeFile(t *testing.T) {
+	g := graph.NewGraph()
+	g.AddSymbol(&graph.Symbol{ID: "A", Name: "A", Package: "p", Filepath: "p/a.go", UnitType: "function", StartLine: 1, EndLine: 3})
+	g.AddSymbol(&graph.Symbol{ID: "B", Name: "B", Package: "p", Filepath: "p/a.go", UnitType: "function", StartLine: 5, EndLine: 9})
+
+	report, err := NewAnalyzer(g).AnalyzeImpact([]git.ChangedFile{{Path: "p/a.go", ChangedLines: []int{}}})
+	require.NoError(t, err)
+	assert.Empty(t, report.DirectlyAffected, "a diff without changed lines touches no symbol")
+
+	report, err = NewAnalyzer(g).AnalyzeImpact([]git.ChangedFile{{Path: "p/a.go", ChangedLines: []int{6}}})
+	require.NoError(t, err)
+	require.Len(t, report.DirectlyAffected, 1)
+	assert.Equal(t, "B", report.DirectlyAffected[0].Unit.ID)
+}
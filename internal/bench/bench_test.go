@@ -0,0 +1,153 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/crawler"
+	"docod/internal/extractor"
+	"docod/internal/git"
+	"docod/internal/graph"
+	"docod/internal/index"
+	"docod/internal/knowledge"
+	"docod/internal/resolver"
+	"docod/internal/retrieval"
+	"docod/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// searchDim is the embedding width of the vector search benchmark.
+const searchDim = 256
+
+func buildGraph(tb testing.TB, root string) *graph.Graph {
+	tb.Helper()
+	ext, err := extractor.NewExtractor("go")
+	require.NoError(tb, err)
+	g, err := index.NewIndexer(crawler.NewCrawler(ext)).BuildGraph(root)
+	require.NoError(tb, err)
+	return g
+}
+
+func generate(tb testing.TB, symbols int) *Fixture {
+	tb.Helper()
+	f, err := GenerateRepo(tb.TempDir(), symbols)
+	require.NoError(tb, err)
+	return f
+}
+
+// changedFiles picks n files spread across the fixture.
+func changedFiles(f *Fixture, n int) []git.ChangedFile {
+	out := make([]git.ChangedFile, 0, n)
+	step := max(1, len(f.Files)/n)
+	for i := 0; i < len(f.Files) && len(out) < n; i += step {
+		out = append(out, git.ChangedFile{Path: f.Files[i], WholeFile: true})
+	}
+	return out
+}
+
+func TestGenerateRepo_ResolvesAcrossPackages(t *testing.T) {
+	f := generate(t, 250)
+	assert.Len(t, f.Files, 25)
+
+	g := buildGraph(t, f.Root)
+	assert.GreaterOrEqual(t, len(g.Nodes), 250)
+	results := resolver.NewDefaultChain().Run(g)
+	require.NotEmpty(t, results)
+	for _, r := range results {
+		require.NoError(t, r.Err, r.Resolver)
+	}
+
+	// p0001's first file calls into p0000.
+	crossPackage := false
+	for _, e := range g.Edges {
+		from, to := g.Nodes[e.From], g.Nodes[e.To]
+		if e.Kind == graph.RelationCalls && from != nil && to != nil && from.Unit.Package == "p0001" && to.Unit.Package == "p0000" {
+			crossPackage = true
+		}
+	}
+	assert.True(t, crossPackage, "resolvers link calls between packages")
+
+	sub := retrieval.ExtractFromChanges(g, changedFiles(f, 2), retrieval.DefaultConfig())
+	assert.NotEmpty(t, sub.SeedIDs)
+	assert.Greater(t, len(sub.NodeIDs), len(sub.SeedIDs))
+}
+
+func BenchmarkExtract(b *testing.B) {
+	for _, size := range Sizes {
+		b.Run(size.Name, func(b *testing.B) {
+			f := generate(b, size.Symbols)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				buildGraph(b, f.Root)
+			}
+		})
+	}
+}
+
+func BenchmarkResolve(b *testing.B) {
+	for _, size := range Sizes {
+		b.Run(size.Name, func(b *testing.B) {
+			f := generate(b, size.Symbols)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				g := buildGraph(b, f.Root)
+				b.StartTimer()
+				resolver.NewDefaultChain().Run(g)
+			}
+		})
+	}
+}
+
+func BenchmarkRetrieval(b *testing.B) {
+	for _, size := range Sizes {
+		b.Run(size.Name, func(b *testing.B) {
+			f := generate(b, size.Symbols)
+			g := buildGraph(b, f.Root)
+			resolver.NewDefaultChain().Run(g)
+			changes := changedFiles(f, 10)
+			cfg := retrieval.DefaultConfig()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				retrieval.ExtractFromChanges(g, changes, cfg)
+			}
+		})
+	}
+}
+
+func BenchmarkVectorSearch(b *testing.B) {
+	for _, size := range Sizes {
+		b.Run(size.Name, func(b *testing.B) {
+			store, err := storage.NewSQLiteStore(filepath.Join(b.TempDir(), "bench.db"))
+			require.NoError(b, err)
+			defer store.Close()
+			rng := rand.New(rand.NewSource(1))
+			vector := func() []float32 {
+				v := make([]float32, searchDim)
+				for i := range v {
+					v[i] = rng.Float32()*2 - 1
+				}
+				return v
+			}
+			items := make([]knowledge.VectorItem, size.Symbols)
+			for i := range items {
+				id := fmt.Sprintf("chunk-%d", i)
+				items[i] = knowledge.VectorItem{Chunk: knowledge.SearchChunk{ID: id, Name: id}, Embedding: vector()}
+			}
+			ctx := context.Background()
+			require.NoError(b, store.SaveEmbeddings(ctx, items))
+			query := vector()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := store.Search(ctx, query, 10, knowledge.SearchFilter{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Package bench generates synthetic Go repositories for the benchmarks of
+// extraction, resolution and retrieval. Run them with
+//
+//	go test ./internal/bench -run '^$' -bench . -benchmem
+//
+// or `make bench`.
+package bench
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FixtureModule is the module path of generated repositories.
+const FixtureModule = "benchfixture"
+
+// Fixture sizes, in symbols.
+var Sizes = []struct {
+	Name    string
+	Symbols int
+}{
+	{"1k", 1_000},
+	{"10k", 10_000},
+	{"50k", 50_000},
+}
+
+const (
+	// symbolsPerFile is the number of declarations fixtureFile writes.
+	symbolsPerFile  = 10
+	filesPerPackage = 10
+)
+
+// Fixture is a generated repository.
+type Fixture struct {
+	Root  string
+	Files []string
+}
+
+// GenerateRepo writes a module of about symbols declarations under dir.
+// Every file declares a struct, an item type, an interface, three methods
+// and four functions that call each other; every package imports the one
+// before it, so resolvers see local, cross-file and cross-package calls,
+// interface implementations and external imports.
+func GenerateRepo(dir string, symbols int) (*Fixture, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module "+FixtureModule+"\n\ngo 1.21\n"), 0644); err != nil {
+		return nil, err
+	}
+	f := &Fixture{Root: dir}
+	files := max(1, (symbols+symbolsPerFile-1)/symbolsPerFile)
+	for n := 0; n < files; n++ {
+		pkg, file := n/filesPerPackage, n%filesPerPackage
+		pkgDir := filepath.Join(dir, pkgName(pkg))
+		if file == 0 {
+			if err := os.MkdirAll(pkgDir, 0755); err != nil {
+				return nil, err
+			}
+		}
+		path := filepath.Join(pkgDir, fmt.Sprintf("file%02d.go", file))
+		if err := os.WriteFile(path, []byte(fixtureFile(pkg, file)), 0644); err != nil {
+			return nil, err
+		}
+		f.Files = append(f.Files, path)
+	}
+	return f, nil
+}
+
+func pkgName(pkg int) string {
+	return fmt.Sprintf("p%04d", pkg)
+}
+
+func fixtureFile(pkg, file int) string {
+	var b strings.Builder
+	name := pkgName(pkg)
+	s := strconv.Itoa(file)
+	if file == 0 {
+		fmt.Fprintf(&b, "// Package %s is generated for benchmarks.\n", name)
+	}
+	fmt.Fprintf(&b, "package %s\n\nimport (\n\t\"fmt\"\n", name)
+	if pkg > 0 && file == 0 {
+		fmt.Fprintf(&b, "\n\tprev %q\n", FixtureModule+"/"+pkgName(pkg-1))
+	}
+	b.WriteString(")\n\n")
+
+	b.WriteString(strings.ReplaceAll(`// ItemXX is a stored value.
+type ItemXX struct {
+	ID   int
+	Name string
+}
+
+// GetterXX reads items by ID.
+type GetterXX interface {
+	Get(id int) (ItemXX, bool)
+}
+
+// StoreXX keeps items in insertion order.
+type StoreXX struct {
+	items []ItemXX
+}
+
+// Get returns the item with id.
+func (s *StoreXX) Get(id int) (ItemXX, bool) {
+	for _, it := range s.items {
+		if it.ID == id {
+			return it, true
+		}
+	}
+	return ItemXX{}, false
+}
+
+// Put appends it.
+func (s *StoreXX) Put(it ItemXX) {
+	s.items = append(s.items, it)
+}
+
+// Len counts the items.
+func (s *StoreXX) Len() int {
+	return len(s.items)
+}
+
+// NewStoreXX returns a store holding n items.
+func NewStoreXX(n int) *StoreXX {
+	s := &StoreXX{}
+	for i := 0; i < n; i++ {
+		s.Put(ItemXX{ID: i, Name: FormatXX(i)})
+	}
+	return s
+}
+
+// FormatXX names item i.
+func FormatXX(i int) string {
+	return fmt.Sprintf("item-%d", i)
+}
+
+// LookupXX reads id through the interface.
+func LookupXX(g GetterXX, id int) string {
+	it, ok := g.Get(id)
+	if !ok {
+		return ""
+	}
+	return it.Name
+}
+
+`, "XX", s))
+	fmt.Fprintf(&b, "// Run%s exercises the store", s)
+	if pkg > 0 || file > 0 {
+		b.WriteString(" and the previous one")
+	}
+	fmt.Fprintf(&b, ".\nfunc Run%s() int {\n\ts := NewStore%s(8)\n\t_ = Lookup%s(s, 3)\n", s, s, s)
+	switch {
+	case file > 0:
+		fmt.Fprintf(&b, "\treturn s.Len() + Run%d()\n", file-1)
+	case pkg > 0:
+		fmt.Fprintf(&b, "\treturn s.Len() + prev.Run%d()\n", filesPerPackage-1)
+	default:
+		b.WriteString("\treturn s.Len()\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
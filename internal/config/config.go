@@ -1,7 +1,11 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -11,7 +15,9 @@ import (
 
 type Config struct {
 	Project struct {
-		Root string `yaml:"root"`
+		Root            string `yaml:"root"`
+		SymlinkPolicy   string `yaml:"symlink_policy"`
+		ScanConcurrency int    `yaml:"scan_concurrency"`
 	} `yaml:"project"`
 	AI struct {
 		EmbeddingProvider string `yaml:"embedding_provider"`
@@ -21,9 +27,48 @@ type Config struct {
 		LLMProvider       string `yaml:"llm_provider"`
 		LLMModel          string `yaml:"llm_model"`
 		LLMAPIKey         string `yaml:"llm_api_key"`
+		LLMContextWindow  int    `yaml:"llm_context_window"`
+		LLMMaxOutput      int    `yaml:"llm_max_output_tokens"`
 		OpenAIBaseURL     string `yaml:"openai_base_url"`
 		LLMBaseURL        string `yaml:"llm_base_url"`
 		OllamaBaseURL     string `yaml:"ollama_base_url"`
+		RerankProvider    string `yaml:"rerank_provider"`
+		RerankModel       string `yaml:"rerank_model"`
+		RerankAPIKey      string `yaml:"rerank_api_key"`
+		RerankBaseURL     string `yaml:"rerank_base_url"`
+		QueryCacheMax     int    `yaml:"query_cache_max_entries"`
+		QueryCacheTTLHrs  int    `yaml:"query_cache_ttl_hours"`
+		DualEmbeddings    bool   `yaml:"dual_embeddings"`
+
+		// Tokenizer and EmbeddingTokenizer count tokens for LLM prompts and
+		// embedded text; empty picks the one matching the provider and model.
+		Tokenizer          string `yaml:"tokenizer"`
+		EmbeddingTokenizer string `yaml:"embedding_tokenizer"`
+		// EmbeddingMaxTokens caps each embedded text; 0 leaves it uncapped.
+		EmbeddingMaxTokens int `yaml:"embedding_max_tokens"`
+		// RequestsPerMinute caps embedding and LLM requests started per
+		// minute, shared across concurrent work; 0 leaves them unlimited.
+		RequestsPerMinute int `yaml:"requests_per_minute"`
+		// Pricing overrides the built-in list prices used for cost
+		// estimates, by model name prefix.
+		Pricing map[string]PriceConfig `yaml:"pricing"`
+		// Azure addresses the Azure OpenAI resource used by the azure-openai
+		// embedding and LLM providers.
+		Azure struct {
+			Endpoint   string `yaml:"endpoint"`
+			APIVersion string `yaml:"api_version"`
+			// APIKey is the resource key, used when embedding_api_key or
+			// llm_api_key is empty.
+			APIKey              string `yaml:"api_key"`
+			EmbeddingDeployment string `yaml:"embedding_deployment"`
+			LLMDeployment       string `yaml:"llm_deployment"`
+		} `yaml:"azure"`
+		// Local runs the ONNX sentence-transformer named by embedding_model
+		// for the local embedding provider.
+		Local struct {
+			RuntimeLibrary    string `yaml:"runtime_library"`
+			MaxSequenceLength int    `yaml:"max_sequence_length"`
+		} `yaml:"local"`
 	} `yaml:"ai"`
 	Docs struct {
 		MaxLLMSections       int     `yaml:"max_llm_sections"`
@@ -32,25 +77,370 @@ type Config struct {
 		MaxLLMRoutes         int     `yaml:"max_llm_routes"`
 		MinConfidenceForLLM  float64 `yaml:"min_confidence_for_llm"`
 		MaxEmbedChunksPerRun int     `yaml:"max_embed_chunks_per_run"`
+		DocumentCycles       bool    `yaml:"document_cycles"`
+		DocumentExternalDeps bool    `yaml:"document_external_deps"`
+		ComponentPages       bool    `yaml:"component_pages"`
+		PackagePages         bool    `yaml:"package_pages"`
+		APIReference         bool    `yaml:"api_reference"`
+		Changelog            bool    `yaml:"changelog"`
+		Diversity            string  `yaml:"diversity"`
+		MMRLambda            float64 `yaml:"mmr_lambda"`
+		HierarchicalSearch   bool    `yaml:"hierarchical_retrieval"`
+		MaxSummariesPerRun   int     `yaml:"max_summaries_per_run"`
+		PlanPath             string  `yaml:"plan_path"`
+		// Preset shapes the section list and plans for a kind of project
+		// (library|service|cli|sdk); empty keeps the default sections.
+		Preset string `yaml:"preset"`
+		// Style is the writing policy for every section; SectionStyles
+		// overrides it per section ID.
+		Style         StyleConfig            `yaml:"style"`
+		SectionStyles map[string]StyleConfig `yaml:"section_styles"`
+		// SectionConcurrency is how many sections a full generation writes
+		// at once; 0 or 1 writes them one at a time.
+		SectionConcurrency int `yaml:"section_concurrency"`
 	} `yaml:"docs"`
+	// Output locates generated files; see OutputPaths.
+	Output struct {
+		Dir        string `yaml:"dir"`
+		DocFile    string `yaml:"doc_file"`
+		ModelFile  string `yaml:"model_file"`
+		ReportsDir string `yaml:"reports_dir"`
+		FeedFile   string `yaml:"feed_file"`
+	} `yaml:"output"`
+	// Feed configures the Atom feed of section changes written by sync.
+	Feed struct {
+		Enabled bool   `yaml:"enabled"`
+		Title   string `yaml:"title"`
+		// Link is the URL the documentation is published at; entries link
+		// to their section anchor below it.
+		Link       string `yaml:"link"`
+		MaxEntries int    `yaml:"max_entries"`
+	} `yaml:"feed"`
+	Scope struct {
+		ExcludeTests bool     `yaml:"exclude_tests"`
+		ExcludePaths []string `yaml:"exclude_paths"`
+	} `yaml:"scope"`
+	Resolvers struct {
+		Chain             []string           `yaml:"chain"`
+		Disable           []string           `yaml:"disable"`
+		MinConfidence     map[string]float64 `yaml:"min_confidence"`
+		MinEdgeConfidence float64            `yaml:"min_edge_confidence"`
+		StrictEdges       bool               `yaml:"strict_edges"`
+	} `yaml:"resolvers"`
+	Planner struct {
+		MaxHops          int     `yaml:"max_hops"`
+		SeedScore        float64 `yaml:"seed_score"`
+		HopDecay         float64 `yaml:"hop_decay"`
+		SymbolMatchBonus float64 `yaml:"symbol_match_bonus"`
+		FileMatchBonus   float64 `yaml:"file_match_bonus"`
+		CentralityWeight float64 `yaml:"centrality_weight"`
+		DependentsWeight float64 `yaml:"dependents_weight"`
+	} `yaml:"planner"`
+	Chunking struct {
+		Strategy      string            `yaml:"strategy"`
+		WindowLines   int               `yaml:"window_lines"`
+		OverlapLines  int               `yaml:"overlap_lines"`
+		WindowTokens  int               `yaml:"window_tokens"`
+		OverlapTokens int               `yaml:"overlap_tokens"`
+		MaxSegments   int               `yaml:"max_segments"`
+		Languages     map[string]string `yaml:"languages"`
+	} `yaml:"chunking"`
+	// VectorSearch bounds the memory semantic search uses on large indexes.
+	VectorSearch struct {
+		PageSize      int `yaml:"page_size"`
+		MemoryLimitMB int `yaml:"memory_limit_mb"`
+		// Workers is the number of goroutines scoring a scan; 0 uses every
+		// CPU.
+		Workers int `yaml:"workers"`
+		// Mmap mirrors the embeddings into a memory-mapped file that searches
+		// scan instead of the database.
+		Mmap       bool   `yaml:"mmap"`
+		VectorFile string `yaml:"vector_file"`
+		// Index is flat (scan every vector) or hnsw (approximate search
+		// over an in-memory graph).
+		Index    string `yaml:"index"`
+		HNSWM    int    `yaml:"hnsw_m"`
+		EfSearch int    `yaml:"ef_search"`
+	} `yaml:"vector_search"`
+	// VectorStore selects the registered driver that holds chunk embeddings
+	// (sqlite|memory|qdrant|pgvector); the graph stays in the project
+	// database.
+	VectorStore struct {
+		Driver     string `yaml:"driver"`
+		URL        string `yaml:"url"`
+		Collection string `yaml:"collection"`
+		APIKey     string `yaml:"api_key"`
+	} `yaml:"vector_store"`
+	// Sharding documents a large repository per top-level directory or Go
+	// module, then composes a global overview.
+	Sharding struct {
+		Enabled bool `yaml:"enabled"`
+		// By is "directory" or "module".
+		By         string `yaml:"by"`
+		Depth      int    `yaml:"depth"`
+		MinSymbols int    `yaml:"min_symbols"`
+		// Workers is the number of shards documented at once; 0 means 1.
+		Workers int `yaml:"workers"`
+		// MaxChunksPerShard bounds the chunks each shard embeds per run; 0
+		// is unbounded.
+		MaxChunksPerShard int `yaml:"max_chunks_per_shard"`
+	} `yaml:"sharding"`
+	Server struct {
+		// APIKeys authorize /api requests to `docod serve`; empty leaves the
+		// API open.
+		APIKeys []string `yaml:"api_keys"`
+		// Slack enables the /docod slash command when a signing secret is
+		// set.
+		Slack struct {
+			SigningSecret string `yaml:"signing_secret"`
+			// Repo names the project this server documents; empty uses the
+			// project root directory name.
+			Repo string `yaml:"repo"`
+			// Channels maps channel IDs to the repo they ask about.
+			Channels map[string]string `yaml:"channels"`
+		} `yaml:"slack"`
+	} `yaml:"server"`
+	// Publish configures the targets of `docod publish`.
+	Publish struct {
+		Confluence struct {
+			BaseURL      string `yaml:"base_url"`
+			SpaceKey     string `yaml:"space_key"`
+			ParentPageID string `yaml:"parent_page_id"`
+			User         string `yaml:"user"`
+			APIToken     string `yaml:"api_token"`
+			TitlePrefix  string `yaml:"title_prefix"`
+		} `yaml:"confluence"`
+		Notion struct {
+			Token           string `yaml:"token"`
+			DatabaseID      string `yaml:"database_id"`
+			ParentPageID    string `yaml:"parent_page_id"`
+			TitleProperty   string `yaml:"title_property"`
+			TitlePrefix     string `yaml:"title_prefix"`
+			DiagramImageURL string `yaml:"diagram_image_url"`
+		} `yaml:"notion"`
+	} `yaml:"publish"`
+	// Profiles are named partial configs layered over the rest of the file
+	// when selected with --profile or DOCOD_PROFILE.
+	Profiles map[string]yaml.Node `yaml:"profiles"`
+	// Profile is the name of the applied profile, if any.
+	Profile string `yaml:"-"`
+
+	// sources and issues are collected while decoding for Validate.
+	sources map[string]source
+	issues  []Issue
+	// untrusted is the project config found by discovery, unless trusted
+	// with DOCOD_TRUST_PROJECT_CONFIG; its cmd:// secrets are not run.
+	untrusted string
+}
+
+// decodeFile decodes one config file over c, recording key positions,
+// unknown keys and values that do not match the schema.
+func (c *Config) decodeFile(file string, data []byte) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	c.walk(file, doc.Content[0], configType, "")
+	if !c.checkSchema(file, doc.Content[0]) {
+		// The schema issues explain the values that would not decode.
+		return nil
+	}
+	if err := doc.Content[0].Decode(c); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// StyleConfig is the writing policy of a section. Empty fields inherit.
+type StyleConfig struct {
+	Tone     string `yaml:"tone"`
+	Audience string `yaml:"audience"`
+	// Diagrams lists the Mermaid diagram types the LLM may draw
+	// (flowchart|sequence|class|er|state), or ["none"].
+	Diagrams     []string `yaml:"diagrams"`
+	CodeLanguage string   `yaml:"code_language"`
+}
+
+// PriceConfig is the USD price of a model per million tokens.
+type PriceConfig struct {
+	InputPerMillion  float64 `yaml:"input_per_million"`
+	OutputPerMillion float64 `yaml:"output_per_million"`
+}
+
+// activeProfile is the profile selected on the command line.
+var activeProfile string
+
+// SetProfile selects the profile LoadConfig applies; it takes precedence over
+// DOCOD_PROFILE. An empty name falls back to the environment.
+func SetProfile(name string) {
+	activeProfile = strings.TrimSpace(name)
+}
+
+// ProfileNames lists the profiles defined in the loaded files.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyProfile decodes the named profile over cfg.
+func applyProfile(cfg *Config, name string) error {
+	node, ok := cfg.Profiles[name]
+	if !ok {
+		available := "none defined"
+		if names := cfg.ProfileNames(); len(names) > 0 {
+			available = "available: " + strings.Join(names, ", ")
+		}
+		return fmt.Errorf("unknown config profile %q (%s)", name, available)
+	}
+	var profile Config
+	if err := node.Decode(&profile); err != nil {
+		return fmt.Errorf("profile %q: %w", name, err)
+	}
+	if len(profile.Profiles) > 0 {
+		return fmt.Errorf("profile %q: profiles cannot be nested", name)
+	}
+	if err := node.Decode(cfg); err != nil {
+		return fmt.Errorf("profile %q: %w", name, err)
+	}
+	prefix := "profiles." + name + "."
+	for path, src := range cfg.sources {
+		if rest, ok := strings.CutPrefix(path, prefix); ok {
+			cfg.sources[rest] = src
+		}
+	}
+	cfg.Profile = name
+	return nil
+}
+
+// DefaultPath is the config path commands pass to LoadConfig. It stands for
+// the nearest project config found by FindProjectConfig.
+const DefaultPath = "config.yaml"
+
+// ProjectConfigNames are the project config file names looked up in each
+// directory, in priority order.
+var ProjectConfigNames = []string{".docod.yaml", "config.yaml"}
+
+// FindProjectConfig searches dir and its parents for a project config file and
+// returns the first one found.
+func FindProjectConfig(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		for _, name := range ProjectConfigNames {
+			candidate := filepath.Join(dir, name)
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate, true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
 }
 
+// UserConfigPath returns the user-level config file holding global defaults:
+// $XDG_CONFIG_HOME/docod/config.yaml, falling back to ~/.config/docod/config.yaml.
+func UserConfigPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "docod", "config.yaml")
+}
+
+// LoadConfig reads the user-level defaults and then the project config at
+// path on top of them, so the project only has to set what differs. The
+// default path is discovered upward from the working directory; at least one
+// of the user and project files must exist. Unknown keys and invalid values
+// fail with a *ValidationError pointing at their YAML paths.
 func LoadConfig(path string) (*Config, error) {
+	cfg, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	if errs := Errors(cfg.Validate()); len(errs) > 0 {
+		return nil, &ValidationError{Issues: errs}
+	}
+	return cfg, nil
+}
+
+func load(path string) (*Config, error) {
 	// 1. Load .env if exists
 	_ = godotenv.Load()
 
-	// 2. Load YAML config
-	file, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
+	// 2. Load YAML config: user defaults first, then the project file.
+	cfg := Config{sources: make(map[string]source)}
+	found := false
+	if userPath := UserConfigPath(); userPath != "" {
+		if file, err := os.ReadFile(userPath); err == nil {
+			if err := cfg.decodeFile(userPath, file); err != nil {
+				return nil, err
+			}
+			found = true
+		}
 	}
 
-	var cfg Config
-	if err := yaml.Unmarshal(file, &cfg); err != nil {
+	projectPath := path
+	if path == DefaultPath {
+		if discovered, ok := FindProjectConfig("."); ok {
+			projectPath = discovered
+			if cwd, err := os.Getwd(); err == nil {
+				if rel, err := filepath.Rel(cwd, discovered); err == nil {
+					projectPath = rel
+				}
+			}
+		}
+	}
+	file, err := os.ReadFile(projectPath)
+	switch {
+	case err == nil:
+		if err := cfg.decodeFile(projectPath, file); err != nil {
+			return nil, err
+		}
+		if path == DefaultPath && !trustProjectConfig() {
+			cfg.untrusted = projectPath
+		}
+	case errors.Is(err, os.ErrNotExist) && found:
+		// User defaults alone are enough.
+	default:
 		return nil, err
 	}
 
-	// 3. Override with Environment Variables if present
+	// 3. Apply the selected profile over the files.
+	profile := activeProfile
+	if profile == "" {
+		profile = strings.TrimSpace(os.Getenv("DOCOD_PROFILE"))
+	}
+	if profile != "" {
+		if err := applyProfile(&cfg, profile); err != nil {
+			return nil, err
+		}
+	}
+
+	// 4. Override with Environment Variables if present
+	if policy := os.Getenv("DOCOD_SYMLINK_POLICY"); policy != "" {
+		cfg.Project.SymlinkPolicy = policy
+	}
+	if workers := os.Getenv("DOCOD_SCAN_CONCURRENCY"); workers != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(workers)); err == nil {
+			cfg.Project.ScanConcurrency = n
+		}
+	}
 	if provider := os.Getenv("DOCOD_EMBEDDING_PROVIDER"); provider != "" {
 		cfg.AI.EmbeddingProvider = provider
 	}
@@ -74,6 +464,27 @@ func LoadConfig(path string) (*Config, error) {
 	if llmKey := os.Getenv("DOCOD_LLM_API_KEY"); llmKey != "" {
 		cfg.AI.LLMAPIKey = llmKey
 	}
+	if v := os.Getenv("DOCOD_LLM_CONTEXT_WINDOW"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			cfg.AI.LLMContextWindow = n
+		}
+	}
+	if v := os.Getenv("DOCOD_LLM_MAX_OUTPUT_TOKENS"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			cfg.AI.LLMMaxOutput = n
+		}
+	}
+	if name := os.Getenv("DOCOD_TOKENIZER"); name != "" {
+		cfg.AI.Tokenizer = name
+	}
+	if name := os.Getenv("DOCOD_EMBEDDING_TOKENIZER"); name != "" {
+		cfg.AI.EmbeddingTokenizer = name
+	}
+	if v := os.Getenv("DOCOD_EMBEDDING_MAX_TOKENS"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			cfg.AI.EmbeddingMaxTokens = n
+		}
+	}
 	if baseURL := os.Getenv("DOCOD_OPENAI_BASE_URL"); baseURL != "" {
 		cfg.AI.OpenAIBaseURL = baseURL
 	}
@@ -83,6 +494,49 @@ func LoadConfig(path string) (*Config, error) {
 	if baseURL := os.Getenv("DOCOD_OLLAMA_BASE_URL"); baseURL != "" {
 		cfg.AI.OllamaBaseURL = baseURL
 	}
+	if endpoint := os.Getenv("DOCOD_AZURE_OPENAI_ENDPOINT"); endpoint != "" {
+		cfg.AI.Azure.Endpoint = endpoint
+	}
+	if v := os.Getenv("DOCOD_AZURE_OPENAI_API_VERSION"); v != "" {
+		cfg.AI.Azure.APIVersion = v
+	}
+	if key := os.Getenv("DOCOD_AZURE_OPENAI_API_KEY"); key != "" {
+		cfg.AI.Azure.APIKey = key
+	}
+	if v := os.Getenv("DOCOD_AZURE_EMBEDDING_DEPLOYMENT"); v != "" {
+		cfg.AI.Azure.EmbeddingDeployment = v
+	}
+	if v := os.Getenv("DOCOD_AZURE_LLM_DEPLOYMENT"); v != "" {
+		cfg.AI.Azure.LLMDeployment = v
+	}
+	if lib := os.Getenv("DOCOD_ONNXRUNTIME_LIB"); lib != "" {
+		cfg.AI.Local.RuntimeLibrary = lib
+	}
+	if provider := os.Getenv("DOCOD_RERANK_PROVIDER"); provider != "" {
+		cfg.AI.RerankProvider = provider
+	}
+	if model := os.Getenv("DOCOD_RERANK_MODEL"); model != "" {
+		cfg.AI.RerankModel = model
+	}
+	if key := os.Getenv("DOCOD_RERANK_API_KEY"); key != "" {
+		cfg.AI.RerankAPIKey = key
+	}
+	if baseURL := os.Getenv("DOCOD_RERANK_BASE_URL"); baseURL != "" {
+		cfg.AI.RerankBaseURL = baseURL
+	}
+	if v := os.Getenv("DOCOD_QUERY_CACHE_MAX_ENTRIES"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			cfg.AI.QueryCacheMax = n
+		}
+	}
+	if v := os.Getenv("DOCOD_QUERY_CACHE_TTL_HOURS"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			cfg.AI.QueryCacheTTLHrs = n
+		}
+	}
+	if v := os.Getenv("DOCOD_DUAL_EMBEDDINGS"); v != "" {
+		cfg.AI.DualEmbeddings = parseBool(v)
+	}
 	// Docs runtime options with env overrides
 	if v := os.Getenv("DOCOD_MAX_LLM_SECTIONS"); v != "" {
 		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
@@ -105,15 +559,214 @@ func LoadConfig(path string) (*Config, error) {
 			cfg.Docs.MinConfidenceForLLM = f
 		}
 	}
+	if v := os.Getenv("DOCOD_REQUESTS_PER_MINUTE"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			cfg.AI.RequestsPerMinute = n
+		}
+	}
 	if v := os.Getenv("DOCOD_MAX_EMBED_CHUNKS_PER_RUN"); v != "" {
 		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
 			cfg.Docs.MaxEmbedChunksPerRun = n
 		}
 	}
+	if v := os.Getenv("DOCOD_DOCUMENT_CYCLES"); v != "" {
+		cfg.Docs.DocumentCycles = parseBool(v)
+	}
+	if v := os.Getenv("DOCOD_DOCUMENT_EXTERNAL_DEPS"); v != "" {
+		cfg.Docs.DocumentExternalDeps = parseBool(v)
+	}
+	if v := os.Getenv("DOCOD_COMPONENT_PAGES"); v != "" {
+		cfg.Docs.ComponentPages = parseBool(v)
+	}
+	if v := os.Getenv("DOCOD_PACKAGE_PAGES"); v != "" {
+		cfg.Docs.PackagePages = parseBool(v)
+	}
+	if v := os.Getenv("DOCOD_API_REFERENCE"); v != "" {
+		cfg.Docs.APIReference = parseBool(v)
+	}
+	if v := os.Getenv("DOCOD_CHANGELOG"); v != "" {
+		cfg.Docs.Changelog = parseBool(v)
+	}
+	if v := os.Getenv("DOCOD_DIVERSITY"); v != "" {
+		cfg.Docs.Diversity = v
+	}
+	if v := os.Getenv("DOCOD_MMR_LAMBDA"); v != "" {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			cfg.Docs.MMRLambda = f
+		}
+	}
+	if v := os.Getenv("DOCOD_HIERARCHICAL_RETRIEVAL"); v != "" {
+		cfg.Docs.HierarchicalSearch = parseBool(v)
+	}
+	if v := os.Getenv("DOCOD_MAX_SUMMARIES_PER_RUN"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			cfg.Docs.MaxSummariesPerRun = n
+		}
+	}
+	if v := os.Getenv("DOCOD_DOC_PLAN_PATH"); v != "" {
+		cfg.Docs.PlanPath = v
+	}
+	if v := os.Getenv("DOCOD_DOCS_PRESET"); v != "" {
+		cfg.Docs.Preset = v
+	}
+	if v := os.Getenv("DOCOD_SECTION_CONCURRENCY"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			cfg.Docs.SectionConcurrency = n
+		}
+	}
+	if v := os.Getenv("DOCOD_SCOPE_EXCLUDE_TESTS"); v != "" {
+		cfg.Scope.ExcludeTests = parseBool(v)
+	}
+	if v := os.Getenv("DOCOD_SCOPE_EXCLUDE_PATHS"); v != "" {
+		cfg.Scope.ExcludePaths = splitList(v)
+	}
+	if v := os.Getenv("DOCOD_RESOLVER_CHAIN"); v != "" {
+		cfg.Resolvers.Chain = splitList(v)
+	}
+	if v := os.Getenv("DOCOD_RESOLVER_DISABLE"); v != "" {
+		cfg.Resolvers.Disable = splitList(v)
+	}
+	if v := os.Getenv("DOCOD_MIN_EDGE_CONFIDENCE"); v != "" {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			cfg.Resolvers.MinEdgeConfidence = f
+		}
+	}
+	if v := os.Getenv("DOCOD_STRICT_EDGES"); v != "" {
+		cfg.Resolvers.StrictEdges = parseBool(v)
+	}
+	if v := os.Getenv("DOCOD_PLANNER_MAX_HOPS"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			cfg.Planner.MaxHops = n
+		}
+	}
+	if v := os.Getenv("DOCOD_PLANNER_SEED_SCORE"); v != "" {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			cfg.Planner.SeedScore = f
+		}
+	}
+	if v := os.Getenv("DOCOD_PLANNER_HOP_DECAY"); v != "" {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			cfg.Planner.HopDecay = f
+		}
+	}
+	if v := os.Getenv("DOCOD_PLANNER_SYMBOL_MATCH_BONUS"); v != "" {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			cfg.Planner.SymbolMatchBonus = f
+		}
+	}
+	if v := os.Getenv("DOCOD_PLANNER_FILE_MATCH_BONUS"); v != "" {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			cfg.Planner.FileMatchBonus = f
+		}
+	}
+	if v := os.Getenv("DOCOD_PLANNER_CENTRALITY_WEIGHT"); v != "" {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			cfg.Planner.CentralityWeight = f
+		}
+	}
+	if v := os.Getenv("DOCOD_PLANNER_DEPENDENTS_WEIGHT"); v != "" {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			cfg.Planner.DependentsWeight = f
+		}
+	}
+	if v := os.Getenv("DOCOD_OUTPUT_DIR"); v != "" {
+		cfg.Output.Dir = v
+	}
+	if v := os.Getenv("DOCOD_REPORTS_DIR"); v != "" {
+		cfg.Output.ReportsDir = v
+	}
+	if v := os.Getenv("DOCOD_FEED_ENABLED"); v != "" {
+		cfg.Feed.Enabled = parseBool(v)
+	}
+	if v := os.Getenv("DOCOD_FEED_LINK"); v != "" {
+		cfg.Feed.Link = v
+	}
+	if v := os.Getenv("DOCOD_CHUNKING_STRATEGY"); v != "" {
+		cfg.Chunking.Strategy = v
+	}
+	if v := os.Getenv("DOCOD_VECTOR_MEMORY_LIMIT_MB"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			cfg.VectorSearch.MemoryLimitMB = n
+		}
+	}
+	if v := os.Getenv("DOCOD_VECTOR_MMAP"); v != "" {
+		cfg.VectorSearch.Mmap = parseBool(v)
+	}
+	if v := os.Getenv("DOCOD_VECTOR_INDEX"); v != "" {
+		cfg.VectorSearch.Index = v
+	}
+	if v := os.Getenv("DOCOD_VECTOR_STORE"); v != "" {
+		cfg.VectorStore.Driver = v
+	}
+	if v := os.Getenv("DOCOD_VECTOR_STORE_URL"); v != "" {
+		cfg.VectorStore.URL = v
+	}
+	if v := os.Getenv("DOCOD_VECTOR_STORE_API_KEY"); v != "" {
+		cfg.VectorStore.APIKey = v
+	}
+	if v := os.Getenv("DOCOD_SHARDING"); v != "" {
+		cfg.Sharding.Enabled = parseBool(v)
+	}
+	if v := os.Getenv("DOCOD_SHARD_WORKERS"); v != "" {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			cfg.Sharding.Workers = n
+		}
+	}
+	if v := os.Getenv("DOCOD_API_KEYS"); v != "" {
+		cfg.Server.APIKeys = splitList(v)
+	}
+	if v := os.Getenv("DOCOD_SLACK_SIGNING_SECRET"); v != "" {
+		cfg.Server.Slack.SigningSecret = v
+	}
+	if v := os.Getenv("DOCOD_CONFLUENCE_BASE_URL"); v != "" {
+		cfg.Publish.Confluence.BaseURL = v
+	}
+	if v := os.Getenv("DOCOD_CONFLUENCE_SPACE_KEY"); v != "" {
+		cfg.Publish.Confluence.SpaceKey = v
+	}
+	if v := os.Getenv("DOCOD_CONFLUENCE_USER"); v != "" {
+		cfg.Publish.Confluence.User = v
+	}
+	if v := os.Getenv("DOCOD_CONFLUENCE_API_TOKEN"); v != "" {
+		cfg.Publish.Confluence.APIToken = v
+	}
+	if v := os.Getenv("DOCOD_NOTION_TOKEN"); v != "" {
+		cfg.Publish.Notion.Token = v
+	}
+	if v := os.Getenv("DOCOD_NOTION_DATABASE_ID"); v != "" {
+		cfg.Publish.Notion.DatabaseID = v
+	}
+	if v := os.Getenv("DOCOD_NOTION_PARENT_PAGE_ID"); v != "" {
+		cfg.Publish.Notion.ParentPageID = v
+	}
+
+	// 5. Resolve cmd:// and keychain:// API keys.
+	cfg.resolveSecrets()
+
+	// 6. Azure OpenAI clients share the resource key unless given their own.
+	if key := cfg.AI.Azure.APIKey; key != "" {
+		if strings.EqualFold(strings.TrimSpace(cfg.AI.EmbeddingProvider), "azure-openai") && strings.TrimSpace(cfg.AI.EmbeddingAPIKey) == "" {
+			cfg.AI.EmbeddingAPIKey = key
+		}
+		if strings.EqualFold(strings.TrimSpace(cfg.AI.LLMProvider), "azure-openai") && strings.TrimSpace(cfg.AI.LLMAPIKey) == "" {
+			cfg.AI.LLMAPIKey = key
+		}
+	}
 
 	return &cfg, nil
 }
 
+// splitList parses a comma-separated env value, dropping empty entries.
+func splitList(v string) []string {
+	var out []string
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
 func parseBool(v string) bool {
 	switch strings.ToLower(strings.TrimSpace(v)) {
 	case "1", "true", "yes", "on":
@@ -0,0 +1,352 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindProjectConfig_SearchesParents(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "internal", "pkg")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "config.yaml"), []byte("{}\n"), 0o644))
+
+	path, ok := FindProjectConfig(sub)
+	require.True(t, ok)
+	assert.Equal(t, filepath.Join(root, "config.yaml"), path)
+
+	// .docod.yaml wins over config.yaml in the same directory.
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".docod.yaml"), []byte("{}\n"), 0o644))
+	path, ok = FindProjectConfig(sub)
+	require.True(t, ok)
+	assert.Equal(t, filepath.Join(root, ".docod.yaml"), path)
+}
+
+func TestLoadConfig_MergesUserDefaults(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("DOCOD_LLM_MODEL", "")
+	require.NoError(t, os.MkdirAll(filepath.Join(xdg, "docod"), 0o755))
+	user := "ai:\n  llm_provider: openai\n  llm_model: user-model\n"
+	require.NoError(t, os.WriteFile(filepath.Join(xdg, "docod", "config.yaml"), []byte(user), 0o644))
+
+	root := t.TempDir()
+	project := "ai:\n  llm_model: project-model\n"
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".docod.yaml"), []byte(project), 0o644))
+	sub := filepath.Join(root, "cmd")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	t.Chdir(sub)
+
+	cfg, err := LoadConfig(DefaultPath)
+	require.NoError(t, err)
+	assert.Equal(t, "openai", cfg.AI.LLMProvider)
+	assert.Equal(t, "project-model", cfg.AI.LLMModel)
+}
+
+func TestLoadConfig_MissingEverywhere(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+
+	_, err := LoadConfig(DefaultPath)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLoadConfig_AppliesProfile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("DOCOD_LLM_MODEL", "")
+	t.Setenv("DOCOD_PROFILE", "")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	data := `ai:
+  llm_provider: gemini
+  llm_model: base-model
+docs:
+  max_llm_sections: 10
+profiles:
+  ci:
+    ai:
+      llm_model: cheap-model
+    docs:
+      max_llm_sections: 2
+`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "base-model", cfg.AI.LLMModel)
+	assert.Equal(t, []string{"ci"}, cfg.ProfileNames())
+
+	t.Setenv("DOCOD_PROFILE", "ci")
+	cfg, err = LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "ci", cfg.Profile)
+	assert.Equal(t, "gemini", cfg.AI.LLMProvider)
+	assert.Equal(t, "cheap-model", cfg.AI.LLMModel)
+	assert.Equal(t, 2, cfg.Docs.MaxLLMSections)
+
+	SetProfile("missing")
+	defer SetProfile("")
+	_, err = LoadConfig(path)
+	assert.ErrorContains(t, err, `unknown config profile "missing" (available: ci)`)
+}
+
+func TestValidateFile_ReportsYAMLPaths(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("DOCOD_PROFILE", "")
+	t.Setenv("DOCOD_EMBEDDING_PROVIDER", "")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `ai:
+  llm_providr: gemini
+  embedding_provider: voyage
+docs:
+  min_confidence_for_llm: 1.5
+profiles:
+  ci:
+    docs:
+      max_llm_sectons: 3
+`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+
+	_, issues, err := ValidateFile(path)
+	require.NoError(t, err)
+	byPath := make(map[string]Issue)
+	for _, i := range issues {
+		byPath[i.Path] = i
+	}
+	assert.Equal(t, 2, byPath["ai.llm_providr"].Line)
+	assert.Contains(t, byPath["ai.llm_providr"].Message, `did you mean "llm_provider"?`)
+	assert.Equal(t, 9, byPath["profiles.ci.docs.max_llm_sectons"].Line)
+	assert.Equal(t, SeverityError, byPath["ai.embedding_provider"].Severity)
+	assert.Equal(t, 5, byPath["docs.min_confidence_for_llm"].Line)
+	assert.Equal(t, SeverityWarning, byPath["ai.llm_api_key"].Severity)
+
+	_, err = LoadConfig(path)
+	var verr *ValidationError
+	require.ErrorAs(t, err, &verr)
+	assert.Len(t, verr.Issues, 4)
+}
+
+func TestLoadConfig_RepoConfigIsValid(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("DOCOD_PROFILE", "")
+	cfg, err := LoadConfig(filepath.Join("..", "..", "config.yaml"))
+	require.NoError(t, err)
+	for _, name := range cfg.ProfileNames() {
+		SetProfile(name)
+		_, err := LoadConfig(filepath.Join("..", "..", "config.yaml"))
+		assert.NoError(t, err, name)
+	}
+	SetProfile("")
+}
+
+func TestLoadConfig_ResolvesSecretReferences(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("DOCOD_PROFILE", "")
+	t.Setenv("DOCOD_LLM_API_KEY", "")
+	t.Setenv("DOCOD_RERANK_API_KEY", "cmd://exit 3")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "ai:\n  llm_provider: openai\n  llm_api_key: \"cmd://printf 'sk-%s\\\\n' test\"\n"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+
+	cfg, issues, err := ValidateFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-test", cfg.AI.LLMAPIKey)
+	errs := Errors(issues)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "ai.rerank_api_key", errs[0].Path)
+	assert.Contains(t, errs[0].Message, "secret command")
+
+	assert.True(t, IsSecretRef("keychain://docod/openai"))
+	_, err = ResolveSecret("keychain://docod")
+	assert.ErrorContains(t, err, "keychain://service/account")
+}
+
+func TestLoadConfig_RefusesCommandsFromDiscoveredConfig(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("DOCOD_PROFILE", "")
+	t.Setenv("DOCOD_LLM_API_KEY", "")
+	t.Setenv("DOCOD_RERANK_API_KEY", "")
+	t.Setenv(TrustProjectConfigEnv, "")
+	require.NoError(t, os.MkdirAll(filepath.Join(xdg, "docod"), 0o755))
+	user := "ai:\n  rerank_api_key: \"cmd://printf user-key\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(xdg, "docod", "config.yaml"), []byte(user), 0o644))
+
+	root := t.TempDir()
+	marker := filepath.Join(root, "ran")
+	project := "ai:\n  llm_provider: openai\n  llm_api_key: \"cmd://touch " + marker + "; printf project-key\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(root, "config.yaml"), []byte(project), 0o644))
+	sub := filepath.Join(root, "cmd")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	t.Chdir(sub)
+
+	cfg, err := load(DefaultPath)
+	require.NoError(t, err)
+	assert.Equal(t, "user-key", cfg.AI.RerankAPIKey, "the user config is trusted")
+	assert.Empty(t, cfg.AI.LLMAPIKey)
+	assert.NoFileExists(t, marker)
+	errs := Errors(cfg.issues)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "ai.llm_api_key", errs[0].Path)
+	assert.Contains(t, errs[0].Message, TrustProjectConfigEnv)
+
+	t.Setenv(TrustProjectConfigEnv, "1")
+	cfg, err = LoadConfig(DefaultPath)
+	require.NoError(t, err)
+	assert.Equal(t, "project-key", cfg.AI.LLMAPIKey)
+}
+
+func TestLoadConfig_AzureSharesResourceKey(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("DOCOD_PROFILE", "")
+	t.Setenv("DOCOD_EMBEDDING_API_KEY", "")
+	t.Setenv("DOCOD_LLM_API_KEY", "")
+	t.Setenv("DOCOD_AZURE_OPENAI_API_KEY", "azure-key")
+	t.Setenv("DOCOD_AZURE_LLM_DEPLOYMENT", "gpt-4o-docs")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "ai:\n  embedding_provider: azure-openai\n  llm_provider: azure-openai\n  llm_api_key: own-key\n"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+
+	cfg, issues, err := ValidateFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "azure-key", cfg.AI.EmbeddingAPIKey)
+	assert.Equal(t, "own-key", cfg.AI.LLMAPIKey, "a per-client key wins")
+	assert.Equal(t, "gpt-4o-docs", cfg.AI.Azure.LLMDeployment)
+	require.Len(t, issues, 1)
+	assert.Equal(t, "ai.azure.endpoint", issues[0].Path)
+}
+
+func TestLive_ReloadSwapsValidConfigs(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("DOCOD_PROFILE", "")
+	t.Setenv("DOCOD_LLM_MODEL", "")
+	t.Setenv("DOCOD_LLM_API_KEY", "")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("ai:\n  llm_model: a\n  llm_api_key: secret-a\n"), 0o644))
+
+	live, err := NewLive(path)
+	require.NoError(t, err)
+	changes, err := live.Reload()
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	var notified []Change
+	live.OnChange(func(_, _ *Config, changes []Change) { notified = changes })
+	require.NoError(t, os.WriteFile(path, []byte("ai:\n  llm_model: b\n  llm_api_key: secret-b\n"), 0o644))
+	changes, err = live.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Path: "ai.llm_model", Old: "a", New: "b"},
+		{Path: "ai.llm_api_key", Old: "(redacted)", New: "(redacted)"},
+	}, changes)
+	assert.Equal(t, changes, notified)
+	assert.Equal(t, "b", live.Current().AI.LLMModel)
+
+	// An invalid edit keeps the previous config.
+	require.NoError(t, os.WriteFile(path, []byte("ai:\n  llm_modle: c\n"), 0o644))
+	_, err = live.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, "b", live.Current().AI.LLMModel)
+}
+
+func TestLoadConfig_ExpandsEnvReferences(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("DOCOD_PROFILE", "")
+	t.Setenv("DOCOD_EMBEDDING_DIMENSION", "")
+	t.Setenv("OLLAMA_HOST", "http://gpu-box:11434")
+	t.Setenv("EMBED_DIM", "1024")
+	t.Setenv("DOCOD_TEST_UNSET", "")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `ai:
+  embedding_provider: ollama
+  ollama_base_url: "${OLLAMA_HOST}"
+  embedding_dimension: ${EMBED_DIM}
+  llm_base_url: ${DOCOD_TEST_UNSET:-http://localhost:8080}/v1
+scope:
+  exclude_paths: ["${DOCOD_TEST_UNSET}gen/", "$${literal}"]
+`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+
+	cfg, issues, err := ValidateFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "http://gpu-box:11434", cfg.AI.OllamaBaseURL)
+	assert.Equal(t, 1024, cfg.AI.EmbeddingDim)
+	assert.Equal(t, "http://localhost:8080/v1", cfg.AI.LLMBaseURL)
+	assert.Equal(t, []string{"gen/", "${literal}"}, cfg.Scope.ExcludePaths)
+
+	var unset []Issue
+	for _, i := range issues {
+		if i.Path == "scope.exclude_paths[0]" {
+			unset = append(unset, i)
+		}
+	}
+	require.Len(t, unset, 1)
+	assert.Equal(t, 7, unset[0].Line)
+}
+
+func TestOutputPaths_DefaultsAndOverrides(t *testing.T) {
+	p := DefaultOutputPaths()
+	assert.Equal(t, filepath.Join("docs", "documentation.md"), p.Doc())
+	assert.Equal(t, filepath.Join("docs", "doc_model.json"), p.Model())
+	assert.Equal(t, filepath.Join("docs", "pipeline_report.json"), p.Report())
+
+	cfg := &Config{}
+	cfg.Output.Dir = "site/"
+	cfg.Output.DocFile = "index.md"
+	cfg.Output.ReportsDir = "build/reports"
+	p = cfg.OutputPaths()
+	assert.Equal(t, filepath.Join("site", "index.md"), p.Doc())
+	assert.Equal(t, filepath.Join("site", "doc_model.json"), p.Model())
+	assert.Equal(t, filepath.Join("build", "reports", "impact_report.json"), p.ImpactReport())
+	assert.Equal(t, filepath.Join("site", "changes.atom"), p.Feed(), "the feed stays with the docs")
+
+	moved := p.WithDir("out")
+	assert.Equal(t, filepath.Join("out", "index.md"), moved.Doc())
+	assert.Equal(t, filepath.Join("build", "reports", "pipeline_report.json"), moved.Report())
+	assert.Equal(t, filepath.Join("out", "impact_report.md"), DefaultOutputPaths().WithDir("out").ImpactSummary())
+}
+
+func TestSchemaJSON_MatchesPublishedSchema(t *testing.T) {
+	data, err := SchemaJSON()
+	require.NoError(t, err)
+	published, err := os.ReadFile(filepath.Join("..", "..", "docs", "config.schema.json"))
+	require.NoError(t, err)
+	assert.Equal(t, string(published), string(data), "run `docod config schema -o docs/config.schema.json`")
+}
+
+func TestValidateFile_ChecksValuesAgainstSchema(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("DOCOD_PROFILE", "")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := `ai:
+  llm_model: 4
+  embedding_dimension: large
+scope:
+  exclude_tests: sometimes
+  exclude_paths: vendor/
+`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+
+	_, issues, err := ValidateFile(path)
+	require.NoError(t, err)
+	byPath := make(map[string]Issue)
+	for _, i := range Errors(issues) {
+		byPath[i.Path] = i
+	}
+	assert.Len(t, byPath, 3)
+	assert.Equal(t, 3, byPath["ai.embedding_dimension"].Line)
+	assert.Contains(t, byPath["ai.embedding_dimension"].Message, "expected integer")
+	assert.Equal(t, 5, byPath["scope.exclude_tests"].Line)
+	assert.Contains(t, byPath["scope.exclude_paths"].Message, "expected array")
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// expandEnv replaces ${VAR} and ${VAR:-default} with environment values.
+// $${ is a literal ${. It also returns the referenced variables that are
+// unset and have no default.
+func expandEnv(s string) (string, []string) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var sb strings.Builder
+	var missing []string
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$${") {
+			sb.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(s[i:], "${") {
+			sb.WriteByte(s[i])
+			i++
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			sb.WriteString(s[i:])
+			break
+		}
+		expr := s[i+2 : i+end]
+		name, def, hasDefault := strings.Cut(expr, ":-")
+		if v, ok := os.LookupEnv(name); ok && v != "" {
+			sb.WriteString(v)
+		} else if hasDefault {
+			sb.WriteString(def)
+		} else {
+			missing = append(missing, name)
+		}
+		i += end + 1
+	}
+	return sb.String(), missing
+}
+
+// expandScalar expands env references in a scalar node in place. Non-string
+// values are re-resolved, so `embedding_dimension: ${DIM}` decodes as a
+// number.
+func (c *Config) expandScalar(file string, node *yaml.Node, stringField bool, path string) {
+	expanded, missing := expandEnv(node.Value)
+	for _, name := range missing {
+		c.issues = append(c.issues, Issue{Severity: SeverityWarning, Path: path, File: file, Line: node.Line, Message: "references unset environment variable " + name + "; use ${" + name + ":-default} for a fallback"})
+	}
+	if expanded == node.Value {
+		return
+	}
+	node.Value = expanded
+	if !stringField {
+		node.Tag = ""
+		node.Style = 0
+	}
+}
@@ -0,0 +1,126 @@
+package config
+
+import "path/filepath"
+
+// Default output locations, relative to the project root.
+const (
+	DefaultOutputDir = "docs"
+	DefaultDocFile   = "documentation.md"
+	DefaultModelFile = "doc_model.json"
+
+	ReportFile        = "pipeline_report.json"
+	ImpactReportFile  = "impact_report.json"
+	ImpactSummaryFile = "impact_report.md"
+	DefaultFeedFile   = "changes.atom"
+	CheckpointFile    = "generate_checkpoint.json"
+)
+
+// OutputPaths locates the generated documentation and reports.
+type OutputPaths struct {
+	// Dir holds the documentation and the doc model.
+	Dir       string
+	DocFile   string
+	ModelFile string
+	// ReportsDir holds the pipeline and impact reports; empty means Dir.
+	ReportsDir string
+	// FeedFile is the Atom feed of documentation changes inside Dir.
+	FeedFile string
+}
+
+// DefaultOutputPaths writes everything under docs/.
+func DefaultOutputPaths() OutputPaths {
+	return OutputPaths{Dir: DefaultOutputDir, DocFile: DefaultDocFile, ModelFile: DefaultModelFile, FeedFile: DefaultFeedFile}
+}
+
+// OutputPaths returns the configured output locations; empty fields keep the
+// defaults.
+func (c *Config) OutputPaths() OutputPaths {
+	p := DefaultOutputPaths()
+	if c == nil {
+		return p
+	}
+	if c.Output.Dir != "" {
+		p.Dir = filepath.Clean(c.Output.Dir)
+	}
+	if c.Output.DocFile != "" {
+		p.DocFile = c.Output.DocFile
+	}
+	if c.Output.ModelFile != "" {
+		p.ModelFile = c.Output.ModelFile
+	}
+	if c.Output.ReportsDir != "" {
+		p.ReportsDir = filepath.Clean(c.Output.ReportsDir)
+	}
+	if c.Output.FeedFile != "" {
+		p.FeedFile = c.Output.FeedFile
+	}
+	return p
+}
+
+// ResolveOutputPaths reads the output locations from the project config,
+// falling back to the defaults when it cannot be loaded.
+func ResolveOutputPaths() OutputPaths {
+	cfg, err := LoadConfig(DefaultPath)
+	if err != nil {
+		return DefaultOutputPaths()
+	}
+	return cfg.OutputPaths()
+}
+
+// WithDir moves the documentation and doc model to dir. Reports follow unless
+// they have their own directory.
+func (p OutputPaths) WithDir(dir string) OutputPaths {
+	p.Dir = dir
+	return p
+}
+
+// Doc is the path of the rendered documentation.
+func (p OutputPaths) Doc() string {
+	return joinOutput(p.Dir, p.DocFile)
+}
+
+// Model is the path of the doc model JSON.
+func (p OutputPaths) Model() string {
+	return joinOutput(p.Dir, p.ModelFile)
+}
+
+// Feed is the path of the Atom feed of documentation changes.
+func (p OutputPaths) Feed() string {
+	return joinOutput(p.Dir, p.FeedFile)
+}
+
+// Checkpoint is the path of the section checkpoint of an unfinished full
+// generation.
+func (p OutputPaths) Checkpoint() string {
+	return joinOutput(p.Dir, CheckpointFile)
+}
+
+// Report is the path of pipeline_report.json.
+func (p OutputPaths) Report() string {
+	return joinOutput(p.reportsDir(), ReportFile)
+}
+
+// ImpactReport is the path of impact_report.json.
+func (p OutputPaths) ImpactReport() string {
+	return joinOutput(p.reportsDir(), ImpactReportFile)
+}
+
+// ImpactSummary is the path of the Markdown impact summary.
+func (p OutputPaths) ImpactSummary() string {
+	return joinOutput(p.reportsDir(), ImpactSummaryFile)
+}
+
+func (p OutputPaths) reportsDir() string {
+	if p.ReportsDir != "" {
+		return p.ReportsDir
+	}
+	return p.Dir
+}
+
+// joinOutput keeps absolute file names as they are.
+func joinOutput(dir, file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(dir, file)
+}
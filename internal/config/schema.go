@@ -0,0 +1,227 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	jsonschema "github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaID identifies the config schema; docs/config.schema.json is the
+// published copy, referenced from config.yaml for yaml-language-server.
+const SchemaID = "https://docod.dev/schema/config.schema.json"
+
+// schemaEnums lists the values editors offer for enumerated keys. Map keys
+// appear as * and sequence items as [] in these paths.
+var schemaEnums = map[string][]string{
+	"project.symlink_policy":           symlinkPolicies,
+	"ai.embedding_provider":            embeddingProviders,
+	"ai.llm_provider":                  llmProviders,
+	"ai.rerank_provider":               rerankProviders,
+	"ai.tokenizer":                     tokenizers,
+	"ai.embedding_tokenizer":           tokenizers,
+	"docs.diversity":                   diversityModes,
+	"docs.preset":                      docsPresets,
+	"docs.style.diagrams[]":            diagramTypes,
+	"docs.section_styles.*.diagrams[]": diagramTypes,
+	"chunking.strategy":                chunkingStrategies,
+	"chunking.languages.*":             chunkingStrategies,
+}
+
+// Schema returns the JSON Schema of the config file, derived from the yaml
+// tags of Config. Profiles accept any part of the config.
+func Schema() map[string]any {
+	s := schemaFor(configType, "")
+	s["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	s["$id"] = SchemaID
+	s["title"] = "Docod Config"
+	s["description"] = "Project and user-level configuration for docod (config.yaml, .docod.yaml)."
+	return s
+}
+
+// SchemaJSON renders Schema as indented JSON, as published in
+// docs/config.schema.json.
+func SchemaJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(Schema()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func schemaFor(t reflect.Type, path string) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == yamlNodeType {
+		return map[string]any{"$ref": "#"}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		props := make(map[string]any)
+		for name, f := range yamlFields(t) {
+			props[name] = schemaFor(f.Type, joinPath(path, name))
+		}
+		return map[string]any{"type": "object", "properties": props, "additionalProperties": false}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem(), joinPath(path, "*"))}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem(), path+"[]")}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		s := map[string]any{"type": "string"}
+		if values, ok := schemaEnums[path]; ok {
+			// Empty keeps the default, so it is allowed as well.
+			s["enum"] = append([]string{""}, values...)
+		}
+		return s
+	}
+}
+
+var (
+	compiledSchemaOnce sync.Once
+	compiledSchema     *jsonschema.Schema
+	compiledSchemaErr  error
+)
+
+func loadSchema() (*jsonschema.Schema, error) {
+	compiledSchemaOnce.Do(func() {
+		data, err := SchemaJSON()
+		if err != nil {
+			compiledSchemaErr = err
+			return
+		}
+		compiler := jsonschema.NewCompiler()
+		if err := compiler.AddResource(SchemaID, bytes.NewReader(data)); err != nil {
+			compiledSchemaErr = err
+			return
+		}
+		compiledSchema, compiledSchemaErr = compiler.Compile(SchemaID)
+	})
+	return compiledSchema, compiledSchemaErr
+}
+
+// checkSchema validates a decoded file against Schema and records violations
+// as issues at their YAML paths. Unknown keys and enum values are left to
+// walk and Validate, which add hints and accept any letter case. It reports
+// whether the document is safe to decode into Config.
+func (c *Config) checkSchema(file string, root *yaml.Node) bool {
+	schema, err := loadSchema()
+	if err != nil {
+		c.issues = append(c.issues, Issue{Severity: SeverityError, File: file, Message: fmt.Sprintf("config schema: %v", err)})
+		return true
+	}
+	var raw any
+	if err := root.Decode(&raw); err != nil {
+		// Decode reports the same problem with more context.
+		return true
+	}
+	data, err := json.Marshal(dropNulls(raw))
+	if err != nil {
+		return true
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return true
+	}
+	verr, ok := schema.Validate(doc).(*jsonschema.ValidationError)
+	if !ok {
+		return true
+	}
+	clean := true
+	for _, e := range leafErrors(verr) {
+		kw := e.KeywordLocation[strings.LastIndex(e.KeywordLocation, "/")+1:]
+		if kw == "additionalProperties" || kw == "enum" {
+			continue
+		}
+		path, line, node := yamlPathAt(root, e.InstanceLocation)
+		if kw == "type" && node != nil && node.Kind == yaml.ScalarNode && strings.HasPrefix(e.Message, "expected string") {
+			// Any scalar decodes into a string field, e.g. `llm_model: 4`.
+			continue
+		}
+		c.issues = append(c.issues, Issue{Severity: SeverityError, Path: path, File: file, Line: line, Message: e.Message})
+		clean = false
+	}
+	return clean
+}
+
+func leafErrors(e *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(e.Causes) == 0 {
+		return []*jsonschema.ValidationError{e}
+	}
+	var out []*jsonschema.ValidationError
+	for _, cause := range e.Causes {
+		out = append(out, leafErrors(cause)...)
+	}
+	return out
+}
+
+// yamlPathAt converts a JSON pointer into the YAML path and line of the value
+// it points at, along with its node.
+func yamlPathAt(root *yaml.Node, pointer string) (string, int, *yaml.Node) {
+	path, line := "", 0
+	node := root
+	for _, seg := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if seg == "" || node == nil {
+			continue
+		}
+		seg = strings.NewReplacer("~1", "/", "~0", "~").Replace(seg)
+		switch node.Kind {
+		case yaml.MappingNode:
+			path = joinPath(path, seg)
+			var next *yaml.Node
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == seg {
+					line, next = node.Content[i].Line, node.Content[i+1]
+				}
+			}
+			node = next
+		case yaml.SequenceNode:
+			path += "[" + seg + "]"
+			i, err := strconv.Atoi(seg)
+			if err != nil || i >= len(node.Content) {
+				node = nil
+				continue
+			}
+			node = node.Content[i]
+			line = node.Line
+		default:
+			node = nil
+		}
+	}
+	return path, line, node
+}
+
+// dropNulls removes empty YAML values such as `chain:` with nothing after
+// it, which decode to the field's zero value.
+func dropNulls(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			if item == nil {
+				delete(v, k)
+				continue
+			}
+			v[k] = dropNulls(item)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = dropNulls(item)
+		}
+	}
+	return v
+}
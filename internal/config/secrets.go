@@ -0,0 +1,174 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Secret reference schemes accepted in place of literal API keys.
+const (
+	// SecretCommand runs the rest of the value as a command and uses its
+	// trimmed stdout, e.g. cmd://op read op://vault/openai/key. A project
+	// config found by searching up from the working directory may come with
+	// a cloned repository, so its commands only run when the user sets
+	// DOCOD_TRUST_PROJECT_CONFIG; the user config, a config loaded by path
+	// and environment variables are trusted.
+	SecretCommand = "cmd://"
+	// SecretKeychain reads service/account from the OS keychain, e.g.
+	// keychain://docod/openai.
+	SecretKeychain = "keychain://"
+)
+
+// TrustProjectConfigEnv lets a discovered project config run cmd:// secrets
+// when set to a true value.
+const TrustProjectConfigEnv = "DOCOD_TRUST_PROJECT_CONFIG"
+
+func trustProjectConfig() bool {
+	trusted, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv(TrustProjectConfigEnv)))
+	return trusted
+}
+
+// secretTimeout bounds a single secret lookup.
+const secretTimeout = 30 * time.Second
+
+var (
+	secretMu    sync.Mutex
+	secretCache = make(map[string]string)
+)
+
+// IsSecretRef reports whether v is a cmd:// or keychain:// reference.
+func IsSecretRef(v string) bool {
+	v = strings.TrimSpace(v)
+	return strings.HasPrefix(v, SecretCommand) || strings.HasPrefix(v, SecretKeychain)
+}
+
+// ResolveSecret returns the secret a reference points at; other values are
+// returned unchanged. Lookups are cached for the life of the process, so a
+// password manager prompts at most once per run.
+func ResolveSecret(v string) (string, error) {
+	ref := strings.TrimSpace(v)
+	if !IsSecretRef(ref) {
+		return v, nil
+	}
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	if s, ok := secretCache[ref]; ok {
+		return s, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if rest, ok := strings.CutPrefix(ref, SecretCommand); ok {
+		if strings.TrimSpace(rest) == "" {
+			return "", fmt.Errorf("empty command in %q", ref)
+		}
+		cmd = shellCommand(ctx, rest)
+	} else {
+		service, account, ok := strings.Cut(strings.TrimPrefix(ref, SecretKeychain), "/")
+		if !ok || service == "" || account == "" {
+			return "", fmt.Errorf("keychain reference %q must be keychain://service/account", ref)
+		}
+		c, err := keychainCommand(ctx, service, account)
+		if err != nil {
+			return "", err
+		}
+		cmd = c
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", schemeOf(ref), err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", schemeOf(ref), err)
+	}
+	secret := strings.TrimRight(string(out), "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("%s returned an empty secret", schemeOf(ref))
+	}
+	secretCache[ref] = secret
+	return secret, nil
+}
+
+func shellCommand(ctx context.Context, line string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", line)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", line)
+}
+
+// keychainCommand reads a generic password with the platform's keychain tool:
+// security on macOS and secret-tool (libsecret) on Linux.
+func keychainCommand(ctx context.Context, service, account string) (*exec.Cmd, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.CommandContext(ctx, "security", "find-generic-password", "-s", service, "-a", account, "-w"), nil
+	case "linux", "freebsd", "openbsd":
+		return exec.CommandContext(ctx, "secret-tool", "lookup", "service", service, "account", account), nil
+	default:
+		return nil, fmt.Errorf("keychain:// is not supported on %s; use cmd:// instead", runtime.GOOS)
+	}
+}
+
+// schemeOf names the reference without its arguments, which may be sensitive.
+func schemeOf(ref string) string {
+	if strings.HasPrefix(ref, SecretKeychain) {
+		return "keychain lookup"
+	}
+	return "secret command"
+}
+
+// resolveSecrets replaces secret references in the API key and token fields
+// and the server API keys, recording failures as issues at their YAML paths.
+// cmd:// references from an untrusted project config are refused.
+func (c *Config) resolveSecrets() {
+	fields := []struct {
+		path  string
+		value *string
+	}{
+		{"ai.embedding_api_key", &c.AI.EmbeddingAPIKey},
+		{"ai.llm_api_key", &c.AI.LLMAPIKey},
+		{"ai.rerank_api_key", &c.AI.RerankAPIKey},
+		{"ai.azure.api_key", &c.AI.Azure.APIKey},
+		{"vector_store.api_key", &c.VectorStore.APIKey},
+		{"server.slack.signing_secret", &c.Server.Slack.SigningSecret},
+		{"publish.confluence.api_token", &c.Publish.Confluence.APIToken},
+		{"publish.notion.token", &c.Publish.Notion.Token},
+	}
+	for i := range c.Server.APIKeys {
+		fields = append(fields, struct {
+			path  string
+			value *string
+		}{fmt.Sprintf("server.api_keys[%d]", i), &c.Server.APIKeys[i]})
+	}
+	for _, f := range fields {
+		src, ok := c.sources[f.path]
+		if base, _, indexed := strings.Cut(f.path, "["); !ok && indexed {
+			src = c.sources[base]
+		}
+		var secret string
+		var err error
+		if c.untrusted != "" && src.file == c.untrusted && strings.HasPrefix(strings.TrimSpace(*f.value), SecretCommand) {
+			err = fmt.Errorf("cmd:// secrets do not run from a discovered project config; move the reference to %s or set %s=1 to trust %s", UserConfigPath(), TrustProjectConfigEnv, src.file)
+		} else {
+			secret, err = ResolveSecret(*f.value)
+		}
+		if err != nil {
+			c.issues = append(c.issues, Issue{Severity: SeverityError, Path: f.path, File: src.file, Line: src.line, Message: err.Error()})
+			*f.value = ""
+			continue
+		}
+		*f.value = secret
+	}
+}
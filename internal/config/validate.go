@@ -0,0 +1,383 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity tells whether an issue stops LoadConfig.
+type Severity string
+
+const (
+	// SeverityError issues make LoadConfig fail.
+	SeverityError Severity = "error"
+	// SeverityWarning issues are only reported by `docod config validate`,
+	// since commands that never call a provider still work.
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a config problem located at a YAML path such as ai.llm_provider.
+type Issue struct {
+	Severity Severity
+	Path     string
+	// File and Line locate the key; both are empty when the value came from
+	// the environment or a default.
+	File    string
+	Line    int
+	Message string
+}
+
+func (i Issue) String() string {
+	loc := ""
+	if i.File != "" {
+		loc = fmt.Sprintf("%s:%d: ", i.File, i.Line)
+	}
+	return fmt.Sprintf("%s%s: %s", loc, i.Path, i.Message)
+}
+
+// ValidationError lists the issues that made LoadConfig fail.
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Issues))
+	for _, i := range e.Issues {
+		lines = append(lines, i.String())
+	}
+	return "invalid config:\n  " + strings.Join(lines, "\n  ")
+}
+
+// source is where a YAML path was last set.
+type source struct {
+	file string
+	line int
+}
+
+var (
+	embeddingProviders = []string{"gemini", "openai", "azure-openai", "ollama", "local"}
+	llmProviders       = []string{"gemini", "openai", "azure-openai", "anthropic"}
+	rerankProviders    = []string{"none", "cohere", "tei"}
+	chunkingStrategies = []string{"lines", "tokens", "ast", "none"}
+	shardStrategies    = []string{"directory", "module"}
+	vectorIndexes      = []string{"flat", "hnsw"}
+	vectorStores       = []string{"sqlite", "memory", "qdrant", "pgvector"}
+	symlinkPolicies    = []string{"files", "skip", "follow"}
+	diversityModes     = []string{"file", "mmr"}
+	diagramTypes       = []string{"flowchart", "sequence", "class", "er", "state", "none"}
+	docsPresets        = []string{"library", "service", "cli", "sdk"}
+	tokenizers         = []string{"cl100k", "o200k", "sentencepiece", "chars"}
+)
+
+// ValidateFile loads the config like LoadConfig but returns every issue,
+// warnings included, instead of failing on errors. The error is only set when
+// a file cannot be read or parsed.
+func ValidateFile(path string) (*Config, []Issue, error) {
+	cfg, err := load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, cfg.Validate(), nil
+}
+
+// Validate returns the unknown keys found while decoding and the values that
+// are out of range, unsupported or missing for the chosen providers.
+func (c *Config) Validate() []Issue {
+	issues := append([]Issue(nil), c.issues...)
+	add := func(sev Severity, path, format string, args ...any) {
+		src, ok := c.sources[path]
+		if base, _, indexed := strings.Cut(path, "["); !ok && indexed {
+			src = c.sources[base]
+		}
+		issues = append(issues, Issue{Severity: sev, Path: path, File: src.file, Line: src.line, Message: fmt.Sprintf(format, args...)})
+	}
+	oneOf := func(path, value string, allowed []string) {
+		v := strings.ToLower(strings.TrimSpace(value))
+		if v == "" {
+			return
+		}
+		for _, a := range allowed {
+			if v == a {
+				return
+			}
+		}
+		add(SeverityError, path, "unsupported value %q (%s)", value, strings.Join(allowed, "|"))
+	}
+	between := func(path string, v, lo, hi float64) {
+		if v < lo || v > hi {
+			add(SeverityError, path, "%g is out of range [%g, %g]", v, lo, hi)
+		}
+	}
+	atLeast := func(path string, v, lo int) {
+		if v < lo {
+			add(SeverityError, path, "%d must be >= %d", v, lo)
+		}
+	}
+
+	oneOf("project.symlink_policy", c.Project.SymlinkPolicy, symlinkPolicies)
+	atLeast("project.scan_concurrency", c.Project.ScanConcurrency, 0)
+
+	ai := c.AI
+	oneOf("ai.embedding_provider", ai.EmbeddingProvider, embeddingProviders)
+	oneOf("ai.llm_provider", ai.LLMProvider, llmProviders)
+	oneOf("ai.rerank_provider", ai.RerankProvider, rerankProviders)
+	atLeast("ai.embedding_dimension", ai.EmbeddingDim, 0)
+	atLeast("ai.llm_context_window", ai.LLMContextWindow, 0)
+	atLeast("ai.llm_max_output_tokens", ai.LLMMaxOutput, 0)
+	if ai.LLMContextWindow > 0 && ai.LLMMaxOutput >= ai.LLMContextWindow {
+		add(SeverityError, "ai.llm_max_output_tokens", "%d leaves no room for the prompt in llm_context_window %d", ai.LLMMaxOutput, ai.LLMContextWindow)
+	}
+	oneOf("ai.tokenizer", ai.Tokenizer, tokenizers)
+	oneOf("ai.embedding_tokenizer", ai.EmbeddingTokenizer, tokenizers)
+	atLeast("ai.embedding_max_tokens", ai.EmbeddingMaxTokens, 0)
+	atLeast("ai.requests_per_minute", ai.RequestsPerMinute, 0)
+	for _, model := range sortedKeys(ai.Pricing) {
+		p := ai.Pricing[model]
+		if p.InputPerMillion < 0 || p.OutputPerMillion < 0 {
+			add(SeverityError, "ai.pricing."+model, "prices must be >= 0")
+		}
+	}
+	atLeast("ai.query_cache_max_entries", ai.QueryCacheMax, 0)
+	atLeast("ai.query_cache_ttl_hours", ai.QueryCacheTTLHrs, -1)
+
+	embedding := providerOrDefault(ai.EmbeddingProvider)
+	if (embedding == "gemini" || embedding == "openai" || embedding == "azure-openai") && strings.TrimSpace(ai.EmbeddingAPIKey) == "" {
+		add(SeverityWarning, "ai.embedding_api_key", "required for embedding_provider %s; set it here or via DOCOD_EMBEDDING_API_KEY", embedding)
+	}
+	if (embedding == "ollama" || embedding == "local") && strings.TrimSpace(ai.EmbeddingAPIKey) != "" {
+		add(SeverityWarning, "ai.embedding_api_key", "ignored by embedding_provider %s", embedding)
+	}
+	if embedding == "local" {
+		if strings.TrimSpace(ai.EmbeddingModel) == "" {
+			add(SeverityWarning, "ai.embedding_model", "required for embedding_provider local; name the directory holding model.onnx and vocab.txt")
+		}
+		if ai.EmbeddingDim <= 0 {
+			add(SeverityWarning, "ai.embedding_dimension", "required for embedding_provider local; use the model's hidden size (384 for all-MiniLM-L6-v2)")
+		}
+	}
+	atLeast("ai.local.max_sequence_length", ai.Local.MaxSequenceLength, 0)
+	if embedding != "openai" && strings.TrimSpace(ai.OpenAIBaseURL) != "" {
+		add(SeverityWarning, "ai.openai_base_url", "only used by embedding_provider openai, not %s", embedding)
+	}
+	llm := providerOrDefault(ai.LLMProvider)
+	if (llm == "gemini" || llm == "openai" || llm == "azure-openai" || llm == "anthropic") && strings.TrimSpace(ai.LLMAPIKey) == "" {
+		add(SeverityWarning, "ai.llm_api_key", "required for llm_provider %s; set it here or via DOCOD_LLM_API_KEY", llm)
+	}
+	if (embedding == "azure-openai" || llm == "azure-openai") && strings.TrimSpace(ai.Azure.Endpoint) == "" {
+		add(SeverityWarning, "ai.azure.endpoint", "required for the azure-openai provider; set it here or via DOCOD_AZURE_OPENAI_ENDPOINT")
+	}
+	if llm != "openai" && llm != "anthropic" && strings.TrimSpace(ai.LLMBaseURL) != "" {
+		add(SeverityWarning, "ai.llm_base_url", "only used by llm_provider openai or anthropic, not %s", llm)
+	}
+	switch strings.ToLower(strings.TrimSpace(ai.RerankProvider)) {
+	case "cohere":
+		if strings.TrimSpace(ai.RerankAPIKey) == "" {
+			add(SeverityWarning, "ai.rerank_api_key", "required for rerank_provider cohere; set it here or via DOCOD_RERANK_API_KEY")
+		}
+	case "tei":
+		if strings.TrimSpace(ai.RerankModel) != "" {
+			add(SeverityWarning, "ai.rerank_model", "ignored by rerank_provider tei, which serves the model it was started with")
+		}
+	}
+
+	docs := c.Docs
+	atLeast("docs.max_llm_sections", docs.MaxLLMSections, 0)
+	atLeast("docs.max_llm_routes", docs.MaxLLMRoutes, 0)
+	atLeast("docs.max_embed_chunks_per_run", docs.MaxEmbedChunksPerRun, 0)
+	atLeast("docs.max_summaries_per_run", docs.MaxSummariesPerRun, 0)
+	atLeast("docs.section_concurrency", docs.SectionConcurrency, 0)
+	between("docs.min_confidence_for_llm", docs.MinConfidenceForLLM, 0, 1)
+	between("docs.mmr_lambda", docs.MMRLambda, 0, 1)
+	oneOf("docs.diversity", docs.Diversity, diversityModes)
+	oneOf("docs.preset", docs.Preset, docsPresets)
+	for i, d := range docs.Style.Diagrams {
+		oneOf(fmt.Sprintf("docs.style.diagrams[%d]", i), d, diagramTypes)
+	}
+	for _, id := range sortedKeys(docs.SectionStyles) {
+		for i, d := range docs.SectionStyles[id].Diagrams {
+			oneOf(fmt.Sprintf("docs.section_styles.%s.diagrams[%d]", id, i), d, diagramTypes)
+		}
+	}
+
+	for _, name := range sortedKeys(c.Resolvers.MinConfidence) {
+		between("resolvers.min_confidence."+name, c.Resolvers.MinConfidence[name], 0, 1)
+	}
+	between("resolvers.min_edge_confidence", c.Resolvers.MinEdgeConfidence, 0, 1)
+
+	atLeast("feed.max_entries", c.Feed.MaxEntries, 0)
+
+	atLeast("planner.max_hops", c.Planner.MaxHops, 0)
+	if c.Planner.HopDecay > 1 {
+		add(SeverityError, "planner.hop_decay", "%g would grow scores with every hop; use a value in (0, 1]", c.Planner.HopDecay)
+	}
+
+	atLeast("vector_search.page_size", c.VectorSearch.PageSize, 0)
+	atLeast("vector_search.memory_limit_mb", c.VectorSearch.MemoryLimitMB, 0)
+	atLeast("vector_search.workers", c.VectorSearch.Workers, 0)
+	oneOf("vector_search.index", c.VectorSearch.Index, vectorIndexes)
+	if c.VectorSearch.HNSWM != 0 {
+		atLeast("vector_search.hnsw_m", c.VectorSearch.HNSWM, 2)
+	}
+	atLeast("vector_search.ef_search", c.VectorSearch.EfSearch, 0)
+	oneOf("vector_store.driver", c.VectorStore.Driver, vectorStores)
+	switch strings.ToLower(strings.TrimSpace(c.VectorStore.Driver)) {
+	case "qdrant", "pgvector":
+		if strings.TrimSpace(c.VectorStore.URL) == "" {
+			add(SeverityWarning, "vector_store.url", "required for vector_store.driver %s; set it here or via DOCOD_VECTOR_STORE_URL", c.VectorStore.Driver)
+		}
+	}
+
+	oneOf("sharding.by", c.Sharding.By, shardStrategies)
+	atLeast("sharding.depth", c.Sharding.Depth, 0)
+	atLeast("sharding.min_symbols", c.Sharding.MinSymbols, 0)
+	atLeast("sharding.workers", c.Sharding.Workers, 0)
+	atLeast("sharding.max_chunks_per_shard", c.Sharding.MaxChunksPerShard, 0)
+
+	ch := c.Chunking
+	oneOf("chunking.strategy", ch.Strategy, chunkingStrategies)
+	for _, lang := range sortedKeys(ch.Languages) {
+		oneOf("chunking.languages."+lang, ch.Languages[lang], chunkingStrategies)
+	}
+	atLeast("chunking.window_lines", ch.WindowLines, 0)
+	atLeast("chunking.window_tokens", ch.WindowTokens, 0)
+	atLeast("chunking.overlap_lines", ch.OverlapLines, -1)
+	atLeast("chunking.overlap_tokens", ch.OverlapTokens, -1)
+	atLeast("chunking.max_segments", ch.MaxSegments, 0)
+	if ch.WindowLines > 0 && ch.OverlapLines >= ch.WindowLines {
+		add(SeverityError, "chunking.overlap_lines", "%d must be smaller than window_lines %d", ch.OverlapLines, ch.WindowLines)
+	}
+	if ch.WindowTokens > 0 && ch.OverlapTokens >= ch.WindowTokens {
+		add(SeverityError, "chunking.overlap_tokens", "%d must be smaller than window_tokens %d", ch.OverlapTokens, ch.WindowTokens)
+	}
+	return issues
+}
+
+// Errors returns the issues with SeverityError.
+func Errors(issues []Issue) []Issue {
+	var out []Issue
+	for _, i := range issues {
+		if i.Severity == SeverityError {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func providerOrDefault(p string) string {
+	if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+		return p
+	}
+	return "gemini"
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var (
+	configType   = reflect.TypeOf(Config{})
+	yamlNodeType = reflect.TypeOf(yaml.Node{})
+)
+
+// walk records where each YAML path is set in file, reports keys that have
+// no matching field in t and expands ${VAR} references in values. Profiles
+// are checked against Config itself.
+func (c *Config) walk(file string, node *yaml.Node, t reflect.Type, path string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == yamlNodeType && strings.HasPrefix(path, "profiles.") {
+		t = configType
+	}
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			child := joinPath(path, key.Value)
+			field, ok := fields[key.Value]
+			if !ok {
+				msg := "unknown key"
+				if hint := closestKey(key.Value, fields); hint != "" {
+					msg += fmt.Sprintf("; did you mean %q?", hint)
+				}
+				c.issues = append(c.issues, Issue{Severity: SeverityError, Path: child, File: file, Line: key.Line, Message: msg})
+				continue
+			}
+			c.sources[child] = source{file: file, line: key.Line}
+			c.walk(file, value, field.Type, child)
+		}
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			child := joinPath(path, node.Content[i].Value)
+			c.sources[child] = source{file: file, line: node.Content[i].Line}
+			c.walk(file, node.Content[i+1], t.Elem(), child)
+		}
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			c.walk(file, item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case node.Kind == yaml.ScalarNode:
+		c.expandScalar(file, node, t.Kind() == reflect.String, path)
+	}
+}
+
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if !f.IsExported() || name == "-" || name == "" {
+			continue
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// closestKey suggests the known key within two edits of key, if any.
+func closestKey(key string, fields map[string]reflect.StructField) string {
+	best, bestDist := "", 3
+	for _, name := range sortedKeys(fields) {
+		if d := editDistance(key, name); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
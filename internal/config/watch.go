@@ -0,0 +1,173 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Change is one config value that differs between two loads.
+type Change struct {
+	Path string
+	Old  string
+	New  string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Path, c.Old, c.New)
+}
+
+// Live holds the config of a long-running command. Reload swaps in a new
+// config only when it loads and validates, so readers always see a complete,
+// valid snapshot through Current.
+type Live struct {
+	path string
+	// Extra lists further files whose edits trigger a reload, such as the
+	// doc plan named by the config.
+	Extra func(cfg *Config) []string
+
+	current  atomic.Pointer[Config]
+	mu       sync.Mutex
+	stamp    string
+	onChange []func(old, cur *Config, changes []Change)
+}
+
+// NewLive loads the config at path (see LoadConfig) for watching.
+func NewLive(path string) (*Live, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	l := &Live{path: path}
+	l.current.Store(cfg)
+	l.stamp = l.fingerprint(cfg)
+	return l, nil
+}
+
+// Current returns the active config snapshot. Callers must not modify it.
+func (l *Live) Current() *Config {
+	return l.current.Load()
+}
+
+// OnChange registers fn to run after each successful swap.
+func (l *Live) OnChange(fn func(old, cur *Config, changes []Change)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onChange = append(l.onChange, fn)
+}
+
+// Reload re-reads the config if any watched file changed. An invalid config
+// is reported and the previous one stays active.
+func (l *Live) Reload() ([]Change, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	old := l.current.Load()
+	stamp := l.fingerprint(old)
+	if stamp == l.stamp {
+		return nil, nil
+	}
+	cfg, err := LoadConfig(l.path)
+	if err != nil {
+		// Report a broken edit once; the next edit is tried again.
+		l.stamp = stamp
+		return nil, err
+	}
+	l.stamp = l.fingerprint(cfg)
+	changes := Diff(old, cfg)
+	l.current.Store(cfg)
+	for _, fn := range l.onChange {
+		fn(old, cfg, changes)
+	}
+	return changes, nil
+}
+
+// Watch polls for config edits every interval until ctx is done, logging
+// what changed and any config that failed to load.
+func (l *Live) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		changes, err := l.Reload()
+		if err != nil {
+			log.Printf("Warning: config reload failed, keeping the previous config: %v", err)
+			continue
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		log.Printf("Config reloaded (%d change(s)):", len(changes))
+		for _, c := range changes {
+			log.Printf("  %s", c)
+		}
+	}
+}
+
+// fingerprint hashes the contents of every file the config is read from.
+func (l *Live) fingerprint(cfg *Config) string {
+	files := []string{UserConfigPath(), l.path}
+	if l.path == DefaultPath {
+		if discovered, ok := FindProjectConfig("."); ok {
+			files[1] = discovered
+		}
+	}
+	if l.Extra != nil && cfg != nil {
+		files = append(files, l.Extra(cfg)...)
+	}
+	h := sha256.New()
+	for _, f := range files {
+		h.Write([]byte(f + "\x00"))
+		if data, err := os.ReadFile(f); err == nil {
+			sum := sha256.Sum256(data)
+			h.Write(sum[:])
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Diff lists the YAML paths whose values differ between old and cur, in
+// field order. Profiles are compared through their effect, and API keys and
+// tokens are redacted.
+func Diff(old, cur *Config) []Change {
+	if old == nil || cur == nil {
+		return nil
+	}
+	var changes []Change
+	diffValue(reflect.ValueOf(*old), reflect.ValueOf(*cur), "", &changes)
+	return changes
+}
+
+func diffValue(a, b reflect.Value, path string, changes *[]Change) {
+	if a.Kind() == reflect.Struct {
+		t := a.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if !f.IsExported() || name == "" || name == "-" || name == "profiles" {
+				continue
+			}
+			diffValue(a.Field(i), b.Field(i), joinPath(path, name), changes)
+		}
+		return
+	}
+	if reflect.DeepEqual(a.Interface(), b.Interface()) {
+		return
+	}
+	if strings.Contains(path, "api_key") || strings.Contains(path, "api_token") || path == "publish.notion.token" || strings.HasSuffix(path, "secret") {
+		*changes = append(*changes, Change{Path: path, Old: "(redacted)", New: "(redacted)"})
+		return
+	}
+	*changes = append(*changes, Change{Path: path, Old: fmt.Sprint(a.Interface()), New: fmt.Sprint(b.Interface())})
+}
@@ -1,34 +1,181 @@
 package crawler
 
 import (
+	"context"
+	"crypto/sha256"
 	"docod/internal/extractor"
+	"encoding/hex"
 	"io/fs"
+	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 )
 
+// SymlinkPolicy controls how the crawler treats symbolic links.
+type SymlinkPolicy string
+
+const (
+	// SymlinkFiles extracts links to files wherever they point, under the
+	// link's path, and does not descend into links to directories. This is
+	// how the crawler has always treated links.
+	SymlinkFiles SymlinkPolicy = "files"
+	// SymlinkSkip ignores every symbolic link (files and directories).
+	SymlinkSkip SymlinkPolicy = "skip"
+	// SymlinkFollow follows links whose target resolves inside the scan root.
+	// Targets are deduplicated by real path, so loops and aliases are visited once.
+	SymlinkFollow SymlinkPolicy = "follow"
+)
+
+// ParseSymlinkPolicy maps a config value to a policy, defaulting to SymlinkFiles.
+func ParseSymlinkPolicy(v string) SymlinkPolicy {
+	switch p := SymlinkPolicy(strings.ToLower(strings.TrimSpace(v))); p {
+	case SymlinkSkip, SymlinkFollow:
+		return p
+	default:
+		return SymlinkFiles
+	}
+}
+
 // Crawler scans a directory for source files.
 type Crawler struct {
-	extractor *extractor.Extractor
-	ignored   []string
+	extractor     *extractor.Extractor
+	ignored       []string
+	symlinkPolicy SymlinkPolicy
+	root          string
+	concurrency   int
 }
 
 // NewCrawler creates a new crawler instance.
 func NewCrawler(ext *extractor.Extractor) *Crawler {
 	return &Crawler{
-		extractor: ext,
-		ignored:   []string{".git", "vendor", "node_modules", "testdata"},
+		extractor:     ext,
+		ignored:       []string{".git", "vendor", "node_modules", "testdata"},
+		symlinkPolicy: SymlinkFiles,
+		root:          ".",
+		concurrency:   runtime.NumCPU(),
+	}
+}
+
+// SetSymlinkPolicy changes how symbolic links are handled during scans.
+func (c *Crawler) SetSymlinkPolicy(p SymlinkPolicy) {
+	c.symlinkPolicy = ParseSymlinkPolicy(string(p))
+}
+
+// SetRoot sets the project root that ScanPaths keeps followed links inside
+// under SymlinkFollow; the default is the working directory. ScanProject
+// uses the root it scans.
+func (c *Crawler) SetRoot(root string) {
+	c.root = root
+}
+
+// SetConcurrency sets how many files ScanProject parses at once; n <= 0 uses
+// one worker per CPU.
+func (c *Crawler) SetConcurrency(n int) {
+	if n <= 0 {
+		n = runtime.NumCPU()
 	}
+	c.concurrency = n
+}
+
+// scanState tracks visited real paths for a single scan.
+type scanState struct {
+	realRoot string
+	dirs     map[string]bool
+	files    map[string]bool
+	pending  []linkedPath
+	// queue lists the files to extract, in walk order.
+	queue []string
+}
+
+// linkedPath is a followed link waiting to be visited.
+type linkedPath struct {
+	display string
+	target  string
+	name    string
+	isDir   bool
+}
+
+// FileScan is the result of one file of ScanProjectCached.
+type FileScan struct {
+	Path string
+	// Hash is the SHA-256 of the file contents, hex encoded.
+	Hash  string
+	Units []*extractor.CodeUnit
+	// Unchanged is set when the caller chose to keep the units of an
+	// earlier scan; the file was not parsed and Units is empty.
+	Unchanged bool
 }
 
 // ScanProject walks the root directory and processes all relevant files.
 // It uses a callback to stream CodeUnits, preventing large memory buildup.
+// Files are parsed concurrently, but units reach onUnit from one goroutine
+// in walk order, so the graph built from them does not depend on timing.
 func (c *Crawler) ScanProject(root string, onUnit func(*extractor.CodeUnit)) error {
-	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+	return c.ScanProjectCached(root, nil, func(f FileScan) {
+		for _, unit := range f.Units {
+			onUnit(unit)
+		}
+	})
+}
+
+// ScanProjectCached is ScanProject for callers that keep the units of
+// earlier scans. Each file is hashed before parsing, and when reuse reports
+// that the path and hash are known the file is passed to onFile as
+// Unchanged without being parsed. reuse is called from several goroutines;
+// onFile from one, in walk order. Files that cannot be read or parsed are
+// left out.
+func (c *Crawler) ScanProjectCached(root string, reuse func(path, hash string) bool, onFile func(FileScan)) error {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+	realRoot, err = filepath.Abs(realRoot)
+	if err != nil {
+		return err
+	}
+	st := &scanState{
+		realRoot: realRoot,
+		dirs:     make(map[string]bool),
+		files:    make(map[string]bool),
+	}
+	if err := c.walk(root, realRoot, st); err != nil {
+		return err
+	}
+	// Links are visited after the real tree so that files keep their
+	// canonical path whenever they are reachable without a link.
+	for len(st.pending) > 0 {
+		next := st.pending[0]
+		st.pending = st.pending[1:]
+		if !next.isDir {
+			c.visitFile(next.display, next.target, next.name, st)
+			continue
+		}
+		if st.dirs[next.target] {
+			continue
+		}
+		if err := c.walk(next.display, next.target, st); err != nil {
+			return err
+		}
+	}
+	c.extractFiles(st.queue, reuse, onFile)
+	return nil
+}
+
+// walk traverses realDir while reporting paths relative to displayDir, so
+// files reached through a followed link keep the link-side path.
+func (c *Crawler) walk(displayDir, realDir string, st *scanState) error {
+	return filepath.WalkDir(realDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
+		display := path
+		if rel, relErr := filepath.Rel(realDir, path); relErr == nil {
+			display = filepath.Join(displayDir, rel)
+		}
+
 		// Skip ignored directories
 		if d.IsDir() {
 			for _, ign := range c.ignored {
@@ -36,26 +183,237 @@ func (c *Crawler) ScanProject(root string, onUnit func(*extractor.CodeUnit)) err
 					return filepath.SkipDir
 				}
 			}
+			if st.dirs[path] {
+				return filepath.SkipDir
+			}
+			st.dirs[path] = true
 			return nil
 		}
 
-		// Only process Go files
-		if !strings.HasSuffix(d.Name(), ".go") || strings.HasSuffix(d.Name(), "_test.go") {
+		if d.Type()&fs.ModeSymlink != 0 {
+			c.queueSymlink(display, path, d, st)
 			return nil
 		}
 
-		// Extract units from file
-		units, err := c.extractor.ExtractFromFile(path)
-		if err != nil {
-			// Log and continue instead of failing the whole scan
-			return nil
+		c.visitFile(display, path, d.Name(), st)
+		return nil
+	})
+}
+
+// queueSymlink records a followable link for visiting after the real tree.
+// Under SymlinkFiles, links to files are visited in place.
+func (c *Crawler) queueSymlink(display, path string, d fs.DirEntry, st *scanState) {
+	if c.symlinkPolicy == SymlinkFiles {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			c.visitFile(display, path, d.Name(), st)
 		}
+		return
+	}
+	if c.symlinkPolicy != SymlinkFollow {
+		return
+	}
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		// Dangling links are not worth failing the scan over.
+		return
+	}
+	target, err = filepath.Abs(target)
+	if err != nil || !withinRoot(st.realRoot, target) {
+		return
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return
+	}
+	if info.IsDir() {
+		for _, ign := range c.ignored {
+			if d.Name() == ign {
+				return
+			}
+		}
+	}
+	st.pending = append(st.pending, linkedPath{display: display, target: target, name: d.Name(), isDir: info.IsDir()})
+}
 
-		// Stream results back
-		for _, unit := range units {
-			onUnit(unit)
+func (c *Crawler) visitFile(display, realPath, name string, st *scanState) {
+	// Only process Go files
+	if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+		return
+	}
+	if st.files[realPath] {
+		return
+	}
+	st.files[realPath] = true
+	st.queue = append(st.queue, display)
+}
+
+// extractFiles parses files on a pool of workers and streams the results to
+// onFile in the order of files. Workers run at most a few files ahead of the
+// slowest pending one, which bounds the units held in memory.
+func (c *Crawler) extractFiles(files []string, reuse func(path, hash string) bool, onFile func(FileScan)) {
+	workers := min(c.concurrency, len(files))
+	if workers <= 1 {
+		for _, f := range files {
+			if res := c.extractFile(f, reuse); res != nil {
+				onFile(*res)
+			}
+		}
+		return
+	}
+
+	results := make([]chan *FileScan, len(files))
+	for i := range results {
+		results[i] = make(chan *FileScan, 1)
+	}
+	window := make(chan struct{}, workers*4)
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range files {
+			window <- struct{}{}
+			jobs <- i
 		}
+	}()
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] <- c.extractFile(files[i], reuse)
+			}
+		}()
+	}
 
+	// Stream results back
+	for _, ch := range results {
+		if res := <-ch; res != nil {
+			onFile(*res)
+		}
+		<-window
+	}
+	wg.Wait()
+}
+
+func (c *Crawler) extractFile(path string, reuse func(path, hash string) bool) *FileScan {
+	src, err := os.ReadFile(path)
+	if err != nil {
 		return nil
-	})
+	}
+	sum := sha256.Sum256(src)
+	res := &FileScan{Path: path, Hash: hex.EncodeToString(sum[:])}
+	if reuse != nil && reuse(path, res.Hash) {
+		res.Unchanged = true
+		return res
+	}
+	res.Units, err = c.extractor.ExtractFromSource(path, src)
+	if err != nil {
+		// Log and continue instead of failing the whole scan
+		return nil
+	}
+	return res
+}
+
+func withinRoot(root, target string) bool {
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// PathChange is the re-extraction result for a single changed path.
+type PathChange struct {
+	Path    string
+	Units   []*extractor.CodeUnit
+	Removed bool
+	Err     error
+}
+
+// ScanPaths re-extracts only the files received on paths, without walking the
+// tree. It returns when paths is closed or ctx is cancelled. Paths that the
+// full scan would ignore are dropped; missing files are reported as Removed.
+func (c *Crawler) ScanPaths(ctx context.Context, paths <-chan string, onChange func(PathChange)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case path, ok := <-paths:
+			if !ok {
+				return nil
+			}
+			if !c.Accepts(path) {
+				continue
+			}
+			info, err := os.Lstat(path)
+			if err != nil {
+				if os.IsNotExist(err) {
+					onChange(PathChange{Path: path, Removed: true})
+				} else {
+					onChange(PathChange{Path: path, Err: err})
+				}
+				continue
+			}
+			if info.Mode()&fs.ModeSymlink != 0 && !c.acceptsLink(path) {
+				continue
+			}
+			if info.IsDir() {
+				continue
+			}
+			units, err := c.extractor.ExtractFromFile(path)
+			onChange(PathChange{Path: path, Units: units, Err: err})
+		}
+	}
+}
+
+// acceptsLink reports whether ScanPaths extracts the link at path: any link
+// under SymlinkFiles, and under SymlinkFollow one resolving inside the root.
+func (c *Crawler) acceptsLink(path string) bool {
+	switch c.symlinkPolicy {
+	case SymlinkFiles:
+		return true
+	case SymlinkFollow:
+		root, err := filepath.EvalSymlinks(c.root)
+		if err != nil {
+			return false
+		}
+		target, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return false
+		}
+		root, err = filepath.Abs(root)
+		if err != nil {
+			return false
+		}
+		target, err = filepath.Abs(target)
+		return err == nil && withinRoot(root, target)
+	default:
+		return false
+	}
+}
+
+// ScanFiles is a convenience wrapper around ScanPaths for a fixed list.
+func (c *Crawler) ScanFiles(ctx context.Context, files []string, onChange func(PathChange)) error {
+	paths := make(chan string, len(files))
+	for _, f := range files {
+		paths <- f
+	}
+	close(paths)
+	return c.ScanPaths(ctx, paths, onChange)
+}
+
+// Accepts reports whether path is a source file the crawler would extract.
+func (c *Crawler) Accepts(path string) bool {
+	name := filepath.Base(path)
+	if !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+		return false
+	}
+	for _, part := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		for _, ign := range c.ignored {
+			if part == ign {
+				return false
+			}
+		}
+	}
+	return true
 }
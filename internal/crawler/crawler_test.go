@@ -1,8 +1,11 @@
 package crawler
 
 import (
+	"context"
 	"docod/internal/extractor"
 	"docod/internal/graph"
+	"fmt"
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -58,3 +61,195 @@ func TestCrawler_ScanSelf(t *testing.T) {
 		assert.True(t, foundExtractorDep, "Crawler should depend on Extractor")
 	})
 }
+
+func writeGoFile(t *testing.T, path, pkg, fn string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	src := "package " + pkg + "\n\nfunc " + fn + "() {}\n"
+	require.NoError(t, os.WriteFile(path, []byte(src), 0o644))
+}
+
+func TestCrawler_SymlinkPolicy(t *testing.T) {
+	ext, err := extractor.NewExtractor("go")
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	writeGoFile(t, filepath.Join(root, "pkg", "a.go"), "pkg", "Alpha")
+	outside := t.TempDir()
+	writeGoFile(t, filepath.Join(outside, "ext.go"), "ext", "Outside")
+
+	// Alias of an in-repo directory, a loop back to the root, and escapes.
+	require.NoError(t, os.Symlink(filepath.Join(root, "pkg"), filepath.Join(root, "alias")))
+	require.NoError(t, os.Symlink(root, filepath.Join(root, "pkg", "loop")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "escape")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "ext.go"), filepath.Join(root, "linked.go")))
+
+	scan := func(p SymlinkPolicy) map[string]int {
+		c := NewCrawler(ext)
+		c.SetSymlinkPolicy(p)
+		names := make(map[string]int)
+		require.NoError(t, c.ScanProject(root, func(unit *extractor.CodeUnit) {
+			names[unit.Name]++
+		}))
+		return names
+	}
+
+	t.Run("files by default", func(t *testing.T) {
+		c := NewCrawler(ext)
+		names := make(map[string]int)
+		var paths []string
+		require.NoError(t, c.ScanProject(root, func(unit *extractor.CodeUnit) {
+			names[unit.Name]++
+			paths = append(paths, unit.Filepath)
+		}))
+		assert.Equal(t, 1, names["Alpha"], "linked directories are not entered")
+		assert.Equal(t, 1, names["Outside"], "linked files are extracted wherever they point")
+		assert.Contains(t, paths, filepath.Join(root, "linked.go"))
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		names := scan(SymlinkSkip)
+		assert.Equal(t, 1, names["Alpha"])
+		assert.Zero(t, names["Outside"])
+	})
+
+	t.Run("follow dedupes by real path", func(t *testing.T) {
+		names := scan(SymlinkFollow)
+		assert.Equal(t, 1, names["Alpha"])
+		assert.Zero(t, names["Outside"], "links leaving the root must not be followed")
+	})
+
+	t.Run("follow through linked root", func(t *testing.T) {
+		link := filepath.Join(t.TempDir(), "linked")
+		require.NoError(t, os.Symlink(root, link))
+		c := NewCrawler(ext)
+		c.SetSymlinkPolicy(SymlinkFollow)
+		var paths []string
+		require.NoError(t, c.ScanProject(link, func(unit *extractor.CodeUnit) {
+			paths = append(paths, unit.Filepath)
+		}))
+		require.Len(t, paths, 1)
+		assert.Equal(t, filepath.Join(link, "pkg", "a.go"), paths[0])
+	})
+}
+
+func TestCrawler_ScanPaths(t *testing.T) {
+	ext, err := extractor.NewExtractor("go")
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	live := filepath.Join(root, "pkg", "a.go")
+	writeGoFile(t, live, "pkg", "Alpha")
+	writeGoFile(t, filepath.Join(root, "pkg", "a_test.go"), "pkg", "TestAlpha")
+	writeGoFile(t, filepath.Join(root, "vendor", "v.go"), "v", "Vendored")
+
+	paths := make(chan string)
+	var changes []PathChange
+	done := make(chan error, 1)
+	go func() {
+		done <- NewCrawler(ext).ScanPaths(context.Background(), paths, func(ch PathChange) {
+			changes = append(changes, ch)
+		})
+	}()
+	paths <- live
+	paths <- filepath.Join(root, "pkg", "a_test.go")
+	paths <- filepath.Join(root, "vendor", "v.go")
+	paths <- filepath.Join(root, "pkg", "gone.go")
+	paths <- filepath.Join(root, "README.md")
+	close(paths)
+	require.NoError(t, <-done)
+
+	require.Len(t, changes, 2)
+	assert.Equal(t, live, changes[0].Path)
+	require.Len(t, changes[0].Units, 1)
+	assert.Equal(t, "Alpha", changes[0].Units[0].Name)
+	assert.True(t, changes[1].Removed)
+}
+
+func TestCrawler_ScanPathsFollowStaysInRoot(t *testing.T) {
+	ext, err := extractor.NewExtractor("go")
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	writeGoFile(t, filepath.Join(root, "pkg", "a.go"), "pkg", "Alpha")
+	outside := t.TempDir()
+	writeGoFile(t, filepath.Join(outside, "ext.go"), "ext", "Outside")
+	inside := filepath.Join(root, "alias.go")
+	escape := filepath.Join(root, "escape.go")
+	require.NoError(t, os.Symlink(filepath.Join(root, "pkg", "a.go"), inside))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "ext.go"), escape))
+
+	scan := func(p SymlinkPolicy) []string {
+		c := NewCrawler(ext)
+		c.SetRoot(root)
+		c.SetSymlinkPolicy(p)
+		var got []string
+		require.NoError(t, c.ScanFiles(context.Background(), []string{inside, escape}, func(ch PathChange) {
+			got = append(got, ch.Path)
+		}))
+		return got
+	}
+	assert.Equal(t, []string{inside}, scan(SymlinkFollow))
+	assert.Equal(t, []string{inside, escape}, scan(SymlinkFiles))
+	assert.Empty(t, scan(SymlinkSkip))
+}
+
+func TestCrawler_ConcurrentScanIsDeterministic(t *testing.T) {
+	ext, err := extractor.NewExtractor("go")
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	for i := 0; i < 40; i++ {
+		pkg := fmt.Sprintf("p%d", i%7)
+		writeGoFile(t, filepath.Join(root, pkg, fmt.Sprintf("f%02d.go", i)), pkg, fmt.Sprintf("Func%02d", i))
+	}
+
+	scan := func(workers int) []string {
+		c := NewCrawler(ext)
+		c.SetConcurrency(workers)
+		var ids []string
+		require.NoError(t, c.ScanProject(root, func(unit *extractor.CodeUnit) {
+			ids = append(ids, unit.ID)
+		}))
+		return ids
+	}
+
+	sequential := scan(1)
+	require.Len(t, sequential, 40)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, sequential, scan(8))
+	}
+}
+
+func TestCrawler_ScanProjectCached(t *testing.T) {
+	ext, err := extractor.NewExtractor("go")
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	writeGoFile(t, filepath.Join(root, "a.go"), "pkg", "Alpha")
+	writeGoFile(t, filepath.Join(root, "b.go"), "pkg", "Beta")
+
+	scan := func(reuse func(path, hash string) bool) map[string]FileScan {
+		files := make(map[string]FileScan)
+		require.NoError(t, NewCrawler(ext).ScanProjectCached(root, reuse, func(f FileScan) {
+			files[filepath.Base(f.Path)] = f
+		}))
+		return files
+	}
+
+	first := scan(nil)
+	require.Len(t, first, 2)
+	assert.Len(t, first["a.go"].Hash, 64)
+	assert.False(t, first["a.go"].Unchanged)
+	require.Len(t, first["a.go"].Units, 1)
+
+	known := map[string]string{first["a.go"].Path: first["a.go"].Hash, first["b.go"].Path: first["b.go"].Hash}
+	writeGoFile(t, filepath.Join(root, "b.go"), "pkg", "Gamma")
+	second := scan(func(path, hash string) bool { return known[path] == hash })
+	assert.True(t, second["a.go"].Unchanged)
+	assert.Empty(t, second["a.go"].Units)
+	assert.Equal(t, first["a.go"].Hash, second["a.go"].Hash)
+	assert.False(t, second["b.go"].Unchanged)
+	require.Len(t, second["b.go"].Units, 1)
+	assert.Equal(t, "Gamma", second["b.go"].Units[0].Name)
+}
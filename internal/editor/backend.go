@@ -0,0 +1,518 @@
+// Package editor is the JSON-RPC backend that editor extensions (VS Code,
+// JetBrains) run as `docod rpc` over stdin/stdout.
+//
+// The protocol is versioned by ProtocolVersion. Requests can be cancelled
+// with $/cancelRequest, and requests that pass a partial_result_token receive
+// intermediate results as $/progress notifications before the final response.
+package editor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"docod/internal/analysis"
+	"docod/internal/generator"
+	"docod/internal/git"
+	"docod/internal/graph"
+	"docod/internal/jsonrpc"
+	"docod/internal/knowledge"
+	"docod/internal/server"
+)
+
+// ProtocolVersion changes only when a method or field is removed or changes
+// meaning; additions keep the version.
+const ProtocolVersion = "1"
+
+// Method names.
+const (
+	MethodInitialize = "initialize"
+	MethodSearch     = "docod/search"
+	MethodExplain    = "docod/explain"
+	MethodImpact     = "docod/impact"
+	MethodShutdown   = "shutdown"
+	// MethodProgress carries partial results for a partial_result_token.
+	MethodProgress = "$/progress"
+)
+
+const (
+	defaultSearchTopK  = 10
+	maxSearchTopK      = 50
+	defaultExplainTopK = 6
+	maxContextNodes    = 20
+)
+
+// InitializeResult describes the server to the extension.
+type InitializeResult struct {
+	ProtocolVersion string       `json:"protocol_version"`
+	ServerName      string       `json:"server_name"`
+	Methods         []string     `json:"methods"`
+	Capabilities    Capabilities `json:"capabilities"`
+}
+
+// Capabilities tells the extension which features are usable.
+type Capabilities struct {
+	// SemanticSearch is false without an embedding provider; search then
+	// returns symbol name matches only.
+	SemanticSearch bool `json:"semantic_search"`
+	// Explanations is false without an LLM; explain then returns graph
+	// context only.
+	Explanations   bool `json:"explanations"`
+	PartialResults bool `json:"partial_results"`
+	Cancellation   bool `json:"cancellation"`
+	// Reason explains missing capabilities, e.g. a missing API key.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Progress is the payload of a $/progress notification.
+type Progress struct {
+	Token any `json:"token"`
+	Value any `json:"value"`
+}
+
+// PartialResult is the value of a $/progress notification. Kind names the
+// stage and matches the field of the final result it fills.
+type PartialResult struct {
+	Kind  string `json:"kind"`
+	Items any    `json:"items"`
+}
+
+// SearchParams are the params of docod/search.
+type SearchParams struct {
+	Query              string `json:"query"`
+	TopK               int    `json:"top_k,omitempty"`
+	PartialResultToken any    `json:"partial_result_token,omitempty"`
+}
+
+// SearchResult is the result of docod/search. Symbols are name matches from
+// the graph and are reported first as a partial result; Results are the
+// semantic hits.
+type SearchResult struct {
+	Query   string                `json:"query"`
+	Symbols []server.NodeSummary  `json:"symbols"`
+	Results []server.SearchResult `json:"results"`
+	Warning string                `json:"warning,omitempty"`
+}
+
+// ExplainParams are the params of docod/explain. The symbol is given by ID,
+// or by a file and 1-based line inside it.
+type ExplainParams struct {
+	ID                 string `json:"id,omitempty"`
+	File               string `json:"file,omitempty"`
+	Line               int    `json:"line,omitempty"`
+	Question           string `json:"question,omitempty"`
+	TopK               int    `json:"top_k,omitempty"`
+	PartialResultToken any    `json:"partial_result_token,omitempty"`
+}
+
+// ExplainContext is the graph neighbourhood of the explained symbol.
+type ExplainContext struct {
+	Symbol  server.NodeSummary `json:"symbol"`
+	Doc     string             `json:"doc,omitempty"`
+	Package string             `json:"package_summary,omitempty"`
+	// Dependencies and Dependents follow graph edges of every kind except
+	// tests, which are listed separately.
+	Dependencies []server.NodeSummary `json:"dependencies"`
+	Dependents   []server.NodeSummary `json:"dependents"`
+	Tests        []server.NodeSummary `json:"tests"`
+}
+
+// ExplainResult is the result of docod/explain. The context is reported
+// first as a partial result; the explanation follows from the LLM.
+type ExplainResult struct {
+	Context     ExplainContext        `json:"context"`
+	Explanation string                `json:"explanation,omitempty"`
+	Sources     []generator.SourceRef `json:"sources"`
+	Warning     string                `json:"warning,omitempty"`
+}
+
+// ChangedFile is a file and the 1-based lines that changed in it; no lines
+// means the whole file.
+type ChangedFile struct {
+	Path  string `json:"path"`
+	Lines []int  `json:"lines,omitempty"`
+}
+
+// ImpactParams are the params of docod/impact. Without files, the changes
+// of the working tree against BaseRef (default HEAD) are analyzed.
+type ImpactParams struct {
+	Files              []ChangedFile `json:"files,omitempty"`
+	BaseRef            string        `json:"base_ref,omitempty"`
+	PartialResultToken any           `json:"partial_result_token,omitempty"`
+}
+
+// ImpactResult is the result of docod/impact. Each field is also reported as
+// a partial result as soon as it is known.
+type ImpactResult struct {
+	Direct   []server.NodeSummary `json:"direct"`
+	Indirect []server.NodeSummary `json:"indirect"`
+	Tests    []server.NodeSummary `json:"tests"`
+	Untested []server.NodeSummary `json:"untested"`
+	Paths    map[string][]string  `json:"paths"`
+}
+
+// Server answers editor extension requests from the knowledge graph and the
+// optional search backend.
+type Server struct {
+	g        *graph.Graph
+	backend  *server.Backend
+	byFile   map[string][]*graph.Symbol
+	packages map[string]graph.PackageSummary
+	// changedFiles lists working tree changes; replaced in tests.
+	changedFiles func(baseRef string) ([]git.ChangedFile, error)
+}
+
+// NewServer serves g with backend for search and explanations; a nil
+// backend leaves only graph-based answers.
+func NewServer(g *graph.Graph, backend *server.Backend) *Server {
+	if g == nil {
+		g = graph.NewGraph()
+	}
+	if backend == nil {
+		backend = &server.Backend{}
+	}
+	s := &Server{
+		g:            g,
+		backend:      backend,
+		byFile:       make(map[string][]*graph.Symbol),
+		packages:     make(map[string]graph.PackageSummary),
+		changedFiles: git.GetChangedFiles,
+	}
+	for _, n := range g.Nodes {
+		if n == nil || n.Unit == nil || n.Unit.Filepath == "" {
+			continue
+		}
+		file := path.Clean(n.Unit.Filepath)
+		s.byFile[file] = append(s.byFile[file], n.Unit)
+	}
+	for file, units := range s.byFile {
+		sort.Slice(units, func(i, j int) bool {
+			si, sj := units[i].EndLine-units[i].StartLine, units[j].EndLine-units[j].StartLine
+			if si != sj {
+				return si < sj
+			}
+			return units[i].ID < units[j].ID
+		})
+		s.byFile[file] = units
+	}
+	for _, p := range g.PackageSummaries() {
+		s.packages[p.Dir] = p
+	}
+	return s
+}
+
+// Serve handles requests from r and writes responses to w until the client
+// sends exit, closes the stream or ctx is done.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	return jsonrpc.NewConn(w).Serve(ctx, r, s.handle)
+}
+
+func (s *Server) handle(ctx context.Context, conn *jsonrpc.Conn, msg *jsonrpc.Message) (any, error) {
+	switch msg.Method {
+	case MethodInitialize:
+		return s.initialize(), nil
+	case MethodShutdown, "initialized":
+		return nil, nil
+	case MethodSearch:
+		var p SearchParams
+		if err := jsonrpc.UnmarshalParams(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		return s.Search(ctx, p, progressFunc(conn, p.PartialResultToken))
+	case MethodExplain:
+		var p ExplainParams
+		if err := jsonrpc.UnmarshalParams(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		return s.Explain(ctx, p, progressFunc(conn, p.PartialResultToken))
+	case MethodImpact:
+		var p ImpactParams
+		if err := jsonrpc.UnmarshalParams(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		return s.Impact(ctx, p, progressFunc(conn, p.PartialResultToken))
+	}
+	if msg.ID == nil {
+		return nil, nil
+	}
+	return nil, jsonrpc.Errorf(jsonrpc.CodeMethodNotFound, "method not supported: %s", msg.Method)
+}
+
+func (s *Server) initialize() InitializeResult {
+	_, answers := s.backend.Summarizer.(knowledge.QuestionAnswerer)
+	caps := Capabilities{
+		SemanticSearch: s.backend.Search != nil,
+		Explanations:   s.backend.Search != nil && answers,
+		PartialResults: true,
+		Cancellation:   true,
+	}
+	if s.backend.Err != nil {
+		caps.Reason = s.backend.Err.Error()
+	}
+	return InitializeResult{
+		ProtocolVersion: ProtocolVersion,
+		ServerName:      "docod",
+		Methods:         []string{MethodSearch, MethodExplain, MethodImpact},
+		Capabilities:    caps,
+	}
+}
+
+// ProgressFunc reports one partial result.
+type ProgressFunc func(kind string, items any)
+
+// progressFunc sends partial results for token, or drops them when the
+// client passed none.
+func progressFunc(conn *jsonrpc.Conn, token any) ProgressFunc {
+	if token == nil {
+		return func(string, any) {}
+	}
+	return func(kind string, items any) {
+		_ = conn.Notify(MethodProgress, Progress{Token: token, Value: PartialResult{Kind: kind, Items: items}})
+	}
+}
+
+// Search matches symbol names in the graph, reports them, then runs semantic
+// search.
+func (s *Server) Search(ctx context.Context, p SearchParams, progress ProgressFunc) (*SearchResult, error) {
+	query := strings.TrimSpace(p.Query)
+	if query == "" {
+		return nil, jsonrpc.Errorf(jsonrpc.CodeInvalidParams, "query is required")
+	}
+	topK := p.TopK
+	if topK <= 0 {
+		topK = defaultSearchTopK
+	}
+	topK = min(topK, maxSearchTopK)
+
+	res := &SearchResult{Query: query, Symbols: s.matchSymbols(query, topK), Results: []server.SearchResult{}}
+	progress("symbols", res.Symbols)
+	if s.backend.Search == nil {
+		res.Warning = "semantic search unavailable: " + errString(s.backend.Err)
+		return res, nil
+	}
+	chunks, err := s.backend.Search.SearchByText(ctx, query, topK, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range chunks {
+		res.Results = append(res.Results, server.NewSearchResult(c))
+	}
+	progress("results", res.Results)
+	return res, nil
+}
+
+// matchSymbols ranks graph symbols whose name contains the query: exact
+// names first, then prefixes, then exported names.
+func (s *Server) matchSymbols(query string, limit int) []server.NodeSummary {
+	q := strings.ToLower(query)
+	type match struct {
+		unit *graph.Symbol
+		rank int
+	}
+	var matches []match
+	for _, n := range s.g.Nodes {
+		if n == nil || n.Unit == nil || !documentable(n.Unit) {
+			continue
+		}
+		name := strings.ToLower(n.Unit.Name)
+		if !strings.Contains(name, q) {
+			continue
+		}
+		rank := 3
+		switch {
+		case name == q:
+			rank = 0
+		case strings.HasPrefix(name, q):
+			rank = 1
+		case isExported(n.Unit.Name):
+			rank = 2
+		}
+		matches = append(matches, match{unit: n.Unit, rank: rank})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].rank != matches[j].rank {
+			return matches[i].rank < matches[j].rank
+		}
+		return matches[i].unit.ID < matches[j].unit.ID
+	})
+	out := []server.NodeSummary{}
+	for i := 0; i < len(matches) && i < limit; i++ {
+		out = append(out, server.SummarizeNode(matches[i].unit))
+	}
+	return out
+}
+
+// Explain reports the graph context of a symbol, then asks the LLM to
+// explain it from the symbol and related code.
+func (s *Server) Explain(ctx context.Context, p ExplainParams, progress ProgressFunc) (*ExplainResult, error) {
+	sym := s.resolve(p)
+	if sym == nil {
+		if p.ID != "" {
+			return nil, jsonrpc.Errorf(jsonrpc.CodeInvalidParams, "unknown symbol: %s", p.ID)
+		}
+		return nil, jsonrpc.Errorf(jsonrpc.CodeInvalidParams, "no symbol at %s:%d", p.File, p.Line)
+	}
+	res := &ExplainResult{Context: s.explainContext(sym), Sources: []generator.SourceRef{}}
+	progress("context", res.Context)
+
+	answerer, ok := s.backend.Summarizer.(knowledge.QuestionAnswerer)
+	if s.backend.Search == nil || !ok {
+		res.Warning = "explanations unavailable: " + errString(s.backend.Err)
+		return res, nil
+	}
+	question := strings.TrimSpace(p.Question)
+	if question == "" {
+		question = fmt.Sprintf("What does %s %s do, and how is it used?", sym.UnitType, sym.Name)
+	}
+	topK := p.TopK
+	if topK <= 0 {
+		topK = defaultExplainTopK
+	}
+	topK = min(topK, maxSearchTopK)
+	related, err := s.backend.Search.SearchByText(ctx, question+" "+sym.Name, topK, sym.ID)
+	if err != nil {
+		return nil, err
+	}
+	evidence := append([]knowledge.SearchChunk{symbolChunk(sym, s.g.Content(sym))}, related...)
+	answer, err := answerer.AnswerQuestion(ctx, question, evidence)
+	if err != nil {
+		return nil, err
+	}
+	res.Explanation = answer
+	res.Sources = generator.MergeSources(nil, evidence)
+	progress("explanation", res.Explanation)
+	return res, nil
+}
+
+func (s *Server) resolve(p ExplainParams) *graph.Symbol {
+	if p.ID != "" {
+		if n, ok := s.g.Nodes[p.ID]; ok && n != nil {
+			return n.Unit
+		}
+		return nil
+	}
+	for _, u := range s.byFile[path.Clean(p.File)] {
+		if u.StartLine <= p.Line && p.Line <= u.EndLine {
+			return u
+		}
+	}
+	return nil
+}
+
+func (s *Server) explainContext(sym *graph.Symbol) ExplainContext {
+	out := ExplainContext{
+		Symbol:       server.SummarizeNode(sym),
+		Doc:          strings.TrimSpace(sym.Description),
+		Dependencies: summarizeNodes(s.g.GetDependencies(sym.ID)),
+		Dependents:   summarizeNodes(s.g.GetDependents(sym.ID)),
+		Tests:        summarizeNodes(s.g.TestsOf(sym.ID)),
+	}
+	if pkg, ok := s.packages[path.Dir(sym.Filepath)]; ok && len(pkg.Responsibilities) > 0 {
+		out.Package = strings.Join(pkg.Responsibilities, " ")
+	}
+	return out
+}
+
+// Impact reports the symbols affected by changes in stages: changed symbols,
+// their dependents, then covering tests and untested changes.
+func (s *Server) Impact(ctx context.Context, p ImpactParams, progress ProgressFunc) (*ImpactResult, error) {
+	var changes []git.ChangedFile
+	for _, f := range p.Files {
+		changes = append(changes, git.ChangedFile{Path: path.Clean(f.Path), ChangedLines: f.Lines, WholeFile: len(f.Lines) == 0})
+	}
+	if len(changes) == 0 {
+		ref := p.BaseRef
+		if ref == "" {
+			ref = "HEAD"
+		}
+		var err error
+		if changes, err = s.changedFiles(ref); err != nil {
+			return nil, fmt.Errorf("failed to list changes against %s: %w", ref, err)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	report, err := analysis.NewAnalyzer(s.g).AnalyzeImpact(changes)
+	if err != nil {
+		return nil, err
+	}
+	res := &ImpactResult{Paths: report.Paths}
+	stages := []struct {
+		kind  string
+		nodes []*graph.Node
+		out   *[]server.NodeSummary
+	}{
+		{"direct", report.DirectlyAffected, &res.Direct},
+		{"indirect", report.IndirectlyAffected, &res.Indirect},
+		{"tests", report.Tests, &res.Tests},
+		{"untested", report.Untested, &res.Untested},
+	}
+	for _, st := range stages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		*st.out = summarizeNodes(st.nodes)
+		progress(st.kind, *st.out)
+	}
+	return res, nil
+}
+
+func summarizeNodes(nodes []*graph.Node) []server.NodeSummary {
+	out := []server.NodeSummary{}
+	seen := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		if n == nil || n.Unit == nil || seen[n.Unit.ID] {
+			continue
+		}
+		seen[n.Unit.ID] = true
+		out = append(out, server.SummarizeNode(n.Unit))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	if len(out) > maxContextNodes {
+		out = out[:maxContextNodes]
+	}
+	return out
+}
+
+// symbolChunk turns the explained symbol into evidence for the LLM.
+func symbolChunk(u *graph.Symbol, content string) knowledge.SearchChunk {
+	return knowledge.SearchChunk{
+		ID:          u.ID,
+		Name:        u.Name,
+		Package:     u.Package,
+		UnitType:    u.UnitType,
+		Content:     content,
+		Signature:   u.Metadata.Signature,
+		Description: u.Description,
+		Sources: []knowledge.ChunkSource{{
+			SymbolID:  u.ID,
+			FilePath:  u.Filepath,
+			StartLine: u.StartLine,
+			EndLine:   u.EndLine,
+			Relation:  "primary",
+		}},
+	}
+}
+
+func documentable(u *graph.Symbol) bool {
+	switch u.UnitType {
+	case graph.UnitTypeTest, graph.UnitTypeExternal, graph.UnitTypePackage, graph.UnitTypePackageSummary:
+		return false
+	}
+	return true
+}
+
+func isExported(name string) bool {
+	return name != "" && strings.ToUpper(name[:1]) == name[:1]
+}
+
+func errString(err error) string {
+	if err == nil {
+		return "not configured"
+	}
+	return err.Error()
+}
@@ -0,0 +1,169 @@
+package editor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"docod/internal/git"
+	"docod/internal/graph"
+	"docod/internal/jsonrpc"
+	"docod/internal/knowledge"
+	"docod/internal/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubSearcher []knowledge.SearchChunk
+
+func (s stubSearcher) SearchByText(_ context.Context, _ string, topK int, excludeID string) ([]knowledge.SearchChunk, error) {
+	var out []knowledge.SearchChunk
+	for _, c := range s {
+		if c.ID != excludeID && len(out) < topK {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+type stubAnswerer struct {
+	knowledge.Summarizer
+	evidence []knowledge.SearchChunk
+}
+
+func (s *stubAnswerer) AnswerQuestion(_ context.Context, _ string, evidence []knowledge.SearchChunk) (string, error) {
+	s.evidence = evidence
+	return "Open connects to the store [" + knowledge.ChunkLocation(evidence[0]) + "].", nil
+}
+
+func testGraph() *graph.Graph {
+	g := graph.NewGraph()
+	g.AddSymbol(&graph.Symbol{ID: "store:Open", Name: "Open", UnitType: "function", Package: "store", Filepath: "store/db.go", StartLine: 3, EndLine: 5, Description: "Open opens the store."})
+	g.AddSymbol(&graph.Symbol{ID: "store:openFile", Name: "openFile", UnitType: "function", Package: "store", Filepath: "store/db.go", StartLine: 7, EndLine: 9})
+	g.AddSymbol(&graph.Symbol{ID: "cli:Run", Name: "Run", UnitType: "function", Package: "cli", Filepath: "cli/run.go", StartLine: 1, EndLine: 6})
+	g.AddSymbol(&graph.Symbol{ID: "store:TestOpen", Name: "TestOpen", UnitType: graph.UnitTypeTest, Package: "store", Filepath: "store/db_test.go", StartLine: 1, EndLine: 4})
+	g.Edges = []graph.Edge{
+		{From: "cli:Run", To: "store:Open", Kind: graph.RelationCalls},
+		{From: "store:Open", To: "store:openFile", Kind: graph.RelationCalls},
+		{From: "store:TestOpen", To: "store:Open", Kind: graph.RelationTests},
+	}
+	return g
+}
+
+func TestSearch_ReportsSymbolsBeforeSemanticResults(t *testing.T) {
+	s := NewServer(testGraph(), &server.Backend{Search: stubSearcher{{ID: "cli:Run", Name: "Run"}}})
+	var kinds []string
+	res, err := s.Search(context.Background(), SearchParams{Query: "open"}, func(kind string, _ any) { kinds = append(kinds, kind) })
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"symbols", "results"}, kinds)
+	require.Len(t, res.Symbols, 2, "tests are not offered as symbols")
+	assert.Equal(t, "store:Open", res.Symbols[0].ID, "exact name first")
+	assert.Equal(t, "store:openFile", res.Symbols[1].ID)
+	require.Len(t, res.Results, 1)
+
+	res, err = NewServer(testGraph(), nil).Search(context.Background(), SearchParams{Query: "run"}, func(string, any) {})
+	require.NoError(t, err)
+	assert.Len(t, res.Symbols, 1)
+	assert.Empty(t, res.Results)
+	assert.Contains(t, res.Warning, "semantic search unavailable")
+}
+
+func TestExplain_ContextThenExplanation(t *testing.T) {
+	answerer := &stubAnswerer{}
+	s := NewServer(testGraph(), &server.Backend{Search: stubSearcher{{ID: "store:Open"}, {ID: "cli:Run", Name: "Run"}}, Summarizer: answerer})
+	var kinds []string
+	res, err := s.Explain(context.Background(), ExplainParams{File: "store/db.go", Line: 4}, func(kind string, _ any) { kinds = append(kinds, kind) })
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"context", "explanation"}, kinds)
+	assert.Equal(t, "store:Open", res.Context.Symbol.ID)
+	assert.Equal(t, "Open opens the store.", res.Context.Doc)
+	assert.Equal(t, "store:openFile", res.Context.Dependencies[0].ID)
+	assert.Equal(t, "cli:Run", res.Context.Dependents[0].ID)
+	assert.Equal(t, "store:TestOpen", res.Context.Tests[0].ID)
+	assert.Equal(t, "Open connects to the store [store/db.go:3-5].", res.Explanation)
+	require.Len(t, answerer.evidence, 2, "the symbol itself leads the evidence and is not repeated")
+	assert.Len(t, res.Sources, 2)
+
+	_, err = s.Explain(context.Background(), ExplainParams{ID: "missing"}, func(string, any) {})
+	var rerr *jsonrpc.Error
+	require.ErrorAs(t, err, &rerr)
+	assert.Equal(t, jsonrpc.CodeInvalidParams, rerr.Code)
+}
+
+func TestImpact_StagesAndBaseRef(t *testing.T) {
+	s := NewServer(testGraph(), nil)
+	var kinds []string
+	res, err := s.Impact(context.Background(), ImpactParams{Files: []ChangedFile{{Path: "store/db.go", Lines: []int{4}}}}, func(kind string, _ any) { kinds = append(kinds, kind) })
+	require.NoError(t, err)
+	assert.Equal(t, []string{"direct", "indirect", "tests", "untested"}, kinds)
+	require.Len(t, res.Direct, 1)
+	assert.Equal(t, "store:Open", res.Direct[0].ID)
+	require.Len(t, res.Indirect, 1)
+	assert.Equal(t, "cli:Run", res.Indirect[0].ID)
+	assert.Len(t, res.Tests, 1)
+	assert.Empty(t, res.Untested)
+
+	s.changedFiles = func(ref string) ([]git.ChangedFile, error) {
+		assert.Equal(t, "main", ref)
+		return []git.ChangedFile{{Path: "store/db.go", ChangedLines: []int{8}}}, nil
+	}
+	res, err = s.Impact(context.Background(), ImpactParams{BaseRef: "main"}, func(string, any) {})
+	require.NoError(t, err)
+	require.Len(t, res.Untested, 1)
+	assert.Equal(t, "store:openFile", res.Untested[0].ID)
+}
+
+func TestServe_PartialResultsOverStdio(t *testing.T) {
+	s := NewServer(testGraph(), nil)
+	var in bytes.Buffer
+	for _, req := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"docod/search","params":{"query":"open","partial_result_token":"t1"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"docod/search","params":{}}`,
+	} {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(req), req)
+	}
+	var out bytes.Buffer
+	require.NoError(t, s.Serve(context.Background(), &in, &out))
+
+	responses := map[string]*jsonrpc.Message{}
+	var progress []Progress
+	r := bufio.NewReader(&out)
+	for {
+		msg, err := jsonrpc.ReadMessage(r)
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if msg.Method == MethodProgress {
+			var p Progress
+			require.NoError(t, json.Unmarshal(msg.Params, &p))
+			progress = append(progress, p)
+			continue
+		}
+		responses[string(*msg.ID)] = msg
+	}
+
+	var init InitializeResult
+	require.NoError(t, json.Unmarshal(responses["1"].Result, &init))
+	assert.Equal(t, ProtocolVersion, init.ProtocolVersion)
+	assert.False(t, init.Capabilities.SemanticSearch)
+	assert.True(t, init.Capabilities.Cancellation)
+
+	require.Len(t, progress, 1)
+	assert.Equal(t, "t1", progress[0].Token)
+	assert.Equal(t, "symbols", progress[0].Value.(map[string]any)["kind"])
+	var search SearchResult
+	require.NoError(t, json.Unmarshal(responses["2"].Result, &search))
+	assert.Len(t, search.Symbols, 2)
+
+	require.NotNil(t, responses["3"].Error)
+	assert.Equal(t, jsonrpc.CodeInvalidParams, responses["3"].Error.Code)
+}
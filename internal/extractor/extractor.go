@@ -14,12 +14,13 @@ type Extractor struct {
 	langName      string
 }
 
-// NewExtractor creates a new extractor for a given language.
+// NewExtractor creates a new extractor for a given language. It caches the
+// module roots it finds, so use a new one for each scan.
 func NewExtractor(lang string) (*Extractor, error) {
 	var langExt LanguageExtractor
 	switch lang {
 	case "go":
-		langExt = &GoExtractor{}
+		langExt = &GoExtractor{modules: &moduleCache{}}
 	default:
 		return nil, fmt.Errorf("unsupported language: %s", lang)
 	}
@@ -32,7 +33,12 @@ func (e *Extractor) ExtractFromFile(filepath string) ([]*CodeUnit, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file %s: %w", filepath, err)
 	}
+	return e.ExtractFromSource(filepath, sourceCode)
+}
 
+// ExtractFromSource extracts the code units of a file already read into
+// sourceCode; filepath is recorded on the units.
+func (e *Extractor) ExtractFromSource(filepath string, sourceCode []byte) ([]*CodeUnit, error) {
 	parser := sitter.NewParser()
 	parser.SetLanguage(e.langExtractor.GetLanguage())
 	tree, err := parser.ParseCtx(context.Background(), nil, sourceCode)
@@ -1,6 +1,7 @@
 package extractor
 
 import (
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -172,3 +173,29 @@ func TestExtractor_ExtractFromFile(t *testing.T) {
 		assert.Contains(t, details.Receiver, "*User")
 	})
 }
+
+func TestExtractor_SeesGoModChangesInNextScan(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.go")
+	require.NoError(t, os.WriteFile(file, []byte("package a\n\nfunc A() {}\n"), 0o644))
+	writeMod := func(module string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module "+module+"\n"), 0o644))
+	}
+	id := func(ext *Extractor) string {
+		units, err := ext.ExtractFromFile(file)
+		require.NoError(t, err)
+		require.Len(t, units, 1)
+		return units[0].ID
+	}
+
+	writeMod("example.com/old")
+	first, err := NewExtractor("go")
+	require.NoError(t, err)
+	before := id(first)
+
+	writeMod("example.com/new")
+	assert.Equal(t, before, id(first), "module roots are cached for a scan")
+	next, err := NewExtractor("go")
+	require.NoError(t, err)
+	assert.NotEqual(t, before, id(next), "a new scan reads the edited go.mod")
+}
@@ -10,7 +10,11 @@ import (
 )
 
 // GoExtractor implements LanguageExtractor for Go.
-type GoExtractor struct{}
+type GoExtractor struct {
+	// modules caches the module roots of the files extracted; nil looks
+	// them up each time.
+	modules *moduleCache
+}
 
 func (g *GoExtractor) GetLanguage() *sitter.Language {
 	return golang.GetLanguage()
@@ -43,7 +47,7 @@ func (g *GoExtractor) ExtractUnit(captureName string, node *sitter.Node, sourceC
 		unit.Package = packageName
 		unit.Language = "go"
 		unit.Role = g.inferRole(unit)
-		unit.ID = BuildStableSymbolID(unit)
+		unit.ID = buildStableSymbolID(unit, g.modules)
 		unit.ContentHash = g.calculateHash(unit.Content) // Calculate hash
 		if unit.Relations == nil {
 			unit.Relations = []Relation{}
@@ -492,6 +496,14 @@ func (g *GoExtractor) extractBodyRelations(bodyNode *sitter.Node, sourceCode []b
 				target = typeNode.Content(sourceCode)
 				kind = "instantiates"
 			}
+		case "type_conversion_expression":
+			// Explicitly instantiated generic calls such as NewBox[int](v)
+			// parse as conversions to a generic type.
+			typeNode := n.ChildByFieldName("type")
+			if typeNode != nil && typeNode.Type() == "generic_type" {
+				target = typeNode.Content(sourceCode)
+				kind = "calls"
+			}
 		}
 		if target != "" && !seen[target] {
 			if !isNoise(target) {
@@ -4,15 +4,34 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"os"
+	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
+
+	"golang.org/x/mod/modfile"
 )
 
+// SymbolIDVersion identifies the fingerprint scheme used by BuildStableSymbolID.
+// Bump it whenever the scheme changes so stored graphs get migrated.
+const SymbolIDVersion = "2"
+
 var whitespaceRe = regexp.MustCompile(`\s+`)
 
 // BuildStableSymbolID creates a deterministic symbol ID.
-// The ID is derived from semantic-ish identity fields and a canonical signature hash.
+// The ID is derived from the package import path, kind, receiver, name and a
+// canonical signature, so it survives edits that only shift line numbers or
+// move a symbol between files of the same package. Types are identified by
+// name alone; their bodies do not affect the ID.
 func BuildStableSymbolID(unit *CodeUnit) string {
+	return buildStableSymbolID(unit, nil)
+}
+
+// buildStableSymbolID is BuildStableSymbolID looking module roots up in
+// modules, which may be nil.
+func buildStableSymbolID(unit *CodeUnit, modules *moduleCache) string {
 	if unit == nil {
 		return ""
 	}
@@ -39,12 +58,15 @@ func BuildStableSymbolID(unit *CodeUnit) string {
 
 	receiver := canonicalize(extractReceiver(unit))
 	signature := canonicalize(extractSignature(unit))
-	if signature == "" {
+	if signature == "" && !isTypeKind(kind) {
+		// Variables and constants may be declared locally, so the declaration
+		// text keeps same-named ones apart.
 		signature = canonicalize(unit.Content)
 	}
 
 	fingerprint := strings.Join([]string{
 		lang,
+		packagePath(unit.Filepath, modules),
 		pkg,
 		kind,
 		receiver,
@@ -57,6 +79,66 @@ func BuildStableSymbolID(unit *CodeUnit) string {
 	return fmt.Sprintf("%s/%s:%s:%s:%s", lang, pkg, kind, name, short)
 }
 
+func isTypeKind(kind string) bool {
+	switch kind {
+	case "struct", "interface", "type":
+		return true
+	}
+	return false
+}
+
+// moduleCache caches directory -> (module root, module path). Each
+// Extractor has its own, so go.mod edits are seen by the next scan.
+type moduleCache struct {
+	roots sync.Map
+}
+
+type moduleRoot struct {
+	dir  string
+	path string
+}
+
+// packagePath returns the import path of the package containing file when it
+// lives in a Go module, and the slash-separated directory otherwise.
+func packagePath(file string, modules *moduleCache) string {
+	if file == "" {
+		return ""
+	}
+	dir := filepath.Dir(file)
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return filepath.ToSlash(dir)
+	}
+	root := modules.find(abs)
+	if root.path == "" {
+		return filepath.ToSlash(dir)
+	}
+	rel, err := filepath.Rel(root.dir, abs)
+	if err != nil || rel == "." {
+		return root.path
+	}
+	return path.Join(root.path, filepath.ToSlash(rel))
+}
+
+// find returns the module root of dir; a nil cache looks it up uncached.
+func (c *moduleCache) find(dir string) moduleRoot {
+	if c != nil {
+		if v, ok := c.roots.Load(dir); ok {
+			return v.(moduleRoot)
+		}
+	}
+	var root moduleRoot
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		root = moduleRoot{dir: dir, path: modfile.ModulePath(data)}
+	} else if parent := filepath.Dir(dir); parent != dir {
+		root = c.find(parent)
+	}
+	if c != nil {
+		c.roots.Store(dir, root)
+	}
+	return root
+}
+
 func extractReceiver(unit *CodeUnit) string {
 	if unit == nil || unit.Details == nil {
 		return ""
@@ -0,0 +1,158 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"docod/internal/graph"
+)
+
+const (
+	// APIChangesSectionID is the section maintained from graph diffs. It is
+	// never rewritten by the LLM.
+	APIChangesSectionID = "api-changes"
+	apiChangesTitle     = "API Changes"
+	// maxAPIChangeEntries bounds how many syncs the section keeps.
+	maxAPIChangeEntries = 20
+	apiChangeEntryMark  = "### "
+)
+
+// buildAPIChangeEntry renders the exported symbol changes of one sync, or ""
+// when the public API did not change.
+func buildAPIChangeEntry(changes []graph.ClassifiedChange, commit string) string {
+	var added, removed, modified []string
+	for _, c := range changes {
+		if !c.Exported {
+			continue
+		}
+		name := "`" + c.Package + "." + c.Name + "`"
+		switch c.Reason {
+		case "added":
+			added = append(added, fmt.Sprintf("- %s (%s)", name, c.UnitType))
+		case "removed":
+			removed = append(removed, fmt.Sprintf("- %s (%s)", name, c.UnitType))
+		case "signature_changed", "fields_changed", "fields_added", "methods_changed":
+			line := "- " + name
+			if c.Detail != "" {
+				line += ": " + c.Detail
+			}
+			if c.Class == graph.ChangeBreaking {
+				line += " **(breaking)**"
+			}
+			modified = append(modified, line)
+		}
+	}
+	if len(added)+len(removed)+len(modified) == 0 {
+		return ""
+	}
+
+	ref := "working tree"
+	if commit != "" {
+		ref = "`" + shortSHA(commit) + "`"
+	}
+	var sb strings.Builder
+	sb.WriteString(apiChangeEntryMark + "Changes on top of " + ref + "\n")
+	for _, group := range []struct {
+		title string
+		lines []string
+	}{{"Added", added}, {"Removed", removed}, {"Modified", modified}} {
+		if len(group.lines) == 0 {
+			continue
+		}
+		sb.WriteString("\n**" + group.title + "**\n\n")
+		sb.WriteString(strings.Join(group.lines, "\n") + "\n")
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// UpdateAPIChangesSection prepends the API changes of one sync to the API
+// Changes section, creating it if needed. It reports whether the model
+// changed.
+func UpdateAPIChangesSection(model *DocModel, changes []graph.ClassifiedChange, commit string) bool {
+	entry := buildAPIChangeEntry(changes, commit)
+	if model == nil || entry == "" {
+		return false
+	}
+	sec := model.SectionByID(APIChangesSectionID)
+	if sec == nil {
+		model.Sections = append(model.Sections, ModelSect{
+			ID:     APIChangesSectionID,
+			Title:  apiChangesTitle,
+			Level:  2,
+			Order:  len(model.Sections),
+			Status: "active",
+		})
+		sec = &model.Sections[len(model.Sections)-1]
+	}
+
+	entries := append([]string{entry}, splitAPIChangeEntries(sec.ContentMD)...)
+	if len(entries) > maxAPIChangeEntries {
+		entries = entries[:maxAPIChangeEntries]
+	}
+	sec.ContentMD = entryLogContent(*sec, entries)
+	sec.Summary = "Exported symbols added, removed or modified in recent syncs."
+	sec.LastUpdated = &UpdateInfo{CommitSHA: commit, Timestamp: time.Now().UTC().Format(time.RFC3339)}
+	sec.Hash = sectionHash(*sec)
+	return true
+}
+
+// entryLogContent joins the entries of a log section under the section
+// heading, which NormalizeDocModel would otherwise write over the first
+// entry's heading.
+func entryLogContent(sec ModelSect, entries []string) string {
+	level := sec.Level
+	if level < 1 || level > 6 {
+		level = 2
+	}
+	return strings.Repeat("#", level) + " " + sec.Title + "\n\n" + strings.Join(entries, "\n\n")
+}
+
+// splitAPIChangeEntries splits section content into its "### " entries. Text
+// before the first entry, such as the section heading NormalizeDocModel
+// adds, is dropped.
+func splitAPIChangeEntries(content string) []string {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil
+	}
+	var entries []string
+	for i, part := range strings.Split("\n"+content, "\n"+apiChangeEntryMark) {
+		if part = strings.TrimSpace(part); part != "" && i > 0 {
+			entries = append(entries, apiChangeEntryMark+part)
+		}
+	}
+	return entries
+}
+
+// ApplyAPIChanges records the API changes of a sync in the doc model next to
+// docPath and re-renders the Markdown. Without a doc model it does nothing.
+func ApplyAPIChanges(docPath string, changes []graph.ClassifiedChange, commit string) (bool, error) {
+	modelPath := ModelPathFor(docPath)
+	model, err := LoadDocModel(modelPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !UpdateAPIChangesSection(model, changes, commit) {
+		return false, nil
+	}
+	NormalizeDocModel(model)
+	if err := model.Validate(); err != nil {
+		return false, fmt.Errorf("doc model validation failed: %w", err)
+	}
+	if err := SaveDocModel(modelPath, model); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(docPath, []byte(RenderMarkdownFromModel(model)), 0644)
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,41 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateAPIChangesSection(t *testing.T) {
+	model := &DocModel{Sections: []ModelSect{{ID: "overview", Title: "Overview", Level: 2, Status: "active"}}}
+
+	first := []graph.ClassifiedChange{
+		{NodeRef: graph.NodeRef{Name: "Start", Package: "app", UnitType: "function", Exported: true}, Class: graph.ChangeAdditive, Reason: "added"},
+		{NodeRef: graph.NodeRef{Name: "helper", Package: "app", UnitType: "function"}, Class: graph.ChangeInternal, Reason: "added"},
+		{NodeRef: graph.NodeRef{Name: "Run", Package: "app", UnitType: "function", Exported: true}, Class: graph.ChangeInternal, Reason: "body_changed"},
+	}
+	require.True(t, UpdateAPIChangesSection(model, first, "0123456789abcdef"))
+
+	sec := model.SectionByID(APIChangesSectionID)
+	require.NotNil(t, sec)
+	assert.Equal(t, "## API Changes\n\n### Changes on top of `0123456`\n\n**Added**\n\n- `app.Start` (function)", sec.ContentMD)
+
+	second := []graph.ClassifiedChange{
+		{NodeRef: graph.NodeRef{Name: "Stop", Package: "app", UnitType: "function", Exported: true}, Class: graph.ChangeBreaking, Reason: "removed"},
+		{NodeRef: graph.NodeRef{Name: "Run", Package: "app", UnitType: "function", Exported: true}, Class: graph.ChangeBreaking, Reason: "signature_changed", Detail: "signature changed from `func Run()` to `func Run(ctx context.Context)`"},
+	}
+	require.True(t, UpdateAPIChangesSection(model, second, "fedcba9876543210"))
+	assert.True(t, strings.HasPrefix(sec.ContentMD, "## API Changes\n\n### Changes on top of `fedcba9`"))
+	assert.Contains(t, sec.ContentMD, "**Removed**\n\n- `app.Stop` (function)")
+	assert.Contains(t, sec.ContentMD, "- `app.Run`: signature changed from `func Run()` to `func Run(ctx context.Context)` **(breaking)**")
+	assert.Len(t, splitAPIChangeEntries(sec.ContentMD), 2)
+	NormalizeDocModel(model)
+	assert.Len(t, splitAPIChangeEntries(model.SectionByID(APIChangesSectionID).ContentMD), 2, "normalizing keeps every entry")
+
+	// Changes that leave the public API alone do not add an entry.
+	assert.False(t, UpdateAPIChangesSection(model, first[1:], "abc"))
+}
@@ -0,0 +1,276 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"docod/internal/graph"
+)
+
+const (
+	// APIReferenceSectionID is the section rendered from the exported
+	// symbols of the graph. It is never rewritten by the LLM.
+	APIReferenceSectionID = "api-reference"
+	apiReferenceTitle     = "API Reference"
+)
+
+// apiPackage collects the exported symbols of one package directory.
+type apiPackage struct {
+	name      string
+	dir       string
+	functions []*graph.Symbol
+	types     []*graph.Symbol
+	// methods maps a receiver type name to its exported methods.
+	methods map[string][]*graph.Symbol
+}
+
+// BuildAPIReference renders the exported API of every non-main package in
+// g: function signature tables with parameters and results, struct field
+// tables and interface method tables. It needs no LLM, so the same graph
+// always yields the same text. Absolute paths are made relative to root.
+func BuildAPIReference(g *graph.Graph, root string) string {
+	packages := collectAPIPackages(g, root)
+	var sb strings.Builder
+	sb.WriteString("# " + apiReferenceTitle + "\n\n")
+	if len(packages) == 0 {
+		sb.WriteString("No exported API was found.\n")
+		return sb.String()
+	}
+	sb.WriteString("Exported functions, types and methods of each package, generated from the code graph.\n")
+	for _, pkg := range packages {
+		sb.WriteString(fmt.Sprintf("\n### Package `%s`\n\n`%s`\n", pkg.name, pkg.dir))
+		if len(pkg.functions) > 0 {
+			sb.WriteString("\n**Functions**\n\n")
+			writeFunctionTable(&sb, "Function", pkg.functions)
+		}
+		typeNames := make(map[string]bool, len(pkg.types))
+		for _, t := range pkg.types {
+			typeNames[t.Name] = true
+			writeAPIType(&sb, t, pkg.methods[t.Name])
+		}
+		var orphans []*graph.Symbol
+		for recv, methods := range pkg.methods {
+			if !typeNames[recv] {
+				orphans = append(orphans, methods...)
+			}
+		}
+		if len(orphans) > 0 {
+			sortSymbols(orphans)
+			sb.WriteString("\n**Other methods**\n\n")
+			writeFunctionTable(&sb, "Method", orphans)
+		}
+	}
+	return sb.String()
+}
+
+func collectAPIPackages(g *graph.Graph, root string) []*apiPackage {
+	if g == nil {
+		return nil
+	}
+	byDir := make(map[string]*apiPackage)
+	for _, n := range g.Nodes {
+		if n == nil || n.Unit == nil {
+			continue
+		}
+		u := n.Unit
+		if u.Package == "main" || !exportedName(u.Name) || strings.HasSuffix(u.Filepath, "_test.go") {
+			continue
+		}
+		dir := relativeToRoot(root, filepath.Dir(u.Filepath))
+		pkg, ok := byDir[dir]
+		if !ok {
+			pkg = &apiPackage{name: u.Package, dir: dir, methods: make(map[string][]*graph.Symbol)}
+		}
+		switch u.UnitType {
+		case "function":
+			pkg.functions = append(pkg.functions, u)
+		case "method":
+			recv := receiverTypeName(u.Metadata.Receiver)
+			if !exportedName(recv) {
+				continue
+			}
+			pkg.methods[recv] = append(pkg.methods[recv], u)
+		case "struct", "interface", "type":
+			pkg.types = append(pkg.types, u)
+		default:
+			continue
+		}
+		byDir[dir] = pkg
+	}
+	out := make([]*apiPackage, 0, len(byDir))
+	for _, pkg := range byDir {
+		sortSymbols(pkg.functions)
+		sortSymbols(pkg.types)
+		for _, methods := range pkg.methods {
+			sortSymbols(methods)
+		}
+		out = append(out, pkg)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].dir < out[j].dir })
+	return out
+}
+
+func sortSymbols(symbols []*graph.Symbol) {
+	sort.Slice(symbols, func(i, j int) bool {
+		if symbols[i].Name != symbols[j].Name {
+			return symbols[i].Name < symbols[j].Name
+		}
+		return symbols[i].ID < symbols[j].ID
+	})
+}
+
+// receiverTypeName returns T of a receiver such as "(s *T)" or "(s T[K])".
+func receiverTypeName(recv string) string {
+	fields := strings.Fields(strings.Trim(strings.TrimSpace(recv), "()"))
+	if len(fields) == 0 {
+		return ""
+	}
+	name := strings.TrimPrefix(fields[len(fields)-1], "*")
+	if i := strings.Index(name, "["); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+func writeAPIType(sb *strings.Builder, t *graph.Symbol, methods []*graph.Symbol) {
+	sb.WriteString(fmt.Sprintf("\n#### `%s` (%s)\n", t.Name, t.UnitType))
+	if desc := firstDocSentence(t.Description); desc != "" {
+		sb.WriteString("\n" + desc + "\n")
+	}
+	switch {
+	case len(t.Metadata.StructFields) > 0:
+		sb.WriteString("\n| Field | Type | Tag |\n|---|---|---|\n")
+		for _, f := range t.Metadata.StructFields {
+			name := f.Name
+			if name == "" || name == f.Type {
+				name = "(embedded)"
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s |\n", codeSpan(name), codeSpan(f.Type), codeSpan(f.Tag)))
+		}
+	case t.UnitType == "interface" && len(t.Metadata.Fields) > 0:
+		sb.WriteString("\n| Method | Signature |\n|---|---|\n")
+		for _, sig := range t.Metadata.Fields {
+			name := sig
+			if i := strings.Index(sig, "("); i > 0 {
+				name = sig[:i]
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %s |\n", codeSpan(strings.TrimSpace(name)), codeSpan(sig)))
+		}
+	}
+	if len(methods) > 0 {
+		sb.WriteString("\n")
+		writeFunctionTable(sb, "Method", methods)
+	}
+}
+
+// writeFunctionTable renders one row per function with its parameters and
+// results.
+func writeFunctionTable(sb *strings.Builder, label string, functions []*graph.Symbol) {
+	sb.WriteString("| " + label + " | Parameters | Returns | Description |\n|---|---|---|---|\n")
+	for _, fn := range functions {
+		params := "—"
+		returns := "—"
+		if len(fn.Metadata.Params) > 0 {
+			params = joinParams(fn.Metadata.Params, true)
+		}
+		if len(fn.Metadata.Returns) > 0 {
+			returns = joinParams(fn.Metadata.Returns, false)
+		}
+		desc := firstDocSentence(fn.Description)
+		if desc == "" {
+			desc = "—"
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", codeSpan(fn.Name), params, returns, markdownCell(desc)))
+	}
+}
+
+func joinParams(params []graph.Param, named bool) string {
+	parts := make([]string, 0, len(params))
+	for _, p := range params {
+		text := p.Type
+		if named && p.Name != "" {
+			text = p.Name + " " + p.Type
+		}
+		parts = append(parts, codeSpan(text))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// codeSpan formats s as inline code that is safe inside a table cell.
+func codeSpan(s string) string {
+	s = strings.Trim(strings.TrimSpace(s), "`")
+	if s == "" {
+		return ""
+	}
+	return "`" + strings.ReplaceAll(strings.ReplaceAll(s, "`", "'"), "|", `\|`) + "`"
+}
+
+func firstDocSentence(doc string) string {
+	doc = strings.Join(strings.Fields(doc), " ")
+	if i := strings.Index(doc, ". "); i >= 0 {
+		doc = doc[:i+1]
+	}
+	return truncate(doc, 200)
+}
+
+// UpdateAPIReferenceSection re-renders the API Reference section from g,
+// creating it as a required root section if needed. It reports whether the
+// model changed.
+func UpdateAPIReferenceSection(model *DocModel, g *graph.Graph, root string) bool {
+	if model == nil {
+		return false
+	}
+	content := strings.TrimSpace(BuildAPIReference(g, root))
+	sec := model.SectionByID(APIReferenceSectionID)
+	if sec == nil {
+		if len(model.Policies.RequiredSectionIDs) == 0 {
+			model.Policies.RequiredSectionIDs = append([]string(nil), canonicalSectionOrder...)
+		}
+		model.Policies.RequiredSectionIDs = append(model.Policies.RequiredSectionIDs, APIReferenceSectionID)
+		model.Sections = append(model.Sections, ModelSect{
+			ID:      APIReferenceSectionID,
+			Title:   apiReferenceTitle,
+			Level:   1,
+			Order:   len(model.Sections),
+			Status:  "active",
+			Sources: []SourceRef{},
+		})
+		sec = &model.Sections[len(model.Sections)-1]
+	} else if strings.TrimSpace(sec.ContentMD) == content {
+		return false
+	}
+	sec.ContentMD = content
+	sec.Summary = "Exported functions, types and methods of each package."
+	sec.LastUpdated = &UpdateInfo{CommitSHA: "HEAD", Timestamp: time.Now().UTC().Format(time.RFC3339)}
+	sec.Hash = sectionHash(*sec)
+	return true
+}
+
+// ApplyAPIReference refreshes the API Reference section in the doc model
+// next to docPath and re-renders the Markdown. Without a doc model it does
+// nothing.
+func ApplyAPIReference(docPath string, g *graph.Graph, root string) (bool, error) {
+	modelPath := ModelPathFor(docPath)
+	model, err := LoadDocModel(modelPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !UpdateAPIReferenceSection(model, g, root) {
+		return false, nil
+	}
+	NormalizeDocModel(model)
+	if err := model.Validate(); err != nil {
+		return false, fmt.Errorf("doc model validation failed: %w", err)
+	}
+	if err := SaveDocModel(modelPath, model); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(docPath, []byte(RenderMarkdownFromModel(model)), 0644)
+}
@@ -0,0 +1,65 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"docod/internal/extractor"
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func apiReferenceGraph() *graph.Graph {
+	g := graph.NewGraph()
+	g.AddUnit(&extractor.CodeUnit{ID: "open", Name: "Open", Package: "store", UnitType: "function", Filepath: "internal/store/db.go",
+		Description: "Open opens the database. It creates the file.",
+		Details: extractor.GoFunctionDetails{
+			Signature:  "func Open(path string, opts ...Option) (*DB, error)",
+			Parameters: []extractor.GoParam{{Name: "path", Type: "string"}, {Name: "opts", Type: "...Option"}},
+			Returns:    []extractor.GoReturn{{Type: "*DB"}, {Type: "error"}},
+		}})
+	g.AddUnit(&extractor.CodeUnit{ID: "db", Name: "DB", Package: "store", UnitType: "struct", Filepath: "internal/store/db.go",
+		Description: "DB wraps a connection.",
+		Details: extractor.GoTypeDetails{Fields: []extractor.GoField{
+			{Name: "Path", Type: "string", Tag: "`json:\"path\"`"},
+			{Name: "mu", Type: "sync.Mutex"},
+		}}})
+	g.AddUnit(&extractor.CodeUnit{ID: "close", Name: "Close", Package: "store", UnitType: "method", Filepath: "internal/store/db.go",
+		Details: extractor.GoFunctionDetails{Receiver: "(d *DB)", Signature: "func (d *DB) Close() error", Returns: []extractor.GoReturn{{Type: "error"}}}})
+	g.AddUnit(&extractor.CodeUnit{ID: "reader", Name: "Reader", Package: "store", UnitType: "interface", Filepath: "internal/store/iface.go",
+		Details: extractor.GoInterfaceDetails{Methods: []extractor.GoFunctionDetails{{Signature: "Read(key string) ([]byte, error)"}}}})
+	g.AddUnit(&extractor.CodeUnit{ID: "helper", Name: "helper", Package: "store", UnitType: "function", Filepath: "internal/store/db.go"})
+	g.AddUnit(&extractor.CodeUnit{ID: "main", Name: "Run", Package: "main", UnitType: "function", Filepath: "cmd/app/main.go"})
+	return g
+}
+
+func TestBuildAPIReference(t *testing.T) {
+	md := BuildAPIReference(apiReferenceGraph(), ".")
+
+	assert.Contains(t, md, "### Package `store`\n\n`internal/store`")
+	assert.Contains(t, md, "| `Open` | `path string`, `opts ...Option` | `*DB`, `error` | Open opens the database. |")
+	assert.Contains(t, md, "#### `DB` (struct)\n\nDB wraps a connection.")
+	assert.Contains(t, md, "| `Path` | `string` | `json:\"path\"` |")
+	assert.Contains(t, md, "| `Close` | — | `error` | — |", "methods are listed under their receiver type")
+	assert.Contains(t, md, "| `Read` | `Read(key string) ([]byte, error)` |")
+	assert.NotContains(t, md, "helper")
+	assert.NotContains(t, md, "`Run`", "main packages have no API")
+	assert.Less(t, strings.Index(md, "`DB` (struct)"), strings.Index(md, "`Reader` (interface)"))
+	assert.Equal(t, md, BuildAPIReference(apiReferenceGraph(), "."), "rendering is deterministic")
+}
+
+func TestUpdateAPIReferenceSection(t *testing.T) {
+	model := &DocModel{Sections: []ModelSect{{ID: "overview", Title: "Overview", Level: 1, Status: "active"}}}
+	require.True(t, UpdateAPIReferenceSection(model, apiReferenceGraph(), "."))
+	NormalizeDocModel(model)
+
+	sec := model.SectionByID(APIReferenceSectionID)
+	require.NotNil(t, sec)
+	assert.Equal(t, 1, sec.Level)
+	assert.Contains(t, model.Policies.RequiredSectionIDs, APIReferenceSectionID)
+	assert.Contains(t, model.Document.RootSectionIDs, APIReferenceSectionID)
+
+	assert.False(t, UpdateAPIReferenceSection(model, apiReferenceGraph(), "."), "an unchanged API leaves the section alone")
+}
@@ -0,0 +1,44 @@
+package generator
+
+import "strings"
+
+const breakingCalloutTitle = "> **⚠️ Breaking Changes**"
+
+func breakingChangesFor(plan *UpdatePlan, sectionID string) []string {
+	if plan == nil {
+		return nil
+	}
+	return plan.BreakingChanges[sectionID]
+}
+
+// applyBreakingCallout replaces the breaking-change callout at the top of a
+// section with one listing items. An empty list removes the callout, so it
+// only describes the latest sync.
+func applyBreakingCallout(content string, items []string) string {
+	content = stripBreakingCallout(content)
+	if len(items) == 0 {
+		return content
+	}
+	var sb strings.Builder
+	sb.WriteString(breakingCalloutTitle + "\n>\n")
+	for _, item := range items {
+		sb.WriteString("> - " + item + "\n")
+	}
+	if content == "" {
+		return strings.TrimSpace(sb.String())
+	}
+	return sb.String() + "\n" + content
+}
+
+func stripBreakingCallout(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, breakingCalloutTitle) {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	i := 0
+	for i < len(lines) && strings.HasPrefix(lines[i], ">") {
+		i++
+	}
+	return strings.TrimSpace(strings.Join(lines[i:], "\n"))
+}
@@ -0,0 +1,20 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyBreakingCallout(t *testing.T) {
+	content := "Intro paragraph.\n\n> A regular quote."
+
+	withCallout := applyBreakingCallout(content, []string{"`p.Run`: signature changed"})
+	assert.Equal(t, "> **⚠️ Breaking Changes**\n>\n> - `p.Run`: signature changed\n\nIntro paragraph.\n\n> A regular quote.", withCallout)
+
+	replaced := applyBreakingCallout(withCallout, []string{"`p.Gone`: removed function Gone"})
+	assert.NotContains(t, replaced, "p.Run")
+	assert.Contains(t, replaced, "p.Gone")
+
+	assert.Equal(t, content, applyBreakingCallout(replaced, nil))
+}
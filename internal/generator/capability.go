@@ -166,7 +166,7 @@ func isCapabilityCandidate(c knowledge.SearchChunk) bool {
 	if name == "" {
 		return false
 	}
-	if strings.Contains(name, "_test") || strings.HasSuffix(name, "test") {
+	if knowledge.IsTestChunk(c) {
 		return false
 	}
 	switch c.UnitType {
@@ -0,0 +1,285 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"docod/internal/git"
+	"docod/internal/graph"
+)
+
+const (
+	// ChangelogSectionID is the "What's New" section maintained from git
+	// history. It is never rewritten by the LLM.
+	ChangelogSectionID = "changelog"
+	changelogTitle     = "What's New"
+	// maxChangelogEntries bounds how many syncs the section keeps.
+	maxChangelogEntries = 20
+	// maxChangelogSymbols bounds the symbols listed per commit.
+	maxChangelogSymbols = 8
+)
+
+// ChangelogInput is what one sync contributes to the changelog.
+type ChangelogInput struct {
+	// Commits are the commits since the docs were last updated, newest
+	// first.
+	Commits []git.Commit
+	// Changes are the symbol changes of the sync. Changes in files no commit
+	// touched are listed as uncommitted.
+	Changes []graph.ClassifiedChange
+	// Model supplies the sections whose sources lie in the changed files.
+	Model *DocModel
+	// Root is the project root; DocDir is the directory of the document,
+	// which source links are relative to.
+	Root   string
+	DocDir string
+	Now    time.Time
+}
+
+// changelogGroup is one bullet of an entry: a commit, or the uncommitted
+// changes.
+type changelogGroup struct {
+	title    string
+	symbols  []string
+	sections []string
+}
+
+// BuildChangelogEntry renders one sync as a changelog entry: every commit
+// with the symbols it changed and the documentation sections citing its
+// files. It returns "" when no commit or change touched documented code.
+func BuildChangelogEntry(in ChangelogInput) string {
+	byFile := make(map[string][]graph.ClassifiedChange)
+	for _, c := range in.Changes {
+		f := relativeToRoot(in.Root, c.Filepath)
+		byFile[f] = append(byFile[f], c)
+	}
+	sectionsByFile := changelogSectionsByFile(in.Model, in.Root)
+
+	var groups []changelogGroup
+	symbols := make(map[string]bool)
+	breaking := 0
+	sections := make(map[string]bool)
+	committed := make(map[string]bool)
+	collect := func(title string, files []string) {
+		g := changelogGroup{title: title}
+		seenSec := make(map[string]bool)
+		for _, f := range files {
+			// A file's changes go to the newest commit touching it.
+			if !committed[f] {
+				committed[f] = true
+				for _, c := range byFile[f] {
+					g.symbols = append(g.symbols, in.symbolLine(c))
+					if !symbols[c.ID] {
+						symbols[c.ID] = true
+						if c.Class == graph.ChangeBreaking {
+							breaking++
+						}
+					}
+				}
+			}
+			for _, sec := range sectionsByFile[f] {
+				if !seenSec[sec.ID] {
+					seenSec[sec.ID] = true
+					sections[sec.ID] = true
+					g.sections = append(g.sections, fmt.Sprintf("[%s](#%s)", sec.Title, sec.ID))
+				}
+			}
+		}
+		if len(g.symbols)+len(g.sections) > 0 {
+			groups = append(groups, g)
+		}
+	}
+	for _, c := range in.Commits {
+		title := fmt.Sprintf("**%s** (`%s`", strings.TrimSpace(c.Subject), shortSHA(c.SHA))
+		if c.Author != "" {
+			title += ", " + c.Author
+		}
+		files := make([]string, len(c.Files))
+		for i, f := range c.Files {
+			files[i] = relativeToRoot(".", f)
+		}
+		collect(title+")", files)
+	}
+	var uncommitted []string
+	for f := range byFile {
+		if !committed[f] {
+			uncommitted = append(uncommitted, f)
+		}
+	}
+	sort.Strings(uncommitted)
+	collect("**Uncommitted changes**", uncommitted)
+	if len(groups) == 0 {
+		return ""
+	}
+
+	now := in.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	var sb strings.Builder
+	sb.WriteString(apiChangeEntryMark + now.UTC().Format("2006-01-02"))
+	switch n := len(in.Commits); {
+	case n == 1:
+		sb.WriteString(" — `" + shortSHA(in.Commits[0].SHA) + "`")
+	case n > 1:
+		sb.WriteString(fmt.Sprintf(" — `%s`..`%s`", shortSHA(in.Commits[n-1].SHA), shortSHA(in.Commits[0].SHA)))
+	}
+	sb.WriteString("\n\n")
+	summary := fmt.Sprintf("%d %s changed %d %s", len(in.Commits), plural(len(in.Commits), "commit", "commits"),
+		len(symbols), plural(len(symbols), "symbol", "symbols"))
+	if len(in.Commits) == 0 {
+		summary = fmt.Sprintf("%d %s changed", len(symbols), plural(len(symbols), "symbol", "symbols"))
+	}
+	if breaking > 0 {
+		summary += fmt.Sprintf(" (%d breaking)", breaking)
+	}
+	sb.WriteString(fmt.Sprintf("%s, touching %d documented %s.\n\n", summary, len(sections), plural(len(sections), "section", "sections")))
+	for _, g := range groups {
+		sb.WriteString("- " + g.title + "\n")
+		if len(g.symbols) > 0 {
+			shown := g.symbols
+			if len(shown) > maxChangelogSymbols {
+				shown = shown[:maxChangelogSymbols]
+			}
+			line := "  - Symbols: " + strings.Join(shown, ", ")
+			if extra := len(g.symbols) - len(shown); extra > 0 {
+				line += fmt.Sprintf(" and %d more", extra)
+			}
+			sb.WriteString(line + "\n")
+		}
+		if len(g.sections) > 0 {
+			sb.WriteString("  - Sections: " + strings.Join(g.sections, ", ") + "\n")
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// symbolLine renders a changed symbol linked to its file.
+func (in ChangelogInput) symbolLine(c graph.ClassifiedChange) string {
+	name := c.Name
+	if c.Package != "" {
+		name = c.Package + "." + c.Name
+	}
+	link := relativeToRoot(in.Root, c.Filepath)
+	if in.DocDir != "" {
+		abs, err := filepath.Abs(filepath.Join(in.Root, link))
+		docDir, derr := filepath.Abs(in.DocDir)
+		if err == nil && derr == nil {
+			if rel, err := filepath.Rel(docDir, abs); err == nil {
+				link = filepath.ToSlash(rel)
+			}
+		}
+	}
+	line := fmt.Sprintf("[`%s`](%s) %s", name, link, changeLabel(c.Reason))
+	if c.Class == graph.ChangeBreaking {
+		line += " **(breaking)**"
+	}
+	return line
+}
+
+func changeLabel(reason string) string {
+	switch reason {
+	case "added", "removed":
+		return reason
+	case "signature_changed":
+		return "signature changed"
+	case "fields_changed", "fields_added", "methods_changed":
+		return "members changed"
+	}
+	return "behavior changed"
+}
+
+// changelogSectionsByFile maps source files to the sections citing them,
+// leaving out the deterministic sections.
+func changelogSectionsByFile(model *DocModel, root string) map[string][]ModelSect {
+	out := make(map[string][]ModelSect)
+	if model == nil {
+		return out
+	}
+	for _, sec := range model.Sections {
+		switch sec.ID {
+		case ChangelogSectionID, APIChangesSectionID, APIReferenceSectionID:
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, src := range sec.Sources {
+			f := relativeToRoot(root, src.FilePath)
+			if !seen[f] {
+				seen[f] = true
+				out[f] = append(out[f], sec)
+			}
+		}
+	}
+	for f := range out {
+		sort.SliceStable(out[f], func(i, j int) bool { return out[f][i].Order < out[f][j].Order })
+	}
+	return out
+}
+
+// UpdateChangelogSection prepends one sync's entry to the What's New
+// section, creating it if needed. It reports whether the model changed.
+func UpdateChangelogSection(model *DocModel, in ChangelogInput, commit string) bool {
+	if model == nil {
+		return false
+	}
+	if in.Model == nil {
+		in.Model = model
+	}
+	entry := BuildChangelogEntry(in)
+	if entry == "" {
+		return false
+	}
+	sec := model.SectionByID(ChangelogSectionID)
+	if sec == nil {
+		model.Sections = append(model.Sections, ModelSect{
+			ID:     ChangelogSectionID,
+			Title:  changelogTitle,
+			Level:  2,
+			Order:  len(model.Sections),
+			Status: "active",
+		})
+		sec = &model.Sections[len(model.Sections)-1]
+	}
+
+	entries := append([]string{entry}, splitAPIChangeEntries(sec.ContentMD)...)
+	if len(entries) > maxChangelogEntries {
+		entries = entries[:maxChangelogEntries]
+	}
+	sec.ContentMD = entryLogContent(*sec, entries)
+	sec.Summary = "Recent commits with the symbols they changed and the sections they affect."
+	sec.LastUpdated = &UpdateInfo{CommitSHA: commit, Timestamp: time.Now().UTC().Format(time.RFC3339)}
+	sec.Hash = sectionHash(*sec)
+	return true
+}
+
+// ApplyChangelog records one sync in the What's New section of the doc
+// model next to docPath and re-renders the Markdown. Without a doc model it
+// does nothing.
+func ApplyChangelog(docPath string, in ChangelogInput, commit string) (bool, error) {
+	modelPath := ModelPathFor(docPath)
+	model, err := LoadDocModel(modelPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if in.DocDir == "" {
+		in.DocDir = filepath.Dir(docPath)
+	}
+	if !UpdateChangelogSection(model, in, commit) {
+		return false, nil
+	}
+	NormalizeDocModel(model)
+	if err := model.Validate(); err != nil {
+		return false, fmt.Errorf("doc model validation failed: %w", err)
+	}
+	if err := SaveDocModel(modelPath, model); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(docPath, []byte(RenderMarkdownFromModel(model)), 0644)
+}
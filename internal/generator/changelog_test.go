@@ -0,0 +1,72 @@
+package generator
+
+import (
+	"testing"
+	"time"
+
+	"docod/internal/git"
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func changelogInput() ChangelogInput {
+	return ChangelogInput{
+		Commits: []git.Commit{
+			{SHA: "bbbbbbbbbbbb", Author: "Ada", Subject: "Retry fetches", Files: []string{"internal/fetch/get.go", "README.md"}},
+			{SHA: "aaaaaaaaaaaa", Author: "Bob", Subject: "Add fetch", Files: []string{"internal/fetch/get.go"}},
+			{SHA: "cccccccccccc", Subject: "Docs only", Files: []string{"CONTRIBUTING.md"}},
+		},
+		Changes: []graph.ClassifiedChange{
+			{NodeRef: graph.NodeRef{ID: "get", Name: "Get", Package: "fetch", Filepath: "/repo/internal/fetch/get.go", Exported: true}, Class: graph.ChangeBreaking, Reason: "signature_changed"},
+			{NodeRef: graph.NodeRef{ID: "retry", Name: "retry", Package: "fetch", Filepath: "/repo/internal/fetch/get.go"}, Class: graph.ChangeInternal, Reason: "added"},
+			{NodeRef: graph.NodeRef{ID: "run", Name: "Run", Package: "main", Filepath: "/repo/cmd/app/main.go", Exported: true}, Class: graph.ChangeInternal, Reason: "body_changed"},
+		},
+		Model: &DocModel{Sections: []ModelSect{
+			{ID: "overview", Title: "Overview", Order: 0, Sources: []SourceRef{{FilePath: "/repo/internal/fetch/get.go"}}},
+			{ID: APIChangesSectionID, Title: "API Changes", Order: 1, Sources: []SourceRef{{FilePath: "/repo/internal/fetch/get.go"}}},
+		}},
+		Root:   "/repo",
+		DocDir: "/repo/docs",
+		Now:    time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestBuildChangelogEntry(t *testing.T) {
+	entry := BuildChangelogEntry(changelogInput())
+
+	assert.Equal(t, "### 2026-10-15 — `ccccccc`..`bbbbbbb`\n\n"+
+		"3 commits changed 3 symbols (1 breaking), touching 1 documented section.\n\n"+
+		"- **Retry fetches** (`bbbbbbb`, Ada)\n"+
+		"  - Symbols: [`fetch.Get`](../internal/fetch/get.go) signature changed **(breaking)**, [`fetch.retry`](../internal/fetch/get.go) added\n"+
+		"  - Sections: [Overview](#overview)\n"+
+		"- **Add fetch** (`aaaaaaa`, Bob)\n"+
+		"  - Sections: [Overview](#overview)\n"+
+		"- **Uncommitted changes**\n"+
+		"  - Symbols: [`main.Run`](../cmd/app/main.go) behavior changed", entry)
+}
+
+func TestBuildChangelogEntry_NothingDocumented(t *testing.T) {
+	in := changelogInput()
+	in.Changes = nil
+	in.Model = nil
+	assert.Empty(t, BuildChangelogEntry(in))
+}
+
+func TestUpdateChangelogSection(t *testing.T) {
+	model := &DocModel{Sections: []ModelSect{{ID: "overview", Title: "Overview", Level: 1, Status: "active", ContentMD: "Hi.", Sources: []SourceRef{{FilePath: "/repo/internal/fetch/get.go"}}}}}
+	require.True(t, UpdateChangelogSection(model, changelogInput(), "bbbbbbbbbbbb"))
+	NormalizeDocModel(model)
+	in := changelogInput()
+	in.Commits = in.Commits[:1]
+	require.True(t, UpdateChangelogSection(model, in, "dddddddddddd"))
+
+	sec := model.SectionByID(ChangelogSectionID)
+	require.NotNil(t, sec)
+	assert.Equal(t, "What's New", sec.Title)
+	entries := splitAPIChangeEntries(sec.ContentMD)
+	require.Len(t, entries, 2, "the normalized heading is not kept as an entry")
+	assert.Contains(t, entries[0], "— `bbbbbbb`\n")
+	assert.Contains(t, entries[1], "`ccccccc`..`bbbbbbb`")
+}
@@ -0,0 +1,129 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"docod/internal/knowledge"
+)
+
+const checkpointVersion = "v1"
+
+// GenerateCheckpoint records the sections a full generation has finished, so
+// a run that fails part-way resumes after the last finished section instead
+// of regenerating everything. It is removed once every section is done.
+type GenerateCheckpoint struct {
+	Version string `json:"version"`
+	// Fingerprint identifies the inputs of the run; a checkpoint written for
+	// other chunks, sections or policies is discarded.
+	Fingerprint string                       `json:"fingerprint"`
+	UpdatedAt   string                       `json:"updated_at"`
+	Sections    map[string]CheckpointSection `json:"sections"`
+
+	path string
+	// mu serializes sections finished concurrently.
+	mu sync.Mutex
+}
+
+// CheckpointSection is one finished section and its report metric.
+type CheckpointSection struct {
+	Section ModelSect     `json:"section"`
+	Metric  SectionMetric `json:"metric"`
+}
+
+// LoadGenerateCheckpoint reads the checkpoint at path. A missing, unreadable
+// or stale checkpoint yields an empty one for fingerprint.
+func LoadGenerateCheckpoint(path, fingerprint string) *GenerateCheckpoint {
+	empty := &GenerateCheckpoint{
+		Version:     checkpointVersion,
+		Fingerprint: fingerprint,
+		Sections:    map[string]CheckpointSection{},
+		path:        path,
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return empty
+	}
+	var c GenerateCheckpoint
+	if err := json.Unmarshal(b, &c); err != nil || c.Version != checkpointVersion || c.Fingerprint != fingerprint || c.Sections == nil {
+		return empty
+	}
+	c.path = path
+	return &c
+}
+
+// Section returns the finished section id, if any.
+func (c *GenerateCheckpoint) Section(id string) (CheckpointSection, bool) {
+	if c == nil {
+		return CheckpointSection{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.Sections[id]
+	return s, ok
+}
+
+// Complete marks a section finished and writes the checkpoint. The file is
+// replaced atomically, so an interrupted write keeps the previous state.
+func (c *GenerateCheckpoint) Complete(sec ModelSect, metric SectionMetric) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Sections[sec.ID] = CheckpointSection{Section: sec, Metric: metric}
+	c.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// Remove deletes the checkpoint file.
+func (c *GenerateCheckpoint) Remove() error {
+	if c == nil {
+		return nil
+	}
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// checkpointFingerprint hashes what a section's output depends on: the
+// prepared chunks and their content hashes, the scaffolded sections and the
+// document policies.
+func checkpointFingerprint(chunks []knowledge.SearchChunk, model *DocModel) string {
+	keys := make([]string, 0, len(chunks))
+	for _, c := range chunks {
+		keys = append(keys, c.ID+"|"+c.ContentHash)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{'\n'})
+	}
+	for _, sec := range model.Sections {
+		h.Write([]byte(sec.ID + "|" + sec.Hash + "\n"))
+	}
+	if policies, err := json.Marshal(model.Policies); err == nil {
+		h.Write(policies)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
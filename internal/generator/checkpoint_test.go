@@ -0,0 +1,145 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"docod/internal/config"
+	"docod/internal/extractor"
+	"docod/internal/graph"
+	"docod/internal/knowledge"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCheckpoint_RoundTripAndFingerprint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), config.CheckpointFile)
+	c := LoadGenerateCheckpoint(path, "fp1")
+	_, ok := c.Section("overview")
+	assert.False(t, ok)
+
+	require.NoError(t, c.Complete(ModelSect{ID: "overview", ContentMD: "# Overview"}, SectionMetric{SectionID: "overview", UsedLLM: true}))
+
+	loaded := LoadGenerateCheckpoint(path, "fp1")
+	done, ok := loaded.Section("overview")
+	require.True(t, ok)
+	assert.Equal(t, "# Overview", done.Section.ContentMD)
+	assert.True(t, done.Metric.UsedLLM)
+
+	// Other inputs discard the checkpoint.
+	assert.Empty(t, LoadGenerateCheckpoint(path, "fp2").Sections)
+
+	require.NoError(t, loaded.Remove())
+	_, err := os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	require.NoError(t, loaded.Remove())
+}
+
+// flakySummarizer fails its first failures calls and counts all calls.
+type flakySummarizer struct {
+	mu       sync.Mutex
+	failures int
+	calls    int
+}
+
+func (f *flakySummarizer) reply(content string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.calls <= f.failures {
+		return "", errors.New("500 internal server error")
+	}
+	return content, nil
+}
+
+func (f *flakySummarizer) SummarizeFullDoc(context.Context, []knowledge.SearchChunk, []knowledge.SearchChunk, []knowledge.SearchChunk) (string, error) {
+	return f.reply("")
+}
+
+func (f *flakySummarizer) UpdateDocSection(_ context.Context, current string, _ []knowledge.SearchChunk) (string, error) {
+	return f.reply(current)
+}
+
+func (f *flakySummarizer) RenderSectionFromDraft(context.Context, string, []knowledge.SearchChunk) (string, error) {
+	return f.reply("")
+}
+
+func (f *flakySummarizer) GenerateNewSection(context.Context, []knowledge.SearchChunk) (string, error) {
+	return f.reply("")
+}
+
+func (f *flakySummarizer) FindInsertionPoint(context.Context, []string, string) (int, error) {
+	return -1, errors.New("unsupported")
+}
+
+type zeroEmbedder struct{}
+
+func (zeroEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i := range texts {
+		out[i] = []float32{1, 0}
+	}
+	return out, nil
+}
+
+func (zeroEmbedder) Dimension() int { return 2 }
+
+// newGenerateTestEngine indexes a one-function graph and returns its engine
+// and an output directory holding the doc model schema.
+func newGenerateTestEngine(t *testing.T) (*knowledge.Engine, string) {
+	t.Helper()
+	g := graph.NewGraph()
+	g.AddUnit(&extractor.CodeUnit{ID: "pkg/run.go:Run", Name: "Run", UnitType: "function", Filepath: "pkg/run.go", Package: "pkg", Content: "func Run() error { return nil }"})
+	g.LinkRelations()
+	engine := knowledge.NewEngine(g, zeroEmbedder{}, knowledge.NewMemoryIndex())
+	require.NoError(t, engine.IndexAll(context.Background()))
+	dir := t.TempDir()
+	_, currentFile, _, ok := runtime.Caller(0)
+	require.True(t, ok)
+	schema, err := os.ReadFile(filepath.Join(filepath.Dir(currentFile), "..", "..", "docs", "doc_model.schema.json"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "doc_model.schema.json"), schema, 0644))
+	return engine, dir
+}
+
+func TestGenerateDocs_ResumesFromCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	engine, dir := newGenerateTestEngine(t)
+	checkpointPath := config.DefaultOutputPaths().WithDir(dir).Checkpoint()
+
+	s := &flakySummarizer{failures: 1}
+	require.NoError(t, NewMarkdownGenerator(engine, s).GenerateDocs(ctx, dir))
+	require.Greater(t, s.calls, 0)
+	data, err := os.ReadFile(checkpointPath)
+	require.NoError(t, err)
+	var first GenerateCheckpoint
+	require.NoError(t, json.Unmarshal(data, &first))
+	model, err := LoadDocModel(config.DefaultOutputPaths().WithDir(dir).Model())
+	require.NoError(t, err)
+	require.NotEmpty(t, first.Sections)
+	require.Less(t, len(first.Sections), len(model.Sections))
+
+	// Only the sections whose LLM calls failed are generated again, and a
+	// clean run removes the checkpoint.
+	s.failures, s.calls = 0, 0
+	report := NewPipelineReport("full_generate", dir)
+	require.NoError(t, NewMarkdownGenerator(engine, s).GenerateDocsWithReport(ctx, dir, report))
+	assert.Greater(t, s.calls, 0)
+	assert.NoFileExists(t, checkpointPath)
+	generated := 0
+	for _, st := range report.Stages {
+		if strings.HasPrefix(st.Name, "section_") {
+			generated++
+		}
+	}
+	assert.Equal(t, len(model.Sections)-len(first.Sections), generated)
+	assert.Len(t, report.Sections, len(model.Sections))
+}
@@ -0,0 +1,194 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"docod/internal/knowledge"
+)
+
+var componentSlugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+func componentSlug(label string) string {
+	slug := strings.Trim(componentSlugPattern.ReplaceAllString(strings.ToLower(label), "-"), "-")
+	if slug == "" {
+		return "component"
+	}
+	return slug
+}
+
+// writeComponentPages writes one Markdown page per detected component plus an
+// index under <outputDir>/components. It returns the number of pages written.
+func (g *MarkdownGenerator) writeComponentPages(outputDir string, maxPages int) (int, error) {
+	components := g.engine.Components()
+	if len(components) == 0 {
+		return 0, nil
+	}
+	if maxPages > 0 && len(components) > maxPages {
+		components = components[:maxPages]
+	}
+	dir := filepath.Join(outputDir, "components")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+
+	var index strings.Builder
+	index.WriteString("# Components\n\n")
+	index.WriteString("Logical components detected by clustering the code knowledge graph.\n\n")
+	written := 0
+	for _, comp := range components {
+		slug := componentSlug(comp.Label)
+		page := g.buildComponentPage(comp)
+		if err := os.WriteFile(filepath.Join(dir, slug+".md"), []byte(page), 0644); err != nil {
+			return written, err
+		}
+		written++
+		index.WriteString(fmt.Sprintf("- [%s](%s.md) — %d symbols in %s\n", comp.Label, slug, len(comp.Members), strings.Join(backtickAll(comp.Packages), ", ")))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.md"), []byte(index.String()), 0644); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+func (g *MarkdownGenerator) buildComponentPage(comp knowledge.Component) string {
+	var sb strings.Builder
+	sb.WriteString("# " + comp.Label + "\n\n")
+	sb.WriteString("Packages: " + strings.Join(backtickAll(comp.Packages), ", ") + "\n\n")
+
+	sb.WriteString("## Key Symbols\n\n")
+	keyIDs := make([]string, 0, 8)
+	for _, id := range comp.KeySymbols {
+		if len(keyIDs) >= 8 {
+			break
+		}
+		node, ok := g.engine.GetNodeByID(id)
+		if !ok || node.Unit == nil || node.Unit.UnitType == "method" {
+			continue
+		}
+		keyIDs = append(keyIDs, id)
+		desc := strings.TrimSpace(node.Unit.Description)
+		if desc == "" {
+			desc = "No description available."
+		}
+		sb.WriteString(fmt.Sprintf("- `%s` (%s): %s\n", node.Unit.Name, node.Unit.UnitType, truncate(desc, 160)))
+	}
+	sb.WriteString("\n")
+
+	dependsOn, usedBy := g.componentLinks(comp)
+	if len(dependsOn) > 0 || len(usedBy) > 0 {
+		sb.WriteString("## Relationships\n\n")
+		if len(dependsOn) > 0 {
+			sb.WriteString("- Depends on: " + strings.Join(dependsOn, ", ") + "\n")
+		}
+		if len(usedBy) > 0 {
+			sb.WriteString("- Used by: " + strings.Join(usedBy, ", ") + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	if diagram := g.componentStructureDiagram(keyIDs); diagram != "" {
+		sb.WriteString("## Structure\n\n")
+		sb.WriteString(diagram)
+	}
+	return sb.String()
+}
+
+// componentLinks lists other components this one depends on / is used by,
+// ordered by the number of crossing edges.
+func (g *MarkdownGenerator) componentLinks(comp knowledge.Component) ([]string, []string) {
+	gr := g.engine.Graph()
+	if gr == nil {
+		return nil, nil
+	}
+	members := make(map[string]bool, len(comp.Members))
+	for _, id := range comp.Members {
+		members[id] = true
+	}
+	out := map[string]int{}
+	in := map[string]int{}
+	for _, e := range gr.Edges {
+		switch {
+		case members[e.From] && !members[e.To]:
+			if other := g.engine.ComponentOf(e.To); other != "" {
+				out[other]++
+			}
+		case members[e.To] && !members[e.From]:
+			if other := g.engine.ComponentOf(e.From); other != "" {
+				in[other]++
+			}
+		}
+	}
+	return rankedLabels(out, 5), rankedLabels(in, 5)
+}
+
+func (g *MarkdownGenerator) componentStructureDiagram(ids []string) string {
+	gr := g.engine.Graph()
+	if gr == nil || len(ids) < 2 {
+		return ""
+	}
+	selected := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		selected[id] = true
+	}
+	var edges []string
+	seen := map[string]bool{}
+	for _, e := range gr.Edges {
+		if !selected[e.From] || !selected[e.To] || e.From == e.To {
+			continue
+		}
+		line := fmt.Sprintf("    %s --> %s\n", sanitizeMermaidID(e.From), sanitizeMermaidID(e.To))
+		if seen[line] {
+			continue
+		}
+		seen[line] = true
+		edges = append(edges, line)
+	}
+	if len(edges) == 0 {
+		return ""
+	}
+	sort.Strings(edges)
+	var sb strings.Builder
+	sb.WriteString("```mermaid\ngraph LR\n")
+	for _, id := range ids {
+		node, _ := g.engine.GetNodeByID(id)
+		sb.WriteString(fmt.Sprintf("    %s[%q]\n", sanitizeMermaidID(id), node.Unit.Name))
+	}
+	for _, line := range edges {
+		sb.WriteString(line)
+	}
+	sb.WriteString("```\n")
+	return sb.String()
+}
+
+func rankedLabels(counts map[string]int, limit int) []string {
+	labels := make([]string, 0, len(counts))
+	for l := range counts {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if counts[labels[i]] == counts[labels[j]] {
+			return labels[i] < labels[j]
+		}
+		return counts[labels[i]] > counts[labels[j]]
+	})
+	if limit > 0 && len(labels) > limit {
+		labels = labels[:limit]
+	}
+	for i, l := range labels {
+		labels[i] = fmt.Sprintf("[%s](%s.md)", l, componentSlug(l))
+	}
+	return labels
+}
+
+func backtickAll(in []string) []string {
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		out = append(out, "`"+s+"`")
+	}
+	return out
+}
@@ -0,0 +1,114 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+
+	"docod/internal/analysis"
+	"docod/internal/config"
+)
+
+const constraintsHeading = "## Known Architectural Constraints"
+
+type generatorOptions struct {
+	documentCycles       bool
+	documentExternalDeps bool
+	componentPages       bool
+	packagePages         bool
+	apiReference         bool
+	diversity            string
+	mmrLambda            float64
+	planPath             string
+	style                config.StyleConfig
+	sectionStyles        map[string]config.StyleConfig
+	preset               string
+	sectionConcurrency   int
+}
+
+func resolveGeneratorOptions() generatorOptions {
+	opts := generatorOptions{}
+	cfg, err := config.LoadConfig("config.yaml")
+	if err != nil || cfg == nil {
+		return opts
+	}
+	opts.documentCycles = cfg.Docs.DocumentCycles
+	opts.documentExternalDeps = cfg.Docs.DocumentExternalDeps
+	opts.componentPages = cfg.Docs.ComponentPages
+	opts.packagePages = cfg.Docs.PackagePages
+	opts.apiReference = cfg.Docs.APIReference
+	opts.diversity = cfg.Docs.Diversity
+	opts.mmrLambda = cfg.Docs.MMRLambda
+	opts.planPath = cfg.Docs.PlanPath
+	opts.style = cfg.Docs.Style
+	opts.sectionStyles = cfg.Docs.SectionStyles
+	opts.preset = cfg.Docs.Preset
+	opts.sectionConcurrency = cfg.Docs.SectionConcurrency
+	return opts
+}
+
+// recordCycles copies detected cycles into the pipeline report.
+func recordCycles(report *PipelineReport, cycles *analysis.CycleReport) {
+	if report == nil || cycles == nil {
+		return
+	}
+	for _, c := range cycles.PackageCycles {
+		report.AddCycle(c.Level, c.Members)
+	}
+	for _, c := range cycles.SymbolCycles {
+		report.AddCycle(c.Level, c.Members)
+	}
+	if len(cycles.PackageCycles) > 0 {
+		report.AddSignal("package_cycles_detected", "cycle_detection", "warning", "Package-level dependency cycles detected in the graph.", float64(len(cycles.PackageCycles)))
+	}
+	if len(cycles.SymbolCycles) > 0 {
+		report.AddSignal("symbol_cycles_detected", "cycle_detection", "info", "Symbol-level dependency cycles detected in the graph.", float64(len(cycles.SymbolCycles)))
+	}
+}
+
+// buildConstraintsMarkdown renders cycles as a section subsection.
+func buildConstraintsMarkdown(cycles *analysis.CycleReport, maxItems int) string {
+	if cycles.Total() == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(constraintsHeading + "\n\n")
+	sb.WriteString("The following dependency cycles were detected. Changes to any member may ripple through the whole cycle.\n\n")
+	written := 0
+	write := func(label string, list []analysis.Cycle) {
+		for _, c := range list {
+			if maxItems > 0 && written >= maxItems {
+				return
+			}
+			names := make([]string, 0, len(c.Members))
+			for _, m := range c.Members {
+				names = append(names, "`"+m+"`")
+			}
+			sb.WriteString(fmt.Sprintf("- %s cycle: %s\n", label, strings.Join(names, " ↔ ")))
+			written++
+		}
+	}
+	write("Package", cycles.PackageCycles)
+	write("Symbol", cycles.SymbolCycles)
+	if rest := cycles.Total() - written; rest > 0 {
+		sb.WriteString(fmt.Sprintf("- ...and %d more (see pipeline_report.json).\n", rest))
+	}
+	return sb.String()
+}
+
+func appendConstraints(content, constraints string) string {
+	return appendSubsection(content, constraintsHeading, constraints)
+}
+
+// appendSubsection appends a generated subsection, replacing a previous copy
+// that starts at heading.
+func appendSubsection(content, heading, sub string) string {
+	content = strings.TrimSpace(content)
+	sub = strings.TrimSpace(sub)
+	if sub == "" {
+		return content
+	}
+	if pos := strings.Index(content, heading); pos >= 0 {
+		content = strings.TrimSpace(content[:pos])
+	}
+	return content + "\n\n" + sub
+}
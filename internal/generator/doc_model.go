@@ -12,6 +12,7 @@ import (
 	"sync"
 	"time"
 
+	"docod/internal/config"
 	"docod/internal/knowledge"
 	jsonschema "github.com/santhosh-tekuri/jsonschema/v5"
 )
@@ -59,6 +60,7 @@ type EvidenceRef struct {
 	ChunkCount  int     `json:"chunk_count"`
 	SourceCount int     `json:"source_count"`
 	QueryCount  int     `json:"query_count"`
+	Relevance   float64 `json:"relevance,omitempty"` // mean retrieval similarity of scored evidence
 	LowEvidence bool    `json:"low_evidence"`
 }
 
@@ -82,6 +84,8 @@ type ModelPolicy struct {
 	RequiredSectionIDs []string    `json:"required_section_ids"`
 	MaxSectionChars    int         `json:"max_section_chars"`
 	Style              PolicyStyle `json:"style"`
+	// SectionStyles overrides Style per section ID.
+	SectionStyles map[string]SectionStylePolicy `json:"section_styles,omitempty"`
 }
 
 type PolicyStyle struct {
@@ -92,6 +96,9 @@ type PolicyStyle struct {
 	AvoidCallGraphNarration    bool   `json:"avoid_call_graph_narration"`
 	PreferConceptualDiagrams   bool   `json:"prefer_conceptual_diagrams"`
 	PreferTaskOrientedExamples bool   `json:"prefer_task_oriented_examples"`
+	// AllowedDiagrams lists the Mermaid diagram types LLM-written sections
+	// may use; empty allows any and ["none"] forbids diagrams.
+	AllowedDiagrams []string `json:"allowed_diagrams,omitempty"`
 }
 
 type ModelMeta struct {
@@ -101,6 +108,12 @@ type ModelMeta struct {
 	GeneratorVersion string `json:"generator_version,omitempty"`
 }
 
+// ModelPathFor returns the doc model that belongs to the documentation at
+// docPath: the configured model file in the same directory.
+func ModelPathFor(docPath string) string {
+	return config.ResolveOutputPaths().WithDir(filepath.Dir(docPath)).Model()
+}
+
 func LoadDocModel(path string) (*DocModel, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
@@ -413,6 +426,24 @@ func BuildSourcesFromChunk(chunk knowledge.SearchChunk) []SourceRef {
 	}
 }
 
+// RemapSourceSymbolIDs rewrites section sources whose symbol IDs appear in
+// mapping (old -> new) and returns the number of references updated.
+func RemapSourceSymbolIDs(m *DocModel, mapping map[string]string) int {
+	if m == nil || len(mapping) == 0 {
+		return 0
+	}
+	updated := 0
+	for i := range m.Sections {
+		for j, src := range m.Sections[i].Sources {
+			if to, ok := mapping[src.SymbolID]; ok {
+				m.Sections[i].Sources[j].SymbolID = to
+				updated++
+			}
+		}
+	}
+	return updated
+}
+
 func MergeSources(existing []SourceRef, chunks []knowledge.SearchChunk) []SourceRef {
 	seen := make(map[string]bool, len(existing))
 	out := make([]SourceRef, 0, len(existing)+len(chunks))
@@ -443,7 +474,7 @@ func clampPositive(n int) int {
 
 func normalizeSourceRelation(v string) string {
 	switch strings.ToLower(strings.TrimSpace(v)) {
-	case "primary", "dependency", "context":
+	case "primary", "dependency", "context", "test":
 		return strings.ToLower(strings.TrimSpace(v))
 	default:
 		return "primary"
@@ -561,7 +592,7 @@ func ensureCanonicalRootSections(m *DocModel) {
 		existing[s.ID] = true
 	}
 
-	for _, id := range canonicalSectionOrder {
+	for _, id := range sectionOrder(m) {
 		if existing[id] {
 			continue
 		}
@@ -586,7 +617,7 @@ func ensureRootSectionIDs(m *DocModel) {
 	seen := make(map[string]bool)
 	var roots []string
 
-	for _, id := range canonicalSectionOrder {
+	for _, id := range sectionOrder(m) {
 		if m.SectionByID(id) != nil {
 			roots = append(roots, id)
 			seen[id] = true
@@ -607,9 +638,10 @@ func ensureRootSectionIDs(m *DocModel) {
 }
 
 func reindexSectionOrder(m *DocModel) {
+	order := sectionOrder(m)
 	sort.Slice(m.Sections, func(i, j int) bool {
-		ri := sectionRank(m.Sections[i].ID)
-		rj := sectionRank(m.Sections[j].ID)
+		ri := sectionRank(order, m.Sections[i].ID)
+		rj := sectionRank(order, m.Sections[j].ID)
 		if ri == rj {
 			return m.Sections[i].Order < m.Sections[j].Order
 		}
@@ -647,13 +679,22 @@ func normalizeSectionHeadings(m *DocModel) {
 	}
 }
 
-func sectionRank(id string) int {
-	for i, v := range canonicalSectionOrder {
+// sectionOrder is the canonical root order of m: its required sections, as
+// chosen by the docs preset, or the default order.
+func sectionOrder(m *DocModel) []string {
+	if len(m.Policies.RequiredSectionIDs) > 0 {
+		return m.Policies.RequiredSectionIDs
+	}
+	return canonicalSectionOrder
+}
+
+func sectionRank(order []string, id string) int {
+	for i, v := range order {
 		if id == v {
 			return i
 		}
 	}
-	return len(canonicalSectionOrder) + 1
+	return len(order) + 1
 }
 
 func sectionTitleFromID(id string) string {
@@ -664,6 +705,8 @@ func sectionTitleFromID(id string) string {
 		return "Key Features"
 	case "development":
 		return "Development"
+	case "api-reference":
+		return "API Reference"
 	default:
 		parts := strings.Split(id, "-")
 		for i := range parts {
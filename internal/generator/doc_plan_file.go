@@ -0,0 +1,181 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"docod/internal/knowledge"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultDocPlanPath is where section retrieval plans are read from when
+// docs.plan_path is not configured.
+const DefaultDocPlanPath = "docplan.yaml"
+
+// docPlanFile mirrors docplan.yaml. Pointer fields distinguish "unset" from
+// zero values so a section entry only overrides what it mentions.
+type docPlanFile struct {
+	Sections []docPlanSection `yaml:"sections"`
+}
+
+type docPlanSection struct {
+	ID                string         `yaml:"id"`
+	Title             string         `yaml:"title"`
+	Goal              string         `yaml:"goal"`
+	RequiredBlocks    []string       `yaml:"required_blocks"`
+	QueryHints        []string       `yaml:"query_hints"`
+	RetrievalKeywords []string       `yaml:"retrieval_keywords"`
+	TopK              *int           `yaml:"top_k"`
+	MinEvidence       *int           `yaml:"min_evidence"`
+	RequireMermaid    *bool          `yaml:"require_mermaid"`
+	AllowLLM          *bool          `yaml:"allow_llm"`
+	Diversity         string         `yaml:"diversity"`
+	MMRLambda         *float64       `yaml:"mmr_lambda"`
+	Filter            *docPlanFilter `yaml:"filter"`
+}
+
+type docPlanFilter struct {
+	Packages         []string `yaml:"packages"`
+	UnitTypes        []string `yaml:"unit_types"`
+	ExcludeUnitTypes []string `yaml:"exclude_unit_types"`
+	PathPrefixes     []string `yaml:"path_prefixes"`
+	ExcludePaths     []string `yaml:"exclude_paths"`
+	ExcludeTests     bool     `yaml:"exclude_tests"`
+}
+
+// LoadFullDocPlan returns the default plan overlaid with the sections defined
+// in the YAML file at path. Entries matching a default section by id override
+// only the fields they set; unknown ids are appended as new sections. A
+// missing file yields the default plan.
+func LoadFullDocPlan(path string) (*FullDocPlan, error) {
+	return overlayDocPlanFile(BuildDefaultFullDocPlan(), path)
+}
+
+// overlayDocPlanFile applies the sections of the YAML file at path to plan.
+func overlayDocPlanFile(plan *FullDocPlan, path string) (*FullDocPlan, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return plan, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return plan, nil
+		}
+		return plan, err
+	}
+	var file docPlanFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return plan, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for i, def := range file.Sections {
+		id := strings.TrimSpace(def.ID)
+		if id == "" {
+			return plan, fmt.Errorf("%s: section %d has no id", path, i+1)
+		}
+		if err := def.validate(); err != nil {
+			return plan, fmt.Errorf("%s: section %q: %w", path, id, err)
+		}
+		idx := -1
+		for j := range plan.Sections {
+			if plan.Sections[j].SectionID == id {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			plan.Sections = append(plan.Sections, SectionDocPlan{SectionID: id, Title: id})
+			idx = len(plan.Sections) - 1
+		}
+		def.applyTo(&plan.Sections[idx])
+	}
+	return plan, nil
+}
+
+func (d docPlanSection) validate() error {
+	if d.TopK != nil && *d.TopK < 0 {
+		return fmt.Errorf("top_k must be >= 0, got %d", *d.TopK)
+	}
+	if d.MinEvidence != nil && *d.MinEvidence < 0 {
+		return fmt.Errorf("min_evidence must be >= 0, got %d", *d.MinEvidence)
+	}
+	switch strings.ToLower(strings.TrimSpace(d.Diversity)) {
+	case "", DiversityFile, DiversityMMR:
+	default:
+		return fmt.Errorf("diversity must be %q or %q, got %q", DiversityFile, DiversityMMR, d.Diversity)
+	}
+	if d.MMRLambda != nil && (*d.MMRLambda < 0 || *d.MMRLambda > 1) {
+		return fmt.Errorf("mmr_lambda must be within 0..1, got %g", *d.MMRLambda)
+	}
+	return nil
+}
+
+func (d docPlanSection) applyTo(s *SectionDocPlan) {
+	if v := strings.TrimSpace(d.Title); v != "" {
+		s.Title = v
+	}
+	if v := strings.TrimSpace(d.Goal); v != "" {
+		s.Goal = v
+	}
+	if d.RequiredBlocks != nil {
+		s.RequiredBlocks = d.RequiredBlocks
+	}
+	if d.QueryHints != nil {
+		s.QueryHints = d.QueryHints
+	}
+	if d.RetrievalKeywords != nil {
+		s.RetrievalKeywords = d.RetrievalKeywords
+	}
+	if d.TopK != nil {
+		s.TopK = *d.TopK
+	}
+	if d.MinEvidence != nil {
+		s.MinEvidence = *d.MinEvidence
+	}
+	if d.RequireMermaid != nil {
+		s.RequireMermaid = *d.RequireMermaid
+	}
+	if d.AllowLLM != nil {
+		s.AllowLLM = *d.AllowLLM
+	}
+	if v := strings.ToLower(strings.TrimSpace(d.Diversity)); v != "" {
+		s.Diversity = v
+	}
+	if d.MMRLambda != nil {
+		s.MMRLambda = *d.MMRLambda
+	}
+	if d.Filter != nil {
+		s.Filter = knowledge.SearchFilter{
+			Packages:         d.Filter.Packages,
+			UnitTypes:        d.Filter.UnitTypes,
+			ExcludeUnitTypes: d.Filter.ExcludeUnitTypes,
+			PathPrefixes:     d.Filter.PathPrefixes,
+			ExcludePaths:     d.Filter.ExcludePaths,
+			ExcludeTests:     d.Filter.ExcludeTests,
+		}
+	}
+}
+
+// resolveFullDocPlan loads the configured section plans over the preset,
+// falling back to the preset alone when the file cannot be used.
+func resolveFullDocPlan(opts generatorOptions) *FullDocPlan {
+	path := opts.planPath
+	if strings.TrimSpace(path) == "" {
+		path = DefaultDocPlanPath
+	}
+	base, err := BuildPresetFullDocPlan(opts.preset)
+	if err != nil {
+		fmt.Printf("⚠️  Ignoring docs preset: %v\n", err)
+	}
+	plan, err := overlayDocPlanFile(base, path)
+	if err != nil {
+		fmt.Printf("⚠️  Ignoring section plan file: %v\n", err)
+		plan, _ = BuildPresetFullDocPlan(opts.preset)
+	}
+	plan.ApplyDiversityDefaults(opts.diversity, opts.mmrLambda)
+	return plan
+}
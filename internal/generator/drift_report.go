@@ -0,0 +1,223 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	// DriftRuleID is the SARIF rule every documentation drift result cites.
+	DriftRuleID = "docod/doc-drift"
+)
+
+// DriftReport describes how the documentation changes with a commit range,
+// for review tools: a PR comment in Markdown or a SARIF log.
+type DriftReport struct {
+	// Base and Head name the range, e.g. origin/main and HEAD.
+	Base string
+	Head string
+	// Root is the repository root; absolute source paths are made relative
+	// to it.
+	Root string
+	// DocPath is the generated document, relative to Root.
+	DocPath string
+	// ChangedFiles are the source files the range touches.
+	ChangedFiles []string
+	Changes      []SectionChange
+	// Model is the documentation after the range; it supplies the sources
+	// that locate each change.
+	Model *DocModel
+}
+
+// Markdown renders the report as a PR comment.
+func (r DriftReport) Markdown() string {
+	var b strings.Builder
+	b.WriteString("### 📄 Documentation drift\n\n")
+	rangeLabel := fmt.Sprintf("`%s...%s`", r.Base, r.Head)
+	if len(r.Changes) == 0 {
+		fmt.Fprintf(&b, "No documentation sections change with %s (%d %s).\n",
+			rangeLabel, len(r.ChangedFiles), plural(len(r.ChangedFiles), "source file", "source files"))
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%d %s of `%s` change with %s (%d %s).\n\n",
+		len(r.Changes), plural(len(r.Changes), "section", "sections"), r.DocPath,
+		rangeLabel, len(r.ChangedFiles), plural(len(r.ChangedFiles), "source file", "source files"))
+	b.WriteString("| Section | Change | Sources in this range |\n|---|---|---|\n")
+	for _, c := range r.Changes {
+		sources := r.changedSources(c.SectionID)
+		cited := "—"
+		if len(sources) > 0 {
+			names := make([]string, len(sources))
+			for i, src := range sources {
+				names[i] = "`" + src.FilePath + "`"
+			}
+			cited = strings.Join(names, ", ")
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", markdownCell(c.Title), c.Kind, cited)
+	}
+	for _, c := range r.Changes {
+		headline, excerpt, _ := strings.Cut(c.Summary, "\n")
+		fmt.Fprintf(&b, "\n<details><summary><b>%s</b> — %s</summary>\n\n%s\n", markdownCell(c.Title), c.Kind, headline)
+		if excerpt != "" {
+			fmt.Fprintf(&b, "\n```diff\n%s\n```\n", excerpt)
+		}
+		b.WriteString("\n</details>\n")
+	}
+	return b.String()
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", " ")
+}
+
+// changedSources returns the sources of a section that lie in the range's
+// changed files, sorted by path and line.
+func (r DriftReport) changedSources(sectionID string) []SourceRef {
+	if r.Model == nil {
+		return nil
+	}
+	changed := make(map[string]bool, len(r.ChangedFiles))
+	for _, f := range r.ChangedFiles {
+		changed[filepath.ToSlash(filepath.Clean(f))] = true
+	}
+	var out []SourceRef
+	seen := make(map[string]bool)
+	for _, sec := range r.Model.Sections {
+		if sec.ID != sectionID {
+			continue
+		}
+		for _, src := range sec.Sources {
+			src.FilePath = r.relative(src.FilePath)
+			key := fmt.Sprintf("%s:%d", src.FilePath, src.StartLine)
+			if !changed[src.FilePath] || seen[key] {
+				continue
+			}
+			seen[key] = true
+			out = append(out, src)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].FilePath != out[j].FilePath {
+			return out[i].FilePath < out[j].FilePath
+		}
+		return out[i].StartLine < out[j].StartLine
+	})
+	return out
+}
+
+func (r DriftReport) relative(path string) string {
+	if filepath.IsAbs(path) && r.Root != "" {
+		if root, err := filepath.Abs(r.Root); err == nil {
+			if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           *sarifRegion  `json:"region,omitempty"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+// SARIF renders the report as a SARIF 2.1.0 log with one note per changed
+// section. Each result points at the section's sources in the changed
+// files, so code scanning annotates the code that drifted the docs; a
+// section without such sources points at the document.
+func (r DriftReport) SARIF() ([]byte, error) {
+	results := make([]sarifResult, 0, len(r.Changes))
+	for _, c := range r.Changes {
+		headline, _, _ := strings.Cut(c.Summary, "\n")
+		res := sarifResult{
+			RuleID:  DriftRuleID,
+			Level:   "note",
+			Message: sarifMessage{Text: fmt.Sprintf("Documentation section %q %s in %s: %s", c.Title, c.Kind, r.DocPath, headline)},
+		}
+		for _, src := range r.changedSources(c.SectionID) {
+			loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifact{URI: src.FilePath}}}
+			if src.StartLine > 0 {
+				loc.PhysicalLocation.Region = &sarifRegion{StartLine: src.StartLine}
+				if src.EndLine >= src.StartLine {
+					loc.PhysicalLocation.Region.EndLine = src.EndLine
+				}
+			}
+			res.Locations = append(res.Locations, loc)
+		}
+		if len(res.Locations) == 0 {
+			res.Locations = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifact{URI: filepath.ToSlash(r.DocPath)},
+			}}}
+		}
+		results = append(results, res)
+	}
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name: "docod",
+				Rules: []sarifRule{{
+					ID:               DriftRuleID,
+					ShortDescription: sarifMessage{Text: "A documentation section changes with this code"},
+				}},
+			}},
+			Results: results,
+		}},
+	}
+	return json.MarshalIndent(log, "", "  ")
+}
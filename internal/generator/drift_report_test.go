@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func driftFixture(t *testing.T) DriftReport {
+	root := t.TempDir()
+	after := &DocModel{Sections: []ModelSect{
+		{ID: "usage", Title: "Usage | CLI", ContentMD: "Run it.\n\nThen check.", Sources: []SourceRef{
+			{FilePath: filepath.Join(root, "cmd", "main.go"), StartLine: 10, EndLine: 20},
+			{FilePath: "internal/other.go", StartLine: 1},
+		}},
+		{ID: "api", Title: "API", ContentMD: "One call."},
+	}}
+	before := &DocModel{Sections: []ModelSect{{ID: "usage", Title: "Usage | CLI", ContentMD: "Run it."}}}
+	return DriftReport{
+		Base: "origin/main", Head: "HEAD", Root: root, DocPath: "docs/documentation.md",
+		ChangedFiles: []string{"cmd/main.go"},
+		Changes:      DiffSections(before, after),
+		Model:        after,
+	}
+}
+
+func TestDriftReport_Markdown(t *testing.T) {
+	md := driftFixture(t).Markdown()
+	assert.Contains(t, md, "2 sections of `docs/documentation.md` change with `origin/main...HEAD` (1 source file).")
+	assert.Contains(t, md, "| Usage \\| CLI | updated | `cmd/main.go` |", "absolute sources are made relative and unchanged files left out")
+	assert.Contains(t, md, "| API | added | — |")
+	assert.Contains(t, md, "```diff\n+ Then check.\n```")
+
+	empty := DriftReport{Base: "a", Head: "b", ChangedFiles: []string{"x.go", "y.go"}}
+	assert.Contains(t, empty.Markdown(), "No documentation sections change with `a...b` (2 source files).")
+}
+
+func TestDriftReport_SARIF(t *testing.T) {
+	data, err := driftFixture(t).SARIF()
+	require.NoError(t, err)
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(data, &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	results := log.Runs[0].Results
+	require.Len(t, results, 2)
+
+	assert.Equal(t, DriftRuleID, results[0].RuleID)
+	require.Len(t, results[0].Locations, 1)
+	loc := results[0].Locations[0].PhysicalLocation
+	assert.Equal(t, "cmd/main.go", loc.ArtifactLocation.URI)
+	assert.Equal(t, &sarifRegion{StartLine: 10, EndLine: 20}, loc.Region)
+
+	assert.Equal(t, "docs/documentation.md", results[1].Locations[0].PhysicalLocation.ArtifactLocation.URI,
+		"a section without changed sources points at the document")
+}
@@ -0,0 +1,71 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"docod/internal/graph"
+)
+
+const externalDepsHeading = "## External Integrations"
+
+type externalDep struct {
+	node  *graph.Symbol
+	users int
+}
+
+// buildExternalDepsMarkdown lists the external packages referenced by the most
+// module symbols, linking each to its documentation.
+func buildExternalDepsMarkdown(g *graph.Graph, maxItems int) string {
+	deps := rankExternalDeps(g)
+	if len(deps) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(externalDepsHeading + "\n\n")
+	sb.WriteString("The project builds on these third-party packages, ordered by how many symbols use them.\n\n")
+	for i, d := range deps {
+		if maxItems > 0 && i >= maxItems {
+			sb.WriteString(fmt.Sprintf("- ...and %d more.\n", len(deps)-i))
+			break
+		}
+		name := "`" + d.node.Name + "`"
+		if url := d.node.Metadata.DocURL; url != "" {
+			name = "[" + name + "](" + url + ")"
+		}
+		sb.WriteString(fmt.Sprintf("- %s (%s %s): used by %d symbol(s)\n", name, d.node.Metadata.Module, d.node.Metadata.Version, d.users))
+	}
+	return sb.String()
+}
+
+func rankExternalDeps(g *graph.Graph) []externalDep {
+	if g == nil {
+		return nil
+	}
+	users := make(map[string]map[string]bool)
+	for _, e := range g.Edges {
+		n, ok := g.Nodes[e.To]
+		if !ok || n.Unit == nil || n.Unit.UnitType != graph.UnitTypeExternal {
+			continue
+		}
+		if src, ok := g.Nodes[e.From]; ok && src.Unit != nil && src.Unit.UnitType == graph.UnitTypeTest {
+			continue
+		}
+		if users[e.To] == nil {
+			users[e.To] = make(map[string]bool)
+		}
+		users[e.To][e.From] = true
+	}
+	deps := make([]externalDep, 0, len(users))
+	for id, from := range users {
+		deps = append(deps, externalDep{node: g.Nodes[id].Unit, users: len(from)})
+	}
+	sort.Slice(deps, func(i, j int) bool {
+		if deps[i].users != deps[j].users {
+			return deps[i].users > deps[j].users
+		}
+		return deps[i].node.Name < deps[j].node.Name
+	})
+	return deps
+}
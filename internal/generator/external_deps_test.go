@@ -0,0 +1,43 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildExternalDepsMarkdown(t *testing.T) {
+	g := graph.NewGraph()
+	g.AddSymbol(&graph.Symbol{ID: "a", Name: "run", UnitType: "function"})
+	g.AddSymbol(&graph.Symbol{ID: "b", Name: "serve", UnitType: "function"})
+	g.AddSymbol(&graph.Symbol{ID: "t", Name: "TestRun", UnitType: graph.UnitTypeTest})
+	g.AddSymbol(&graph.Symbol{
+		ID: "external:github.com/spf13/cobra", Name: "github.com/spf13/cobra", UnitType: graph.UnitTypeExternal,
+		Metadata: graph.SymbolMetadata{Module: "github.com/spf13/cobra", Version: "v1.8.0", DocURL: "https://pkg.go.dev/github.com/spf13/cobra@v1.8.0"},
+	})
+	g.AddSymbol(&graph.Symbol{
+		ID: "external:gopkg.in/yaml.v3", Name: "gopkg.in/yaml.v3", UnitType: graph.UnitTypeExternal,
+		Metadata: graph.SymbolMetadata{Module: "gopkg.in/yaml.v3", Version: "v3.0.1", DocURL: "https://pkg.go.dev/gopkg.in/yaml.v3@v3.0.1"},
+	})
+	g.Edges = []graph.Edge{
+		{From: "a", To: "external:github.com/spf13/cobra", Kind: graph.RelationCalls},
+		{From: "b", To: "external:github.com/spf13/cobra", Kind: graph.RelationUsesType},
+		{From: "a", To: "external:gopkg.in/yaml.v3", Kind: graph.RelationCalls},
+		{From: "t", To: "external:gopkg.in/yaml.v3", Kind: graph.RelationCalls},
+	}
+
+	md := buildExternalDepsMarkdown(g, 10)
+	assert.Contains(t, md, externalDepsHeading)
+	assert.Contains(t, md, "[`github.com/spf13/cobra`](https://pkg.go.dev/github.com/spf13/cobra@v1.8.0) (github.com/spf13/cobra v1.8.0): used by 2 symbol(s)")
+	assert.Contains(t, md, "used by 1 symbol(s)")
+	assert.Less(t, strings.Index(md, "spf13/cobra"), strings.Index(md, "yaml.v3"))
+
+	assert.Contains(t, buildExternalDepsMarkdown(g, 1), "...and 1 more")
+	assert.Empty(t, buildExternalDepsMarkdown(graph.NewGraph(), 10))
+
+	content := appendSubsection("Intro.\n\n"+md, externalDepsHeading, md)
+	assert.Equal(t, 1, strings.Count(content, externalDepsHeading))
+}
@@ -0,0 +1,319 @@
+package generator
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	atomNamespace         = "http://www.w3.org/2005/Atom"
+	defaultFeedMaxEntries = 50
+	// feedExcerptLines caps the added lines quoted in an entry summary.
+	feedExcerptLines = 3
+)
+
+// Section change kinds reported by DiffSections.
+const (
+	SectionAdded   = "added"
+	SectionUpdated = "updated"
+	SectionRemoved = "removed"
+)
+
+// SectionChange is one section whose content differs between two models.
+type SectionChange struct {
+	SectionID string
+	Title     string
+	Kind      string
+	// Summary describes the diff: lines added and removed and an excerpt of
+	// the new text.
+	Summary string
+	// Hash is the section hash after the change, or before it for removals.
+	Hash string
+}
+
+// DiffSections returns the sections added, updated or removed between before
+// and after, in the order of after followed by removals. A nil before
+// reports every section as added.
+func DiffSections(before, after *DocModel) []SectionChange {
+	old := make(map[string]ModelSect)
+	if before != nil {
+		for _, sec := range before.Sections {
+			old[sec.ID] = sec
+		}
+	}
+	var changes []SectionChange
+	seen := make(map[string]bool)
+	if after != nil {
+		for _, sec := range sortedModelSections(after.Sections) {
+			seen[sec.ID] = true
+			prev, ok := old[sec.ID]
+			switch {
+			case !ok:
+				changes = append(changes, SectionChange{
+					SectionID: sec.ID, Title: sec.Title, Kind: SectionAdded, Hash: sec.Hash,
+					Summary: summarizeLineDiff("", sec.ContentMD),
+				})
+			case prev.ContentMD != sec.ContentMD || prev.Title != sec.Title:
+				changes = append(changes, SectionChange{
+					SectionID: sec.ID, Title: sec.Title, Kind: SectionUpdated, Hash: sec.Hash,
+					Summary: summarizeLineDiff(prev.ContentMD, sec.ContentMD),
+				})
+			}
+		}
+	}
+	if before != nil {
+		for _, sec := range sortedModelSections(before.Sections) {
+			if !seen[sec.ID] {
+				changes = append(changes, SectionChange{
+					SectionID: sec.ID, Title: sec.Title, Kind: SectionRemoved, Hash: sec.Hash,
+					Summary: "Section removed.",
+				})
+			}
+		}
+	}
+	return changes
+}
+
+func sortedModelSections(sections []ModelSect) []ModelSect {
+	out := append([]ModelSect(nil), sections...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Level != out[j].Level {
+			return out[i].Level < out[j].Level
+		}
+		return out[i].Order < out[j].Order
+	})
+	return out
+}
+
+// summarizeLineDiff counts the non-blank lines only in after (added) and only
+// in before (removed) and quotes the first added lines.
+func summarizeLineDiff(before, after string) string {
+	remaining := make(map[string]int)
+	for _, line := range strings.Split(before, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			remaining[line]++
+		}
+	}
+	var added []string
+	for _, line := range strings.Split(after, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if remaining[line] > 0 {
+			remaining[line]--
+			continue
+		}
+		added = append(added, line)
+	}
+	removed := 0
+	for _, n := range remaining {
+		removed += n
+	}
+	if len(added) == 0 && removed == 0 {
+		return "Formatting changed."
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d %s added, %d removed.", len(added), plural(len(added), "line", "lines"), removed)
+	for i, line := range added {
+		if i == feedExcerptLines {
+			fmt.Fprintf(&b, "\n…and %d more.", len(added)-i)
+			break
+		}
+		b.WriteString("\n+ " + truncateRunes(line, 160))
+	}
+	return b.String()
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "…"
+}
+
+// AtomFeed is an Atom 1.0 feed document.
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  AtomPerson  `xml:"author"`
+	Links   []AtomLink  `xml:"link"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+// AtomPerson names a feed author.
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+// AtomLink is an Atom link element.
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+// AtomEntry is one documentation change.
+type AtomEntry struct {
+	ID         string       `xml:"id"`
+	Title      string       `xml:"title"`
+	Updated    string       `xml:"updated"`
+	Links      []AtomLink   `xml:"link"`
+	Categories []AtomTerm   `xml:"category"`
+	Summary    string       `xml:"summary"`
+	Content    *AtomContent `xml:"content,omitempty"`
+}
+
+// AtomTerm is an Atom category.
+type AtomTerm struct {
+	Term string `xml:"term,attr"`
+}
+
+// AtomContent is entry content of the given type.
+type AtomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// FeedOptions shapes the entries AppendFeed writes.
+type FeedOptions struct {
+	// Title defaults to "<document title> documentation changes".
+	Title string
+	// Link is the published documentation URL; entries link to
+	// Link#section-id.
+	Link string
+	// MaxEntries keeps the newest entries; 0 keeps defaultFeedMaxEntries.
+	MaxEntries int
+	// Commit is the commit the documentation was synced at.
+	Commit string
+	// Now stamps new entries; zero uses the current time.
+	Now time.Time
+}
+
+// AppendFeed prepends one entry per change to the Atom feed at path, creating
+// it when missing, and drops the oldest entries beyond MaxEntries. It returns
+// the number of entries added.
+func AppendFeed(path string, model *DocModel, changes []SectionChange, opts FeedOptions) (int, error) {
+	if len(changes) == 0 {
+		return 0, nil
+	}
+	feed, err := LoadFeed(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	if feed == nil {
+		feed = &AtomFeed{}
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	stamp := now.UTC().Format(time.RFC3339)
+	docID := "docod"
+	docTitle := "Documentation"
+	if model != nil {
+		if model.Document.ID != "" {
+			docID = model.Document.ID
+		}
+		if model.Document.Title != "" {
+			docTitle = model.Document.Title
+		}
+	}
+
+	feed.XMLNS = atomNamespace
+	if feed.ID == "" {
+		feed.ID = "urn:docod:" + docID + ":changes"
+	}
+	feed.Title = opts.Title
+	if feed.Title == "" {
+		feed.Title = docTitle + " documentation changes"
+	}
+	feed.Updated = stamp
+	feed.Author = AtomPerson{Name: "docod"}
+	feed.Links = nil
+	if opts.Link != "" {
+		feed.Links = []AtomLink{{Href: opts.Link}}
+	}
+
+	entries := make([]AtomEntry, 0, len(changes)+len(feed.Entries))
+	for _, c := range changes {
+		entries = append(entries, feedEntry(docID, c, opts, stamp))
+	}
+	feed.Entries = append(entries, feed.Entries...)
+	limit := opts.MaxEntries
+	if limit <= 0 {
+		limit = defaultFeedMaxEntries
+	}
+	if len(feed.Entries) > limit {
+		feed.Entries = feed.Entries[:limit]
+	}
+	return len(changes), SaveFeed(path, feed)
+}
+
+func feedEntry(docID string, c SectionChange, opts FeedOptions, stamp string) AtomEntry {
+	verb := map[string]string{SectionAdded: "Added", SectionUpdated: "Updated", SectionRemoved: "Removed"}[c.Kind]
+	version := c.Hash
+	if version == "" {
+		version = stamp
+	}
+	e := AtomEntry{
+		// The section hash makes the ID stable for one version of a section,
+		// so readers do not show a rerun as a new change.
+		ID:         fmt.Sprintf("urn:docod:%s:%s:%s:%s", docID, c.SectionID, c.Kind, strings.TrimPrefix(version, "sha256:")),
+		Title:      verb + ": " + c.Title,
+		Updated:    stamp,
+		Categories: []AtomTerm{{Term: c.Kind}},
+		Summary:    c.Summary,
+	}
+	if opts.Link != "" && c.Kind != SectionRemoved {
+		e.Links = []AtomLink{{Href: strings.TrimRight(opts.Link, "#") + "#" + c.SectionID}}
+	}
+	content := c.Summary
+	if opts.Commit != "" {
+		content += "\n\nCommit: " + opts.Commit
+		e.Categories = append(e.Categories, AtomTerm{Term: "commit:" + shortSHA(opts.Commit)})
+	}
+	e.Content = &AtomContent{Type: "text", Body: content}
+	return e
+}
+
+// LoadFeed reads an Atom feed written by AppendFeed.
+func LoadFeed(path string) (*AtomFeed, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var feed AtomFeed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("parse feed %s: %w", path, err)
+	}
+	return &feed, nil
+}
+
+// SaveFeed writes feed to path.
+func SaveFeed(path string, feed *AtomFeed) error {
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), append(data, '\n')...), 0644)
+}
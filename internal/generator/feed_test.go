@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSections(t *testing.T) {
+	before := &DocModel{Sections: []ModelSect{
+		{ID: "overview", Title: "Overview", Order: 0, ContentMD: "# Overview\n\nA tool.", Hash: "h1"},
+		{ID: "usage", Title: "Usage", Order: 1, ContentMD: "Run it.", Hash: "h2"},
+		{ID: "legacy", Title: "Legacy", Order: 2, ContentMD: "Old.", Hash: "h3"},
+	}}
+	after := &DocModel{Sections: []ModelSect{
+		{ID: "overview", Title: "Overview", Order: 0, ContentMD: "# Overview\n\nA tool.", Hash: "h1"},
+		{ID: "usage", Title: "Usage", Order: 1, ContentMD: "Run it.\n\nThen **check** the report.\n- a\n- b\n- c", Hash: "h2b"},
+		{ID: "api", Title: "API", Order: 3, ContentMD: "One call.", Hash: "h4"},
+	}}
+
+	changes := DiffSections(before, after)
+	require.Len(t, changes, 3)
+	assert.Equal(t, SectionChange{SectionID: "usage", Title: "Usage", Kind: SectionUpdated, Hash: "h2b",
+		Summary: "4 lines added, 0 removed.\n+ Then **check** the report.\n+ - a\n+ - b\n…and 1 more."}, changes[0])
+	assert.Equal(t, SectionAdded, changes[1].Kind)
+	assert.Equal(t, "1 line added, 0 removed.\n+ One call.", changes[1].Summary)
+	assert.Equal(t, SectionRemoved, changes[2].Kind)
+	assert.Equal(t, "legacy", changes[2].SectionID)
+
+	assert.Len(t, DiffSections(nil, after), 3, "a first generation adds every section")
+}
+
+func TestAppendFeed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docs", "changes.atom")
+	model := &DocModel{Document: ModelDoc{ID: "payments", Title: "Payments"}}
+	opts := FeedOptions{Link: "https://docs.example.com/payments", MaxEntries: 3, Commit: "0123456789abcdef", Now: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	n, err := AppendFeed(path, model, []SectionChange{
+		{SectionID: "usage", Title: "Usage", Kind: SectionUpdated, Summary: "1 line added, 0 removed.", Hash: "sha256:aa"},
+		{SectionID: "legacy", Title: "Legacy", Kind: SectionRemoved, Summary: "Section removed.", Hash: "sha256:bb"},
+	}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	feed, err := LoadFeed(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Payments documentation changes", feed.Title)
+	assert.Equal(t, "urn:docod:payments:changes", feed.ID)
+	require.Len(t, feed.Entries, 2)
+	e := feed.Entries[0]
+	assert.Equal(t, "Updated: Usage", e.Title)
+	assert.Equal(t, "urn:docod:payments:usage:updated:aa", e.ID)
+	assert.Equal(t, "2026-01-02T03:04:05Z", e.Updated)
+	assert.Equal(t, []AtomLink{{Href: "https://docs.example.com/payments#usage"}}, e.Links)
+	assert.Equal(t, []AtomTerm{{Term: "updated"}, {Term: "commit:0123456"}}, e.Categories)
+	assert.True(t, strings.HasSuffix(e.Content.Body, "Commit: 0123456789abcdef"))
+	assert.Empty(t, feed.Entries[1].Links, "removed sections have no anchor to link")
+
+	opts.Now = opts.Now.Add(time.Hour)
+	_, err = AppendFeed(path, model, []SectionChange{
+		{SectionID: "api", Title: "API", Kind: SectionAdded, Hash: "sha256:cc"},
+		{SectionID: "usage", Title: "Usage", Kind: SectionUpdated, Hash: "sha256:dd"},
+	}, opts)
+	require.NoError(t, err)
+	feed, err = LoadFeed(path)
+	require.NoError(t, err)
+	require.Len(t, feed.Entries, 3, "the oldest entries are dropped")
+	assert.Equal(t, "Added: API", feed.Entries[0].Title)
+	assert.Equal(t, "urn:docod:payments:usage:updated:aa", feed.Entries[2].ID)
+	assert.Equal(t, "2026-01-02T04:04:05Z", feed.Updated)
+
+	n, err = AppendFeed(path, model, nil, opts)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
@@ -1,6 +1,11 @@
 package generator
 
-import "strings"
+import (
+	"strings"
+
+	"docod/internal/graph"
+	"docod/internal/knowledge"
+)
 
 // FullDocPlan defines section-level contracts for full documentation generation.
 type FullDocPlan struct {
@@ -19,8 +24,22 @@ type SectionDocPlan struct {
 	MinEvidence       int
 	RequireMermaid    bool
 	AllowLLM          bool
+	// Diversity selects how evidence is diversified: DiversityFile (default)
+	// caps chunks per file, DiversityMMR uses maximal marginal relevance.
+	Diversity string
+	// MMRLambda trades relevance (1) against diversity (0) for DiversityMMR.
+	MMRLambda float64
+	// Filter restricts retrieval at the query level.
+	Filter knowledge.SearchFilter
 }
 
+const (
+	DiversityFile = "file"
+	DiversityMMR  = "mmr"
+
+	defaultMMRLambda = 0.7
+)
+
 func BuildDefaultFullDocPlan() *FullDocPlan {
 	return &FullDocPlan{Sections: []SectionDocPlan{
 		{
@@ -34,6 +53,7 @@ func BuildDefaultFullDocPlan() *FullDocPlan {
 			MinEvidence:       6,
 			RequireMermaid:    true,
 			AllowLLM:          false,
+			Filter:            knowledge.SearchFilter{ExcludeUnitTypes: []string{"constant", "variable"}},
 		},
 		{
 			SectionID:         "key-features",
@@ -46,6 +66,11 @@ func BuildDefaultFullDocPlan() *FullDocPlan {
 			MinEvidence:       8,
 			RequireMermaid:    false,
 			AllowLLM:          true,
+			// Prefer semantic behavior units over physical module wrappers.
+			Filter: knowledge.SearchFilter{
+				ExcludeUnitTypes: []string{"file_module", graph.UnitTypePackageSummary, "constant", "variable"},
+				ExcludeTests:     true,
+			},
 		},
 		{
 			SectionID:         "development",
@@ -58,10 +83,27 @@ func BuildDefaultFullDocPlan() *FullDocPlan {
 			MinEvidence:       5,
 			RequireMermaid:    true,
 			AllowLLM:          false,
+			Filter:            knowledge.SearchFilter{ExcludeTests: true},
 		},
 	}}
 }
 
+// ApplyDiversityDefaults fills sections without an explicit diversity mode.
+func (p *FullDocPlan) ApplyDiversityDefaults(mode string, lambda float64) {
+	if p == nil {
+		return
+	}
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	for i := range p.Sections {
+		if p.Sections[i].Diversity == "" {
+			p.Sections[i].Diversity = mode
+		}
+		if p.Sections[i].MMRLambda <= 0 {
+			p.Sections[i].MMRLambda = lambda
+		}
+	}
+}
+
 func (p *FullDocPlan) SectionByID(id string) (SectionDocPlan, bool) {
 	if p == nil {
 		return SectionDocPlan{}, false
@@ -1,6 +1,8 @@
 package generator
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,3 +20,57 @@ func TestBuildDefaultFullDocPlan(t *testing.T) {
 	assert.Greater(t, overview.TopK, 0)
 	assert.NotEmpty(t, overview.QueryText())
 }
+
+func TestFullDocPlan_ApplyDiversityDefaults(t *testing.T) {
+	plan := BuildDefaultFullDocPlan()
+	plan.Sections[0].Diversity = DiversityFile
+	plan.ApplyDiversityDefaults(" MMR ", 0.6)
+
+	assert.Equal(t, DiversityFile, plan.Sections[0].Diversity)
+	assert.Equal(t, DiversityMMR, plan.Sections[1].Diversity)
+	assert.Equal(t, 0.6, plan.Sections[1].MMRLambda)
+}
+
+func TestLoadFullDocPlan_OverridesAndAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "docplan.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+sections:
+  - id: overview
+    top_k: 24
+    allow_llm: true
+    query_hints: ["entry points"]
+  - id: operations
+    title: Operations
+    min_evidence: 3
+    filter:
+      path_prefixes: ["deploy/"]
+`), 0644))
+
+	plan, err := LoadFullDocPlan(path)
+	require.NoError(t, err)
+
+	overview, ok := plan.SectionByID("overview")
+	require.True(t, ok)
+	assert.Equal(t, 24, overview.TopK)
+	assert.True(t, overview.AllowLLM)
+	assert.Equal(t, []string{"entry points"}, overview.QueryHints)
+	assert.Equal(t, 6, overview.MinEvidence, "unset fields keep defaults")
+	assert.True(t, overview.RequireMermaid)
+
+	ops, ok := plan.SectionByID("operations")
+	require.True(t, ok)
+	assert.Equal(t, "Operations", ops.Title)
+	assert.Equal(t, 3, ops.MinEvidence)
+	assert.Equal(t, []string{"deploy/"}, ops.Filter.PathPrefixes)
+}
+
+func TestLoadFullDocPlan_MissingAndInvalid(t *testing.T) {
+	plan, err := LoadFullDocPlan(filepath.Join(t.TempDir(), "absent.yaml"))
+	require.NoError(t, err)
+	assert.Len(t, plan.Sections, len(BuildDefaultFullDocPlan().Sections))
+
+	path := filepath.Join(t.TempDir(), "docplan.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("sections:\n  - id: overview\n    diversity: random\n"), 0644))
+	_, err = LoadFullDocPlan(path)
+	assert.ErrorContains(t, err, "diversity")
+}
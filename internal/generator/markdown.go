@@ -2,13 +2,18 @@ package generator
 
 import (
 	"context"
+	"docod/internal/analysis"
+	"docod/internal/config"
+	"docod/internal/graph"
 	"docod/internal/knowledge"
+	"docod/internal/profiling"
+	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -17,6 +22,9 @@ type MarkdownGenerator struct {
 	engine     *knowledge.Engine
 	summarizer knowledge.Summarizer
 	mermaid    *MermaidGenerator
+
+	// restart ignores the checkpoint of an interrupted run.
+	restart bool
 }
 
 type sectionEvidencePack struct {
@@ -31,6 +39,77 @@ type sectionGenerationTrace struct {
 	UsedDraft    bool
 	UsedLLM      bool
 	UsedFallback bool
+	// LLMFailed is set when an LLM call of the section returned an error.
+	LLMFailed bool
+}
+
+// sectionLLMBudget caps the LLM rewrites of a full generation. A rewrite
+// takes from it before the call and refunds it when the call yields nothing.
+// Sections take in document order: a section's take waits until every
+// earlier section has settled, so concurrent runs hand the budget to the
+// same sections a sequential run would.
+type sectionLLMBudget struct {
+	mu      sync.Mutex
+	settled sync.Cond
+	left    int
+	done    []bool
+	// next is the first section that has not settled.
+	next int
+}
+
+func newSectionLLMBudget(n, sections int) *sectionLLMBudget {
+	b := &sectionLLMBudget{left: n, done: make([]bool, sections)}
+	b.settled.L = &b.mu
+	return b
+}
+
+// forSection returns the budget as seen by section i.
+func (b *sectionLLMBudget) forSection(i int) sectionLLMTurn {
+	return sectionLLMTurn{budget: b, section: i}
+}
+
+// settle marks section i finished with the budget.
+func (b *sectionLLMBudget) settle(i int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.done[i] = true
+	for b.next < len(b.done) && b.done[b.next] {
+		b.next++
+	}
+	b.settled.Broadcast()
+}
+
+// sectionLLMTurn is the LLM budget of one section.
+type sectionLLMTurn struct {
+	budget  *sectionLLMBudget
+	section int
+}
+
+func (t sectionLLMTurn) take() bool {
+	b := t.budget
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.next < t.section {
+		b.settled.Wait()
+	}
+	if b.left <= 0 {
+		return false
+	}
+	b.left--
+	return true
+}
+
+func (t sectionLLMTurn) refund() {
+	b := t.budget
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.left++
+	b.mu.Unlock()
 }
 
 func NewMarkdownGenerator(e *knowledge.Engine, s knowledge.Summarizer) *MarkdownGenerator {
@@ -41,6 +120,12 @@ func NewMarkdownGenerator(e *knowledge.Engine, s knowledge.Summarizer) *Markdown
 	}
 }
 
+// SetRestart makes the next run generate every section again instead of
+// resuming from the checkpoint of an interrupted run.
+func (g *MarkdownGenerator) SetRestart(restart bool) {
+	g.restart = restart
+}
+
 // GenerateDocs builds docs from KG/index retrieval and writes model + markdown.
 func (g *MarkdownGenerator) GenerateDocs(ctx context.Context, outputDir string) error {
 	report := NewPipelineReport("full_generate", outputDir)
@@ -52,7 +137,8 @@ func (g *MarkdownGenerator) GenerateDocsWithReport(ctx context.Context, outputDi
 	if report == nil {
 		report = NewPipelineReport("full_generate", outputDir)
 	}
-	reportPath := filepath.Join(outputDir, "pipeline_report.json")
+	paths := config.ResolveOutputPaths().WithDir(outputDir)
+	reportPath := paths.Report()
 	defer func() {
 		if retErr != nil {
 			report.AddSignal("full_generate_failed", "generator", "critical", "Full documentation generation failed.", 1)
@@ -83,9 +169,26 @@ func (g *MarkdownGenerator) GenerateDocsWithReport(ctx context.Context, outputDi
 		fmt.Println("⚠️  No searchable chunks found. Generating skeletal documentation.")
 	}
 
-	model := g.buildSchemaScaffoldModel(now)
-	fullPlan := BuildDefaultFullDocPlan()
-	llmBudget := 1
+	stage = report.BeginStage("cycle_detection")
+	cycles := analysis.DetectCycles(g.engine.Graph())
+	recordCycles(report, cycles)
+	report.EndStage(stage, "ok", map[string]float64{
+		"package_cycles": float64(len(cycles.PackageCycles)),
+		"symbol_cycles":  float64(len(cycles.SymbolCycles)),
+	}, nil, nil)
+	opts := resolveGeneratorOptions()
+
+	fullPlan := resolveFullDocPlan(opts)
+	model := g.buildSchemaScaffoldModel(now, canonicalSections(opts.preset), fullPlan)
+	applyStyleConfig(model, opts.style, opts.sectionStyles)
+	checkpoint := LoadGenerateCheckpoint(paths.Checkpoint(), checkpointFingerprint(allChunks, model))
+	if g.restart {
+		checkpoint.Sections = map[string]CheckpointSection{}
+	} else if n := len(checkpoint.Sections); n > 0 {
+		fmt.Printf("⏩ Resuming: %d of %d sections finished by an interrupted run.\n", n, len(model.Sections))
+		report.AddSignal("generate_resumed", "generator", "info", "Sections were reused from the checkpoint of an interrupted run.", float64(n))
+	}
+	llmBudget := newSectionLLMBudget(1, len(model.Sections))
 	keyFeaturePlan, _ := fullPlan.SectionByID("key-features")
 	if strings.TrimSpace(keyFeaturePlan.SectionID) == "" {
 		keyFeaturePlan = SectionDocPlan{
@@ -99,9 +202,35 @@ func (g *MarkdownGenerator) GenerateDocsWithReport(ctx context.Context, outputDi
 	}
 	keyFeatureSeed := g.selectSectionEvidence(ctx, keyFeaturePlan, allChunks, nil)
 	globalCapabilities := ExtractCapabilities(keyFeatureSeed.Chunks, 6)
-	for i := range model.Sections {
+
+	// Sections only read the shared chunks and write their own entry of
+	// model.Sections and metrics, so up to sectionConcurrency of them run at
+	// once; their model calls share the embedder's and summarizer's rate limit.
+	metrics := make([]SectionMetric, len(model.Sections))
+	var (
+		mu        sync.Mutex
+		llmFailed int
+		stopped   = -1
+	)
+	generateSection := func(i int) {
+		defer llmBudget.settle(i)
 		sec := &model.Sections[i]
-		sectionStage := report.BeginStage("section_" + sec.ID)
+		if err := ctx.Err(); err != nil {
+			mu.Lock()
+			if stopped < 0 || i < stopped {
+				stopped = i
+			}
+			mu.Unlock()
+			return
+		}
+		if done, ok := checkpoint.Section(sec.ID); ok {
+			*sec = done.Section
+			metric := done.Metric
+			metric.Usage = nil
+			metrics[i] = metric
+			return
+		}
+		sectionStage := report.BeginSectionStage(sec.ID)
 		secPlan, ok := fullPlan.SectionByID(sec.ID)
 		if !ok {
 			secPlan = fallbackSectionPlan(*sec)
@@ -110,12 +239,15 @@ func (g *MarkdownGenerator) GenerateDocsWithReport(ctx context.Context, outputDi
 		if sec.ID == "key-features" {
 			secCaps = globalCapabilities
 		}
-		pack := g.selectSectionEvidence(ctx, secPlan, allChunks, secCaps)
+		packs := &knowledge.PackTally{}
+		usageCtx := knowledge.WithPackTally(knowledge.WithUsageSection(ctx, sec.ID), packs)
+		pack := g.selectSectionEvidence(usageCtx, secPlan, allChunks, secCaps)
 		sectionChunks := pack.Chunks
 		if sec.ID == "key-features" && len(secCaps) == 0 {
 			secCaps = ExtractCapabilities(sectionChunks, 6)
 		}
-		content, trace := g.generateSectionContent(ctx, *sec, secPlan, sectionChunks, secCaps, &llmBudget)
+		secCtx := knowledge.WithSectionStyle(usageCtx, model.Policies.StyleFor(sec.ID))
+		content, trace := g.generateSectionContent(secCtx, *sec, secPlan, sectionChunks, secCaps, llmBudget.forSection(i))
 		if pack.Stats != nil && pack.Stats.LowEvidence {
 			content = applyLowEvidencePolicy(content)
 			report.AddSignal("low_evidence_section", "section_"+sec.ID, "warning", "Section evidence is below required threshold.", pack.Stats.Confidence)
@@ -129,6 +261,12 @@ func (g *MarkdownGenerator) GenerateDocsWithReport(ctx context.Context, outputDi
 				report.AddSignal("heuristic_dominant", "section_"+sec.ID, "warning", "Heuristic retrieval dominates section evidence selection.", heuristicShare)
 			}
 		}
+		if sec.ID == "development" && opts.documentCycles {
+			content = appendConstraints(content, buildConstraintsMarkdown(cycles, 10))
+		}
+		if sec.ID == "overview" && opts.documentExternalDeps {
+			content = appendSubsection(content, externalDepsHeading, buildExternalDepsMarkdown(g.engine.Graph(), 10))
+		}
 		wq := assessWriterQuality(sec.ID, content)
 		if wq.Score < 0.55 {
 			report.AddSignal("writer_quality_low", "section_"+sec.ID, "warning", "Writer quality score is below target threshold.", wq.Score)
@@ -143,13 +281,15 @@ func (g *MarkdownGenerator) GenerateDocsWithReport(ctx context.Context, outputDi
 		chunkCount := len(sectionChunks)
 		confidence := 0.0
 		coverage := 0.0
+		relevance := 0.0
 		lowEvidence := false
 		if pack.Stats != nil {
 			confidence = pack.Stats.Confidence
 			coverage = pack.Stats.Coverage
+			relevance = pack.Stats.Relevance
 			lowEvidence = pack.Stats.LowEvidence
 		}
-		report.AddSectionMetric(SectionMetric{
+		metric := SectionMetric{
 			SectionID:           sec.ID,
 			Title:               sec.Title,
 			QueryCount:          len(pack.Queries),
@@ -160,13 +300,16 @@ func (g *MarkdownGenerator) GenerateDocsWithReport(ctx context.Context, outputDi
 			FileDiversity:       uniqueFileCount(sectionChunks),
 			EvidenceConfidence:  confidence,
 			EvidenceCoverage:    coverage,
+			EvidenceRelevance:   relevance,
 			LowEvidence:         lowEvidence,
 			WriterQualityScore:  wq.Score,
 			WriterQualityIssues: wq.Issues,
 			UsedDraft:           trace.UsedDraft,
 			UsedLLM:             trace.UsedLLM,
 			UsedFallback:        trace.UsedFallback,
-		})
+		}
+		metrics[i] = metric
+		packed := packs.Total()
 		report.EndStage(sectionStage, "ok", map[string]float64{
 			"queries":        float64(len(pack.Queries)),
 			"search_hits":    float64(pack.SearchHits),
@@ -175,14 +318,69 @@ func (g *MarkdownGenerator) GenerateDocsWithReport(ctx context.Context, outputDi
 			"source_count":   float64(sourceCount),
 			"file_diversity": float64(uniqueFileCount(sectionChunks)),
 			"evidence_confidence": confidence,
+			"evidence_relevance": relevance,
 			"writer_quality": wq.Score,
+			"packed_tokens":  float64(packed.UsedTokens),
+			"budget_tokens":  float64(packed.BudgetTokens),
+			"packed_dropped_chunks": float64(packed.Dropped),
 		}, nil, nil)
+		if trace.LLMFailed {
+			// Left out of the checkpoint so the next run retries it.
+			mu.Lock()
+			llmFailed++
+			mu.Unlock()
+			report.AddSignal("section_llm_failed", "section_"+sec.ID, "warning", "An LLM call failed; the section is regenerated on the next run.", 1)
+		} else if err := checkpoint.Complete(*sec, metric); err != nil {
+			fmt.Printf("⚠️  Failed to write generation checkpoint: %v\n", err)
+		}
+	}
+	workers := min(max(opts.sectionConcurrency, 1), len(model.Sections))
+	endProfile := profiling.Stage("sections")
+	if workers <= 1 {
+		for i := range model.Sections {
+			generateSection(i)
+		}
+	} else {
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					generateSection(i)
+				}
+			}()
+		}
+		for i := range model.Sections {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+	}
+	endProfile()
+	sectionIDs := make([]string, len(model.Sections))
+	for i, sec := range model.Sections {
+		sectionIDs[i] = sec.ID
+	}
+	report.orderSectionStages(sectionIDs)
+	for _, metric := range metrics {
+		report.AddSectionMetric(metric)
+	}
+	if stopped >= 0 {
+		return fmt.Errorf("generation stopped before section %s: %w", model.Sections[stopped].ID, ctx.Err())
+	}
+
+	if opts.apiReference {
+		stage = report.BeginStage("api_reference")
+		UpdateAPIReferenceSection(model, g.engine.Graph(), ".")
+		report.EndStage(stage, "ok", nil, nil, nil)
 	}
 
 	model.Meta.GeneratedAt = now
 	NormalizeDocModel(model)
 
-	modelPath := filepath.Join(outputDir, "doc_model.json")
+	modelPath := paths.Model()
 	stage = report.BeginStage("save_doc_model")
 	if err := SaveDocModel(modelPath, model); err != nil {
 		report.EndStage(stage, "error", nil, nil, err)
@@ -192,7 +390,7 @@ func (g *MarkdownGenerator) GenerateDocsWithReport(ctx context.Context, outputDi
 		"sections_total": float64(len(model.Sections)),
 	}, nil, nil)
 
-	path := filepath.Join(outputDir, "documentation.md")
+	path := paths.Doc()
 	stage = report.BeginStage("render_markdown")
 	rendered := RenderMarkdownFromModel(model)
 	if err := os.WriteFile(path, []byte(rendered), 0644); err != nil {
@@ -202,21 +400,59 @@ func (g *MarkdownGenerator) GenerateDocsWithReport(ctx context.Context, outputDi
 	report.EndStage(stage, "ok", map[string]float64{
 		"rendered_bytes": float64(len(rendered)),
 	}, nil, nil)
+
+	if opts.componentPages {
+		stage = report.BeginStage("component_pages")
+		pages, err := g.writeComponentPages(outputDir, 12)
+		if err != nil {
+			report.EndStage(stage, "error", nil, nil, err)
+			return fmt.Errorf("failed to write component pages: %w", err)
+		}
+		report.EndStage(stage, "ok", map[string]float64{
+			"components_total": float64(len(g.engine.Components())),
+			"pages_written":    float64(pages),
+		}, nil, nil)
+	}
+	if opts.packagePages {
+		stage = report.BeginStage("package_pages")
+		plan := BuildPackageDocPlan(g.engine.Graph(), ".")
+		pages, err := writePackagePages(outputDir, plan)
+		if err != nil {
+			report.EndStage(stage, "error", nil, nil, err)
+			return fmt.Errorf("failed to write package pages: %w", err)
+		}
+		report.EndStage(stage, "ok", map[string]float64{
+			"packages_total": float64(len(plan.Pages)),
+			"pages_written":  float64(pages),
+		}, nil, nil)
+	}
+	if llmFailed > 0 {
+		fmt.Printf("⏸️  %d sections fell back after LLM errors; run generate again to retry them.\n", llmFailed)
+	} else if err := checkpoint.Remove(); err != nil {
+		fmt.Printf("⚠️  Failed to remove generation checkpoint: %v\n", err)
+	}
 	report.AddSignal("full_generate_complete", "generator", "info", "Full generation completed successfully.", 1)
 	return nil
 }
 
-func (g *MarkdownGenerator) buildSchemaScaffoldModel(now string) *DocModel {
-	sections := make([]ModelSect, 0, len(canonicalSectionOrder))
-	for i, id := range canonicalSectionOrder {
+func (g *MarkdownGenerator) buildSchemaScaffoldModel(now string, order []string, plan *FullDocPlan) *DocModel {
+	sections := make([]ModelSect, 0, len(order))
+	for i, id := range order {
 		title := sectionTitleFromID(id)
+		scaffold := sectionScaffold(id, title)
+		if secPlan, ok := plan.SectionByID(id); ok {
+			if strings.TrimSpace(secPlan.Title) != "" {
+				title = secPlan.Title
+			}
+			scaffold = planScaffold(secPlan, title)
+		}
 		sec := ModelSect{
 			ID:        id,
 			Title:     title,
 			Level:     1,
 			Order:     i,
 			ParentID:  nil,
-			ContentMD: sectionScaffold(id, title),
+			ContentMD: scaffold,
 			Summary:   "",
 			Status:    "active",
 			Sources:   []SourceRef{},
@@ -234,11 +470,11 @@ func (g *MarkdownGenerator) buildSchemaScaffoldModel(now string) *DocModel {
 		Document: ModelDoc{
 			ID:             "docod-main-doc",
 			Title:          "Project Documentation",
-			RootSectionIDs: append([]string(nil), canonicalSectionOrder...),
+			RootSectionIDs: append([]string(nil), order...),
 		},
 		Sections: sections,
 		Policies: ModelPolicy{
-			RequiredSectionIDs: append([]string(nil), canonicalSectionOrder...),
+			RequiredSectionIDs: append([]string(nil), order...),
 			MaxSectionChars:    8000,
 			Style: PolicyStyle{
 				Tone:                       "technical, objective",
@@ -283,33 +519,55 @@ func (g *MarkdownGenerator) selectSectionEvidence(ctx context.Context, secPlan S
 	}
 	selected := make([]knowledge.SearchChunk, 0, topK*2)
 	searchHits := 0
-	for _, q := range queries {
-		q = strings.TrimSpace(q)
-		if q == "" {
-			continue
+	if g.engine.HasCoarseLayer() {
+		for _, q := range queries {
+			q = strings.TrimSpace(q)
+			if q == "" {
+				continue
+			}
+			hits, err := g.engine.SearchHierarchical(ctx, q, perQueryTopK, secPlan.Filter)
+			if err != nil {
+				continue
+			}
+			searchHits += len(hits)
+			selected = append(selected, hits...)
 		}
-		hits, err := g.engine.SearchByText(ctx, q, perQueryTopK, "")
-		if err != nil {
-			continue
+	} else if batches, err := g.engine.SearchByTexts(ctx, queries, perQueryTopK, secPlan.Filter); err == nil {
+		// One embedding batch and one index pass for all section queries.
+		for _, hits := range batches {
+			searchHits += len(hits)
+			selected = append(selected, hits...)
 		}
-		searchHits += len(hits)
-		selected = append(selected, hits...)
+	}
+	if secPlan.SectionID == "overview" {
+		// Package summaries are computed from the graph and lead overview evidence.
+		selected = mergeChunkLists(packageSummaryChunks(allChunks, topK/3), selected, topK*2)
 	}
 	selected = mergeChunkLists(nil, selected, topK*2)
-	selected = filterChunksForSection(secPlan.SectionID, selected)
+	if searchHits > 0 {
+		// Pull in graph neighbors of the symbols the queries hit.
+		selected = g.engine.ExpandWithNeighbors(selected, topK/2)
+	}
+	// Package summaries and graph neighbors bypass the index, so filter again.
+	selected = filterChunksWithFallback(secPlan.Filter, selected)
 
 	heuristicHits := 0
 	if len(selected) < topK/2 {
 		heuristic := heuristicSelectChunks(allChunks, secPlan.RetrievalKeywords, topK)
-		heuristic = filterChunksForSection(secPlan.SectionID, heuristic)
+		heuristic = filterChunksWithFallback(secPlan.Filter, heuristic)
 		heuristicHits = len(heuristic)
 		selected = mergeChunkLists(selected, heuristic, topK)
 	}
 
 	if len(selected) == 0 {
-		selected = topNChunks(filterChunksForSection(secPlan.SectionID, allChunks), topK)
+		selected = topNChunks(filterChunksWithFallback(secPlan.Filter, allChunks), topK)
 	}
-	selected = DiversityRerank(selected, topK, 2)
+	if reranked, err := g.engine.Rerank(ctx, secPlan.QueryText(), selected, 0); err == nil {
+		selected = reranked
+	} else {
+		fmt.Printf("⚠️  Rerank skipped for section %s: %v\n", secPlan.SectionID, err)
+	}
+	selected = g.diversify(ctx, secPlan, selected, topK)
 	stats := buildEvidenceStats(secPlan, queries, selected)
 	return sectionEvidencePack{
 		Queries:       queries,
@@ -320,6 +578,43 @@ func (g *MarkdownGenerator) selectSectionEvidence(ctx context.Context, secPlan S
 	}
 }
 
+// diversify trims evidence to limit using the section's diversity mode.
+// MMR falls back to the per-file heuristic when embeddings are unavailable.
+func (g *MarkdownGenerator) diversify(ctx context.Context, secPlan SectionDocPlan, chunks []knowledge.SearchChunk, limit int) []knowledge.SearchChunk {
+	if secPlan.Diversity != DiversityMMR || len(chunks) <= limit {
+		return DiversityRerank(chunks, limit, 2)
+	}
+	ids := make([]string, 0, len(chunks))
+	for _, c := range chunks {
+		ids = append(ids, c.ID)
+	}
+	vectors, err := g.engine.ChunkEmbeddings(ctx, ids)
+	if err != nil || len(vectors) == 0 {
+		return DiversityRerank(chunks, limit, 2)
+	}
+	lambda := secPlan.MMRLambda
+	if lambda <= 0 {
+		lambda = defaultMMRLambda
+	}
+	return knowledge.MMRSelect(chunks, vectors, limit, lambda)
+}
+
+// packageSummaryChunks returns up to limit package summary chunks, most
+// central first.
+func packageSummaryChunks(chunks []knowledge.SearchChunk, limit int) []knowledge.SearchChunk {
+	var out []knowledge.SearchChunk
+	for _, c := range chunks {
+		if c.UnitType == graph.UnitTypePackageSummary {
+			out = append(out, c)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Centrality > out[j].Centrality })
+	if limit >= 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
 func fallbackSectionPlan(sec ModelSect) SectionDocPlan {
 	return SectionDocPlan{
 		SectionID:         sec.ID,
@@ -359,6 +654,7 @@ func heuristicSelectChunks(chunks []knowledge.SearchChunk, keywords []string, li
 		case "function", "method", "struct", "interface", "file_module":
 			score += 1
 		}
+		score += int(c.Centrality * 4)
 		ranked = append(ranked, scored{chunk: c, score: score})
 	}
 	sort.Slice(ranked, func(i, j int) bool {
@@ -407,14 +703,14 @@ func mergeChunkLists(primary, secondary []knowledge.SearchChunk, limit int) []kn
 	return out
 }
 
-func (g *MarkdownGenerator) generateSectionContent(ctx context.Context, sec ModelSect, secPlan SectionDocPlan, chunks []knowledge.SearchChunk, capabilities []Capability, llmBudget *int) (string, sectionGenerationTrace) {
+func (g *MarkdownGenerator) generateSectionContent(ctx context.Context, sec ModelSect, secPlan SectionDocPlan, chunks []knowledge.SearchChunk, capabilities []Capability, llmBudget sectionLLMTurn) (string, sectionGenerationTrace) {
 	trace := sectionGenerationTrace{}
 	draft := BuildSectionDraft(sec.ID, sec.Title, chunks, capabilities)
 	if err := ValidateSectionDraft(draft); err == nil {
 		trace.UsedDraft = true
 		content := RenderSectionDraftMarkdown(draft)
 		if g.summarizer != nil {
-			if refined, ok := g.tryRenderDraftWithLLM(ctx, draft, chunks); ok {
+			if refined, ok := g.tryRenderDraftWithLLM(ctx, draft, chunks, &trace); ok {
 				content = refined
 				trace.UsedLLM = true
 			}
@@ -424,15 +720,16 @@ func (g *MarkdownGenerator) generateSectionContent(ctx context.Context, sec Mode
 		if !isLowQualitySection(sec.ID, content) && q.Score >= 0.55 {
 			return content, trace
 		}
-		if g.summarizer != nil && secPlan.AllowLLM && llmBudget != nil && *llmBudget > 0 {
-			if refined, ok := g.tryLLMSectionRewrite(ctx, sec.ID, sec.Title, content, chunks); ok {
-				*llmBudget--
+		if g.summarizer != nil && secPlan.AllowLLM && llmBudget.take() {
+			if refined, ok := g.tryLLMSectionRewrite(ctx, sec.ID, sec.Title, content, chunks, &trace); ok {
 				refined = g.enrichSectionWithDiagrams(sec.ID, refined, chunks)
 				rq := assessWriterQuality(sec.ID, refined)
 				if !isLowQualitySection(sec.ID, refined) && rq.Score >= 0.55 {
 					trace.UsedLLM = true
 					return refined, trace
 				}
+			} else {
+				llmBudget.refund()
 			}
 		}
 	}
@@ -448,11 +745,12 @@ func (g *MarkdownGenerator) generateSectionContent(ctx context.Context, sec Mode
 		content = BuildKeyFeaturesSection(capabilities)
 		avgConf := AverageCapabilityConfidence(capabilities)
 		needsSemanticLift := len(capabilities) < 3 || avgConf < 0.5
-		if needsSemanticLift && secPlan.AllowLLM && llmBudget != nil && *llmBudget > 0 {
-			if refined, ok := g.tryLLMSectionRewrite(ctx, sec.ID, sec.Title, content, chunks); ok {
-				*llmBudget--
+		if needsSemanticLift && secPlan.AllowLLM && llmBudget.take() {
+			if refined, ok := g.tryLLMSectionRewrite(ctx, sec.ID, sec.Title, content, chunks, &trace); ok {
 				content = refined
 				trace.UsedLLM = true
+			} else {
+				llmBudget.refund()
 			}
 		}
 	case "development":
@@ -469,7 +767,7 @@ func (g *MarkdownGenerator) generateSectionContent(ctx context.Context, sec Mode
 	return content, trace
 }
 
-func (g *MarkdownGenerator) tryLLMSectionRewrite(ctx context.Context, sectionID, sectionTitle, seed string, chunks []knowledge.SearchChunk) (string, bool) {
+func (g *MarkdownGenerator) tryLLMSectionRewrite(ctx context.Context, sectionID, sectionTitle, seed string, chunks []knowledge.SearchChunk, trace *sectionGenerationTrace) (string, bool) {
 	if g.summarizer == nil {
 		return "", false
 	}
@@ -479,6 +777,7 @@ func (g *MarkdownGenerator) tryLLMSectionRewrite(ctx context.Context, sectionID,
 	}
 	generated, err := g.summarizer.UpdateDocSection(ctx, promptSeed, topNChunks(chunks, 10))
 	if err != nil {
+		trace.noteLLMError(err)
 		return "", false
 	}
 	generated = sanitizeGeneratedSection(generated)
@@ -491,7 +790,7 @@ func (g *MarkdownGenerator) tryLLMSectionRewrite(ctx context.Context, sectionID,
 	return generated, true
 }
 
-func (g *MarkdownGenerator) tryRenderDraftWithLLM(ctx context.Context, draft SectionDraft, chunks []knowledge.SearchChunk) (string, bool) {
+func (g *MarkdownGenerator) tryRenderDraftWithLLM(ctx context.Context, draft SectionDraft, chunks []knowledge.SearchChunk, trace *sectionGenerationTrace) (string, bool) {
 	if g.summarizer == nil {
 		return "", false
 	}
@@ -502,6 +801,7 @@ func (g *MarkdownGenerator) tryRenderDraftWithLLM(ctx context.Context, draft Sec
 	}
 	generated, err := g.summarizer.RenderSectionFromDraft(ctx, draftJSON, contextChunks)
 	if err != nil {
+		trace.noteLLMError(err)
 		return "", false
 	}
 	generated = sanitizeGeneratedSection(generated)
@@ -515,6 +815,14 @@ func (g *MarkdownGenerator) tryRenderDraftWithLLM(ctx context.Context, draft Sec
 	return generated, true
 }
 
+// noteLLMError records a failed LLM call; calls skipped by a dry run are not
+// failures.
+func (t *sectionGenerationTrace) noteLLMError(err error) {
+	if !errors.Is(err, knowledge.ErrDryRun) {
+		t.LLMFailed = true
+	}
+}
+
 func sectionScaffold(sectionID, title string) string {
 	switch sectionID {
 	case "overview":
@@ -537,6 +845,24 @@ func sectionScaffold(sectionID, title string) string {
 	}
 }
 
+// planScaffold outlines sections without a built-in scaffold with the
+// required blocks of their plan.
+func planScaffold(secPlan SectionDocPlan, title string) string {
+	switch secPlan.SectionID {
+	case "overview", "key-features", "development":
+		return sectionScaffold(secPlan.SectionID, title)
+	}
+	if len(secPlan.RequiredBlocks) == 0 {
+		return sectionScaffold(secPlan.SectionID, title)
+	}
+	var sb strings.Builder
+	sb.WriteString("# " + title + "\n\n")
+	for _, block := range secPlan.RequiredBlocks {
+		sb.WriteString("## " + block + "\n\n")
+	}
+	return sb.String()
+}
+
 func (g *MarkdownGenerator) enrichSectionWithDiagrams(sectionID, content string, chunks []knowledge.SearchChunk) string {
 	trimmed := strings.TrimSpace(content)
 	if trimmed == "" {
@@ -548,6 +874,9 @@ func (g *MarkdownGenerator) enrichSectionWithDiagrams(sectionID, content string,
 	case "development":
 		return upsertSectionMermaid(trimmed, "## Architecture Snapshot", g.mermaid.GenerateArchitectureSnapshot(topNChunks(chunks, 24)))
 	default:
+		if diagram := g.dataModelDiagram(chunks); diagram != "" {
+			return upsertSectionMermaid(trimmed, dataModelHeading, diagram)
+		}
 		return trimmed
 	}
 }
@@ -681,7 +1010,11 @@ func topNChunks(chunks []knowledge.SearchChunk, n int) []knowledge.SearchChunk {
 		return chunks
 	}
 	cp := append([]knowledge.SearchChunk(nil), chunks...)
-	sort.Slice(cp, func(i, j int) bool {
+	// Most central symbols first so architectural anchors survive the cut.
+	sort.SliceStable(cp, func(i, j int) bool {
+		if cp[i].Centrality != cp[j].Centrality {
+			return cp[i].Centrality > cp[j].Centrality
+		}
 		if cp[i].ID == cp[j].ID {
 			return cp[i].Name < cp[j].Name
 		}
@@ -804,34 +1137,10 @@ func upsertSectionMermaid(content, heading, diagram string) string {
 	return injectDiagram(trimmed, heading, diagram)
 }
 
-func filterChunksForSection(sectionID string, chunks []knowledge.SearchChunk) []knowledge.SearchChunk {
-	if len(chunks) == 0 {
-		return chunks
-	}
-	out := make([]knowledge.SearchChunk, 0, len(chunks))
-	for _, c := range chunks {
-		name := strings.ToLower(strings.TrimSpace(c.Name))
-		switch sectionID {
-		case "key-features":
-			// Prefer semantic behavior units over physical module wrappers.
-			if c.UnitType == "file_module" || c.UnitType == "constant" || c.UnitType == "variable" {
-				continue
-			}
-			if strings.Contains(name, "_test") || strings.HasSuffix(name, "test") {
-				continue
-			}
-		case "overview":
-			if c.UnitType == "constant" || c.UnitType == "variable" {
-				continue
-			}
-		case "development":
-			// Keep config/runtime facing units; exclude noisy test symbols.
-			if strings.Contains(name, "_test") || strings.HasSuffix(name, "test") {
-				continue
-			}
-		}
-		out = append(out, c)
-	}
+// filterChunksWithFallback applies a section filter, keeping the input when
+// nothing would remain.
+func filterChunksWithFallback(filter knowledge.SearchFilter, chunks []knowledge.SearchChunk) []knowledge.SearchChunk {
+	out := filter.Apply(chunks)
 	if len(out) == 0 {
 		return chunks
 	}
@@ -32,10 +32,15 @@ func (m *MermaidGenerator) GeneratePackageDiagram(pkgName string, chunks []knowl
 
 	// Define relationships
 	for _, c := range chunks {
+		realized := make(map[string]bool, len(c.Implements))
+		for _, iface := range c.Implements {
+			realized[iface] = true
+			sb.WriteString(fmt.Sprintf("    %s <|.. %s : implements\n", iface, c.Name))
+		}
 		for _, dep := range c.Dependencies {
 			// Basic dependency arrow
 			// Filter to only show internal dependencies to avoid clutter with stdlib
-			if !strings.Contains(dep, ".") {
+			if !strings.Contains(dep, ".") && !realized[dep] {
 				sb.WriteString(fmt.Sprintf("    %s ..> %s : uses\n", c.Name, dep))
 			}
 		}
@@ -68,6 +73,10 @@ func (m *MermaidGenerator) GenerateFlowChart(chunks []knowledge.SearchChunk) str
 
 // GenerateArchitectureFlow builds a high-level architecture flow from semantically relevant symbols.
 func (m *MermaidGenerator) GenerateArchitectureFlow(chunks []knowledge.SearchChunk) string {
+	// Prefer detected graph communities over keyword buckets when available.
+	if flow := m.generateComponentFlow(chunks); flow != "" {
+		return flow
+	}
 	stageKeywords := []struct {
 		Key   string
 		Label string
@@ -200,6 +209,121 @@ func (m *MermaidGenerator) GenerateArchitectureFlow(chunks []knowledge.SearchChu
 	return sb.String()
 }
 
+// generateComponentFlow draws dependencies between detected components.
+// It returns "" when chunks do not span enough labeled components.
+func (m *MermaidGenerator) generateComponentFlow(chunks []knowledge.SearchChunk) string {
+	type edge struct {
+		from string
+		to   string
+	}
+	compCount := map[string]int{}
+	compExamples := map[string][]knowledge.SearchChunk{}
+	nameComp := map[string]string{}
+	for _, c := range chunks {
+		comp := strings.TrimSpace(c.Component)
+		if comp == "" {
+			continue
+		}
+		compCount[comp]++
+		if c.UnitType != "file_module" && c.UnitType != "symbol_segment" {
+			compExamples[comp] = append(compExamples[comp], c)
+			if strings.TrimSpace(c.Name) != "" {
+				nameComp[c.Name] = comp
+			}
+		}
+	}
+	if len(compCount) < 3 {
+		return ""
+	}
+
+	edgeWeight := map[edge]int{}
+	for _, c := range chunks {
+		from := strings.TrimSpace(c.Component)
+		if from == "" {
+			continue
+		}
+		for _, dep := range c.Dependencies {
+			to := nameComp[strings.TrimSpace(dep)]
+			if to == "" || to == from {
+				continue
+			}
+			edgeWeight[edge{from: from, to: to}]++
+		}
+	}
+	if len(edgeWeight) == 0 {
+		return ""
+	}
+
+	comps := make([]string, 0, len(compCount))
+	for c := range compCount {
+		comps = append(comps, c)
+	}
+	sort.Slice(comps, func(i, j int) bool {
+		if compCount[comps[i]] == compCount[comps[j]] {
+			return comps[i] < comps[j]
+		}
+		return compCount[comps[i]] > compCount[comps[j]]
+	})
+	if len(comps) > 6 {
+		comps = comps[:6]
+	}
+	selected := map[string]bool{}
+	for _, c := range comps {
+		selected[c] = true
+	}
+
+	type weighted struct {
+		e edge
+		w int
+	}
+	edges := make([]weighted, 0, len(edgeWeight))
+	for e, w := range edgeWeight {
+		if selected[e.from] && selected[e.to] {
+			edges = append(edges, weighted{e: e, w: w})
+		}
+	}
+	if len(edges) == 0 {
+		return ""
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].w == edges[j].w {
+			if edges[i].e.from == edges[j].e.from {
+				return edges[i].e.to < edges[j].e.to
+			}
+			return edges[i].e.from < edges[j].e.from
+		}
+		return edges[i].w > edges[j].w
+	})
+	if len(edges) > 10 {
+		edges = edges[:10]
+	}
+
+	var sb strings.Builder
+	sb.WriteString("```mermaid\n")
+	sb.WriteString("graph LR\n")
+	for _, comp := range comps {
+		label := comp
+		examples := compExamples[comp]
+		sort.SliceStable(examples, func(i, j int) bool { return examples[i].Centrality > examples[j].Centrality })
+		names := make([]string, 0, 2)
+		for _, ex := range examples {
+			if len(names) >= 2 {
+				break
+			}
+			names = append(names, ex.Name)
+		}
+		if len(names) > 0 {
+			label = label + "\\n" + strings.Join(names, ", ")
+		}
+		sb.WriteString(fmt.Sprintf("    %s[%q]\n", sanitizeMermaidID("comp_"+comp), label))
+	}
+	for _, e := range edges {
+		sb.WriteString(fmt.Sprintf("    %s --> %s\n", sanitizeMermaidID("comp_"+e.e.from), sanitizeMermaidID("comp_"+e.e.to)))
+	}
+	sb.WriteString("```\n")
+	return sb.String()
+}
+
 func topStageExamples(m map[string]int, limit int) []string {
 	if len(m) == 0 || limit <= 0 {
 		return nil
@@ -357,8 +481,23 @@ func (m *MermaidGenerator) GenerateArchitectureSnapshot(chunks []knowledge.Searc
 	var sb strings.Builder
 	sb.WriteString("```mermaid\n")
 	sb.WriteString("graph LR\n")
+	pkgNames := make([]string, 0, len(nodes))
 	for _, n := range nodes {
-		sb.WriteString(fmt.Sprintf("    %s[%q]\n", sanitizeMermaidID(n.name), n.name))
+		pkgNames = append(pkgNames, n.name)
+	}
+	groups, order := packageComponents(chunks, pkgNames)
+	if len(order) >= 2 {
+		for i, comp := range order {
+			sb.WriteString(fmt.Sprintf("    subgraph %s[%q]\n", sanitizeMermaidID(fmt.Sprintf("component_%d", i)), comp))
+			for _, pkg := range groups[comp] {
+				sb.WriteString(fmt.Sprintf("        %s[%q]\n", sanitizeMermaidID(pkg), pkg))
+			}
+			sb.WriteString("    end\n")
+		}
+	} else {
+		for _, n := range nodes {
+			sb.WriteString(fmt.Sprintf("    %s[%q]\n", sanitizeMermaidID(n.name), n.name))
+		}
 	}
 	if len(edges) == 0 {
 		for i := 1; i < len(nodes); i++ {
@@ -373,6 +512,43 @@ func (m *MermaidGenerator) GenerateArchitectureSnapshot(chunks []knowledge.Searc
 	return sb.String()
 }
 
+// packageComponents assigns each package to the component most of its chunks
+// belong to, returning packages grouped by component in first-seen order.
+func packageComponents(chunks []knowledge.SearchChunk, pkgs []string) (map[string][]string, []string) {
+	votes := map[string]map[string]int{}
+	for _, c := range chunks {
+		if c.Package == "" || strings.TrimSpace(c.Component) == "" {
+			continue
+		}
+		if votes[c.Package] == nil {
+			votes[c.Package] = map[string]int{}
+		}
+		votes[c.Package][c.Component]++
+	}
+	groups := map[string][]string{}
+	var order []string
+	for _, pkg := range pkgs {
+		best, bestN := "", 0
+		for comp, n := range votes[pkg] {
+			if n > bestN || (n == bestN && comp < best) {
+				best, bestN = comp, n
+			}
+		}
+		if best == "" {
+			best = pkg
+		}
+		if _, ok := groups[best]; !ok {
+			order = append(order, best)
+		}
+		groups[best] = append(groups[best], pkg)
+	}
+	if len(order) == len(pkgs) {
+		// One package per component adds no structure; keep the flat view.
+		return groups, nil
+	}
+	return groups, order
+}
+
 func bestStageForChunk(c knowledge.SearchChunk, defs []struct {
 	Key   string
 	Label string
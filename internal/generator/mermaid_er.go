@@ -0,0 +1,253 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"docod/internal/graph"
+	"docod/internal/knowledge"
+)
+
+const (
+	// maxEREntities bounds the structs drawn in one ER diagram.
+	maxEREntities = 12
+	// maxERAttributes bounds the fields listed per struct.
+	maxERAttributes = 10
+	// dataModelHeading is the subsection ER diagrams are placed under.
+	dataModelHeading = "## Data Model"
+)
+
+var erWordPattern = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// erEntity is one struct of an ER diagram.
+type erEntity struct {
+	sym  *graph.Symbol
+	name string
+}
+
+// GenerateERDiagram renders the structs among ids, and the structs they
+// reference, as a Mermaid erDiagram. Attributes come from the struct fields;
+// relationships follow uses_type and embeds edges, with the cardinality read
+// off the field type (slices and maps are "many", pointers are optional). It
+// returns "" when the structs are not related to each other.
+func (m *MermaidGenerator) GenerateERDiagram(g *graph.Graph, ids []string) string {
+	if g == nil {
+		return ""
+	}
+	entities := make(map[string]*erEntity)
+	var order []string
+	add := func(id string) bool {
+		if _, ok := entities[id]; ok {
+			return true
+		}
+		if len(order) >= maxEREntities {
+			return false
+		}
+		n, ok := g.Nodes[id]
+		if !ok || n.Unit == nil || n.Unit.UnitType != "struct" {
+			return false
+		}
+		entities[id] = &erEntity{sym: n.Unit}
+		order = append(order, id)
+		return true
+	}
+	seeds := append([]string(nil), ids...)
+	sort.Strings(seeds)
+	seeded := make(map[string]bool)
+	for _, id := range seeds {
+		if add(id) {
+			seeded[id] = true
+		}
+	}
+
+	type relation struct {
+		from, to string
+		kind     graph.RelationKind
+	}
+	var relations []relation
+	seen := make(map[relation]bool)
+	edges := append([]graph.Edge(nil), g.Edges...)
+	sort.SliceStable(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		return edges[i].To < edges[j].To
+	})
+	for _, e := range edges {
+		if e.Kind != graph.RelationUsesType && e.Kind != graph.RelationEmbeds {
+			continue
+		}
+		if e.From == e.To {
+			continue
+		}
+		// Structs the seeds reference join the diagram; the walk goes no
+		// further than that.
+		if !seeded[e.From] || !add(e.To) {
+			continue
+		}
+		r := relation{from: e.From, to: e.To, kind: e.Kind}
+		if !seen[r] {
+			seen[r] = true
+			relations = append(relations, r)
+		}
+	}
+	if len(seeded) == 0 || len(relations) == 0 {
+		return ""
+	}
+
+	// Struct names repeat across packages; qualify the repeats.
+	counts := make(map[string]int)
+	for _, id := range order {
+		counts[entities[id].sym.Name]++
+	}
+	for _, id := range order {
+		e := entities[id]
+		e.name = e.sym.Name
+		if counts[e.name] > 1 && e.sym.Package != "" {
+			e.name = e.sym.Package + "_" + e.name
+		}
+		e.name = erWord(e.name)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("```mermaid\n")
+	sb.WriteString("erDiagram\n")
+	for _, id := range order {
+		e := entities[id]
+		var attrs []string
+		for _, f := range e.sym.Metadata.StructFields {
+			if f.Name == "" || len(attrs) >= maxERAttributes {
+				continue
+			}
+			attrs = append(attrs, erAttribute(f))
+		}
+		if len(attrs) == 0 {
+			sb.WriteString("    " + e.name + " {\n    }\n")
+			continue
+		}
+		sb.WriteString("    " + e.name + " {\n")
+		for _, a := range attrs {
+			sb.WriteString("        " + a + "\n")
+		}
+		sb.WriteString("    }\n")
+	}
+	for _, r := range relations {
+		from, to := entities[r.from], entities[r.to]
+		card, label := "||--||", "embeds"
+		if r.kind == graph.RelationUsesType {
+			card, label = erCardinality(from.sym, to.sym.Name)
+		}
+		sb.WriteString(fmt.Sprintf("    %s %s %s : %q\n", from.name, card, to.name, label))
+	}
+	sb.WriteString("```\n")
+	return sb.String()
+}
+
+// erAttribute renders a field as an erDiagram attribute. Mermaid types are
+// single words, so composite Go types keep their spelling in the comment.
+func erAttribute(f graph.Field) string {
+	typ := erWord(erBaseType(strings.TrimSpace(f.Type)))
+	if typ == "" || (typ[0] >= '0' && typ[0] <= '9') {
+		typ = "any"
+	}
+	attr := typ + " " + erWord(f.Name)
+	if typ != f.Type {
+		attr += fmt.Sprintf(" %q", strings.ReplaceAll(f.Type, `"`, "'"))
+	}
+	return attr
+}
+
+// erCardinality picks the relationship of owner to the struct named target
+// from the first field of that type: many for slices and maps, zero or one
+// for pointers, exactly one otherwise. The label is the field name.
+func erCardinality(owner *graph.Symbol, target string) (string, string) {
+	for _, f := range owner.Metadata.StructFields {
+		typ := strings.TrimSpace(f.Type)
+		if erBaseType(typ) != target {
+			continue
+		}
+		label := f.Name
+		if label == "" {
+			label = "embeds"
+		}
+		switch {
+		case strings.HasPrefix(typ, "[]"), strings.HasPrefix(typ, "map["):
+			return "||--o{", label
+		case strings.HasPrefix(typ, "*"):
+			return "||--o|", label
+		}
+		return "||--||", label
+	}
+	return "||--||", "uses"
+}
+
+// erBaseType strips pointers, slices, maps and the package qualifier from a
+// field type: map[string][]*store.Item is Item.
+func erBaseType(typ string) string {
+	for {
+		switch {
+		case strings.HasPrefix(typ, "*"):
+			typ = typ[1:]
+		case strings.HasPrefix(typ, "[]"):
+			typ = typ[2:]
+		case strings.HasPrefix(typ, "map["):
+			end, depth := -1, 0
+			for i := 3; i < len(typ) && end < 0; i++ {
+				switch typ[i] {
+				case '[':
+					depth++
+				case ']':
+					if depth--; depth == 0 {
+						end = i
+					}
+				}
+			}
+			if end < 0 {
+				return typ
+			}
+			typ = typ[end+1:]
+		default:
+			if i := strings.LastIndex(typ, "."); i >= 0 {
+				typ = typ[i+1:]
+			}
+			return typ
+		}
+	}
+}
+
+func erWord(s string) string {
+	return strings.Trim(erWordPattern.ReplaceAllString(s, "_"), "_")
+}
+
+// isDataModelSection reports whether a section's evidence is mostly structs:
+// at least three, making up at least 40% of its symbols.
+func isDataModelSection(chunks []knowledge.SearchChunk) bool {
+	structs, symbols := 0, 0
+	for _, c := range chunks {
+		switch c.UnitType {
+		case "struct":
+			structs++
+			symbols++
+		case "function", "method", "interface", "type":
+			symbols++
+		}
+	}
+	return structs >= 3 && structs*5 >= symbols*2
+}
+
+// dataModelDiagram draws the structs of a section's evidence, or returns ""
+// when they are not related.
+func (g *MarkdownGenerator) dataModelDiagram(chunks []knowledge.SearchChunk) string {
+	if g.engine == nil || !isDataModelSection(chunks) {
+		return ""
+	}
+	var ids []string
+	for _, c := range chunks {
+		if c.UnitType == "struct" {
+			ids = append(ids, c.ID)
+		}
+	}
+	return g.mermaid.GenerateERDiagram(g.engine.Graph(), ids)
+}
@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"docod/internal/graph"
+	"docod/internal/knowledge"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func erGraph() *graph.Graph {
+	g := graph.NewGraph()
+	for _, s := range []*graph.Symbol{
+		{ID: "order", Name: "Order", Package: "shop", UnitType: "struct", Metadata: graph.SymbolMetadata{StructFields: []graph.Field{
+			{Type: "Audit"},
+			{Name: "ID", Type: "string", Tag: `json:"id"`},
+			{Name: "Items", Type: "[]*LineItem"},
+			{Name: "Buyer", Type: "*Customer"},
+			{Name: "Meta", Type: "map[string]string"},
+		}}},
+		{ID: "item", Name: "LineItem", Package: "shop", UnitType: "struct", Metadata: graph.SymbolMetadata{StructFields: []graph.Field{
+			{Name: "SKU", Type: "string"},
+			{Name: "Qty", Type: "int"},
+		}}},
+		{ID: "customer", Name: "Customer", Package: "crm", UnitType: "struct", Metadata: graph.SymbolMetadata{StructFields: []graph.Field{
+			{Name: "Address", Type: "geo.Address"},
+		}}},
+		{ID: "audit", Name: "Audit", Package: "shop", UnitType: "struct"},
+		{ID: "address", Name: "Address", Package: "geo", UnitType: "struct"},
+		{ID: "place", Name: "Place", Package: "shop", UnitType: "function"},
+	} {
+		g.AddSymbol(s)
+	}
+	g.Edges = []graph.Edge{
+		{From: "order", To: "item", Kind: graph.RelationUsesType},
+		{From: "order", To: "customer", Kind: graph.RelationUsesType},
+		{From: "order", To: "audit", Kind: graph.RelationEmbeds},
+		{From: "order", To: "item", Kind: graph.RelationUsesType},
+		{From: "customer", To: "address", Kind: graph.RelationUsesType},
+		{From: "place", To: "order", Kind: graph.RelationUsesType},
+	}
+	return g
+}
+
+func TestGenerateERDiagram(t *testing.T) {
+	m := &MermaidGenerator{}
+	got := m.GenerateERDiagram(erGraph(), []string{"order", "item"})
+
+	assert.True(t, strings.HasPrefix(got, "```mermaid\nerDiagram\n"))
+	assert.Contains(t, got, "    Order {\n"+
+		"        string ID\n"+
+		"        LineItem Items \"[]*LineItem\"\n"+
+		"        Customer Buyer \"*Customer\"\n"+
+		"        string Meta \"map[string]string\"\n"+
+		"    }\n")
+	assert.Contains(t, got, "    LineItem {\n        string SKU\n        int Qty\n    }\n")
+	assert.Contains(t, got, "    Audit {\n    }\n")
+	assert.Contains(t, got, `    Order ||--o{ LineItem : "Items"`)
+	assert.Contains(t, got, `    Order ||--o| Customer : "Buyer"`)
+	assert.Contains(t, got, `    Order ||--|| Audit : "embeds"`)
+	assert.Equal(t, 1, strings.Count(got, "Order ||--o{ LineItem"), "duplicate edges are drawn once")
+	assert.NotContains(t, got, "Address {", "only structs the seeds reference are added")
+	assert.NotContains(t, got, "Place")
+}
+
+func TestGenerateERDiagram_Unrelated(t *testing.T) {
+	m := &MermaidGenerator{}
+	assert.Empty(t, m.GenerateERDiagram(erGraph(), []string{"item", "audit"}))
+	assert.Empty(t, m.GenerateERDiagram(erGraph(), []string{"place"}))
+	assert.Empty(t, m.GenerateERDiagram(nil, []string{"order"}))
+}
+
+func TestERBaseType(t *testing.T) {
+	for typ, want := range map[string]string{
+		"Item":                     "Item",
+		"*store.Item":              "Item",
+		"[]*Item":                  "Item",
+		"map[string][]*store.Item": "Item",
+		"map[Key]map[string]Item":  "Item",
+	} {
+		assert.Equal(t, want, erBaseType(typ), typ)
+	}
+}
+
+func TestIsDataModelSection(t *testing.T) {
+	chunk := func(unitType string) knowledge.SearchChunk { return knowledge.SearchChunk{UnitType: unitType} }
+	assert.True(t, isDataModelSection([]knowledge.SearchChunk{chunk("struct"), chunk("struct"), chunk("struct"), chunk("function"), chunk("file_module")}))
+	assert.False(t, isDataModelSection([]knowledge.SearchChunk{chunk("struct"), chunk("struct"), chunk("function")}))
+	assert.False(t, isDataModelSection([]knowledge.SearchChunk{chunk("struct"), chunk("struct"), chunk("struct"),
+		chunk("function"), chunk("function"), chunk("method"), chunk("method"), chunk("method")}))
+}
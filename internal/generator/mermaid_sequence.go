@@ -0,0 +1,236 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"docod/internal/graph"
+)
+
+const (
+	defaultSequenceDepth    = 4
+	defaultSequenceMessages = 40
+)
+
+// SequenceOptions bounds a call-flow sequence diagram.
+type SequenceOptions struct {
+	// MaxDepth is how many calls deep the walk goes; 0 uses 4.
+	MaxDepth int
+	// MaxMessages caps the arrows drawn; 0 uses 40.
+	MaxMessages int
+	// MayCall also follows may_call edges from interface calls to their
+	// implementations.
+	MayCall bool
+}
+
+// sequenceWalk renders one call chain, handing out participants as
+// packages are reached.
+type sequenceWalk struct {
+	g        *graph.Graph
+	opts     SequenceOptions
+	calls    map[string][]graph.Edge
+	lanes    map[string]string // package dir -> participant ID
+	order    []string          // participant declarations in first-call order
+	lines    []string
+	messages int
+	// truncated is set once the message limit cut the walk short.
+	truncated bool
+	expanded  map[string]bool
+	onStack   map[string]bool
+}
+
+// GenerateSequenceDiagram renders the call chain starting at entryID as a
+// Mermaid sequenceDiagram with one participant per package. Calls follow
+// resolved calls edges in source order; a callee already drawn is not
+// expanded again, recursion is marked, and the walk stops at the depth and
+// message limits. It returns "" when entryID is unknown or calls nothing.
+func (m *MermaidGenerator) GenerateSequenceDiagram(g *graph.Graph, entryID string, opts SequenceOptions) string {
+	if g == nil {
+		return ""
+	}
+	entry, ok := g.Nodes[entryID]
+	if !ok || entry.Unit == nil {
+		return ""
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = defaultSequenceDepth
+	}
+	if opts.MaxMessages <= 0 {
+		opts.MaxMessages = defaultSequenceMessages
+	}
+	w := &sequenceWalk{
+		g:        g,
+		opts:     opts,
+		calls:    make(map[string][]graph.Edge),
+		lanes:    make(map[string]string),
+		expanded: make(map[string]bool),
+		onStack:  make(map[string]bool),
+	}
+	for _, e := range g.Edges {
+		if e.Kind == graph.RelationCalls || (opts.MayCall && e.Kind == graph.RelationMayCall) {
+			w.calls[e.From] = append(w.calls[e.From], e)
+		}
+	}
+	for from := range w.calls {
+		edges := w.calls[from]
+		sort.SliceStable(edges, func(i, j int) bool {
+			if edges[i].Evidence.StartLine != edges[j].Evidence.StartLine {
+				return edges[i].Evidence.StartLine < edges[j].Evidence.StartLine
+			}
+			return edges[i].To < edges[j].To
+		})
+	}
+
+	w.lane(entry.Unit)
+	w.walk(entry.Unit, 1)
+	if len(w.lines) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("```mermaid\n")
+	sb.WriteString("sequenceDiagram\n")
+	for _, decl := range w.order {
+		sb.WriteString("    " + decl + "\n")
+	}
+	for _, line := range w.lines {
+		sb.WriteString("    " + line + "\n")
+	}
+	sb.WriteString("```\n")
+	return sb.String()
+}
+
+func (w *sequenceWalk) walk(caller *graph.Symbol, depth int) {
+	w.onStack[caller.ID] = true
+	w.expanded[caller.ID] = true
+	defer delete(w.onStack, caller.ID)
+
+	seen := make(map[string]bool)
+	for _, e := range w.calls[caller.ID] {
+		if seen[e.To] {
+			continue
+		}
+		seen[e.To] = true
+		node, ok := w.g.Nodes[e.To]
+		if !ok || node.Unit == nil || node.Unit.UnitType == graph.UnitTypeExternal {
+			continue
+		}
+		callee := node.Unit
+		if w.messages >= w.opts.MaxMessages {
+			if !w.truncated {
+				w.truncated = true
+				w.lines = append(w.lines, fmt.Sprintf("Note over %s: … more calls omitted", w.lane(caller)))
+			}
+			return
+		}
+		label := sequenceLabel(callee)
+		expand := depth < w.opts.MaxDepth
+		switch {
+		case w.onStack[callee.ID]:
+			label += " (recursive)"
+			expand = false
+		case w.expanded[callee.ID]:
+			label += " (see above)"
+			expand = false
+		}
+		arrow := "->>"
+		if e.Kind == graph.RelationMayCall {
+			arrow = "-)"
+		}
+		w.lines = append(w.lines, fmt.Sprintf("%s%s%s: %s", w.lane(caller), arrow, w.lane(callee), label))
+		w.messages++
+		if expand {
+			w.walk(callee, depth+1)
+		}
+	}
+}
+
+// lane returns the participant of sym's package, declaring it on first use.
+// Participants are keyed by directory so same-named packages stay apart.
+func (w *sequenceWalk) lane(sym *graph.Symbol) string {
+	dir := filepath.ToSlash(filepath.Dir(sym.Filepath))
+	if id, ok := w.lanes[dir]; ok {
+		return id
+	}
+	label := sym.Package
+	if label == "" {
+		label = filepath.Base(dir)
+	}
+	id := fmt.Sprintf("p%d", len(w.lanes))
+	if w.labelTaken(label) {
+		label = dir
+	}
+	w.lanes[dir] = id
+	w.order = append(w.order, fmt.Sprintf("participant %s as %s", id, label))
+	return id
+}
+
+func (w *sequenceWalk) labelTaken(label string) bool {
+	for _, decl := range w.order {
+		if strings.HasSuffix(decl, " as "+label) {
+			return true
+		}
+	}
+	return false
+}
+
+// sequenceLabel names a call: Recv.Method() or Func().
+func sequenceLabel(sym *graph.Symbol) string {
+	if recv := receiverTypeName(sym.Metadata.Receiver); recv != "" {
+		return recv + "." + sym.Name + "()"
+	}
+	return sym.Name + "()"
+}
+
+// FindEntrypoint resolves a symbol ID, or a name such as main, Run,
+// pkg.Func or Type.Method, to the ID of a function or method in g. A bare
+// name prefers package main.
+func FindEntrypoint(g *graph.Graph, query string) (string, error) {
+	query = strings.TrimSpace(query)
+	if g == nil || query == "" {
+		return "", fmt.Errorf("no entrypoint given")
+	}
+	if n, ok := g.Nodes[query]; ok && n.Unit != nil {
+		return query, nil
+	}
+	qualifier, name := "", query
+	if i := strings.LastIndex(query, "."); i >= 0 {
+		qualifier, name = query[:i], query[i+1:]
+	}
+	var matches []*graph.Symbol
+	for _, n := range g.Nodes {
+		u := n.Unit
+		if u == nil || u.Name != name || (u.UnitType != "function" && u.UnitType != "method") {
+			continue
+		}
+		if qualifier != "" && qualifier != u.Package && qualifier != receiverTypeName(u.Metadata.Receiver) {
+			continue
+		}
+		matches = append(matches, u)
+	}
+	if len(matches) > 1 && qualifier == "" {
+		var mains []*graph.Symbol
+		for _, u := range matches {
+			if u.Package == "main" {
+				mains = append(mains, u)
+			}
+		}
+		if len(mains) > 0 {
+			matches = mains
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no function or method named %q", query)
+	case 1:
+		return matches[0].ID, nil
+	}
+	sortSymbols(matches)
+	ids := make([]string, len(matches))
+	for i, u := range matches {
+		ids[i] = u.ID
+	}
+	return "", fmt.Errorf("%q is ambiguous; use one of: %s", query, strings.Join(ids, ", "))
+}
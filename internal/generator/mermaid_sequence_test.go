@@ -0,0 +1,90 @@
+package generator
+
+import (
+	"testing"
+
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sequenceGraph() *graph.Graph {
+	g := graph.NewGraph()
+	for _, s := range []*graph.Symbol{
+		{ID: "main", Name: "main", Package: "main", UnitType: "function", Filepath: "cmd/app/main.go"},
+		{ID: "run", Name: "Run", Package: "sync", UnitType: "method", Filepath: "internal/sync/run.go", Metadata: graph.SymbolMetadata{Receiver: "(s *Syncer)"}},
+		{ID: "load", Name: "Load", Package: "store", UnitType: "function", Filepath: "internal/store/store.go"},
+		{ID: "save", Name: "Save", Package: "store", UnitType: "function", Filepath: "internal/store/store.go"},
+		{ID: "walk", Name: "walk", Package: "sync", UnitType: "function", Filepath: "internal/sync/run.go"},
+		{ID: "legacy", Name: "Load", Package: "store", UnitType: "function", Filepath: "legacy/store/store.go"},
+		{ID: "cobra", Name: "cobra", UnitType: graph.UnitTypeExternal},
+	} {
+		g.AddSymbol(s)
+	}
+	call := func(from, to string, line int) graph.Edge {
+		return graph.Edge{From: from, To: to, Kind: graph.RelationCalls, Evidence: graph.Evidence{StartLine: line}}
+	}
+	g.Edges = []graph.Edge{
+		call("main", "cobra", 3),
+		call("main", "run", 5),
+		call("run", "save", 20), // after Load in source order
+		call("run", "load", 12),
+		call("run", "walk", 14),
+		call("walk", "walk", 30),
+		call("walk", "legacy", 31),
+		call("main", "load", 9),
+		{From: "run", To: "run", Kind: graph.RelationUsesType},
+	}
+	return g
+}
+
+func TestGenerateSequenceDiagram(t *testing.T) {
+	m := &MermaidGenerator{}
+	got := m.GenerateSequenceDiagram(sequenceGraph(), "main", SequenceOptions{})
+
+	assert.Equal(t, "```mermaid\nsequenceDiagram\n"+
+		"    participant p0 as main\n"+
+		"    participant p1 as sync\n"+
+		"    participant p2 as store\n"+
+		"    participant p3 as legacy/store\n"+
+		"    p0->>p1: Syncer.Run()\n"+
+		"    p1->>p2: Load()\n"+
+		"    p1->>p1: walk()\n"+
+		"    p1->>p1: walk() (recursive)\n"+
+		"    p1->>p3: Load()\n"+
+		"    p1->>p2: Save()\n"+
+		"    p0->>p2: Load() (see above)\n"+
+		"```\n", got)
+}
+
+func TestGenerateSequenceDiagram_Limits(t *testing.T) {
+	m := &MermaidGenerator{}
+	shallow := m.GenerateSequenceDiagram(sequenceGraph(), "main", SequenceOptions{MaxDepth: 1})
+	assert.NotContains(t, shallow, "walk()")
+	assert.Contains(t, shallow, "p0->>p2: Load()\n")
+
+	capped := m.GenerateSequenceDiagram(sequenceGraph(), "main", SequenceOptions{MaxMessages: 2})
+	assert.Contains(t, capped, "    p1->>p2: Load()\n    Note over p1: … more calls omitted\n```")
+
+	assert.Empty(t, m.GenerateSequenceDiagram(sequenceGraph(), "save", SequenceOptions{}), "leaf functions have no diagram")
+	assert.Empty(t, m.GenerateSequenceDiagram(sequenceGraph(), "missing", SequenceOptions{}))
+}
+
+func TestFindEntrypoint(t *testing.T) {
+	g := sequenceGraph()
+	for query, want := range map[string]string{
+		"main":       "main",
+		"run":        "run",
+		"Syncer.Run": "run",
+		"sync.walk":  "walk",
+	} {
+		got, err := FindEntrypoint(g, query)
+		require.NoError(t, err, query)
+		assert.Equal(t, want, got, query)
+	}
+	_, err := FindEntrypoint(g, "store.Load")
+	assert.ErrorContains(t, err, "ambiguous; use one of: legacy, load")
+	_, err = FindEntrypoint(g, "Nope")
+	assert.ErrorContains(t, err, "no function or method")
+}
@@ -0,0 +1,243 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"docod/internal/graph"
+)
+
+// maxPackagePageSymbols bounds the exported API table of a package page.
+const maxPackagePageSymbols = 25
+
+// PackageDocPlan lays out the per-package documentation: one page per
+// package directory, named by slug so pages can link to each other.
+type PackageDocPlan struct {
+	Pages  []PackageDocPage
+	bySlug map[string]int
+}
+
+// PackageDocPage is the plan of one package page.
+type PackageDocPage struct {
+	graph.PackageSummary
+	// Dir is the package directory relative to the project root.
+	Dir string
+	// Slug names the page, <Slug>.md.
+	Slug string
+	// Files are the package's source files relative to the project root.
+	Files []string
+	// API lists the most used exported symbols, methods excluded.
+	API []PackageAPIEntry
+	// DependsOn and UsedBy are the slugs of related pages, most connected
+	// first.
+	DependsOn []string
+	UsedBy    []string
+}
+
+// PackageAPIEntry is one row of a package page's exported API table.
+type PackageAPIEntry struct {
+	Name        string
+	Kind        string
+	Description string
+}
+
+// BuildPackageDocPlan plans a page for every package of g. Absolute paths
+// are made relative to root.
+func BuildPackageDocPlan(g *graph.Graph, root string) *PackageDocPlan {
+	summaries := g.PackageSummaries()
+	plan := &PackageDocPlan{bySlug: make(map[string]int, len(summaries))}
+	slugOf := make(map[string]string, len(summaries))
+	for _, s := range summaries {
+		if s.Symbols == 0 {
+			continue
+		}
+		dir := relativeToRoot(root, s.Dir)
+		slug := componentSlug(dir)
+		if dir == "." {
+			slug = componentSlug(s.Package)
+		}
+		for base, n := slug, 2; ; n++ {
+			if _, taken := plan.bySlug[slug]; !taken {
+				break
+			}
+			slug = fmt.Sprintf("%s-%d", base, n)
+		}
+		slugOf[s.Dir] = slug
+
+		page := PackageDocPage{PackageSummary: s, Dir: dir, Slug: slug}
+		files := make(map[string]bool)
+		for _, id := range s.SymbolIDs {
+			n, ok := g.Nodes[id]
+			if !ok || n.Unit == nil {
+				continue
+			}
+			files[relativeToRoot(root, n.Unit.Filepath)] = true
+			if len(page.API) < maxPackagePageSymbols && n.Unit.UnitType != "method" && exportedName(n.Unit.Name) {
+				page.API = append(page.API, PackageAPIEntry{
+					Name:        n.Unit.Name,
+					Kind:        n.Unit.UnitType,
+					Description: strings.TrimSpace(n.Unit.Description),
+				})
+			}
+		}
+		for f := range files {
+			page.Files = append(page.Files, f)
+		}
+		sort.Strings(page.Files)
+		plan.bySlug[slug] = len(plan.Pages)
+		plan.Pages = append(plan.Pages, page)
+	}
+	for i := range plan.Pages {
+		page := &plan.Pages[i]
+		page.DependsOn = rankedSlugs(page.PackageSummary.DependsOn, slugOf)
+		page.UsedBy = rankedSlugs(page.PackageSummary.UsedBy, slugOf)
+	}
+	return plan
+}
+
+// Page returns the planned page with slug.
+func (p *PackageDocPlan) Page(slug string) (PackageDocPage, bool) {
+	i, ok := p.bySlug[slug]
+	if !ok {
+		return PackageDocPage{}, false
+	}
+	return p.Pages[i], true
+}
+
+func rankedSlugs(counts map[string]int, slugOf map[string]string) []string {
+	dirs := make([]string, 0, len(counts))
+	for dir := range counts {
+		if _, ok := slugOf[dir]; ok {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if counts[dirs[i]] != counts[dirs[j]] {
+			return counts[dirs[i]] > counts[dirs[j]]
+		}
+		return dirs[i] < dirs[j]
+	})
+	slugs := make([]string, len(dirs))
+	for i, dir := range dirs {
+		slugs[i] = slugOf[dir]
+	}
+	return slugs
+}
+
+func relativeToRoot(root, path string) string {
+	if filepath.IsAbs(path) {
+		if abs, err := filepath.Abs(root); err == nil {
+			if rel, err := filepath.Rel(abs, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+// writePackagePages writes the pages of plan plus an index under
+// <outputDir>/packages. It returns the number of pages written.
+func writePackagePages(outputDir string, plan *PackageDocPlan) (int, error) {
+	if len(plan.Pages) == 0 {
+		return 0, nil
+	}
+	dir := filepath.Join(outputDir, "packages")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, err
+	}
+	written := 0
+	for _, page := range plan.Pages {
+		if err := os.WriteFile(filepath.Join(dir, page.Slug+".md"), []byte(buildPackagePage(plan, page)), 0644); err != nil {
+			return written, err
+		}
+		written++
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.md"), []byte(buildPackageIndex(plan)), 0644); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+func buildPackageIndex(plan *PackageDocPlan) string {
+	var sb strings.Builder
+	sb.WriteString("# Packages\n\n")
+	sb.WriteString("One page per package, with its exported API and the packages it depends on and is used by.\n\n")
+	sb.WriteString("| Package | Directory | Symbols | Summary |\n|---|---|---|---|\n")
+	for _, page := range plan.Pages {
+		summary := "—"
+		if len(page.Responsibilities) > 0 {
+			summary = markdownCell(truncate(page.Responsibilities[0], 120))
+		}
+		sb.WriteString(fmt.Sprintf("| [%s](%s.md) | `%s` | %d | %s |\n", page.Package, page.Slug, page.Dir, page.Symbols, summary))
+	}
+	return sb.String()
+}
+
+func buildPackagePage(plan *PackageDocPlan, page PackageDocPage) string {
+	var sb strings.Builder
+	sb.WriteString("# Package `" + page.Package + "`\n\n")
+	sb.WriteString(fmt.Sprintf("`%s` · %d %s · %d symbols · %d tests · [All packages](index.md)\n\n",
+		page.Dir, len(page.Files), plural(len(page.Files), "file", "files"), page.Symbols, page.Tests))
+
+	if len(page.Responsibilities) > 0 {
+		sb.WriteString("## Responsibilities\n\n")
+		for _, r := range page.Responsibilities {
+			sb.WriteString("- " + r + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(page.API) > 0 {
+		sb.WriteString("## Exported API\n\n")
+		sb.WriteString("| Symbol | Kind | Description |\n|---|---|---|\n")
+		for _, entry := range page.API {
+			desc := entry.Description
+			if desc == "" {
+				desc = "—"
+			}
+			sb.WriteString(fmt.Sprintf("| `%s` | %s | %s |\n", entry.Name, entry.Kind, markdownCell(truncate(desc, 160))))
+		}
+		if exported := len(page.Exported); exported > len(page.API) {
+			sb.WriteString(fmt.Sprintf("\n…and %d more exported symbols.\n", exported-len(page.API)))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(page.DependsOn) > 0 || len(page.UsedBy) > 0 {
+		sb.WriteString("## Relationships\n\n")
+		if len(page.DependsOn) > 0 {
+			sb.WriteString("- Depends on: " + strings.Join(packageLinks(plan, page.DependsOn), ", ") + "\n")
+		}
+		if len(page.UsedBy) > 0 {
+			sb.WriteString("- Used by: " + strings.Join(packageLinks(plan, page.UsedBy), ", ") + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## Files\n\n")
+	for _, f := range page.Files {
+		sb.WriteString("- `" + f + "`\n")
+	}
+	return sb.String()
+}
+
+func packageLinks(plan *PackageDocPlan, slugs []string) []string {
+	links := make([]string, 0, len(slugs))
+	for _, slug := range slugs {
+		if page, ok := plan.Page(slug); ok {
+			links = append(links, fmt.Sprintf("[%s](%s.md)", page.Dir, slug))
+		}
+	}
+	return links
+}
+
+func exportedName(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
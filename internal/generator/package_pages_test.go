@@ -0,0 +1,64 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func packagePagesGraph(root string) *graph.Graph {
+	g := graph.NewGraph()
+	g.AddSymbol(&graph.Symbol{ID: "open", Name: "Open", Package: "store", UnitType: "function", Filepath: filepath.Join(root, "internal/store/db.go"), Description: "Open opens the database."})
+	g.AddSymbol(&graph.Symbol{ID: "db", Name: "DB", Package: "store", UnitType: "struct", Filepath: filepath.Join(root, "internal/store/types.go"), Description: "DB wraps a | connection."})
+	g.AddSymbol(&graph.Symbol{ID: "helper", Name: "helper", Package: "store", UnitType: "function", Filepath: filepath.Join(root, "internal/store/db.go")})
+	g.AddSymbol(&graph.Symbol{ID: "run", Name: "Run", Package: "cli", UnitType: "function", Filepath: filepath.Join(root, "cmd/cli/run.go")})
+	g.Edges = []graph.Edge{
+		{From: "run", To: "open", Kind: graph.RelationCalls},
+		{From: "open", To: "db", Kind: graph.RelationUsesType},
+	}
+	return g
+}
+
+func TestBuildPackageDocPlan(t *testing.T) {
+	root := t.TempDir()
+	plan := BuildPackageDocPlan(packagePagesGraph(root), root)
+	require.Len(t, plan.Pages, 2)
+
+	cli, ok := plan.Page("cmd-cli")
+	require.True(t, ok)
+	assert.Equal(t, "cmd/cli", cli.Dir)
+	assert.Equal(t, []string{"internal-store"}, cli.DependsOn)
+
+	store, ok := plan.Page("internal-store")
+	require.True(t, ok)
+	assert.Equal(t, []string{"internal/store/db.go", "internal/store/types.go"}, store.Files)
+	assert.Equal(t, []string{"cmd-cli"}, store.UsedBy)
+	require.Len(t, store.API, 2, "unexported symbols are left out")
+	assert.Equal(t, "Open", store.API[0].Name, "most used first")
+}
+
+func TestWritePackagePages(t *testing.T) {
+	root := t.TempDir()
+	out := t.TempDir()
+	plan := BuildPackageDocPlan(packagePagesGraph(root), root)
+	written, err := writePackagePages(out, plan)
+	require.NoError(t, err)
+	assert.Equal(t, 2, written)
+
+	index, err := os.ReadFile(filepath.Join(out, "packages", "index.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(index), "| [store](internal-store.md) | `internal/store` | 3 | Open opens the database. |")
+
+	page, err := os.ReadFile(filepath.Join(out, "packages", "internal-store.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), "# Package `store`")
+	assert.Contains(t, string(page), "[All packages](index.md)")
+	assert.Contains(t, string(page), "| `DB` | struct | DB wraps a \\| connection. |")
+	assert.Contains(t, string(page), "- Used by: [cmd/cli](cmd-cli.md)")
+	assert.Contains(t, string(page), "- `internal/store/types.go`")
+}
@@ -6,7 +6,11 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
+
+	"docod/internal/knowledge"
+	"docod/internal/profiling"
 )
 
 type ReportSignal struct {
@@ -26,6 +30,8 @@ type StageMetric struct {
 	Counters   map[string]float64 `json:"counters,omitempty"`
 	Notes      []string           `json:"notes,omitempty"`
 	Error      string             `json:"error,omitempty"`
+	// Usage is the model usage of the stage, when it made any calls.
+	Usage *knowledge.Usage `json:"usage,omitempty"`
 }
 
 type SectionMetric struct {
@@ -39,12 +45,26 @@ type SectionMetric struct {
 	FileDiversity       int      `json:"file_diversity"`
 	EvidenceConfidence  float64  `json:"evidence_confidence"`
 	EvidenceCoverage    float64  `json:"evidence_coverage"`
+	EvidenceRelevance   float64  `json:"evidence_relevance,omitempty"`
 	LowEvidence         bool     `json:"low_evidence"`
 	WriterQualityScore  float64  `json:"writer_quality_score"`
 	WriterQualityIssues []string `json:"writer_quality_issues,omitempty"`
 	UsedDraft           bool     `json:"used_draft"`
 	UsedLLM             bool     `json:"used_llm"`
 	UsedFallback        bool     `json:"used_fallback"`
+	// Usage is the model usage attributed to the section.
+	Usage *knowledge.Usage `json:"usage,omitempty"`
+}
+
+type ReportCycle struct {
+	Level   string   `json:"level"`
+	Members []string `json:"members"`
+}
+
+// ReportResolver records one configured resolver stage, in run order.
+type ReportResolver struct {
+	Name          string  `json:"name"`
+	MinConfidence float64 `json:"min_confidence,omitempty"`
 }
 
 type ReportSummary struct {
@@ -54,22 +74,37 @@ type ReportSummary struct {
 	LowEvidenceSections int    `json:"low_evidence_sections"`
 	AvgWriterQuality   float64 `json:"avg_writer_quality"`
 	SignalsBySeverity  map[string]int `json:"signals_by_severity"`
+	// Usage is the model usage of the whole run.
+	Usage *knowledge.Usage `json:"usage,omitempty"`
 }
 
 type PipelineReport struct {
-	Version     string          `json:"version"`
-	Mode        string          `json:"mode"`
-	GeneratedAt string          `json:"generated_at"`
-	OutputDir   string          `json:"output_dir"`
-	Stages      []StageMetric   `json:"stages"`
-	Sections    []SectionMetric `json:"sections,omitempty"`
-	Signals     []ReportSignal  `json:"signals,omitempty"`
-	Summary     ReportSummary   `json:"summary"`
+	Version     string           `json:"version"`
+	Mode        string           `json:"mode"`
+	GeneratedAt string           `json:"generated_at"`
+	OutputDir   string           `json:"output_dir"`
+	Stages      []StageMetric    `json:"stages"`
+	Sections    []SectionMetric  `json:"sections,omitempty"`
+	Signals     []ReportSignal   `json:"signals,omitempty"`
+	Cycles      []ReportCycle    `json:"cycles,omitempty"`
+	Resolvers   []ReportResolver `json:"resolvers,omitempty"`
+	Summary     ReportSummary    `json:"summary"`
+
+	meter *knowledge.UsageMeter
+	// mu guards the appends of stages run concurrently.
+	mu sync.Mutex
 }
 
 type StageHandle struct {
 	name    string
 	started time.Time
+	// endProfile ends the stage's profile under --pprof.
+	endProfile func()
+	// usage is the meter total when the stage began, or the section's
+	// usage for a section stage.
+	usage knowledge.Usage
+	// section is the section ID of a section stage.
+	section string
 }
 
 func NewPipelineReport(mode, outputDir string) *PipelineReport {
@@ -84,11 +119,68 @@ func NewPipelineReport(mode, outputDir string) *PipelineReport {
 	}
 }
 
+// SetUsageMeter attributes the usage recorded on m to the stages and
+// sections of the report.
+func (r *PipelineReport) SetUsageMeter(m *knowledge.UsageMeter) {
+	if r == nil {
+		return
+	}
+	r.meter = m
+}
+
 func (r *PipelineReport) BeginStage(name string) StageHandle {
-	return StageHandle{name: strings.TrimSpace(name), started: time.Now().UTC()}
+	name = strings.TrimSpace(name)
+	h := StageHandle{name: name, started: time.Now().UTC(), endProfile: profiling.Stage(name)}
+	if r != nil {
+		h.usage = r.meter.Total()
+	}
+	return h
+}
+
+// BeginSectionStage begins the stage "section_<id>". Its usage is what was
+// recorded for the section, so sections generated concurrently do not count
+// each other's calls. It is not profiled on its own: sections may run
+// concurrently, so their CPU time is profiled by the caller's "sections"
+// stage.
+func (r *PipelineReport) BeginSectionStage(id string) StageHandle {
+	h := StageHandle{name: "section_" + id, started: time.Now().UTC(), section: id}
+	if r != nil {
+		h.usage = r.meter.Section(id)
+	}
+	return h
+}
+
+// orderSectionStages puts the recorded section stages in the order of ids.
+// Sections generated concurrently finish, and so are recorded, in any order;
+// the other stages keep their places.
+func (r *PipelineReport) orderSectionStages(ids []string) {
+	if r == nil {
+		return
+	}
+	rank := make(map[string]int, len(ids))
+	for i, id := range ids {
+		rank["section_"+id] = i
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var slots []int
+	var sections []StageMetric
+	for i, st := range r.Stages {
+		if _, ok := rank[st.Name]; ok {
+			slots = append(slots, i)
+			sections = append(sections, st)
+		}
+	}
+	sort.SliceStable(sections, func(i, j int) bool { return rank[sections[i].Name] < rank[sections[j].Name] })
+	for i, slot := range slots {
+		r.Stages[slot] = sections[i]
+	}
 }
 
 func (r *PipelineReport) EndStage(h StageHandle, status string, counters map[string]float64, notes []string, err error) {
+	if h.endProfile != nil {
+		h.endProfile()
+	}
 	if r == nil || strings.TrimSpace(h.name) == "" {
 		return
 	}
@@ -111,7 +203,14 @@ func (r *PipelineReport) EndStage(h StageHandle, status string, counters map[str
 			m.Status = "error"
 		}
 	}
+	if h.section != "" {
+		m.Usage = usageOrNil(r.meter.Section(h.section).Sub(h.usage))
+	} else {
+		m.Usage = usageOrNil(r.meter.Total().Sub(h.usage))
+	}
+	r.mu.Lock()
 	r.Stages = append(r.Stages, m)
+	r.mu.Unlock()
 }
 
 func (r *PipelineReport) AddSignal(code, stage, severity, message string, value float64) {
@@ -128,14 +227,41 @@ func (r *PipelineReport) AddSignal(code, stage, severity, message string, value
 	if s.Code == "" || s.Stage == "" || s.Severity == "" || s.Message == "" {
 		return
 	}
+	r.mu.Lock()
 	r.Signals = append(r.Signals, s)
+	r.mu.Unlock()
 }
 
 func (r *PipelineReport) AddSectionMetric(m SectionMetric) {
 	if r == nil || strings.TrimSpace(m.SectionID) == "" {
 		return
 	}
+	if m.Usage == nil {
+		m.Usage = usageOrNil(r.meter.Section(m.SectionID))
+	}
+	r.mu.Lock()
 	r.Sections = append(r.Sections, m)
+	r.mu.Unlock()
+}
+
+func (r *PipelineReport) AddCycle(level string, members []string) {
+	if r == nil || strings.TrimSpace(level) == "" || len(members) == 0 {
+		return
+	}
+	r.Cycles = append(r.Cycles, ReportCycle{
+		Level:   strings.TrimSpace(level),
+		Members: append([]string(nil), members...),
+	})
+}
+
+func (r *PipelineReport) AddResolver(name string, minConfidence float64) {
+	if r == nil || strings.TrimSpace(name) == "" {
+		return
+	}
+	r.Resolvers = append(r.Resolvers, ReportResolver{
+		Name:          strings.TrimSpace(name),
+		MinConfidence: minConfidence,
+	})
 }
 
 func (r *PipelineReport) Finalize() {
@@ -194,7 +320,16 @@ func (r *PipelineReport) Finalize() {
 		LowEvidenceSections: lowEvidence,
 		AvgWriterQuality:   avgQuality,
 		SignalsBySeverity:  severityCount,
+		Usage:              usageOrNil(r.meter.Total()),
+	}
+}
+
+// usageOrNil returns u, or nil when it has no requests.
+func usageOrNil(u knowledge.Usage) *knowledge.Usage {
+	if u.Requests == 0 {
+		return nil
 	}
+	return &u
 }
 
 func (r *PipelineReport) Save(path string) error {
@@ -0,0 +1,196 @@
+package generator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"docod/internal/knowledge"
+)
+
+// DocPreset tailors the section list and retrieval plans to a kind of
+// project, so common layouts need no docplan.yaml.
+type DocPreset struct {
+	Name string
+	// Sections is the canonical root section order; IDs without a default
+	// plan must be defined in Plans.
+	Sections []string
+	// Plans adds sections or replaces the default plan of a section.
+	Plans []SectionDocPlan
+	// Keywords extends the retrieval keywords of default sections.
+	Keywords map[string][]string
+}
+
+var docPresets = map[string]DocPreset{
+	"library": {
+		Name:     "library",
+		Sections: []string{"overview", "key-features", "usage", "development"},
+		Plans: []SectionDocPlan{{
+			SectionID:         "usage",
+			Title:             "Usage",
+			Goal:              "Show how callers import the package, construct its main types and combine them for common tasks.",
+			RequiredBlocks:    []string{"Getting Started", "Common Tasks"},
+			QueryHints:        []string{"public api", "constructor", "options", "example usage"},
+			RetrievalKeywords: []string{"new", "option", "config", "client", "example", "default"},
+			TopK:              14,
+			MinEvidence:       5,
+			AllowLLM:          true,
+			Filter:            knowledge.SearchFilter{ExcludeUnitTypes: []string{"constant", "variable"}, ExcludeTests: true},
+		}},
+		Keywords: map[string][]string{"key-features": {"interface", "option"}},
+	},
+	"service": {
+		Name:     "service",
+		Sections: []string{"overview", "key-features", "operations", "development"},
+		Plans: []SectionDocPlan{{
+			SectionID:         "operations",
+			Title:             "Operations",
+			Goal:              "Explain how the service is configured, deployed and observed: endpoints, ports, health checks, metrics and failure handling.",
+			RequiredBlocks:    []string{"Endpoints", "Configuration", "Observability"},
+			QueryHints:        []string{"http server", "request handlers", "deployment configuration", "health and metrics"},
+			RetrievalKeywords: []string{"server", "handler", "route", "endpoint", "listen", "port", "health", "metric", "env"},
+			TopK:              16,
+			MinEvidence:       6,
+			RequireMermaid:    true,
+			AllowLLM:          true,
+			Filter:            knowledge.SearchFilter{ExcludeTests: true},
+		}},
+		Keywords: map[string][]string{"overview": {"server", "handler", "request"}, "key-features": {"endpoint", "handler"}},
+	},
+	"cli": {
+		Name:     "cli",
+		Sections: []string{"overview", "commands", "key-features", "development"},
+		Plans: []SectionDocPlan{{
+			SectionID:         "commands",
+			Title:             "Commands",
+			Goal:              "Document each command with its purpose, flags, arguments and a typical invocation.",
+			RequiredBlocks:    []string{"Command Reference", "Examples"},
+			QueryHints:        []string{"cli commands", "command flags", "arguments", "subcommands"},
+			RetrievalKeywords: []string{"command", "cmd", "flag", "args", "cobra", "run", "usage"},
+			TopK:              18,
+			MinEvidence:       5,
+			AllowLLM:          true,
+			Filter:            knowledge.SearchFilter{ExcludeTests: true},
+		}},
+		Keywords: map[string][]string{"development": {"flag", "main"}},
+	},
+	"sdk": {
+		Name:     "sdk",
+		Sections: []string{"overview", "installation", "key-features", "api-reference", "development"},
+		Plans: []SectionDocPlan{
+			{
+				SectionID:         "installation",
+				Title:             "Installation",
+				Goal:              "Explain how to add the SDK to a project, which versions are supported and how to authenticate the first call.",
+				RequiredBlocks:    []string{"Requirements", "Install", "First Call"},
+				QueryHints:        []string{"install", "module path", "client setup", "authentication"},
+				RetrievalKeywords: []string{"client", "new", "auth", "token", "key", "version", "config"},
+				TopK:              10,
+				MinEvidence:       3,
+				AllowLLM:          true,
+				Filter:            knowledge.SearchFilter{ExcludeTests: true},
+			},
+			{
+				SectionID:         "api-reference",
+				Title:             "API Reference",
+				Goal:              "List the exported types and functions grouped by package, with signatures and one-line behavior.",
+				RequiredBlocks:    []string{"Types", "Functions"},
+				QueryHints:        []string{"exported types", "public functions", "method signatures"},
+				RetrievalKeywords: []string{"client", "request", "response", "option", "error"},
+				TopK:              24,
+				MinEvidence:       8,
+				AllowLLM:          false,
+				Filter: knowledge.SearchFilter{
+					UnitTypes:    []string{"function", "method", "struct", "interface"},
+					ExcludeTests: true,
+				},
+			},
+		},
+		Keywords: map[string][]string{"key-features": {"client", "request"}},
+	},
+}
+
+// LookupDocPreset returns the named preset. An empty name means the default
+// plan and reports false without an error.
+func LookupDocPreset(name string) (DocPreset, bool, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return DocPreset{}, false, nil
+	}
+	p, ok := docPresets[name]
+	if !ok {
+		return DocPreset{}, false, fmt.Errorf("unknown docs preset %q (%s)", name, strings.Join(DocPresetNames(), "|"))
+	}
+	return p, true, nil
+}
+
+// DocPresetNames lists the built-in presets in name order.
+func DocPresetNames() []string {
+	names := make([]string, 0, len(docPresets))
+	for name := range docPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply reorders plan to the preset's sections, adding the preset plans and
+// keywords. Sections outside the preset list keep their place after it.
+func (p DocPreset) Apply(plan *FullDocPlan) {
+	if plan == nil {
+		return
+	}
+	for _, extra := range p.Plans {
+		replaced := false
+		for i := range plan.Sections {
+			if plan.Sections[i].SectionID == extra.SectionID {
+				plan.Sections[i] = extra
+				replaced = true
+			}
+		}
+		if !replaced {
+			plan.Sections = append(plan.Sections, extra)
+		}
+	}
+	for i := range plan.Sections {
+		sec := &plan.Sections[i]
+		if kws := p.Keywords[sec.SectionID]; len(kws) > 0 {
+			sec.RetrievalKeywords = uniqueStrings(append(append([]string(nil), sec.RetrievalKeywords...), kws...))
+		}
+	}
+	rank := make(map[string]int, len(p.Sections))
+	for i, id := range p.Sections {
+		rank[id] = i
+	}
+	sort.SliceStable(plan.Sections, func(i, j int) bool {
+		ri, ok := rank[plan.Sections[i].SectionID]
+		if !ok {
+			ri = len(p.Sections)
+		}
+		rj, ok := rank[plan.Sections[j].SectionID]
+		if !ok {
+			rj = len(p.Sections)
+		}
+		return ri < rj
+	})
+}
+
+// BuildPresetFullDocPlan returns the default plan shaped by the named preset.
+func BuildPresetFullDocPlan(name string) (*FullDocPlan, error) {
+	plan := BuildDefaultFullDocPlan()
+	preset, ok, err := LookupDocPreset(name)
+	if err != nil || !ok {
+		return plan, err
+	}
+	preset.Apply(plan)
+	return plan, nil
+}
+
+// canonicalSections returns the root section order for a preset, falling
+// back to the default order.
+func canonicalSections(name string) []string {
+	if preset, ok, _ := LookupDocPreset(name); ok {
+		return append([]string(nil), preset.Sections...)
+	}
+	return append([]string(nil), canonicalSectionOrder...)
+}
@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocPresets_EverySectionHasAPlan(t *testing.T) {
+	assert.Equal(t, []string{"cli", "library", "sdk", "service"}, DocPresetNames())
+	for _, name := range DocPresetNames() {
+		plan, err := BuildPresetFullDocPlan(name)
+		require.NoError(t, err)
+		for i, id := range canonicalSections(name) {
+			sec, ok := plan.SectionByID(id)
+			require.True(t, ok, "%s: %s", name, id)
+			assert.Equal(t, id, plan.Sections[i].SectionID, "%s: plan follows the section order", name)
+			assert.NotEmpty(t, sec.RetrievalKeywords)
+		}
+	}
+}
+
+func TestBuildPresetFullDocPlan_SDK(t *testing.T) {
+	plan, err := BuildPresetFullDocPlan(" SDK ")
+	require.NoError(t, err)
+
+	ref, ok := plan.SectionByID("api-reference")
+	require.True(t, ok)
+	assert.Equal(t, "API Reference", ref.Title)
+	features, _ := plan.SectionByID("key-features")
+	assert.Contains(t, features.RetrievalKeywords, "client")
+	assert.Contains(t, features.RetrievalKeywords, "feature", "default keywords are kept")
+
+	_, err = BuildPresetFullDocPlan("monorepo")
+	assert.ErrorContains(t, err, "cli|library|sdk|service")
+	plan, err = BuildPresetFullDocPlan("")
+	require.NoError(t, err)
+	assert.Len(t, plan.Sections, len(BuildDefaultFullDocPlan().Sections))
+}
+
+func TestBuildSchemaScaffoldModel_UsesPresetSections(t *testing.T) {
+	plan, err := BuildPresetFullDocPlan("sdk")
+	require.NoError(t, err)
+	g := &MarkdownGenerator{}
+	model := g.buildSchemaScaffoldModel("2026-01-01T00:00:00Z", canonicalSections("sdk"), plan)
+
+	assert.Equal(t, []string{"overview", "installation", "key-features", "api-reference", "development"}, model.Document.RootSectionIDs)
+	assert.Contains(t, model.SectionByID("installation").ContentMD, "## First Call")
+
+	// Normalizing keeps the preset order and restores dropped sections.
+	model.Sections = model.Sections[1:]
+	NormalizeDocModel(model)
+	ids := make([]string, 0, len(model.Sections))
+	for _, s := range model.Sections {
+		ids = append(ids, s.ID)
+	}
+	assert.Equal(t, model.Policies.RequiredSectionIDs, ids)
+}
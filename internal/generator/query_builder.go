@@ -120,6 +120,7 @@ func chunkRichnessScore(c knowledge.SearchChunk) int {
 	}
 	score += len(c.Dependencies)
 	score += len(c.UsedBy)
+	score += int(c.Centrality * 6)
 	switch c.UnitType {
 	case "function", "method", "struct", "interface":
 		score += 2
@@ -135,8 +136,13 @@ func buildEvidenceStats(plan SectionDocPlan, queries []string, chunks []knowledg
 	confidenceSum := 0.0
 	confidenceN := 0.0
 	fileSet := map[string]bool{}
+	scoreSum, scoreN := 0.0, 0.0
 
 	for _, c := range chunks {
+		if c.Score > 0 {
+			scoreSum += c.Score
+			scoreN++
+		}
 		fileKey := chunkFileKey(c)
 		if strings.TrimSpace(fileKey) != "" {
 			fileSet[fileKey] = true
@@ -172,6 +178,13 @@ func buildEvidenceStats(plan SectionDocPlan, queries []string, chunks []knowledg
 	if confidenceN > 0 {
 		baseConfidence = confidenceSum / confidenceN
 	}
+	// Retrieval similarity tempers source confidence when search scored the
+	// evidence; heuristic-only evidence keeps the source-based confidence.
+	relevance := 0.0
+	if scoreN > 0 {
+		relevance = scoreSum / scoreN
+		baseConfidence = 0.75*baseConfidence + 0.25*relevance
+	}
 	diversityBonus := 0.0
 	if chunkCount > 0 {
 		diversityBonus = 0.2 * (float64(len(fileSet)) / float64(chunkCount))
@@ -190,6 +203,7 @@ func buildEvidenceStats(plan SectionDocPlan, queries []string, chunks []knowledg
 		ChunkCount:  chunkCount,
 		SourceCount: sourceCount,
 		QueryCount:  len(queries),
+		Relevance:   relevance,
 		LowEvidence: coverage < 0.7 || confidence < 0.6,
 	}
 }
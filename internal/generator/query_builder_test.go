@@ -71,3 +71,24 @@ func TestBuildEvidenceStats_ComputesCoverageAndConfidence(t *testing.T) {
 	assert.Equal(t, 2, stats.ChunkCount)
 	assert.True(t, stats.LowEvidence)
 }
+
+func TestBuildEvidenceStats_UsesRetrievalScores(t *testing.T) {
+	plan := SectionDocPlan{SectionID: "overview", MinEvidence: 2}
+	chunk := func(id string, score float64) knowledge.SearchChunk {
+		return knowledge.SearchChunk{
+			ID:       id,
+			FilePath: id + ".go",
+			Score:    score,
+			Sources:  []knowledge.ChunkSource{{SymbolID: id, FilePath: id + ".go", Confidence: 0.9}},
+		}
+	}
+
+	unscored := buildEvidenceStats(plan, nil, []knowledge.SearchChunk{chunk("a", 0), chunk("b", 0)})
+	strong := buildEvidenceStats(plan, nil, []knowledge.SearchChunk{chunk("a", 0.9), chunk("b", 0.8)})
+	weak := buildEvidenceStats(plan, nil, []knowledge.SearchChunk{chunk("a", 0.2), chunk("b", 0.1)})
+
+	assert.Zero(t, unscored.Relevance)
+	assert.InDelta(t, 0.85, strong.Relevance, 1e-9)
+	assert.Greater(t, strong.Confidence, weak.Confidence)
+	assert.Less(t, weak.Confidence, unscored.Confidence)
+}
@@ -0,0 +1,139 @@
+package generator
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"docod/internal/config"
+	"docod/internal/knowledge"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowSummarizer holds each call briefly and tracks how many overlap.
+type slowSummarizer struct {
+	flakySummarizer
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+}
+
+func (s *slowSummarizer) hold() func() {
+	s.mu.Lock()
+	s.inFlight++
+	s.maxInFlight = max(s.maxInFlight, s.inFlight)
+	s.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	return func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}
+}
+
+func (s *slowSummarizer) UpdateDocSection(ctx context.Context, current string, chunks []knowledge.SearchChunk) (string, error) {
+	defer s.hold()()
+	return s.flakySummarizer.UpdateDocSection(ctx, current, chunks)
+}
+
+func (s *slowSummarizer) RenderSectionFromDraft(ctx context.Context, draft string, chunks []knowledge.SearchChunk) (string, error) {
+	defer s.hold()()
+	return s.flakySummarizer.RenderSectionFromDraft(ctx, draft, chunks)
+}
+
+func TestGenerateDocs_GeneratesSectionsConcurrently(t *testing.T) {
+	t.Setenv("DOCOD_SECTION_CONCURRENCY", "3")
+	engine, dir := newGenerateTestEngine(t)
+	s := &slowSummarizer{}
+	report := NewPipelineReport("full_generate", dir)
+	require.NoError(t, NewMarkdownGenerator(engine, s).GenerateDocsWithReport(context.Background(), dir, report))
+
+	assert.Greater(t, s.maxInFlight, 1)
+	model, err := LoadDocModel(config.DefaultOutputPaths().WithDir(dir).Model())
+	require.NoError(t, err)
+	// Section metrics keep document order whatever order sections finish in.
+	require.Len(t, report.Sections, len(model.Sections))
+	for i, sec := range model.Sections {
+		assert.Equal(t, sec.ID, report.Sections[i].SectionID)
+	}
+}
+
+// jitterSummarizer delays the draft renders of earlier sections more, so
+// later sections reach their rewrite first, and marks the rewrites it makes.
+type jitterSummarizer struct {
+	flakySummarizer
+	mu      sync.Mutex
+	renders int
+}
+
+func (s *jitterSummarizer) RenderSectionFromDraft(ctx context.Context, draft string, chunks []knowledge.SearchChunk) (string, error) {
+	s.mu.Lock()
+	s.renders++
+	delay := time.Duration(max(12-s.renders, 0)) * 5 * time.Millisecond
+	s.mu.Unlock()
+	time.Sleep(delay)
+	return s.flakySummarizer.RenderSectionFromDraft(ctx, draft, chunks)
+}
+
+func (s *jitterSummarizer) UpdateDocSection(ctx context.Context, current string, chunks []knowledge.SearchChunk) (string, error) {
+	out, err := s.flakySummarizer.UpdateDocSection(ctx, current, chunks)
+	return out + "\n\nRewritten by the model.", err
+}
+
+func TestGenerateDocs_SameOutputAtAnyConcurrency(t *testing.T) {
+	generate := func(concurrency string) map[string]string {
+		t.Setenv("DOCOD_SECTION_CONCURRENCY", concurrency)
+		engine, dir := newGenerateTestEngine(t)
+		report := NewPipelineReport("full_generate", dir)
+		require.NoError(t, NewMarkdownGenerator(engine, &jitterSummarizer{}).GenerateDocsWithReport(context.Background(), dir, report))
+		model, err := LoadDocModel(config.DefaultOutputPaths().WithDir(dir).Model())
+		require.NoError(t, err)
+		out := make(map[string]string)
+		var want []string
+		for _, sec := range model.Sections {
+			out[sec.ID] = sec.ContentMD
+			want = append(want, "section_"+sec.ID)
+		}
+		var stages []string
+		for _, st := range report.Stages {
+			if strings.HasPrefix(st.Name, "section_") {
+				stages = append(stages, st.Name)
+			}
+		}
+		assert.Equal(t, want, stages, "section stages are reported in section order")
+		return out
+	}
+
+	sequential := generate("1")
+	for run := 0; run < 3; run++ {
+		assert.Equal(t, sequential, generate("4"))
+	}
+}
+
+func TestSectionLLMBudget_GoesToFirstEligibleSection(t *testing.T) {
+	b := newSectionLLMBudget(1, 4)
+	took := make([]bool, 4)
+	var wg sync.WaitGroup
+	for i := 3; i >= 0; i-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer b.settle(i)
+			if i == 0 {
+				return // not eligible: never asks
+			}
+			// Later sections ask first.
+			time.Sleep(time.Duration(3-i) * 10 * time.Millisecond)
+			took[i] = b.forSection(i).take()
+			if i == 1 {
+				b.forSection(i).refund() // the call yielded nothing
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, []bool{false, true, true, false}, took)
+}
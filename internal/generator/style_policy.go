@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"strings"
+
+	"docod/internal/config"
+	"docod/internal/knowledge"
+)
+
+// SectionStylePolicy overrides the document style for one section. Empty
+// fields inherit from ModelPolicy.Style.
+type SectionStylePolicy struct {
+	Tone              string   `json:"tone,omitempty"`
+	Audience          string   `json:"audience,omitempty"`
+	CodeBlockLanguage string   `json:"code_block_language,omitempty"`
+	AllowedDiagrams   []string `json:"allowed_diagrams,omitempty"`
+}
+
+// applyStyleConfig copies docs.style and docs.section_styles into the model
+// policies. The config is authoritative for section overrides, so removing
+// one from the config removes it from the model.
+func applyStyleConfig(m *DocModel, style config.StyleConfig, sections map[string]config.StyleConfig) {
+	if m == nil {
+		return
+	}
+	if v := strings.TrimSpace(style.Tone); v != "" {
+		m.Policies.Style.Tone = v
+	}
+	if v := strings.TrimSpace(style.Audience); v != "" {
+		m.Policies.Style.Audience = v
+	}
+	if v := strings.TrimSpace(style.CodeLanguage); v != "" {
+		m.Policies.Style.CodeBlockLanguage = v
+	}
+	m.Policies.Style.AllowedDiagrams = normalizeDiagrams(style.Diagrams)
+
+	m.Policies.SectionStyles = nil
+	for id, s := range sections {
+		if m.Policies.SectionStyles == nil {
+			m.Policies.SectionStyles = make(map[string]SectionStylePolicy, len(sections))
+		}
+		m.Policies.SectionStyles[id] = SectionStylePolicy{
+			Tone:              strings.TrimSpace(s.Tone),
+			Audience:          strings.TrimSpace(s.Audience),
+			CodeBlockLanguage: strings.TrimSpace(s.CodeLanguage),
+			AllowedDiagrams:   normalizeDiagrams(s.Diagrams),
+		}
+	}
+}
+
+// StyleFor returns the effective style of a section for prompt building.
+func (p ModelPolicy) StyleFor(sectionID string) knowledge.SectionStyle {
+	style := knowledge.SectionStyle{
+		Tone:         p.Style.Tone,
+		Audience:     p.Style.Audience,
+		CodeLanguage: p.Style.CodeBlockLanguage,
+		Diagrams:     p.Style.AllowedDiagrams,
+	}
+	override, ok := p.SectionStyles[sectionID]
+	if !ok {
+		return style
+	}
+	if override.Tone != "" {
+		style.Tone = override.Tone
+	}
+	if override.Audience != "" {
+		style.Audience = override.Audience
+	}
+	if override.CodeBlockLanguage != "" {
+		style.CodeLanguage = override.CodeBlockLanguage
+	}
+	if len(override.AllowedDiagrams) > 0 {
+		style.Diagrams = override.AllowedDiagrams
+	}
+	return style
+}
+
+func normalizeDiagrams(in []string) []string {
+	var out []string
+	for _, d := range in {
+		if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+			out = append(out, d)
+		}
+	}
+	return uniqueStrings(out)
+}
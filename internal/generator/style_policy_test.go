@@ -0,0 +1,48 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"docod/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyStyleConfig_SectionOverrides(t *testing.T) {
+	model := BuildModelFromMarkdown("# Overview\n\nhello\n\n# Development\n\nbuild it\n")
+	applyStyleConfig(model,
+		config.StyleConfig{Audience: "application developers", Diagrams: []string{"Flowchart"}},
+		map[string]config.StyleConfig{
+			"development": {Tone: "friendly, hands-on", CodeLanguage: "bash", Diagrams: []string{"none"}},
+		},
+	)
+
+	overview := model.Policies.StyleFor("overview")
+	assert.Equal(t, "technical, objective", overview.Tone)
+	assert.Equal(t, "application developers", overview.Audience)
+	assert.Equal(t, "go", overview.CodeLanguage)
+	assert.Equal(t, []string{"flowchart"}, overview.Diagrams)
+
+	dev := model.Policies.StyleFor("development")
+	assert.Equal(t, "friendly, hands-on", dev.Tone)
+	assert.Equal(t, "application developers", dev.Audience)
+	assert.Equal(t, "bash", dev.CodeLanguage)
+	assert.Equal(t, []string{"none"}, dev.Diagrams)
+
+	// The policies still validate against the doc model schema.
+	tmp := t.TempDir()
+	_, currentFile, _, ok := runtime.Caller(0)
+	require.True(t, ok)
+	schemaBytes, err := os.ReadFile(filepath.Join(filepath.Dir(currentFile), "..", "..", "docs", "doc_model.schema.json"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(tmp, "doc_model.schema.json"), schemaBytes, 0644))
+	require.NoError(t, SaveDocModel(filepath.Join(tmp, "doc_model.json"), model))
+
+	// Dropping the override from the config drops it from the model.
+	applyStyleConfig(model, config.StyleConfig{}, nil)
+	assert.Empty(t, model.Policies.SectionStyles)
+}
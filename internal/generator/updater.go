@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"math"
 	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -31,6 +30,9 @@ type UpdatePlan struct {
 	StrictSectionScope  bool
 	SectionConfidence   map[string]float64
 	MinConfidenceForLLM float64
+	// BreakingChanges lists breaking API changes per section ID; they are
+	// shown in a callout at the top of the section.
+	BreakingChanges map[string][]string
 }
 
 func NewDocUpdater(e *knowledge.Engine, s knowledge.Summarizer) *DocUpdater {
@@ -48,7 +50,7 @@ func (u *DocUpdater) UpdateDocs(ctx context.Context, docPath string, changedFile
 // UpdateDocsWithPlan incrementally updates docs with optional section-priority guidance.
 func (u *DocUpdater) UpdateDocsWithPlan(ctx context.Context, docPath string, changedFilePaths []string, plan *UpdatePlan) error {
 	opts := resolveUpdaterOptions()
-	modelPath := filepath.Join(filepath.Dir(docPath), "doc_model.json")
+	modelPath := ModelPathFor(docPath)
 
 	// Ensure we can bootstrap from existing markdown if model doesn't exist yet.
 	model, err := u.loadOrBootstrapModel(modelPath, docPath)
@@ -125,6 +127,16 @@ func (u *DocUpdater) UpdateDocsWithPlan(ctx context.Context, docPath string, cha
 		}
 	}
 
+	// Sections documenting breaking changes always get their callout, even
+	// when no changed chunk matched them.
+	if plan != nil {
+		for secID := range plan.BreakingChanges {
+			if _, ok := affected[secID]; !ok && model.SectionByID(secID) != nil {
+				affected[secID] = nil
+			}
+		}
+	}
+
 	if len(affected) == 0 && len(unmatched) == 0 {
 		fmt.Println("  -> No relevant documentation changes needed.")
 		return nil
@@ -139,19 +151,24 @@ func (u *DocUpdater) UpdateDocsWithPlan(ctx context.Context, docPath string, cha
 		updateOrder = mergePreferredSectionOrder(updateOrder, plan.PreferredSectionIDs)
 	}
 	llmApplied := 0
+	genOpts := resolveGeneratorOptions()
+	sectionPlans := resolveFullDocPlan(genOpts)
+	applyStyleConfig(model, genOpts.style, genOpts.sectionStyles)
 
 	// Update affected sections.
 	for _, secID := range updateOrder {
+		if secID == APIChangesSectionID || secID == APIReferenceSectionID || secID == ChangelogSectionID {
+			// Maintained deterministically, without the LLM.
+			continue
+		}
 		triggeringChunks := affected[secID]
 		sec := model.SectionByID(secID)
 		if sec == nil {
 			continue
 		}
 		secPlan := fallbackSectionPlan(*sec)
-		if defaultPlan := BuildDefaultFullDocPlan(); defaultPlan != nil {
-			if planned, ok := defaultPlan.SectionByID(secID); ok {
-				secPlan = planned
-			}
+		if planned, ok := sectionPlans.SectionByID(secID); ok {
+			secPlan = planned
 		}
 		evidence := buildEvidenceStats(secPlan, []string{"incremental update " + secID}, triggeringChunks)
 
@@ -174,15 +191,18 @@ func (u *DocUpdater) UpdateDocsWithPlan(ctx context.Context, docPath string, cha
 				sec.ContentMD = applyLowEvidencePolicy(sec.ContentMD)
 				sec.Summary = summarizeContent(sec.ContentMD)
 			}
+			sec.ContentMD = applyBreakingCallout(sec.ContentMD, breakingChangesFor(plan, secID))
 			sec.Hash = sectionHash(*sec)
 			appliedUpdates++
 			continue
 		}
 		llmApplied++
 
-		updatedContent, err := u.summarizer.UpdateDocSection(ctx, sec.ContentMD, triggeringChunks)
+		secCtx := knowledge.WithSectionStyle(knowledge.WithUsageSection(ctx, secID), model.Policies.StyleFor(secID))
+		updatedContent, err := u.summarizer.UpdateDocSection(secCtx, sec.ContentMD, triggeringChunks)
 		if err != nil {
 			fmt.Printf("Failed to update section %s: %v\n", sec.Title, err)
+			sec.ContentMD = applyBreakingCallout(sec.ContentMD, breakingChangesFor(plan, secID))
 			sec.Hash = sectionHash(*sec)
 			appliedUpdates++
 			continue
@@ -193,6 +213,7 @@ func (u *DocUpdater) UpdateDocsWithPlan(ctx context.Context, docPath string, cha
 			sec.ContentMD = applyLowEvidencePolicy(sec.ContentMD)
 		}
 		sec.Summary = summarizeContent(sec.ContentMD)
+		sec.ContentMD = applyBreakingCallout(sec.ContentMD, breakingChangesFor(plan, secID))
 		sec.Hash = sectionHash(*sec)
 		appliedUpdates++
 	}
@@ -214,7 +235,8 @@ func (u *DocUpdater) UpdateDocsWithPlan(ctx context.Context, docPath string, cha
 		newEvidence := buildEvidenceStats(newSecPlan, []string{"incremental unmatched changes"}, batch)
 		newContent := ""
 		if shouldUseLLMForEvidence(newEvidence) {
-			content, err := u.summarizer.GenerateNewSection(ctx, batch)
+			secCtx := knowledge.WithSectionStyle(knowledge.WithUsageSection(ctx, newSecPlan.SectionID), model.Policies.StyleFor(newSecPlan.SectionID))
+			content, err := u.summarizer.GenerateNewSection(secCtx, batch)
 			if err != nil {
 				fmt.Printf("Failed to generate new section for unmatched changes: %v\n", err)
 			} else {
@@ -379,6 +401,12 @@ func ensureUniqueSectionID(model *DocModel, base string) string {
 	}
 }
 
+// MaxLLMSections returns how many sections one incremental update may
+// rewrite with the LLM.
+func MaxLLMSections() int {
+	return resolveUpdaterOptions().maxLLMSections
+}
+
 func resolveUpdaterOptions() updaterOptions {
 	// Safe defaults for low-cost operation.
 	opts := updaterOptions{
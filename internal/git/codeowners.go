@@ -0,0 +1,136 @@
+package git
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// codeownersLocations are checked in order, as on GitHub.
+var codeownersLocations = []string{
+	filepath.Join(".github", "CODEOWNERS"),
+	"CODEOWNERS",
+	filepath.Join("docs", "CODEOWNERS"),
+}
+
+type codeownersRule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// CodeOwners maps repository paths to their owners.
+type CodeOwners struct {
+	rules []codeownersRule
+}
+
+// LoadCodeOwners reads the first CODEOWNERS file found under root. It returns
+// nil and no error when the repository has none.
+func LoadCodeOwners(root string) (*CodeOwners, error) {
+	for _, loc := range codeownersLocations {
+		f, err := os.Open(filepath.Join(root, loc))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ParseCodeOwners(f)
+	}
+	return nil, nil
+}
+
+// ParseCodeOwners parses CODEOWNERS content. Lines without owners are kept:
+// they clear ownership for the paths they match.
+func ParseCodeOwners(r io.Reader) (*CodeOwners, error) {
+	co := &CodeOwners{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		re, err := regexp.Compile(codeownersPatternRegexp(fields[0]))
+		if err != nil {
+			continue
+		}
+		co.rules = append(co.rules, codeownersRule{pattern: re, owners: fields[1:]})
+	}
+	return co, sc.Err()
+}
+
+// Owners returns the owners of path (relative to the repository root). The
+// last matching rule wins.
+func (c *CodeOwners) Owners(path string) []string {
+	if c == nil {
+		return nil
+	}
+	path = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "./")
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		if c.rules[i].pattern.MatchString(path) {
+			return c.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// OwnersOf returns the sorted union of the owners of paths.
+func (c *CodeOwners) OwnersOf(paths []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, p := range paths {
+		for _, o := range c.Owners(p) {
+			if !seen[o] {
+				seen[o] = true
+				out = append(out, o)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// codeownersPatternRegexp translates gitignore-style CODEOWNERS patterns.
+// Patterns without a leading or inner slash match at any depth, and a
+// pattern naming a directory also matches everything below it.
+func codeownersPatternRegexp(pattern string) string {
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+	pattern = strings.Trim(pattern, "/")
+
+	var sb strings.Builder
+	if anchored {
+		sb.WriteString("^")
+	} else {
+		sb.WriteString("(^|/)")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch ch := pattern[i]; ch {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					sb.WriteString("(.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	sb.WriteString("(/.*)?$")
+	return sb.String()
+}
@@ -0,0 +1,47 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeOwners(t *testing.T) {
+	co, err := ParseCodeOwners(strings.NewReader(`# Default owners
+*       @org/core
+*.md    @org/docs
+/internal/storage/ @alice @bob
+docs/   @org/writers # inline comment
+**/testdata/** @qa
+/cmd/generated.go
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"@org/core"}, co.Owners("internal/graph/graph.go"))
+	assert.Equal(t, []string{"@org/docs"}, co.Owners("README.md"))
+	assert.Equal(t, []string{"@alice", "@bob"}, co.Owners("internal/storage/sqlite.go"))
+	assert.Equal(t, []string{"@org/core"}, co.Owners("pkg/internal/storage/x.go"))
+	assert.Equal(t, []string{"@org/writers"}, co.Owners("docs/documentation.md"))
+	assert.Equal(t, []string{"@org/writers"}, co.Owners("site/docs/guide.txt"))
+	assert.Equal(t, []string{"@qa"}, co.Owners("internal/x/testdata/a/b.go"))
+	assert.Empty(t, co.Owners("cmd/generated.go"))
+	assert.Equal(t, []string{"@alice", "@bob", "@org/core"}, co.OwnersOf([]string{"internal/storage/sqlite.go", "main.go", "./main.go"}))
+}
+
+func TestLoadCodeOwners(t *testing.T) {
+	root := t.TempDir()
+	co, err := LoadCodeOwners(root)
+	require.NoError(t, err)
+	assert.Nil(t, co)
+	assert.Empty(t, co.Owners("a.go"))
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".github"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".github", "CODEOWNERS"), []byte("*.go @gophers\n"), 0o644))
+	co, err = LoadCodeOwners(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"@gophers"}, co.Owners("a/b.go"))
+}
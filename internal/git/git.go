@@ -1,18 +1,40 @@
 package git
 
 import (
+	"archive/tar"
 	"bufio"
 	"bytes"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type ChangedFile struct {
 	Path         string
 	ChangedLines []int
+	// WholeFile marks a change without line information, such as a file
+	// reported by a watcher or deleted in a diff: every line counts as
+	// changed.
+	WholeFile bool
+}
+
+// Touches reports whether the change touches any of the lines start..end.
+func (c ChangedFile) Touches(start, end int) bool {
+	if c.WholeFile {
+		return true
+	}
+	for _, line := range c.ChangedLines {
+		if line >= start && line <= end {
+			return true
+		}
+	}
+	return false
 }
 
 // GetChangedFiles runs git diff and returns a list of changed files with line numbers.
@@ -26,6 +48,123 @@ func GetChangedFiles(baseRef string) ([]ChangedFile, error) {
 	return parseDiff(output)
 }
 
+// GetChangedFilesInRange returns the files changed on head since it forked
+// from base (git diff base...head), as a pull request shows them.
+func GetChangedFilesInRange(base, head string) ([]ChangedFile, error) {
+	return diffRange(base, "...", head)
+}
+
+// GetChangedFilesForRange returns the files changed by a revision range:
+// A..B compares the two commits, A...B compares B with its merge base with A.
+// An omitted side is HEAD.
+func GetChangedFilesForRange(spec string) ([]ChangedFile, error) {
+	base, head, ok := strings.Cut(spec, "..")
+	if !ok {
+		return nil, fmt.Errorf("invalid range %q: want A..B or A...B", spec)
+	}
+	op := ".."
+	if rest, found := strings.CutPrefix(head, "."); found {
+		op, head = "...", rest
+	}
+	return diffRange(base, op, head)
+}
+
+// diffRange diffs the range base<op>head, op being ".." or "...". Revisions
+// starting with "-" are rejected so git cannot read them as options.
+func diffRange(base, op, head string) ([]ChangedFile, error) {
+	if base == "" {
+		base = "HEAD"
+	}
+	if head == "" {
+		head = "HEAD"
+	}
+	spec := base + op + head
+	if strings.HasPrefix(base, "-") || strings.HasPrefix(head, "-") {
+		return nil, fmt.Errorf("invalid range %q: revisions must not start with '-'", spec)
+	}
+	cmd := exec.Command("git", "diff", "-U0", spec)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff %s failed: %w", spec, err)
+	}
+
+	return parseDiff(output)
+}
+
+// RangeHead returns the head revision of a range spec: B of A..B or A...B,
+// HEAD when B is omitted.
+func RangeHead(spec string) string {
+	idx := strings.LastIndex(spec, "..")
+	if idx < 0 {
+		return "HEAD"
+	}
+	if head := spec[idx+2:]; head != "" {
+		return head
+	}
+	return "HEAD"
+}
+
+// ResolveCommit returns the full commit SHA for ref.
+func ResolveCommit(ref string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("unknown commit %q: %w", ref, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ExportTree writes the tracked files of ref into dest without touching the
+// working tree or the index.
+func ExportTree(ref, dest string) error {
+	cmd := exec.Command("git", "archive", "--format=tar", ref)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("git archive %s failed: %w: %s", ref, err, strings.TrimSpace(stderr.String()))
+	}
+	return extractTar(bytes.NewReader(output), dest)
+}
+
+func extractTar(r io.Reader, dest string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read archive: %w", err)
+		}
+		target := filepath.Join(dest, filepath.FromSlash(hdr.Name))
+		if rel, err := filepath.Rel(dest, target); err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 func parseDiff(output []byte) ([]ChangedFile, error) {
 	scanner := bufio.NewScanner(bytes.NewReader(output))
 	var changes []ChangedFile
@@ -60,6 +199,11 @@ func parseDiff(output []byte) ([]ChangedFile, error) {
 			continue
 		}
 
+		if strings.HasPrefix(line, "deleted file mode") {
+			currentFile.WholeFile = true
+			continue
+		}
+
 		if strings.HasPrefix(line, "@@") {
 			matches := chunkHeader.FindStringSubmatch(line)
 			if len(matches) > 1 {
@@ -69,11 +213,11 @@ func parseDiff(output []byte) ([]ChangedFile, error) {
 					count, _ = strconv.Atoi(matches[2])
 				}
 
-				// If count is 0, it's a deletion, we might skip or mark adjacent lines.
-				// For now, let's treat it as "something changed around here".
-				// But strictly, if count is 0, no lines exist in the new file at this pos.
-				// However, usually we care about added/modified lines.
-
+				// A count of 0 removes lines after startLine; mark that line so
+				// the symbol the lines were removed from counts as changed.
+				if count == 0 {
+					currentFile.ChangedLines = append(currentFile.ChangedLines, max(startLine, 1))
+				}
 				for i := 0; i < count; i++ {
 					currentFile.ChangedLines = append(currentFile.ChangedLines, startLine+i)
 				}
@@ -87,3 +231,59 @@ func parseDiff(output []byte) ([]ChangedFile, error) {
 
 	return changes, nil
 }
+
+// Commit is one commit of a log with the files it touched.
+type Commit struct {
+	SHA     string
+	Author  string
+	Date    time.Time
+	Subject string
+	Files   []string
+}
+
+// GetCommits lists the non-merge commits reachable from head but not from
+// base, newest first, at most max of them (0 for no limit). An empty base
+// lists head's history.
+func GetCommits(base, head string, max int) ([]Commit, error) {
+	if head == "" {
+		head = "HEAD"
+	}
+	spec := head
+	if base != "" {
+		spec = base + ".." + head
+	}
+	args := []string{"log", "--no-merges", "--name-only", "--format=" + commitFormat}
+	if max > 0 {
+		args = append(args, "-n", strconv.Itoa(max))
+	}
+	cmd := exec.Command("git", append(args, spec, "--")...)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git log %s failed: %w", spec, err)
+	}
+	return parseLog(output), nil
+}
+
+// commitFormat starts every commit with a record separator and splits its
+// header fields with unit separators; --name-only lists the files after it.
+const commitFormat = "%x1e%H%x1f%an%x1f%aI%x1f%s"
+
+func parseLog(output []byte) []Commit {
+	var commits []Commit
+	for _, record := range strings.Split(string(output), "\x1e") {
+		header, files, _ := strings.Cut(record, "\n")
+		fields := strings.Split(header, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		c := Commit{SHA: fields[0], Author: fields[1], Subject: fields[3]}
+		c.Date, _ = time.Parse(time.RFC3339, fields[2])
+		for _, f := range strings.Split(files, "\n") {
+			if f = strings.TrimSpace(f); f != "" {
+				c.Files = append(c.Files, f)
+			}
+		}
+		commits = append(commits, c)
+	}
+	return commits
+}
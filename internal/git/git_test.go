@@ -0,0 +1,60 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeHead(t *testing.T) {
+	for spec, want := range map[string]string{
+		"v1.0..v1.1":         "v1.1",
+		"origin/main...HEAD": "HEAD",
+		"abc123..":           "HEAD",
+		"abc123...":          "HEAD",
+		"main...feature/x":   "feature/x",
+	} {
+		assert.Equal(t, want, RangeHead(spec), spec)
+	}
+}
+
+func TestGetChangedFilesForRange_RejectsSingleRevision(t *testing.T) {
+	_, err := GetChangedFilesForRange("HEAD~3")
+	assert.ErrorContains(t, err, "want A..B")
+}
+
+func TestGetChangedFilesForRange_RejectsOptions(t *testing.T) {
+	for _, spec := range []string{"--output=/tmp/x..HEAD", "HEAD..--stat", "-p...main"} {
+		_, err := GetChangedFilesForRange(spec)
+		assert.ErrorContains(t, err, "must not start with '-'", spec)
+	}
+	_, err := GetChangedFilesInRange("--output=/tmp/x", "HEAD")
+	assert.ErrorContains(t, err, "must not start with '-'")
+}
+
+func TestParseDiff_DeletionsAndDeletedFiles(t *testing.T) {
+	out := "diff --git a/a.go b/a.go\nindex 1..2 100644\n--- a/a.go\n+++ b/a.go\n@@ -10,2 +9,0 @@ func A() {\n" +
+		"diff --git a/b.go b/b.go\ndeleted file mode 100644\nindex 3..0\n--- a/b.go\n+++ /dev/null\n@@ -1,3 +0,0 @@\n"
+	changes, err := parseDiff([]byte(out))
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, []int{9}, changes[0].ChangedLines)
+	assert.False(t, changes[0].WholeFile)
+	assert.True(t, changes[1].WholeFile)
+	assert.True(t, changes[1].Touches(40, 50))
+}
+
+func TestParseLog(t *testing.T) {
+	out := "\x1eabc123\x1fAda\x1f2026-10-01T12:00:00+02:00\x1fAdd retries\n\ninternal/fetch/get.go\ninternal/fetch/get_test.go\n" +
+		"\x1edef456\x1fBob\x1f2026-09-30T08:00:00Z\x1fDocs only\n\nREADME.md\n"
+	commits := parseLog([]byte(out))
+	if assert.Len(t, commits, 2) {
+		assert.Equal(t, "abc123", commits[0].SHA)
+		assert.Equal(t, "Ada", commits[0].Author)
+		assert.Equal(t, "Add retries", commits[0].Subject)
+		assert.Equal(t, []string{"internal/fetch/get.go", "internal/fetch/get_test.go"}, commits[0].Files)
+		assert.Equal(t, 2026, commits[0].Date.Year())
+		assert.Equal(t, []string{"README.md"}, commits[1].Files)
+	}
+}
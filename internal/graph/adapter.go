@@ -1,6 +1,10 @@
 package graph
 
-import "docod/internal/extractor"
+import (
+	"strings"
+
+	"docod/internal/extractor"
+)
 
 // FromCodeUnit converts extractor output into graph-domain Symbol.
 func FromCodeUnit(unit *extractor.CodeUnit) *Symbol {
@@ -24,8 +28,11 @@ func FromCodeUnit(unit *extractor.CodeUnit) *Symbol {
 		Metadata: SymbolMetadata{
 			Signature: extractSignature(unit),
 			Receiver:  extractReceiver(unit),
+			Fields:    extractFields(unit),
 		},
 	}
+	s.Metadata.Params, s.Metadata.Returns = extractParams(unit)
+	s.Metadata.StructFields = extractStructFields(unit)
 
 	if len(unit.Relations) > 0 {
 		s.Relations = make([]Relation, 0, len(unit.Relations))
@@ -77,6 +84,63 @@ func extractReceiver(unit *extractor.CodeUnit) string {
 	return ""
 }
 
+func extractFields(unit *extractor.CodeUnit) []string {
+	if unit == nil || unit.Details == nil {
+		return nil
+	}
+	var out []string
+	switch d := unit.Details.(type) {
+	case extractor.GoTypeDetails:
+		for _, f := range d.Fields {
+			out = append(out, strings.TrimSpace(f.Name+" "+f.Type))
+		}
+	case extractor.GoInterfaceDetails:
+		for _, m := range d.Methods {
+			out = append(out, m.Signature)
+		}
+	}
+	return out
+}
+
+func extractParams(unit *extractor.CodeUnit) ([]Param, []Param) {
+	if unit == nil || unit.Details == nil {
+		return nil, nil
+	}
+	var d *extractor.GoFunctionDetails
+	switch v := unit.Details.(type) {
+	case extractor.GoFunctionDetails:
+		d = &v
+	case *extractor.GoFunctionDetails:
+		d = v
+	}
+	if d == nil {
+		return nil, nil
+	}
+	var params, returns []Param
+	for _, p := range d.Parameters {
+		params = append(params, Param{Name: p.Name, Type: p.Type})
+	}
+	for _, r := range d.Returns {
+		returns = append(returns, Param{Name: r.Name, Type: r.Type})
+	}
+	return params, returns
+}
+
+func extractStructFields(unit *extractor.CodeUnit) []Field {
+	if unit == nil || unit.Details == nil {
+		return nil
+	}
+	d, ok := unit.Details.(extractor.GoTypeDetails)
+	if !ok {
+		return nil
+	}
+	var out []Field
+	for _, f := range d.Fields {
+		out = append(out, Field{Name: f.Name, Type: f.Type, Tag: f.Tag})
+	}
+	return out
+}
+
 // AddUnit is a compatibility adapter to keep existing callers stable.
 func (g *Graph) AddUnit(unit *extractor.CodeUnit) {
 	g.AddSymbol(FromCodeUnit(unit))
@@ -0,0 +1,142 @@
+package graph
+
+import (
+	"sort"
+	"strings"
+)
+
+// ChangeClass rates how a symbol change affects users of the package API.
+type ChangeClass string
+
+const (
+	ChangeBreaking ChangeClass = "breaking"
+	ChangeAdditive ChangeClass = "additive"
+	ChangeInternal ChangeClass = "internal"
+)
+
+// ClassifiedChange is one symbol change between two graph states.
+type ClassifiedChange struct {
+	NodeRef
+	OldID  string      `json:"old_id,omitempty"`
+	Class  ChangeClass `json:"class"`
+	Reason string      `json:"reason"`
+	// Detail describes the change for humans, e.g. old and new signatures.
+	Detail string `json:"detail,omitempty"`
+}
+
+// ClassifyChanges diffs two graph states and classifies each symbol change.
+// Removed exported symbols, changed exported signatures and removed or
+// retyped exported struct fields (or changed interface methods) are breaking;
+// new exported symbols and fields are additive; everything else is internal.
+// Callers usually pass only the symbols of the files that changed.
+func ClassifyChanges(before, after *Graph) []ClassifiedChange {
+	d := Diff(before, after)
+	out := make([]ClassifiedChange, 0, len(d.AddedNodes)+len(d.RemovedNodes)+len(d.ChangedNodes))
+
+	for _, n := range d.RemovedNodes {
+		c := ClassifiedChange{NodeRef: n, Class: ChangeInternal, Reason: "removed"}
+		if n.Exported {
+			c.Class = ChangeBreaking
+			c.Detail = "removed " + n.UnitType + " " + n.Name
+		}
+		out = append(out, c)
+	}
+	for _, n := range d.AddedNodes {
+		c := ClassifiedChange{NodeRef: n, Class: ChangeInternal, Reason: "added"}
+		if n.Exported {
+			c.Class = ChangeAdditive
+		}
+		out = append(out, c)
+	}
+	for _, n := range d.ChangedNodes {
+		c := ClassifiedChange{NodeRef: n.NodeRef, OldID: n.OldID, Class: ChangeInternal, Reason: "body_changed"}
+		switch {
+		case n.Change == "signature":
+			c.Reason = "signature_changed"
+			if n.Exported {
+				c.Class = ChangeBreaking
+				c.Detail = "signature changed from `" + n.OldSignature + "` to `" + n.Signature + "`"
+			}
+		case n.Exported && isTypeUnit(n.UnitType):
+			old, cur := before.Nodes[n.ID], after.Nodes[n.ID]
+			if old != nil && cur != nil && old.Unit != nil && cur.Unit != nil {
+				c.Class, c.Reason, c.Detail = classifyFields(n.UnitType, old.Unit.Metadata.Fields, cur.Unit.Metadata.Fields)
+			}
+		}
+		out = append(out, c)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Class != out[j].Class {
+			return classRank(out[i].Class) < classRank(out[j].Class)
+		}
+		return nodeRefLess(out[i].NodeRef, out[j].NodeRef)
+	})
+	return out
+}
+
+// classifyFields compares the members of an exported type. Graphs stored
+// before member tracking have no fields and compare as internal.
+func classifyFields(unitType string, before, after []string) (ChangeClass, string, string) {
+	if before == nil {
+		return ChangeInternal, "body_changed", ""
+	}
+	removed := missingMembers(before, after, unitType == "struct")
+	added := missingMembers(after, before, unitType == "struct")
+	switch {
+	case unitType == "interface" && len(removed)+len(added) > 0:
+		return ChangeBreaking, "methods_changed", memberDetail("method", removed, added)
+	case len(removed) > 0:
+		return ChangeBreaking, "fields_changed", memberDetail("field", removed, added)
+	case len(added) > 0:
+		return ChangeAdditive, "fields_added", memberDetail("field", nil, added)
+	}
+	return ChangeInternal, "body_changed", ""
+}
+
+// missingMembers returns members of a absent from b. For structs only
+// exported fields count.
+func missingMembers(a, b []string, exportedOnly bool) []string {
+	set := make(map[string]bool, len(b))
+	for _, m := range b {
+		set[m] = true
+	}
+	var out []string
+	for _, m := range a {
+		if set[m] || (exportedOnly && !isExportedName(m)) {
+			continue
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func memberDetail(kind string, removed, added []string) string {
+	parts := make([]string, 0, len(removed)+len(added))
+	for _, m := range removed {
+		parts = append(parts, "removed "+kind+" `"+m+"`")
+	}
+	for _, m := range added {
+		parts = append(parts, "added "+kind+" `"+m+"`")
+	}
+	return strings.Join(parts, "; ")
+}
+
+func classRank(c ChangeClass) int {
+	switch c {
+	case ChangeBreaking:
+		return 0
+	case ChangeAdditive:
+		return 1
+	}
+	return 2
+}
+
+// CountChangeClasses tallies classified changes by class.
+func CountChangeClasses(changes []ClassifiedChange) map[ChangeClass]int {
+	counts := make(map[ChangeClass]int)
+	for _, c := range changes {
+		counts[c.Class]++
+	}
+	return counts
+}
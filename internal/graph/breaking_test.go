@@ -0,0 +1,46 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyChanges(t *testing.T) {
+	before := NewGraph()
+	before.AddSymbol(&Symbol{ID: "f1", Name: "Run", Package: "p", Filepath: "p/a.go", UnitType: "function", Metadata: SymbolMetadata{Signature: "func Run()"}})
+	before.AddSymbol(&Symbol{ID: "old", Name: "Gone", Package: "p", Filepath: "p/a.go", UnitType: "function"})
+	before.AddSymbol(&Symbol{ID: "cfg", Name: "Config", Package: "p", Filepath: "p/a.go", UnitType: "struct", Content: "v1", Metadata: SymbolMetadata{Fields: []string{"Name string", "Port int"}}})
+	before.AddSymbol(&Symbol{ID: "opt", Name: "Options", Package: "p", Filepath: "p/a.go", UnitType: "struct", Content: "v1", Metadata: SymbolMetadata{Fields: []string{"Debug bool"}}})
+	before.AddSymbol(&Symbol{ID: "h", Name: "helper", Package: "p", Filepath: "p/a.go", UnitType: "function", Content: "v1"})
+
+	after := NewGraph()
+	after.AddSymbol(&Symbol{ID: "f2", Name: "Run", Package: "p", Filepath: "p/a.go", UnitType: "function", Metadata: SymbolMetadata{Signature: "func Run(ctx context.Context)"}})
+	after.AddSymbol(&Symbol{ID: "cfg", Name: "Config", Package: "p", Filepath: "p/a.go", UnitType: "struct", Content: "v2", Metadata: SymbolMetadata{Fields: []string{"Name string", "Port string"}}})
+	after.AddSymbol(&Symbol{ID: "opt", Name: "Options", Package: "p", Filepath: "p/a.go", UnitType: "struct", Content: "v2", Metadata: SymbolMetadata{Fields: []string{"Debug bool", "Verbose bool", "cache bool"}}})
+	after.AddSymbol(&Symbol{ID: "h", Name: "helper", Package: "p", Filepath: "p/a.go", UnitType: "function", Content: "v2"})
+	after.AddSymbol(&Symbol{ID: "new", Name: "Start", Package: "p", Filepath: "p/a.go", UnitType: "function"})
+
+	byName := make(map[string]ClassifiedChange)
+	for _, c := range ClassifyChanges(before, after) {
+		byName[c.Name] = c
+	}
+
+	assert.Equal(t, ChangeBreaking, byName["Gone"].Class)
+	assert.Equal(t, "removed", byName["Gone"].Reason)
+	assert.Equal(t, ChangeBreaking, byName["Run"].Class)
+	assert.Equal(t, "signature_changed", byName["Run"].Reason)
+	assert.Equal(t, "f1", byName["Run"].OldID)
+	assert.Equal(t, ChangeBreaking, byName["Config"].Class)
+	assert.Equal(t, "fields_changed", byName["Config"].Reason)
+	assert.Contains(t, byName["Config"].Detail, "removed field `Port int`")
+	assert.Equal(t, ChangeAdditive, byName["Options"].Class)
+	assert.Equal(t, "added field `Verbose bool`", byName["Options"].Detail)
+	assert.Equal(t, ChangeAdditive, byName["Start"].Class)
+	assert.Equal(t, ChangeInternal, byName["helper"].Class)
+
+	counts := CountChangeClasses(ClassifyChanges(before, after))
+	assert.Equal(t, 3, counts[ChangeBreaking])
+	assert.Equal(t, 2, counts[ChangeAdditive])
+	assert.Equal(t, 1, counts[ChangeInternal])
+}
@@ -0,0 +1,99 @@
+package graph
+
+import "sort"
+
+// PageRank scores nodes by how much of the graph depends on them. Rank flows
+// along edges (From -> To) weighted by edge confidence, so heavily used types
+// and functions rank highest. Scores are scaled so the top node is 1.0.
+func (g *Graph) PageRank(damping float64, iterations int) map[string]float64 {
+	out := make(map[string]float64)
+	if g == nil || len(g.Nodes) == 0 {
+		return out
+	}
+	if damping <= 0 || damping >= 1 {
+		damping = 0.85
+	}
+	if iterations <= 0 {
+		iterations = 30
+	}
+
+	ids := make([]string, 0, len(g.Nodes))
+	for id, n := range g.Nodes {
+		// External packages would absorb rank from module code, and package
+		// nodes from their own symbols.
+		if n != nil && n.Unit != nil && (n.Unit.UnitType == UnitTypeExternal || n.Unit.UnitType == UnitTypePackage) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	pos := make(map[string]int, len(ids))
+	for i, id := range ids {
+		pos[id] = i
+	}
+
+	type arc struct {
+		to     int
+		weight float64
+	}
+	adj := make([][]arc, len(ids))
+	outWeight := make([]float64, len(ids))
+	for _, e := range g.Edges {
+		from, okFrom := pos[e.From]
+		to, okTo := pos[e.To]
+		if !okFrom || !okTo || from == to || e.Kind == RelationTests {
+			continue
+		}
+		w := e.Confidence
+		if w <= 0 {
+			w = 0.5
+		}
+		if w > 1 {
+			w = 1
+		}
+		adj[from] = append(adj[from], arc{to: to, weight: w})
+		outWeight[from] += w
+	}
+
+	n := float64(len(ids))
+	rank := make([]float64, len(ids))
+	for i := range rank {
+		rank[i] = 1 / n
+	}
+	next := make([]float64, len(ids))
+	for iter := 0; iter < iterations; iter++ {
+		dangling := 0.0
+		for i := range rank {
+			if outWeight[i] == 0 {
+				dangling += rank[i]
+			}
+		}
+		base := (1-damping)/n + damping*dangling/n
+		for i := range next {
+			next[i] = base
+		}
+		for i, arcs := range adj {
+			if outWeight[i] == 0 {
+				continue
+			}
+			share := damping * rank[i] / outWeight[i]
+			for _, a := range arcs {
+				next[a.to] += share * a.weight
+			}
+		}
+		rank, next = next, rank
+	}
+
+	maxRank := 0.0
+	for _, r := range rank {
+		if r > maxRank {
+			maxRank = r
+		}
+	}
+	for i, id := range ids {
+		if maxRank > 0 {
+			out[id] = rank[i] / maxRank
+		}
+	}
+	return out
+}
@@ -0,0 +1,174 @@
+package graph
+
+import "sort"
+
+// Community is a group of densely connected nodes (a logical component).
+type Community struct {
+	ID      int      `json:"id"`
+	Members []string `json:"members"`
+}
+
+// DetectCommunities clusters the graph with the Louvain modularity method,
+// treating edges as undirected and weighting them by confidence. Communities
+// are returned largest first; IDs follow that order.
+func (g *Graph) DetectCommunities() []Community {
+	if g == nil || len(g.Nodes) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(g.Nodes))
+	for id, n := range g.Nodes {
+		// External packages would pull unrelated callers together, and
+		// package nodes would merge every package into one community.
+		if n != nil && n.Unit != nil && (n.Unit.UnitType == UnitTypeExternal || n.Unit.UnitType == UnitTypePackage) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	pos := make(map[string]int, len(ids))
+	for i, id := range ids {
+		pos[id] = i
+	}
+
+	adj := make([]map[int]float64, len(ids))
+	for i := range adj {
+		adj[i] = make(map[int]float64)
+	}
+	for _, e := range g.Edges {
+		from, okFrom := pos[e.From]
+		to, okTo := pos[e.To]
+		if !okFrom || !okTo || from == to || e.Kind == RelationTests {
+			continue
+		}
+		w := e.Confidence
+		if w <= 0 {
+			w = 0.5
+		}
+		adj[from][to] += w
+		adj[to][from] += w
+	}
+
+	// assign maps original node index -> current community of its super node.
+	assign := make([]int, len(ids))
+	for i := range assign {
+		assign[i] = i
+	}
+	for level := 0; level < 10; level++ {
+		comm, moved := louvainLocalMoves(adj)
+		if !moved {
+			break
+		}
+		// Relabel densely and fold original assignments through this level.
+		relabel := make(map[int]int)
+		for _, c := range comm {
+			if _, ok := relabel[c]; !ok {
+				relabel[c] = len(relabel)
+			}
+		}
+		for i := range assign {
+			assign[i] = relabel[comm[assign[i]]]
+		}
+		next := make([]map[int]float64, len(relabel))
+		for i := range next {
+			next[i] = make(map[int]float64)
+		}
+		for i, nbrs := range adj {
+			ci := relabel[comm[i]]
+			for j, w := range nbrs {
+				cj := relabel[comm[j]]
+				switch {
+				case ci != cj:
+					next[ci][cj] += w
+				case i == j:
+					next[ci][ci] += w
+				case i < j:
+					// Keep intra-community weight as a self-loop.
+					next[ci][ci] += 2 * w
+				}
+			}
+		}
+		adj = next
+	}
+
+	groups := make(map[int][]string)
+	for i, c := range assign {
+		groups[c] = append(groups[c], ids[i])
+	}
+	out := make([]Community, 0, len(groups))
+	for _, members := range groups {
+		sort.Strings(members)
+		out = append(out, Community{Members: members})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i].Members) != len(out[j].Members) {
+			return len(out[i].Members) > len(out[j].Members)
+		}
+		return out[i].Members[0] < out[j].Members[0]
+	})
+	for i := range out {
+		out[i].ID = i
+	}
+	return out
+}
+
+// louvainLocalMoves runs the first Louvain phase and returns the community of
+// each node, plus whether any node changed community.
+func louvainLocalMoves(adj []map[int]float64) ([]int, bool) {
+	n := len(adj)
+	comm := make([]int, n)
+	degree := make([]float64, n)
+	tot := make([]float64, n)
+	m2 := 0.0
+	for i, nbrs := range adj {
+		comm[i] = i
+		for _, w := range nbrs {
+			degree[i] += w
+		}
+		tot[i] = degree[i]
+		m2 += degree[i]
+	}
+	if m2 == 0 {
+		return comm, false
+	}
+
+	movedAny := false
+	for pass := 0; pass < 50; pass++ {
+		moved := false
+		for i := 0; i < n; i++ {
+			current := comm[i]
+			links := make(map[int]float64)
+			for j, w := range adj[i] {
+				if j != i {
+					links[comm[j]] += w
+				}
+			}
+			tot[current] -= degree[i]
+
+			best := current
+			bestGain := links[current] - tot[current]*degree[i]/m2
+			candidates := make([]int, 0, len(links))
+			for c := range links {
+				candidates = append(candidates, c)
+			}
+			sort.Ints(candidates)
+			for _, c := range candidates {
+				gain := links[c] - tot[c]*degree[i]/m2
+				if gain > bestGain+1e-12 {
+					best = c
+					bestGain = gain
+				}
+			}
+			tot[best] += degree[i]
+			if best != current {
+				comm[i] = best
+				moved = true
+				movedAny = true
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+	return comm, movedAny
+}
@@ -0,0 +1,314 @@
+package graph
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// NodeRef identifies a symbol in a graph diff.
+type NodeRef struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Package   string `json:"package"`
+	UnitType  string `json:"unit_type"`
+	Filepath  string `json:"filepath"`
+	Signature string `json:"signature,omitempty"`
+	Exported  bool   `json:"exported"`
+}
+
+// NodeChange describes a symbol present in both graphs whose signature or
+// body changed. A signature change also changes the stable ID.
+type NodeChange struct {
+	NodeRef
+	OldID        string `json:"old_id,omitempty"`
+	Change       string `json:"change"` // "signature" or "body"
+	OldSignature string `json:"old_signature,omitempty"`
+}
+
+// EdgeRef identifies a dependency edge in a graph diff.
+type EdgeRef struct {
+	From     string       `json:"from"`
+	To       string       `json:"to"`
+	Kind     RelationKind `json:"kind"`
+	FromName string       `json:"from_name"`
+	ToName   string       `json:"to_name"`
+	// CrossPackage marks edges between different packages (new/dropped dependencies).
+	CrossPackage bool `json:"cross_package,omitempty"`
+}
+
+// DiffSummary aggregates a graph diff into API-level counts.
+type DiffSummary struct {
+	NodesAdded          int `json:"nodes_added"`
+	NodesRemoved        int `json:"nodes_removed"`
+	NodesChanged        int `json:"nodes_changed"`
+	EdgesAdded          int `json:"edges_added"`
+	EdgesRemoved        int `json:"edges_removed"`
+	APIAdded            int `json:"api_added"`
+	APIRemoved          int `json:"api_removed"`
+	APIChanged          int `json:"api_changed"`
+	TypesRemoved        int `json:"types_removed"`
+	DependenciesAdded   int `json:"dependencies_added"`
+	DependenciesRemoved int `json:"dependencies_removed"`
+}
+
+// GraphDiff is the structural difference between two graphs.
+type GraphDiff struct {
+	Summary      DiffSummary  `json:"summary"`
+	AddedNodes   []NodeRef    `json:"added_nodes"`
+	RemovedNodes []NodeRef    `json:"removed_nodes"`
+	ChangedNodes []NodeChange `json:"changed_nodes"`
+	AddedEdges   []EdgeRef    `json:"added_edges"`
+	RemovedEdges []EdgeRef    `json:"removed_edges"`
+}
+
+// Diff compares two graphs. Symbols are matched by stable ID first and then
+// by package, directory, kind, receiver and name so that signature changes are
+// reported as changes rather than as a removal plus an addition. Package
+// nodes and their edges are derived from the symbols and are left out.
+func Diff(before, after *Graph) *GraphDiff {
+	if before == nil {
+		before = NewGraph()
+	}
+	if after == nil {
+		after = NewGraph()
+	}
+	d := &GraphDiff{
+		AddedNodes:   []NodeRef{},
+		RemovedNodes: []NodeRef{},
+		ChangedNodes: []NodeChange{},
+		AddedEdges:   []EdgeRef{},
+		RemovedEdges: []EdgeRef{},
+	}
+
+	// renamed maps old IDs to new IDs for symbols whose ID changed.
+	renamed := make(map[string]string)
+	removedByKey := make(map[string][]string)
+	for id, n := range before.Nodes {
+		if n == nil || n.Unit == nil || isPackageNode(n) {
+			continue
+		}
+		if other, ok := after.Nodes[id]; ok && other != nil && other.Unit != nil {
+			if n.Unit.ContentHash != other.Unit.ContentHash || n.Unit.Content != other.Unit.Content {
+				d.ChangedNodes = append(d.ChangedNodes, NodeChange{NodeRef: nodeRef(other.Unit), Change: "body"})
+			}
+			continue
+		}
+		key := symbolKey(n.Unit)
+		removedByKey[key] = append(removedByKey[key], id)
+	}
+	for key := range removedByKey {
+		sort.Strings(removedByKey[key])
+	}
+
+	addedIDs := make([]string, 0)
+	for id, n := range after.Nodes {
+		if n == nil || n.Unit == nil || isPackageNode(n) {
+			continue
+		}
+		if _, ok := before.Nodes[id]; !ok {
+			addedIDs = append(addedIDs, id)
+		}
+	}
+	sort.Strings(addedIDs)
+	for _, id := range addedIDs {
+		unit := after.Nodes[id].Unit
+		key := symbolKey(unit)
+		if olds := removedByKey[key]; len(olds) > 0 {
+			oldID := olds[0]
+			removedByKey[key] = olds[1:]
+			renamed[oldID] = id
+			d.ChangedNodes = append(d.ChangedNodes, NodeChange{
+				NodeRef:      nodeRef(unit),
+				OldID:        oldID,
+				Change:       "signature",
+				OldSignature: before.Nodes[oldID].Unit.Metadata.Signature,
+			})
+			continue
+		}
+		d.AddedNodes = append(d.AddedNodes, nodeRef(unit))
+	}
+	for _, olds := range removedByKey {
+		for _, id := range olds {
+			d.RemovedNodes = append(d.RemovedNodes, nodeRef(before.Nodes[id].Unit))
+		}
+	}
+
+	beforeEdges := edgeSet(before, renamed)
+	afterEdges := edgeSet(after, nil)
+	for key, e := range afterEdges {
+		if _, ok := beforeEdges[key]; !ok {
+			d.AddedEdges = append(d.AddedEdges, edgeRef(after, e))
+		}
+	}
+	for key, e := range beforeEdges {
+		if _, ok := afterEdges[key]; !ok {
+			d.RemovedEdges = append(d.RemovedEdges, edgeRef(before, e))
+		}
+	}
+
+	sortNodeRefs(d.AddedNodes)
+	sortNodeRefs(d.RemovedNodes)
+	sort.Slice(d.ChangedNodes, func(i, j int) bool {
+		return nodeRefLess(d.ChangedNodes[i].NodeRef, d.ChangedNodes[j].NodeRef)
+	})
+	sortEdgeRefs(d.AddedEdges)
+	sortEdgeRefs(d.RemovedEdges)
+	d.summarize()
+	return d
+}
+
+// Empty reports whether the diff contains no changes.
+func (d *GraphDiff) Empty() bool {
+	return d == nil || len(d.AddedNodes)+len(d.RemovedNodes)+len(d.ChangedNodes)+len(d.AddedEdges)+len(d.RemovedEdges) == 0
+}
+
+func (d *GraphDiff) summarize() {
+	s := DiffSummary{
+		NodesAdded:   len(d.AddedNodes),
+		NodesRemoved: len(d.RemovedNodes),
+		NodesChanged: len(d.ChangedNodes),
+		EdgesAdded:   len(d.AddedEdges),
+		EdgesRemoved: len(d.RemovedEdges),
+	}
+	for _, n := range d.AddedNodes {
+		if n.Exported {
+			s.APIAdded++
+		}
+	}
+	for _, n := range d.RemovedNodes {
+		if n.Exported {
+			s.APIRemoved++
+		}
+		if isTypeUnit(n.UnitType) {
+			s.TypesRemoved++
+		}
+	}
+	for _, n := range d.ChangedNodes {
+		if n.Exported && n.Change == "signature" {
+			s.APIChanged++
+		}
+	}
+	for _, e := range d.AddedEdges {
+		if e.CrossPackage {
+			s.DependenciesAdded++
+		}
+	}
+	for _, e := range d.RemovedEdges {
+		if e.CrossPackage {
+			s.DependenciesRemoved++
+		}
+	}
+	d.Summary = s
+}
+
+func symbolKey(u *Symbol) string {
+	return strings.Join([]string{
+		u.Package,
+		filepath.ToSlash(filepath.Dir(u.Filepath)),
+		u.UnitType,
+		u.Metadata.Receiver,
+		u.Name,
+	}, "|")
+}
+
+func nodeRef(u *Symbol) NodeRef {
+	return NodeRef{
+		ID:        u.ID,
+		Name:      u.Name,
+		Package:   u.Package,
+		UnitType:  u.UnitType,
+		Filepath:  u.Filepath,
+		Signature: u.Metadata.Signature,
+		Exported:  isExportedName(u.Name),
+	}
+}
+
+// edgeSet keys edges by endpoints and kind, translating endpoints through
+// remap so that edges of re-identified symbols compare equal.
+func edgeSet(g *Graph, remap map[string]string) map[string]Edge {
+	out := make(map[string]Edge, len(g.Edges))
+	for _, e := range g.Edges {
+		if IsPackageRelation(e.Kind) {
+			continue
+		}
+		from, to := e.From, e.To
+		if id, ok := remap[from]; ok {
+			from = id
+		}
+		if id, ok := remap[to]; ok {
+			to = id
+		}
+		out[from+"|"+to+"|"+string(e.Kind)] = e
+	}
+	return out
+}
+
+func edgeRef(g *Graph, e Edge) EdgeRef {
+	ref := EdgeRef{From: e.From, To: e.To, Kind: e.Kind, FromName: e.From, ToName: e.To}
+	from, okFrom := g.Nodes[e.From]
+	to, okTo := g.Nodes[e.To]
+	if okFrom && from != nil && from.Unit != nil {
+		ref.FromName = qualifiedName(from.Unit)
+	}
+	if okTo && to != nil && to.Unit != nil {
+		ref.ToName = qualifiedName(to.Unit)
+	}
+	if okFrom && okTo && from != nil && to != nil && from.Unit != nil && to.Unit != nil {
+		ref.CrossPackage = symbolPackageKey(from.Unit) != symbolPackageKey(to.Unit)
+	}
+	return ref
+}
+
+func qualifiedName(u *Symbol) string {
+	if u.Metadata.Receiver != "" {
+		return u.Package + "." + strings.TrimPrefix(u.Metadata.Receiver, "*") + "." + u.Name
+	}
+	return u.Package + "." + u.Name
+}
+
+func symbolPackageKey(u *Symbol) string {
+	return filepath.ToSlash(filepath.Dir(u.Filepath)) + "|" + u.Package
+}
+
+func isTypeUnit(unitType string) bool {
+	switch unitType {
+	case "struct", "interface", "type":
+		return true
+	}
+	return false
+}
+
+func isExportedName(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+func nodeRefLess(a, b NodeRef) bool {
+	if a.Package != b.Package {
+		return a.Package < b.Package
+	}
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.ID < b.ID
+}
+
+func sortNodeRefs(refs []NodeRef) {
+	sort.Slice(refs, func(i, j int) bool { return nodeRefLess(refs[i], refs[j]) })
+}
+
+func sortEdgeRefs(refs []EdgeRef) {
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].FromName != refs[j].FromName {
+			return refs[i].FromName < refs[j].FromName
+		}
+		if refs[i].ToName != refs[j].ToName {
+			return refs[i].ToName < refs[j].ToName
+		}
+		return refs[i].Kind < refs[j].Kind
+	})
+}
@@ -0,0 +1,80 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	before := NewGraph()
+	before.AddSymbol(&Symbol{ID: "a1", Name: "Open", Package: "store", UnitType: "function", Filepath: "store/open.go", Metadata: SymbolMetadata{Signature: "func Open(path string)"}, Content: "v1"})
+	before.AddSymbol(&Symbol{ID: "b", Name: "Config", Package: "store", UnitType: "struct", Filepath: "store/config.go", Content: "v1"})
+	before.AddSymbol(&Symbol{ID: "c", Name: "helper", Package: "store", UnitType: "function", Filepath: "store/open.go", Content: "v1"})
+	before.AddSymbol(&Symbol{ID: "d", Name: "Run", Package: "cli", UnitType: "function", Filepath: "cli/run.go", Content: "v1"})
+	before.Edges = []Edge{
+		{From: "a1", To: "b", Kind: RelationUsesType},
+		{From: "d", To: "a1", Kind: RelationCalls},
+	}
+
+	after := NewGraph()
+	after.AddSymbol(&Symbol{ID: "a2", Name: "Open", Package: "store", UnitType: "function", Filepath: "store/open.go", Metadata: SymbolMetadata{Signature: "func Open(path string, ro bool)"}, Content: "v2"})
+	after.AddSymbol(&Symbol{ID: "c", Name: "helper", Package: "store", UnitType: "function", Filepath: "store/open.go", Content: "v2"})
+	after.AddSymbol(&Symbol{ID: "d", Name: "Run", Package: "cli", UnitType: "function", Filepath: "cli/run.go", Content: "v1"})
+	after.AddSymbol(&Symbol{ID: "e", Name: "Flags", Package: "cli", UnitType: "struct", Filepath: "cli/flags.go", Content: "v1"})
+	after.Edges = []Edge{
+		{From: "d", To: "a2", Kind: RelationCalls},
+		{From: "a2", To: "c", Kind: RelationCalls},
+		{From: "d", To: "e", Kind: RelationUsesType},
+	}
+
+	d := Diff(before, after)
+
+	assert.Len(t, d.AddedNodes, 1)
+	assert.Equal(t, "Flags", d.AddedNodes[0].Name)
+	assert.Len(t, d.RemovedNodes, 1)
+	assert.Equal(t, "Config", d.RemovedNodes[0].Name)
+
+	assert.Len(t, d.ChangedNodes, 2)
+	changes := map[string]NodeChange{}
+	for _, c := range d.ChangedNodes {
+		changes[c.Name] = c
+	}
+	assert.Equal(t, "signature", changes["Open"].Change)
+	assert.Equal(t, "a1", changes["Open"].OldID)
+	assert.Equal(t, "func Open(path string)", changes["Open"].OldSignature)
+	assert.Equal(t, "body", changes["helper"].Change)
+
+	// d -> Open survives the ID change; only real edge changes are reported.
+	assert.Len(t, d.AddedEdges, 2)
+	assert.Len(t, d.RemovedEdges, 1)
+	assert.Equal(t, "store.Open", d.RemovedEdges[0].FromName)
+	assert.Equal(t, "store.Config", d.RemovedEdges[0].ToName)
+
+	assert.Equal(t, 1, d.Summary.APIAdded)
+	assert.Equal(t, 1, d.Summary.APIRemoved)
+	assert.Equal(t, 1, d.Summary.APIChanged)
+	assert.Equal(t, 1, d.Summary.TypesRemoved)
+	assert.Equal(t, 0, d.Summary.DependenciesAdded)
+	assert.False(t, d.Empty())
+	assert.True(t, Diff(after, after).Empty())
+}
+
+func TestMatchSymbolIDs(t *testing.T) {
+	before := NewGraph()
+	before.AddSymbol(&Symbol{ID: "/old/checkout/store/db.go:Open:12", Name: "Open", Package: "store", UnitType: "function", Filepath: "/old/checkout/store/db.go"})
+	before.AddSymbol(&Symbol{ID: "/old/checkout/a/main.go:main:3", Name: "main", Package: "main", UnitType: "function", Filepath: "/old/checkout/a/main.go"})
+	before.AddSymbol(&Symbol{ID: "same", Name: "Keep", Package: "store", UnitType: "function", Filepath: "store/keep.go"})
+
+	after := NewGraph()
+	after.AddSymbol(&Symbol{ID: "go/store:function:Open:aa", Name: "Open", Package: "store", UnitType: "function", Filepath: "store/db.go"})
+	after.AddSymbol(&Symbol{ID: "go/main:function:main:bb", Name: "main", Package: "main", UnitType: "function", Filepath: "a/main.go"})
+	after.AddSymbol(&Symbol{ID: "go/main:function:main:cc", Name: "main", Package: "main", UnitType: "function", Filepath: "b/main.go"})
+	after.AddSymbol(&Symbol{ID: "same", Name: "Keep", Package: "store", UnitType: "function", Filepath: "store/keep.go"})
+
+	mapping := MatchSymbolIDs(before, after)
+	assert.Equal(t, map[string]string{
+		"/old/checkout/store/db.go:Open:12": "go/store:function:Open:aa",
+		"/old/checkout/a/main.go:main:3":    "go/main:function:main:bb",
+	}, mapping)
+}
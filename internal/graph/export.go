@@ -0,0 +1,156 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cypherBatchSize bounds the rows per UNWIND statement.
+const cypherBatchSize = 500
+
+// WriteCypher writes g as an idempotent Cypher script for Neo4j. Every symbol
+// becomes a :Symbol node keyed by id with a second label for its unit type;
+// edges become relationships named after their kind (CALLS, USES_TYPE, ...).
+func WriteCypher(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "// Generated by docod. Safe to re-run: nodes and relationships are merged.")
+	fmt.Fprintln(bw, "CREATE CONSTRAINT docod_symbol_id IF NOT EXISTS FOR (s:Symbol) REQUIRE s.id IS UNIQUE;")
+
+	byLabel := make(map[string][]*Symbol)
+	for _, n := range g.Nodes {
+		if n == nil || n.Unit == nil {
+			continue
+		}
+		label := cypherLabel(n.Unit.UnitType)
+		byLabel[label] = append(byLabel[label], n.Unit)
+	}
+	for _, label := range sortedKeys(byLabel) {
+		units := byLabel[label]
+		sort.Slice(units, func(i, j int) bool { return units[i].ID < units[j].ID })
+		for start := 0; start < len(units); start += cypherBatchSize {
+			end := min(start+cypherBatchSize, len(units))
+			rows := make([]string, 0, end-start)
+			for _, u := range units[start:end] {
+				rows = append(rows, cypherMap([][2]string{
+					{"id", cypherString(u.ID)},
+					{"name", cypherString(u.Name)},
+					{"package", cypherString(u.Package)},
+					{"unit_type", cypherString(u.UnitType)},
+					{"filepath", cypherString(u.Filepath)},
+					{"start_line", strconv.Itoa(u.StartLine)},
+					{"end_line", strconv.Itoa(u.EndLine)},
+					{"signature", cypherString(u.Metadata.Signature)},
+					{"receiver", cypherString(u.Metadata.Receiver)},
+				}))
+			}
+			fmt.Fprintf(bw, "UNWIND [\n  %s\n] AS row\nMERGE (n:Symbol {id: row.id})\nSET n += row, n:%s;\n", strings.Join(rows, ",\n  "), label)
+		}
+	}
+
+	byKind := make(map[string][]Edge)
+	for _, e := range g.Edges {
+		if _, ok := g.Nodes[e.From]; !ok {
+			continue
+		}
+		if _, ok := g.Nodes[e.To]; !ok {
+			continue
+		}
+		byKind[cypherRelType(e.Kind)] = append(byKind[cypherRelType(e.Kind)], e)
+	}
+	for _, relType := range sortedKeys(byKind) {
+		edges := byKind[relType]
+		sort.Slice(edges, func(i, j int) bool {
+			if edges[i].From != edges[j].From {
+				return edges[i].From < edges[j].From
+			}
+			return edges[i].To < edges[j].To
+		})
+		for start := 0; start < len(edges); start += cypherBatchSize {
+			end := min(start+cypherBatchSize, len(edges))
+			rows := make([]string, 0, end-start)
+			for _, e := range edges[start:end] {
+				rows = append(rows, cypherMap([][2]string{
+					{"from", cypherString(e.From)},
+					{"to", cypherString(e.To)},
+					{"resolver", cypherString(e.Resolver)},
+					{"confidence", strconv.FormatFloat(e.Confidence, 'f', -1, 64)},
+				}))
+			}
+			fmt.Fprintf(bw, "UNWIND [\n  %s\n] AS row\nMATCH (a:Symbol {id: row.from}), (b:Symbol {id: row.to})\nMERGE (a)-[r:%s]->(b)\nSET r.resolver = row.resolver, r.confidence = row.confidence;\n", strings.Join(rows, ",\n  "), relType)
+		}
+	}
+	return bw.Flush()
+}
+
+// cypherLabel maps a unit type such as "file_module" to a label like "FileModule".
+func cypherLabel(unitType string) string {
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(unitType, func(r rune) bool { return r == '_' || !isIdentRune(r) }) {
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	if sb.Len() == 0 {
+		return "Unknown"
+	}
+	return sb.String()
+}
+
+func cypherRelType(kind RelationKind) string {
+	rel := strings.ToUpper(strings.Map(func(r rune) rune {
+		if isIdentRune(r) {
+			return r
+		}
+		return '_'
+	}, string(kind)))
+	if rel == "" {
+		return "RELATES_TO"
+	}
+	return rel
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+func cypherMap(fields [][2]string) string {
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		parts = append(parts, f[0]+": "+f[1])
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+func cypherString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\'':
+			sb.WriteString(`\'`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('\'')
+	return sb.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,232 @@
+package graph
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExportFormats lists the formats WriteExport accepts.
+var ExportFormats = []string{"cypher", "dot", "graphml", "json"}
+
+// WriteExport writes g in the named format: cypher (Neo4j), dot
+// (Graphviz), graphml (Gephi, yEd) or json.
+func WriteExport(w io.Writer, g *Graph, format string) error {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "cypher":
+		return WriteCypher(w, g)
+	case "dot":
+		return WriteDOT(w, g)
+	case "graphml":
+		return WriteGraphML(w, g)
+	case "json":
+		return WriteJSON(w, g)
+	}
+	return fmt.Errorf("unsupported export format %q (use %s)", format, strings.Join(ExportFormats, ", "))
+}
+
+// exportNodes returns the symbols of g sorted by ID.
+func exportNodes(g *Graph) []*Symbol {
+	out := make([]*Symbol, 0, len(g.Nodes))
+	for _, n := range g.Nodes {
+		if n != nil && n.Unit != nil {
+			out = append(out, n.Unit)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// exportEdges returns the edges of g between known nodes, sorted by source,
+// target and kind.
+func exportEdges(g *Graph) []Edge {
+	out := make([]Edge, 0, len(g.Edges))
+	for _, e := range g.Edges {
+		if g.Nodes[e.From] == nil || g.Nodes[e.To] == nil {
+			continue
+		}
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].From != out[j].From {
+			return out[i].From < out[j].From
+		}
+		if out[i].To != out[j].To {
+			return out[i].To < out[j].To
+		}
+		return out[i].Kind < out[j].Kind
+	})
+	return out
+}
+
+// dotShapes draws unit types apart in Graphviz.
+var dotShapes = map[string]string{
+	"struct":         "box",
+	"interface":      "box",
+	"method":         "ellipse",
+	"function":       "ellipse",
+	UnitTypeTest:     "note",
+	UnitTypeExternal: "cylinder",
+	UnitTypePackage:  "folder",
+}
+
+// WriteDOT writes g as a Graphviz digraph. Nodes are labelled with their
+// qualified name and shaped by unit type; edges carry their kind, resolver
+// and confidence, and may_call edges are dashed.
+func WriteDOT(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "// Generated by docod.")
+	fmt.Fprintln(bw, "digraph docod {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, "  node [fontname=\"Helvetica\", fontsize=10];")
+	fmt.Fprintln(bw, "  edge [fontname=\"Helvetica\", fontsize=8];")
+	for _, u := range exportNodes(g) {
+		shape := dotShapes[u.UnitType]
+		if shape == "" {
+			shape = "plaintext"
+		}
+		fmt.Fprintf(bw, "  %s [label=%s, shape=%s, unit_type=%s, package=%s, filepath=%s];\n",
+			dotID(u.ID), dotID(exportLabel(u)), shape, dotID(u.UnitType), dotID(u.Package), dotID(u.Filepath))
+	}
+	for _, e := range exportEdges(g) {
+		attrs := fmt.Sprintf("label=%s, kind=%s, resolver=%s, confidence=%s",
+			dotID(string(e.Kind)), dotID(string(e.Kind)), dotID(e.Resolver), strconv.FormatFloat(e.Confidence, 'f', -1, 64))
+		if e.Kind == RelationMayCall {
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(bw, "  %s -> %s [%s];\n", dotID(e.From), dotID(e.To), attrs)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// exportLabel names a node for humans: Package.Name, or the name alone.
+func exportLabel(u *Symbol) string {
+	if u.Package == "" || u.UnitType == UnitTypePackage || u.UnitType == UnitTypeExternal {
+		return u.Name
+	}
+	return u.Package + "." + u.Name
+}
+
+// dotID quotes s as a DOT string.
+func dotID(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// graphMLKeys are the attributes declared for GraphML nodes and edges.
+var graphMLKeys = []struct{ id, target, name, typ string }{
+	{"n_name", "node", "name", "string"},
+	{"n_label", "node", "label", "string"},
+	{"n_package", "node", "package", "string"},
+	{"n_unit_type", "node", "unit_type", "string"},
+	{"n_filepath", "node", "filepath", "string"},
+	{"n_start_line", "node", "start_line", "int"},
+	{"n_end_line", "node", "end_line", "int"},
+	{"n_signature", "node", "signature", "string"},
+	{"e_kind", "edge", "kind", "string"},
+	{"e_resolver", "edge", "resolver", "string"},
+	{"e_confidence", "edge", "confidence", "double"},
+}
+
+// WriteGraphML writes g as a GraphML document with typed node and edge
+// attributes, for tools such as Gephi and yEd.
+func WriteGraphML(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(xml.Header)
+	bw.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	for _, k := range graphMLKeys {
+		fmt.Fprintf(bw, "  <key id=%q for=%q attr.name=%q attr.type=%q/>\n", k.id, k.target, k.name, k.typ)
+	}
+	bw.WriteString(`  <graph id="docod" edgedefault="directed">` + "\n")
+	for _, u := range exportNodes(g) {
+		fmt.Fprintf(bw, "    <node id=\"%s\">\n", xmlText(u.ID))
+		for _, d := range [][2]string{
+			{"n_name", u.Name},
+			{"n_label", exportLabel(u)},
+			{"n_package", u.Package},
+			{"n_unit_type", u.UnitType},
+			{"n_filepath", u.Filepath},
+			{"n_start_line", strconv.Itoa(u.StartLine)},
+			{"n_end_line", strconv.Itoa(u.EndLine)},
+			{"n_signature", u.Metadata.Signature},
+		} {
+			if d[1] != "" {
+				fmt.Fprintf(bw, "      <data key=%q>%s</data>\n", d[0], xmlText(d[1]))
+			}
+		}
+		bw.WriteString("    </node>\n")
+	}
+	for i, e := range exportEdges(g) {
+		fmt.Fprintf(bw, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", i, xmlText(e.From), xmlText(e.To))
+		fmt.Fprintf(bw, "      <data key=\"e_kind\">%s</data>\n", xmlText(string(e.Kind)))
+		if e.Resolver != "" {
+			fmt.Fprintf(bw, "      <data key=\"e_resolver\">%s</data>\n", xmlText(e.Resolver))
+		}
+		fmt.Fprintf(bw, "      <data key=\"e_confidence\">%s</data>\n", strconv.FormatFloat(e.Confidence, 'f', -1, 64))
+		bw.WriteString("    </edge>\n")
+	}
+	bw.WriteString("  </graph>\n</graphml>\n")
+	return bw.Flush()
+}
+
+func xmlText(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+// jsonExport is the document WriteJSON produces.
+type jsonExport struct {
+	Nodes []jsonExportNode `json:"nodes"`
+	Edges []jsonExportEdge `json:"edges"`
+}
+
+type jsonExportNode struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Package   string `json:"package,omitempty"`
+	UnitType  string `json:"unit_type"`
+	Filepath  string `json:"filepath,omitempty"`
+	StartLine int    `json:"start_line,omitempty"`
+	EndLine   int    `json:"end_line,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Receiver  string `json:"receiver,omitempty"`
+}
+
+type jsonExportEdge struct {
+	From       string       `json:"from"`
+	To         string       `json:"to"`
+	Kind       RelationKind `json:"kind"`
+	Resolver   string       `json:"resolver,omitempty"`
+	Confidence float64      `json:"confidence"`
+}
+
+// WriteJSON writes g as {"nodes": [...], "edges": [...]}, without symbol
+// bodies, for scripts and other tools.
+func WriteJSON(w io.Writer, g *Graph) error {
+	doc := jsonExport{Nodes: []jsonExportNode{}, Edges: []jsonExportEdge{}}
+	for _, u := range exportNodes(g) {
+		doc.Nodes = append(doc.Nodes, jsonExportNode{
+			ID:        u.ID,
+			Name:      u.Name,
+			Package:   u.Package,
+			UnitType:  u.UnitType,
+			Filepath:  u.Filepath,
+			StartLine: u.StartLine,
+			EndLine:   u.EndLine,
+			Signature: u.Metadata.Signature,
+			Receiver:  u.Metadata.Receiver,
+		})
+	}
+	for _, e := range exportEdges(g) {
+		doc.Edges = append(doc.Edges, jsonExportEdge{From: e.From, To: e.To, Kind: e.Kind, Resolver: e.Resolver, Confidence: e.Confidence})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
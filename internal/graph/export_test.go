@@ -0,0 +1,96 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCypher(t *testing.T) {
+	g := NewGraph()
+	g.AddSymbol(&Symbol{ID: "go/store:function:Open:1", Name: "Open", Package: "store", UnitType: "function", Metadata: SymbolMetadata{Signature: "func Open(path string) (*DB, error)"}})
+	g.AddSymbol(&Symbol{ID: "go/store:struct:DB:2", Name: "DB", Package: "store", UnitType: "struct", Filepath: "it's/db.go"})
+	g.AddSymbol(&Symbol{ID: "go/store:file_module:x:3", Name: "x", UnitType: "file_module"})
+	g.Edges = []Edge{
+		{From: "go/store:function:Open:1", To: "go/store:struct:DB:2", Kind: RelationUsesType, Resolver: "types", Confidence: 0.9},
+		{From: "go/store:function:Open:1", To: "missing", Kind: RelationCalls},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteCypher(&buf, g))
+	out := buf.String()
+
+	assert.Contains(t, out, "CREATE CONSTRAINT docod_symbol_id IF NOT EXISTS")
+	assert.Contains(t, out, "SET n += row, n:Function;")
+	assert.Contains(t, out, "SET n += row, n:Struct;")
+	assert.Contains(t, out, "SET n += row, n:FileModule;")
+	assert.Contains(t, out, `filepath: 'it\'s/db.go'`)
+	assert.Contains(t, out, "MERGE (a)-[r:USES_TYPE]->(b)")
+	assert.Contains(t, out, "confidence: 0.9")
+	// Edges to nodes outside the graph are skipped.
+	assert.NotContains(t, out, "CALLS")
+	assert.Equal(t, 1, strings.Count(out, "MATCH (a:Symbol"))
+}
+
+func exportGraph() *Graph {
+	g := NewGraph()
+	g.AddSymbol(&Symbol{ID: "open", Name: "Open", Package: "store", UnitType: "function", Filepath: "store/db.go", StartLine: 3, EndLine: 9, Metadata: SymbolMetadata{Signature: "func Open(path string) (*DB, error)"}})
+	g.AddSymbol(&Symbol{ID: "db", Name: "DB", Package: "store", UnitType: "struct", Filepath: `say "hi"/db.go`})
+	g.Edges = []Edge{
+		{From: "open", To: "db", Kind: RelationUsesType, Resolver: "types", Confidence: 0.9},
+		{From: "open", To: "missing", Kind: RelationCalls},
+		{From: "db", To: "open", Kind: RelationMayCall, Resolver: "dispatch", Confidence: 0.5},
+	}
+	return g
+}
+
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteExport(&buf, exportGraph(), "DOT"))
+	out := buf.String()
+
+	assert.True(t, strings.HasPrefix(out, "// Generated by docod.\ndigraph docod {\n"))
+	assert.Contains(t, out, `  "open" [label="store.Open", shape=ellipse, unit_type="function", package="store", filepath="store/db.go"];`)
+	assert.Contains(t, out, `filepath="say \"hi\"/db.go"`)
+	assert.Contains(t, out, `  "open" -> "db" [label="uses_type", kind="uses_type", resolver="types", confidence=0.9];`)
+	assert.Contains(t, out, `  "db" -> "open" [label="may_call", kind="may_call", resolver="dispatch", confidence=0.5, style=dashed];`)
+	assert.NotContains(t, out, "missing")
+}
+
+func TestWriteGraphML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteExport(&buf, exportGraph(), "graphml"))
+	out := buf.String()
+
+	assert.Contains(t, out, `<key id="e_confidence" for="edge" attr.name="confidence" attr.type="double"/>`)
+	assert.Contains(t, out, "<data key=\"n_signature\">func Open(path string) (*DB, error)</data>")
+	assert.Contains(t, out, "<data key=\"n_filepath\">say &#34;hi&#34;/db.go</data>")
+	assert.Contains(t, out, `<edge id="e1" source="open" target="db">`)
+	assert.Equal(t, 2, strings.Count(out, "<edge "))
+	assert.True(t, strings.HasSuffix(out, "</graphml>\n"))
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteExport(&buf, exportGraph(), "json"))
+
+	var doc struct {
+		Nodes []map[string]any `json:"nodes"`
+		Edges []map[string]any `json:"edges"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	require.Len(t, doc.Nodes, 2)
+	assert.Equal(t, "db", doc.Nodes[0]["id"])
+	assert.Equal(t, float64(3), doc.Nodes[1]["start_line"])
+	require.Len(t, doc.Edges, 2)
+	assert.Equal(t, map[string]any{"from": "open", "to": "db", "kind": "uses_type", "resolver": "types", "confidence": 0.9}, doc.Edges[1])
+}
+
+func TestWriteExport_UnknownFormat(t *testing.T) {
+	err := WriteExport(&bytes.Buffer{}, NewGraph(), "xml")
+	assert.ErrorContains(t, err, "use cypher, dot, graphml, json")
+}
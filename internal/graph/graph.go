@@ -37,6 +37,40 @@ type Graph struct {
 	// Index for faster lookup: Name -> []ID
 	// Useful for resolving name-based relations to actual IDs.
 	nameIndex map[string][]string
+
+	// loadContent fetches symbol bodies left out of a partially loaded graph.
+	loadContent ContentLoader
+}
+
+// ContentLoader returns the source of the symbol id.
+type ContentLoader func(id string) (string, error)
+
+// SetContentLoader marks the graph as partially hydrated: its symbols carry
+// metadata only, and Content loads their source through fn on demand.
+func (g *Graph) SetContentLoader(fn ContentLoader) {
+	g.loadContent = fn
+}
+
+// Partial reports whether symbol content is loaded on demand.
+func (g *Graph) Partial() bool {
+	return g.loadContent != nil
+}
+
+// Content returns the source of u. In a partial graph a symbol without
+// content is fetched through the loader each time, so the body is not kept
+// in memory; load failures read as empty content.
+func (g *Graph) Content(u *Symbol) string {
+	if u == nil {
+		return ""
+	}
+	if u.Content != "" || g.loadContent == nil {
+		return u.Content
+	}
+	content, err := g.loadContent(u.ID)
+	if err != nil {
+		return ""
+	}
+	return content
 }
 
 // NewGraph creates an empty graph.
@@ -58,6 +92,30 @@ func (g *Graph) AddSymbol(unit *Symbol) {
 	g.addToIndex(unit)
 }
 
+// Subgraph returns the nodes of g that keep accepts, with the edges and
+// unresolved relations among them. Symbols are shared with g, and content
+// keeps loading through g's loader.
+func (g *Graph) Subgraph(keep func(*Node) bool) *Graph {
+	sub := NewGraph()
+	sub.loadContent = g.loadContent
+	for _, node := range g.Nodes {
+		if node != nil && node.Unit != nil && keep(node) {
+			sub.AddSymbol(node.Unit)
+		}
+	}
+	for _, e := range g.Edges {
+		if sub.Nodes[e.From] != nil && sub.Nodes[e.To] != nil {
+			sub.Edges = append(sub.Edges, e)
+		}
+	}
+	for _, ur := range g.Unresolved {
+		if sub.Nodes[ur.From] != nil {
+			sub.Unresolved = append(sub.Unresolved, ur)
+		}
+	}
+	return sub
+}
+
 // RebuildIndices reconstructs the nameIndex from the current Nodes map.
 // This is essential after loading a graph from persistence (JSON).
 func (g *Graph) RebuildIndices() {
@@ -68,6 +126,11 @@ func (g *Graph) RebuildIndices() {
 }
 
 func (g *Graph) addToIndex(unit *Symbol) {
+	// Package nodes are named by directory and must not capture
+	// relation targets.
+	if unit.UnitType == UnitTypePackage {
+		return
+	}
 	// Simple index: Name -> ID
 	g.nameIndex[unit.Name] = append(g.nameIndex[unit.Name], unit.ID)
 
@@ -78,13 +141,54 @@ func (g *Graph) addToIndex(unit *Symbol) {
 	}
 }
 
+// QualifiedLookup resolves package-qualified targets (e.g. "storage.NewSQLiteStore")
+// using import information. handled=true with no ids marks the target as external.
+type QualifiedLookup func(source *Symbol, target string) (ids []string, handled bool)
+
 // LinkRelations attempts to resolve all name-based relations to actual node IDs.
 func (g *Graph) LinkRelations() {
+	g.LinkRelationsWith(nil, "")
+}
+
+// LinkRelationsWith links relations, consulting lookup before the name-based
+// heuristics. Edges found by lookup are attributed to resolverName.
+func (g *Graph) LinkRelationsWith(lookup QualifiedLookup, resolverName string) {
 	g.Edges = []Edge{}                    // Reset edges
 	g.Unresolved = []UnresolvedRelation{} // Reset unresolved candidates
 
 	for sourceID, node := range g.Nodes {
 		for _, rel := range node.Unit.Relations {
+			if lookup != nil {
+				if ids, handled := lookup(node.Unit, rel.Target); handled {
+					if len(ids) == 0 {
+						g.Unresolved = append(g.Unresolved, UnresolvedRelation{
+							From:       sourceID,
+							Target:     rel.Target,
+							Kind:       rel.Kind,
+							Reason:     ReasonExternal,
+							Resolver:   rel.Resolver,
+							Confidence: rel.Confidence,
+							Evidence:   rel.Evidence,
+						})
+						continue
+					}
+					confidence := rel.Confidence
+					if len(ids) == 1 && confidence < 0.95 {
+						confidence = 0.95
+					}
+					for _, targetID := range ids {
+						g.Edges = append(g.Edges, Edge{
+							From:       sourceID,
+							To:         targetID,
+							Kind:       rel.Kind,
+							Resolver:   resolverName,
+							Confidence: confidence,
+							Evidence:   rel.Evidence,
+						})
+					}
+					continue
+				}
+			}
 			targets := g.resolveTarget(rel.Target, node.Unit.Package)
 			if len(targets) == 0 {
 				g.Unresolved = append(g.Unresolved, UnresolvedRelation{
@@ -161,10 +265,12 @@ func (g *Graph) GetDependencies(id string) []*Node {
 }
 
 // GetDependents returns all nodes that depend on the given node.
+// Tests exercising the node are reported by TestsOf instead, and the package
+// containing it by PackageOf.
 func (g *Graph) GetDependents(id string) []*Node {
 	var deps []*Node
 	for _, edge := range g.Edges {
-		if edge.To == id {
+		if edge.To == id && edge.Kind != RelationTests && edge.Kind != RelationContains {
 			if node, ok := g.Nodes[edge.From]; ok {
 				deps = append(deps, node)
 			}
@@ -172,3 +278,19 @@ func (g *Graph) GetDependents(id string) []*Node {
 	}
 	return deps
 }
+
+// TestsOf returns the test nodes that exercise the given node.
+func (g *Graph) TestsOf(id string) []*Node {
+	var tests []*Node
+	seen := make(map[string]bool)
+	for _, edge := range g.Edges {
+		if edge.To != id || edge.Kind != RelationTests || seen[edge.From] {
+			continue
+		}
+		if node, ok := g.Nodes[edge.From]; ok {
+			seen[edge.From] = true
+			tests = append(tests, node)
+		}
+	}
+	return tests
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGraph_LinkRelations(t *testing.T) {
@@ -91,3 +92,62 @@ func TestGraph_UnresolvedReasonMetrics(t *testing.T) {
 	counts := g.UnresolvedReasonCounts()
 	assert.Equal(t, 1, counts[ReasonNoCandidate])
 }
+
+func TestGraph_PageRank(t *testing.T) {
+	g := NewGraph()
+	for _, id := range []string{"hub", "a", "b", "c", "leaf"} {
+		g.AddSymbol(&Symbol{ID: id, Name: id})
+	}
+	g.Edges = []Edge{
+		{From: "a", To: "hub", Kind: RelationCalls, Confidence: 0.9},
+		{From: "b", To: "hub", Kind: RelationCalls, Confidence: 0.9},
+		{From: "c", To: "hub", Kind: RelationCalls, Confidence: 0.9},
+		{From: "leaf", To: "a", Kind: RelationCalls, Confidence: 0.9},
+	}
+
+	scores := g.PageRank(0.85, 50)
+	assert.InDelta(t, 1.0, scores["hub"], 1e-9)
+	assert.Greater(t, scores["hub"], scores["a"])
+	assert.Greater(t, scores["a"], scores["b"], "a is also used by leaf")
+	assert.InDelta(t, scores["b"], scores["c"], 1e-9)
+}
+
+func TestGraph_DetectCommunities(t *testing.T) {
+	g := NewGraph()
+	for _, id := range []string{"a1", "a2", "a3", "b1", "b2", "b3"} {
+		g.AddSymbol(&Symbol{ID: id, Name: id})
+	}
+	link := func(from, to string, c float64) {
+		g.Edges = append(g.Edges, Edge{From: from, To: to, Kind: RelationCalls, Confidence: c})
+	}
+	link("a1", "a2", 0.9)
+	link("a2", "a3", 0.9)
+	link("a3", "a1", 0.9)
+	link("b1", "b2", 0.9)
+	link("b2", "b3", 0.9)
+	link("b3", "b1", 0.9)
+	link("a1", "b1", 0.1)
+
+	comms := g.DetectCommunities()
+	assert.Len(t, comms, 2)
+	assert.Equal(t, []string{"a1", "a2", "a3"}, comms[0].Members)
+	assert.Equal(t, []string{"b1", "b2", "b3"}, comms[1].Members)
+	assert.Equal(t, 0, comms[0].ID)
+}
+
+func TestGraph_Subgraph(t *testing.T) {
+	g := NewGraph()
+	g.AddUnit(&extractor.CodeUnit{ID: "a", Name: "A", Package: "p", Relations: []extractor.Relation{{Target: "B", Kind: "calls"}, {Target: "Missing", Kind: "calls"}}})
+	g.AddUnit(&extractor.CodeUnit{ID: "b", Name: "B", Package: "p", Relations: []extractor.Relation{{Target: "C", Kind: "calls"}}})
+	g.AddUnit(&extractor.CodeUnit{ID: "c", Name: "C", Package: "p"})
+	g.LinkRelations()
+	require.Len(t, g.Edges, 2)
+
+	sub := g.Subgraph(func(n *Node) bool { return n.Unit.ID != "c" })
+	assert.Len(t, sub.Nodes, 2)
+	require.Len(t, sub.Edges, 1, "edges into dropped nodes are dropped")
+	assert.Equal(t, "a", sub.Edges[0].From)
+	assert.Equal(t, "b", sub.Edges[0].To)
+	assert.Len(t, sub.Unresolved, 1)
+	assert.Same(t, g.Nodes["a"].Unit, sub.Nodes["a"].Unit, "symbols are shared")
+}
@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// MatchSymbolIDs maps IDs of before that are missing from after to the ID of
+// the same symbol in after. Symbols are matched by package, directory, kind,
+// receiver and name, falling back to a match without the directory when it
+// is unambiguous or the new path is a suffix of the old one (e.g. graphs
+// built from different checkout paths). The
+// mapping lets stored references follow an ID scheme or signature change.
+func MatchSymbolIDs(before, after *Graph) map[string]string {
+	mapping := make(map[string]string)
+	if before == nil || after == nil {
+		return mapping
+	}
+	exact := make(map[string][]string)
+	loose := make(map[string][]string)
+	for id, n := range after.Nodes {
+		if n == nil || n.Unit == nil {
+			continue
+		}
+		exact[symbolKey(n.Unit)] = append(exact[symbolKey(n.Unit)], id)
+		loose[looseSymbolKey(n.Unit)] = append(loose[looseSymbolKey(n.Unit)], id)
+	}
+	for id, n := range before.Nodes {
+		if n == nil || n.Unit == nil {
+			continue
+		}
+		if _, ok := after.Nodes[id]; ok {
+			continue
+		}
+		if ids := exact[symbolKey(n.Unit)]; len(ids) == 1 {
+			mapping[id] = ids[0]
+			continue
+		}
+		ids := loose[looseSymbolKey(n.Unit)]
+		if len(ids) == 1 {
+			mapping[id] = ids[0]
+			continue
+		}
+		// Prefer the candidate whose path is a suffix of the old path.
+		var match []string
+		for _, cand := range ids {
+			if hasPathSuffix(n.Unit.Filepath, after.Nodes[cand].Unit.Filepath) {
+				match = append(match, cand)
+			}
+		}
+		if len(match) == 1 {
+			mapping[id] = match[0]
+		}
+	}
+	return mapping
+}
+
+func hasPathSuffix(full, suffix string) bool {
+	full, suffix = filepath.ToSlash(full), filepath.ToSlash(suffix)
+	return suffix != "" && (full == suffix || strings.HasSuffix(full, "/"+suffix))
+}
+
+func looseSymbolKey(u *Symbol) string {
+	return strings.Join([]string{u.Package, u.UnitType, u.Metadata.Receiver, u.Name}, "|")
+}
@@ -14,3 +14,84 @@ func (g *Graph) UnresolvedReasonCounts() map[UnresolvedReason]int {
 	}
 	return counts
 }
+
+// ConfidenceBuckets are the lower bounds of the confidence histogram buckets.
+var ConfidenceBuckets = []float64{0, 0.5, 0.7, 0.9}
+
+// ConfidenceStats summarizes the confidence of the edges from one resolver.
+type ConfidenceStats struct {
+	Edges int
+	Min   float64
+	Max   float64
+	Mean  float64
+	// Buckets counts edges per ConfidenceBuckets range.
+	Buckets []int
+}
+
+// EdgeConfidenceByResolver reports the confidence distribution of edges per
+// resolver. Edges without a resolver are grouped under "unknown".
+func (g *Graph) EdgeConfidenceByResolver() map[string]ConfidenceStats {
+	out := make(map[string]ConfidenceStats)
+	if g == nil {
+		return out
+	}
+	for _, e := range g.Edges {
+		name := e.Resolver
+		if name == "" {
+			name = "unknown"
+		}
+		s, ok := out[name]
+		if !ok {
+			s = ConfidenceStats{Min: e.Confidence, Max: e.Confidence, Buckets: make([]int, len(ConfidenceBuckets))}
+		}
+		if e.Confidence < s.Min {
+			s.Min = e.Confidence
+		}
+		if e.Confidence > s.Max {
+			s.Max = e.Confidence
+		}
+		s.Mean += (e.Confidence - s.Mean) / float64(s.Edges+1)
+		s.Edges++
+		for i := len(ConfidenceBuckets) - 1; i >= 0; i-- {
+			if e.Confidence >= ConfidenceBuckets[i] || i == 0 {
+				s.Buckets[i]++
+				break
+			}
+		}
+		out[name] = s
+	}
+	return out
+}
+
+// DropLowConfidenceEdges removes edges below minConfidence and records their
+// relations as unresolved with ReasonLowConfidence. It returns the number of
+// edges removed.
+func (g *Graph) DropLowConfidenceEdges(minConfidence float64) int {
+	if g == nil || minConfidence <= 0 {
+		return 0
+	}
+	kept := g.Edges[:0]
+	dropped := 0
+	for _, e := range g.Edges {
+		if e.Confidence >= minConfidence {
+			kept = append(kept, e)
+			continue
+		}
+		dropped++
+		target := e.To
+		if n, ok := g.Nodes[e.To]; ok && n.Unit != nil {
+			target = n.Unit.Name
+		}
+		g.Unresolved = append(g.Unresolved, UnresolvedRelation{
+			From:       e.From,
+			Target:     target,
+			Kind:       e.Kind,
+			Reason:     ReasonLowConfidence,
+			Resolver:   e.Resolver,
+			Confidence: e.Confidence,
+			Evidence:   e.Evidence,
+		})
+	}
+	g.Edges = kept
+	return dropped
+}
@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraph_EdgeConfidenceByResolver(t *testing.T) {
+	g := NewGraph()
+	g.Edges = []Edge{
+		{From: "a", To: "b", Resolver: "heuristic", Confidence: 0.4},
+		{From: "a", To: "c", Resolver: "heuristic", Confidence: 0.8},
+		{From: "a", To: "d", Resolver: "types", Confidence: 1.0},
+		{From: "a", To: "e", Confidence: 0.6},
+	}
+
+	dist := g.EdgeConfidenceByResolver()
+	h := dist["heuristic"]
+	assert.Equal(t, 2, h.Edges)
+	assert.InDelta(t, 0.4, h.Min, 1e-9)
+	assert.InDelta(t, 0.8, h.Max, 1e-9)
+	assert.InDelta(t, 0.6, h.Mean, 1e-9)
+	assert.Equal(t, []int{1, 0, 1, 0}, h.Buckets)
+	assert.Equal(t, []int{0, 0, 0, 1}, dist["types"].Buckets)
+	assert.Equal(t, 1, dist["unknown"].Edges)
+}
+
+func TestGraph_DropLowConfidenceEdges(t *testing.T) {
+	g := NewGraph()
+	g.Edges = []Edge{
+		{From: "a", To: "b", Kind: RelationCalls, Resolver: "heuristic", Confidence: 0.4},
+		{From: "a", To: "c", Kind: RelationCalls, Resolver: "types", Confidence: 1.0},
+	}
+
+	assert.Equal(t, 0, g.DropLowConfidenceEdges(0))
+	assert.Equal(t, 1, g.DropLowConfidenceEdges(0.5))
+	assert.Len(t, g.Edges, 1)
+	assert.Equal(t, "c", g.Edges[0].To)
+	if assert.Len(t, g.Unresolved, 1) {
+		assert.Equal(t, ReasonLowConfidence, g.Unresolved[0].Reason)
+		assert.Equal(t, "heuristic", g.Unresolved[0].Resolver)
+	}
+}
@@ -0,0 +1,93 @@
+package graph
+
+import "strings"
+
+// packageNodeResolver is the Resolver recorded on package edges.
+const packageNodeResolver = "package_nodes"
+
+// PackageNodeStats counts what BuildPackageNodes added.
+type PackageNodeStats struct {
+	Packages int
+	Contains int
+	Imports  int
+}
+
+// BuildPackageNodes replaces the package nodes of g with one node per
+// package directory, linked to its symbols by contains edges and to the
+// packages it depends on by imports edges. Imports are derived from the
+// resolved symbol edges between packages, so they reflect what the code
+// actually uses. Package node IDs are PackageSummaryID of the directory and
+// their Name is the directory.
+func (g *Graph) BuildPackageNodes() PackageNodeStats {
+	var stats PackageNodeStats
+	if g == nil {
+		return stats
+	}
+	g.RemovePackageNodes()
+	for _, s := range g.PackageSummaries() {
+		g.AddSymbol(&Symbol{
+			ID:          s.ID,
+			Package:     s.Package,
+			Language:    "go",
+			UnitType:    UnitTypePackage,
+			Name:        s.Dir,
+			Description: strings.Join(s.Responsibilities, " "),
+		})
+		stats.Packages++
+		for _, id := range s.SymbolIDs {
+			g.Edges = append(g.Edges, Edge{From: s.ID, To: id, Kind: RelationContains, Resolver: packageNodeResolver, Confidence: 1})
+			stats.Contains++
+		}
+		for _, dir := range rankedKeys(s.DependsOn) {
+			g.Edges = append(g.Edges, Edge{From: s.ID, To: PackageSummaryID(dir), Kind: RelationImports, Resolver: packageNodeResolver, Confidence: 1})
+			stats.Imports++
+		}
+	}
+	return stats
+}
+
+// RemovePackageNodes drops the package nodes of g and their edges.
+func (g *Graph) RemovePackageNodes() {
+	removed := false
+	for id, n := range g.Nodes {
+		if isPackageNode(n) {
+			delete(g.Nodes, id)
+			removed = true
+		}
+	}
+	if !removed {
+		return
+	}
+	kept := g.Edges[:0]
+	for _, e := range g.Edges {
+		if !IsPackageRelation(e.Kind) {
+			kept = append(kept, e)
+		}
+	}
+	g.Edges = kept
+	g.RebuildIndices()
+}
+
+// PackageOf returns the package node containing the symbol id, or nil when
+// the graph has no package nodes.
+func (g *Graph) PackageOf(id string) *Symbol {
+	for _, e := range g.Edges {
+		if e.Kind == RelationContains && e.To == id {
+			if n := g.Nodes[e.From]; isPackageNode(n) {
+				return n.Unit
+			}
+		}
+	}
+	return nil
+}
+
+func isPackageNode(n *Node) bool {
+	return n != nil && n.Unit != nil && n.Unit.UnitType == UnitTypePackage
+}
+
+// IsPackageRelation reports whether kind belongs to the package layer. Those
+// edges group symbols rather than relate them, so symbol-level traversals
+// skip them.
+func IsPackageRelation(kind RelationKind) bool {
+	return kind == RelationContains || kind == RelationImports
+}
@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func packageGraph() *Graph {
+	g := NewGraph()
+	g.AddSymbol(&Symbol{ID: "open", Name: "Open", Package: "store", UnitType: "function", Filepath: "internal/store/db.go", Description: "Open opens the database."})
+	g.AddSymbol(&Symbol{ID: "db", Name: "DB", Package: "store", UnitType: "struct", Filepath: "internal/store/types.go"})
+	g.AddSymbol(&Symbol{ID: "test", Name: "TestOpen", Package: "store", UnitType: UnitTypeTest, Filepath: "internal/store/db_test.go"})
+	g.AddSymbol(&Symbol{ID: "run", Name: "Run", Package: "cli", UnitType: "function", Filepath: "cmd/cli/run.go"})
+	g.Edges = []Edge{
+		{From: "run", To: "open", Kind: RelationCalls},
+		{From: "run", To: "db", Kind: RelationUsesType},
+		{From: "test", To: "open", Kind: RelationTests},
+	}
+	return g
+}
+
+func TestBuildPackageNodes(t *testing.T) {
+	g := packageGraph()
+	stats := g.BuildPackageNodes()
+	assert.Equal(t, PackageNodeStats{Packages: 2, Contains: 3, Imports: 1}, stats)
+
+	storeID := PackageSummaryID("internal/store")
+	pkg := g.Nodes[storeID]
+	require.NotNil(t, pkg)
+	assert.Equal(t, UnitTypePackage, pkg.Unit.UnitType)
+	assert.Equal(t, "internal/store", pkg.Unit.Name)
+	assert.Equal(t, "store", pkg.Unit.Package)
+	assert.Empty(t, pkg.Unit.Filepath, "package nodes have no source file")
+	assert.Equal(t, "Open opens the database.", pkg.Unit.Description)
+
+	assert.Contains(t, g.Edges, Edge{From: PackageSummaryID("cmd/cli"), To: storeID, Kind: RelationImports, Resolver: packageNodeResolver, Confidence: 1})
+	assert.Equal(t, pkg.Unit, g.PackageOf("open"))
+	assert.Nil(t, g.PackageOf("test"), "tests are not package members")
+
+	// Package nodes stay out of symbol-level views.
+	assert.Empty(t, g.resolveTarget("internal/store", ""))
+	assert.Len(t, g.GetDependents("open"), 1)
+	assert.Len(t, g.PackageSummaries(), 2)
+
+	// Rebuilding replaces the layer instead of duplicating it.
+	g.BuildPackageNodes()
+	assert.Len(t, g.Nodes, 6)
+	assert.Len(t, g.Edges, 3+3+1)
+
+	g.RemovePackageNodes()
+	assert.Len(t, g.Nodes, 4)
+	assert.Len(t, g.Edges, 3)
+}
+
+func TestDiff_IgnoresPackageNodes(t *testing.T) {
+	before := packageGraph()
+	after := packageGraph()
+	after.BuildPackageNodes()
+	assert.True(t, Diff(before, after).Empty())
+}
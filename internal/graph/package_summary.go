@@ -0,0 +1,169 @@
+package graph
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// UnitTypePackageSummary marks synthesized per-package summary nodes.
+const UnitTypePackageSummary = "package_summary"
+
+// maxSummaryResponsibilities bounds the doc sentences kept per package.
+const maxSummaryResponsibilities = 5
+
+// PackageSummary aggregates a package's exported API and dependencies. It is
+// computed from the graph alone, without an LLM.
+type PackageSummary struct {
+	ID               string         `json:"id"`
+	Package          string         `json:"package"`
+	Dir              string         `json:"dir"`
+	Files            int            `json:"files"`
+	Symbols          int            `json:"symbols"`
+	Tests            int            `json:"tests"`
+	Exported         []string       `json:"exported"`         // exported symbol names, most used first
+	Responsibilities []string       `json:"responsibilities"` // first doc sentence of key exported symbols
+	DependsOn        map[string]int `json:"depends_on"`       // package dir -> edge count
+	UsedBy           map[string]int `json:"used_by"`
+	SymbolIDs        []string       `json:"symbol_ids"`
+}
+
+// PackageSummaryID returns the synthetic node ID for the package in dir.
+func PackageSummaryID(dir string) string {
+	return "package:" + filepath.ToSlash(dir)
+}
+
+// PackageSummaries computes one summary per package directory, sorted by dir.
+// Test functions are counted but not treated as API, and tests edges do not
+// count as dependencies.
+func (g *Graph) PackageSummaries() []PackageSummary {
+	if g == nil {
+		return nil
+	}
+	byDir := make(map[string]*PackageSummary)
+	files := make(map[string]map[string]bool)
+	dirOf := make(map[string]string, len(g.Nodes))
+	for id, n := range g.Nodes {
+		if n == nil || n.Unit == nil || n.Unit.UnitType == UnitTypePackageSummary || n.Unit.UnitType == UnitTypeExternal || n.Unit.UnitType == UnitTypePackage {
+			continue
+		}
+		dir := filepath.ToSlash(filepath.Dir(n.Unit.Filepath))
+		dirOf[id] = dir
+		s, ok := byDir[dir]
+		if !ok {
+			s = &PackageSummary{
+				ID:        PackageSummaryID(dir),
+				Package:   n.Unit.Package,
+				Dir:       dir,
+				DependsOn: make(map[string]int),
+				UsedBy:    make(map[string]int),
+			}
+			byDir[dir] = s
+			files[dir] = make(map[string]bool)
+		}
+		files[dir][n.Unit.Filepath] = true
+		if n.Unit.UnitType == UnitTypeTest {
+			s.Tests++
+			continue
+		}
+		s.Symbols++
+		s.SymbolIDs = append(s.SymbolIDs, id)
+	}
+
+	inDegree := make(map[string]int)
+	for _, e := range g.Edges {
+		if e.Kind == RelationTests {
+			continue
+		}
+		from, okFrom := dirOf[e.From]
+		to, okTo := dirOf[e.To]
+		if !okFrom || !okTo || from == to {
+			continue
+		}
+		byDir[from].DependsOn[to]++
+		byDir[to].UsedBy[from]++
+		inDegree[e.To]++
+	}
+
+	out := make([]PackageSummary, 0, len(byDir))
+	for dir, s := range byDir {
+		s.Files = len(files[dir])
+		sort.Slice(s.SymbolIDs, func(i, j int) bool {
+			a, b := s.SymbolIDs[i], s.SymbolIDs[j]
+			if inDegree[a] != inDegree[b] {
+				return inDegree[a] > inDegree[b]
+			}
+			return a < b
+		})
+		for _, id := range s.SymbolIDs {
+			u := g.Nodes[id].Unit
+			if u.UnitType == "method" || !isExportedName(u.Name) {
+				continue
+			}
+			s.Exported = append(s.Exported, u.Name)
+			if len(s.Responsibilities) < maxSummaryResponsibilities {
+				if sentence := firstSentence(u.Description); sentence != "" {
+					s.Responsibilities = append(s.Responsibilities, sentence)
+				}
+			}
+		}
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Dir < out[j].Dir })
+	return out
+}
+
+// Symbol renders the summary as a synthetic graph symbol so it can be
+// indexed and cited like any other unit.
+func (s PackageSummary) Symbol() *Symbol {
+	var sb strings.Builder
+	sb.WriteString("Package `" + s.Package + "` (" + s.Dir + ")\n")
+	if len(s.Responsibilities) > 0 {
+		sb.WriteString("Responsibilities:\n")
+		for _, r := range s.Responsibilities {
+			sb.WriteString("- " + r + "\n")
+		}
+	}
+	if len(s.Exported) > 0 {
+		sb.WriteString("Exported API: " + strings.Join(s.Exported, ", ") + "\n")
+	}
+	if deps := rankedKeys(s.DependsOn); len(deps) > 0 {
+		sb.WriteString("Depends on: " + strings.Join(deps, ", ") + "\n")
+	}
+	if users := rankedKeys(s.UsedBy); len(users) > 0 {
+		sb.WriteString("Used by: " + strings.Join(users, ", ") + "\n")
+	}
+	return &Symbol{
+		ID:          s.ID,
+		Filepath:    s.Dir,
+		Package:     s.Package,
+		Language:    "go",
+		Content:     sb.String(),
+		UnitType:    UnitTypePackageSummary,
+		Name:        s.Package,
+		Description: strings.Join(s.Responsibilities, " "),
+	}
+}
+
+// rankedKeys sorts map keys by descending count, then name.
+func rankedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if m[keys[i]] != m[keys[j]] {
+			return m[keys[i]] > m[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+func firstSentence(doc string) string {
+	doc = strings.Join(strings.Fields(doc), " ")
+	if i := strings.Index(doc, ". "); i >= 0 {
+		doc = doc[:i+1]
+	}
+	return doc
+}
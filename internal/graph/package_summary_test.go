@@ -0,0 +1,42 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageSummaries(t *testing.T) {
+	g := NewGraph()
+	g.AddSymbol(&Symbol{ID: "open", Name: "Open", Package: "store", UnitType: "function", Filepath: "internal/store/db.go", Description: "Open opens the database. It creates the file if needed."})
+	g.AddSymbol(&Symbol{ID: "db", Name: "DB", Package: "store", UnitType: "struct", Filepath: "internal/store/types.go", Description: "DB wraps a connection."})
+	g.AddSymbol(&Symbol{ID: "close", Name: "Close", Package: "store", UnitType: "method", Filepath: "internal/store/db.go"})
+	g.AddSymbol(&Symbol{ID: "test", Name: "TestOpen", Package: "store", UnitType: UnitTypeTest, Filepath: "internal/store/db_test.go"})
+	g.AddSymbol(&Symbol{ID: "run", Name: "Run", Package: "cli", UnitType: "function", Filepath: "cmd/cli/run.go"})
+	g.Edges = []Edge{
+		{From: "run", To: "open", Kind: RelationCalls},
+		{From: "run", To: "db", Kind: RelationUsesType},
+		{From: "open", To: "db", Kind: RelationUsesType},
+		{From: "test", To: "open", Kind: RelationTests},
+	}
+
+	summaries := g.PackageSummaries()
+	require.Len(t, summaries, 2)
+	assert.Equal(t, "cmd/cli", summaries[0].Dir)
+	assert.Equal(t, map[string]int{"internal/store": 2}, summaries[0].DependsOn)
+
+	store := summaries[1]
+	assert.Equal(t, PackageSummaryID("internal/store"), store.ID)
+	assert.Equal(t, 3, store.Files)
+	assert.Equal(t, 3, store.Symbols)
+	assert.Equal(t, 1, store.Tests)
+	assert.Equal(t, []string{"DB", "Open"}, store.Exported)
+	assert.Equal(t, []string{"DB wraps a connection.", "Open opens the database."}, store.Responsibilities)
+	assert.Equal(t, map[string]int{"cmd/cli": 2}, store.UsedBy)
+
+	sym := store.Symbol()
+	assert.Equal(t, UnitTypePackageSummary, sym.UnitType)
+	assert.Contains(t, sym.Content, "Exported API: DB, Open")
+	assert.Contains(t, sym.Content, "Used by: cmd/cli")
+}
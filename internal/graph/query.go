@@ -0,0 +1,298 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxAmbiguousMatches bounds the candidates listed for an ambiguous query.
+const maxAmbiguousMatches = 10
+
+// QueryNode is one symbol of a query result. In a tree, Via holds the kinds
+// of the edges that lead to it from its parent; in a path, from the previous
+// step.
+type QueryNode struct {
+	ID       string         `json:"id"`
+	Name     string         `json:"name"`
+	UnitType string         `json:"unit_type"`
+	Filepath string         `json:"filepath,omitempty"`
+	Line     int            `json:"line,omitempty"`
+	Via      []RelationKind `json:"via,omitempty"`
+	// Repeated marks a symbol already expanded elsewhere in the tree.
+	Repeated bool         `json:"repeated,omitempty"`
+	Children []*QueryNode `json:"children,omitempty"`
+}
+
+// FindSymbol resolves a symbol ID, or a name such as Open, store.Open or
+// DB.Close, to a symbol of g. Package nodes are only found by ID.
+func (g *Graph) FindSymbol(query string) (*Symbol, error) {
+	query = strings.TrimSpace(query)
+	if g == nil || query == "" {
+		return nil, fmt.Errorf("no symbol given")
+	}
+	if n, ok := g.Nodes[query]; ok && n.Unit != nil {
+		return n.Unit, nil
+	}
+	qualifier, name := "", query
+	if i := strings.LastIndex(query, "."); i >= 0 {
+		qualifier, name = query[:i], query[i+1:]
+	}
+	var matches []*Symbol
+	for _, n := range g.Nodes {
+		u := n.Unit
+		if u == nil || u.Name != name || u.UnitType == UnitTypePackage {
+			continue
+		}
+		if qualifier != "" && qualifier != u.Package && qualifier != receiverType(u.Metadata.Receiver) {
+			continue
+		}
+		matches = append(matches, u)
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no symbol named %q", query)
+	case 1:
+		return matches[0], nil
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+	ids := make([]string, 0, maxAmbiguousMatches)
+	for _, u := range matches {
+		if len(ids) == maxAmbiguousMatches {
+			ids = append(ids, fmt.Sprintf("and %d more", len(matches)-maxAmbiguousMatches))
+			break
+		}
+		ids = append(ids, u.ID)
+	}
+	return nil, fmt.Errorf("%q is ambiguous; use one of: %s", query, strings.Join(ids, ", "))
+}
+
+// WhoCalls returns the callers of id, and their callers up to depth levels,
+// as a tree rooted at id. Calls through interfaces (may_call) count.
+func (g *Graph) WhoCalls(id string, depth int) *QueryNode {
+	callers := make(map[string][]Edge)
+	for _, e := range g.Edges {
+		if e.Kind == RelationCalls || e.Kind == RelationMayCall {
+			callers[e.To] = append(callers[e.To], e)
+		}
+	}
+	return g.queryTree(id, depth, func(id string) []Edge { return callers[id] }, func(e Edge) string { return e.From })
+}
+
+// DependenciesOf returns what id depends on, through every edge kind but
+// tests and the package layer, up to depth levels as a tree rooted at id.
+func (g *Graph) DependenciesOf(id string, depth int) *QueryNode {
+	deps := g.dependencyEdges()
+	return g.queryTree(id, depth, func(id string) []Edge { return deps[id] }, func(e Edge) string { return e.To })
+}
+
+// PathBetween returns a shortest chain of dependency edges leading from one
+// symbol to another, both ends included, or nil when to is not reachable.
+func (g *Graph) PathBetween(from, to string) []*QueryNode {
+	if g.Nodes[from] == nil || g.Nodes[to] == nil {
+		return nil
+	}
+	deps := g.dependencyEdges()
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		if _, found := prev[to]; found {
+			break
+		}
+		cur := queue[0]
+		queue = queue[1:]
+		for _, e := range deps[cur] {
+			if _, seen := prev[e.To]; seen || g.Nodes[e.To] == nil {
+				continue
+			}
+			prev[e.To] = cur
+			queue = append(queue, e.To)
+		}
+	}
+	if _, ok := prev[to]; !ok {
+		return nil
+	}
+	var ids []string
+	for id := to; id != ""; id = prev[id] {
+		ids = append([]string{id}, ids...)
+	}
+	path := make([]*QueryNode, len(ids))
+	for i, id := range ids {
+		path[i] = g.queryNode(id)
+		if i > 0 {
+			for _, e := range deps[ids[i-1]] {
+				if e.To == id {
+					path[i].Via = appendKind(path[i].Via, e.Kind)
+				}
+			}
+		}
+	}
+	return path
+}
+
+// dependencyEdges indexes the outgoing edges of every symbol, leaving out
+// tests and the package layer, in a stable order.
+func (g *Graph) dependencyEdges() map[string][]Edge {
+	out := make(map[string][]Edge)
+	for _, e := range g.Edges {
+		if e.Kind == RelationTests || IsPackageRelation(e.Kind) || e.From == e.To {
+			continue
+		}
+		out[e.From] = append(out[e.From], e)
+	}
+	for _, edges := range out {
+		sort.Slice(edges, func(i, j int) bool {
+			if edges[i].To != edges[j].To {
+				return edges[i].To < edges[j].To
+			}
+			return edges[i].Kind < edges[j].Kind
+		})
+	}
+	return out
+}
+
+// queryTree walks edges from id breadth-first up to depth levels. A symbol
+// reached through several edges is listed once with all their kinds; one
+// already expanded is listed again but marked Repeated.
+func (g *Graph) queryTree(id string, depth int, edges func(string) []Edge, other func(Edge) string) *QueryNode {
+	if g.Nodes[id] == nil {
+		return nil
+	}
+	if depth <= 0 {
+		depth = 1
+	}
+	root := g.queryNode(id)
+	expanded := map[string]bool{id: true}
+	level := []*QueryNode{root}
+	for d := 0; d < depth && len(level) > 0; d++ {
+		var next []*QueryNode
+		for _, parent := range level {
+			byID := make(map[string]*QueryNode)
+			for _, e := range edges(parent.ID) {
+				oid := other(e)
+				if g.Nodes[oid] == nil || oid == parent.ID {
+					continue
+				}
+				child, ok := byID[oid]
+				if !ok {
+					child = g.queryNode(oid)
+					byID[oid] = child
+					parent.Children = append(parent.Children, child)
+				}
+				child.Via = appendKind(child.Via, e.Kind)
+			}
+			sort.Slice(parent.Children, func(i, j int) bool { return parent.Children[i].ID < parent.Children[j].ID })
+			for _, child := range parent.Children {
+				if expanded[child.ID] {
+					child.Repeated = true
+					continue
+				}
+				expanded[child.ID] = true
+				next = append(next, child)
+			}
+		}
+		level = next
+	}
+	return root
+}
+
+func (g *Graph) queryNode(id string) *QueryNode {
+	u := g.Nodes[id].Unit
+	name := u.Name
+	if recv := receiverType(u.Metadata.Receiver); recv != "" {
+		name = recv + "." + name
+	}
+	if u.Package != "" && u.UnitType != UnitTypePackage && u.UnitType != UnitTypeExternal {
+		name = u.Package + "." + name
+	}
+	return &QueryNode{ID: u.ID, Name: name, UnitType: u.UnitType, Filepath: u.Filepath, Line: u.StartLine}
+}
+
+func appendKind(kinds []RelationKind, kind RelationKind) []RelationKind {
+	for _, k := range kinds {
+		if k == kind {
+			return kinds
+		}
+	}
+	kinds = append(kinds, kind)
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+	return kinds
+}
+
+// receiverType returns the type name of a method receiver such as
+// "(d *DB)".
+func receiverType(recv string) string {
+	fields := strings.Fields(strings.Trim(strings.TrimSpace(recv), "()"))
+	if len(fields) == 0 {
+		return ""
+	}
+	name := strings.TrimPrefix(fields[len(fields)-1], "*")
+	if i := strings.Index(name, "["); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// WriteTree prints n and its children as an indented tree, one symbol per
+// line with the edge kinds that lead to it and its location.
+func (n *QueryNode) WriteTree(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, n.label()); err != nil {
+		return err
+	}
+	return n.writeChildren(w, "")
+}
+
+func (n *QueryNode) writeChildren(w io.Writer, indent string) error {
+	for i, child := range n.Children {
+		branch, next := "├── ", "│   "
+		if i == len(n.Children)-1 {
+			branch, next = "└── ", "    "
+		}
+		if _, err := fmt.Fprintln(w, indent+branch+child.label()); err != nil {
+			return err
+		}
+		if err := child.writeChildren(w, indent+next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WritePath prints a path one step per line, each with the edge kinds
+// leading to it from the previous step.
+func WritePath(w io.Writer, path []*QueryNode) error {
+	for i, n := range path {
+		line := n.label()
+		if i > 0 {
+			line = "  → " + line
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *QueryNode) label() string {
+	var sb strings.Builder
+	if len(n.Via) > 0 {
+		kinds := make([]string, len(n.Via))
+		for i, k := range n.Via {
+			kinds[i] = string(k)
+		}
+		sb.WriteString("[" + strings.Join(kinds, ", ") + "] ")
+	}
+	sb.WriteString(n.Name + " (" + n.UnitType + ")")
+	if n.Filepath != "" {
+		sb.WriteString(" " + n.Filepath)
+		if n.Line > 0 {
+			sb.WriteString(":" + strconv.Itoa(n.Line))
+		}
+	}
+	if n.Repeated {
+		sb.WriteString(" (expanded elsewhere)")
+	}
+	return sb.String()
+}
@@ -0,0 +1,118 @@
+package graph
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func queryGraph() *Graph {
+	g := NewGraph()
+	g.AddSymbol(&Symbol{ID: "main", Name: "main", Package: "main", UnitType: "function", Filepath: "cmd/main.go", StartLine: 5})
+	g.AddSymbol(&Symbol{ID: "open", Name: "Open", Package: "store", UnitType: "function", Filepath: "store/db.go", StartLine: 10})
+	g.AddSymbol(&Symbol{ID: "db", Name: "DB", Package: "store", UnitType: "struct", Filepath: "store/db.go", StartLine: 3})
+	g.AddSymbol(&Symbol{ID: "close", Name: "Close", Package: "store", UnitType: "method", Filepath: "store/db.go", StartLine: 20, Metadata: SymbolMetadata{Receiver: "(d *DB)"}})
+	g.AddSymbol(&Symbol{ID: "cache.close", Name: "Close", Package: "cache", UnitType: "method", Filepath: "cache/cache.go", StartLine: 8, Metadata: SymbolMetadata{Receiver: "(c *Cache)"}})
+	g.AddSymbol(&Symbol{ID: "test", Name: "TestOpen", Package: "store", UnitType: "function", Filepath: "store/db_test.go"})
+	g.Edges = []Edge{
+		{From: "main", To: "open", Kind: RelationCalls},
+		{From: "main", To: "close", Kind: RelationCalls},
+		{From: "open", To: "db", Kind: RelationUsesType},
+		{From: "open", To: "db", Kind: RelationInstantiates},
+		{From: "close", To: "db", Kind: RelationUsesType},
+		{From: "open", To: "close", Kind: RelationMayCall},
+		{From: "test", To: "open", Kind: RelationTests},
+	}
+	return g
+}
+
+func TestFindSymbol(t *testing.T) {
+	g := queryGraph()
+
+	for query, want := range map[string]string{
+		"open":        "open",
+		"Open":        "open",
+		"store.DB":    "db",
+		"DB.Close":    "close",
+		"cache.Close": "cache.close",
+	} {
+		sym, err := g.FindSymbol(query)
+		require.NoError(t, err, query)
+		assert.Equal(t, want, sym.ID, query)
+	}
+
+	_, err := g.FindSymbol("Close")
+	assert.EqualError(t, err, `"Close" is ambiguous; use one of: cache.close, close`)
+	_, err = g.FindSymbol("Missing")
+	assert.EqualError(t, err, `no symbol named "Missing"`)
+}
+
+func TestWhoCalls(t *testing.T) {
+	g := queryGraph()
+
+	tree := g.WhoCalls("close", 2)
+	require.NotNil(t, tree)
+	assert.Equal(t, "store.DB.Close", tree.Name)
+	require.Len(t, tree.Children, 2)
+	assert.Equal(t, "main", tree.Children[0].ID)
+	assert.Equal(t, []RelationKind{RelationCalls}, tree.Children[0].Via)
+	assert.Equal(t, "open", tree.Children[1].ID)
+	assert.Equal(t, []RelationKind{RelationMayCall}, tree.Children[1].Via)
+
+	// main calls Open too, but is already listed one level up.
+	require.Len(t, tree.Children[1].Children, 1)
+	assert.True(t, tree.Children[1].Children[0].Repeated)
+
+	// Depth 1 stops at the direct callers.
+	assert.Empty(t, g.WhoCalls("close", 1).Children[1].Children)
+	assert.Nil(t, g.WhoCalls("missing", 1))
+}
+
+func TestDependenciesOf(t *testing.T) {
+	g := queryGraph()
+
+	tree := g.DependenciesOf("open", 1)
+	require.Len(t, tree.Children, 2)
+	assert.Equal(t, "close", tree.Children[0].ID)
+	assert.Equal(t, "db", tree.Children[1].ID)
+	assert.Equal(t, []RelationKind{RelationInstantiates, RelationUsesType}, tree.Children[1].Via)
+
+	// Tests are not dependencies.
+	assert.Empty(t, g.DependenciesOf("test", 3).Children)
+}
+
+func TestPathBetween(t *testing.T) {
+	g := queryGraph()
+
+	path := g.PathBetween("main", "db")
+	require.Len(t, path, 3)
+	assert.Equal(t, "main", path[0].ID)
+	assert.Empty(t, path[0].Via)
+	assert.Equal(t, "close", path[1].ID)
+	assert.Equal(t, []RelationKind{RelationUsesType}, path[2].Via)
+
+	assert.Len(t, g.PathBetween("open", "open"), 1)
+	assert.Nil(t, g.PathBetween("db", "main"))
+	assert.Nil(t, g.PathBetween("main", "missing"))
+}
+
+func TestQueryNode_Write(t *testing.T) {
+	g := queryGraph()
+
+	var buf bytes.Buffer
+	require.NoError(t, g.WhoCalls("close", 2).WriteTree(&buf))
+	assert.Equal(t, `store.DB.Close (method) store/db.go:20
+├── [calls] main.main (function) cmd/main.go:5
+└── [may_call] store.Open (function) store/db.go:10
+    └── [calls] main.main (function) cmd/main.go:5 (expanded elsewhere)
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, WritePath(&buf, g.PathBetween("main", "db")))
+	assert.Equal(t, `main.main (function) cmd/main.go:5
+  → [calls] store.DB.Close (method) store/db.go:20
+  → [uses_type] store.DB (struct) store/db.go:3
+`, buf.String())
+}
@@ -8,8 +8,29 @@ const (
 	RelationBelongsTo    RelationKind = "belongs_to"
 	RelationInstantiates RelationKind = "instantiates"
 	RelationEmbeds       RelationKind = "embeds"
+	RelationImplements   RelationKind = "implements"
+	RelationTests        RelationKind = "tests"
+	// RelationMayCall links a call through an interface value to each known
+	// implementation of the called method.
+	RelationMayCall RelationKind = "may_call"
+	// RelationContains links a package node to each of its symbols;
+	// RelationImports links a package node to the packages it depends on.
+	RelationContains RelationKind = "contains"
+	RelationImports  RelationKind = "imports"
 )
 
+// UnitTypeTest marks Test/Benchmark/Fuzz/Example functions from _test.go files.
+// Test nodes only carry tests edges and are not documented themselves.
+const UnitTypeTest = "test"
+
+// UnitTypeExternal marks packages of third-party modules that module code
+// references. External nodes have no source and are not documented themselves.
+const UnitTypeExternal = "external"
+
+// UnitTypePackage marks package nodes, one per package directory. They have
+// no source of their own and only carry contains and imports edges.
+const UnitTypePackage = "package"
+
 type UnresolvedReason string
 
 const (
@@ -17,11 +38,40 @@ const (
 	ReasonAmbiguous     UnresolvedReason = "ambiguous"
 	ReasonTypecheckFail UnresolvedReason = "typecheck_failed"
 	ReasonSourceMissing UnresolvedReason = "source_missing"
+	ReasonExternal      UnresolvedReason = "external"
+	// ReasonLowConfidence marks edges dropped by a minimum confidence.
+	ReasonLowConfidence UnresolvedReason = "low_confidence"
 )
 
 type SymbolMetadata struct {
 	Signature string `json:"signature,omitempty"`
 	Receiver  string `json:"receiver,omitempty"`
+	// Fields lists struct fields ("Name Type") or interface method
+	// signatures, so API changes to types can be detected.
+	Fields []string `json:"fields,omitempty"`
+	// Params and Returns are a function's parameters and results;
+	// StructFields are a struct's fields with their tags.
+	Params       []Param `json:"params,omitempty"`
+	Returns      []Param `json:"returns,omitempty"`
+	StructFields []Field `json:"struct_fields,omitempty"`
+	// Module, Version and DocURL describe external nodes.
+	Module  string `json:"module,omitempty"`
+	Version string `json:"version,omitempty"`
+	DocURL  string `json:"doc_url,omitempty"`
+}
+
+// Param is a named, typed function parameter or result. Name is empty for
+// unnamed results.
+type Param struct {
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+}
+
+// Field is a struct field. Name is empty for embedded fields.
+type Field struct {
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+	Tag  string `json:"tag,omitempty"`
 }
 
 // Symbol is the graph-domain node payload.
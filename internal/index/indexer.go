@@ -1,6 +1,7 @@
 package index
 
 import (
+	"context"
 	"docod/internal/crawler"
 	"docod/internal/extractor"
 	"docod/internal/graph"
@@ -9,6 +10,28 @@ import (
 	"os"
 )
 
+// fileCacheVersion is part of every file cache key. Bump it when the
+// extractor starts producing different symbols from the same source.
+const fileCacheVersion = "1"
+
+// FileCache remembers the symbols extracted from each source file, keyed by
+// a hash of its contents, so that scans can skip files that did not change.
+type FileCache interface {
+	// FileHashes returns the cache key of every cached file by path.
+	FileHashes(ctx context.Context) (map[string]string, error)
+	// CachedSymbols returns the symbols cached for path.
+	CachedSymbols(ctx context.Context, path string) ([]*graph.Symbol, error)
+	// ReplaceFileCache stores the symbols of the files in parsed under their
+	// key in hashes and drops every file missing from hashes.
+	ReplaceFileCache(ctx context.Context, hashes map[string]string, parsed map[string][]*graph.Symbol) error
+}
+
+// CacheStats counts the files of a cached build.
+type CacheStats struct {
+	Parsed int
+	Reused int
+}
+
 // Indexer orchestrates codebase indexing and graph management.
 type Indexer struct {
 	crawler *crawler.Crawler
@@ -34,10 +57,74 @@ func (i *Indexer) BuildGraph(root string) (*graph.Graph, error) {
 
 	// Resolve relationships after all units are loaded
 	g.LinkRelations()
+	g.BuildPackageNodes()
 
 	return g, nil
 }
 
+// BuildGraphCached is BuildGraph for repeated scans: files whose contents
+// hash to the key stored in cache keep their cached symbols instead of being
+// parsed again, and the cache is updated to match the tree afterwards. With
+// refresh set every file is parsed and the cache rewritten.
+func (i *Indexer) BuildGraphCached(ctx context.Context, root string, cache FileCache, refresh bool) (*graph.Graph, CacheStats, error) {
+	var stats CacheStats
+	stored := map[string]string{}
+	if !refresh {
+		var err error
+		if stored, err = cache.FileHashes(ctx); err != nil {
+			return nil, stats, fmt.Errorf("failed to read file cache: %w", err)
+		}
+	}
+
+	g := graph.NewGraph()
+	hashes := make(map[string]string)
+	parsed := make(map[string][]*graph.Symbol)
+	var cacheErr error
+	err := i.crawler.ScanProjectCached(root, func(path, hash string) bool {
+		return stored[path] == fileCacheKey(hash)
+	}, func(f crawler.FileScan) {
+		hashes[f.Path] = fileCacheKey(f.Hash)
+		if f.Unchanged {
+			symbols, err := cache.CachedSymbols(ctx, f.Path)
+			if err != nil && cacheErr == nil {
+				cacheErr = fmt.Errorf("failed to read cached symbols of %s: %w", f.Path, err)
+			}
+			for _, s := range symbols {
+				g.AddSymbol(s)
+			}
+			stats.Reused++
+			return
+		}
+		symbols := make([]*graph.Symbol, 0, len(f.Units))
+		for _, unit := range f.Units {
+			s := graph.FromCodeUnit(unit)
+			symbols = append(symbols, s)
+			g.AddSymbol(s)
+		}
+		parsed[f.Path] = symbols
+		stats.Parsed++
+	})
+	if err != nil {
+		return nil, stats, fmt.Errorf("scan failed: %w", err)
+	}
+	if cacheErr != nil {
+		return nil, stats, cacheErr
+	}
+	// Cache the symbols as extracted, before linking adds to them.
+	if err := cache.ReplaceFileCache(ctx, hashes, parsed); err != nil {
+		return nil, stats, fmt.Errorf("failed to update file cache: %w", err)
+	}
+
+	g.LinkRelations()
+	g.BuildPackageNodes()
+
+	return g, stats, nil
+}
+
+func fileCacheKey(hash string) string {
+	return fileCacheVersion + "/" + extractor.SymbolIDVersion + ":" + hash
+}
+
 // SaveGraph persists the graph to a JSON file.
 func (i *Indexer) SaveGraph(g *graph.Graph, path string) error {
 	f, err := os.Create(path)
@@ -0,0 +1,283 @@
+// Package jsonrpc implements JSON-RPC 2.0 over a Content-Length framed
+// stream, as used by the Language Server Protocol and docod's editor backend,
+// or over newline-delimited JSON, as used by the Model Context Protocol.
+package jsonrpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Error codes from the JSON-RPC and LSP specifications.
+const (
+	CodeParseError       = -32700
+	CodeInvalidRequest   = -32600
+	CodeMethodNotFound   = -32601
+	CodeInvalidParams    = -32602
+	CodeInternalError    = -32603
+	CodeRequestCancelled = -32800
+)
+
+// Message is a request, notification or response. Requests carry an ID;
+// notifications do not.
+type Message struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  json.RawMessage  `json:"result,omitempty"`
+	Error   *Error           `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error object. Handlers return it to choose the code.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Errorf returns an Error with the given code.
+func Errorf(code int, format string, args ...any) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// ReadMessage reads one framed message. A malformed body is reported as an
+// *Error with CodeParseError; the stream stays usable.
+func ReadMessage(r *bufio.Reader) (*Message, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid Content-Length %q", header.Get("Content-Length"))
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	var msg Message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, Errorf(CodeParseError, "%v", err)
+	}
+	return &msg, nil
+}
+
+// ReadLine reads one newline-delimited message, skipping blank lines. A
+// malformed line is reported as an *Error with CodeParseError.
+func ReadLine(r *bufio.Reader) (*Message, error) {
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) == 0 {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		var msg Message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			return nil, Errorf(CodeParseError, "%v", err)
+		}
+		return &msg, nil
+	}
+}
+
+// WriteLine writes one message followed by a newline.
+func WriteLine(w io.Writer, msg *Message) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(body, '\n'))
+	return err
+}
+
+// WriteMessage writes one framed message.
+func WriteMessage(w io.Writer, msg *Message) error {
+	msg.JSONRPC = "2.0"
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// UnmarshalParams decodes request params into v, reporting CodeInvalidParams.
+// Missing params leave v unchanged.
+func UnmarshalParams(raw json.RawMessage, v any) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return Errorf(CodeInvalidParams, "invalid params: %v", err)
+	}
+	return nil
+}
+
+// Handler answers one request or notification. The result of a notification
+// is discarded. ctx is cancelled by $/cancelRequest or when the connection
+// closes.
+type Handler func(ctx context.Context, conn *Conn, msg *Message) (any, error)
+
+// Conn serves one client. Notifications are handled in arrival order before
+// the next message is read, so document updates apply before later requests;
+// requests run concurrently and can be cancelled.
+type Conn struct {
+	w       io.Writer
+	writeMu sync.Mutex
+	// lines selects newline-delimited messages over Content-Length frames.
+	lines bool
+
+	mu      sync.Mutex
+	pending map[string]context.CancelFunc
+}
+
+// NewConn returns a connection that writes to w.
+func NewConn(w io.Writer) *Conn {
+	return &Conn{w: w, pending: make(map[string]context.CancelFunc)}
+}
+
+// NewLineConn returns a connection exchanging newline-delimited messages
+// with w, the stdio transport of the Model Context Protocol.
+func NewLineConn(w io.Writer) *Conn {
+	c := NewConn(w)
+	c.lines = true
+	return c
+}
+
+// Notify sends a notification to the client.
+func (c *Conn) Notify(method string, params any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.send(&Message{Method: method, Params: data})
+}
+
+func (c *Conn) send(msg *Message) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.lines {
+		return WriteLine(c.w, msg)
+	}
+	return WriteMessage(c.w, msg)
+}
+
+// Serve reads messages from r until EOF, an exit notification or ctx is done.
+// Requests still running are answered before it returns.
+func (c *Conn) Serve(ctx context.Context, r io.Reader, h Handler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	reader := bufio.NewReader(r)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		read := ReadMessage
+		if c.lines {
+			read = ReadLine
+		}
+		msg, err := read(reader)
+		if err != nil {
+			var rerr *Error
+			if errors.As(err, &rerr) {
+				c.reply(json.RawMessage("null"), nil, rerr)
+				continue
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		switch {
+		case msg.Method == "exit":
+			return nil
+		case msg.Method == "$/cancelRequest":
+			var p struct {
+				ID json.RawMessage `json:"id"`
+			}
+			if UnmarshalParams(msg.Params, &p) == nil {
+				c.cancel(strings.TrimSpace(string(p.ID)))
+			}
+		case msg.Method == "notifications/cancelled":
+			var p struct {
+				RequestID json.RawMessage `json:"requestId"`
+			}
+			if UnmarshalParams(msg.Params, &p) == nil {
+				c.cancel(strings.TrimSpace(string(p.RequestID)))
+			}
+		case msg.ID == nil:
+			if _, err := h(ctx, c, msg); err != nil {
+				log.Printf("Warning: %s: %v", msg.Method, err)
+			}
+		default:
+			reqCtx, reqCancel := context.WithCancel(ctx)
+			key := strings.TrimSpace(string(*msg.ID))
+			c.mu.Lock()
+			c.pending[key] = reqCancel
+			c.mu.Unlock()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer c.cancel(key)
+				result, err := h(reqCtx, c, msg)
+				if err == nil && reqCtx.Err() != nil {
+					err = reqCtx.Err()
+				}
+				c.reply(*msg.ID, result, err)
+			}()
+		}
+	}
+}
+
+func (c *Conn) cancel(id string) {
+	c.mu.Lock()
+	cancel, ok := c.pending[id]
+	delete(c.pending, id)
+	c.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+func (c *Conn) reply(id json.RawMessage, result any, err error) {
+	resp := &Message{ID: &id}
+	if err != nil {
+		var rerr *Error
+		switch {
+		case errors.As(err, &rerr):
+			resp.Error = rerr
+		case errors.Is(err, context.Canceled):
+			resp.Error = Errorf(CodeRequestCancelled, "request cancelled")
+		default:
+			resp.Error = Errorf(CodeInternalError, "%v", err)
+		}
+	} else if data, merr := json.Marshal(result); merr != nil {
+		resp.Error = Errorf(CodeInternalError, "%v", merr)
+	} else {
+		resp.Result = data
+	}
+	if err := c.send(resp); err != nil {
+		log.Printf("Warning: jsonrpc write failed: %v", err)
+	}
+}
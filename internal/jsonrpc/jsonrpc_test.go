@@ -0,0 +1,93 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func frame(req string) string {
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(req), req)
+}
+
+func TestConn_CancelRequest(t *testing.T) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	started := make(chan struct{})
+	handler := func(ctx context.Context, conn *Conn, msg *Message) (any, error) {
+		switch msg.Method {
+		case "slow":
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		case "echo":
+			return json.RawMessage(msg.Params), nil
+		}
+		return nil, Errorf(CodeMethodNotFound, "method not supported: %s", msg.Method)
+	}
+	done := make(chan error, 1)
+	go func() { done <- NewConn(outW).Serve(context.Background(), inR, handler) }()
+	out := bufio.NewReader(outR)
+
+	_, err := io.WriteString(inW, frame(`{"jsonrpc":"2.0","id":1,"method":"slow"}`))
+	require.NoError(t, err)
+	<-started
+	_, err = io.WriteString(inW, frame(`{"jsonrpc":"2.0","id":2,"method":"echo","params":{"a":1}}`))
+	require.NoError(t, err)
+
+	resp, err := ReadMessage(out)
+	require.NoError(t, err)
+	assert.Equal(t, "2", string(*resp.ID), "other requests are answered while one is running")
+	assert.JSONEq(t, `{"a":1}`, string(resp.Result))
+
+	_, err = io.WriteString(inW, frame(`{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":1}}`))
+	require.NoError(t, err)
+	resp, err = ReadMessage(out)
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(*resp.ID))
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, CodeRequestCancelled, resp.Error.Code)
+
+	_, err = io.WriteString(inW, frame(`{"jsonrpc":"2.0","id":3,"method":"nope"}`)+frame(`{"jsonrpc":"2.0","method":"exit"}`))
+	require.NoError(t, err)
+	resp, err = ReadMessage(out)
+	require.NoError(t, err)
+	assert.Equal(t, CodeMethodNotFound, resp.Error.Code)
+	require.NoError(t, <-done)
+}
+
+func TestLineConn_NewlineDelimited(t *testing.T) {
+	handler := func(ctx context.Context, conn *Conn, msg *Message) (any, error) {
+		return json.RawMessage(msg.Params), nil
+	}
+	in := "{\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"echo\",\"params\":[1]}\n\n" +
+		"not json\n" +
+		"{\"jsonrpc\":\"2.0\",\"id\":\"b\",\"method\":\"echo\",\"params\":{\"x\":true}}"
+	var out bytes.Buffer
+	require.NoError(t, NewLineConn(&out).Serve(context.Background(), strings.NewReader(in), handler))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	byID := make(map[string]*Message)
+	for _, line := range lines {
+		msg, err := ReadLine(bufio.NewReader(strings.NewReader(line)))
+		require.NoError(t, err)
+		id := "null"
+		if msg.ID != nil {
+			id = string(*msg.ID)
+		}
+		byID[id] = msg
+	}
+	assert.JSONEq(t, `[1]`, string(byID["1"].Result))
+	assert.JSONEq(t, `{"x":true}`, string(byID[`"b"`].Result), "the last line needs no newline")
+	require.NotNil(t, byID["null"].Error)
+	assert.Equal(t, CodeParseError, byID["null"].Error.Code)
+}
@@ -0,0 +1,31 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+)
+
+// QuestionAnswerer is an optional Summarizer capability that answers a
+// question about the codebase from retrieved chunks, citing their
+// ChunkLocation in brackets.
+type QuestionAnswerer interface {
+	AnswerQuestion(ctx context.Context, question string, evidence []SearchChunk) (string, error)
+}
+
+// ChunkLocation renders where a chunk is defined as file:start-end, falling
+// back to the file or chunk ID when line numbers are unknown.
+func ChunkLocation(c SearchChunk) string {
+	for _, src := range c.Sources {
+		if src.Relation != "primary" || src.FilePath == "" {
+			continue
+		}
+		if src.StartLine > 0 && src.EndLine > src.StartLine {
+			return fmt.Sprintf("%s:%d-%d", src.FilePath, src.StartLine, src.EndLine)
+		}
+		if src.StartLine > 0 {
+			return fmt.Sprintf("%s:%d", src.FilePath, src.StartLine)
+		}
+		return src.FilePath
+	}
+	return chunkSourcePath(c)
+}
@@ -0,0 +1,238 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	anthropicAPIVersion = "2023-06-01"
+	anthropicRetries    = 4
+	// anthropicRetryDelay doubles after every retried attempt unless the API
+	// sends retry-after.
+	anthropicRetryDelay    = 2 * time.Second
+	anthropicMaxRetryDelay = 60 * time.Second
+	// statusOverloaded is returned by the Messages API when it is overloaded.
+	statusOverloaded = 529
+)
+
+// AnthropicSummarizer drafts documentation with Claude models through the
+// Anthropic Messages API.
+type AnthropicSummarizer struct {
+	client        *http.Client
+	apiKey        string
+	model         string
+	endpoint      string
+	maxTokens     int
+	retryDelay    time.Duration
+	promptBuilder *PromptBuilder
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	Messages    []anthropicMessage `json:"messages"`
+	Temperature float64            `json:"temperature,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+type anthropicErrorBody struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// NewAnthropicSummarizer calls the Messages API at baseURL, the API root or
+// the full /v1/messages URL; empty uses api.anthropic.com. maxTokens bounds
+// each response; 0 uses the default output reserve.
+func NewAnthropicSummarizer(apiKey, model, baseURL string, maxTokens int) *AnthropicSummarizer {
+	endpoint := strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	switch {
+	case endpoint == "":
+		endpoint = "https://api.anthropic.com/v1/messages"
+	case strings.HasSuffix(endpoint, "/messages"):
+	case strings.HasSuffix(endpoint, "/v1"):
+		endpoint += "/messages"
+	default:
+		endpoint += "/v1/messages"
+	}
+	if maxTokens <= 0 {
+		maxTokens = defaultReserveOutput
+	}
+	return &AnthropicSummarizer{
+		client: &http.Client{
+			Timeout: 180 * time.Second,
+		},
+		apiKey:        apiKey,
+		model:         model,
+		endpoint:      endpoint,
+		maxTokens:     maxTokens,
+		retryDelay:    anthropicRetryDelay,
+		promptBuilder: NewPromptBuilder(model),
+	}
+}
+
+func (s *AnthropicSummarizer) SummarizeFullDoc(ctx context.Context, archChunks, featChunks, confChunks []SearchChunk) (string, error) {
+	prompt, pack := s.promptBuilder.BuildFullDocPrompt(archChunks, featChunks, confChunks)
+	recordPack(ctx, pack)
+	return s.generate(ctx, prompt)
+}
+
+func (s *AnthropicSummarizer) UpdateDocSection(ctx context.Context, currentContent string, relevantCode []SearchChunk) (string, error) {
+	prompt, pack := s.promptBuilder.BuildUpdateDocPrompt(currentContent, relevantCode, SectionStyleFrom(ctx))
+	recordPack(ctx, pack)
+	return s.generate(ctx, prompt)
+}
+
+func (s *AnthropicSummarizer) RenderSectionFromDraft(ctx context.Context, draftJSON string, relevantCode []SearchChunk) (string, error) {
+	prompt, pack := s.promptBuilder.BuildRenderFromDraftPrompt(draftJSON, relevantCode, SectionStyleFrom(ctx))
+	recordPack(ctx, pack)
+	return s.generate(ctx, prompt)
+}
+
+func (s *AnthropicSummarizer) GenerateNewSection(ctx context.Context, relevantCode []SearchChunk) (string, error) {
+	prompt, pack := s.promptBuilder.BuildNewSectionPrompt(relevantCode, SectionStyleFrom(ctx))
+	recordPack(ctx, pack)
+	return s.generate(ctx, prompt)
+}
+
+// SummarizeUnit implements UnitSummarizer.
+func (s *AnthropicSummarizer) SummarizeUnit(ctx context.Context, kind, name, content string) (string, error) {
+	prompt := s.promptBuilder.BuildUnitSummaryPrompt(kind, name, content)
+	return s.generate(ctx, prompt)
+}
+
+// AnswerQuestion implements QuestionAnswerer.
+func (s *AnthropicSummarizer) AnswerQuestion(ctx context.Context, question string, evidence []SearchChunk) (string, error) {
+	prompt, pack := s.promptBuilder.BuildAnswerPrompt(question, evidence)
+	recordPack(ctx, pack)
+	return s.generate(ctx, prompt)
+}
+
+func (s *AnthropicSummarizer) FindInsertionPoint(ctx context.Context, toc []string, newContent string) (int, error) {
+	prompt := s.promptBuilder.BuildInsertionPointPrompt(toc, newContent)
+	resp, err := s.generate(ctx, prompt)
+	if err != nil {
+		return -1, err
+	}
+	val := strings.TrimSpace(resp)
+	n, err := strconv.Atoi(val)
+	if err == nil {
+		return n, nil
+	}
+	for _, token := range strings.Fields(val) {
+		if n, err := strconv.Atoi(token); err == nil {
+			return n, nil
+		}
+	}
+	return -1, fmt.Errorf("failed to parse index from LLM response: %s", resp)
+}
+
+// generate sends prompt as one user message. Rate limits, overload and
+// server errors are retried with exponential backoff, honouring retry-after.
+func (s *AnthropicSummarizer) generate(ctx context.Context, prompt string) (string, error) {
+	if strings.TrimSpace(s.apiKey) == "" {
+		return "", fmt.Errorf("anthropic api key is required")
+	}
+	if strings.TrimSpace(s.model) == "" {
+		return "", fmt.Errorf("anthropic model is required")
+	}
+	body, err := json.Marshal(anthropicRequest{
+		Model:       s.model,
+		MaxTokens:   s.maxTokens,
+		Messages:    []anthropicMessage{{Role: "user", Content: prompt}},
+		Temperature: 0.1,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var lastErr error
+	delay := s.retryDelay
+	for attempt := 0; attempt <= anthropicRetries; attempt++ {
+		if attempt > 0 {
+			if !waitOrCancel(ctx, delay) {
+				return "", ctx.Err()
+			}
+			delay = min(delay*2, anthropicMaxRetryDelay)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("x-api-key", s.apiKey)
+		req.Header.Set("anthropic-version", anthropicAPIVersion)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := s.client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+		raw, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return "", err
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			msg := strings.TrimSpace(string(raw))
+			var errBody anthropicErrorBody
+			if json.Unmarshal(raw, &errBody) == nil && strings.TrimSpace(errBody.Error.Message) != "" {
+				msg = strings.TrimSpace(errBody.Error.Message)
+			}
+			lastErr = fmt.Errorf("anthropic messages request failed (%d): %s", resp.StatusCode, msg)
+			if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != statusOverloaded && resp.StatusCode < 500 {
+				return "", lastErr
+			}
+			if secs, err := strconv.Atoi(strings.TrimSpace(resp.Header.Get("retry-after"))); err == nil && secs > 0 {
+				delay = min(time.Duration(secs)*time.Second, anthropicMaxRetryDelay)
+			}
+			continue
+		}
+
+		var parsed anthropicResponse
+		if err := json.Unmarshal(raw, &parsed); err != nil {
+			return "", err
+		}
+		var text strings.Builder
+		for _, block := range parsed.Content {
+			if block.Type == "text" {
+				text.WriteString(block.Text)
+			}
+		}
+		s.promptBuilder.recordUsage(ctx, "anthropic", s.model, prompt, text.String(), parsed.Usage.InputTokens, parsed.Usage.OutputTokens)
+		if strings.TrimSpace(text.String()) == "" {
+			return "No analysis available.", nil
+		}
+		return cleanMarkdownOutput(text.String()), nil
+	}
+	return "", lastErr
+}
@@ -0,0 +1,75 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnthropicSummarizer_SendsMessagesRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "key", r.Header.Get("x-api-key"))
+		assert.Equal(t, anthropicAPIVersion, r.Header.Get("anthropic-version"))
+		var req anthropicRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "claude-sonnet-4-5", req.Model)
+		assert.Equal(t, 1024, req.MaxTokens)
+		require.Len(t, req.Messages, 1)
+		assert.Equal(t, "user", req.Messages[0].Role)
+		w.Write([]byte(`{"content":[{"type":"text","text":"Opens "},{"type":"text","text":"the store."}],"stop_reason":"end_turn"}`))
+	}))
+	defer srv.Close()
+
+	s := NewAnthropicSummarizer("key", "claude-sonnet-4-5", srv.URL, 1024)
+	out, err := s.AnswerQuestion(context.Background(), "What does Open do?", []SearchChunk{{ID: "a", Name: "Open"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Opens the store.", out)
+}
+
+func TestAnthropicSummarizer_RetriesOverloadAndRateLimits(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(statusOverloaded)
+			w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Write([]byte(`{"content":[{"type":"text","text":"ok"}]}`))
+		}
+	}))
+	defer srv.Close()
+
+	s := NewAnthropicSummarizer("key", "claude-haiku-4-5", srv.URL+"/v1", 0)
+	s.retryDelay = time.Millisecond
+	out, err := s.SummarizeUnit(context.Background(), "function", "Open", "func Open() {}")
+	require.NoError(t, err)
+	assert.Equal(t, "ok", out)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestAnthropicSummarizer_DoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens: too large"}}`))
+	}))
+	defer srv.Close()
+
+	s := NewAnthropicSummarizer("key", "claude-haiku-4-5", srv.URL+"/v1/messages", 0)
+	s.retryDelay = time.Millisecond
+	_, err := s.SummarizeUnit(context.Background(), "function", "Open", "func Open() {}")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "(400): max_tokens: too large")
+	assert.Equal(t, int32(1), calls.Load())
+}
@@ -0,0 +1,59 @@
+package knowledge
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AzureOpenAIAPIVersion is the api-version sent when none is configured.
+const AzureOpenAIAPIVersion = "2024-10-21"
+
+// azureDeploymentURL builds the data-plane URL of one deployment operation,
+// e.g. https://res.openai.azure.com/openai/deployments/gpt-4o/chat/completions?api-version=2024-10-21.
+// endpoint is the resource URL; a trailing /openai is accepted.
+func azureDeploymentURL(endpoint, deployment, operation, apiVersion string) string {
+	base := strings.TrimRight(strings.TrimSpace(endpoint), "/")
+	base = strings.TrimSuffix(base, "/openai")
+	if strings.TrimSpace(apiVersion) == "" {
+		apiVersion = AzureOpenAIAPIVersion
+	}
+	return fmt.Sprintf("%s/openai/deployments/%s/%s?api-version=%s",
+		base, url.PathEscape(strings.TrimSpace(deployment)), operation, url.QueryEscape(strings.TrimSpace(apiVersion)))
+}
+
+// NewAzureOpenAIEmbedder calls the embeddings operation of an Azure OpenAI
+// deployment, authenticating with the resource key in the api-key header.
+func NewAzureOpenAIEmbedder(apiKey, endpoint, deployment, apiVersion string, dim int) *OpenAIEmbedder {
+	e := NewOpenAIEmbedder(apiKey, deployment, dim, "")
+	e.endpoint = azureDeploymentURL(endpoint, deployment, "embeddings", apiVersion)
+	e.azure = true
+	return e
+}
+
+// NewAzureOpenAISummarizer calls the chat completions operation of an Azure
+// OpenAI deployment. model names the deployed model for prompt budgeting;
+// deployment defaults to it.
+func NewAzureOpenAISummarizer(apiKey, endpoint, deployment, model, apiVersion string) *OpenAISummarizer {
+	if strings.TrimSpace(deployment) == "" {
+		deployment = model
+	}
+	if strings.TrimSpace(model) == "" {
+		model = deployment
+	}
+	s := NewOpenAISummarizer(apiKey, model, "")
+	s.endpoint = azureDeploymentURL(endpoint, deployment, "chat/completions", apiVersion)
+	s.azure = true
+	return s
+}
+
+// setOpenAIAuth authenticates an OpenAI-compatible request: Azure resource
+// keys go in the api-key header, everything else is a bearer token.
+func setOpenAIAuth(req *http.Request, apiKey string, azure bool) {
+	if azure {
+		req.Header.Set("api-key", apiKey)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+}
@@ -0,0 +1,49 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureOpenAI_AddressesDeployments(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "res-key", r.Header.Get("api-key"))
+		assert.Empty(t, r.Header.Get("Authorization"))
+		switch r.URL.Path {
+		case "/openai/deployments/embed-small/embeddings":
+			assert.Equal(t, AzureOpenAIAPIVersion, r.URL.Query().Get("api-version"))
+			w.Write([]byte(`{"data":[{"index":0,"embedding":[0.5,0.25]}]}`))
+		case "/openai/deployments/gpt-4o-docs/chat/completions":
+			assert.Equal(t, "2025-01-01-preview", r.URL.Query().Get("api-version"))
+			var req openAIChatRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			assert.Equal(t, "gpt-4o", req.Model)
+			w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"Opens the store."}}]}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	embedder, err := NewEmbedder(context.Background(), EmbedderOptions{Provider: "azure-openai", APIKey: "res-key", Model: "embed-small", BaseURL: srv.URL + "/openai/"})
+	require.NoError(t, err)
+	vecs, err := embedder.Embed(context.Background(), []string{"func Open() {}"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]float32{{0.5, 0.25}}, vecs)
+
+	summarizer, err := NewSummarizer(context.Background(), SummarizerOptions{Provider: "azure-openai", APIKey: "res-key", Model: "gpt-4o", BaseURL: srv.URL, Deployment: "gpt-4o-docs", APIVersion: "2025-01-01-preview"})
+	require.NoError(t, err)
+	out, err := summarizer.(QuestionAnswerer).AnswerQuestion(context.Background(), "What does Open do?", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Opens the store.", out)
+
+	_, err = NewSummarizer(context.Background(), SummarizerOptions{Provider: "azure-openai", APIKey: "res-key", Model: "gpt-4o"})
+	assert.ErrorContains(t, err, "requires an endpoint")
+}
@@ -0,0 +1,156 @@
+package knowledge
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters and the reciprocal rank fusion constant.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	rrfK   = 60
+	// nameFieldWeight repeats name tokens so identifier hits outrank mentions.
+	nameFieldWeight = 3
+)
+
+// BM25Rank scores chunks against a keyword query and returns the topK
+// matches with Score set to the BM25 score. Chunks without any query term are
+// dropped.
+func BM25Rank(chunks []SearchChunk, query string, topK int) []VectorItem {
+	terms := uniqueTokens(tokenizeIdentifiers(query))
+	if len(terms) == 0 || len(chunks) == 0 {
+		return nil
+	}
+
+	docs := make([]map[string]int, len(chunks))
+	lengths := make([]int, len(chunks))
+	df := make(map[string]int)
+	total := 0
+	for i, c := range chunks {
+		tf := make(map[string]int)
+		for _, tok := range tokenizeIdentifiers(c.Name) {
+			tf[tok] += nameFieldWeight
+			lengths[i] += nameFieldWeight
+		}
+		for _, tok := range tokenizeIdentifiers(c.Signature + " " + c.Description + " " + c.Content) {
+			tf[tok]++
+			lengths[i]++
+		}
+		for tok := range tf {
+			df[tok]++
+		}
+		docs[i] = tf
+		total += lengths[i]
+	}
+	avgLen := float64(total) / float64(len(chunks))
+	if avgLen == 0 {
+		avgLen = 1
+	}
+
+	n := float64(len(chunks))
+	var out []VectorItem
+	for i, tf := range docs {
+		score := 0.0
+		for _, term := range terms {
+			f := float64(tf[term])
+			if f == 0 {
+				continue
+			}
+			idf := math.Log(1 + (n-float64(df[term])+0.5)/(float64(df[term])+0.5))
+			norm := f + bm25K1*(1-bm25B+bm25B*float64(lengths[i])/avgLen)
+			score += idf * f * (bm25K1 + 1) / norm
+		}
+		if score > 0 {
+			out = append(out, VectorItem{Chunk: chunks[i], Score: float32(score)})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Chunk.ID < out[j].Chunk.ID
+	})
+	if topK > 0 && len(out) > topK {
+		out = out[:topK]
+	}
+	return out
+}
+
+// fuseRRF merges ranked lists with reciprocal rank fusion. Scores are
+// normalized to 0..1 so graph proximity boosts keep their weight.
+func fuseRRF(lists ...[]VectorItem) []VectorItem {
+	scores := make(map[string]float64)
+	items := make(map[string]VectorItem)
+	var order []string
+	for _, list := range lists {
+		for rank, item := range list {
+			id := item.Chunk.ID
+			if _, ok := items[id]; !ok {
+				items[id] = item
+				order = append(order, id)
+			}
+			scores[id] += 1 / float64(rrfK+rank+1)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool { return scores[order[i]] > scores[order[j]] })
+	out := make([]VectorItem, 0, len(order))
+	for _, id := range order {
+		item := items[id]
+		item.Score = float32(scores[id] / scores[order[0]])
+		out = append(out, item)
+	}
+	return out
+}
+
+// tokenizeIdentifiers lowercases text into word tokens. Identifiers are kept
+// whole and also split on camelCase and digit boundaries, so
+// "IndexIncrementalWithOptions" matches both exactly and by its parts.
+func tokenizeIdentifiers(text string) []string {
+	var out []string
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		lower := strings.ToLower(word)
+		out = append(out, lower)
+		parts := splitCamel(word)
+		if len(parts) > 1 {
+			for _, p := range parts {
+				out = append(out, strings.ToLower(p))
+			}
+		}
+	}
+	return out
+}
+
+func splitCamel(word string) []string {
+	runes := []rune(word)
+	var parts []string
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		prev, cur := runes[i-1], runes[i]
+		boundary := unicode.IsLower(prev) && unicode.IsUpper(cur) ||
+			unicode.IsLetter(prev) != unicode.IsLetter(cur) ||
+			// "HTTPServer" -> "HTTP", "Server"
+			i+1 < len(runes) && unicode.IsUpper(prev) && unicode.IsUpper(cur) && unicode.IsLower(runes[i+1])
+		if boundary {
+			parts = append(parts, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(parts, string(runes[start:]))
+}
+
+func uniqueTokens(tokens []string) []string {
+	seen := make(map[string]bool, len(tokens))
+	out := tokens[:0]
+	for _, t := range tokens {
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package knowledge
+
+import (
+	"context"
+	"testing"
+
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenizeIdentifiers(t *testing.T) {
+	assert.Equal(t,
+		[]string{"indexincrementalwithoptions", "index", "incremental", "with", "options", "httpserver", "http", "server", "v2", "v", "2"},
+		tokenizeIdentifiers("IndexIncrementalWithOptions(HTTPServer, v2)"))
+}
+
+func TestBM25Rank_ExactIdentifierFirst(t *testing.T) {
+	chunks := []SearchChunk{
+		{ID: "a", Name: "IndexIncremental", Description: "Updates the index incrementally with default options."},
+		{ID: "b", Name: "IndexIncrementalWithOptions", Description: "Updates embeddings with budget controls."},
+		{ID: "c", Name: "Search", Description: "Finds chunks."},
+	}
+	hits := BM25Rank(chunks, "IndexIncrementalWithOptions", 10)
+	require.Len(t, hits, 2)
+	assert.Equal(t, "b", hits[0].Chunk.ID)
+	assert.Equal(t, "a", hits[1].Chunk.ID)
+}
+
+func TestEngine_SearchByText_FusesKeywordHits(t *testing.T) {
+	idx := NewMemoryIndex()
+	// Zero embeddings make vector scores uninformative.
+	require.NoError(t, idx.Add(context.Background(), []VectorItem{
+		{Chunk: SearchChunk{ID: "a", Name: "Search"}, Embedding: []float32{0, 0}},
+		{Chunk: SearchChunk{ID: "b", Name: "IndexAll"}, Embedding: []float32{0, 0}},
+		{Chunk: SearchChunk{ID: "c", Name: "IndexIncrementalWithOptions"}, Embedding: []float32{0, 0}},
+	}))
+	engine := NewEngine(graph.NewGraph(), &mockEmbedder{dim: 2}, idx)
+
+	results, err := engine.SearchByText(context.Background(), "IndexIncrementalWithOptions", 2, "")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "c", results[0].ID)
+}
+
+func TestFuseRRF(t *testing.T) {
+	vector := []VectorItem{{Chunk: SearchChunk{ID: "x"}}, {Chunk: SearchChunk{ID: "y"}}}
+	keyword := []VectorItem{{Chunk: SearchChunk{ID: "y"}}, {Chunk: SearchChunk{ID: "z"}}}
+	fused := fuseRRF(vector, keyword)
+	require.Len(t, fused, 3)
+	assert.Equal(t, "y", fused[0].Chunk.ID)
+	assert.Equal(t, float32(1), fused[0].Score)
+}
@@ -0,0 +1,247 @@
+package knowledge
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// Chunking strategies for splitting long symbols into segment chunks.
+const (
+	ChunkingLines  = "lines"  // fixed line windows with overlap
+	ChunkingTokens = "tokens" // token windows snapped to line boundaries
+	ChunkingAST    = "ast"    // statement/block boundaries (Go only; falls back to lines)
+	ChunkingNone   = "none"   // never segment
+)
+
+// ChunkingOptions controls symbol segmentation. Zero fields take defaults;
+// a negative overlap disables overlap.
+type ChunkingOptions struct {
+	Strategy      string
+	WindowLines   int
+	OverlapLines  int
+	WindowTokens  int
+	OverlapTokens int
+	MaxSegments   int
+	// Languages overrides Strategy per symbol language (e.g. "go": "ast").
+	Languages map[string]string
+}
+
+// defaultChunkingLanguages are applied unless the config overrides them.
+var defaultChunkingLanguages = map[string]string{
+	"go": ChunkingAST,
+}
+
+// DefaultChunkingOptions returns the built-in segmentation settings.
+func DefaultChunkingOptions() ChunkingOptions {
+	return ChunkingOptions{}.withDefaults()
+}
+
+func (o ChunkingOptions) withDefaults() ChunkingOptions {
+	if strings.TrimSpace(o.Strategy) == "" {
+		o.Strategy = ChunkingLines
+	}
+	if o.WindowLines <= 0 {
+		o.WindowLines = 40
+	}
+	if o.OverlapLines == 0 {
+		o.OverlapLines = 8
+	}
+	if o.OverlapLines < 0 || o.OverlapLines >= o.WindowLines {
+		o.OverlapLines = 0
+	}
+	if o.WindowTokens <= 0 {
+		o.WindowTokens = 256
+	}
+	if o.OverlapTokens == 0 {
+		o.OverlapTokens = 32
+	}
+	if o.OverlapTokens < 0 || o.OverlapTokens >= o.WindowTokens {
+		o.OverlapTokens = 0
+	}
+	if o.MaxSegments <= 0 {
+		o.MaxSegments = 3
+	}
+	langs := make(map[string]string, len(defaultChunkingLanguages)+len(o.Languages))
+	for lang, s := range defaultChunkingLanguages {
+		langs[lang] = s
+	}
+	for lang, s := range o.Languages {
+		langs[strings.ToLower(strings.TrimSpace(lang))] = strings.ToLower(strings.TrimSpace(s))
+	}
+	o.Languages = langs
+	return o
+}
+
+// StrategyFor returns the strategy used for symbols of the given language.
+func (o ChunkingOptions) StrategyFor(language string) string {
+	if s, ok := o.Languages[strings.ToLower(language)]; ok && s != "" {
+		return s
+	}
+	return strings.ToLower(o.Strategy)
+}
+
+// lineSpan is a half-open [start, end) range of zero-based content lines.
+type lineSpan struct {
+	start, end int
+}
+
+// segmentSpans splits content into at most MaxSegments line spans, or returns
+// nil when the content fits in one chunk.
+func (o ChunkingOptions) segmentSpans(content, language string) []lineSpan {
+	lines := strings.Split(content, "\n")
+	switch o.StrategyFor(language) {
+	case ChunkingNone:
+		return nil
+	case ChunkingTokens:
+		if len(strings.Fields(content)) <= o.WindowTokens {
+			return nil
+		}
+		return o.tokenSpans(lines)
+	case ChunkingAST:
+		if lineCount(content) <= o.WindowLines+5 {
+			return nil
+		}
+		if spans, ok := o.astSpans(content, len(lines)); ok {
+			return spans
+		}
+		return o.lineSpans(lines)
+	default:
+		if lineCount(content) <= o.WindowLines+5 {
+			return nil
+		}
+		return o.lineSpans(lines)
+	}
+}
+
+func (o ChunkingOptions) lineSpans(lines []string) []lineSpan {
+	step := o.WindowLines - o.OverlapLines
+	if step <= 0 {
+		step = o.WindowLines
+	}
+	var spans []lineSpan
+	for start := 0; start < len(lines) && len(spans) < o.MaxSegments; start += step {
+		spans = append(spans, lineSpan{start: start, end: min(start+o.WindowLines, len(lines))})
+	}
+	return spans
+}
+
+// tokenSpans packs whole lines into windows of about WindowTokens
+// whitespace-separated tokens, starting each window OverlapTokens back.
+func (o ChunkingOptions) tokenSpans(lines []string) []lineSpan {
+	counts := make([]int, len(lines))
+	for i, l := range lines {
+		counts[i] = len(strings.Fields(l))
+	}
+	var spans []lineSpan
+	for start := 0; start < len(lines) && len(spans) < o.MaxSegments; {
+		end, tokens := start, 0
+		for end < len(lines) && (tokens == 0 || tokens+counts[end] <= o.WindowTokens) {
+			tokens += counts[end]
+			end++
+		}
+		spans = append(spans, lineSpan{start: start, end: end})
+		if end >= len(lines) {
+			break
+		}
+		next, back := end, 0
+		for next > start+1 && back+counts[next-1] <= o.OverlapTokens {
+			next--
+			back += counts[next]
+		}
+		start = next
+	}
+	return spans
+}
+
+// astSpans cuts a Go function at statement boundaries, descending into
+// blocks and branches whose statements do not fit a window on their own.
+func (o ChunkingOptions) astSpans(content string, totalLines int) ([]lineSpan, bool) {
+	const header = "package p\n"
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", header+content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, false
+	}
+	var body *ast.BlockStmt
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+			body = fn.Body
+			break
+		}
+	}
+	if body == nil || len(body.List) == 0 {
+		return nil, false
+	}
+
+	span := func(n ast.Node) lineSpan {
+		// Lines are 1-based and shifted by the synthetic header line.
+		return lineSpan{start: fset.Position(n.Pos()).Line - 2, end: fset.Position(n.End()).Line - 1}
+	}
+	var units []lineSpan
+	var collect func(stmts []ast.Stmt)
+	collect = func(stmts []ast.Stmt) {
+		for _, st := range stmts {
+			s := span(st)
+			children := childStatements(st)
+			if s.end-s.start <= o.WindowLines || len(children) == 0 {
+				units = append(units, s)
+				continue
+			}
+			collect(children)
+		}
+	}
+	collect(body.List)
+
+	var spans []lineSpan
+	cur := units[0]
+	for _, u := range units[1:] {
+		if u.end-cur.start <= o.WindowLines {
+			cur.end = max(cur.end, u.end)
+			continue
+		}
+		spans = append(spans, cur)
+		cur = u
+	}
+	spans = append(spans, cur)
+
+	for i := range spans {
+		spans[i].start = max(spans[i].start, 0)
+		spans[i].end = min(spans[i].end, totalLines)
+	}
+	if len(spans) > o.MaxSegments {
+		spans = spans[:o.MaxSegments]
+	}
+	return spans, true
+}
+
+func childStatements(st ast.Stmt) []ast.Stmt {
+	switch s := st.(type) {
+	case *ast.BlockStmt:
+		return s.List
+	case *ast.IfStmt:
+		out := append([]ast.Stmt(nil), s.Body.List...)
+		if s.Else != nil {
+			out = append(out, s.Else)
+		}
+		return out
+	case *ast.ForStmt:
+		return s.Body.List
+	case *ast.RangeStmt:
+		return s.Body.List
+	case *ast.SwitchStmt:
+		return s.Body.List
+	case *ast.TypeSwitchStmt:
+		return s.Body.List
+	case *ast.SelectStmt:
+		return s.Body.List
+	case *ast.CaseClause:
+		return s.Body
+	case *ast.CommClause:
+		return s.Body
+	case *ast.LabeledStmt:
+		return []ast.Stmt{s.Stmt}
+	}
+	return nil
+}
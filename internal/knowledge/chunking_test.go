@@ -0,0 +1,73 @@
+package knowledge
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkingOptions_LinesDefault(t *testing.T) {
+	opts := DefaultChunkingOptions()
+	content := strings.Repeat("line()\n", 70)
+
+	spans := opts.segmentSpans(content, "")
+	require.Len(t, spans, 3)
+	assert.Equal(t, lineSpan{start: 0, end: 40}, spans[0])
+	assert.Equal(t, lineSpan{start: 32, end: 71}, spans[1])
+	assert.Nil(t, opts.segmentSpans(strings.Repeat("x()\n", 20), ""))
+}
+
+func TestChunkingOptions_None(t *testing.T) {
+	opts := ChunkingOptions{Strategy: ChunkingNone}.withDefaults()
+	assert.Nil(t, opts.segmentSpans(strings.Repeat("line()\n", 200), "python"))
+}
+
+func TestChunkingOptions_TokenWindows(t *testing.T) {
+	opts := ChunkingOptions{Strategy: ChunkingTokens, WindowTokens: 10, OverlapTokens: 2, MaxSegments: 10}.withDefaults()
+	// 12 lines of 2 tokens each.
+	content := strings.TrimSuffix(strings.Repeat("a b\n", 12), "\n")
+
+	spans := opts.segmentSpans(content, "")
+	require.NotEmpty(t, spans)
+	assert.Equal(t, lineSpan{start: 0, end: 5}, spans[0])
+	assert.Equal(t, lineSpan{start: 4, end: 9}, spans[1], "next window starts one line back for overlap")
+	assert.Equal(t, 12, spans[len(spans)-1].end)
+}
+
+func TestChunkingOptions_ASTRespectsBlocks(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("func Long() {\n")
+	for i := 0; i < 3; i++ {
+		fmt.Fprintf(&b, "\tif cond%d {\n", i)
+		for j := 0; j < 15; j++ {
+			b.WriteString("\t\tstep()\n")
+		}
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("}\n")
+	content := b.String()
+
+	opts := ChunkingOptions{WindowLines: 20}.withDefaults()
+	require.Equal(t, ChunkingAST, opts.StrategyFor("go"))
+
+	spans := opts.segmentSpans(content, "go")
+	require.Len(t, spans, 3)
+	lines := strings.Split(content, "\n")
+	for _, s := range spans {
+		assert.True(t, strings.HasPrefix(strings.TrimSpace(lines[s.start]), "if cond"), "segment starts at a branch: %q", lines[s.start])
+		assert.Equal(t, "}", strings.TrimSpace(lines[s.end-1]))
+	}
+}
+
+func TestChunkingOptions_ASTFallsBackOnParseError(t *testing.T) {
+	opts := ChunkingOptions{Languages: map[string]string{"python": ChunkingAST}}.withDefaults()
+	content := "def long():\n" + strings.Repeat("    step()\n", 60)
+
+	spans := opts.segmentSpans(content, "python")
+	require.Len(t, spans, 2)
+	assert.Equal(t, 0, spans[0].start)
+	assert.Equal(t, 40, spans[0].end)
+}
@@ -0,0 +1,234 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"docod/internal/graph"
+)
+
+// UnitTypeCoarseSummary marks LLM summaries of file and package chunks that
+// form the coarse retrieval layer.
+const UnitTypeCoarseSummary = "coarse_summary"
+
+const coarseIDPrefix = "summary:"
+
+// UnitSummarizer is an optional Summarizer capability that writes a short
+// summary of one file or package.
+type UnitSummarizer interface {
+	SummarizeUnit(ctx context.Context, kind, name, content string) (string, error)
+}
+
+// CachedSummary is a stored summary and the content hash it was written for.
+type CachedSummary struct {
+	ContentHash string
+	Summary     string
+}
+
+// SummaryCache persists coarse summaries so unchanged files and packages are
+// summarized only once.
+type SummaryCache interface {
+	GetSummaries(ctx context.Context, ids []string) (map[string]CachedSummary, error)
+	PutSummary(ctx context.Context, id, contentHash, summary string) error
+}
+
+// CoarseOptions controls the coarse summary layer.
+type CoarseOptions struct {
+	// MaxSummariesPerRun caps new LLM summaries per indexing run (0 = unlimited).
+	MaxSummariesPerRun int
+}
+
+// SetCoarseLayer enables hierarchical retrieval: indexing also embeds cached
+// LLM summaries of file and package chunks, and SearchHierarchical uses them
+// to scope symbol search. A nil summarizer or cache disables the layer.
+func (e *Engine) SetCoarseLayer(s UnitSummarizer, cache SummaryCache, opts CoarseOptions) {
+	if s == nil || cache == nil {
+		e.coarseSummarizer, e.summaryCache = nil, nil
+		return
+	}
+	e.coarseSummarizer = s
+	e.summaryCache = cache
+	e.coarseOpts = opts
+}
+
+// HasCoarseLayer reports whether hierarchical retrieval is enabled.
+func (e *Engine) HasCoarseLayer() bool {
+	return e.coarseSummarizer != nil && e.summaryCache != nil
+}
+
+// IsCoarseSource reports whether c is summarized into the coarse layer.
+func IsCoarseSource(c SearchChunk) bool {
+	return c.UnitType == "file_module" || c.UnitType == graph.UnitTypePackageSummary
+}
+
+// CoarseChunkID is the ID of the coarse summary chunk for a source chunk.
+func CoarseChunkID(sourceID string) string {
+	return coarseIDPrefix + sourceID
+}
+
+// BuildCoarseChunks returns a summary chunk for every file and package chunk
+// in chunks. Summaries are reused while the source chunk's content hash is
+// unchanged; new ones are requested from the summarizer within the per-run cap.
+func (e *Engine) BuildCoarseChunks(ctx context.Context, chunks []SearchChunk) ([]SearchChunk, error) {
+	if !e.HasCoarseLayer() {
+		return nil, nil
+	}
+	var sources []SearchChunk
+	var ids []string
+	for _, c := range chunks {
+		if IsCoarseSource(c) {
+			sources = append(sources, c)
+			ids = append(ids, CoarseChunkID(c.ID))
+		}
+	}
+	if len(sources) == 0 {
+		return nil, nil
+	}
+	cached, err := e.summaryCache.GetSummaries(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read summary cache: %w", err)
+	}
+
+	out := make([]SearchChunk, 0, len(sources))
+	generated, skipped := 0, 0
+	for _, src := range sources {
+		id := CoarseChunkID(src.ID)
+		hash := contentHash(src.ContentHash + src.Content)
+		summary := ""
+		if c, ok := cached[id]; ok && c.ContentHash == hash {
+			summary = c.Summary
+		} else if e.coarseOpts.MaxSummariesPerRun > 0 && generated >= e.coarseOpts.MaxSummariesPerRun {
+			skipped++
+			continue
+		} else {
+			kind := "file"
+			if src.UnitType == graph.UnitTypePackageSummary {
+				kind = "package"
+			}
+			summary, err = e.coarseSummarizer.SummarizeUnit(ctx, kind, src.Name, coarseSummaryInput(src, e.tokenizer))
+			if errors.Is(err, ErrDryRun) {
+				generated++
+				continue
+			}
+			if err != nil {
+				return out, fmt.Errorf("failed to summarize %s: %w", src.ID, err)
+			}
+			summary = strings.TrimSpace(summary)
+			if err := e.summaryCache.PutSummary(ctx, id, hash, summary); err != nil {
+				return out, fmt.Errorf("failed to cache summary for %s: %w", src.ID, err)
+			}
+			generated++
+		}
+		if summary == "" {
+			continue
+		}
+		out = append(out, coarseChunk(id, hash, summary, src))
+	}
+	fmt.Printf("🗂️  Coarse layer: %d summaries (%d new", len(out), generated)
+	if skipped > 0 {
+		fmt.Printf(", %d deferred by per-run cap", skipped)
+	}
+	fmt.Println(")")
+	return out, nil
+}
+
+func coarseSummaryInput(src SearchChunk, t Tokenizer) string {
+	var sb strings.Builder
+	sb.WriteString(src.Description)
+	if src.Signature != "" {
+		fmt.Fprintf(&sb, "\n%s", src.Signature)
+	}
+	if src.Content != "" {
+		fmt.Fprintf(&sb, "\n%s", TruncateTokens(t, src.Content, chunkContentTokens))
+	}
+	return sb.String()
+}
+
+func coarseChunk(id, hash, summary string, src SearchChunk) SearchChunk {
+	return SearchChunk{
+		ID:           id,
+		FilePath:     src.FilePath,
+		Name:         src.Name,
+		UnitType:     UnitTypeCoarseSummary,
+		Package:      src.Package,
+		Description:  summary,
+		Content:      summary,
+		ContentHash:  hash,
+		Dependencies: src.Dependencies,
+		UsedBy:       src.UsedBy,
+		Centrality:   src.Centrality,
+		Component:    src.Component,
+		Sources:      append([]ChunkSource(nil), src.Sources...),
+	}
+}
+
+// SearchHierarchical searches the coarse summary layer first and then drills
+// into symbol chunks of the files and packages it hit. Without a coarse layer,
+// or when the coarse layer finds nothing, it is a flat SearchByTextFiltered.
+// Results are backfilled from the flat search when scoped hits are short.
+func (e *Engine) SearchHierarchical(ctx context.Context, query string, topK int, filter SearchFilter) ([]SearchChunk, error) {
+	if !e.HasCoarseLayer() || len(filter.PathPrefixes) > 0 {
+		return e.SearchByTextFiltered(ctx, query, topK, "", filter)
+	}
+	coarseFilter := filter
+	coarseFilter.UnitTypes = []string{UnitTypeCoarseSummary}
+	coarse, err := e.SearchByTextFiltered(ctx, query, max(3, topK/3), "", coarseFilter)
+	if err != nil {
+		return nil, err
+	}
+	scope := coarseScope(coarse)
+
+	fine := filter
+	fine.ExcludeUnitTypes = append(append([]string(nil), filter.ExcludeUnitTypes...), UnitTypeCoarseSummary)
+	if len(scope) == 0 {
+		return e.SearchByTextFiltered(ctx, query, topK, "", fine)
+	}
+	scoped := fine
+	scoped.PathPrefixes = scope
+	results, err := e.SearchByTextFiltered(ctx, query, topK, "", scoped)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) >= topK {
+		return results, nil
+	}
+	flat, err := e.SearchByTextFiltered(ctx, query, topK, "", fine)
+	if err != nil {
+		return results, nil
+	}
+	for _, c := range flat {
+		if len(results) >= topK {
+			break
+		}
+		if !containsChunkID(results, c.ID) {
+			results = append(results, c)
+		}
+	}
+	return results, nil
+}
+
+// coarseScope turns coarse hits into path prefixes: the file itself for file
+// summaries and the package directory for package summaries. A hit on the
+// root package yields no scope, since it would cover the whole tree.
+func coarseScope(coarse []SearchChunk) []string {
+	seen := make(map[string]bool)
+	var scope []string
+	for _, c := range coarse {
+		path := filepath.ToSlash(strings.TrimSpace(c.FilePath))
+		if strings.HasPrefix(c.ID, CoarseChunkID(graph.PackageSummaryID(""))) {
+			if path == "" || path == "." {
+				return nil
+			}
+			path = strings.TrimSuffix(path, "/") + "/"
+		}
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		scope = append(scope, path)
+	}
+	return scope
+}
@@ -0,0 +1,117 @@
+package knowledge
+
+import (
+	"context"
+	"testing"
+
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUnitSummarizer struct {
+	calls int
+}
+
+func (f *fakeUnitSummarizer) SummarizeUnit(_ context.Context, kind, name, _ string) (string, error) {
+	f.calls++
+	return kind + " " + name + " summary", nil
+}
+
+type mapSummaryCache map[string]CachedSummary
+
+func (m mapSummaryCache) GetSummaries(_ context.Context, ids []string) (map[string]CachedSummary, error) {
+	out := make(map[string]CachedSummary)
+	for _, id := range ids {
+		if c, ok := m[id]; ok {
+			out[id] = c
+		}
+	}
+	return out, nil
+}
+
+func (m mapSummaryCache) PutSummary(_ context.Context, id, hash, summary string) error {
+	m[id] = CachedSummary{ContentHash: hash, Summary: summary}
+	return nil
+}
+
+func TestEngine_BuildCoarseChunksCachesByContentHash(t *testing.T) {
+	ctx := context.Background()
+	s := &fakeUnitSummarizer{}
+	cache := mapSummaryCache{}
+	engine := NewEngine(graph.NewGraph(), nil, nil)
+	engine.SetCoarseLayer(s, cache, CoarseOptions{})
+
+	chunks := []SearchChunk{
+		{ID: "pkg/a.go", Name: "a.go", UnitType: "file_module", FilePath: "pkg/a.go", ContentHash: "h1"},
+		{ID: "package:pkg", Name: "pkg", UnitType: graph.UnitTypePackageSummary, FilePath: "pkg", ContentHash: "h2"},
+		{ID: "pkg/a.go:Run:1", Name: "Run", UnitType: "function"},
+	}
+	coarse, err := engine.BuildCoarseChunks(ctx, chunks)
+	require.NoError(t, err)
+	require.Len(t, coarse, 2)
+	assert.Equal(t, CoarseChunkID("pkg/a.go"), coarse[0].ID)
+	assert.Equal(t, UnitTypeCoarseSummary, coarse[0].UnitType)
+	assert.Equal(t, "file a.go summary", coarse[0].Content)
+	assert.Equal(t, "package pkg summary", coarse[1].Content)
+	assert.Equal(t, 2, s.calls)
+
+	// Unchanged content reuses the cache; a changed file is summarized again.
+	chunks[0].ContentHash = "h1-changed"
+	_, err = engine.BuildCoarseChunks(ctx, chunks)
+	require.NoError(t, err)
+	assert.Equal(t, 3, s.calls)
+}
+
+func TestEngine_BuildCoarseChunksRespectsPerRunCap(t *testing.T) {
+	s := &fakeUnitSummarizer{}
+	engine := NewEngine(graph.NewGraph(), nil, nil)
+	engine.SetCoarseLayer(s, mapSummaryCache{}, CoarseOptions{MaxSummariesPerRun: 1})
+
+	coarse, err := engine.BuildCoarseChunks(context.Background(), []SearchChunk{
+		{ID: "a.go", UnitType: "file_module", ContentHash: "1"},
+		{ID: "b.go", UnitType: "file_module", ContentHash: "2"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, coarse, 1)
+	assert.Equal(t, 1, s.calls)
+}
+
+func TestEngine_SearchHierarchicalScopesToCoarseHits(t *testing.T) {
+	ctx := context.Background()
+	idx := NewMemoryIndex()
+	require.NoError(t, idx.Add(ctx, []VectorItem{
+		{Chunk: SearchChunk{ID: CoarseChunkID("net/router.go"), UnitType: UnitTypeCoarseSummary, FilePath: "net/router.go", Description: "routing table lookup for requests"}},
+		{Chunk: SearchChunk{ID: CoarseChunkID("log/log.go"), UnitType: UnitTypeCoarseSummary, FilePath: "log/log.go", Description: "structured logging output"}},
+		{Chunk: SearchChunk{ID: "net/router.go:Lookup:1", Name: "Lookup", UnitType: "function", FilePath: "net/router.go", Description: "finds a routing entry"}},
+		{Chunk: SearchChunk{ID: "log/log.go:Fields:1", Name: "Fields", UnitType: "function", FilePath: "log/log.go", Description: "routing table routing table fields"}},
+	}))
+	engine := NewEngine(graph.NewGraph(), nil, idx)
+
+	flat, err := engine.SearchHierarchical(ctx, "routing table", 1, SearchFilter{})
+	require.NoError(t, err)
+	require.Len(t, flat, 1)
+	assert.Equal(t, "log/log.go:Fields:1", flat[0].ID, "without a coarse layer the search is flat")
+
+	engine.SetCoarseLayer(&fakeUnitSummarizer{}, mapSummaryCache{}, CoarseOptions{})
+	scoped, err := engine.SearchHierarchical(ctx, "routing table", 1, SearchFilter{})
+	require.NoError(t, err)
+	require.Len(t, scoped, 1)
+	assert.Equal(t, "net/router.go:Lookup:1", scoped[0].ID)
+
+	// Short scoped results are backfilled from the flat search, never with summaries.
+	wide, err := engine.SearchHierarchical(ctx, "routing table", 4, SearchFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"net/router.go:Lookup:1", "log/log.go:Fields:1"}, chunkIDs(wide))
+}
+
+func TestCoarseScope(t *testing.T) {
+	scope := coarseScope([]SearchChunk{
+		{ID: CoarseChunkID("a/b.go"), FilePath: "a/b.go"},
+		{ID: CoarseChunkID(graph.PackageSummaryID("a/c")), FilePath: "a/c"},
+		{ID: CoarseChunkID("a/b.go"), FilePath: "a/b.go"},
+	})
+	assert.Equal(t, []string{"a/b.go", "a/c/"}, scope)
+	assert.Nil(t, coarseScope([]SearchChunk{{ID: CoarseChunkID(graph.PackageSummaryID(".")), FilePath: "."}}))
+}
@@ -0,0 +1,98 @@
+package knowledge
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Component is a labeled graph community used to structure architecture docs.
+type Component struct {
+	ID         int      `json:"id"`
+	Label      string   `json:"label"`
+	Packages   []string `json:"packages"`
+	Members    []string `json:"members"`
+	KeySymbols []string `json:"key_symbols"` // member IDs, most central first
+}
+
+// Components returns the cached communities of the graph with at least two
+// members, labeled by their dominant package and most central symbol.
+func (e *Engine) Components() []Component {
+	e.componentsOnce.Do(e.buildComponents)
+	return e.components
+}
+
+func (e *Engine) buildComponents() {
+	e.components = []Component{}
+	e.componentOf = make(map[string]string)
+	if e.graph == nil {
+		return
+	}
+
+	labelUse := make(map[string]int)
+	for _, comm := range e.graph.DetectCommunities() {
+		if len(comm.Members) < 2 {
+			continue
+		}
+		pkgCount := make(map[string]int)
+		for _, id := range comm.Members {
+			if n, ok := e.graph.Nodes[id]; ok && n.Unit != nil && n.Unit.Package != "" {
+				pkgCount[n.Unit.Package]++
+			}
+		}
+		packages := make([]string, 0, len(pkgCount))
+		for p := range pkgCount {
+			packages = append(packages, p)
+		}
+		sort.Slice(packages, func(i, j int) bool {
+			if pkgCount[packages[i]] != pkgCount[packages[j]] {
+				return pkgCount[packages[i]] > pkgCount[packages[j]]
+			}
+			return packages[i] < packages[j]
+		})
+
+		key := append([]string(nil), comm.Members...)
+		sort.SliceStable(key, func(i, j int) bool {
+			return e.Centrality(key[i]) > e.Centrality(key[j])
+		})
+		label := "component"
+		if len(packages) > 0 {
+			label = packages[0]
+		}
+		labelUse[label]++
+		e.components = append(e.components, Component{
+			ID:         comm.ID,
+			Label:      label,
+			Packages:   packages,
+			Members:    comm.Members,
+			KeySymbols: key,
+		})
+	}
+
+	// Disambiguate components that share a dominant package.
+	for i := range e.components {
+		c := &e.components[i]
+		if labelUse[c.Label] > 1 {
+			anchor := ""
+			for _, id := range c.KeySymbols {
+				if n, ok := e.graph.Nodes[id]; ok && n.Unit != nil && n.Unit.UnitType != "method" {
+					anchor = n.Unit.Name
+					break
+				}
+			}
+			if anchor == "" {
+				anchor = fmt.Sprintf("#%d", c.ID)
+			}
+			c.Label = fmt.Sprintf("%s (%s)", c.Label, anchor)
+		}
+		for _, id := range c.Members {
+			e.componentOf[id] = c.Label
+		}
+	}
+}
+
+// ComponentOf returns the component label for a node, or "" if unclustered.
+func (e *Engine) ComponentOf(id string) string {
+	e.componentsOnce.Do(e.buildComponents)
+	return e.componentOf[strings.TrimSpace(id)]
+}
@@ -0,0 +1,268 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Context budget defaults.
+const (
+	defaultContextWindow   = 32000
+	defaultReserveOutput   = 8192
+	minTrimmedChunkTokens  = 64
+	tokenEstimateCharRatio = 4
+)
+
+// knownContextWindows maps model name prefixes to their input context sizes.
+// Longer prefixes are listed before shorter ones that they extend.
+var knownContextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gemini-2.5", 1048576},
+	{"gemini-2.0", 1048576},
+	{"gemini-1.5-pro", 2097152},
+	{"gemini-1.5", 1048576},
+	{"gpt-4.1", 1047576},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-3.5", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude", 200000},
+}
+
+// ContextBudget is the prompt token budget for one model.
+type ContextBudget struct {
+	Model string
+	// ContextWindow is the model's total token window.
+	ContextWindow int
+	// ReserveOutput is held back from the window for the model's response.
+	ReserveOutput int
+	// Tokenizer measures prompt text; nil uses EstimateTokens.
+	Tokenizer Tokenizer
+}
+
+// NewContextBudget builds a budget for model. Zero window or reserve values
+// are filled from the known model table and defaults.
+func NewContextBudget(model string, window, reserve int) ContextBudget {
+	if window <= 0 {
+		window = ContextWindowFor(model)
+	}
+	if reserve <= 0 {
+		reserve = defaultReserveOutput
+	}
+	if reserve >= window {
+		reserve = window / 4
+	}
+	return ContextBudget{Model: model, ContextWindow: window, ReserveOutput: reserve}
+}
+
+// ContextWindowFor returns the known context window of model, or a
+// conservative default for unknown models.
+func ContextWindowFor(model string) int {
+	name := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, w := range knownContextWindows {
+		if strings.HasPrefix(name, w.prefix) {
+			return w.tokens
+		}
+	}
+	return defaultContextWindow
+}
+
+// Available is the number of prompt tokens that fit the budget.
+func (b ContextBudget) Available() int {
+	return b.ContextWindow - b.ReserveOutput
+}
+
+// CountTokens measures s with the budget's tokenizer.
+func (b ContextBudget) CountTokens(s string) int {
+	if b.Tokenizer == nil {
+		return EstimateTokens(s)
+	}
+	return b.Tokenizer.CountTokens(s)
+}
+
+// EstimateTokens approximates the token count of s (about four characters
+// per token for code and English prose).
+func EstimateTokens(s string) int {
+	if s == "" {
+		return 0
+	}
+	return (len(s) + tokenEstimateCharRatio - 1) / tokenEstimateCharRatio
+}
+
+// PackResult reports how evidence chunks were fitted into a prompt.
+type PackResult struct {
+	// Groups holds the kept chunks per input group, in input order.
+	Groups       [][]SearchChunk
+	Dropped      int
+	Trimmed      int
+	UsedTokens   int
+	BudgetTokens int
+}
+
+// Utilization is the fraction of the prompt budget used (0..1).
+func (r PackResult) Utilization() float64 {
+	if r.BudgetTokens <= 0 {
+		return 0
+	}
+	return float64(r.UsedTokens) / float64(r.BudgetTokens)
+}
+
+// String summarizes the budget usage for console output.
+func (r PackResult) String() string {
+	s := fmt.Sprintf("%d/%d tokens (%.0f%%)", r.UsedTokens, r.BudgetTokens, r.Utilization()*100)
+	if r.Trimmed > 0 {
+		s += fmt.Sprintf(", %d trimmed", r.Trimmed)
+	}
+	if r.Dropped > 0 {
+		s += fmt.Sprintf(", %d dropped", r.Dropped)
+	}
+	return s
+}
+
+// PackTally sums the packing of the prompts built for the model calls made
+// with a context it is attached to. It is safe for concurrent use.
+type PackTally struct {
+	mu    sync.Mutex
+	total PackResult
+}
+
+// Total returns the summed token and chunk counts of the prompts packed so
+// far; Groups is always nil.
+func (t *PackTally) Total() PackResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.total
+}
+
+type packTallyKey struct{}
+
+// WithPackTally attaches the tally that sums the prompt packing of the model
+// calls made with ctx.
+func WithPackTally(ctx context.Context, t *PackTally) context.Context {
+	return context.WithValue(ctx, packTallyKey{}, t)
+}
+
+// recordPack adds a packed prompt to the tally attached to ctx, if any.
+// Prompts built without a budget are not counted.
+func recordPack(ctx context.Context, p PackResult) {
+	t, _ := ctx.Value(packTallyKey{}).(*PackTally)
+	if t == nil || p.BudgetTokens <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.total.Dropped += p.Dropped
+	t.total.Trimmed += p.Trimmed
+	t.total.UsedTokens += p.UsedTokens
+	t.total.BudgetTokens += p.BudgetTokens
+}
+
+// PackContext fits chunk groups into budget tokens after fixedTokens of
+// prompt scaffolding. Each group is ordered by priority (most relevant first)
+// and groups are interleaved so that every group keeps its best evidence.
+// A chunk that does not fit whole has its code trimmed when a useful part
+// still fits; otherwise it is dropped.
+func PackContext(budget, fixedTokens int, groups [][]SearchChunk, render []func(SearchChunk) string) PackResult {
+	return packContext(EstimateTokens, budget, fixedTokens, groups, render)
+}
+
+// packContext is PackContext measuring text with count.
+func packContext(count func(string) int, budget, fixedTokens int, groups [][]SearchChunk, render []func(SearchChunk) string) PackResult {
+	res := PackResult{Groups: make([][]SearchChunk, len(groups)), UsedTokens: fixedTokens, BudgetTokens: budget}
+	type slot struct{ group, index int }
+	var order []slot
+	for i := 0; ; i++ {
+		added := false
+		for g := range groups {
+			if i < len(groups[g]) {
+				order = append(order, slot{g, i})
+				added = true
+			}
+		}
+		if !added {
+			break
+		}
+	}
+
+	kept := make([][]bool, len(groups))
+	chunks := make([][]SearchChunk, len(groups))
+	for g := range groups {
+		kept[g] = make([]bool, len(groups[g]))
+		chunks[g] = append([]SearchChunk(nil), groups[g]...)
+	}
+	for _, s := range order {
+		c := chunks[s.group][s.index]
+		remaining := budget - res.UsedTokens
+		cost := count(render[s.group](c))
+		if cost > remaining {
+			trimmed, ok := trimChunkToFit(count, c, render[s.group], remaining)
+			if !ok {
+				res.Dropped++
+				continue
+			}
+			c = trimmed
+			cost = count(render[s.group](c))
+			res.Trimmed++
+		}
+		chunks[s.group][s.index] = c
+		kept[s.group][s.index] = true
+		res.UsedTokens += cost
+	}
+	for g := range groups {
+		for i, ok := range kept[g] {
+			if ok {
+				res.Groups[g] = append(res.Groups[g], chunks[g][i])
+			}
+		}
+	}
+	return res
+}
+
+// trimChunkToFit cuts c's code at line boundaries until its rendering fits
+// within limit tokens. It gives up when less than minTrimmedChunkTokens of
+// code would remain.
+func trimChunkToFit(count func(string) int, c SearchChunk, render func(SearchChunk) string, limit int) (SearchChunk, bool) {
+	overhead := count(render(SearchChunk{
+		ID: c.ID, FilePath: c.FilePath, Name: c.Name, UnitType: c.UnitType,
+		Package: c.Package, Signature: c.Signature, Description: c.Description,
+	}))
+	room := limit - overhead
+	if room < minTrimmedChunkTokens || strings.TrimSpace(c.Content) == "" {
+		return c, false
+	}
+	maxChars := room * tokenEstimateCharRatio
+	content := c.Content
+	if len(content) > maxChars {
+		content = content[:maxChars]
+		if i := strings.LastIndex(content, "\n"); i > 0 {
+			content = content[:i]
+		}
+	}
+	const marker = "\n// ... trimmed to fit context budget"
+	for len(content) > 0 && count(render(withContent(c, content+marker))) > limit {
+		i := strings.LastIndex(content, "\n")
+		if i <= 0 {
+			return c, false
+		}
+		content = content[:i]
+	}
+	if count(content) < minTrimmedChunkTokens {
+		return c, false
+	}
+	return withContent(c, content+marker), true
+}
+
+func withContent(c SearchChunk, content string) SearchChunk {
+	c.Content = content
+	return c
+}
@@ -0,0 +1,93 @@
+package knowledge
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextWindowFor(t *testing.T) {
+	assert.Equal(t, 1048576, ContextWindowFor("gemini-2.5-flash-lite"))
+	assert.Equal(t, 128000, ContextWindowFor("gpt-4o-mini"))
+	assert.Equal(t, 8192, ContextWindowFor("gpt-4"))
+	assert.Equal(t, defaultContextWindow, ContextWindowFor("llama3"))
+
+	b := NewContextBudget("llama3", 0, 0)
+	assert.Equal(t, defaultContextWindow-defaultReserveOutput, b.Available())
+}
+
+func TestPackContext_DropsLowestPriorityAndTrims(t *testing.T) {
+	code := strings.Repeat("statement(argument)\n", 100) // ~500 tokens
+	chunks := []SearchChunk{
+		{ID: "a", Name: "A", Content: code},
+		{ID: "b", Name: "B", Content: code},
+		{ID: "c", Name: "C", Content: code},
+	}
+	render := []func(SearchChunk) string{renderEvidenceChunk}
+
+	res := PackContext(1000, 100, [][]SearchChunk{chunks}, render)
+	require.Len(t, res.Groups, 1)
+	kept := res.Groups[0]
+	require.Len(t, kept, 2)
+	assert.Equal(t, "a", kept[0].ID)
+	assert.Equal(t, code, kept[0].Content)
+	assert.Equal(t, "b", kept[1].ID)
+	assert.Contains(t, kept[1].Content, "trimmed to fit context budget")
+	assert.Equal(t, 1, res.Trimmed)
+	assert.Equal(t, 1, res.Dropped)
+	assert.LessOrEqual(t, res.UsedTokens, res.BudgetTokens)
+	assert.Greater(t, res.Utilization(), 0.9)
+}
+
+func TestPromptBuilder_FitsBudget(t *testing.T) {
+	pb := &PromptBuilder{}
+	pb.SetContextBudget(NewContextBudget("small", 3000, 1000))
+	var chunks []SearchChunk
+	for i := 0; i < 20; i++ {
+		chunks = append(chunks, SearchChunk{ID: "c", Name: "Sym", Content: strings.Repeat("code()\n", 60)})
+	}
+
+	prompt, res := pb.BuildUpdateDocPrompt("## Section\nbody", chunks, SectionStyle{})
+	assert.LessOrEqual(t, EstimateTokens(prompt), 2000)
+	assert.Greater(t, res.Dropped, 0)
+	assert.Contains(t, prompt, "=== EXISTING DOCUMENTATION SECTION ===")
+	assert.Contains(t, prompt, "OUTPUT ONLY markdown")
+}
+
+func TestPromptBuilder_FullDocKeepsEveryGroup(t *testing.T) {
+	pb := &PromptBuilder{}
+	pb.SetContextBudget(NewContextBudget("small", 2200, 1000))
+	long := func(name string) []SearchChunk {
+		var out []SearchChunk
+		for i := 0; i < 50; i++ {
+			out = append(out, SearchChunk{Name: name, Description: strings.Repeat("describes behavior ", 5)})
+		}
+		return out
+	}
+
+	_, res := pb.BuildFullDocPrompt(long("Arch"), long("Feat"), long("Conf"))
+	for g := range res.Groups {
+		assert.NotEmpty(t, res.Groups[g], "group %d", g)
+	}
+	assert.Greater(t, res.Dropped, 0)
+}
+
+func TestPackTally_SumsBudgetedPrompts(t *testing.T) {
+	s := newDryRunSummarizer("openai", "small", NewContextBudget("small", 3000, 1000))
+	chunks := []SearchChunk{{ID: "c", Name: "Sym", Content: strings.Repeat("code()\n", 60)}}
+	tally := &PackTally{}
+	ctx := WithPackTally(context.Background(), tally)
+
+	_, _ = s.GenerateNewSection(ctx, chunks)
+	_, _ = s.UpdateDocSection(ctx, "## Section\nbody", chunks)
+	total := tally.Total()
+	assert.Greater(t, total.UsedTokens, 0)
+	assert.Equal(t, 2*2000, total.BudgetTokens)
+	assert.Nil(t, total.Groups)
+
+	_, _ = s.GenerateNewSection(context.Background(), chunks)
+	assert.Equal(t, total, tally.Total())
+}
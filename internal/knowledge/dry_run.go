@@ -0,0 +1,113 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrDryRun is returned by the dry-run summarizer in place of model output.
+var ErrDryRun = errors.New("dry run: model call skipped")
+
+// Assumed response sizes, in tokens, of the calls a dry run skips.
+const (
+	dryRunSectionTokens   = 400
+	dryRunMinUpdateTokens = 200
+	dryRunUnitTokens      = 80
+	dryRunInsertionTokens = 5
+)
+
+// dryRunSummarizer builds the prompts a provider summarizer would send and
+// records their estimated usage, but never calls the model. Section rewrites
+// return the current content unchanged so callers account the call as made;
+// everything else fails with ErrDryRun and takes the caller's fallback.
+type dryRunSummarizer struct {
+	provider      string
+	model         string
+	promptBuilder *PromptBuilder
+}
+
+func newDryRunSummarizer(provider, model string, budget ContextBudget) *dryRunSummarizer {
+	pb := NewPromptBuilder(model)
+	pb.SetContextBudget(budget)
+	return &dryRunSummarizer{provider: provider, model: model, promptBuilder: pb}
+}
+
+func (s *dryRunSummarizer) estimate(ctx context.Context, prompt string, outputTokens int) {
+	s.promptBuilder.recordUsage(ctx, s.provider, s.model, prompt, "", 0, outputTokens)
+}
+
+func (s *dryRunSummarizer) SummarizeFullDoc(ctx context.Context, archChunks, featChunks, confChunks []SearchChunk) (string, error) {
+	prompt, pack := s.promptBuilder.BuildFullDocPrompt(archChunks, featChunks, confChunks)
+	recordPack(ctx, pack)
+	s.estimate(ctx, prompt, dryRunSectionTokens)
+	return "", ErrDryRun
+}
+
+func (s *dryRunSummarizer) UpdateDocSection(ctx context.Context, currentContent string, relevantCode []SearchChunk) (string, error) {
+	out := max(s.promptBuilder.budget.CountTokens(currentContent), dryRunMinUpdateTokens)
+	prompt, pack := s.promptBuilder.BuildUpdateDocPrompt(currentContent, relevantCode, SectionStyleFrom(ctx))
+	recordPack(ctx, pack)
+	s.estimate(ctx, prompt, out)
+	return currentContent, nil
+}
+
+func (s *dryRunSummarizer) RenderSectionFromDraft(ctx context.Context, draftJSON string, relevantCode []SearchChunk) (string, error) {
+	prompt, pack := s.promptBuilder.BuildRenderFromDraftPrompt(draftJSON, relevantCode, SectionStyleFrom(ctx))
+	recordPack(ctx, pack)
+	s.estimate(ctx, prompt, dryRunSectionTokens)
+	return "", ErrDryRun
+}
+
+func (s *dryRunSummarizer) GenerateNewSection(ctx context.Context, relevantCode []SearchChunk) (string, error) {
+	prompt, pack := s.promptBuilder.BuildNewSectionPrompt(relevantCode, SectionStyleFrom(ctx))
+	recordPack(ctx, pack)
+	s.estimate(ctx, prompt, dryRunSectionTokens)
+	return "", ErrDryRun
+}
+
+// SummarizeUnit implements UnitSummarizer.
+func (s *dryRunSummarizer) SummarizeUnit(ctx context.Context, kind, name, content string) (string, error) {
+	s.estimate(ctx, s.promptBuilder.BuildUnitSummaryPrompt(kind, name, content), dryRunUnitTokens)
+	return "", ErrDryRun
+}
+
+// AnswerQuestion implements QuestionAnswerer.
+func (s *dryRunSummarizer) AnswerQuestion(ctx context.Context, question string, evidence []SearchChunk) (string, error) {
+	prompt, pack := s.promptBuilder.BuildAnswerPrompt(question, evidence)
+	recordPack(ctx, pack)
+	s.estimate(ctx, prompt, dryRunSectionTokens)
+	return "", ErrDryRun
+}
+
+func (s *dryRunSummarizer) FindInsertionPoint(ctx context.Context, toc []string, newContent string) (int, error) {
+	s.estimate(ctx, s.promptBuilder.BuildInsertionPointPrompt(toc, newContent), dryRunInsertionTokens)
+	return -1, ErrDryRun
+}
+
+// SetDryRun makes indexing estimate instead of write: texts that would be
+// embedded are counted on the usage meter but not sent, and neither the
+// index nor the embedding cache is modified. Searches still embed queries.
+func (e *Engine) SetDryRun(enabled bool) {
+	e.dryRun = enabled
+}
+
+// deleteFromIndex removes the chunks of files from the index, unless this is
+// a dry run.
+func (e *Engine) deleteFromIndex(ctx context.Context, files []string) error {
+	if e.dryRun {
+		return nil
+	}
+	return e.index.Delete(ctx, files)
+}
+
+// embedTexts embeds texts for indexing, or under a dry run records their
+// estimated usage and returns empty vectors.
+func (e *Engine) embedTexts(ctx context.Context, texts []string) ([][]float32, error) {
+	if !e.dryRun {
+		return e.embedder.Embed(ctx, texts)
+	}
+	if m, ok := e.embedder.(*meteredEmbedder); ok {
+		m.record(ctx, texts)
+	}
+	return make([][]float32, len(texts)), nil
+}
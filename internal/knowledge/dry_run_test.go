@@ -0,0 +1,70 @@
+package knowledge
+
+import (
+	"context"
+	"testing"
+
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSummarizer_DryRunEstimatesWithoutCalling(t *testing.T) {
+	s, err := NewSummarizer(context.Background(), SummarizerOptions{Provider: "openai", Model: "gpt-4o", DryRun: true})
+	require.NoError(t, err)
+	m := NewUsageMeter(nil)
+	ctx := WithUsageSection(WithUsageMeter(context.Background(), m), "overview")
+
+	// Rewrites keep the current content so callers count the call as made.
+	out, err := s.UpdateDocSection(ctx, "# Overview", []SearchChunk{{ID: "a", Content: "func A() {}"}})
+	require.NoError(t, err)
+	assert.Equal(t, "# Overview", out)
+
+	_, err = s.GenerateNewSection(ctx, nil)
+	assert.ErrorIs(t, err, ErrDryRun)
+	_, err = s.(UnitSummarizer).SummarizeUnit(context.Background(), "file", "a.go", "package a")
+	assert.ErrorIs(t, err, ErrDryRun)
+
+	run := m.Run("dry_run")
+	require.Len(t, run.Records, 1)
+	rec := run.Records[0]
+	assert.Equal(t, UsageLLM, rec.Kind)
+	assert.Equal(t, "overview", rec.Section)
+	assert.Equal(t, 2, rec.Requests)
+	assert.Equal(t, dryRunMinUpdateTokens+dryRunSectionTokens, rec.OutputTokens)
+	assert.Greater(t, rec.InputTokens, 0)
+	assert.Greater(t, rec.Cost, 0.0)
+}
+
+func TestEngine_DryRunCountsEmbeddingsWithoutIndexing(t *testing.T) {
+	tok, err := NewTokenizer(TokenizerChars)
+	require.NoError(t, err)
+	em := &textCountingEmbedder{}
+	idx := NewMemoryIndex()
+	engine := NewEngine(graph.NewGraph(), &meteredEmbedder{Embedder: em, provider: "openai", model: "text-embedding-3-small", tokenizer: tok}, idx)
+	engine.SetDryRun(true)
+	s := &dryRunSummarizer{provider: "openai", model: "gpt-4o", promptBuilder: NewPromptBuilder("gpt-4o")}
+	cache := mapSummaryCache{}
+	engine.SetCoarseLayer(s, cache, CoarseOptions{})
+	m := NewUsageMeter(nil)
+	ctx := WithUsageMeter(context.Background(), m)
+
+	chunks := []SearchChunk{
+		{ID: "pkg/a.go", Name: "a.go", UnitType: "file_module", FilePath: "pkg/a.go", ContentHash: "h1"},
+		{ID: "pkg/a.go:Run:1", Name: "Run", UnitType: "function", ContentHash: "h2"},
+	}
+	require.NoError(t, engine.embedChunks(ctx, append(chunks, engine.coarseChunksFor(ctx, chunks)...)))
+
+	assert.Equal(t, 0, em.texts)
+	assert.Empty(t, idx.items)
+	assert.Empty(t, cache)
+	assert.Equal(t, 2, engine.IndexStats().Embedded)
+
+	run := m.Run("dry_run")
+	require.Len(t, run.Records, 2)
+	assert.Equal(t, UsageEmbedding, run.Records[0].Kind)
+	assert.Equal(t, 1, run.Records[0].Requests)
+	assert.Equal(t, UsageLLM, run.Records[1].Kind)
+	assert.Equal(t, 1, run.Records[1].Requests)
+}
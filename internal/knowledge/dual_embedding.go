@@ -0,0 +1,177 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// codeViewUnitTypes are the unit types whose raw code body gets its own
+// vector when dual embeddings are enabled. File modules and summaries carry
+// prose or whole files and only keep the natural-language view.
+var codeViewUnitTypes = map[string]bool{
+	"function":  true,
+	"method":    true,
+	"struct":    true,
+	"interface": true,
+	"type":      true,
+}
+
+// SetDualEmbeddings enables a second, code-only vector per symbol chunk.
+// Searches score both views and keep the better similarity (late fusion), so
+// identifier-heavy code and natural-language queries can each match the view
+// closest to them.
+func (e *Engine) SetDualEmbeddings(enabled bool) {
+	e.dualEmbeddings = enabled
+}
+
+// codeViewText returns the raw code body embedded as the code view, or "" when
+// the chunk has no separate code view.
+func codeViewText(c SearchChunk) string {
+	if !codeViewUnitTypes[c.UnitType] {
+		return ""
+	}
+	return strings.TrimSpace(c.Content)
+}
+
+// attachCodeEmbeddings fills CodeEmbedding for items with a code view,
+// reusing stored or cached code vectors by content hash and embedding each
+// remaining hash once.
+func (e *Engine) attachCodeEmbeddings(ctx context.Context, items []VectorItem) error {
+	reused := e.existingCodeEmbeddingsByHash(ctx, items)
+	var hashes []string
+	for _, item := range items {
+		if codeViewText(item.Chunk) != "" {
+			hashes = append(hashes, item.Chunk.ContentHash)
+		}
+	}
+	model := e.embeddingModel + codeViewModelSuffix
+	cached := e.cachedEmbeddings(ctx, model, hashes, reused)
+	if len(cached) > 0 && reused == nil {
+		reused = make(map[string][]float32, len(cached))
+	}
+	for h, vec := range cached {
+		reused[h] = vec
+	}
+	var texts []string
+	textIndex := make([]int, len(items))
+	firstByHash := make(map[string]int)
+	for i, item := range items {
+		textIndex[i] = -1
+		text := codeViewText(item.Chunk)
+		if text == "" {
+			continue
+		}
+		if h := item.Chunk.ContentHash; h != "" {
+			if _, ok := reused[h]; ok {
+				continue
+			}
+			if j, ok := firstByHash[h]; ok {
+				textIndex[i] = j
+				continue
+			}
+			firstByHash[h] = len(texts)
+		}
+		textIndex[i] = len(texts)
+		texts = append(texts, TruncateTokens(e.tokenizer, text, e.maxEmbedTokens))
+	}
+
+	var vectors [][]float32
+	if len(texts) > 0 {
+		var err error
+		vectors, err = e.embedTexts(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to generate code embeddings: %w", err)
+		}
+	}
+	fresh := make(map[string][]float32)
+	for i := range items {
+		if textIndex[i] >= 0 && textIndex[i] < len(vectors) {
+			items[i].CodeEmbedding = vectors[textIndex[i]]
+			if h := items[i].Chunk.ContentHash; h != "" {
+				fresh[h] = items[i].CodeEmbedding
+			}
+		} else if codeViewText(items[i].Chunk) != "" {
+			items[i].CodeEmbedding = reused[items[i].Chunk.ContentHash]
+		}
+	}
+	if !e.dryRun {
+		e.cacheEmbeddings(ctx, model, fresh)
+	}
+	e.indexStats.Embedded += len(texts)
+	return nil
+}
+
+func (e *Engine) existingCodeEmbeddingsByHash(ctx context.Context, items []VectorItem) map[string][]float32 {
+	reader, ok := e.index.(CodeEmbeddingHashReader)
+	if !ok {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var hashes []string
+	for _, item := range items {
+		h := item.Chunk.ContentHash
+		if h != "" && !seen[h] && codeViewText(item.Chunk) != "" {
+			seen[h] = true
+			hashes = append(hashes, h)
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	found, err := reader.GetCodeEmbeddingsByContentHash(ctx, hashes)
+	if err != nil {
+		return nil
+	}
+	return found
+}
+
+// chunksMissingCodeView returns the unchanged chunks that have a code view but
+// no stored code vector yet, so enabling dual embeddings backfills them. Their
+// text vectors are reused by content hash.
+func (e *Engine) chunksMissingCodeView(ctx context.Context, unchanged []SearchChunk) []SearchChunk {
+	reader, ok := e.index.(CodeEmbeddingHashReader)
+	if !ok {
+		return nil
+	}
+	var candidates []SearchChunk
+	var hashes []string
+	seen := make(map[string]bool)
+	for _, c := range unchanged {
+		if codeViewText(c) == "" || c.ContentHash == "" {
+			continue
+		}
+		candidates = append(candidates, c)
+		if !seen[c.ContentHash] {
+			seen[c.ContentHash] = true
+			hashes = append(hashes, c.ContentHash)
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	stored, err := reader.GetCodeEmbeddingsByContentHash(ctx, hashes)
+	if err != nil {
+		return nil
+	}
+	var out []SearchChunk
+	for _, c := range candidates {
+		if _, ok := stored[c.ContentHash]; !ok {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// ViewSimilarity fuses the cosine similarity of query against the
+// natural-language vector and, when present, the code vector of a chunk by
+// keeping the higher of the two.
+func ViewSimilarity(query, text, code []float32) float32 {
+	score := cosineSimilarity(query, text)
+	if len(code) > 0 {
+		if s := cosineSimilarity(query, code); s > score {
+			score = s
+		}
+	}
+	return score
+}
@@ -0,0 +1,70 @@
+package knowledge
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// viewEmbedder maps code bodies ("func ...") to one axis and everything else
+// to the other, so each view is only similar to queries of its own kind.
+type viewEmbedder struct {
+	texts []string
+}
+
+func (v *viewEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	v.texts = append(v.texts, texts...)
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		if strings.HasPrefix(t, "func ") {
+			out[i] = []float32{0, 1}
+		} else {
+			out[i] = []float32{1, 0}
+		}
+	}
+	return out, nil
+}
+
+func (v *viewEmbedder) Dimension() int { return 2 }
+
+func TestEngine_DualEmbeddingsMatchCodeView(t *testing.T) {
+	ctx := context.Background()
+	em := &viewEmbedder{}
+	idx := NewMemoryIndex()
+	engine := NewEngine(graph.NewGraph(), em, idx)
+	engine.SetDualEmbeddings(true)
+
+	chunk := SearchChunk{ID: "svc.go:Run:1", Name: "Run", UnitType: "function", Content: "func Run() {}", ContentHash: "h1"}
+	file := SearchChunk{ID: "svc.go", Name: "svc.go", UnitType: "file_module", Content: "package svc", ContentHash: "h2"}
+	require.NoError(t, engine.embedChunks(ctx, []SearchChunk{chunk, file}))
+	assert.Len(t, em.texts, 3, "text views for both chunks, code view for the function only")
+
+	hits, err := idx.Search(ctx, []float32{0, 1}, 2, SearchFilter{})
+	require.NoError(t, err)
+	require.NotEmpty(t, hits)
+	assert.Equal(t, chunk.ID, hits[0].Chunk.ID)
+	assert.InDelta(t, 1.0, hits[0].Score, 1e-6, "code view similarity wins")
+}
+
+func TestEngine_DualEmbeddingsBackfillUnchangedChunks(t *testing.T) {
+	ctx := context.Background()
+	em := &viewEmbedder{}
+	idx := NewMemoryIndex()
+	engine := NewEngine(graph.NewGraph(), em, idx)
+
+	chunk := SearchChunk{ID: "svc.go:Run:1", Name: "Run", UnitType: "function", Content: "func Run() {}", ContentHash: "h1"}
+	require.NoError(t, engine.embedChunks(ctx, []SearchChunk{chunk}))
+	require.Len(t, em.texts, 1)
+
+	engine.SetDualEmbeddings(true)
+	require.NoError(t, engine.embedChunks(ctx, []SearchChunk{chunk}))
+	assert.Equal(t, []string{"func Run() {}"}, em.texts[1:], "only the missing code view is embedded")
+
+	require.NoError(t, engine.embedChunks(ctx, []SearchChunk{chunk}))
+	assert.Len(t, em.texts, 2, "nothing left to backfill")
+}
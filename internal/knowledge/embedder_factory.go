@@ -12,22 +12,65 @@ type EmbedderOptions struct {
 	Model     string
 	Dimension int
 	BaseURL   string
+	// Deployment and APIVersion address an azure-openai deployment, whose
+	// resource endpoint is BaseURL. Deployment defaults to Model.
+	Deployment string
+	APIVersion string
+	// RuntimeLibrary and MaxSequenceLength configure the local provider,
+	// whose Model is the directory of an ONNX sentence-transformer.
+	RuntimeLibrary    string
+	MaxSequenceLength int
+	// Tokenizer counts embedded tokens for usage accounting; empty picks
+	// the one matching Provider and Model.
+	Tokenizer string
+	// RateLimiter, if set, is waited on before each Embed request.
+	RateLimiter *RateLimiter
 }
 
+// NewEmbedder returns the embedder of opts.Provider. Its calls are recorded
+// on the UsageMeter attached to their context, if any, and wait on
+// opts.RateLimiter.
 func NewEmbedder(ctx context.Context, opts EmbedderOptions) (Embedder, error) {
 	provider := strings.ToLower(strings.TrimSpace(opts.Provider))
 	if provider == "" {
 		provider = "gemini"
 	}
+	tokenizer := opts.Tokenizer
+	if strings.TrimSpace(tokenizer) == "" {
+		tokenizer = TokenizerFor(provider, opts.Model)
+	}
+	t, err := NewTokenizer(tokenizer)
+	if err != nil {
+		return nil, err
+	}
 
+	var embedder Embedder
 	switch provider {
 	case "gemini":
-		return NewGeminiEmbedder(ctx, opts.APIKey, opts.Model, opts.Dimension)
+		embedder, err = NewGeminiEmbedder(ctx, opts.APIKey, opts.Model, opts.Dimension)
+		if err != nil {
+			return nil, err
+		}
 	case "openai":
-		return NewOpenAIEmbedder(opts.APIKey, opts.Model, opts.Dimension, opts.BaseURL), nil
+		embedder = NewOpenAIEmbedder(opts.APIKey, opts.Model, opts.Dimension, opts.BaseURL)
+	case "azure-openai":
+		if strings.TrimSpace(opts.BaseURL) == "" {
+			return nil, fmt.Errorf("azure-openai embedder requires an endpoint")
+		}
+		deployment := opts.Deployment
+		if strings.TrimSpace(deployment) == "" {
+			deployment = opts.Model
+		}
+		embedder = NewAzureOpenAIEmbedder(opts.APIKey, opts.BaseURL, deployment, opts.APIVersion, opts.Dimension)
+	case "local":
+		embedder = NewLocalEmbedder(opts.Model, opts.RuntimeLibrary, opts.Dimension, opts.MaxSequenceLength)
 	case "ollama":
-		return NewOllamaEmbedder(opts.Model, opts.Dimension, opts.BaseURL), nil
+		embedder = NewOllamaEmbedder(opts.Model, opts.Dimension, opts.BaseURL)
 	default:
 		return nil, fmt.Errorf("unsupported embedder provider: %s", opts.Provider)
 	}
+	if opts.RateLimiter != nil {
+		embedder = &limitedEmbedder{Embedder: embedder, limiter: opts.RateLimiter}
+	}
+	return &meteredEmbedder{Embedder: embedder, provider: provider, model: opts.Model, tokenizer: t}, nil
 }
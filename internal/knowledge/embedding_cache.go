@@ -0,0 +1,62 @@
+package knowledge
+
+import "context"
+
+// codeViewModelSuffix keys code-view vectors apart from text vectors of the
+// same content in the embedding cache.
+const codeViewModelSuffix = "+code"
+
+// EmbeddingCache persists chunk vectors by embedding provider, model and
+// chunk content hash. Unlike vectors held by the index, cached vectors
+// survive index rebuilds and backend switches, so unchanged content is
+// embedded once per model.
+type EmbeddingCache interface {
+	// GetCachedEmbeddings returns the cached vectors among hashes.
+	GetCachedEmbeddings(ctx context.Context, provider, model string, hashes []string) (map[string][]float32, error)
+	// PutCachedEmbeddings stores vectors by content hash.
+	PutCachedEmbeddings(ctx context.Context, provider, model string, vectors map[string][]float32) error
+}
+
+// SetEmbeddingCache enables the persistent embedding cache. model should
+// identify the embedding space, dimension included when the model supports
+// several. A nil cache disables it.
+func (e *Engine) SetEmbeddingCache(cache EmbeddingCache, provider, model string) {
+	e.embeddingCache = cache
+	e.embeddingProvider = provider
+	e.embeddingModel = model
+}
+
+// cachedEmbeddings returns the cached vectors for the hashes that found is
+// missing. model is the engine model, with the code view suffix for code
+// vectors.
+func (e *Engine) cachedEmbeddings(ctx context.Context, model string, hashes []string, found map[string][]float32) map[string][]float32 {
+	if e.embeddingCache == nil {
+		return nil
+	}
+	var missing []string
+	seen := make(map[string]bool)
+	for _, h := range hashes {
+		if _, ok := found[h]; ok || h == "" || seen[h] {
+			continue
+		}
+		seen[h] = true
+		missing = append(missing, h)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	cached, err := e.embeddingCache.GetCachedEmbeddings(ctx, e.embeddingProvider, model, missing)
+	if err != nil {
+		return nil
+	}
+	return cached
+}
+
+// cacheEmbeddings stores freshly embedded vectors. A failure only costs
+// embedding them again later, so it is ignored.
+func (e *Engine) cacheEmbeddings(ctx context.Context, model string, vectors map[string][]float32) {
+	if e.embeddingCache == nil || len(vectors) == 0 {
+		return
+	}
+	_ = e.embeddingCache.PutCachedEmbeddings(ctx, e.embeddingProvider, model, vectors)
+}
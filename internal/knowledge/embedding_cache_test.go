@@ -0,0 +1,69 @@
+package knowledge
+
+import (
+	"context"
+	"testing"
+
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mapEmbeddingCache struct {
+	entries map[string][]float32
+}
+
+func (m *mapEmbeddingCache) GetCachedEmbeddings(_ context.Context, provider, model string, hashes []string) (map[string][]float32, error) {
+	out := make(map[string][]float32)
+	for _, h := range hashes {
+		if v, ok := m.entries[provider+"|"+model+"|"+h]; ok {
+			out[h] = v
+		}
+	}
+	return out, nil
+}
+
+func (m *mapEmbeddingCache) PutCachedEmbeddings(_ context.Context, provider, model string, vectors map[string][]float32) error {
+	for h, v := range vectors {
+		m.entries[provider+"|"+model+"|"+h] = v
+	}
+	return nil
+}
+
+func TestEngine_EmbeddingCacheSurvivesIndexRebuild(t *testing.T) {
+	ctx := context.Background()
+	cache := &mapEmbeddingCache{entries: map[string][]float32{}}
+	chunks := []SearchChunk{
+		{ID: "a.go:New:1", Name: "New", UnitType: "function", Content: "func New() {}", ContentHash: "h1"},
+		{ID: "b.go:Run:1", Name: "Run", UnitType: "function", Content: "func Run() {}", ContentHash: "h2"},
+	}
+
+	em := &textCountingEmbedder{}
+	first := NewEngine(graph.NewGraph(), em, NewMemoryIndex())
+	first.SetEmbeddingCache(cache, "ollama", "nomic/768")
+	first.SetDualEmbeddings(true)
+	require.NoError(t, first.embedChunks(ctx, chunks))
+	assert.Equal(t, 4, em.texts)
+	assert.Len(t, cache.entries, 4)
+	assert.Contains(t, cache.entries, "ollama|nomic/768+code|h1")
+
+	// A rebuilt, empty index embeds nothing for the same model.
+	idx := NewMemoryIndex()
+	second := NewEngine(graph.NewGraph(), em, idx)
+	second.SetEmbeddingCache(cache, "ollama", "nomic/768")
+	second.SetDualEmbeddings(true)
+	require.NoError(t, second.embedChunks(ctx, chunks))
+	assert.Equal(t, 4, em.texts)
+	assert.Equal(t, 2, second.IndexStats().Cached)
+	vecs, err := idx.GetEmbeddings(ctx, []string{"a.go:New:1"})
+	require.NoError(t, err)
+	assert.Equal(t, cache.entries["ollama|nomic/768|h1"], vecs["a.go:New:1"])
+
+	// Another model does not share vectors.
+	third := NewEngine(graph.NewGraph(), em, NewMemoryIndex())
+	third.SetEmbeddingCache(cache, "ollama", "other/768")
+	require.NoError(t, third.embedChunks(ctx, chunks))
+	assert.Equal(t, 6, em.texts)
+	assert.Zero(t, third.IndexStats().Cached)
+}
@@ -2,11 +2,14 @@ package knowledge
 
 import (
 	"context"
+	"crypto/sha256"
 	"docod/internal/graph"
+	"encoding/hex"
 	"fmt"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 )
 
 // SearchChunk represents a structured piece of code knowledge, ready for indexing or embedding.
@@ -22,7 +25,15 @@ type SearchChunk struct {
 	ContentHash  string        `json:"content_hash"` // Hash for change detection
 	Dependencies []string      `json:"dependencies"`
 	UsedBy       []string      `json:"used_by"`
+	Implements   []string      `json:"implements,omitempty"`
+	TestedBy     []string      `json:"tested_by,omitempty"`
+	Centrality   float64       `json:"centrality,omitempty"` // PageRank scaled to 0..1
+	Component    string        `json:"component,omitempty"`  // community label
 	Sources      []ChunkSource `json:"sources,omitempty"`
+	// Score is the retrieval relevance set by search: cosine similarity for
+	// vector hits, or the normalized fusion score for keyword-only hits. It is
+	// not persisted.
+	Score float64 `json:"-"`
 }
 
 type ChunkSource struct {
@@ -30,7 +41,7 @@ type ChunkSource struct {
 	FilePath   string  `json:"file_path"`
 	StartLine  int     `json:"start_line"`
 	EndLine    int     `json:"end_line"`
-	Relation   string  `json:"relation"` // primary, dependency, context
+	Relation   string  `json:"relation"` // primary, dependency, context, test
 	Confidence float64 `json:"confidence,omitempty"`
 }
 
@@ -48,15 +59,70 @@ func (c SearchChunk) ToEmbeddableText() string {
 	if len(c.UsedBy) > 0 {
 		fmt.Fprintf(&sb, "Used by: %s\n", strings.Join(c.UsedBy, ", "))
 	}
+	if len(c.Implements) > 0 {
+		fmt.Fprintf(&sb, "Implements: %s\n", strings.Join(c.Implements, ", "))
+	}
+	if len(c.TestedBy) > 0 {
+		fmt.Fprintf(&sb, "Tested by: %s\n", strings.Join(c.TestedBy, ", "))
+	}
 	return sb.String()
 }
 
 // Engine handles data refinement and preparation for LLM/Embedding.
 type Engine struct {
-	graph         *graph.Graph
-	embedder      Embedder
-	index         Indexer
-	queryVecCache map[string][]float32
+	graph          *graph.Graph
+	embedder       Embedder
+	index          Indexer
+	reranker       Reranker
+	queryVecCache  *vectorLRU
+	queryCache     QueryVectorCache
+	queryCacheOpts QueryCacheOptions
+	centrality     map[string]float64
+	components     []Component
+	componentOf    map[string]string
+	packages       []graph.PackageSummary
+	// The graph of an engine never changes, so values derived from it are
+	// computed once, on first use; callers with a new graph build a new
+	// engine.
+	centralityOnce sync.Once
+	componentsOnce sync.Once
+	packagesOnce   sync.Once
+	chunking       ChunkingOptions
+	indexStats     IndexStats
+	scope          SearchFilter
+	pathScope      []string
+	dualEmbeddings bool
+
+	coarseSummarizer UnitSummarizer
+	summaryCache     SummaryCache
+	coarseOpts       CoarseOptions
+
+	// embeddingCache persists chunk vectors across index rebuilds.
+	embeddingCache    EmbeddingCache
+	embeddingProvider string
+	embeddingModel    string
+
+	// tokenizer measures chunk content and embeddable text for the
+	// embedding model; maxEmbedTokens caps the text sent per chunk.
+	tokenizer      Tokenizer
+	maxEmbedTokens int
+
+	// dryRun estimates indexing instead of writing it; see SetDryRun.
+	dryRun bool
+}
+
+// IndexStats counts chunks handled by indexing runs.
+type IndexStats struct {
+	Prepared int
+	// Unchanged chunks already had an up-to-date vector under their ID.
+	Unchanged int
+	// Embedded is the number of texts sent to the embedder.
+	Embedded int
+	// Deduplicated chunks reused the vector of identical content.
+	Deduplicated int
+	// Cached chunks took their vector from the persistent embedding cache;
+	// they are counted in Deduplicated too.
+	Cached int
 }
 
 type IndexingOptions struct {
@@ -69,8 +135,33 @@ func NewEngine(g *graph.Graph, em Embedder, idx Indexer) *Engine {
 		graph:         g,
 		embedder:      em,
 		index:         idx,
-		queryVecCache: make(map[string][]float32),
+		queryVecCache: newVectorLRU(defaultQueryMemoryEntries),
+		chunking:      DefaultChunkingOptions(),
+		tokenizer:     charTokenizer{},
+	}
+}
+
+// SetTokenizer sets how chunk content is measured against its token budgets
+// and caps the embeddable text of one chunk at maxEmbedTokens (0 keeps it
+// whole). A nil tokenizer restores the character estimate.
+func (e *Engine) SetTokenizer(t Tokenizer, maxEmbedTokens int) {
+	if t == nil {
+		t = charTokenizer{}
 	}
+	e.tokenizer = t
+	e.maxEmbedTokens = maxEmbedTokens
+}
+
+func (e *Engine) Graph() *graph.Graph {
+	return e.graph
+}
+
+// Centrality returns the cached PageRank score (0..1) for a node.
+func (e *Engine) Centrality(id string) float64 {
+	e.centralityOnce.Do(func() {
+		e.centrality = e.graph.PageRank(0.85, 30)
+	})
+	return e.centrality[id]
 }
 
 func (e *Engine) Embedder() Embedder {
@@ -81,6 +172,57 @@ func (e *Engine) Indexer() Indexer {
 	return e.index
 }
 
+// SetChunkingOptions configures how long symbols are split into segments.
+func (e *Engine) SetChunkingOptions(opts ChunkingOptions) {
+	e.chunking = opts.withDefaults()
+}
+
+// SetScopeFilter sets project-wide exclusions (tests, vendor or example
+// directories). Only the negative criteria of f are used; they apply to chunk
+// preparation and to every search.
+func (e *Engine) SetScopeFilter(f SearchFilter) {
+	e.scope = SearchFilter{}.WithExclusions(f)
+}
+
+// SetPathScope confines every search to files under prefixes, minus the
+// excluded paths, e.g. one shard of a monorepo whose neighbours share the
+// index. Filters that name their own path prefixes keep them.
+func (e *Engine) SetPathScope(prefixes, exclude []string) {
+	e.pathScope = append([]string(nil), prefixes...)
+	e.scope = e.scope.WithExclusions(SearchFilter{ExcludePaths: exclude})
+}
+
+// scoped narrows a search filter by the project and path scopes.
+func (e *Engine) scoped(filter SearchFilter) SearchFilter {
+	filter = filter.WithExclusions(e.scope)
+	if len(filter.PathPrefixes) == 0 && len(e.pathScope) > 0 {
+		filter.PathPrefixes = e.pathScope
+	}
+	return filter
+}
+
+// inScope reports whether a graph node passes the project scope filter.
+func (e *Engine) inScope(node *graph.Node) bool {
+	if e.scope.IsZero() || node == nil || node.Unit == nil {
+		return true
+	}
+	return e.scope.Match(SearchChunk{Name: node.Unit.Name, UnitType: node.Unit.UnitType, FilePath: node.Unit.Filepath})
+}
+
+// SetReranker enables cross-encoder reranking of retrieved candidates.
+func (e *Engine) SetReranker(r Reranker) {
+	e.reranker = r
+}
+
+// Rerank reorders retrieved chunks with the configured cross-encoder. Without
+// a reranker the chunks are returned unchanged.
+func (e *Engine) Rerank(ctx context.Context, query string, chunks []SearchChunk, topN int) ([]SearchChunk, error) {
+	if e.reranker == nil || len(chunks) < 2 || strings.TrimSpace(query) == "" {
+		return chunks, nil
+	}
+	return e.reranker.Rerank(ctx, query, chunks, topN)
+}
+
 // IndexAll processes all graph nodes, converts them to embeddings, and adds them to the index.
 func (e *Engine) IndexAll(ctx context.Context) error {
 	return e.IndexAllWithOptions(ctx, IndexingOptions{})
@@ -93,8 +235,9 @@ func (e *Engine) IndexAllWithOptions(ctx context.Context, opts IndexingOptions)
 	}
 
 	chunks := e.PrepareSearchChunks()
+	coarse := e.coarseChunksFor(ctx, chunks)
 	chunks = limitChunksByBudget(chunks, opts.MaxChunksPerRun)
-	return e.embedChunks(ctx, chunks)
+	return e.embedChunks(ctx, append(chunks, coarse...))
 }
 
 // IndexIncremental updates embeddings only for the specified files and removes deleted ones.
@@ -111,7 +254,7 @@ func (e *Engine) IndexIncrementalWithOptions(ctx context.Context, updatedFiles [
 	// 1. Remove chunks for deleted files
 	// Note: Chunk ID currently equals the filepath for file-level chunks
 	if len(deletedFiles) > 0 {
-		if err := e.index.Delete(ctx, deletedFiles); err != nil {
+		if err := e.deleteFromIndex(ctx, deletedFiles); err != nil {
 			return fmt.Errorf("failed to delete stale chunks: %w", err)
 		}
 	}
@@ -119,12 +262,13 @@ func (e *Engine) IndexIncrementalWithOptions(ctx context.Context, updatedFiles [
 	// 2. Process updated files
 	if len(updatedFiles) > 0 {
 		// Remove existing chunks for updated files first to avoid stale symbol IDs.
-		if err := e.index.Delete(ctx, updatedFiles); err != nil {
+		if err := e.deleteFromIndex(ctx, updatedFiles); err != nil {
 			return fmt.Errorf("failed to delete stale chunks for updated files: %w", err)
 		}
 
 		chunks := e.PrepareChunksForFiles(updatedFiles)
-		chunks = limitChunksByBudget(chunks, opts.MaxChunksPerRun)
+		coarse := e.coarseChunksFor(ctx, chunks)
+		chunks = append(limitChunksByBudget(chunks, opts.MaxChunksPerRun), coarse...)
 		if len(chunks) > 0 {
 			if err := e.embedChunks(ctx, chunks); err != nil {
 				return fmt.Errorf("failed to embed updated chunks: %w", err)
@@ -135,6 +279,16 @@ func (e *Engine) IndexIncrementalWithOptions(ctx context.Context, updatedFiles [
 	return nil
 }
 
+// coarseChunksFor builds the coarse summary layer for chunks. Summarization
+// failures only cost the coarse layer, so they are reported and skipped.
+func (e *Engine) coarseChunksFor(ctx context.Context, chunks []SearchChunk) []SearchChunk {
+	coarse, err := e.BuildCoarseChunks(ctx, chunks)
+	if err != nil {
+		fmt.Printf("⚠️ Coarse summaries incomplete: %v\n", err)
+	}
+	return coarse
+}
+
 func limitChunksByBudget(chunks []SearchChunk, max int) []SearchChunk {
 	if max <= 0 || len(chunks) <= max {
 		return chunks
@@ -195,73 +349,400 @@ func limitChunksByBudget(chunks []SearchChunk, max int) []SearchChunk {
 }
 
 func (e *Engine) embedChunks(ctx context.Context, chunks []SearchChunk) error {
+	prepared := len(chunks)
 	chunks = e.filterChunksForEmbedding(ctx, chunks)
+	e.indexStats.Prepared += prepared
+	e.indexStats.Unchanged += prepared - len(chunks)
 	if len(chunks) == 0 {
 		return nil
 	}
 
+	// Chunks with identical content share one vector: reuse what the index
+	// already holds or the embedding cache remembers, and embed each
+	// remaining content hash once.
+	reused := e.existingEmbeddingsByHash(ctx, chunks)
+	cached := e.cachedEmbeddings(ctx, e.embeddingModel, chunkHashes(chunks), reused)
+	if len(cached) > 0 && reused == nil {
+		reused = make(map[string][]float32, len(cached))
+	}
+	for h, vec := range cached {
+		reused[h] = vec
+	}
 	var texts []string
-	for _, c := range chunks {
-		texts = append(texts, c.ToEmbeddableText())
+	textIndex := make([]int, len(chunks))
+	firstByHash := make(map[string]int)
+	for i, c := range chunks {
+		textIndex[i] = -1
+		if c.ContentHash != "" {
+			if _, ok := reused[c.ContentHash]; ok {
+				continue
+			}
+			if j, ok := firstByHash[c.ContentHash]; ok {
+				textIndex[i] = j
+				continue
+			}
+			firstByHash[c.ContentHash] = len(texts)
+		}
+		textIndex[i] = len(texts)
+		texts = append(texts, TruncateTokens(e.tokenizer, c.ToEmbeddableText(), e.maxEmbedTokens))
 	}
 
-	vectors, err := e.embedder.Embed(ctx, texts)
-	if err != nil {
-		return fmt.Errorf("failed to generate embeddings: %w", err)
+	var vectors [][]float32
+	if len(texts) > 0 {
+		var err error
+		vectors, err = e.embedTexts(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to generate embeddings: %w", err)
+		}
 	}
 
+	fresh := make(map[string][]float32)
 	var items []VectorItem
 	for i, chunk := range chunks {
+		vec := reused[chunk.ContentHash]
+		if textIndex[i] >= 0 {
+			vec = vectors[textIndex[i]]
+			if chunk.ContentHash != "" {
+				fresh[chunk.ContentHash] = vec
+			}
+		} else if _, ok := cached[chunk.ContentHash]; ok {
+			e.indexStats.Cached++
+		}
 		items = append(items, VectorItem{
 			Chunk:     chunk,
-			Embedding: vectors[i],
+			Embedding: vec,
 		})
 	}
+	if !e.dryRun {
+		e.cacheEmbeddings(ctx, e.embeddingModel, fresh)
+	}
+	if e.dualEmbeddings {
+		if err := e.attachCodeEmbeddings(ctx, items); err != nil {
+			return err
+		}
+	}
+	deduped := len(chunks) - len(texts)
+	e.indexStats.Embedded += len(texts)
+	e.indexStats.Deduplicated += deduped
+	if deduped > 0 {
+		fmt.Printf("♻️  Reused %d embeddings for duplicate content (%d embedded)\n", deduped, len(texts))
+	}
+	if e.dryRun {
+		return nil
+	}
 
 	return e.index.Add(ctx, items)
 }
 
+// existingEmbeddingsByHash returns stored vectors for the content hashes of
+// chunks when the index can look them up.
+func (e *Engine) existingEmbeddingsByHash(ctx context.Context, chunks []SearchChunk) map[string][]float32 {
+	reader, ok := e.index.(EmbeddingHashReader)
+	if !ok {
+		return nil
+	}
+	found, err := reader.GetEmbeddingsByContentHash(ctx, chunkHashes(chunks))
+	if err != nil {
+		return nil
+	}
+	return found
+}
+
+// chunkHashes returns the distinct non-empty content hashes of chunks.
+func chunkHashes(chunks []SearchChunk) []string {
+	seen := make(map[string]bool)
+	var hashes []string
+	for _, c := range chunks {
+		if c.ContentHash != "" && !seen[c.ContentHash] {
+			seen[c.ContentHash] = true
+			hashes = append(hashes, c.ContentHash)
+		}
+	}
+	return hashes
+}
+
+// IndexStats returns embedding counters accumulated by this engine's
+// indexing runs.
+func (e *Engine) IndexStats() IndexStats {
+	return e.indexStats
+}
+
 // SearchRelated finds semantically similar code units for a given chunk to provide better context.
 func (e *Engine) SearchRelated(ctx context.Context, chunk SearchChunk, topK int) ([]SearchChunk, error) {
 	return e.SearchByText(ctx, chunk.ToEmbeddableText(), topK+1, chunk.ID)
 }
 
-// SearchByText finds code units semantically similar to the provided query text.
+// SearchByText finds code units relevant to the query text. Vector hits are
+// fused with BM25 keyword hits (reciprocal rank fusion) when the index
+// supports keyword search, so exact identifier queries rank correctly even
+// when embeddings are weak.
 func (e *Engine) SearchByText(ctx context.Context, query string, topK int, excludeID string) ([]SearchChunk, error) {
-	if e.embedder == nil || e.index == nil {
+	return e.SearchByTextFiltered(ctx, query, topK, excludeID, SearchFilter{})
+}
+
+// SearchByTextFiltered is SearchByText restricted to chunks passing filter.
+func (e *Engine) SearchByTextFiltered(ctx context.Context, query string, topK int, excludeID string, filter SearchFilter) ([]SearchChunk, error) {
+	if e.index == nil {
+		return nil, nil
+	}
+	filter = e.scoped(filter)
+	keyword, hasKeyword := e.index.(KeywordSearcher)
+	if e.embedder == nil && !hasKeyword {
 		return nil, nil
 	}
 
-	queryKey := strings.TrimSpace(query)
-	var queryVec []float32
-	if cached, ok := e.queryVecCache[queryKey]; ok && len(cached) > 0 {
-		queryVec = cached
-	} else {
-		// 1. Get embedding for the query text
-		vectors, err := e.embedder.Embed(ctx, []string{query})
-		if err != nil || len(vectors) == 0 {
+	// Over-fetch when fusion or graph proximity can reorder results.
+	proximity := e.graphProximity(chunkSymbolID(excludeID), 2)
+	fetchK := topK
+	if len(proximity) > 0 || hasKeyword {
+		fetchK = topK * 3
+	}
+
+	var items []VectorItem
+	similarity := make(map[string]float32)
+	if e.embedder != nil {
+		queryVec, err := e.queryVector(ctx, query)
+		if err != nil || len(queryVec) == 0 {
 			return nil, err
 		}
-		queryVec = vectors[0]
-		if queryKey != "" {
-			e.queryVecCache[queryKey] = queryVec
+		items, err = e.index.Search(ctx, queryVec, fetchK, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			similarity[item.Chunk.ID] = item.Score
+		}
+	}
+	if hasKeyword {
+		hits, err := keyword.KeywordSearch(ctx, query, fetchK, filter)
+		if err != nil {
+			return nil, err
 		}
+		items = fuseRRF(items, hits)
+	}
+	if len(proximity) > 0 {
+		items = rerankByGraph(items, proximity, topK)
 	}
 
-	// 2. Search index
-	items, err := e.index.Search(ctx, queryVec, topK)
-	if err != nil {
-		return nil, err
+	return searchResults(items, similarity, topK, excludeID), nil
+}
+
+// SearchByTexts runs several filtered searches at once: all query vectors are
+// embedded in one provider batch and, when the index implements
+// BatchSearcher, scored in one index pass. Results are returned per query, in
+// query order, and rank like SearchByTextFiltered without an exclusion.
+func (e *Engine) SearchByTexts(ctx context.Context, queries []string, topK int, filter SearchFilter) ([][]SearchChunk, error) {
+	if e.index == nil || len(queries) == 0 {
+		return make([][]SearchChunk, len(queries)), nil
+	}
+	filter = e.scoped(filter)
+	keyword, hasKeyword := e.index.(KeywordSearcher)
+	fetchK := topK
+	if hasKeyword {
+		fetchK = topK * 3
+	}
+
+	perQuery := make([][]VectorItem, len(queries))
+	if e.embedder != nil {
+		vectors, err := e.queryVectors(ctx, queries)
+		if err != nil {
+			return nil, err
+		}
+		if batch, ok := e.index.(BatchSearcher); ok {
+			var live [][]float32
+			var liveIdx []int
+			for i, v := range vectors {
+				if len(v) > 0 {
+					live = append(live, v)
+					liveIdx = append(liveIdx, i)
+				}
+			}
+			if len(live) > 0 {
+				hits, err := batch.SearchBatch(ctx, live, fetchK, filter)
+				if err != nil {
+					return nil, err
+				}
+				for j, i := range liveIdx {
+					if j < len(hits) {
+						perQuery[i] = hits[j]
+					}
+				}
+			}
+		} else {
+			for i, v := range vectors {
+				if len(v) == 0 {
+					continue
+				}
+				hits, err := e.index.Search(ctx, v, fetchK, filter)
+				if err != nil {
+					return nil, err
+				}
+				perQuery[i] = hits
+			}
+		}
+	} else if !hasKeyword {
+		return make([][]SearchChunk, len(queries)), nil
+	}
+
+	out := make([][]SearchChunk, len(queries))
+	for i, q := range queries {
+		items := perQuery[i]
+		similarity := make(map[string]float32, len(items))
+		for _, item := range items {
+			similarity[item.Chunk.ID] = item.Score
+		}
+		if hasKeyword {
+			hits, err := keyword.KeywordSearch(ctx, q, fetchK, filter)
+			if err != nil {
+				return nil, err
+			}
+			items = fuseRRF(items, hits)
+		}
+		out[i] = searchResults(items, similarity, topK, "")
 	}
+	return out, nil
+}
 
+// searchResults converts ranked items to chunks, skipping excludeID and
+// reporting cosine similarity as the score where the vector search saw the item.
+func searchResults(items []VectorItem, similarity map[string]float32, topK int, excludeID string) []SearchChunk {
 	var results []SearchChunk
 	for _, item := range items {
 		if item.Chunk.ID == excludeID {
 			continue // Skip exclusion target (usually itself)
 		}
-		results = append(results, item.Chunk)
+		if topK > 0 && len(results) >= topK {
+			break
+		}
+		chunk := item.Chunk
+		chunk.Score = float64(item.Score)
+		if sim, ok := similarity[chunk.ID]; ok {
+			chunk.Score = float64(sim)
+		}
+		results = append(results, chunk)
+	}
+	return results
+}
+
+// Graph proximity boosts, scaled by the confidence of the connecting edges.
+const (
+	graphBoostDirect = 0.2
+	graphBoostTwoHop = 0.1
+)
+
+// graphProximity returns a score boost for nodes within maxDepth hops of
+// sourceID. The boost decays with hop distance and with the confidence of the
+// strongest path, so guessed edges pull results up less than resolved ones.
+func (e *Engine) graphProximity(sourceID string, maxDepth int) map[string]float32 {
+	if e.graph == nil || sourceID == "" {
+		return nil
+	}
+	if _, ok := e.graph.Nodes[sourceID]; !ok {
+		return nil
+	}
+	type arc struct {
+		to   string
+		conf float64
+	}
+	adj := make(map[string][]arc)
+	for _, edge := range e.graph.Edges {
+		if graph.IsPackageRelation(edge.Kind) {
+			continue
+		}
+		conf := edge.Confidence
+		if conf <= 0 {
+			conf = 0.5
+		}
+		if conf > 1 {
+			conf = 1
+		}
+		adj[edge.From] = append(adj[edge.From], arc{to: edge.To, conf: conf})
+		adj[edge.To] = append(adj[edge.To], arc{to: edge.From, conf: conf})
+	}
+
+	strength := map[string]float64{sourceID: 1}
+	depth := map[string]int{sourceID: 0}
+	frontier := []string{sourceID}
+	for d := 1; d <= maxDepth && len(frontier) > 0; d++ {
+		var next []string
+		for _, id := range frontier {
+			for _, a := range adj[id] {
+				if prev, seen := depth[a.to]; seen && prev < d {
+					continue
+				}
+				s := strength[id] * a.conf
+				if _, seen := depth[a.to]; !seen {
+					depth[a.to] = d
+					next = append(next, a.to)
+				}
+				if s > strength[a.to] {
+					strength[a.to] = s
+				}
+			}
+		}
+		frontier = next
+	}
+
+	out := make(map[string]float32, len(depth))
+	for id, d := range depth {
+		switch d {
+		case 1:
+			out[id] = float32(graphBoostDirect * strength[id])
+		case 2:
+			out[id] = float32(graphBoostTwoHop * strength[id])
+		}
+	}
+	return out
+}
+
+// rerankByGraph adds graph proximity to the index similarity and keeps topK.
+// Indexes that do not report scores fall back to their rank order.
+func rerankByGraph(items []VectorItem, proximity map[string]float32, topK int) []VectorItem {
+	scored := false
+	for _, item := range items {
+		if item.Score != 0 {
+			scored = true
+			break
+		}
+	}
+	type ranked struct {
+		item  VectorItem
+		score float32
+	}
+	out := make([]ranked, 0, len(items))
+	for i, item := range items {
+		base := item.Score
+		if !scored {
+			base = 1 - float32(i)/float32(len(items))
+		}
+		boost := proximity[chunkSymbolID(item.Chunk.ID)]
+		for _, src := range item.Chunk.Sources {
+			if b := proximity[src.SymbolID]; b > boost {
+				boost = b
+			}
+		}
+		out = append(out, ranked{item: item, score: base + boost})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].score > out[j].score
+	})
+	if topK > 0 && len(out) > topK {
+		out = out[:topK]
 	}
-	return results, nil
+	result := make([]VectorItem, len(out))
+	for i, r := range out {
+		result[i] = r.item
+		result[i].Score = r.score
+	}
+	return result
+}
+
+// chunkSymbolID maps a symbol chunk or segment ID back to its graph node ID.
+func chunkSymbolID(chunkID string) string {
+	if i := strings.Index(chunkID, "::seg:"); i >= 0 {
+		return chunkID[:i]
+	}
+	return chunkID
 }
 
 func (e *Engine) filterChunksForEmbedding(ctx context.Context, chunks []SearchChunk) []SearchChunk {
@@ -294,16 +775,21 @@ func (e *Engine) filterChunksForEmbedding(ctx context.Context, chunks []SearchCh
 	}
 
 	out := make([]SearchChunk, 0, len(chunks))
+	var unchanged []SearchChunk
 	for _, c := range chunks {
 		id := strings.TrimSpace(c.ID)
 		if id == "" {
 			continue
 		}
 		if oldHash, ok := existing[id]; ok && oldHash != "" && c.ContentHash != "" && oldHash == c.ContentHash {
+			unchanged = append(unchanged, c)
 			continue
 		}
 		out = append(out, c)
 	}
+	if e.dualEmbeddings {
+		out = append(out, e.chunksMissingCodeView(ctx, unchanged)...)
+	}
 	return out
 }
 
@@ -334,7 +820,7 @@ func (e *Engine) PrepareChunksForFiles(filepaths []string) []SearchChunk {
 	fileNodes := make(map[string][]*graph.Node)
 	for id, node := range e.graph.Nodes {
 		if targetFiles[node.Unit.Filepath] {
-			if !e.isDocRelevantNode(id, node) {
+			if !e.isDocRelevantNode(id, node) || !e.inScope(node) {
 				continue
 			}
 			fileNodes[node.Unit.Filepath] = append(fileNodes[node.Unit.Filepath], node)
@@ -342,12 +828,13 @@ func (e *Engine) PrepareChunksForFiles(filepaths []string) []SearchChunk {
 	}
 
 	// 1) Symbol-first chunks
+	implements := e.implementsIndex()
 	for _, nodes := range fileNodes {
 		for _, node := range nodes {
 			if node == nil || node.Unit == nil {
 				continue
 			}
-			symbolChunks := e.createSymbolChunksForNode(node)
+			symbolChunks := e.createSymbolChunksForNode(node, implements)
 			chunks = append(chunks, symbolChunks...)
 		}
 	}
@@ -400,13 +887,15 @@ func (e *Engine) PrepareChunksForFiles(filepaths []string) []SearchChunk {
 
 			// Aggregate Content (Actual Code)
 			// Only include actual code for Structs, Interfaces, and Functions
+			var content string
 			if node.Unit.UnitType == "struct" || node.Unit.UnitType == "interface" || node.Unit.UnitType == "function" || node.Unit.UnitType == "method" {
-				fmt.Fprintf(&contentBuilder, "// %s %s\n%s\n\n", node.Unit.UnitType, node.Unit.Name, node.Unit.Content)
+				content = e.graph.Content(node.Unit)
+				fmt.Fprintf(&contentBuilder, "// %s %s\n%s\n\n", node.Unit.UnitType, node.Unit.Name, content)
 			}
 
 			// Aggregate Signature
 			if node.Unit.UnitType == "struct" || node.Unit.UnitType == "interface" {
-				fmt.Fprintf(&sigBuilder, "%s\n\n", e.getConciseSignature(node.Unit))
+				fmt.Fprintf(&sigBuilder, "%s\n\n", e.getConciseSignature(node.Unit, content))
 			}
 
 			// Aggregate dependencies
@@ -421,13 +910,8 @@ func (e *Engine) PrepareChunksForFiles(filepaths []string) []SearchChunk {
 		chunk.Description = descBuilder.String()
 		chunk.Signature = sigBuilder.String()
 
-		// Truncate content to avoid excessive tokens (e.g., 3000 chars)
-		rawContent := contentBuilder.String()
-		if len(rawContent) > 3000 {
-			chunk.Content = rawContent[:3000] + "\n... (truncated)"
-		} else {
-			chunk.Content = rawContent
-		}
+		// Truncate content to avoid excessive tokens
+		chunk.Content = TruncateTokens(e.tokenizer, contentBuilder.String(), chunkContentTokens)
 
 		for dep := range depsSet {
 			chunk.Dependencies = append(chunk.Dependencies, dep)
@@ -438,11 +922,90 @@ func (e *Engine) PrepareChunksForFiles(filepaths []string) []SearchChunk {
 
 		chunks = append(chunks, chunk)
 	}
+
+	// 3) Package summary chunks for every package touched by the files.
+	touched := make(map[string]bool)
+	for path := range fileNodes {
+		touched[filepath.ToSlash(filepath.Dir(path))] = true
+	}
+	for _, ps := range e.PackageSummaries() {
+		if touched[ps.Dir] && len(ps.Exported) > 0 {
+			chunks = append(chunks, e.packageSummaryChunk(ps))
+		}
+	}
+
 	sortChunksByPriority(chunks)
 	fmt.Printf("📦 Prepared %d Chunks (symbol-first) from %d files\n", len(chunks), len(filepaths))
 	return chunks
 }
 
+// PackageSummaries returns the cached per-package summaries of the graph.
+func (e *Engine) PackageSummaries() []graph.PackageSummary {
+	e.packagesOnce.Do(func() {
+		e.packages = e.graph.PackageSummaries()
+	})
+	return e.packages
+}
+
+// packageSummaryChunk turns a package summary into an overview-grade chunk
+// citing the package's most used exported symbols.
+func (e *Engine) packageSummaryChunk(ps graph.PackageSummary) SearchChunk {
+	const maxSources = 8
+	u := ps.Symbol()
+	chunk := SearchChunk{
+		ID:           ps.ID,
+		FilePath:     ps.Dir,
+		Name:         u.Name,
+		UnitType:     u.UnitType,
+		Package:      ps.Package,
+		Description:  u.Description,
+		Content:      u.Content,
+		ContentHash:  contentHash(u.Content),
+		Dependencies: rankedPackageNames(ps.DependsOn),
+		UsedBy:       rankedPackageNames(ps.UsedBy),
+	}
+	for _, id := range ps.SymbolIDs {
+		if len(chunk.Sources) >= maxSources {
+			break
+		}
+		n := e.graph.Nodes[id]
+		if n == nil || n.Unit == nil || !isExported(n.Unit.Name) {
+			continue
+		}
+		chunk.Sources = append(chunk.Sources, ChunkSource{
+			SymbolID:   n.Unit.ID,
+			FilePath:   n.Unit.Filepath,
+			StartLine:  n.Unit.StartLine,
+			EndLine:    n.Unit.EndLine,
+			Relation:   "primary",
+			Confidence: 0.85,
+		})
+		if c := e.Centrality(id); c > chunk.Centrality {
+			chunk.Centrality = c
+		}
+	}
+	return chunk
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func rankedPackageNames(counts map[string]int) []string {
+	dirs := make([]string, 0, len(counts))
+	for d := range counts {
+		dirs = append(dirs, d)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if counts[dirs[i]] != counts[dirs[j]] {
+			return counts[dirs[i]] > counts[dirs[j]]
+		}
+		return dirs[i] < dirs[j]
+	})
+	return dirs
+}
+
 func isExported(name string) bool {
 	if len(name) == 0 {
 		return false
@@ -453,7 +1016,7 @@ func isExported(name string) bool {
 // isDocRelevantNode keeps documentation scope focused while still capturing
 // internal changes that are connected to public symbols.
 func (e *Engine) isDocRelevantNode(id string, node *graph.Node) bool {
-	if node == nil || node.Unit == nil {
+	if node == nil || node.Unit == nil || node.Unit.UnitType == graph.UnitTypeTest || node.Unit.UnitType == graph.UnitTypeExternal || node.Unit.UnitType == graph.UnitTypePackage {
 		return false
 	}
 	if isExported(node.Unit.Name) {
@@ -529,7 +1092,29 @@ func (e *Engine) GetChunkByID(id string) (SearchChunk, bool) {
 
 // CreateChunk builds a structured SearchChunk from a graph node.
 func (e *Engine) CreateChunk(id string, node *graph.Node) SearchChunk {
+	return e.createChunk(id, node, e.implementsIndex())
+}
+
+// implementsIndex maps unit IDs to the names of the interfaces they
+// implement. Callers building many chunks build it once and pass it to
+// createChunk.
+func (e *Engine) implementsIndex() map[string][]string {
+	index := make(map[string][]string)
+	for _, edge := range e.graph.Edges {
+		if edge.Kind != graph.RelationImplements {
+			continue
+		}
+		if target, ok := e.graph.Nodes[edge.To]; ok {
+			index[edge.From] = append(index[edge.From], target.Unit.Name)
+		}
+	}
+	return index
+}
+
+func (e *Engine) createChunk(id string, node *graph.Node, implements map[string][]string) SearchChunk {
 	u := node.Unit
+	// In a partial graph this is the one store read for the symbol body.
+	content := e.graph.Content(u)
 	chunk := SearchChunk{
 		ID:          id,
 		FilePath:    u.Filepath,
@@ -537,9 +1122,11 @@ func (e *Engine) CreateChunk(id string, node *graph.Node) SearchChunk {
 		UnitType:    u.UnitType,
 		Package:     u.Package,
 		Description: u.Description,
-		Signature:   e.getConciseSignature(u),
-		Content:     u.Content,
+		Signature:   e.getConciseSignature(u, content),
+		Content:     content,
 		ContentHash: u.ContentHash,
+		Centrality:  e.Centrality(u.ID),
+		Component:   e.ComponentOf(u.ID),
 		Sources: []ChunkSource{
 			{
 				SymbolID:   u.ID,
@@ -560,39 +1147,47 @@ func (e *Engine) CreateChunk(id string, node *graph.Node) SearchChunk {
 		chunk.UsedBy = append(chunk.UsedBy, d.Unit.Name)
 	}
 
+	chunk.Implements = append(chunk.Implements, implements[id]...)
+
+	// Tests are cited as behavioral evidence for the symbol.
+	for _, t := range e.graph.TestsOf(id) {
+		chunk.TestedBy = append(chunk.TestedBy, t.Unit.Name)
+		chunk.Sources = append(chunk.Sources, ChunkSource{
+			SymbolID:   t.Unit.ID,
+			FilePath:   t.Unit.Filepath,
+			StartLine:  t.Unit.StartLine,
+			EndLine:    t.Unit.EndLine,
+			Relation:   "test",
+			Confidence: 0.8,
+		})
+	}
+
 	return chunk
 }
 
-func (e *Engine) createSymbolChunksForNode(node *graph.Node) []SearchChunk {
-	base := e.CreateChunk(node.Unit.ID, node)
-	base.Content = truncateChunkContent(base.Content, 1200)
+func (e *Engine) createSymbolChunksForNode(node *graph.Node, implements map[string][]string) []SearchChunk {
+	base := e.createChunk(node.Unit.ID, node, implements)
+	full := base.Content
+	base.Content = TruncateTokens(e.tokenizer, base.Content, symbolContentTokens)
 	if !shouldSegmentChunk(base) {
 		return []SearchChunk{base}
 	}
 
-	const (
-		segmentLines   = 40
-		segmentOverlap = 8
-		maxSegments    = 3
-	)
-	lines := strings.Split(base.Content, "\n")
-	step := segmentLines - segmentOverlap
-	if step <= 0 {
-		step = segmentLines
+	// Segments are cut from the full body so long symbols stay searchable
+	// past the truncated base chunk.
+	spans := e.chunking.segmentSpans(full, node.Unit.Language)
+	if len(spans) == 0 {
+		return []SearchChunk{base}
 	}
-
-	segments := make([]SearchChunk, 0, maxSegments+1)
+	lines := strings.Split(full, "\n")
+	segments := make([]SearchChunk, 0, len(spans)+1)
 	segments = append(segments, base)
 
-	for idx, start := 0, 0; start < len(lines) && idx < maxSegments; idx, start = idx+1, start+step {
-		end := start + segmentLines
-		if end > len(lines) {
-			end = len(lines)
-		}
-		if end <= start {
+	for idx, span := range spans {
+		if span.end <= span.start {
 			break
 		}
-		block := strings.TrimSpace(strings.Join(lines[start:end], "\n"))
+		block := strings.TrimSpace(strings.Join(lines[span.start:span.end], "\n"))
 		if block == "" {
 			continue
 		}
@@ -602,7 +1197,7 @@ func (e *Engine) createSymbolChunksForNode(node *graph.Node) []SearchChunk {
 		seg.Description = fmt.Sprintf("%s [segment %d]", strings.TrimSpace(base.Description), idx+1)
 		seg.Content = block
 		seg.ContentHash = fmt.Sprintf("%s::seg:%d", base.ContentHash, idx+1)
-		seg.Sources = segmentSources(base.Sources, start, end)
+		seg.Sources = segmentSources(base.Sources, span.start, span.end)
 		segments = append(segments, seg)
 	}
 	return segments
@@ -611,7 +1206,7 @@ func (e *Engine) createSymbolChunksForNode(node *graph.Node) []SearchChunk {
 func shouldSegmentChunk(c SearchChunk) bool {
 	switch c.UnitType {
 	case "function", "method":
-		return lineCount(c.Content) > 45
+		return true
 	default:
 		return false
 	}
@@ -647,11 +1242,13 @@ func segmentSources(src []ChunkSource, segStartOffset int, segEndOffset int) []C
 	return out
 }
 
-func (e *Engine) getConciseSignature(u *graph.Symbol) string {
+// getConciseSignature prefers the extracted signature and falls back to the
+// first code line of content, the symbol's body.
+func (e *Engine) getConciseSignature(u *graph.Symbol, content string) string {
 	if u != nil && strings.TrimSpace(u.Metadata.Signature) != "" {
 		return strings.TrimSpace(u.Metadata.Signature)
 	}
-	lines := strings.Split(u.Content, "\n")
+	lines := strings.Split(content, "\n")
 	if len(lines) > 0 {
 		for _, line := range lines {
 			trimmed := strings.TrimSpace(line)
@@ -671,13 +1268,6 @@ func getFileName(path string) string {
 	return path
 }
 
-func truncateChunkContent(content string, max int) string {
-	if max <= 0 || len(content) <= max {
-		return content
-	}
-	return content[:max] + "\n... (truncated)"
-}
-
 func containsChunkID(chunks []SearchChunk, id string) bool {
 	for _, c := range chunks {
 		if c.ID == id {
@@ -703,9 +1293,12 @@ func sortChunksByPriority(chunks []SearchChunk) {
 
 func chunkPriority(c SearchChunk) int {
 	score := 0
-	if c.UnitType == "file_module" {
+	switch c.UnitType {
+	case "file_module":
 		score += 5
-	} else {
+	case graph.UnitTypePackageSummary:
+		score += 30
+	default:
 		score += 40
 	}
 	if isExported(c.Name) {
@@ -719,6 +1312,7 @@ func chunkPriority(c SearchChunk) int {
 	}
 	score += minInt(len(c.Dependencies), 8)
 	score += minInt(len(c.UsedBy), 8)
+	score += int(c.Centrality * 30)
 	return score
 }
 
@@ -37,7 +37,7 @@ func TestEngine_IndexAll(t *testing.T) {
 	g.LinkRelations()
 
 	embedder := &mockEmbedder{dim: 768}
-	index := NewMemoryIndex(g)
+	index := NewMemoryIndex()
 	engine := NewEngine(g, embedder, index)
 
 	err := engine.IndexAll(context.Background())
@@ -107,6 +107,40 @@ func TestEngine_CreateChunk(t *testing.T) {
 	})
 }
 
+func TestEngine_CreateChunk_ListsImplementedInterfaces(t *testing.T) {
+	g := graph.NewGraph()
+	g.AddUnit(&extractor.CodeUnit{ID: "store:Store:1", Name: "Store", UnitType: "interface", Package: "store"})
+	g.AddUnit(&extractor.CodeUnit{ID: "store:Closer:1", Name: "Closer", UnitType: "interface", Package: "store"})
+	g.AddUnit(&extractor.CodeUnit{ID: "store:DB:1", Name: "DB", UnitType: "struct", Package: "store"})
+	g.Edges = append(g.Edges,
+		graph.Edge{From: "store:DB:1", To: "store:Store:1", Kind: graph.RelationImplements},
+		graph.Edge{From: "store:DB:1", To: "store:Closer:1", Kind: graph.RelationImplements},
+	)
+	engine := NewEngine(g, nil, nil)
+
+	assert.Equal(t, []string{"Store", "Closer"}, engine.CreateChunk("store:DB:1", g.Nodes["store:DB:1"]).Implements)
+	implements := engine.implementsIndex()
+	assert.Equal(t, []string{"Store", "Closer"}, engine.createChunk("store:DB:1", g.Nodes["store:DB:1"], implements).Implements)
+	assert.Empty(t, engine.createChunk("store:Store:1", g.Nodes["store:Store:1"], implements).Implements)
+}
+
+func TestEngine_CreateChunk_PartialGraphLoadsContent(t *testing.T) {
+	g := graph.NewGraph()
+	g.AddUnit(&extractor.CodeUnit{ID: "file1:Open:1", Name: "Open", UnitType: "function", Package: "store"})
+	bodies := map[string]string{"file1:Open:1": "// Open opens the store.\nfunc Open(path string) (*DB, error) {\n\treturn nil, nil\n}"}
+	var loads int
+	g.SetContentLoader(func(id string) (string, error) {
+		loads++
+		return bodies[id], nil
+	})
+
+	chunk := NewEngine(g, nil, nil).CreateChunk("file1:Open:1", g.Nodes["file1:Open:1"])
+	assert.Equal(t, bodies["file1:Open:1"], chunk.Content)
+	assert.Equal(t, "func Open(path string) (*DB, error) {", chunk.Signature)
+	assert.Equal(t, 1, loads, "one store read per chunk")
+	assert.Empty(t, g.Nodes["file1:Open:1"].Unit.Content)
+}
+
 func TestEngine_IndexIncrementalWithOptions_BudgetLimit(t *testing.T) {
 	g := graph.NewGraph()
 	g.AddUnit(&extractor.CodeUnit{
@@ -124,7 +158,7 @@ func TestEngine_IndexIncrementalWithOptions_BudgetLimit(t *testing.T) {
 	g.LinkRelations()
 
 	embedder := &mockEmbedder{dim: 8}
-	index := NewMemoryIndex(g)
+	index := NewMemoryIndex()
 	engine := NewEngine(g, embedder, index)
 
 	err := engine.IndexIncrementalWithOptions(
@@ -154,7 +188,7 @@ func TestEngine_IndexAllWithOptions_BudgetLimit(t *testing.T) {
 	g.LinkRelations()
 
 	embedder := &mockEmbedder{dim: 8}
-	index := NewMemoryIndex(g)
+	index := NewMemoryIndex()
 	engine := NewEngine(g, embedder, index)
 
 	err := engine.IndexAllWithOptions(context.Background(), IndexingOptions{MaxChunksPerRun: 1})
@@ -185,7 +219,7 @@ func TestEngine_CreateSymbolChunksForNode_SegmentsLongFunction(t *testing.T) {
 
 	engine := NewEngine(g, nil, nil)
 	node := g.Nodes[unit.ID]
-	parts := engine.createSymbolChunksForNode(node)
+	parts := engine.createSymbolChunksForNode(node, engine.implementsIndex())
 	require.Greater(t, len(parts), 1)
 	assert.Equal(t, "function", parts[0].UnitType)
 
@@ -199,3 +233,183 @@ func TestEngine_CreateSymbolChunksForNode_SegmentsLongFunction(t *testing.T) {
 	}
 	assert.True(t, foundSegment)
 }
+
+type scoredIndex struct {
+	items []VectorItem
+}
+
+func (s *scoredIndex) Add(ctx context.Context, items []VectorItem) error { return nil }
+func (s *scoredIndex) Delete(ctx context.Context, ids []string) error    { return nil }
+func (s *scoredIndex) Search(ctx context.Context, queryVector []float32, topK int, filter SearchFilter) ([]VectorItem, error) {
+	if topK < len(s.items) {
+		return s.items[:topK], nil
+	}
+	return s.items, nil
+}
+
+func TestEngine_SearchRelated_BlendsEdgeConfidence(t *testing.T) {
+	g := graph.NewGraph()
+	for _, id := range []string{"src", "strong", "weak", "far"} {
+		g.AddSymbol(&graph.Symbol{ID: id, Name: id, UnitType: "function"})
+	}
+	g.Edges = []graph.Edge{
+		{From: "src", To: "strong", Kind: graph.RelationCalls, Confidence: 0.95},
+		{From: "src", To: "weak", Kind: graph.RelationCalls, Confidence: 0.3},
+	}
+	index := &scoredIndex{items: []VectorItem{
+		{Chunk: SearchChunk{ID: "far"}, Score: 0.80},
+		{Chunk: SearchChunk{ID: "weak"}, Score: 0.75},
+		{Chunk: SearchChunk{ID: "strong::seg:1"}, Score: 0.70},
+		{Chunk: SearchChunk{ID: "src"}, Score: 0.99},
+	}}
+	engine := NewEngine(g, &mockEmbedder{dim: 4}, index)
+
+	results, err := engine.SearchRelated(context.Background(), SearchChunk{ID: "src", Name: "src"}, 3)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	// strong: 0.70+0.19, weak: 0.75+0.06, far: 0.80
+	assert.Equal(t, "strong::seg:1", results[0].ID)
+	assert.Equal(t, "weak", results[1].ID)
+	assert.Equal(t, "far", results[2].ID)
+}
+
+type textCountingEmbedder struct {
+	texts int
+}
+
+func (t *textCountingEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	t.texts += len(texts)
+	out := make([][]float32, len(texts))
+	for i := range texts {
+		out[i] = []float32{float32(t.texts), 1}
+	}
+	return out, nil
+}
+
+func (t *textCountingEmbedder) Dimension() int { return 2 }
+
+func TestEngine_EmbedChunksDeduplicatesByContentHash(t *testing.T) {
+	ctx := context.Background()
+	em := &textCountingEmbedder{}
+	idx := NewMemoryIndex()
+	engine := NewEngine(graph.NewGraph(), em, idx)
+
+	require.NoError(t, engine.embedChunks(ctx, []SearchChunk{
+		{ID: "a/gen.go:New:1", Name: "New", ContentHash: "same"},
+		{ID: "b/gen.go:New:1", Name: "New", ContentHash: "same"},
+		{ID: "c/other.go:Run:1", Name: "Run", ContentHash: "other"},
+	}))
+	assert.Equal(t, 2, em.texts)
+
+	// A later copy of the same content reuses the stored vector.
+	require.NoError(t, engine.embedChunks(ctx, []SearchChunk{
+		{ID: "d/gen.go:New:1", Name: "New", ContentHash: "same"},
+	}))
+	assert.Equal(t, 2, em.texts)
+
+	vecs, err := idx.GetEmbeddings(ctx, []string{"a/gen.go:New:1", "b/gen.go:New:1", "d/gen.go:New:1"})
+	require.NoError(t, err)
+	assert.Equal(t, vecs["a/gen.go:New:1"], vecs["b/gen.go:New:1"])
+	assert.Equal(t, vecs["a/gen.go:New:1"], vecs["d/gen.go:New:1"])
+
+	stats := engine.IndexStats()
+	assert.Equal(t, 2, stats.Embedded)
+	assert.Equal(t, 2, stats.Deduplicated)
+}
+
+type fixedEmbedder struct {
+	vec []float32
+}
+
+func (f fixedEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i := range texts {
+		out[i] = f.vec
+	}
+	return out, nil
+}
+
+func (f fixedEmbedder) Dimension() int { return len(f.vec) }
+
+func TestEngine_SearchByTextReportsSimilarity(t *testing.T) {
+	ctx := context.Background()
+	idx := NewMemoryIndex()
+	require.NoError(t, idx.Add(ctx, []VectorItem{
+		{Chunk: SearchChunk{ID: "near", Name: "Near"}, Embedding: []float32{1, 0}},
+		{Chunk: SearchChunk{ID: "far", Name: "Far"}, Embedding: []float32{0.6, 0.8}},
+	}))
+	engine := NewEngine(graph.NewGraph(), fixedEmbedder{vec: []float32{1, 0}}, idx)
+
+	hits, err := engine.SearchByText(ctx, "anything", 2, "")
+	require.NoError(t, err)
+	require.Len(t, hits, 2)
+	assert.Equal(t, "near", hits[0].ID)
+	assert.InDelta(t, 1.0, hits[0].Score, 1e-6)
+	assert.InDelta(t, 0.6, hits[1].Score, 1e-6, "cosine similarity survives keyword fusion")
+}
+
+func TestEngine_ScopeFilterExcludesPaths(t *testing.T) {
+	g := graph.NewGraph()
+	g.AddUnit(&extractor.CodeUnit{ID: "pkg/api.go:Serve:1", Name: "Serve", UnitType: "function", Package: "pkg", Filepath: "pkg/api.go", Content: "func Serve() {}"})
+	g.AddUnit(&extractor.CodeUnit{ID: "vendor/lib/lib.go:Helper:1", Name: "Helper", UnitType: "function", Package: "lib", Filepath: "vendor/lib/lib.go", Content: "func Helper() {}"})
+	g.LinkRelations()
+
+	engine := NewEngine(g, nil, nil)
+	engine.SetScopeFilter(SearchFilter{ExcludePaths: []string{"vendor/"}, Packages: []string{"ignored"}})
+	for _, c := range engine.PrepareSearchChunks() {
+		assert.NotContains(t, c.FilePath, "vendor/", c.ID)
+	}
+	assert.True(t, containsChunkID(engine.PrepareSearchChunks(), "pkg/api.go:Serve:1"), "positive criteria of the scope are ignored")
+}
+
+func TestEngine_PathScopeLimitsSearch(t *testing.T) {
+	ctx := context.Background()
+	idx := NewMemoryIndex()
+	require.NoError(t, idx.Add(ctx, []VectorItem{
+		{Chunk: SearchChunk{ID: "cmd", Name: "Main", FilePath: "cmd/docod/main.go"}, Embedding: []float32{1, 0}},
+		{Chunk: SearchChunk{ID: "internal", Name: "Run", FilePath: "internal/run/run.go"}, Embedding: []float32{1, 0}},
+		{Chunk: SearchChunk{ID: "top", Name: "Version", FilePath: "version.go"}, Embedding: []float32{1, 0}},
+	}))
+	ids := func(hits []SearchChunk) []string {
+		out := make([]string, 0, len(hits))
+		for _, h := range hits {
+			out = append(out, h.ID)
+		}
+		return out
+	}
+
+	shard := NewEngine(graph.NewGraph(), fixedEmbedder{vec: []float32{1, 0}}, idx)
+	shard.SetPathScope([]string{"internal/"}, nil)
+	hits, err := shard.SearchByText(ctx, "run", 5, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"internal"}, ids(hits))
+
+	root := NewEngine(graph.NewGraph(), fixedEmbedder{vec: []float32{1, 0}}, idx)
+	root.SetPathScope(nil, []string{"cmd", "internal"})
+	hits, err = root.SearchByText(ctx, "version", 5, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"top"}, ids(hits))
+}
+
+func TestEngine_SearchByTextsBatchesEmbedding(t *testing.T) {
+	ctx := context.Background()
+	idx := NewMemoryIndex()
+	require.NoError(t, idx.Add(ctx, []VectorItem{
+		{Chunk: SearchChunk{ID: "a", Name: "Alpha"}, Embedding: []float32{1, 1}},
+		{Chunk: SearchChunk{ID: "b", Name: "Beta"}, Embedding: []float32{1, 0}},
+	}))
+	em := &countingEmbedder{}
+	engine := NewEngine(graph.NewGraph(), em, idx)
+
+	results, err := engine.SearchByTexts(ctx, []string{"alpha", "beta", "  "}, 2, SearchFilter{})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, 1, em.calls, "all queries embedded in one batch")
+	assert.Len(t, results[0], 2)
+	assert.Equal(t, "b", results[1][0].ID, "keyword hit fused into the second query")
+	assert.Empty(t, results[2])
+
+	_, err = engine.SearchByTexts(ctx, []string{"alpha", "beta"}, 2, SearchFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, em.calls, "cached query vectors are reused")
+}
@@ -0,0 +1,96 @@
+package knowledge
+
+import (
+	"sort"
+
+	"docod/internal/graph"
+)
+
+// defaultExpansionConfidence is used for edges without a recorded confidence.
+const defaultExpansionConfidence = 0.5
+
+// ExpandWithNeighbors appends the direct dependencies and dependents of
+// symbol hits to the evidence set, adding at most budget chunks. Neighbors are
+// weighted by the confidence of the connecting edge and by the rank of the
+// hit that reached them, so neighbors of the best hits over well-resolved
+// edges come first. Test edges and symbols outside documentation scope are
+// skipped.
+func (e *Engine) ExpandWithNeighbors(hits []SearchChunk, budget int) []SearchChunk {
+	if e.graph == nil || budget <= 0 || len(hits) == 0 {
+		return hits
+	}
+	present := make(map[string]bool, len(hits))
+	rankOf := make(map[string]int, len(hits))
+	for i, h := range hits {
+		present[h.ID] = true
+		id := chunkSymbolID(h.ID)
+		present[id] = true
+		if _, ok := rankOf[id]; !ok {
+			rankOf[id] = i
+		}
+	}
+
+	type candidate struct {
+		id       string
+		weight   float64
+		conf     float64
+		relation string
+	}
+	best := make(map[string]candidate)
+	consider := func(hitID, neighborID, relation string, conf float64) {
+		if present[neighborID] {
+			return
+		}
+		if conf <= 0 {
+			conf = defaultExpansionConfidence
+		}
+		weight := conf * (1 - float64(rankOf[hitID])/float64(len(hits)))
+		if c, ok := best[neighborID]; !ok || weight > c.weight {
+			best[neighborID] = candidate{id: neighborID, weight: weight, conf: conf, relation: relation}
+		}
+	}
+	for _, edge := range e.graph.Edges {
+		if edge.Kind == graph.RelationTests || graph.IsPackageRelation(edge.Kind) {
+			continue
+		}
+		if _, ok := rankOf[edge.From]; ok {
+			consider(edge.From, edge.To, "dependency", edge.Confidence)
+		}
+		if _, ok := rankOf[edge.To]; ok {
+			consider(edge.To, edge.From, "context", edge.Confidence)
+		}
+	}
+
+	candidates := make([]candidate, 0, len(best))
+	for id, c := range best {
+		node, ok := e.graph.Nodes[id]
+		if !ok || !e.isDocRelevantNode(id, node) || !e.inScope(node) {
+			continue
+		}
+		candidates = append(candidates, c)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].weight != candidates[j].weight {
+			return candidates[i].weight > candidates[j].weight
+		}
+		return candidates[i].id < candidates[j].id
+	})
+	if len(candidates) > budget {
+		candidates = candidates[:budget]
+	}
+
+	out := append([]SearchChunk(nil), hits...)
+	var implements map[string][]string
+	if len(candidates) > 0 {
+		implements = e.implementsIndex()
+	}
+	for _, c := range candidates {
+		chunk := e.createChunk(c.id, e.graph.Nodes[c.id], implements)
+		chunk.Content = TruncateTokens(e.tokenizer, chunk.Content, symbolContentTokens)
+		// The neighbor is cited through the expansion, at the edge's confidence.
+		chunk.Sources[0].Relation = c.relation
+		chunk.Sources[0].Confidence = c.conf
+		out = append(out, chunk)
+	}
+	return out
+}
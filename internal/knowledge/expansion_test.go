@@ -0,0 +1,40 @@
+package knowledge
+
+import (
+	"testing"
+
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_ExpandWithNeighbors(t *testing.T) {
+	g := graph.NewGraph()
+	for _, name := range []string{"Hit", "Strong", "Weak", "Caller", "TestHit", "Far"} {
+		unitType := "function"
+		if name == "TestHit" {
+			unitType = graph.UnitTypeTest
+		}
+		g.AddSymbol(&graph.Symbol{ID: name, Name: name, UnitType: unitType, Filepath: "a.go"})
+	}
+	g.Edges = []graph.Edge{
+		{From: "Hit", To: "Strong", Kind: graph.RelationCalls, Confidence: 0.95},
+		{From: "Hit", To: "Weak", Kind: graph.RelationCalls, Confidence: 0.3},
+		{From: "Caller", To: "Hit", Kind: graph.RelationCalls, Confidence: 0.8},
+		{From: "TestHit", To: "Hit", Kind: graph.RelationTests, Confidence: 0.9},
+		{From: "Strong", To: "Far", Kind: graph.RelationCalls, Confidence: 0.9},
+	}
+	engine := NewEngine(g, nil, nil)
+
+	out := engine.ExpandWithNeighbors([]SearchChunk{{ID: "Hit::seg:1"}}, 2)
+	require.Len(t, out, 3)
+	assert.Equal(t, "Hit::seg:1", out[0].ID)
+	assert.Equal(t, "Strong", out[1].ID)
+	assert.Equal(t, "dependency", out[1].Sources[0].Relation)
+	assert.InDelta(t, 0.95, out[1].Sources[0].Confidence, 1e-9)
+	assert.Equal(t, "Caller", out[2].ID)
+	assert.Equal(t, "context", out[2].Sources[0].Relation)
+
+	assert.Len(t, engine.ExpandWithNeighbors([]SearchChunk{{ID: "Hit"}}, 10), 4)
+}
@@ -0,0 +1,120 @@
+package knowledge
+
+import (
+	"path/filepath"
+	"strings"
+
+	"docod/internal/graph"
+)
+
+// SearchFilter restricts search results by chunk metadata. The zero value
+// matches every chunk.
+type SearchFilter struct {
+	Packages         []string // match any of these package names
+	UnitTypes        []string // match any of these unit types
+	ExcludeUnitTypes []string
+	PathPrefixes     []string // match any of these file path prefixes
+	ExcludePaths     []string // drop paths under these prefixes or directory names (e.g. "vendor/")
+	ExcludeTests     bool     // drop test units, _test.go files and *Test names
+}
+
+// IsZero reports whether the filter matches everything.
+func (f SearchFilter) IsZero() bool {
+	return len(f.Packages) == 0 && len(f.UnitTypes) == 0 && len(f.ExcludeUnitTypes) == 0 &&
+		len(f.PathPrefixes) == 0 && len(f.ExcludePaths) == 0 && !f.ExcludeTests
+}
+
+// WithExclusions returns f narrowed by the negative criteria of scope:
+// excluded unit types, excluded paths and test exclusion.
+func (f SearchFilter) WithExclusions(scope SearchFilter) SearchFilter {
+	if len(scope.ExcludeUnitTypes) > 0 {
+		f.ExcludeUnitTypes = append(append([]string(nil), f.ExcludeUnitTypes...), scope.ExcludeUnitTypes...)
+	}
+	if len(scope.ExcludePaths) > 0 {
+		f.ExcludePaths = append(append([]string(nil), f.ExcludePaths...), scope.ExcludePaths...)
+	}
+	f.ExcludeTests = f.ExcludeTests || scope.ExcludeTests
+	return f
+}
+
+// Match reports whether a chunk passes the filter.
+func (f SearchFilter) Match(c SearchChunk) bool {
+	if len(f.Packages) > 0 && !containsString(f.Packages, c.Package) {
+		return false
+	}
+	if len(f.UnitTypes) > 0 && !containsString(f.UnitTypes, c.UnitType) {
+		return false
+	}
+	if containsString(f.ExcludeUnitTypes, c.UnitType) {
+		return false
+	}
+	if len(f.PathPrefixes) > 0 {
+		path := filepath.ToSlash(c.FilePath)
+		matched := false
+		for _, p := range f.PathPrefixes {
+			if strings.HasPrefix(path, filepath.ToSlash(p)) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, p := range f.ExcludePaths {
+		if pathExcluded(c.FilePath, p) {
+			return false
+		}
+	}
+	if f.ExcludeTests && IsTestChunk(c) {
+		return false
+	}
+	return true
+}
+
+// pathExcluded reports whether path lies under entry. An entry without an
+// inner slash ("vendor/", "examples") names a directory at any depth; other
+// entries ("internal/legacy/") are prefixes from the project root.
+func pathExcluded(path, entry string) bool {
+	entry = strings.Trim(filepath.ToSlash(strings.TrimSpace(entry)), "/")
+	if entry == "" {
+		return false
+	}
+	path = strings.TrimPrefix(filepath.ToSlash(path), "./")
+	if path == entry || strings.HasPrefix(path, entry+"/") {
+		return true
+	}
+	return !strings.Contains(entry, "/") && strings.Contains("/"+path, "/"+entry+"/")
+}
+
+// Apply returns the chunks that pass the filter.
+func (f SearchFilter) Apply(chunks []SearchChunk) []SearchChunk {
+	if f.IsZero() {
+		return chunks
+	}
+	out := make([]SearchChunk, 0, len(chunks))
+	for _, c := range chunks {
+		if f.Match(c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// IsTestChunk reports whether a chunk describes test code.
+func IsTestChunk(c SearchChunk) bool {
+	if c.UnitType == graph.UnitTypeTest || strings.HasSuffix(c.FilePath, "_test.go") {
+		return true
+	}
+	name := strings.ToLower(strings.TrimSpace(c.Name))
+	return strings.Contains(name, "_test") || strings.HasSuffix(name, "test")
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package knowledge
+
+import (
+	"testing"
+
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchFilter_Match(t *testing.T) {
+	cfg := SearchChunk{ID: "a", Name: "LoadConfig", Package: "config", UnitType: "function", FilePath: "internal/config/config.go"}
+	test := SearchChunk{ID: "b", Name: "TestLoadConfig", Package: "config", UnitType: graph.UnitTypeTest, FilePath: "internal/config/config_test.go"}
+	constant := SearchChunk{ID: "c", Name: "DefaultPath", Package: "config", UnitType: "constant", FilePath: "internal/config/paths.go"}
+	other := SearchChunk{ID: "d", Name: "Run", Package: "cli", UnitType: "function", FilePath: "cmd/cli/run.go"}
+
+	assert.True(t, SearchFilter{}.IsZero())
+	assert.True(t, SearchFilter{}.Match(test))
+
+	f := SearchFilter{PathPrefixes: []string{"internal/config"}, ExcludeTests: true, ExcludeUnitTypes: []string{"constant"}}
+	assert.True(t, f.Match(cfg))
+	assert.False(t, f.Match(test))
+	assert.False(t, f.Match(constant))
+	assert.False(t, f.Match(other))
+
+	assert.Equal(t, []SearchChunk{other}, SearchFilter{Packages: []string{"cli"}}.Apply([]SearchChunk{cfg, other}))
+	assert.Equal(t, []SearchChunk{constant}, SearchFilter{UnitTypes: []string{"constant"}}.Apply([]SearchChunk{cfg, constant}))
+}
+
+func TestSearchFilter_ExcludePaths(t *testing.T) {
+	f := SearchFilter{ExcludePaths: []string{"vendor/", "examples", "internal/legacy/"}}
+	assert.False(t, f.IsZero())
+	assert.False(t, f.Match(SearchChunk{FilePath: "vendor/github.com/x/y.go"}))
+	assert.False(t, f.Match(SearchChunk{FilePath: "cmd/examples/demo.go"}))
+	assert.False(t, f.Match(SearchChunk{FilePath: "internal/legacy/old.go"}))
+	assert.True(t, f.Match(SearchChunk{FilePath: "pkg/legacy/old.go"}), "prefix entries only match from the root")
+	assert.True(t, f.Match(SearchChunk{FilePath: "internal/examplesutil/a.go"}))
+}
+
+func TestSearchFilter_WithExclusions(t *testing.T) {
+	section := SearchFilter{Packages: []string{"config"}, ExcludeUnitTypes: []string{"constant"}}
+	scope := SearchFilter{Packages: []string{"ignored"}, ExcludePaths: []string{"vendor/"}, ExcludeTests: true}
+
+	got := section.WithExclusions(scope)
+	assert.Equal(t, []string{"config"}, got.Packages)
+	assert.Equal(t, []string{"constant"}, got.ExcludeUnitTypes)
+	assert.Equal(t, []string{"vendor/"}, got.ExcludePaths)
+	assert.True(t, got.ExcludeTests)
+	assert.Empty(t, section.ExcludePaths, "the receiver is not modified")
+}
@@ -26,27 +26,44 @@ func NewGeminiSummarizer(ctx context.Context, apiKey string, modelName string) (
 	return &GeminiSummarizer{
 		client:        client,
 		model:         modelName,
-		promptBuilder: &PromptBuilder{},
+		promptBuilder: NewPromptBuilder(modelName),
 	}, nil
 }
 
 func (s *GeminiSummarizer) SummarizeFullDoc(ctx context.Context, archChunks, featChunks, confChunks []SearchChunk) (string, error) {
-	prompt := s.promptBuilder.BuildFullDocPrompt(archChunks, featChunks, confChunks)
+	prompt, pack := s.promptBuilder.BuildFullDocPrompt(archChunks, featChunks, confChunks)
+	recordPack(ctx, pack)
 	return s.generate(ctx, prompt)
 }
 
 func (s *GeminiSummarizer) UpdateDocSection(ctx context.Context, currentContent string, relevantCode []SearchChunk) (string, error) {
-	prompt := s.promptBuilder.BuildUpdateDocPrompt(currentContent, relevantCode)
+	prompt, pack := s.promptBuilder.BuildUpdateDocPrompt(currentContent, relevantCode, SectionStyleFrom(ctx))
+	recordPack(ctx, pack)
 	return s.generate(ctx, prompt)
 }
 
 func (s *GeminiSummarizer) RenderSectionFromDraft(ctx context.Context, draftJSON string, relevantCode []SearchChunk) (string, error) {
-	prompt := s.promptBuilder.BuildRenderFromDraftPrompt(draftJSON, relevantCode)
+	prompt, pack := s.promptBuilder.BuildRenderFromDraftPrompt(draftJSON, relevantCode, SectionStyleFrom(ctx))
+	recordPack(ctx, pack)
 	return s.generate(ctx, prompt)
 }
 
 func (s *GeminiSummarizer) GenerateNewSection(ctx context.Context, relevantCode []SearchChunk) (string, error) {
-	prompt := s.promptBuilder.BuildNewSectionPrompt(relevantCode)
+	prompt, pack := s.promptBuilder.BuildNewSectionPrompt(relevantCode, SectionStyleFrom(ctx))
+	recordPack(ctx, pack)
+	return s.generate(ctx, prompt)
+}
+
+// SummarizeUnit implements UnitSummarizer.
+func (s *GeminiSummarizer) SummarizeUnit(ctx context.Context, kind, name, content string) (string, error) {
+	prompt := s.promptBuilder.BuildUnitSummaryPrompt(kind, name, content)
+	return s.generate(ctx, prompt)
+}
+
+// AnswerQuestion implements QuestionAnswerer.
+func (s *GeminiSummarizer) AnswerQuestion(ctx context.Context, question string, evidence []SearchChunk) (string, error) {
+	prompt, pack := s.promptBuilder.BuildAnswerPrompt(question, evidence)
+	recordPack(ctx, pack)
 	return s.generate(ctx, prompt)
 }
 
@@ -78,6 +95,12 @@ func (s *GeminiSummarizer) generate(ctx context.Context, prompt string) (string,
 		return "", err
 	}
 	text := resp.Text()
+	inputTokens, outputTokens := 0, 0
+	if resp.UsageMetadata != nil {
+		inputTokens = int(resp.UsageMetadata.PromptTokenCount)
+		outputTokens = int(resp.UsageMetadata.CandidatesTokenCount)
+	}
+	s.promptBuilder.recordUsage(ctx, "gemini", s.model, prompt, text, inputTokens, outputTokens)
 	if text == "" {
 		return "No analysis available.", nil
 	}
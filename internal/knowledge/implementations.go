@@ -7,24 +7,20 @@ import (
 	"os"
 	"sort"
 	"strings"
-
-	"docod/internal/graph"
 )
 
-// MemoryIndex is a simple in-memory vector storage with hash-based caching and graph awareness.
+// MemoryIndex is a simple in-memory vector storage with hash-based caching.
 type MemoryIndex struct {
 	items         []VectorItem
 	indexByID     map[string]int
 	contentHashes map[string]string
-	graph         *graph.Graph // Reference to the dependency graph for hybrid search
 }
 
-func NewMemoryIndex(g *graph.Graph) *MemoryIndex {
+func NewMemoryIndex() *MemoryIndex {
 	return &MemoryIndex{
 		items:         []VectorItem{},
 		indexByID:     make(map[string]int),
 		contentHashes: make(map[string]string),
-		graph:         g,
 	}
 }
 
@@ -128,111 +124,111 @@ func (m *MemoryIndex) GetContentHashes(ctx context.Context, ids []string) (map[s
 	return out, nil
 }
 
-// Search implements Indexer and performs hybrid search (vector + graph proximity).
-func (m *MemoryIndex) Search(ctx context.Context, queryVector []float32, topK int) ([]VectorItem, error) {
-	return m.searchWithSource(ctx, queryVector, topK, "")
-}
-
-// searchWithSource performs hybrid search; sourceID boosts graph-neighbor scores.
-func (m *MemoryIndex) searchWithSource(_ context.Context, queryVector []float32, topK int, sourceID string) ([]VectorItem, error) {
-	if len(m.items) == 0 {
-		return nil, nil
-	}
-
-	type scoreItem struct {
-		item  VectorItem
-		score float32
+// GetEmbeddings implements EmbeddingReader.
+func (m *MemoryIndex) GetEmbeddings(_ context.Context, ids []string) (map[string][]float32, error) {
+	out := make(map[string][]float32, len(ids))
+	for _, id := range ids {
+		if idx, ok := m.indexByID[id]; ok && len(m.items[idx].Embedding) > 0 {
+			out[id] = m.items[idx].Embedding
+		}
 	}
-	scores := make([]scoreItem, 0, len(m.items))
+	return out, nil
+}
 
-	// Pre-calculate graph distances if sourceID is valid
-	distances := make(map[string]int)
-	if sourceID != "" && m.graph != nil {
-		distances = m.bfsDistances(sourceID, 2) // Limit depth to 2 hops
+// GetEmbeddingsByContentHash implements EmbeddingHashReader.
+func (m *MemoryIndex) GetEmbeddingsByContentHash(_ context.Context, hashes []string) (map[string][]float32, error) {
+	want := make(map[string]bool, len(hashes))
+	for _, h := range hashes {
+		want[h] = true
 	}
-
+	out := make(map[string][]float32)
 	for _, item := range m.items {
-		// 1. Vector Similarity (0.0 ~ 1.0)
-		vecScore := cosineSimilarity(queryVector, item.Embedding)
-
-		// 2. Graph Proximity Boost
-		// Direct neighbor (dist=1): +0.2
-		// 2-hop neighbor (dist=2): +0.1
-		graphBoost := float32(0.0)
-		if dist, ok := distances[item.Chunk.ID]; ok {
-			switch dist {
-			case 1:
-				graphBoost = 0.2
-			case 2:
-				graphBoost = 0.1
+		h := item.Chunk.ContentHash
+		if want[h] && len(item.Embedding) > 0 {
+			if _, ok := out[h]; !ok {
+				out[h] = item.Embedding
 			}
 		}
-
-		finalScore := vecScore + graphBoost
-		scores = append(scores, scoreItem{item: item, score: finalScore})
 	}
+	return out, nil
+}
 
-	sort.Slice(scores, func(i, j int) bool {
-		return scores[i].score > scores[j].score
-	})
-
-	limit := topK
-	if limit > len(scores) {
-		limit = len(scores)
+// GetCodeEmbeddingsByContentHash implements CodeEmbeddingHashReader.
+func (m *MemoryIndex) GetCodeEmbeddingsByContentHash(_ context.Context, hashes []string) (map[string][]float32, error) {
+	want := make(map[string]bool, len(hashes))
+	for _, h := range hashes {
+		want[h] = true
 	}
-
-	results := make([]VectorItem, 0, limit)
-	for i := 0; i < limit; i++ {
-		results = append(results, scores[i].item)
+	out := make(map[string][]float32)
+	for _, item := range m.items {
+		h := item.Chunk.ContentHash
+		if want[h] && len(item.CodeEmbedding) > 0 {
+			if _, ok := out[h]; !ok {
+				out[h] = item.CodeEmbedding
+			}
+		}
 	}
-
-	return results, nil
+	return out, nil
 }
 
-// bfsDistances calculates shortest path distances from startNode up to maxDepth.
-func (m *MemoryIndex) bfsDistances(startID string, maxDepth int) map[string]int {
-	dists := make(map[string]int)
-	if m.graph == nil {
-		return dists
+// KeywordSearch implements KeywordSearcher with BM25 over the stored chunks.
+func (m *MemoryIndex) KeywordSearch(_ context.Context, query string, topK int, filter SearchFilter) ([]VectorItem, error) {
+	chunks := make([]SearchChunk, 0, len(m.items))
+	for _, item := range m.items {
+		if filter.Match(item.Chunk) {
+			chunks = append(chunks, item.Chunk)
+		}
 	}
+	return BM25Rank(chunks, query, topK), nil
+}
 
-	// BFS queue: [NodeID, Depth]
-	type queueItem struct {
-		id    string
-		depth int
+// Search implements Indexer and ranks items by cosine similarity. Graph-aware
+// expansion happens in Engine.ExpandWithNeighbors.
+func (m *MemoryIndex) Search(_ context.Context, queryVector []float32, topK int, filter SearchFilter) ([]VectorItem, error) {
+	if len(m.items) == 0 {
+		return nil, nil
 	}
-	queue := []queueItem{{id: startID, depth: 0}}
-	visited := map[string]bool{startID: true}
-
-	for len(queue) > 0 {
-		curr := queue[0]
-		queue = queue[1:]
-
-		if curr.depth > 0 {
-			dists[curr.id] = curr.depth
+	results := make([]VectorItem, 0, len(m.items))
+	for _, item := range m.items {
+		if !filter.Match(item.Chunk) {
+			continue
 		}
+		item.Score = ViewSimilarity(queryVector, item.Embedding, item.CodeEmbedding)
+		results = append(results, item)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if topK < len(results) {
+		results = results[:topK]
+	}
+	return results, nil
+}
 
-		if curr.depth >= maxDepth {
+// SearchBatch implements BatchSearcher, scoring every query vector in one
+// pass over the stored items.
+func (m *MemoryIndex) SearchBatch(_ context.Context, queryVectors [][]float32, topK int, filter SearchFilter) ([][]VectorItem, error) {
+	out := make([][]VectorItem, len(queryVectors))
+	for _, item := range m.items {
+		if !filter.Match(item.Chunk) {
 			continue
 		}
-
-		// Check Dependencies (Outgoing edges)
-		for _, dep := range m.graph.GetDependencies(curr.id) {
-			if !visited[dep.Unit.ID] {
-				visited[dep.Unit.ID] = true
-				queue = append(queue, queueItem{id: dep.Unit.ID, depth: curr.depth + 1})
-			}
+		for q, vec := range queryVectors {
+			scored := item
+			scored.Score = ViewSimilarity(vec, item.Embedding, item.CodeEmbedding)
+			out[q] = append(out[q], scored)
 		}
-
-		// Check Dependents (Incoming edges) - context flows both ways
-		for _, dep := range m.graph.GetDependents(curr.id) {
-			if !visited[dep.Unit.ID] {
-				visited[dep.Unit.ID] = true
-				queue = append(queue, queueItem{id: dep.Unit.ID, depth: curr.depth + 1})
-			}
+	}
+	for q := range out {
+		results := out[q]
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Score > results[j].Score
+		})
+		if topK < len(results) {
+			out[q] = results[:topK]
 		}
 	}
-	return dists
+	return out, nil
 }
 
 func cosineSimilarity(a, b []float32) float32 {
@@ -0,0 +1,189 @@
+package knowledge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	localEmbedBatchSize = 32
+	// defaultLocalMaxSeqLen matches the 256 word pieces all-MiniLM-L6-v2 was
+	// trained with.
+	defaultLocalMaxSeqLen = 256
+)
+
+// errLocalUnsupported is returned when docod was built without the onnx tag.
+var errLocalUnsupported = errors.New("local embeddings need ONNX Runtime; rebuild docod with -tags onnx")
+
+// onnxRunner runs a sentence-transformer forward pass. The inputs are
+// batch×seq row-major tensors; it returns the last hidden state as
+// batch×seq×hidden floats.
+type onnxRunner interface {
+	Run(inputIDs, attentionMask, tokenTypeIDs []int64, batch, seq int) ([]float32, error)
+}
+
+// LocalEmbedder embeds texts offline with a sentence-transformer exported to
+// ONNX (e.g. all-MiniLM-L6-v2): WordPiece tokenization, one forward pass per
+// batch, then mean pooling over the attention mask and L2 normalisation.
+type LocalEmbedder struct {
+	modelDir    string
+	runtimeLib  string
+	dimension   int
+	maxSeqLen   int
+	tokenizer   *WordPieceTokenizer
+	runner      onnxRunner
+	loadOnce    sync.Once
+	loadErr     error
+	mu          sync.Mutex
+	newRunnerFn func(modelPath, runtimeLib string, hidden int) (onnxRunner, error)
+}
+
+// NewLocalEmbedder loads the model in modelDir, which holds model.onnx (or
+// onnx/model.onnx) and vocab.txt, on first use. runtimeLib is the ONNX
+// Runtime shared library; empty uses the platform default name. dim is the
+// model's hidden size (384 for MiniLM).
+func NewLocalEmbedder(modelDir, runtimeLib string, dim, maxSeqLen int) *LocalEmbedder {
+	if maxSeqLen <= 0 {
+		maxSeqLen = defaultLocalMaxSeqLen
+	}
+	return &LocalEmbedder{
+		modelDir:    modelDir,
+		runtimeLib:  runtimeLib,
+		dimension:   dim,
+		maxSeqLen:   maxSeqLen,
+		newRunnerFn: newONNXRunner,
+	}
+}
+
+func (l *LocalEmbedder) Dimension() int {
+	return l.dimension
+}
+
+func (l *LocalEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	if err := l.load(); err != nil {
+		return nil, err
+	}
+
+	results := make([][]float32, 0, len(texts))
+	for i := 0; i < len(texts); i += localEmbedBatchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		end := min(i+localEmbedBatchSize, len(texts))
+		vecs, err := l.embedBatch(texts[i:end])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, vecs...)
+	}
+	return results, nil
+}
+
+func (l *LocalEmbedder) load() error {
+	l.loadOnce.Do(func() {
+		dir := strings.TrimSpace(l.modelDir)
+		if dir == "" {
+			l.loadErr = fmt.Errorf("local embedder requires embedding_model to name a model directory")
+			return
+		}
+		if l.dimension <= 0 {
+			l.loadErr = fmt.Errorf("local embedder requires embedding_dimension (384 for all-MiniLM-L6-v2)")
+			return
+		}
+		tok, err := LoadWordPieceVocab(filepath.Join(dir, "vocab.txt"))
+		if err != nil {
+			l.loadErr = fmt.Errorf("failed to load local tokenizer: %w", err)
+			return
+		}
+		modelPath := filepath.Join(dir, "model.onnx")
+		if _, err := os.Stat(modelPath); err != nil {
+			modelPath = filepath.Join(dir, "onnx", "model.onnx")
+		}
+		runner, err := l.newRunnerFn(modelPath, l.runtimeLib, l.dimension)
+		if err != nil {
+			l.loadErr = fmt.Errorf("failed to load local model %s: %w", modelPath, err)
+			return
+		}
+		l.tokenizer = tok
+		l.runner = runner
+	})
+	return l.loadErr
+}
+
+// embedBatch pads the batch to its longest sequence and pools each row.
+func (l *LocalEmbedder) embedBatch(batch []string) ([][]float32, error) {
+	encoded := make([][]int64, len(batch))
+	seq := 0
+	for i, text := range batch {
+		encoded[i] = l.tokenizer.Encode(text, l.maxSeqLen)
+		seq = max(seq, len(encoded[i]))
+	}
+	ids := make([]int64, len(batch)*seq)
+	mask := make([]int64, len(batch)*seq)
+	types := make([]int64, len(batch)*seq)
+	for i, row := range encoded {
+		for j := 0; j < seq; j++ {
+			if j < len(row) {
+				ids[i*seq+j] = row[j]
+				mask[i*seq+j] = 1
+			} else {
+				ids[i*seq+j] = l.tokenizer.pad
+			}
+		}
+	}
+
+	l.mu.Lock()
+	hidden, err := l.runner.Run(ids, mask, types, len(batch), seq)
+	l.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("local embedding inference failed: %w", err)
+	}
+	if len(hidden) != len(batch)*seq*l.dimension {
+		return nil, fmt.Errorf("local model returned %d values, want %d×%d×%d; check embedding_dimension", len(hidden), len(batch), seq, l.dimension)
+	}
+	return meanPool(hidden, mask, len(batch), seq, l.dimension), nil
+}
+
+// meanPool averages the token vectors the attention mask keeps and scales
+// each sentence vector to unit length.
+func meanPool(hidden []float32, mask []int64, batch, seq, dim int) [][]float32 {
+	out := make([][]float32, batch)
+	for b := 0; b < batch; b++ {
+		vec := make([]float32, dim)
+		var count float32
+		for s := 0; s < seq; s++ {
+			if mask[b*seq+s] == 0 {
+				continue
+			}
+			count++
+			row := hidden[(b*seq+s)*dim : (b*seq+s+1)*dim]
+			for d, v := range row {
+				vec[d] += v
+			}
+		}
+		var norm float64
+		for d := range vec {
+			if count > 0 {
+				vec[d] /= count
+			}
+			norm += float64(vec[d]) * float64(vec[d])
+		}
+		if norm > 0 {
+			scale := float32(1 / math.Sqrt(norm))
+			for d := range vec {
+				vec[d] *= scale
+			}
+		}
+		out[b] = vec
+	}
+	return out
+}
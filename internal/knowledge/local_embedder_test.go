@@ -0,0 +1,82 @@
+package knowledge
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testVocab = []string{"[PAD]", "[UNK]", "[CLS]", "[SEP]", "open", "the", "store", "##s", "(", ")", "cafe", "un", "##known"}
+
+func TestWordPieceTokenizer_Encode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vocab.txt")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Join(testVocab, "\n")+"\n"), 0o644))
+	tok, err := LoadWordPieceVocab(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int64{2, 4, 5, 6, 7, 8, 9, 3}, tok.Encode("Open the Stores()", 0))
+	assert.Equal(t, []int64{2, 10, 11, 12, 1, 3}, tok.Encode("Café unknown xyz", 0), "accents are stripped and unknown words become [UNK]")
+	assert.Equal(t, []int64{2, 4, 3}, tok.Encode("open the store", 3))
+
+	_, err = NewWordPieceTokenizer(map[string]int64{"[UNK]": 0})
+	assert.ErrorContains(t, err, "[CLS]")
+}
+
+// fakeRunner returns token ID t as the hidden vector (t, 1) so pooling can be
+// checked by hand.
+type fakeRunner struct{ seqs []int }
+
+func (f *fakeRunner) Run(ids, mask, types []int64, batch, seq int) ([]float32, error) {
+	f.seqs = append(f.seqs, seq)
+	out := make([]float32, 0, batch*seq*2)
+	for _, id := range ids {
+		out = append(out, float32(id), 1)
+	}
+	return out, nil
+}
+
+func TestLocalEmbedder_MeanPoolsAndNormalises(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vocab.txt"), []byte(strings.Join(testVocab, "\n")), 0o644))
+	runner := &fakeRunner{}
+	e := NewLocalEmbedder(dir, "", 2, 0)
+	var modelPath string
+	e.newRunnerFn = func(path, _ string, hidden int) (onnxRunner, error) {
+		modelPath = path
+		assert.Equal(t, 2, hidden)
+		return runner, nil
+	}
+
+	vecs, err := e.Embed(context.Background(), []string{"open", "the store"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "onnx", "model.onnx"), modelPath)
+	assert.Equal(t, []int{4}, runner.seqs, "the batch is padded to its longest text")
+	require.Len(t, vecs, 2)
+	// [CLS] open [SEP] averages to (3, 1); padding is masked out.
+	assert.InDelta(t, 3/sqrt10, vecs[0][0], 1e-6)
+	assert.InDelta(t, 1/sqrt10, vecs[0][1], 1e-6)
+	// [CLS] the store [SEP] averages to (4, 1).
+	assert.InDelta(t, 4/sqrt17, vecs[1][0], 1e-6)
+}
+
+const (
+	sqrt10 = 3.1622776601683795
+	sqrt17 = 4.123105625617661
+)
+
+func TestLocalEmbedder_ReportsMissingRuntime(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vocab.txt"), []byte(strings.Join(testVocab, "\n")), 0o644))
+	e := NewLocalEmbedder(dir, "", 384, 0)
+	e.newRunnerFn = func(string, string, int) (onnxRunner, error) { return nil, errLocalUnsupported }
+	_, err := e.Embed(context.Background(), []string{"open"})
+	assert.ErrorIs(t, err, errLocalUnsupported)
+
+	_, err = NewLocalEmbedder("", "", 384, 0).Embed(context.Background(), []string{"open"})
+	assert.ErrorContains(t, err, "embedding_model")
+}
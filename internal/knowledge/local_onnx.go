@@ -0,0 +1,93 @@
+//go:build onnx
+
+package knowledge
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// ortEnv initialises the process-wide ONNX Runtime environment once; the
+// shared library path of the first model loaded wins.
+var ortEnv struct {
+	once sync.Once
+	err  error
+}
+
+type ortRunner struct {
+	session *ort.DynamicAdvancedSession
+	inputs  []string
+	hidden  int
+}
+
+func newONNXRunner(modelPath, runtimeLib string, hidden int) (onnxRunner, error) {
+	ortEnv.once.Do(func() {
+		if runtimeLib != "" {
+			ort.SetSharedLibraryPath(runtimeLib)
+		}
+		ortEnv.err = ort.InitializeEnvironment()
+	})
+	if ortEnv.err != nil {
+		return nil, fmt.Errorf("failed to initialise ONNX Runtime: %w", ortEnv.err)
+	}
+
+	inputInfo, outputInfo, err := ort.GetInputOutputInfo(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	// Exports differ in whether they take token_type_ids; feed what the
+	// model declares.
+	known := []string{"input_ids", "attention_mask", "token_type_ids"}
+	var inputs []string
+	for _, info := range inputInfo {
+		if !slices.Contains(known, info.Name) {
+			return nil, fmt.Errorf("unsupported model input %q (want %v)", info.Name, known)
+		}
+		inputs = append(inputs, info.Name)
+	}
+	if len(outputInfo) == 0 {
+		return nil, fmt.Errorf("model has no outputs")
+	}
+	output := outputInfo[0].Name
+	for _, info := range outputInfo {
+		if info.Name == "last_hidden_state" {
+			output = info.Name
+		}
+	}
+
+	session, err := ort.NewDynamicAdvancedSession(modelPath, inputs, []string{output}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ortRunner{session: session, inputs: inputs, hidden: hidden}, nil
+}
+
+func (r *ortRunner) Run(inputIDs, attentionMask, tokenTypeIDs []int64, batch, seq int) ([]float32, error) {
+	data := map[string][]int64{
+		"input_ids":      inputIDs,
+		"attention_mask": attentionMask,
+		"token_type_ids": tokenTypeIDs,
+	}
+	shape := ort.NewShape(int64(batch), int64(seq))
+	values := make([]ort.Value, 0, len(r.inputs))
+	for _, name := range r.inputs {
+		t, err := ort.NewTensor(shape, data[name])
+		if err != nil {
+			return nil, err
+		}
+		defer t.Destroy()
+		values = append(values, t)
+	}
+	out, err := ort.NewEmptyTensor[float32](ort.NewShape(int64(batch), int64(seq), int64(r.hidden)))
+	if err != nil {
+		return nil, err
+	}
+	defer out.Destroy()
+	if err := r.session.Run(values, []ort.Value{out}); err != nil {
+		return nil, err
+	}
+	return slices.Clone(out.GetData()), nil
+}
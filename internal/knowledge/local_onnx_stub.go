@@ -0,0 +1,7 @@
+//go:build !onnx
+
+package knowledge
+
+func newONNXRunner(modelPath, runtimeLib string, hidden int) (onnxRunner, error) {
+	return nil, errLocalUnsupported
+}
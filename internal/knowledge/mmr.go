@@ -0,0 +1,83 @@
+package knowledge
+
+import (
+	"context"
+	"strings"
+)
+
+// EmbeddingReader is an optional capability for index implementations that
+// can return stored chunk embeddings by ID.
+type EmbeddingReader interface {
+	GetEmbeddings(ctx context.Context, ids []string) (map[string][]float32, error)
+}
+
+// ChunkEmbeddings returns stored embeddings for the given chunk IDs, or nil
+// when the index cannot provide them.
+func (e *Engine) ChunkEmbeddings(ctx context.Context, ids []string) (map[string][]float32, error) {
+	reader, ok := e.index.(EmbeddingReader)
+	if !ok || len(ids) == 0 {
+		return nil, nil
+	}
+	return reader.GetEmbeddings(ctx, ids)
+}
+
+// MMRSelect picks up to limit chunks by maximal marginal relevance. Input
+// order is treated as relevance (best first); redundancy is the cosine
+// similarity between candidate embeddings. Candidates without an embedding
+// count as fully redundant with chunks from the same file and unrelated to
+// everything else. lambda trades relevance (1) against diversity (0).
+func MMRSelect(chunks []SearchChunk, vectors map[string][]float32, limit int, lambda float64) []SearchChunk {
+	if limit <= 0 || len(chunks) <= limit {
+		return chunks
+	}
+	if lambda < 0 || lambda > 1 {
+		lambda = 0.7
+	}
+
+	n := len(chunks)
+	relevance := make([]float64, n)
+	for i := range chunks {
+		relevance[i] = 1 - float64(i)/float64(n)
+	}
+	similarity := func(a, b SearchChunk) float64 {
+		va, okA := vectors[a.ID]
+		vb, okB := vectors[b.ID]
+		if okA && okB {
+			return float64(cosineSimilarity(va, vb))
+		}
+		if fa, fb := strings.TrimSpace(a.FilePath), strings.TrimSpace(b.FilePath); fa != "" && fa == fb {
+			return 1
+		}
+		return 0
+	}
+
+	used := make([]bool, n)
+	// maxSim[i] is the highest similarity of candidate i to any selected chunk.
+	maxSim := make([]float64, n)
+	out := make([]SearchChunk, 0, limit)
+	for len(out) < limit {
+		best, bestScore := -1, 0.0
+		for i := range chunks {
+			if used[i] {
+				continue
+			}
+			score := lambda*relevance[i] - (1-lambda)*maxSim[i]
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			break
+		}
+		used[best] = true
+		out = append(out, chunks[best])
+		for i := range chunks {
+			if !used[i] {
+				if s := similarity(chunks[i], chunks[best]); s > maxSim[i] {
+					maxSim[i] = s
+				}
+			}
+		}
+	}
+	return out
+}
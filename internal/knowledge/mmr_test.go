@@ -0,0 +1,28 @@
+package knowledge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMMRSelect_SkipsNearDuplicates(t *testing.T) {
+	chunks := []SearchChunk{{ID: "a"}, {ID: "a2"}, {ID: "b"}, {ID: "c"}}
+	vectors := map[string][]float32{
+		"a":  {1, 0},
+		"a2": {0.99, 0.1},
+		"b":  {0, 1},
+		"c":  {0.7, 0.7},
+	}
+	out := MMRSelect(chunks, vectors, 2, 0.5)
+	assert.Equal(t, []string{"a", "b"}, chunkIDs(out))
+
+	// lambda 1 keeps pure relevance order.
+	assert.Equal(t, []string{"a", "a2"}, chunkIDs(MMRSelect(chunks, vectors, 2, 1)))
+}
+
+func TestMMRSelect_SameFileWithoutEmbeddings(t *testing.T) {
+	chunks := []SearchChunk{{ID: "a", FilePath: "x.go"}, {ID: "b", FilePath: "x.go"}, {ID: "c", FilePath: "y.go"}}
+	out := MMRSelect(chunks, nil, 2, 0.6)
+	assert.Equal(t, []string{"a", "c"}, chunkIDs(out))
+}
@@ -24,6 +24,8 @@ type OpenAIEmbedder struct {
 	model     string
 	dimension int
 	endpoint  string
+	// azure sends the key in the api-key header instead of as a bearer token.
+	azure bool
 }
 
 type openAIEmbeddingRequest struct {
@@ -130,7 +132,7 @@ func (o *OpenAIEmbedder) embedBatch(ctx context.Context, batch []string) ([][]fl
 		if err != nil {
 			return nil, err
 		}
-		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+		setOpenAIAuth(req, o.apiKey, o.azure)
 		req.Header.Set("Content-Type", "application/json")
 
 		resp, err := o.client.Do(req)
@@ -17,6 +17,7 @@ type OpenAISummarizer struct {
 	apiKey        string
 	model         string
 	endpoint      string
+	azure         bool
 	promptBuilder *PromptBuilder
 }
 
@@ -35,6 +36,10 @@ type openAIChatResponse struct {
 	Choices []struct {
 		Message openAIChatMessage `json:"message"`
 	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
 }
 
 func NewOpenAISummarizer(apiKey, model, baseURL string) *OpenAISummarizer {
@@ -58,27 +63,44 @@ func NewOpenAISummarizer(apiKey, model, baseURL string) *OpenAISummarizer {
 		apiKey:        apiKey,
 		model:         model,
 		endpoint:      endpoint,
-		promptBuilder: &PromptBuilder{},
+		promptBuilder: NewPromptBuilder(model),
 	}
 }
 
 func (s *OpenAISummarizer) SummarizeFullDoc(ctx context.Context, archChunks, featChunks, confChunks []SearchChunk) (string, error) {
-	prompt := s.promptBuilder.BuildFullDocPrompt(archChunks, featChunks, confChunks)
+	prompt, pack := s.promptBuilder.BuildFullDocPrompt(archChunks, featChunks, confChunks)
+	recordPack(ctx, pack)
 	return s.generate(ctx, prompt)
 }
 
 func (s *OpenAISummarizer) UpdateDocSection(ctx context.Context, currentContent string, relevantCode []SearchChunk) (string, error) {
-	prompt := s.promptBuilder.BuildUpdateDocPrompt(currentContent, relevantCode)
+	prompt, pack := s.promptBuilder.BuildUpdateDocPrompt(currentContent, relevantCode, SectionStyleFrom(ctx))
+	recordPack(ctx, pack)
 	return s.generate(ctx, prompt)
 }
 
 func (s *OpenAISummarizer) RenderSectionFromDraft(ctx context.Context, draftJSON string, relevantCode []SearchChunk) (string, error) {
-	prompt := s.promptBuilder.BuildRenderFromDraftPrompt(draftJSON, relevantCode)
+	prompt, pack := s.promptBuilder.BuildRenderFromDraftPrompt(draftJSON, relevantCode, SectionStyleFrom(ctx))
+	recordPack(ctx, pack)
 	return s.generate(ctx, prompt)
 }
 
 func (s *OpenAISummarizer) GenerateNewSection(ctx context.Context, relevantCode []SearchChunk) (string, error) {
-	prompt := s.promptBuilder.BuildNewSectionPrompt(relevantCode)
+	prompt, pack := s.promptBuilder.BuildNewSectionPrompt(relevantCode, SectionStyleFrom(ctx))
+	recordPack(ctx, pack)
+	return s.generate(ctx, prompt)
+}
+
+// SummarizeUnit implements UnitSummarizer.
+func (s *OpenAISummarizer) SummarizeUnit(ctx context.Context, kind, name, content string) (string, error) {
+	prompt := s.promptBuilder.BuildUnitSummaryPrompt(kind, name, content)
+	return s.generate(ctx, prompt)
+}
+
+// AnswerQuestion implements QuestionAnswerer.
+func (s *OpenAISummarizer) AnswerQuestion(ctx context.Context, question string, evidence []SearchChunk) (string, error) {
+	prompt, pack := s.promptBuilder.BuildAnswerPrompt(question, evidence)
+	recordPack(ctx, pack)
 	return s.generate(ctx, prompt)
 }
 
@@ -125,7 +147,7 @@ func (s *OpenAISummarizer) generate(ctx context.Context, prompt string) (string,
 	if err != nil {
 		return "", err
 	}
-	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	setOpenAIAuth(req, s.apiKey, s.azure)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := s.client.Do(req)
@@ -146,10 +168,15 @@ func (s *OpenAISummarizer) generate(ctx context.Context, prompt string) (string,
 	if err := json.Unmarshal(raw, &parsed); err != nil {
 		return "", err
 	}
-	if len(parsed.Choices) == 0 {
-		return "No analysis available.", nil
+	text := ""
+	if len(parsed.Choices) > 0 {
+		text = parsed.Choices[0].Message.Content
+	}
+	provider := "openai"
+	if s.azure {
+		provider = "azure-openai"
 	}
-	text := parsed.Choices[0].Message.Content
+	s.promptBuilder.recordUsage(ctx, provider, s.model, prompt, text, parsed.Usage.PromptTokens, parsed.Usage.CompletionTokens)
 	if strings.TrimSpace(text) == "" {
 		return "No analysis available.", nil
 	}
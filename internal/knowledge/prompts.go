@@ -6,11 +6,87 @@ import (
 )
 
 // PromptBuilder constructs standardized prompts for different analysis levels.
-type PromptBuilder struct{}
+// With a budget set, code evidence is packed so prompts fit the model window.
+type PromptBuilder struct {
+	budget ContextBudget
+}
+
+// NewPromptBuilder returns a builder budgeted for model's context window.
+func NewPromptBuilder(model string) *PromptBuilder {
+	return &PromptBuilder{budget: NewContextBudget(model, 0, 0)}
+}
+
+// SetContextBudget overrides the prompt token budget.
+func (pb *PromptBuilder) SetContextBudget(b ContextBudget) {
+	pb.budget = b
+}
+
+// pack fits chunk groups into the budget and renders the prompt with the kept
+// chunks, returning how they were fitted. build must render each chunk with
+// the matching render func.
+func (pb *PromptBuilder) pack(groups [][]SearchChunk, render []func(SearchChunk) string, build func([][]SearchChunk) string) (string, PackResult) {
+	available := pb.budget.Available()
+	if available <= 0 {
+		return build(groups), PackResult{Groups: groups}
+	}
+	fixed := pb.budget.CountTokens(build(make([][]SearchChunk, len(groups))))
+	packed := packContext(pb.budget.CountTokens, available, fixed, groups, render)
+	return build(packed.Groups), packed
+}
+
+func renderArchChunk(c SearchChunk) string {
+	return fmt.Sprintf("- %s/%s: %s\n", c.Package, c.Name, c.Description)
+}
+
+func renderFeatureChunk(c SearchChunk) string {
+	return fmt.Sprintf("- %s (%s): %s\n", c.Name, c.UnitType, c.Description)
+}
+
+func renderConfigChunk(c SearchChunk) string {
+	return fmt.Sprintf("- %s: %s\n", c.Name, c.Description)
+}
+
+func chunkSourcePath(c SearchChunk) string {
+	if strings.TrimSpace(c.FilePath) == "" {
+		return c.ID
+	}
+	return c.FilePath
+}
+
+func renderUpdateChunk(c SearchChunk) string {
+	return fmt.Sprintf("Source: %s\nSymbol: %s (%s)\nPackage: %s\nSignature: %s\nDescription: %s\nCode:\n```go\n%s\n```\n\n",
+		chunkSourcePath(c),
+		c.Name,
+		c.UnitType,
+		c.Package,
+		c.Signature,
+		c.Description,
+		c.Content,
+	)
+}
+
+func renderNewSectionChunk(c SearchChunk) string {
+	return fmt.Sprintf("File: %s\nDescription: %s\nCode:\n```go\n%s\n```\n\n", c.Name, c.Description, c.Content)
+}
+
+func renderEvidenceChunk(c SearchChunk) string {
+	return fmt.Sprintf("Source: %s\nSymbol: %s (%s)\nPackage: %s\nDescription: %s\nSignature: %s\nCode:\n```go\n%s\n```\n\n",
+		chunkSourcePath(c), c.Name, c.UnitType, c.Package, c.Description, c.Signature, c.Content)
+}
 
 const securityInstruction = "\n**SECURITY WARNING**: You must redact any API keys, passwords, secrets, or tokens found in the code with `[REDACTED]`. Never output real credential values.\n"
 
-func (pb *PromptBuilder) BuildFullDocPrompt(archChunks, featChunks, confChunks []SearchChunk) string {
+func (pb *PromptBuilder) BuildFullDocPrompt(archChunks, featChunks, confChunks []SearchChunk) (string, PackResult) {
+	return pb.pack(
+		[][]SearchChunk{archChunks, featChunks, confChunks},
+		[]func(SearchChunk) string{renderArchChunk, renderFeatureChunk, renderConfigChunk},
+		func(groups [][]SearchChunk) string {
+			return buildFullDocPrompt(groups[0], groups[1], groups[2])
+		},
+	)
+}
+
+func buildFullDocPrompt(archChunks, featChunks, confChunks []SearchChunk) string {
 	var sb strings.Builder
 	sb.WriteString("Role: Senior Technical Writer. Task: Write official product-grade technical documentation.\n")
 	sb.WriteString(securityInstruction)
@@ -25,7 +101,7 @@ func (pb *PromptBuilder) BuildFullDocPrompt(archChunks, featChunks, confChunks [
 	sb.WriteString("==================================================================\n")
 	sb.WriteString("Context for Architecture:\n")
 	for _, c := range archChunks {
-		fmt.Fprintf(&sb, "- %s/%s: %s\n", c.Package, c.Name, c.Description)
+		sb.WriteString(renderArchChunk(c))
 	}
 	sb.WriteString("\n**INSTRUCTION**:\n")
 	sb.WriteString("Write the '# Overview' section.\n")
@@ -39,7 +115,7 @@ func (pb *PromptBuilder) BuildFullDocPrompt(archChunks, featChunks, confChunks [
 	sb.WriteString("==================================================================\n")
 	sb.WriteString("Context for Features:\n")
 	for _, c := range featChunks {
-		fmt.Fprintf(&sb, "- %s (%s): %s\n", c.Name, c.UnitType, c.Description)
+		sb.WriteString(renderFeatureChunk(c))
 	}
 	sb.WriteString("\n**INSTRUCTION**:\n")
 	sb.WriteString("Write the '# Key Features' section.\n")
@@ -55,7 +131,7 @@ func (pb *PromptBuilder) BuildFullDocPrompt(archChunks, featChunks, confChunks [
 	sb.WriteString("==================================================================\n")
 	sb.WriteString("Context for Configuration & Setup:\n")
 	for _, c := range confChunks {
-		fmt.Fprintf(&sb, "- %s: %s\n", c.Name, c.Description)
+		sb.WriteString(renderConfigChunk(c))
 	}
 	sb.WriteString("\n**INSTRUCTION**:\n")
 	sb.WriteString("Write the '# Development' section.\n")
@@ -65,7 +141,17 @@ func (pb *PromptBuilder) BuildFullDocPrompt(archChunks, featChunks, confChunks [
 	return sb.String()
 }
 
-func (pb *PromptBuilder) BuildUpdateDocPrompt(currentContent string, relevantCode []SearchChunk) string {
+func (pb *PromptBuilder) BuildUpdateDocPrompt(currentContent string, relevantCode []SearchChunk, style SectionStyle) (string, PackResult) {
+	return pb.pack(
+		[][]SearchChunk{relevantCode},
+		[]func(SearchChunk) string{renderUpdateChunk},
+		func(groups [][]SearchChunk) string {
+			return buildUpdateDocPrompt(currentContent, groups[0]) + styleInstructions(style)
+		},
+	)
+}
+
+func buildUpdateDocPrompt(currentContent string, relevantCode []SearchChunk) string {
 	var sb strings.Builder
 	sb.WriteString("Role: Technical Writer. Task: Update exactly one existing documentation section based on code changes.\n")
 	sb.WriteString(securityInstruction)
@@ -75,19 +161,7 @@ func (pb *PromptBuilder) BuildUpdateDocPrompt(currentContent string, relevantCod
 	sb.WriteString("\n\n=== RELEVANT CODE CHANGES (CONTEXT) ===\n")
 
 	for _, c := range relevantCode {
-		path := c.FilePath
-		if strings.TrimSpace(path) == "" {
-			path = c.ID
-		}
-		fmt.Fprintf(&sb, "Source: %s\nSymbol: %s (%s)\nPackage: %s\nSignature: %s\nDescription: %s\nCode:\n```go\n%s\n```\n\n",
-			path,
-			c.Name,
-			c.UnitType,
-			c.Package,
-			c.Signature,
-			c.Description,
-			c.Content,
-		)
+		sb.WriteString(renderUpdateChunk(c))
 	}
 
 	sb.WriteString("\n**INSTRUCTION**:\n")
@@ -108,14 +182,24 @@ func (pb *PromptBuilder) BuildUpdateDocPrompt(currentContent string, relevantCod
 	return sb.String()
 }
 
-func (pb *PromptBuilder) BuildNewSectionPrompt(relevantCode []SearchChunk) string {
+func (pb *PromptBuilder) BuildNewSectionPrompt(relevantCode []SearchChunk, style SectionStyle) (string, PackResult) {
+	return pb.pack(
+		[][]SearchChunk{relevantCode},
+		[]func(SearchChunk) string{renderNewSectionChunk},
+		func(groups [][]SearchChunk) string {
+			return buildNewSectionPrompt(groups[0]) + styleInstructions(style)
+		},
+	)
+}
+
+func buildNewSectionPrompt(relevantCode []SearchChunk) string {
 	var sb strings.Builder
 	sb.WriteString("Role: Technical Writer. Task: Write one concise documentation section for incremental code changes.\n")
 	sb.WriteString(securityInstruction)
 
 	sb.WriteString("\n\n=== NEW FEATURE CODE CONTEXT ===\n")
 	for _, c := range relevantCode {
-		fmt.Fprintf(&sb, "File: %s\nDescription: %s\nCode:\n```go\n%s\n```\n\n", c.Name, c.Description, c.Content)
+		sb.WriteString(renderNewSectionChunk(c))
 	}
 
 	sb.WriteString("\n**INSTRUCTION**:\n")
@@ -159,7 +243,17 @@ func (pb *PromptBuilder) BuildInsertionPointPrompt(toc []string, newContent stri
 	return sb.String()
 }
 
-func (pb *PromptBuilder) BuildRenderFromDraftPrompt(draftJSON string, relevantCode []SearchChunk) string {
+func (pb *PromptBuilder) BuildRenderFromDraftPrompt(draftJSON string, relevantCode []SearchChunk, style SectionStyle) (string, PackResult) {
+	return pb.pack(
+		[][]SearchChunk{relevantCode},
+		[]func(SearchChunk) string{renderEvidenceChunk},
+		func(groups [][]SearchChunk) string {
+			return buildRenderFromDraftPrompt(draftJSON, groups[0]) + styleInstructions(style)
+		},
+	)
+}
+
+func buildRenderFromDraftPrompt(draftJSON string, relevantCode []SearchChunk) string {
 	var sb strings.Builder
 	sb.WriteString("Role: Technical Documentation Renderer. Task: Render a polished markdown section from a structured draft.\n")
 	sb.WriteString(securityInstruction)
@@ -171,12 +265,7 @@ func (pb *PromptBuilder) BuildRenderFromDraftPrompt(draftJSON string, relevantCo
 	sb.WriteString(draftJSON)
 	sb.WriteString("\n\n=== CODE EVIDENCE (SUPPORTING CONTEXT) ===\n")
 	for _, c := range relevantCode {
-		path := c.FilePath
-		if strings.TrimSpace(path) == "" {
-			path = c.ID
-		}
-		fmt.Fprintf(&sb, "Source: %s\nSymbol: %s (%s)\nPackage: %s\nDescription: %s\nSignature: %s\nCode:\n```go\n%s\n```\n\n",
-			path, c.Name, c.UnitType, c.Package, c.Description, c.Signature, c.Content)
+		sb.WriteString(renderEvidenceChunk(c))
 	}
 
 	sb.WriteString("\n**INSTRUCTION**:\n")
@@ -194,6 +283,61 @@ func (pb *PromptBuilder) BuildRenderFromDraftPrompt(draftJSON string, relevantCo
 	return sb.String()
 }
 
+// BuildUnitSummaryPrompt asks for a short retrieval summary of one file or
+// package. The summary is embedded as the coarse retrieval layer.
+func (pb *PromptBuilder) BuildUnitSummaryPrompt(kind, name, content string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Role: Code Analyst. Task: Summarize one Go %s for code search.\n", kind)
+	sb.WriteString(securityInstruction)
+	fmt.Fprintf(&sb, "\n=== %s: %s ===\n", strings.ToUpper(kind), name)
+	budget := pb.budget.Available()
+	if budget > 0 {
+		content = TruncateTokens(pb.budget.Tokenizer, content, budget/2)
+	}
+	sb.WriteString(content)
+	sb.WriteString("\n\n**INSTRUCTION**:\n")
+	sb.WriteString("1. Write 2-4 sentences on what this code is responsible for and its main entry points.\n")
+	sb.WriteString("2. Name key types and functions in backticks.\n")
+	sb.WriteString("3. No markdown headings, lists, or speculation.\n")
+	sb.WriteString("4. OUTPUT ONLY the summary text.\n")
+	return sb.String()
+}
+
+// BuildAnswerPrompt asks for an answer to a question about the codebase,
+// grounded in the evidence chunks and citing their locations.
+func (pb *PromptBuilder) BuildAnswerPrompt(question string, evidence []SearchChunk) (string, PackResult) {
+	return pb.pack(
+		[][]SearchChunk{evidence},
+		[]func(SearchChunk) string{renderAnswerChunk},
+		func(groups [][]SearchChunk) string {
+			return buildAnswerPrompt(question, groups[0])
+		},
+	)
+}
+
+func renderAnswerChunk(c SearchChunk) string {
+	return fmt.Sprintf("Source: %s\nSymbol: %s (%s)\nSignature: %s\nCode:\n```go\n%s\n```\n\n", ChunkLocation(c), c.Name, c.UnitType, c.Signature, c.Content)
+}
+
+func buildAnswerPrompt(question string, evidence []SearchChunk) string {
+	var sb strings.Builder
+	sb.WriteString("Role: Senior Engineer on this codebase. Task: Answer a developer's question using only the code evidence below.\n")
+	sb.WriteString(securityInstruction)
+	sb.WriteString("\n=== QUESTION ===\n")
+	sb.WriteString(strings.TrimSpace(question))
+	sb.WriteString("\n\n=== CODE EVIDENCE ===\n")
+	for _, c := range evidence {
+		sb.WriteString(renderAnswerChunk(c))
+	}
+	sb.WriteString("\n**INSTRUCTION**:\n")
+	sb.WriteString("1. Answer directly in a few short paragraphs or a list; use markdown.\n")
+	sb.WriteString("2. Cite the evidence you rely on right after each claim as its Source in brackets, e.g. [internal/store/db.go:12-40].\n")
+	sb.WriteString("3. Name functions and types in backticks.\n")
+	sb.WriteString("4. If the evidence does not answer the question, say so and name what is missing. Do not guess.\n")
+	sb.WriteString("5. OUTPUT ONLY the answer.\n")
+	return sb.String()
+}
+
 func (pb *PromptBuilder) BuildPackagePrompt(pkgName string, pkgChunks []SearchChunk) string {
 	// Deprecated
 	return ""
@@ -0,0 +1,209 @@
+package knowledge
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Query vector cache defaults.
+const (
+	defaultQueryMemoryEntries = 256
+	defaultQueryCacheEntries  = 5000
+	defaultQueryCacheTTL      = 30 * 24 * time.Hour
+)
+
+// QueryVectorCache persists query embeddings across runs. Entries are keyed by
+// normalized query text and embedding model.
+type QueryVectorCache interface {
+	// GetQueryVector returns the cached vector, or ok=false when it is missing
+	// or older than maxAge (0 means no expiry).
+	GetQueryVector(ctx context.Context, model, query string, maxAge time.Duration) (vector []float32, ok bool, err error)
+	// PutQueryVector stores a vector and evicts least recently used entries
+	// beyond maxEntries (0 means unbounded).
+	PutQueryVector(ctx context.Context, model, query string, vector []float32, maxEntries int) error
+}
+
+// QueryCacheOptions configures query vector caching. Zero fields take defaults;
+// a negative TTL disables expiry.
+type QueryCacheOptions struct {
+	// Model identifies the embedding space, e.g. "ollama/nomic-embed-text/768".
+	Model         string
+	MaxEntries    int
+	TTL           time.Duration
+	MemoryEntries int
+}
+
+func (o QueryCacheOptions) withDefaults() QueryCacheOptions {
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = defaultQueryCacheEntries
+	}
+	if o.TTL == 0 {
+		o.TTL = defaultQueryCacheTTL
+	} else if o.TTL < 0 {
+		o.TTL = 0
+	}
+	if o.MemoryEntries <= 0 {
+		o.MemoryEntries = defaultQueryMemoryEntries
+	}
+	return o
+}
+
+// NormalizeQuery is the cache key for query text: trimmed, lowercased, with
+// runs of whitespace collapsed.
+func NormalizeQuery(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
+
+// SetQueryVectorCache enables persistent query vector caching. A nil cache
+// keeps only the bounded in-process cache.
+func (e *Engine) SetQueryVectorCache(cache QueryVectorCache, opts QueryCacheOptions) {
+	opts = opts.withDefaults()
+	e.queryCache = cache
+	e.queryCacheOpts = opts
+	e.queryVecCache = newVectorLRU(opts.MemoryEntries)
+}
+
+// queryVector embeds the query text, consulting the in-process LRU and the
+// persistent cache before calling the embedder.
+func (e *Engine) queryVector(ctx context.Context, query string) ([]float32, error) {
+	key := NormalizeQuery(query)
+	if key == "" {
+		return e.embedQuery(ctx, query)
+	}
+	if cached, ok := e.queryVecCache.get(key); ok {
+		return cached, nil
+	}
+	if e.queryCache != nil {
+		vec, ok, err := e.queryCache.GetQueryVector(ctx, e.queryCacheOpts.Model, key, e.queryCacheOpts.TTL)
+		if err != nil {
+			fmt.Printf("⚠️ Query cache read failed: %v\n", err)
+		} else if ok && len(vec) > 0 {
+			e.queryVecCache.put(key, vec)
+			return vec, nil
+		}
+	}
+	vec, err := e.embedQuery(ctx, query)
+	if err != nil || len(vec) == 0 {
+		return vec, err
+	}
+	e.queryVecCache.put(key, vec)
+	if e.queryCache != nil {
+		if err := e.queryCache.PutQueryVector(ctx, e.queryCacheOpts.Model, key, vec, e.queryCacheOpts.MaxEntries); err != nil {
+			fmt.Printf("⚠️ Query cache write failed: %v\n", err)
+		}
+	}
+	return vec, nil
+}
+
+// queryVectors resolves vectors for several queries, sending every cache miss
+// to the embedder in one batch. Blank queries yield nil vectors.
+func (e *Engine) queryVectors(ctx context.Context, queries []string) ([][]float32, error) {
+	out := make([][]float32, len(queries))
+	var missIdx []int
+	var missTexts []string
+	for i, q := range queries {
+		key := NormalizeQuery(q)
+		if key == "" {
+			continue
+		}
+		if cached, ok := e.queryVecCache.get(key); ok {
+			out[i] = cached
+			continue
+		}
+		if e.queryCache != nil {
+			vec, ok, err := e.queryCache.GetQueryVector(ctx, e.queryCacheOpts.Model, key, e.queryCacheOpts.TTL)
+			if err != nil {
+				fmt.Printf("⚠️ Query cache read failed: %v\n", err)
+			} else if ok && len(vec) > 0 {
+				e.queryVecCache.put(key, vec)
+				out[i] = vec
+				continue
+			}
+		}
+		missIdx = append(missIdx, i)
+		missTexts = append(missTexts, q)
+	}
+	if len(missTexts) == 0 {
+		return out, nil
+	}
+	vectors, err := e.embedder.Embed(ctx, missTexts)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range missIdx {
+		if j >= len(vectors) || len(vectors[j]) == 0 {
+			continue
+		}
+		out[i] = vectors[j]
+		key := NormalizeQuery(queries[i])
+		e.queryVecCache.put(key, vectors[j])
+		if e.queryCache != nil {
+			if err := e.queryCache.PutQueryVector(ctx, e.queryCacheOpts.Model, key, vectors[j], e.queryCacheOpts.MaxEntries); err != nil {
+				fmt.Printf("⚠️ Query cache write failed: %v\n", err)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (e *Engine) embedQuery(ctx context.Context, query string) ([]float32, error) {
+	vectors, err := e.embedder.Embed(ctx, []string{query})
+	if err != nil || len(vectors) == 0 {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// vectorLRU is a fixed-capacity least-recently-used vector cache.
+type vectorLRU struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+type vectorLRUEntry struct {
+	key    string
+	vector []float32
+}
+
+func newVectorLRU(capacity int) *vectorLRU {
+	return &vectorLRU{capacity: capacity, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *vectorLRU) get(key string) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*vectorLRUEntry).vector, true
+}
+
+func (c *vectorLRU) put(key string, vector []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value.(*vectorLRUEntry).vector = vector
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&vectorLRUEntry{key: key, vector: vector})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*vectorLRUEntry).key)
+	}
+}
+
+func (c *vectorLRU) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,86 @@
+package knowledge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingEmbedder struct {
+	calls int
+}
+
+func (c *countingEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	c.calls++
+	out := make([][]float32, len(texts))
+	for i := range texts {
+		out[i] = []float32{float32(c.calls), 1}
+	}
+	return out, nil
+}
+
+func (c *countingEmbedder) Dimension() int { return 2 }
+
+type mapQueryCache struct {
+	entries map[string][]float32
+}
+
+func (m *mapQueryCache) GetQueryVector(_ context.Context, model, query string, _ time.Duration) ([]float32, bool, error) {
+	v, ok := m.entries[model+"|"+query]
+	return v, ok, nil
+}
+
+func (m *mapQueryCache) PutQueryVector(_ context.Context, model, query string, vector []float32, _ int) error {
+	m.entries[model+"|"+query] = vector
+	return nil
+}
+
+func TestVectorLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newVectorLRU(2)
+	c.put("a", []float32{1})
+	c.put("b", []float32{2})
+	_, _ = c.get("a")
+	c.put("c", []float32{3})
+
+	assert.Equal(t, 2, c.len())
+	_, ok := c.get("b")
+	assert.False(t, ok)
+	_, ok = c.get("a")
+	assert.True(t, ok)
+}
+
+func TestEngine_QueryVectorUsesPersistentCache(t *testing.T) {
+	ctx := context.Background()
+	cache := &mapQueryCache{entries: map[string][]float32{}}
+
+	em := &countingEmbedder{}
+	engine := NewEngine(graph.NewGraph(), em, nil)
+	engine.SetQueryVectorCache(cache, QueryCacheOptions{Model: "test/model/2"})
+	first, err := engine.queryVector(ctx, "  How does   Search work ")
+	require.NoError(t, err)
+	_, err = engine.queryVector(ctx, "how does search work")
+	require.NoError(t, err)
+	assert.Equal(t, 1, em.calls)
+	assert.Contains(t, cache.entries, "test/model/2|how does search work")
+
+	// A fresh engine (new run) reads the persisted vector instead of embedding.
+	em2 := &countingEmbedder{}
+	next := NewEngine(graph.NewGraph(), em2, nil)
+	next.SetQueryVectorCache(cache, QueryCacheOptions{Model: "test/model/2"})
+	got, err := next.queryVector(ctx, "How does search work")
+	require.NoError(t, err)
+	assert.Equal(t, first, got)
+	assert.Zero(t, em2.calls)
+
+	// Another model does not share entries.
+	other := NewEngine(graph.NewGraph(), em2, nil)
+	other.SetQueryVectorCache(cache, QueryCacheOptions{Model: "other/model/2"})
+	_, err = other.queryVector(ctx, "How does search work")
+	require.NoError(t, err)
+	assert.Equal(t, 1, em2.calls)
+}
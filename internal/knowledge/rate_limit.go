@@ -0,0 +1,155 @@
+package knowledge
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter spaces model requests evenly so that no more than a fixed
+// number start per minute. One limiter is shared by the embedder and the
+// summarizer, so concurrent work stays under a single provider quota. A nil
+// limiter never waits.
+type RateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewRateLimiter returns a limiter allowing perMinute requests per minute,
+// or nil when perMinute is not positive.
+func NewRateLimiter(perMinute int) *RateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &RateLimiter{interval: time.Minute / time.Duration(perMinute)}
+}
+
+// Wait blocks until the next request may start or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(start)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// limitedEmbedder waits on its limiter before each Embed request.
+type limitedEmbedder struct {
+	Embedder
+	limiter *RateLimiter
+}
+
+func (e *limitedEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := e.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return e.Embedder.Embed(ctx, texts)
+}
+
+// limitedSummarizer waits on its limiter before each model request.
+// withRateLimit wraps it further to forward the optional UnitSummarizer and
+// QuestionAnswerer methods only when the summarizer implements them.
+type limitedSummarizer struct {
+	Summarizer
+	limiter *RateLimiter
+}
+
+func (s *limitedSummarizer) SummarizeFullDoc(ctx context.Context, archChunks, featChunks, confChunks []SearchChunk) (string, error) {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+	return s.Summarizer.SummarizeFullDoc(ctx, archChunks, featChunks, confChunks)
+}
+
+func (s *limitedSummarizer) UpdateDocSection(ctx context.Context, currentContent string, relevantCode []SearchChunk) (string, error) {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+	return s.Summarizer.UpdateDocSection(ctx, currentContent, relevantCode)
+}
+
+func (s *limitedSummarizer) RenderSectionFromDraft(ctx context.Context, draftJSON string, relevantCode []SearchChunk) (string, error) {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+	return s.Summarizer.RenderSectionFromDraft(ctx, draftJSON, relevantCode)
+}
+
+func (s *limitedSummarizer) GenerateNewSection(ctx context.Context, relevantCode []SearchChunk) (string, error) {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+	return s.Summarizer.GenerateNewSection(ctx, relevantCode)
+}
+
+func (s *limitedSummarizer) FindInsertionPoint(ctx context.Context, toc []string, newContent string) (int, error) {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return -1, err
+	}
+	return s.Summarizer.FindInsertionPoint(ctx, toc, newContent)
+}
+
+func (s *limitedSummarizer) summarizeUnit(ctx context.Context, kind, name, content string) (string, error) {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+	return s.Summarizer.(UnitSummarizer).SummarizeUnit(ctx, kind, name, content)
+}
+
+func (s *limitedSummarizer) answerQuestion(ctx context.Context, question string, evidence []SearchChunk) (string, error) {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return "", err
+	}
+	return s.Summarizer.(QuestionAnswerer).AnswerQuestion(ctx, question, evidence)
+}
+
+// limitedUnitSummarizer is a limitedSummarizer of a UnitSummarizer.
+type limitedUnitSummarizer struct{ *limitedSummarizer }
+
+// SummarizeUnit implements UnitSummarizer.
+func (s limitedUnitSummarizer) SummarizeUnit(ctx context.Context, kind, name, content string) (string, error) {
+	return s.summarizeUnit(ctx, kind, name, content)
+}
+
+// limitedAnswerer is a limitedSummarizer of a QuestionAnswerer.
+type limitedAnswerer struct{ *limitedSummarizer }
+
+// AnswerQuestion implements QuestionAnswerer.
+func (s limitedAnswerer) AnswerQuestion(ctx context.Context, question string, evidence []SearchChunk) (string, error) {
+	return s.answerQuestion(ctx, question, evidence)
+}
+
+// limitedUnitAnswerer is a limitedSummarizer of a summarizer that is both a
+// UnitSummarizer and a QuestionAnswerer.
+type limitedUnitAnswerer struct{ *limitedSummarizer }
+
+// SummarizeUnit implements UnitSummarizer.
+func (s limitedUnitAnswerer) SummarizeUnit(ctx context.Context, kind, name, content string) (string, error) {
+	return s.summarizeUnit(ctx, kind, name, content)
+}
+
+// AnswerQuestion implements QuestionAnswerer.
+func (s limitedUnitAnswerer) AnswerQuestion(ctx context.Context, question string, evidence []SearchChunk) (string, error) {
+	return s.answerQuestion(ctx, question, evidence)
+}
@@ -0,0 +1,51 @@
+package knowledge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_SpacesRequests(t *testing.T) {
+	assert.Nil(t, NewRateLimiter(0))
+	require.NoError(t, (*RateLimiter)(nil).Wait(context.Background()))
+
+	l := NewRateLimiter(1200) // one request every 50ms
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, l.Wait(context.Background()))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestRateLimiter_WaitStopsWithContext(t *testing.T) {
+	l := NewRateLimiter(1) // one request a minute
+	require.NoError(t, l.Wait(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded)
+}
+
+func TestNewSummarizer_RateLimitedKeepsOptionalMethods(t *testing.T) {
+	s, err := NewSummarizer(context.Background(), SummarizerOptions{Provider: "openai", APIKey: "k", Model: "gpt-4o", RateLimiter: NewRateLimiter(60)})
+	require.NoError(t, err)
+	_, ok := s.(limitedUnitAnswerer)
+	assert.True(t, ok)
+	_, ok = s.(UnitSummarizer)
+	assert.True(t, ok)
+	_, ok = s.(QuestionAnswerer)
+	assert.True(t, ok)
+}
+
+func TestWithRateLimit_OmitsMissingOptionalMethods(t *testing.T) {
+	s := withRateLimit(struct{ Summarizer }{}, NewRateLimiter(60))
+	_, ok := s.(*limitedSummarizer)
+	assert.True(t, ok)
+	_, ok = s.(UnitSummarizer)
+	assert.False(t, ok)
+	_, ok = s.(QuestionAnswerer)
+	assert.False(t, ok)
+}
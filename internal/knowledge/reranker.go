@@ -0,0 +1,199 @@
+package knowledge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// rerankDocumentChars bounds the text sent per candidate.
+const rerankDocumentChars = 2000
+
+// CohereReranker calls a Cohere-compatible /v1/rerank endpoint. Jina and most
+// hosted rerank APIs accept the same request shape.
+type CohereReranker struct {
+	client   *http.Client
+	apiKey   string
+	model    string
+	endpoint string
+}
+
+type cohereRerankRequest struct {
+	Model     string   `json:"model"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n,omitempty"`
+}
+
+type cohereRerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+func NewCohereReranker(apiKey, model, baseURL string) *CohereReranker {
+	if strings.TrimSpace(model) == "" {
+		model = "rerank-v3.5"
+	}
+	url := strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if url == "" {
+		url = "https://api.cohere.com"
+	}
+	if !strings.HasSuffix(url, "/rerank") {
+		url += "/v1/rerank"
+	}
+	return &CohereReranker{
+		client:   &http.Client{Timeout: 60 * time.Second},
+		apiKey:   apiKey,
+		model:    model,
+		endpoint: url,
+	}
+}
+
+func (r *CohereReranker) Rerank(ctx context.Context, query string, chunks []SearchChunk, topN int) ([]SearchChunk, error) {
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+	var parsed cohereRerankResponse
+	err := postRerank(ctx, r.client, r.endpoint, r.apiKey, cohereRerankRequest{
+		Model:     r.model,
+		Query:     query,
+		Documents: rerankDocuments(chunks),
+		TopN:      topN,
+	}, &parsed)
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[int]float64, len(parsed.Results))
+	for _, res := range parsed.Results {
+		scores[res.Index] = res.RelevanceScore
+	}
+	return orderByRerankScores(chunks, scores, topN), nil
+}
+
+// TEIReranker calls the /rerank endpoint of a local text-embeddings-inference
+// server hosting a cross-encoder such as BAAI/bge-reranker-base.
+type TEIReranker struct {
+	client   *http.Client
+	endpoint string
+}
+
+type teiRerankRequest struct {
+	Query    string   `json:"query"`
+	Texts    []string `json:"texts"`
+	Truncate bool     `json:"truncate"`
+}
+
+type teiRerankResult struct {
+	Index int     `json:"index"`
+	Score float64 `json:"score"`
+}
+
+func NewTEIReranker(baseURL string) *TEIReranker {
+	url := strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if url == "" {
+		url = "http://127.0.0.1:8080"
+	}
+	if !strings.HasSuffix(url, "/rerank") {
+		url += "/rerank"
+	}
+	return &TEIReranker{
+		client:   &http.Client{Timeout: 60 * time.Second},
+		endpoint: url,
+	}
+}
+
+func (r *TEIReranker) Rerank(ctx context.Context, query string, chunks []SearchChunk, topN int) ([]SearchChunk, error) {
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+	var parsed []teiRerankResult
+	err := postRerank(ctx, r.client, r.endpoint, "", teiRerankRequest{
+		Query:    query,
+		Texts:    rerankDocuments(chunks),
+		Truncate: true,
+	}, &parsed)
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[int]float64, len(parsed))
+	for _, res := range parsed {
+		scores[res.Index] = res.Score
+	}
+	return orderByRerankScores(chunks, scores, topN), nil
+}
+
+func postRerank(ctx context.Context, client *http.Client, endpoint, apiKey string, reqBody, out interface{}) error {
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("rerank request failed (%d): %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// rerankDocuments renders each chunk as the passage judged by the cross-encoder.
+func rerankDocuments(chunks []SearchChunk) []string {
+	docs := make([]string, len(chunks))
+	for i, c := range chunks {
+		text := c.ToEmbeddableText()
+		if len(text) > rerankDocumentChars {
+			text = text[:rerankDocumentChars]
+		}
+		docs[i] = text
+	}
+	return docs
+}
+
+// orderByRerankScores sorts chunks by model score. Candidates the model did
+// not score keep their retrieval order after the scored ones.
+func orderByRerankScores(chunks []SearchChunk, scores map[int]float64, topN int) []SearchChunk {
+	order := make([]int, len(chunks))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		sa, okA := scores[order[a]]
+		sb, okB := scores[order[b]]
+		if okA != okB {
+			return okA
+		}
+		return sa > sb
+	})
+	if topN > 0 && len(order) > topN {
+		order = order[:topN]
+	}
+	out := make([]SearchChunk, len(order))
+	for i, idx := range order {
+		out[i] = chunks[idx]
+	}
+	return out
+}
@@ -0,0 +1,31 @@
+package knowledge
+
+import (
+	"fmt"
+	"strings"
+)
+
+type RerankerOptions struct {
+	Provider string
+	APIKey   string
+	Model    string
+	BaseURL  string
+}
+
+// NewReranker returns nil when no rerank provider is configured.
+func NewReranker(opts RerankerOptions) (Reranker, error) {
+	provider := strings.ToLower(strings.TrimSpace(opts.Provider))
+	switch provider {
+	case "", "none":
+		return nil, nil
+	case "cohere":
+		if strings.TrimSpace(opts.APIKey) == "" {
+			return nil, fmt.Errorf("rerank API key not configured for provider=%s", opts.Provider)
+		}
+		return NewCohereReranker(opts.APIKey, opts.Model, opts.BaseURL), nil
+	case "tei":
+		return NewTEIReranker(opts.BaseURL), nil
+	default:
+		return nil, fmt.Errorf("unsupported reranker provider: %s", opts.Provider)
+	}
+}
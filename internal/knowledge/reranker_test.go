@@ -0,0 +1,65 @@
+package knowledge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rerankChunks() []SearchChunk {
+	return []SearchChunk{{ID: "a", Name: "A"}, {ID: "b", Name: "B"}, {ID: "c", Name: "C"}}
+}
+
+func TestCohereReranker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/rerank", r.URL.Path)
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		var req cohereRerankRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "query", req.Query)
+		assert.Len(t, req.Documents, 3)
+		_, _ = w.Write([]byte(`{"results":[{"index":2,"relevance_score":0.9},{"index":0,"relevance_score":0.4}]}`))
+	}))
+	defer srv.Close()
+
+	out, err := NewCohereReranker("key", "", srv.URL).Rerank(context.Background(), "query", rerankChunks(), 0)
+	require.NoError(t, err)
+	// b was not scored and keeps its place after the scored candidates.
+	assert.Equal(t, []string{"c", "a", "b"}, chunkIDs(out))
+}
+
+func TestTEIReranker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rerank", r.URL.Path)
+		_, _ = w.Write([]byte(`[{"index":1,"score":0.8},{"index":0,"score":0.1},{"index":2,"score":0.5}]`))
+	}))
+	defer srv.Close()
+
+	out, err := NewTEIReranker(srv.URL).Rerank(context.Background(), "query", rerankChunks(), 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, chunkIDs(out))
+}
+
+func TestNewReranker(t *testing.T) {
+	r, err := NewReranker(RerankerOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, r)
+
+	_, err = NewReranker(RerankerOptions{Provider: "cohere"})
+	assert.Error(t, err)
+	_, err = NewReranker(RerankerOptions{Provider: "unknown"})
+	assert.Error(t, err)
+}
+
+func chunkIDs(chunks []SearchChunk) []string {
+	ids := make([]string, len(chunks))
+	for i, c := range chunks {
+		ids[i] = c.ID
+	}
+	return ids
+}
@@ -0,0 +1,85 @@
+package knowledge
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SectionStyle is the writing policy for one documentation section. Zero
+// fields leave the prompt defaults in place.
+type SectionStyle struct {
+	Tone         string
+	Audience     string
+	CodeLanguage string
+	// Diagrams lists the allowed Mermaid diagram types; ["none"] forbids
+	// diagrams and an empty list allows any.
+	Diagrams []string
+}
+
+type sectionStyleKey struct{}
+
+// WithSectionStyle attaches the style the summarizer applies to section
+// prompts made with ctx.
+func WithSectionStyle(ctx context.Context, style SectionStyle) context.Context {
+	return context.WithValue(ctx, sectionStyleKey{}, style)
+}
+
+// SectionStyleFrom returns the style attached to ctx, if any.
+func SectionStyleFrom(ctx context.Context) SectionStyle {
+	style, _ := ctx.Value(sectionStyleKey{}).(SectionStyle)
+	return style
+}
+
+// mermaidKeywords maps diagram types to the Mermaid keywords that open them.
+var mermaidKeywords = map[string]string{
+	"flowchart": "`graph`/`flowchart`",
+	"sequence":  "`sequenceDiagram`",
+	"class":     "`classDiagram`",
+	"er":        "`erDiagram`",
+	"state":     "`stateDiagram-v2`",
+}
+
+// styleInstructions renders the style as prompt rules that take precedence
+// over the generic instructions before them.
+func styleInstructions(style SectionStyle) string {
+	var rules []string
+	if t := strings.TrimSpace(style.Tone); t != "" {
+		rules = append(rules, "Tone: "+t+".")
+	}
+	if a := strings.TrimSpace(style.Audience); a != "" {
+		rules = append(rules, "Audience: "+a+". Choose depth and terminology for them.")
+	}
+	if l := strings.TrimSpace(style.CodeLanguage); l != "" {
+		rules = append(rules, fmt.Sprintf("Fence code examples as ```%s.", l))
+	}
+	if len(style.Diagrams) > 0 {
+		var allowed []string
+		none := false
+		for _, d := range style.Diagrams {
+			d = strings.ToLower(strings.TrimSpace(d))
+			if d == "none" {
+				none = true
+				break
+			}
+			if kw, ok := mermaidKeywords[d]; ok {
+				allowed = append(allowed, kw)
+			}
+		}
+		switch {
+		case none:
+			rules = append(rules, "Do NOT include any diagrams.")
+		case len(allowed) > 0:
+			rules = append(rules, "Diagrams: use only Mermaid "+strings.Join(allowed, ", ")+" diagrams.")
+		}
+	}
+	if len(rules) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n**STYLE POLICY** (overrides the instructions above where they conflict):\n")
+	for _, r := range rules {
+		sb.WriteString("- " + r + "\n")
+	}
+	return sb.String()
+}
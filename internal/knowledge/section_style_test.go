@@ -0,0 +1,31 @@
+package knowledge
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromptBuilder_AppliesSectionStyle(t *testing.T) {
+	pb := NewPromptBuilder("gpt-4o-mini")
+	chunks := []SearchChunk{{ID: "a", Name: "Run", Content: "func Run() {}"}}
+
+	plain, _ := pb.BuildUpdateDocPrompt("## Setup\nbody", chunks, SectionStyle{})
+	assert.NotContains(t, plain, "STYLE POLICY")
+
+	ctx := WithSectionStyle(context.Background(), SectionStyle{
+		Tone:         "friendly",
+		Audience:     "new contributors",
+		CodeLanguage: "bash",
+		Diagrams:     []string{"sequence", "class"},
+	})
+	styled, _ := pb.BuildUpdateDocPrompt("## Setup\nbody", chunks, SectionStyleFrom(ctx))
+	assert.Contains(t, styled, "- Tone: friendly.")
+	assert.Contains(t, styled, "- Audience: new contributors.")
+	assert.Contains(t, styled, "```bash")
+	assert.Contains(t, styled, "use only Mermaid `sequenceDiagram`, `classDiagram` diagrams")
+
+	none, _ := pb.BuildNewSectionPrompt(chunks, SectionStyle{Diagrams: []string{"none"}})
+	assert.Contains(t, none, "Do NOT include any diagrams.")
+}
@@ -11,6 +11,23 @@ type SummarizerOptions struct {
 	APIKey   string
 	Model    string
 	BaseURL  string
+	// ContextWindow overrides the model's known context window (tokens).
+	ContextWindow int
+	// MaxOutputTokens is reserved from the window for the response.
+	MaxOutputTokens int
+	// Deployment and APIVersion address an azure-openai deployment, whose
+	// resource endpoint is BaseURL. Deployment defaults to Model.
+	Deployment string
+	APIVersion string
+	// Tokenizer measures prompts against the context window; empty picks
+	// the one matching Provider and Model.
+	Tokenizer string
+	// DryRun returns a summarizer that builds the same prompts and records
+	// their estimated usage without calling the provider.
+	DryRun bool
+	// RateLimiter, if set, is waited on before each model request. Dry runs
+	// make no requests and ignore it.
+	RateLimiter *RateLimiter
 }
 
 func NewSummarizer(ctx context.Context, opts SummarizerOptions) (Summarizer, error) {
@@ -19,12 +36,63 @@ func NewSummarizer(ctx context.Context, opts SummarizerOptions) (Summarizer, err
 		provider = "gemini"
 	}
 
+	budget := NewContextBudget(opts.Model, opts.ContextWindow, opts.MaxOutputTokens)
+	tokenizer := opts.Tokenizer
+	if strings.TrimSpace(tokenizer) == "" {
+		tokenizer = TokenizerFor(provider, opts.Model)
+	}
+	t, err := NewTokenizer(tokenizer)
+	if err != nil {
+		return nil, err
+	}
+	budget.Tokenizer = t
+	if opts.DryRun {
+		return newDryRunSummarizer(provider, opts.Model, budget), nil
+	}
 	switch provider {
 	case "gemini":
-		return NewGeminiSummarizer(ctx, opts.APIKey, opts.Model)
+		s, err := NewGeminiSummarizer(ctx, opts.APIKey, opts.Model)
+		if err != nil {
+			return nil, err
+		}
+		s.promptBuilder.SetContextBudget(budget)
+		return withRateLimit(s, opts.RateLimiter), nil
 	case "openai":
-		return NewOpenAISummarizer(opts.APIKey, opts.Model, opts.BaseURL), nil
+		s := NewOpenAISummarizer(opts.APIKey, opts.Model, opts.BaseURL)
+		s.promptBuilder.SetContextBudget(budget)
+		return withRateLimit(s, opts.RateLimiter), nil
+	case "azure-openai":
+		if strings.TrimSpace(opts.BaseURL) == "" {
+			return nil, fmt.Errorf("azure-openai summarizer requires an endpoint")
+		}
+		s := NewAzureOpenAISummarizer(opts.APIKey, opts.BaseURL, opts.Deployment, opts.Model, opts.APIVersion)
+		s.promptBuilder.SetContextBudget(budget)
+		return withRateLimit(s, opts.RateLimiter), nil
+	case "anthropic":
+		s := NewAnthropicSummarizer(opts.APIKey, opts.Model, opts.BaseURL, opts.MaxOutputTokens)
+		s.promptBuilder.SetContextBudget(budget)
+		return withRateLimit(s, opts.RateLimiter), nil
 	default:
 		return nil, fmt.Errorf("unsupported summarizer provider: %s", opts.Provider)
 	}
 }
+
+// withRateLimit wraps s to wait on limiter, if any. The wrapper implements
+// UnitSummarizer and QuestionAnswerer exactly when s does.
+func withRateLimit(s Summarizer, limiter *RateLimiter) Summarizer {
+	if limiter == nil {
+		return s
+	}
+	limited := &limitedSummarizer{Summarizer: s, limiter: limiter}
+	_, unit := s.(UnitSummarizer)
+	_, answer := s.(QuestionAnswerer)
+	switch {
+	case unit && answer:
+		return limitedUnitAnswerer{limited}
+	case unit:
+		return limitedUnitSummarizer{limited}
+	case answer:
+		return limitedAnswerer{limited}
+	}
+	return limited
+}
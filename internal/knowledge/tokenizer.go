@@ -0,0 +1,155 @@
+package knowledge
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer names accepted by NewTokenizer.
+const (
+	TokenizerCL100K        = "cl100k"
+	TokenizerO200K         = "o200k"
+	TokenizerSentencePiece = "sentencepiece"
+	TokenizerChars         = "chars"
+)
+
+// Tokenizers lists the names accepted by NewTokenizer.
+var Tokenizers = []string{TokenizerCL100K, TokenizerO200K, TokenizerSentencePiece, TokenizerChars}
+
+// Chunk content budgets, in tokens of the engine tokenizer.
+const (
+	chunkContentTokens  = 750
+	symbolContentTokens = 300
+)
+
+const truncationMarker = "\n... (truncated)"
+
+// pretokenPattern splits text the way tiktoken's encoders do before BPE
+// merges: contractions, letter runs with their leading space, numbers of up
+// to three digits, punctuation runs and whitespace.
+var pretokenPattern = regexp.MustCompile(`'(?i:[sdmt]|ll|ve|re)| ?\pL+| ?\pN{1,3}| ?[^\s\pL\pN]+|\s+`)
+
+// Tokenizer counts the tokens a model's tokenizer would produce for a text.
+type Tokenizer interface {
+	CountTokens(s string) int
+}
+
+// bpeTokenizer approximates a BPE vocabulary without shipping it: text is
+// pre-tokenized like tiktoken, and each piece costs one token per so many
+// characters of its class, which tracks real counts on code and English
+// prose far better than a flat character ratio.
+type bpeTokenizer struct {
+	// letters and punct are the characters one token covers on average
+	// within a letter run and a punctuation run.
+	letters int
+	punct   int
+}
+
+func (t bpeTokenizer) CountTokens(s string) int {
+	n := 0
+	for _, piece := range pretokenPattern.FindAllString(s, -1) {
+		body := strings.TrimPrefix(piece, " ")
+		runes := utf8.RuneCountInString(body)
+		switch {
+		case runes == 0, strings.TrimSpace(piece) == "":
+			// A space alone, or a whitespace run, merges into one token.
+			n++
+		case unicode.IsLetter(firstRune(body)):
+			n += (runes + t.letters - 1) / t.letters
+		case unicode.IsNumber(firstRune(body)):
+			n++
+		default:
+			n += (runes + t.punct - 1) / t.punct
+		}
+	}
+	return n
+}
+
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+// charTokenizer is the flat four-characters-per-token estimate.
+type charTokenizer struct{}
+
+func (charTokenizer) CountTokens(s string) int { return EstimateTokens(s) }
+
+// NewTokenizer returns the named tokenizer; "" is the character estimate.
+func NewTokenizer(name string) (Tokenizer, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case TokenizerCL100K:
+		return bpeTokenizer{letters: 4, punct: 2}, nil
+	case TokenizerO200K:
+		return bpeTokenizer{letters: 5, punct: 2}, nil
+	case TokenizerSentencePiece:
+		return bpeTokenizer{letters: 4, punct: 1}, nil
+	case TokenizerChars, "":
+		return charTokenizer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown tokenizer %q (use %s)", name, strings.Join(Tokenizers, ", "))
+	}
+}
+
+// TokenizerFor picks the tokenizer that matches a provider and model:
+// o200k for the GPT-4o generation, cl100k for other OpenAI, Anthropic and
+// Ollama models, SentencePiece for Gemini, and the character estimate for
+// anything else.
+func TokenizerFor(provider, model string) string {
+	name := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	switch strings.ToLower(strings.TrimSpace(provider)) {
+	case "openai", "azure-openai":
+		for _, prefix := range []string{"gpt-4o", "gpt-4.1", "gpt-5", "o1", "o3", "o4"} {
+			if strings.HasPrefix(name, prefix) {
+				return TokenizerO200K
+			}
+		}
+		return TokenizerCL100K
+	case "anthropic", "ollama":
+		return TokenizerCL100K
+	case "gemini":
+		return TokenizerSentencePiece
+	}
+	return TokenizerChars
+}
+
+// TruncateTokens cuts s to at most max tokens, at a line boundary when one
+// falls in the second half of the kept text, and marks the cut. A max of 0
+// or less keeps s whole.
+func TruncateTokens(t Tokenizer, s string, max int) string {
+	if t == nil {
+		t = charTokenizer{}
+	}
+	if max <= 0 || t.CountTokens(s) <= max {
+		return s
+	}
+	room := max - t.CountTokens(truncationMarker)
+	if room <= 0 {
+		return truncationMarker
+	}
+	// Counts grow with the prefix, so the longest prefix that fits is
+	// found by bisection over byte offsets.
+	lo, hi := 0, len(s)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if t.CountTokens(s[:mid]) <= room {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	for lo > 0 && !utf8.RuneStart(s[lo]) {
+		lo--
+	}
+	kept := s[:lo]
+	if i := strings.LastIndex(kept, "\n"); i > len(kept)/2 {
+		kept = kept[:i]
+	}
+	return kept + truncationMarker
+}
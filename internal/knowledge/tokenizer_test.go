@@ -0,0 +1,84 @@
+package knowledge
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTokenizer(t *testing.T) {
+	for _, name := range append(Tokenizers, "", " CL100K ") {
+		tok, err := NewTokenizer(name)
+		require.NoError(t, err, name)
+		assert.NotNil(t, tok, name)
+	}
+
+	_, err := NewTokenizer("p50k")
+	assert.EqualError(t, err, `unknown tokenizer "p50k" (use cl100k, o200k, sentencepiece, chars)`)
+}
+
+func TestTokenizer_CountTokens(t *testing.T) {
+	cl100k, err := NewTokenizer(TokenizerCL100K)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, cl100k.CountTokens(""))
+	// "func", " main", "()", " {" and the newline.
+	assert.Equal(t, 5, cl100k.CountTokens("func main() {\n"))
+	// Long identifiers cost more than one token; numbers split every three digits.
+	assert.Equal(t, 4, cl100k.CountTokens("NewContextBudget"))
+	assert.Equal(t, 2, cl100k.CountTokens("123456"))
+
+	// Symbol-heavy code is denser in tokens than the flat estimate assumes.
+	code := strings.Repeat("if err != nil { return nil, err }\n", 20)
+	assert.Greater(t, cl100k.CountTokens(code), EstimateTokens(code))
+
+	chars, err := NewTokenizer(TokenizerChars)
+	require.NoError(t, err)
+	assert.Equal(t, EstimateTokens(code), chars.CountTokens(code))
+}
+
+func TestTokenizerFor(t *testing.T) {
+	for _, tc := range []struct{ provider, model, want string }{
+		{"openai", "gpt-4o-mini", TokenizerO200K},
+		{"openai", "text-embedding-3-small", TokenizerCL100K},
+		{"azure-openai", "openai/gpt-4.1", TokenizerO200K},
+		{"anthropic", "claude-sonnet-4", TokenizerCL100K},
+		{"ollama", "nomic-embed-text", TokenizerCL100K},
+		{"Gemini", "gemini-2.5-flash", TokenizerSentencePiece},
+		{"local", "all-MiniLM-L6-v2", TokenizerChars},
+	} {
+		assert.Equal(t, tc.want, TokenizerFor(tc.provider, tc.model), tc.provider+"/"+tc.model)
+	}
+}
+
+func TestTruncateTokens(t *testing.T) {
+	tok, err := NewTokenizer(TokenizerCL100K)
+	require.NoError(t, err)
+
+	short := "func main() {}"
+	assert.Equal(t, short, TruncateTokens(tok, short, 100))
+	assert.Equal(t, short, TruncateTokens(tok, short, 0))
+
+	var b strings.Builder
+	for i := 0; i < 200; i++ {
+		b.WriteString("\tvalue := compute(input, options)\n")
+	}
+	long := b.String()
+	got := TruncateTokens(tok, long, 120)
+	assert.LessOrEqual(t, tok.CountTokens(got), 120)
+	assert.True(t, strings.HasSuffix(got, truncationMarker))
+	// The cut falls on a line boundary.
+	kept := strings.TrimSuffix(got, truncationMarker)
+	assert.True(t, strings.HasSuffix(kept, ")"), kept[len(kept)-20:])
+	assert.True(t, strings.HasPrefix(long, kept))
+
+	// Multi-byte text is never split inside a rune.
+	wide := strings.Repeat("가", 500)
+	assert.True(t, utf8.ValidString(TruncateTokens(tok, wide, 10)))
+
+	// A nil tokenizer falls back to the character estimate.
+	assert.LessOrEqual(t, EstimateTokens(TruncateTokens(nil, long, 50)), 50)
+}
@@ -23,13 +23,19 @@ type Summarizer interface {
 type VectorItem struct {
 	Chunk     SearchChunk
 	Embedding []float32
+	// CodeEmbedding is the optional vector of the raw code body (dual
+	// embeddings); searches keep the better of the two similarities.
+	CodeEmbedding []float32
+	// Score is the similarity reported by Search (0 when the index does not score).
+	Score float32
 }
 
 // Indexer manages the storage and retrieval of VectorItems.
 type Indexer interface {
 	Add(ctx context.Context, items []VectorItem) error
 	Delete(ctx context.Context, ids []string) error
-	Search(ctx context.Context, queryVector []float32, topK int) ([]VectorItem, error)
+	// Search returns the topK most similar items that pass filter.
+	Search(ctx context.Context, queryVector []float32, topK int, filter SearchFilter) ([]VectorItem, error)
 }
 
 // IndexContentHashReader is an optional capability for index implementations.
@@ -37,3 +43,36 @@ type Indexer interface {
 type IndexContentHashReader interface {
 	GetContentHashes(ctx context.Context, ids []string) (map[string]string, error)
 }
+
+// EmbeddingHashReader is an optional capability for index implementations
+// that can look up stored vectors by chunk content hash, so identical content
+// is embedded only once.
+type EmbeddingHashReader interface {
+	GetEmbeddingsByContentHash(ctx context.Context, hashes []string) (map[string][]float32, error)
+}
+
+// CodeEmbeddingHashReader is the code-view counterpart of EmbeddingHashReader
+// for indexes that store dual embeddings.
+type CodeEmbeddingHashReader interface {
+	GetCodeEmbeddingsByContentHash(ctx context.Context, hashes []string) (map[string][]float32, error)
+}
+
+// KeywordSearcher is an optional capability for index implementations that
+// can rank stored chunks by keyword relevance (BM25) alongside vectors.
+type KeywordSearcher interface {
+	KeywordSearch(ctx context.Context, query string, topK int, filter SearchFilter) ([]VectorItem, error)
+}
+
+// BatchSearcher is an optional capability for index implementations that can
+// score several query vectors in a single pass over the stored chunks.
+type BatchSearcher interface {
+	// SearchBatch returns, per query vector, the topK most similar items
+	// that pass filter.
+	SearchBatch(ctx context.Context, queryVectors [][]float32, topK int, filter SearchFilter) ([][]VectorItem, error)
+}
+
+// Reranker scores query/candidate pairs jointly (cross-encoder) and returns
+// the candidates in descending relevance, keeping at most topN.
+type Reranker interface {
+	Rerank(ctx context.Context, query string, chunks []SearchChunk, topN int) ([]SearchChunk, error)
+}
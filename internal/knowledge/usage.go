@@ -0,0 +1,309 @@
+package knowledge
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Usage kinds.
+const (
+	UsageEmbedding = "embedding"
+	UsageLLM       = "llm"
+)
+
+// Usage counts model requests and tokens, and what they are estimated to
+// cost in USD.
+type Usage struct {
+	Requests     int     `json:"requests"`
+	InputTokens  int     `json:"input_tokens"`
+	OutputTokens int     `json:"output_tokens"`
+	Cost         float64 `json:"cost_estimate"`
+}
+
+// Add accumulates o into u.
+func (u *Usage) Add(o Usage) {
+	u.Requests += o.Requests
+	u.InputTokens += o.InputTokens
+	u.OutputTokens += o.OutputTokens
+	u.Cost += o.Cost
+}
+
+// Sub returns the usage in u that is not in o.
+func (u Usage) Sub(o Usage) Usage {
+	return Usage{
+		Requests:     u.Requests - o.Requests,
+		InputTokens:  u.InputTokens - o.InputTokens,
+		OutputTokens: u.OutputTokens - o.OutputTokens,
+		Cost:         u.Cost - o.Cost,
+	}
+}
+
+// ModelPrice is the USD price of a model per million tokens.
+type ModelPrice struct {
+	Input  float64
+	Output float64
+}
+
+// defaultModelPrices are list prices of common hosted models. Models are
+// matched by the longest prefix, so dated snapshots share their base price;
+// unknown and local models cost nothing.
+var defaultModelPrices = map[string]ModelPrice{
+	"gpt-4o":                 {Input: 2.50, Output: 10},
+	"gpt-4o-mini":            {Input: 0.15, Output: 0.60},
+	"gpt-4.1":                {Input: 2, Output: 8},
+	"gpt-4.1-mini":           {Input: 0.40, Output: 1.60},
+	"gpt-4.1-nano":           {Input: 0.10, Output: 0.40},
+	"text-embedding-3-small": {Input: 0.02},
+	"text-embedding-3-large": {Input: 0.13},
+	"text-embedding-ada-002": {Input: 0.10},
+	"gemini-2.5-pro":         {Input: 1.25, Output: 10},
+	"gemini-2.5-flash":       {Input: 0.30, Output: 2.50},
+	"gemini-2.5-flash-lite":  {Input: 0.10, Output: 0.40},
+	"gemini-embedding-001":   {Input: 0.15},
+	"claude-opus-4":          {Input: 15, Output: 75},
+	"claude-sonnet-4":        {Input: 3, Output: 15},
+	"claude-3-5-haiku":       {Input: 0.80, Output: 4},
+	"claude-haiku-4-5":       {Input: 1, Output: 5},
+}
+
+// UsageRecord is the usage of one model within one section; Section is
+// empty for calls made outside of any section.
+type UsageRecord struct {
+	Kind     string `json:"kind"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Section  string `json:"section,omitempty"`
+	Usage
+}
+
+// UsageRun is the usage recorded by one pipeline run.
+type UsageRun struct {
+	ID        int64
+	Mode      string
+	StartedAt time.Time
+	Records   []UsageRecord
+}
+
+// Total sums the records of the run.
+func (r UsageRun) Total() Usage {
+	var total Usage
+	for _, rec := range r.Records {
+		total.Add(rec.Usage)
+	}
+	return total
+}
+
+// Sections sums the records of the run by section.
+func (r UsageRun) Sections() map[string]Usage {
+	out := make(map[string]Usage)
+	for _, rec := range r.Records {
+		u := out[rec.Section]
+		u.Add(rec.Usage)
+		out[rec.Section] = u
+	}
+	return out
+}
+
+type usageKey struct {
+	kind, provider, model, section string
+}
+
+// UsageMeter aggregates the usage of the embedder and summarizer calls made
+// with a context it is attached to. It is safe for concurrent use.
+type UsageMeter struct {
+	started time.Time
+	prices  map[string]ModelPrice
+
+	mu      sync.Mutex
+	records map[usageKey]*Usage
+}
+
+// NewUsageMeter returns a meter pricing models with prices over the built-in
+// list prices.
+func NewUsageMeter(prices map[string]ModelPrice) *UsageMeter {
+	merged := make(map[string]ModelPrice, len(defaultModelPrices)+len(prices))
+	for model, p := range defaultModelPrices {
+		merged[model] = p
+	}
+	for model, p := range prices {
+		merged[strings.ToLower(strings.TrimSpace(model))] = p
+	}
+	return &UsageMeter{
+		started: time.Now().UTC(),
+		prices:  merged,
+		records: make(map[usageKey]*Usage),
+	}
+}
+
+// Price returns the price of model, or zero when it is unknown.
+func (m *UsageMeter) Price(model string) ModelPrice {
+	name := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	best := ""
+	for prefix := range m.prices {
+		if strings.HasPrefix(name, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	return m.prices[best]
+}
+
+// Record adds one request of model to section.
+func (m *UsageMeter) Record(kind, provider, model, section string, inputTokens, outputTokens int) {
+	if m == nil {
+		return
+	}
+	price := m.Price(model)
+	cost := (float64(inputTokens)*price.Input + float64(outputTokens)*price.Output) / 1e6
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := usageKey{kind: kind, provider: provider, model: model, section: section}
+	u := m.records[key]
+	if u == nil {
+		u = &Usage{}
+		m.records[key] = u
+	}
+	u.Add(Usage{Requests: 1, InputTokens: inputTokens, OutputTokens: outputTokens, Cost: cost})
+}
+
+// Total is the usage recorded so far.
+func (m *UsageMeter) Total() Usage {
+	var total Usage
+	if m == nil {
+		return total
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, u := range m.records {
+		total.Add(*u)
+	}
+	return total
+}
+
+// Section is the usage recorded so far for section.
+func (m *UsageMeter) Section(section string) Usage {
+	var total Usage
+	if m == nil {
+		return total
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, u := range m.records {
+		if key.section == section {
+			total.Add(*u)
+		}
+	}
+	return total
+}
+
+// Run returns the usage recorded so far as a run of mode, records sorted by
+// section, kind, provider and model.
+func (m *UsageMeter) Run(mode string) UsageRun {
+	run := UsageRun{Mode: mode}
+	if m == nil {
+		return run
+	}
+	run.StartedAt = m.started
+	m.mu.Lock()
+	for key, u := range m.records {
+		run.Records = append(run.Records, UsageRecord{Kind: key.kind, Provider: key.provider, Model: key.model, Section: key.section, Usage: *u})
+	}
+	m.mu.Unlock()
+	sort.Slice(run.Records, func(i, j int) bool {
+		a, b := run.Records[i], run.Records[j]
+		if a.Section != b.Section {
+			return a.Section < b.Section
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.Model < b.Model
+	})
+	return run
+}
+
+type usageMeterKey struct{}
+
+type usageSectionKey struct{}
+
+// WithUsageMeter attaches the meter that records the model calls made with
+// ctx.
+func WithUsageMeter(ctx context.Context, m *UsageMeter) context.Context {
+	return context.WithValue(ctx, usageMeterKey{}, m)
+}
+
+// UsageMeterFrom returns the meter attached to ctx, or nil.
+func UsageMeterFrom(ctx context.Context) *UsageMeter {
+	m, _ := ctx.Value(usageMeterKey{}).(*UsageMeter)
+	return m
+}
+
+// WithUsageSection attributes the model calls made with ctx to a section.
+func WithUsageSection(ctx context.Context, section string) context.Context {
+	return context.WithValue(ctx, usageSectionKey{}, section)
+}
+
+// recordUsage records one request on the meter attached to ctx, if any.
+func recordUsage(ctx context.Context, kind, provider, model string, inputTokens, outputTokens int) {
+	m := UsageMeterFrom(ctx)
+	if m == nil {
+		return
+	}
+	section, _ := ctx.Value(usageSectionKey{}).(string)
+	m.Record(kind, provider, model, section, inputTokens, outputTokens)
+}
+
+// meteredEmbedder records the usage of each Embed call. Embedding APIs do
+// not all report token counts, so input tokens are counted locally.
+type meteredEmbedder struct {
+	Embedder
+	provider  string
+	model     string
+	tokenizer Tokenizer
+}
+
+func (e *meteredEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, err := e.Embedder.Embed(ctx, texts)
+	if err != nil {
+		return vectors, err
+	}
+	e.record(ctx, texts)
+	return vectors, err
+}
+
+// record records one Embed request of texts.
+func (e *meteredEmbedder) record(ctx context.Context, texts []string) {
+	if UsageMeterFrom(ctx) == nil {
+		return
+	}
+	tokens := 0
+	for _, t := range texts {
+		tokens += e.tokenizer.CountTokens(t)
+	}
+	recordUsage(ctx, UsageEmbedding, e.provider, e.model, tokens, 0)
+}
+
+// recordUsage records an LLM request for prompt and output. Token counts the
+// API reported are used as is; missing ones are counted with the budget
+// tokenizer.
+func (pb *PromptBuilder) recordUsage(ctx context.Context, provider, model, prompt, output string, inputTokens, outputTokens int) {
+	if UsageMeterFrom(ctx) == nil {
+		return
+	}
+	if inputTokens <= 0 {
+		inputTokens = pb.budget.CountTokens(prompt)
+	}
+	if outputTokens <= 0 {
+		outputTokens = pb.budget.CountTokens(output)
+	}
+	recordUsage(ctx, UsageLLM, provider, model, inputTokens, outputTokens)
+}
@@ -0,0 +1,79 @@
+package knowledge
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageMeter_Price(t *testing.T) {
+	m := NewUsageMeter(map[string]ModelPrice{"GPT-4o": {Input: 1, Output: 2}, "my-model": {Input: 0.5}})
+
+	assert.Equal(t, ModelPrice{Input: 1, Output: 2}, m.Price("gpt-4o-2024-08-06"))
+	assert.Equal(t, ModelPrice{Input: 0.15, Output: 0.60}, m.Price("gpt-4o-mini"))
+	assert.Equal(t, ModelPrice{Input: 0.5}, m.Price("org/my-model"))
+	assert.Equal(t, ModelPrice{}, m.Price("nomic-embed-text"))
+}
+
+func TestUsageMeter_RecordsBySection(t *testing.T) {
+	m := NewUsageMeter(nil)
+	ctx := WithUsageMeter(context.Background(), m)
+
+	recordUsage(WithUsageSection(ctx, "overview"), UsageLLM, "openai", "gpt-4o", 1_000_000, 100_000)
+	recordUsage(WithUsageSection(ctx, "overview"), UsageLLM, "openai", "gpt-4o", 0, 0)
+	recordUsage(ctx, UsageEmbedding, "openai", "text-embedding-3-small", 500_000, 0)
+	// Without a meter nothing is recorded.
+	recordUsage(context.Background(), UsageLLM, "openai", "gpt-4o", 10, 10)
+
+	overview := m.Section("overview")
+	assert.Equal(t, 2, overview.Requests)
+	assert.InDelta(t, 3.5, overview.Cost, 1e-9)
+	assert.InDelta(t, 3.51, m.Total().Cost, 1e-9)
+
+	run := m.Run("full_generate")
+	assert.Equal(t, "full_generate", run.Mode)
+	require.Len(t, run.Records, 2)
+	assert.Equal(t, "", run.Records[0].Section)
+	assert.Equal(t, UsageEmbedding, run.Records[0].Kind)
+	assert.Equal(t, "overview", run.Records[1].Section)
+	assert.Equal(t, m.Total(), run.Total())
+	assert.Equal(t, overview, run.Sections()["overview"])
+}
+
+func TestMeteredEmbedder_CountsInputTokens(t *testing.T) {
+	tok, err := NewTokenizer(TokenizerChars)
+	require.NoError(t, err)
+	e := &meteredEmbedder{Embedder: fixedEmbedder{vec: []float32{1, 0}}, provider: "openai", model: "text-embedding-3-small", tokenizer: tok}
+	m := NewUsageMeter(nil)
+
+	_, err = e.Embed(WithUsageMeter(context.Background(), m), []string{"12345678", "1234"})
+	require.NoError(t, err)
+	assert.Equal(t, Usage{Requests: 1, InputTokens: 3, Cost: 3 * 0.02 / 1e6}, m.Total())
+}
+
+func TestSummarizer_RecordsReportedUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"content":[{"type":"text","text":"ok"}],"usage":{"input_tokens":1200,"output_tokens":30}}`))
+	}))
+	defer srv.Close()
+
+	m := NewUsageMeter(nil)
+	ctx := WithUsageSection(WithUsageMeter(context.Background(), m), "overview")
+	s := NewAnthropicSummarizer("key", "claude-sonnet-4-5", srv.URL, 1024)
+	_, err := s.SummarizeUnit(ctx, "function", "Open", "func Open() {}")
+	require.NoError(t, err)
+
+	run := m.Run("full_generate")
+	require.Len(t, run.Records, 1)
+	rec := run.Records[0]
+	assert.Equal(t, UsageLLM, rec.Kind)
+	assert.Equal(t, "anthropic", rec.Provider)
+	assert.Equal(t, "overview", rec.Section)
+	assert.Equal(t, 1200, rec.InputTokens)
+	assert.Equal(t, 30, rec.OutputTokens)
+	assert.InDelta(t, (1200*3+30*15)/1e6, rec.Cost, 1e-12)
+}
@@ -0,0 +1,145 @@
+package knowledge
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxWordPieceChars is the longest word split into sub-words; longer words
+// become [UNK] as in BERT.
+const maxWordPieceChars = 100
+
+// WordPieceTokenizer is the uncased BERT tokenizer used by sentence-transformer
+// models such as all-MiniLM-L6-v2.
+type WordPieceTokenizer struct {
+	vocab map[string]int64
+	unk   int64
+	cls   int64
+	sep   int64
+	pad   int64
+}
+
+// LoadWordPieceVocab reads a vocab.txt with one token per line; the line
+// number is the token ID.
+func LoadWordPieceVocab(path string) (*WordPieceTokenizer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vocab := make(map[string]int64)
+	sc := bufio.NewScanner(f)
+	for id := int64(0); sc.Scan(); id++ {
+		token := strings.TrimRight(sc.Text(), "\r")
+		if _, dup := vocab[token]; !dup {
+			vocab[token] = id
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return NewWordPieceTokenizer(vocab)
+}
+
+// NewWordPieceTokenizer wraps a vocabulary that contains the [UNK], [CLS],
+// [SEP] and [PAD] special tokens.
+func NewWordPieceTokenizer(vocab map[string]int64) (*WordPieceTokenizer, error) {
+	t := &WordPieceTokenizer{vocab: vocab}
+	for _, special := range []struct {
+		token string
+		id    *int64
+	}{{"[UNK]", &t.unk}, {"[CLS]", &t.cls}, {"[SEP]", &t.sep}, {"[PAD]", &t.pad}} {
+		id, ok := vocab[special.token]
+		if !ok {
+			return nil, fmt.Errorf("wordpiece vocab has no %s token", special.token)
+		}
+		*special.id = id
+	}
+	return t, nil
+}
+
+// Encode returns [CLS] tokens [SEP], truncated to maxLen IDs (0 keeps all).
+func (t *WordPieceTokenizer) Encode(text string, maxLen int) []int64 {
+	ids := []int64{t.cls}
+	for _, word := range basicTokens(text) {
+		ids = append(ids, t.wordPieces(word)...)
+	}
+	if maxLen > 1 && len(ids) > maxLen-1 {
+		ids = ids[:maxLen-1]
+	}
+	return append(ids, t.sep)
+}
+
+// wordPieces splits word greedily into the longest vocabulary prefixes,
+// continuing pieces marked with ##.
+func (t *WordPieceTokenizer) wordPieces(word string) []int64 {
+	runes := []rune(word)
+	if len(runes) > maxWordPieceChars {
+		return []int64{t.unk}
+	}
+	var ids []int64
+	for start := 0; start < len(runes); {
+		end := len(runes)
+		found := false
+		for ; end > start; end-- {
+			piece := string(runes[start:end])
+			if start > 0 {
+				piece = "##" + piece
+			}
+			if id, ok := t.vocab[piece]; ok {
+				ids = append(ids, id)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return []int64{t.unk}
+		}
+		start = end
+	}
+	return ids
+}
+
+// basicTokens lowercases text, strips accents and control characters, and
+// splits it on whitespace and punctuation, keeping punctuation and CJK
+// characters as their own tokens.
+func basicTokens(text string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range norm.NFD.String(strings.ToLower(text)) {
+		switch {
+		case r == 0 || r == unicode.ReplacementChar || unicode.Is(unicode.Mn, r):
+		case unicode.IsSpace(r):
+			flush()
+		case unicode.IsControl(r):
+		case isBERTPunct(r) || unicode.Is(unicode.Han, r):
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// isBERTPunct treats every non-alphanumeric ASCII symbol as punctuation, as
+// BERT does for characters like ^, $ and `.
+func isBERTPunct(r rune) bool {
+	if (r >= 33 && r <= 47) || (r >= 58 && r <= 64) || (r >= 91 && r <= 96) || (r >= 123 && r <= 126) {
+		return true
+	}
+	return unicode.IsPunct(r)
+}
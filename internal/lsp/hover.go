@@ -0,0 +1,245 @@
+package lsp
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"docod/internal/generator"
+	"docod/internal/graph"
+)
+
+// maxHoverNeighbors bounds the callers and callees listed in a hover.
+const maxHoverNeighbors = 5
+
+// maxExcerptChars bounds the generated-documentation excerpt in a hover.
+const maxExcerptChars = 400
+
+// Hover describes the symbol at pos, or returns nil when the graph does not
+// know it.
+func (s *Server) Hover(uri string, pos Position) *Hover {
+	sym, rng := s.symbolAt(uri, pos)
+	if sym == nil {
+		return nil
+	}
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: s.renderHover(sym)}, Range: rng}
+}
+
+// Definition returns the declaration of the symbol at pos.
+func (s *Server) Definition(uri string, pos Position) *Location {
+	sym, _ := s.symbolAt(uri, pos)
+	if sym == nil {
+		return nil
+	}
+	line := max(sym.StartLine-1, 0)
+	return &Location{
+		URI:   s.uriFor(sym.Filepath),
+		Range: Range{Start: Position{Line: line}, End: Position{Line: line}},
+	}
+}
+
+// symbolAt resolves the identifier under pos: the enclosing declaration when
+// it is the declared name, a symbol the enclosing declaration references,
+// or else a symbol of that name, preferring the document's package.
+func (s *Server) symbolAt(uri string, pos Position) (*graph.Symbol, *Range) {
+	text, ok := s.document(uri)
+	if !ok {
+		return nil, nil
+	}
+	word, rng := wordAt(text, pos)
+	if word == "" {
+		return nil, nil
+	}
+	file := s.relPath(uri)
+	var enclosing *graph.Symbol
+	for _, u := range s.byFile[file] {
+		if u.StartLine <= pos.Line+1 && pos.Line+1 <= u.EndLine {
+			enclosing = u
+			break
+		}
+	}
+	if enclosing != nil {
+		if enclosing.Name == word {
+			return enclosing, rng
+		}
+		var best *graph.Symbol
+		bestConf := -1.0
+		for _, e := range s.out[enclosing.ID] {
+			n, ok := s.g.Nodes[e.To]
+			if !ok || n == nil || n.Unit == nil || n.Unit.Name != word {
+				continue
+			}
+			if e.Confidence > bestConf {
+				best, bestConf = n.Unit, e.Confidence
+			}
+		}
+		if best != nil {
+			return best, rng
+		}
+	}
+	candidates := s.byName[word]
+	dir := path.Dir(file)
+	for _, u := range candidates {
+		if path.Dir(filepathSlash(u.Filepath)) == dir && u.UnitType != graph.UnitTypeTest {
+			return u, rng
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], rng
+	}
+	return nil, nil
+}
+
+func (s *Server) renderHover(sym *graph.Symbol) string {
+	var b strings.Builder
+	sig := strings.TrimSpace(sym.Metadata.Signature)
+	if sig == "" {
+		sig = strings.TrimSpace(sym.UnitType + " " + sym.Name)
+	}
+	fmt.Fprintf(&b, "```go\n%s\n```\n\n", sig)
+	if doc := strings.TrimSpace(sym.Description); doc != "" {
+		b.WriteString(doc + "\n\n")
+	}
+	if sym.UnitType == graph.UnitTypeExternal && sym.Metadata.DocURL != "" {
+		fmt.Fprintf(&b, "External module `%s` %s: %s\n\n", sym.Metadata.Module, sym.Metadata.Version, sym.Metadata.DocURL)
+	}
+	for _, sec := range s.sections[sym.ID] {
+		fmt.Fprintf(&b, "**Documented in %s**", sec.Title)
+		if excerpt := sectionExcerpt(sec, sym.Name); excerpt != "" {
+			b.WriteString(": " + excerpt)
+		}
+		b.WriteString("\n\n")
+	}
+	if pkg, ok := s.packages[path.Dir(filepathSlash(sym.Filepath))]; ok {
+		fmt.Fprintf(&b, "**Package** `%s`", pkg.Dir)
+		if len(pkg.Responsibilities) > 0 {
+			b.WriteString(": " + pkg.Responsibilities[0])
+		}
+		b.WriteString("\n\n")
+	}
+	var calls, callers, implements, tests []string
+	for _, e := range s.out[sym.ID] {
+		switch e.Kind {
+		case graph.RelationCalls, graph.RelationMayCall:
+			calls = append(calls, s.name(e.To))
+		case graph.RelationImplements:
+			implements = append(implements, s.name(e.To))
+		}
+	}
+	for _, e := range s.in[sym.ID] {
+		switch e.Kind {
+		case graph.RelationCalls, graph.RelationMayCall:
+			callers = append(callers, s.name(e.From))
+		case graph.RelationTests:
+			tests = append(tests, s.name(e.From))
+		}
+	}
+	writeList(&b, "Calls", calls)
+	writeList(&b, "Called by", callers)
+	writeList(&b, "Implements", implements)
+	writeList(&b, "Tested by", tests)
+	if sym.Filepath != "" {
+		fmt.Fprintf(&b, "_%s:%d-%d_\n", filepathSlash(sym.Filepath), sym.StartLine, sym.EndLine)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func (s *Server) name(id string) string {
+	if n, ok := s.g.Nodes[id]; ok && n != nil && n.Unit != nil && n.Unit.Name != "" {
+		if n.Unit.Metadata.Receiver != "" {
+			return n.Unit.Metadata.Receiver + "." + n.Unit.Name
+		}
+		return n.Unit.Name
+	}
+	return id
+}
+
+func writeList(b *strings.Builder, label string, names []string) {
+	names = uniqueSorted(names)
+	if len(names) == 0 {
+		return
+	}
+	shown := names
+	if len(shown) > maxHoverNeighbors {
+		shown = shown[:maxHoverNeighbors]
+	}
+	fmt.Fprintf(b, "**%s** `%s`", label, strings.Join(shown, "`, `"))
+	if extra := len(names) - len(shown); extra > 0 {
+		fmt.Fprintf(b, " and %d more", extra)
+	}
+	b.WriteString("\n\n")
+}
+
+// sectionExcerpt returns the section summary, or the first paragraph of the
+// section that mentions name.
+func sectionExcerpt(sec generator.ModelSect, name string) string {
+	text := strings.TrimSpace(sec.Summary)
+	if text == "" {
+		for _, para := range strings.Split(sec.ContentMD, "\n\n") {
+			para = strings.TrimSpace(para)
+			if para == "" || strings.HasPrefix(para, "#") || strings.HasPrefix(para, "```") {
+				continue
+			}
+			if strings.Contains(para, name) {
+				text = para
+				break
+			}
+		}
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	if r := []rune(text); len(r) > maxExcerptChars {
+		text = string(r[:maxExcerptChars]) + "…"
+	}
+	return text
+}
+
+// wordAt returns the identifier at pos and its range. Character offsets are
+// UTF-16 code units.
+func wordAt(text string, pos Position) (string, *Range) {
+	lines := strings.Split(text, "\n")
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return "", nil
+	}
+	units := utf16.Encode([]rune(strings.TrimSuffix(lines[pos.Line], "\r")))
+	if pos.Character < 0 || pos.Character > len(units) {
+		return "", nil
+	}
+	isIdent := func(u uint16) bool {
+		r := rune(u)
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	start, end := pos.Character, pos.Character
+	for start > 0 && isIdent(units[start-1]) {
+		start--
+	}
+	for end < len(units) && isIdent(units[end]) {
+		end++
+	}
+	if start == end {
+		return "", nil
+	}
+	return string(utf16.Decode(units[start:end])), &Range{
+		Start: Position{Line: pos.Line, Character: start},
+		End:   Position{Line: pos.Line, Character: end},
+	}
+}
+
+func filepathSlash(p string) string {
+	return path.Clean(strings.ReplaceAll(p, "\\", "/"))
+}
+
+func uniqueSorted(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	out := make([]string, 0, len(items))
+	for _, it := range items {
+		if !seen[it] {
+			seen[it] = true
+			out = append(out, it)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
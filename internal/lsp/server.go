@@ -0,0 +1,258 @@
+package lsp
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"docod/internal/generator"
+	"docod/internal/graph"
+	"docod/internal/jsonrpc"
+)
+
+// Options configures a Server.
+type Options struct {
+	// Root is the directory graph file paths are relative to. When empty the
+	// workspace root sent by the editor is used.
+	Root string
+	// Model is the generated doc model whose sections are linked from hovers.
+	// It may be nil before the first `docod generate`.
+	Model *generator.DocModel
+}
+
+// Server answers LSP hover and definition requests from the knowledge graph,
+// so editors show the doc comment, generated documentation and graph context
+// of a symbol. It speaks JSON-RPC over a single stream, usually stdio.
+type Server struct {
+	g        *graph.Graph
+	root     string
+	byFile   map[string][]*graph.Symbol // slash path -> symbols, smallest span first
+	byName   map[string][]*graph.Symbol
+	out      map[string][]graph.Edge
+	in       map[string][]graph.Edge
+	sections map[string][]generator.ModelSect // symbol ID -> sections citing it
+	packages map[string]graph.PackageSummary  // package dir -> summary
+
+	mu   sync.Mutex
+	docs map[string]string // open document URI -> text
+}
+
+// NewServer indexes g for serving. The graph must not be mutated afterwards.
+func NewServer(g *graph.Graph, opts Options) *Server {
+	if g == nil {
+		g = graph.NewGraph()
+	}
+	s := &Server{
+		g:        g,
+		root:     opts.Root,
+		byFile:   make(map[string][]*graph.Symbol),
+		byName:   make(map[string][]*graph.Symbol),
+		out:      make(map[string][]graph.Edge),
+		in:       make(map[string][]graph.Edge),
+		sections: make(map[string][]generator.ModelSect),
+		packages: make(map[string]graph.PackageSummary),
+		docs:     make(map[string]string),
+	}
+	for _, n := range g.Nodes {
+		if n == nil || n.Unit == nil || n.Unit.Filepath == "" {
+			continue
+		}
+		u := n.Unit
+		path := filepath.ToSlash(filepath.Clean(u.Filepath))
+		s.byFile[path] = append(s.byFile[path], u)
+		s.byName[u.Name] = append(s.byName[u.Name], u)
+	}
+	for path, units := range s.byFile {
+		sort.Slice(units, func(i, j int) bool {
+			si, sj := units[i].EndLine-units[i].StartLine, units[j].EndLine-units[j].StartLine
+			if si != sj {
+				return si < sj
+			}
+			return units[i].ID < units[j].ID
+		})
+		s.byFile[path] = units
+	}
+	for _, units := range s.byName {
+		sort.Slice(units, func(i, j int) bool { return units[i].ID < units[j].ID })
+	}
+	for _, e := range g.Edges {
+		s.out[e.From] = append(s.out[e.From], e)
+		s.in[e.To] = append(s.in[e.To], e)
+	}
+	if opts.Model != nil {
+		for _, sec := range opts.Model.Sections {
+			seen := make(map[string]bool)
+			for _, src := range sec.Sources {
+				if src.SymbolID != "" && !seen[src.SymbolID] {
+					seen[src.SymbolID] = true
+					s.sections[src.SymbolID] = append(s.sections[src.SymbolID], sec)
+				}
+			}
+		}
+	}
+	for _, p := range g.PackageSummaries() {
+		s.packages[p.Dir] = p
+	}
+	return s
+}
+
+// Serve handles requests from r and writes responses to w until the client
+// sends exit or ctx is done.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	return jsonrpc.NewConn(w).Serve(ctx, r, s.handle)
+}
+
+func (s *Server) handle(_ context.Context, _ *jsonrpc.Conn, msg *jsonrpc.Message) (any, error) {
+	switch msg.Method {
+	case "initialize":
+		var p initializeParams
+		if err := jsonrpc.UnmarshalParams(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		if s.root == "" {
+			s.root = workspaceRoot(p)
+		}
+		s.mu.Unlock()
+		return map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync":   1, // full
+				"hoverProvider":      true,
+				"definitionProvider": true,
+			},
+			"serverInfo": map[string]string{"name": "docod"},
+		}, nil
+	case "initialized", "shutdown", "workspace/didChangeConfiguration", "textDocument/didSave":
+		return nil, nil
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := jsonrpc.UnmarshalParams(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		s.setDocument(p.TextDocument.URI, p.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := jsonrpc.UnmarshalParams(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		if n := len(p.ContentChanges); n > 0 {
+			s.setDocument(p.TextDocument.URI, p.ContentChanges[n-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err := jsonrpc.UnmarshalParams(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		delete(s.docs, p.TextDocument.URI)
+		s.mu.Unlock()
+		return nil, nil
+	case "textDocument/hover":
+		var p textDocumentPositionParams
+		if err := jsonrpc.UnmarshalParams(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		if h := s.Hover(p.TextDocument.URI, p.Position); h != nil {
+			return h, nil
+		}
+		return nil, nil
+	case "textDocument/definition":
+		var p textDocumentPositionParams
+		if err := jsonrpc.UnmarshalParams(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		if loc := s.Definition(p.TextDocument.URI, p.Position); loc != nil {
+			return loc, nil
+		}
+		return nil, nil
+	}
+	if msg.ID == nil || strings.HasPrefix(msg.Method, "$/") {
+		return nil, nil
+	}
+	return nil, jsonrpc.Errorf(jsonrpc.CodeMethodNotFound, "method not supported: %s", msg.Method)
+}
+
+func (s *Server) setDocument(uri, text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs[uri] = text
+}
+
+// document returns the editor's copy of uri, falling back to the file on disk.
+func (s *Server) document(uri string) (string, bool) {
+	s.mu.Lock()
+	text, ok := s.docs[uri]
+	s.mu.Unlock()
+	if ok {
+		return text, true
+	}
+	data, err := os.ReadFile(uriToPath(uri))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// relPath maps a document URI to the slash path the graph knows it by.
+func (s *Server) relPath(uri string) string {
+	path := uriToPath(uri)
+	s.mu.Lock()
+	root := s.root
+	s.mu.Unlock()
+	if root != "" {
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			path = rel
+		}
+	}
+	path = filepath.ToSlash(filepath.Clean(path))
+	if _, ok := s.byFile[path]; ok {
+		return path
+	}
+	// The graph may have been scanned from another directory; fall back to
+	// the longest indexed path the document ends with.
+	best := ""
+	for known := range s.byFile {
+		if (path == known || strings.HasSuffix(path, "/"+known)) && len(known) > len(best) {
+			best = known
+		}
+	}
+	if best != "" {
+		return best
+	}
+	return path
+}
+
+func (s *Server) uriFor(path string) string {
+	s.mu.Lock()
+	root := s.root
+	s.mu.Unlock()
+	if !filepath.IsAbs(path) && root != "" {
+		path = filepath.Join(root, path)
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+func workspaceRoot(p initializeParams) string {
+	if p.RootURI != "" {
+		return uriToPath(p.RootURI)
+	}
+	if len(p.WorkspaceFolders) > 0 {
+		return uriToPath(p.WorkspaceFolders[0].URI)
+	}
+	return p.RootPath
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
@@ -0,0 +1,135 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"docod/internal/generator"
+	"docod/internal/graph"
+	"docod/internal/jsonrpc"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const storeSource = `package store
+
+func Open(path string) (*DB, error) {
+	return connect(path)
+}
+
+func connect(path string) (*DB, error) {
+	return &DB{}, nil
+}
+`
+
+func testServer() *Server {
+	g := graph.NewGraph()
+	g.AddSymbol(&graph.Symbol{ID: "go/store:file", Name: "db.go", UnitType: "file_module", Filepath: "store/db.go", StartLine: 1, EndLine: 9})
+	g.AddSymbol(&graph.Symbol{
+		ID: "go/store:function:Open", Name: "Open", UnitType: "function", Package: "store",
+		Filepath: "store/db.go", StartLine: 3, EndLine: 5,
+		Metadata: graph.SymbolMetadata{Signature: "func Open(path string) (*DB, error)"},
+	})
+	g.AddSymbol(&graph.Symbol{
+		ID: "go/store:function:connect", Name: "connect", UnitType: "function", Package: "store",
+		Filepath: "store/db.go", StartLine: 7, EndLine: 9, Description: "connect dials the database.",
+	})
+	g.AddSymbol(&graph.Symbol{ID: "go/cli:function:Run", Name: "Run", UnitType: "function", Filepath: "cli/run.go", StartLine: 1, EndLine: 4})
+	g.AddSymbol(&graph.Symbol{ID: "go/store:test:TestOpen", Name: "TestOpen", UnitType: graph.UnitTypeTest, Filepath: "store/db_test.go", StartLine: 1, EndLine: 3})
+	g.Edges = []graph.Edge{
+		{From: "go/store:function:Open", To: "go/store:function:connect", Kind: graph.RelationCalls, Confidence: 0.9},
+		{From: "go/cli:function:Run", To: "go/store:function:Open", Kind: graph.RelationCalls, Confidence: 0.9},
+		{From: "go/store:test:TestOpen", To: "go/store:function:Open", Kind: graph.RelationTests, Confidence: 1},
+	}
+	model := &generator.DocModel{Sections: []generator.ModelSect{{
+		ID:        "key-features",
+		Title:     "Key Features",
+		ContentMD: "# Key Features\n\nStorage is opened lazily.\n\nOpen validates the path and connects once.",
+		Sources:   []generator.SourceRef{{SymbolID: "go/store:function:Open"}},
+	}}}
+	s := NewServer(g, Options{Root: "/work", Model: model})
+	s.setDocument("file:///work/store/db.go", storeSource)
+	return s
+}
+
+func TestHover_DeclarationShowsDocsAndGraphContext(t *testing.T) {
+	s := testServer()
+
+	h := s.Hover("file:///work/store/db.go", Position{Line: 2, Character: 6})
+	require.NotNil(t, h)
+	assert.Equal(t, "markdown", h.Contents.Kind)
+	assert.Contains(t, h.Contents.Value, "func Open(path string) (*DB, error)")
+	assert.Contains(t, h.Contents.Value, "**Documented in Key Features**: Open validates the path and connects once.")
+	assert.Contains(t, h.Contents.Value, "**Calls** `connect`")
+	assert.Contains(t, h.Contents.Value, "**Called by** `Run`")
+	assert.Contains(t, h.Contents.Value, "**Tested by** `TestOpen`")
+	assert.Equal(t, Range{Start: Position{Line: 2, Character: 5}, End: Position{Line: 2, Character: 9}}, *h.Range)
+}
+
+func TestHover_ReferenceResolvesThroughEdges(t *testing.T) {
+	s := testServer()
+
+	h := s.Hover("file:///work/store/db.go", Position{Line: 3, Character: 10})
+	require.NotNil(t, h)
+	assert.Contains(t, h.Contents.Value, "connect dials the database.")
+
+	assert.Nil(t, s.Hover("file:///work/store/db.go", Position{Line: 3, Character: 1}), "keywords are not symbols")
+
+	loc := s.Definition("file:///work/store/db.go", Position{Line: 3, Character: 10})
+	require.NotNil(t, loc)
+	assert.Equal(t, "file:///work/store/db.go", loc.URI)
+	assert.Equal(t, 6, loc.Range.Start.Line)
+}
+
+func TestServe_HoverOverStdio(t *testing.T) {
+	s := testServer()
+	var in bytes.Buffer
+	for _, req := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"rootUri":"file:///elsewhere"}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///work/store/db.go"},"position":{"line":2,"character":6}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///work/store/db.go"},"position":{"line":0,"character":0}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"workspace/symbol","params":{}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	} {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(req), req)
+	}
+	var out bytes.Buffer
+	require.NoError(t, s.Serve(context.Background(), &in, &out))
+
+	responses := readAll(t, &out)
+	require.Len(t, responses, 5, "notifications get no response")
+	assert.Contains(t, string(responses["1"].Result), `"hoverProvider":true`)
+
+	var hover Hover
+	require.NoError(t, json.Unmarshal(responses["2"].Result, &hover))
+	assert.Contains(t, hover.Contents.Value, "func Open")
+	assert.Equal(t, "null", string(responses["3"].Result), "unknown words hover to null")
+	require.NotNil(t, responses["4"].Error)
+	assert.Equal(t, jsonrpc.CodeMethodNotFound, responses["4"].Error.Code)
+	assert.Equal(t, "null", string(responses["5"].Result))
+}
+
+// readAll returns the responses in out by request ID; requests are answered
+// concurrently, so their order is not fixed.
+func readAll(t *testing.T, r io.Reader) map[string]*jsonrpc.Message {
+	t.Helper()
+	br := bufio.NewReader(r)
+	msgs := make(map[string]*jsonrpc.Message)
+	for {
+		msg, err := jsonrpc.ReadMessage(br)
+		if err == io.EOF {
+			return msgs
+		}
+		require.NoError(t, err)
+		require.NotNil(t, msg.ID)
+		msgs[string(*msg.ID)] = msg
+	}
+}
@@ -0,0 +1,424 @@
+// Package mcp serves the knowledge graph to AI agents as a Model Context
+// Protocol server, run as `docod mcp` over stdin/stdout. Agents call its
+// tools to search the code, read symbols with their graph neighbourhood and
+// find the code behind generated documentation sections.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"docod/internal/editor"
+	"docod/internal/generator"
+	"docod/internal/graph"
+	"docod/internal/jsonrpc"
+	"docod/internal/server"
+)
+
+// ProtocolVersion is the newest MCP revision the server speaks. Clients
+// asking for an older supported revision get that one.
+const ProtocolVersion = "2025-06-18"
+
+// ServerVersion changes only when a tool is removed or changes meaning;
+// additions keep the version.
+const ServerVersion = "1"
+
+var supportedVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// Tool names.
+const (
+	ToolSearchCode        = "search_code"
+	ToolGetSymbol         = "get_symbol"
+	ToolListSections      = "list_sections"
+	ToolGetSectionSources = "get_section_sources"
+)
+
+const (
+	// maxSymbolContent caps the source returned by get_symbol.
+	maxSymbolContent = 20000
+	maxNeighbors     = 20
+)
+
+// Tool describes a tool in tools/list.
+type Tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// Content is a block of a tool result.
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// CallResult is the result of tools/call. Tool failures such as an unknown
+// symbol are results with IsError set, so the agent can read and recover.
+type CallResult struct {
+	Content           []Content `json:"content"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	IsError           bool      `json:"isError,omitempty"`
+}
+
+// SymbolResult is the result of get_symbol.
+type SymbolResult struct {
+	Symbol       server.NodeSummary   `json:"symbol"`
+	Doc          string               `json:"doc,omitempty"`
+	Content      string               `json:"content,omitempty"`
+	Truncated    bool                 `json:"truncated,omitempty"`
+	Dependencies []server.NodeSummary `json:"dependencies"`
+	Dependents   []server.NodeSummary `json:"dependents"`
+	Tests        []server.NodeSummary `json:"tests"`
+	// Others lists further symbols with the requested name.
+	Others []server.NodeSummary `json:"others,omitempty"`
+}
+
+// SectionSources is the result of get_section_sources.
+type SectionSources struct {
+	ID      string                `json:"id"`
+	Title   string                `json:"title"`
+	Summary string                `json:"summary,omitempty"`
+	Sources []generator.SourceRef `json:"sources"`
+}
+
+// Options configures a Server.
+type Options struct {
+	// ModelPath locates doc_model.json; it is read per call, so a sync is
+	// visible immediately.
+	ModelPath string
+}
+
+// Server answers MCP requests from the knowledge graph and the optional
+// search backend.
+type Server struct {
+	g      *graph.Graph
+	search *editor.Server
+	opts   Options
+	byFile map[string][]*graph.Symbol
+}
+
+// NewServer serves g with backend for semantic search; a nil backend leaves
+// search_code with symbol name matches only.
+func NewServer(g *graph.Graph, backend *server.Backend, opts Options) *Server {
+	if g == nil {
+		g = graph.NewGraph()
+	}
+	s := &Server{g: g, search: editor.NewServer(g, backend), opts: opts, byFile: make(map[string][]*graph.Symbol)}
+	for _, n := range g.Nodes {
+		if n != nil && n.Unit != nil && n.Unit.Filepath != "" {
+			file := path.Clean(n.Unit.Filepath)
+			s.byFile[file] = append(s.byFile[file], n.Unit)
+		}
+	}
+	for _, units := range s.byFile {
+		// Innermost symbols first, so a line resolves to a method before its
+		// type.
+		sort.Slice(units, func(i, j int) bool {
+			si, sj := units[i].EndLine-units[i].StartLine, units[j].EndLine-units[j].StartLine
+			if si != sj {
+				return si < sj
+			}
+			return units[i].ID < units[j].ID
+		})
+	}
+	return s
+}
+
+// Serve handles newline-delimited requests from r and writes responses to w
+// until the client closes the stream or ctx is done.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	return jsonrpc.NewLineConn(w).Serve(ctx, r, s.handle)
+}
+
+func (s *Server) handle(ctx context.Context, _ *jsonrpc.Conn, msg *jsonrpc.Message) (any, error) {
+	switch msg.Method {
+	case "initialize":
+		var p struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		if err := jsonrpc.UnmarshalParams(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		return initializeResult(p.ProtocolVersion), nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]any{"tools": Tools()}, nil
+	case "tools/call":
+		var p struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := jsonrpc.UnmarshalParams(msg.Params, &p); err != nil {
+			return nil, err
+		}
+		return s.Call(ctx, p.Name, p.Arguments)
+	}
+	if msg.ID == nil {
+		// notifications/initialized and other notifications need no answer.
+		return nil, nil
+	}
+	return nil, jsonrpc.Errorf(jsonrpc.CodeMethodNotFound, "method not supported: %s", msg.Method)
+}
+
+func initializeResult(requested string) map[string]any {
+	version := ProtocolVersion
+	for _, v := range supportedVersions {
+		if v == requested {
+			version = v
+		}
+	}
+	return map[string]any{
+		"protocolVersion": version,
+		"capabilities":    map[string]any{"tools": map[string]any{}},
+		"serverInfo":      map[string]any{"name": "docod", "version": ServerVersion},
+		"instructions": "docod indexes this repository as a knowledge graph. Use search_code to find relevant code, " +
+			"get_symbol to read a symbol with its callers, callees and tests, and list_sections or get_section_sources " +
+			"to find the code behind the generated documentation.",
+	}
+}
+
+// Tools lists the tools the server offers.
+func Tools() []Tool {
+	str := func(desc string) map[string]any { return map[string]any{"type": "string", "description": desc} }
+	object := func(props map[string]any, required ...string) map[string]any {
+		schema := map[string]any{"type": "object", "properties": props}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return []Tool{
+		{
+			Name:        ToolSearchCode,
+			Description: "Search the indexed codebase. Returns symbols whose name matches the query and, when embeddings are configured, the semantically closest code chunks with file:line locations.",
+			InputSchema: object(map[string]any{
+				"query": str("Natural-language question or identifier."),
+				"top_k": map[string]any{"type": "integer", "description": "Maximum results (default 10, at most 50).", "minimum": 1},
+			}, "query"),
+		},
+		{
+			Name:        ToolGetSymbol,
+			Description: "Read a symbol: its signature, doc comment and source with the symbols it depends on, its dependents and its tests. Give an id, a name, or a file and 1-based line.",
+			InputSchema: object(map[string]any{
+				"id":   str("Symbol ID as returned by search_code."),
+				"name": str("Symbol name, optionally qualified as package.Name."),
+				"file": str("File path as stored in the graph."),
+				"line": map[string]any{"type": "integer", "description": "1-based line inside file.", "minimum": 1},
+			}),
+		},
+		{
+			Name:        ToolListSections,
+			Description: "List the sections of the generated documentation with their IDs and summaries.",
+			InputSchema: object(map[string]any{}),
+		},
+		{
+			Name:        ToolGetSectionSources,
+			Description: "List the code locations a generated documentation section was written from.",
+			InputSchema: object(map[string]any{
+				"section_id": str("Section ID as returned by list_sections."),
+			}, "section_id"),
+		},
+	}
+}
+
+// Call runs the tool name with JSON arguments. Unknown tools and malformed
+// arguments are protocol errors; everything else is a tool result.
+func (s *Server) Call(ctx context.Context, name string, args json.RawMessage) (*CallResult, error) {
+	var (
+		out any
+		err error
+	)
+	switch name {
+	case ToolSearchCode:
+		var p struct {
+			Query string `json:"query"`
+			TopK  int    `json:"top_k"`
+		}
+		if err := jsonrpc.UnmarshalParams(args, &p); err != nil {
+			return nil, err
+		}
+		out, err = s.search.Search(ctx, editor.SearchParams{Query: p.Query, TopK: p.TopK}, func(string, any) {})
+	case ToolGetSymbol:
+		var p struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			File string `json:"file"`
+			Line int    `json:"line"`
+		}
+		if err := jsonrpc.UnmarshalParams(args, &p); err != nil {
+			return nil, err
+		}
+		out, err = s.Symbol(p.ID, p.Name, p.File, p.Line)
+	case ToolListSections:
+		out, err = s.Sections()
+	case ToolGetSectionSources:
+		var p struct {
+			SectionID string `json:"section_id"`
+		}
+		if err := jsonrpc.UnmarshalParams(args, &p); err != nil {
+			return nil, err
+		}
+		out, err = s.SectionSources(p.SectionID)
+	default:
+		return nil, jsonrpc.Errorf(jsonrpc.CodeInvalidParams, "unknown tool: %s", name)
+	}
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		return &CallResult{Content: []Content{{Type: "text", Text: err.Error()}}, IsError: true}, nil
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &CallResult{Content: []Content{{Type: "text", Text: string(data)}}, StructuredContent: out}, nil
+}
+
+// Symbol resolves a symbol by ID, by name or by file and line, and returns
+// it with its graph neighbourhood.
+func (s *Server) Symbol(id, name, file string, line int) (*SymbolResult, error) {
+	var (
+		sym    *graph.Symbol
+		others []server.NodeSummary
+	)
+	switch {
+	case id != "":
+		if n := s.g.Nodes[id]; n != nil && n.Unit != nil {
+			sym = n.Unit
+		}
+	case name != "":
+		matches := s.byName(name)
+		if len(matches) > 0 {
+			sym = matches[0]
+			for _, m := range matches[1:min(len(matches), maxNeighbors+1)] {
+				others = append(others, server.SummarizeNode(m))
+			}
+		}
+	case file != "":
+		for _, u := range s.byFile[path.Clean(file)] {
+			if u.StartLine <= line && line <= u.EndLine {
+				sym = u
+				break
+			}
+		}
+	default:
+		return nil, fmt.Errorf("give an id, a name, or a file and line")
+	}
+	if sym == nil {
+		return nil, fmt.Errorf("symbol not found; use %s to find symbol IDs", ToolSearchCode)
+	}
+	content := s.g.Content(sym)
+	res := &SymbolResult{
+		Symbol:       server.SummarizeNode(sym),
+		Doc:          strings.TrimSpace(sym.Description),
+		Dependencies: summarize(s.g.GetDependencies(sym.ID)),
+		Dependents:   summarize(s.g.GetDependents(sym.ID)),
+		Tests:        summarize(s.g.TestsOf(sym.ID)),
+		Others:       others,
+	}
+	if len(content) > maxSymbolContent {
+		content, res.Truncated = content[:maxSymbolContent], true
+	}
+	res.Content = content
+	return res, nil
+}
+
+// byName returns the symbols named name, or pkg.Name when qualified,
+// exported and non-test symbols first.
+func (s *Server) byName(name string) []*graph.Symbol {
+	pkg, base := "", name
+	if i := strings.LastIndex(name, "."); i > 0 {
+		pkg, base = name[:i], name[i+1:]
+	}
+	var out []*graph.Symbol
+	for _, n := range s.g.Nodes {
+		if n == nil || n.Unit == nil || n.Unit.Name != base || (pkg != "" && n.Unit.Package != pkg) {
+			continue
+		}
+		out = append(out, n.Unit)
+	}
+	rank := func(u *graph.Symbol) int {
+		switch u.UnitType {
+		case graph.UnitTypeTest, graph.UnitTypeExternal, graph.UnitTypePackage, graph.UnitTypePackageSummary:
+			return 1
+		}
+		return 0
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if ri, rj := rank(out[i]), rank(out[j]); ri != rj {
+			return ri < rj
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Sections lists the sections of the generated documentation.
+func (s *Server) Sections() ([]server.SectionSummary, error) {
+	model, err := s.loadModel()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]server.SectionSummary, 0, len(model.Sections))
+	for _, sec := range model.Sections {
+		out = append(out, server.SectionSummary{ID: sec.ID, Title: sec.Title, Summary: sec.Summary})
+	}
+	return out, nil
+}
+
+// SectionSources returns the sources a generated section cites.
+func (s *Server) SectionSources(id string) (*SectionSources, error) {
+	if strings.TrimSpace(id) == "" {
+		return nil, fmt.Errorf("section_id is required")
+	}
+	model, err := s.loadModel()
+	if err != nil {
+		return nil, err
+	}
+	sec := model.SectionByID(id)
+	if sec == nil {
+		return nil, fmt.Errorf("section not found: %s; use %s to list sections", id, ToolListSections)
+	}
+	sources := sec.Sources
+	if sources == nil {
+		sources = []generator.SourceRef{}
+	}
+	return &SectionSources{ID: sec.ID, Title: sec.Title, Summary: sec.Summary, Sources: sources}, nil
+}
+
+func (s *Server) loadModel() (*generator.DocModel, error) {
+	if s.opts.ModelPath == "" {
+		return nil, fmt.Errorf("no documentation configured")
+	}
+	model, err := generator.LoadDocModel(s.opts.ModelPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no documentation generated yet; run `docod sync`")
+	}
+	return model, err
+}
+
+func summarize(nodes []*graph.Node) []server.NodeSummary {
+	out := []server.NodeSummary{}
+	seen := make(map[string]bool, len(nodes))
+	for _, n := range nodes {
+		if n == nil || n.Unit == nil || seen[n.Unit.ID] {
+			continue
+		}
+		seen[n.Unit.ID] = true
+		out = append(out, server.SummarizeNode(n.Unit))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	if len(out) > maxNeighbors {
+		out = out[:maxNeighbors]
+	}
+	return out
+}
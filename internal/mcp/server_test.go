@@ -0,0 +1,158 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"docod/internal/graph"
+	"docod/internal/jsonrpc"
+	"docod/internal/knowledge"
+	"docod/internal/server"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubSearcher []knowledge.SearchChunk
+
+func (s stubSearcher) SearchByText(_ context.Context, _ string, topK int, _ string) ([]knowledge.SearchChunk, error) {
+	return s[:min(topK, len(s))], nil
+}
+
+func testGraph() *graph.Graph {
+	g := graph.NewGraph()
+	g.AddSymbol(&graph.Symbol{ID: "store:Open", Name: "Open", UnitType: "function", Package: "store", Filepath: "store/db.go", StartLine: 3, EndLine: 5, Content: "func Open() {}", Description: "Open opens the store."})
+	g.AddSymbol(&graph.Symbol{ID: "cli:Run", Name: "Run", UnitType: "function", Package: "cli", Filepath: "cli/run.go", StartLine: 1, EndLine: 6})
+	g.AddSymbol(&graph.Symbol{ID: "store:TestOpen", Name: "TestOpen", UnitType: graph.UnitTypeTest, Package: "store", Filepath: "store/db_test.go", StartLine: 1, EndLine: 4})
+	g.Edges = []graph.Edge{
+		{From: "cli:Run", To: "store:Open", Kind: graph.RelationCalls},
+		{From: "store:TestOpen", To: "store:Open", Kind: graph.RelationTests},
+	}
+	return g
+}
+
+// serve runs the requests, one per line, and returns the responses by ID.
+func serve(t *testing.T, s *Server, requests ...string) map[string]*jsonrpc.Message {
+	t.Helper()
+	var out bytes.Buffer
+	require.NoError(t, s.Serve(context.Background(), strings.NewReader(strings.Join(requests, "\n")), &out))
+	responses := make(map[string]*jsonrpc.Message)
+	r := bufio.NewReader(&out)
+	for {
+		msg, err := jsonrpc.ReadLine(r)
+		if err != nil {
+			break
+		}
+		require.NotNil(t, msg.ID, "notifications get no response")
+		responses[string(*msg.ID)] = msg
+	}
+	return responses
+}
+
+func callResult(t *testing.T, msg *jsonrpc.Message) (CallResult, map[string]any) {
+	t.Helper()
+	require.Nil(t, msg.Error)
+	var res CallResult
+	require.NoError(t, json.Unmarshal(msg.Result, &res))
+	require.Len(t, res.Content, 1)
+	var body map[string]any
+	if !res.IsError {
+		require.NoError(t, json.Unmarshal([]byte(res.Content[0].Text), &body))
+	}
+	return res, body
+}
+
+func TestServer_HandshakeAndTools(t *testing.T) {
+	s := NewServer(testGraph(), nil, Options{})
+	resp := serve(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"resources/list"}`,
+	)
+	require.Len(t, resp, 4)
+
+	var init struct {
+		ProtocolVersion string         `json:"protocolVersion"`
+		Capabilities    map[string]any `json:"capabilities"`
+	}
+	require.NoError(t, json.Unmarshal(resp["1"].Result, &init))
+	assert.Equal(t, "2024-11-05", init.ProtocolVersion, "a supported older revision is kept")
+	assert.Contains(t, init.Capabilities, "tools")
+
+	var list struct {
+		Tools []Tool `json:"tools"`
+	}
+	require.NoError(t, json.Unmarshal(resp["2"].Result, &list))
+	var names []string
+	for _, tool := range list.Tools {
+		names = append(names, tool.Name)
+		assert.Equal(t, "object", tool.InputSchema["type"])
+	}
+	assert.Equal(t, []string{ToolSearchCode, ToolGetSymbol, ToolListSections, ToolGetSectionSources}, names)
+	assert.JSONEq(t, `{}`, string(resp["3"].Result))
+	assert.Equal(t, jsonrpc.CodeMethodNotFound, resp["4"].Error.Code)
+}
+
+func TestServer_SearchCodeAndGetSymbol(t *testing.T) {
+	s := NewServer(testGraph(), &server.Backend{Search: stubSearcher{{ID: "cli:Run", Name: "Run"}}}, Options{})
+	resp := serve(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"search_code","arguments":{"query":"open"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_symbol","arguments":{"name":"store.Open"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"get_symbol","arguments":{"file":"cli/run.go","line":2}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"get_symbol","arguments":{"id":"missing"}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"delete_repo","arguments":{}}}`,
+	)
+
+	_, search := callResult(t, resp["1"])
+	assert.Len(t, search["symbols"], 1)
+	assert.Len(t, search["results"], 1)
+
+	_, sym := callResult(t, resp["2"])
+	assert.Equal(t, "store:Open", sym["symbol"].(map[string]any)["id"])
+	assert.Equal(t, "func Open() {}", sym["content"])
+	assert.Len(t, sym["dependents"], 1)
+	assert.Len(t, sym["tests"], 1)
+
+	_, byLine := callResult(t, resp["3"])
+	assert.Equal(t, "cli:Run", byLine["symbol"].(map[string]any)["id"])
+
+	missing, _ := callResult(t, resp["4"])
+	assert.True(t, missing.IsError)
+	assert.Contains(t, missing.Content[0].Text, "search_code")
+
+	require.NotNil(t, resp["5"].Error)
+	assert.Equal(t, jsonrpc.CodeInvalidParams, resp["5"].Error.Code)
+}
+
+func TestServer_SectionSources(t *testing.T) {
+	modelPath := filepath.Join(t.TempDir(), "doc_model.json")
+	s := NewServer(testGraph(), nil, Options{ModelPath: modelPath})
+
+	_, err := s.Sections()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no documentation generated yet")
+
+	require.NoError(t, os.WriteFile(modelPath, []byte(`{"sections":[{"id":"storage","title":"Storage","summary":"How data is kept.",
+		"sources":[{"symbol_id":"store:Open","file_path":"store/db.go","start_line":3,"end_line":5}]}]}`), 0o644))
+	sections, err := s.Sections()
+	require.NoError(t, err)
+	require.Len(t, sections, 1)
+	assert.Equal(t, "storage", sections[0].ID)
+
+	res, err := s.SectionSources("storage")
+	require.NoError(t, err)
+	assert.Equal(t, "Storage", res.Title)
+	require.Len(t, res.Sources, 1)
+	assert.Equal(t, "store/db.go", res.Sources[0].FilePath)
+
+	_, err = s.SectionSources("nope")
+	assert.ErrorContains(t, err, "list_sections")
+}
@@ -0,0 +1,107 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"docod/internal/generator"
+	"docod/internal/knowledge"
+)
+
+// Section change statuses reported by a dry run.
+const (
+	SectionNew       = "new"
+	SectionChanged   = "changed"
+	SectionRewritten = "rewritten"
+	SectionUnchanged = "unchanged"
+	SectionRemoved   = "removed"
+)
+
+// SectionChange is how a dry-run generation would change one section.
+type SectionChange struct {
+	SectionID string
+	Title     string
+	Status    string
+	// LLM is the estimated LLM usage of the section.
+	LLM knowledge.Usage
+}
+
+// CompareDocModels lists the sections of after, in order, against the
+// current model before (nil when no docs exist yet), followed by the sections
+// that would be removed. A section whose generated content and sources match
+// is "rewritten" when it still has LLM calls planned, since their output is
+// not known until they are made.
+func CompareDocModels(before, after *generator.DocModel, meter *knowledge.UsageMeter) []SectionChange {
+	llm := llmUsageBySection(meter)
+	var out []SectionChange
+	seen := make(map[string]bool)
+	if after != nil {
+		for _, sec := range after.Sections {
+			seen[sec.ID] = true
+			c := SectionChange{SectionID: sec.ID, Title: sec.Title, LLM: llm[sec.ID]}
+			var old *generator.ModelSect
+			if before != nil {
+				old = before.SectionByID(sec.ID)
+			}
+			switch {
+			case old == nil:
+				c.Status = SectionNew
+			case old.Hash != sec.Hash:
+				c.Status = SectionChanged
+			case c.LLM.Requests > 0:
+				c.Status = SectionRewritten
+			default:
+				c.Status = SectionUnchanged
+			}
+			out = append(out, c)
+		}
+	}
+	if before != nil {
+		for _, sec := range before.Sections {
+			if !seen[sec.ID] {
+				out = append(out, SectionChange{SectionID: sec.ID, Title: sec.Title, Status: SectionRemoved})
+			}
+		}
+	}
+	return out
+}
+
+func llmUsageBySection(meter *knowledge.UsageMeter) map[string]knowledge.Usage {
+	out := make(map[string]knowledge.Usage)
+	for _, rec := range meter.Run("").Records {
+		if rec.Kind != knowledge.UsageLLM {
+			continue
+		}
+		u := out[rec.Section]
+		u.Add(rec.Usage)
+		out[rec.Section] = u
+	}
+	return out
+}
+
+// PrintDryRun writes the sections a dry run would change and the model
+// spend it estimated.
+func PrintDryRun(changes []SectionChange, meter *knowledge.UsageMeter) {
+	fmt.Println("🧪 Dry run (nothing written):")
+	unchanged := 0
+	for _, c := range changes {
+		if c.Status == SectionUnchanged {
+			unchanged++
+			continue
+		}
+		title := c.SectionID
+		if c.Title != "" {
+			title = fmt.Sprintf("%s (%s)", c.SectionID, c.Title)
+		}
+		line := fmt.Sprintf("  -> Section[%s]: %s", title, c.Status)
+		if c.LLM.Requests > 0 {
+			line += fmt.Sprintf(", %d LLM calls ~%d in / ~%d out tokens", c.LLM.Requests, c.LLM.InputTokens, c.LLM.OutputTokens)
+		}
+		fmt.Println(line)
+	}
+	if unchanged > 0 {
+		fmt.Printf("  -> %d sections unchanged.\n", unchanged)
+	}
+	preview := &PlanPreview{DryRun: true}
+	preview.LLM, preview.Embedding = usageByKind(meter)
+	preview.printSpend()
+}
@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"docod/internal/crawler"
+	"docod/internal/extractor"
+	"docod/internal/git"
+	"docod/internal/graph"
+	"docod/internal/index"
+	"docod/internal/resolver"
+)
+
+// BuildGraphAtCommit builds and resolves the knowledge graph of the tree at
+// ref. The tree is exported to a temporary directory, so the working copy is
+// left untouched; symbol file paths in the result are relative to the repo root.
+func BuildGraphAtCommit(ref string) (*graph.Graph, error) {
+	sha, err := git.ResolveCommit(ref)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "docod-snapshot-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create snapshot dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := git.ExportTree(sha, tmp); err != nil {
+		return nil, err
+	}
+	ext, err := extractor.NewExtractor("go")
+	if err != nil {
+		return nil, err
+	}
+	g, err := index.NewIndexer(crawler.NewCrawler(ext)).BuildGraph(tmp)
+	if err != nil {
+		return nil, fmt.Errorf("graph build at %s failed: %w", ref, err)
+	}
+	for _, r := range resolver.NewDefaultChain().Run(g) {
+		if r.Err != nil {
+			log.Printf("Warning: %s resolver failed at %s: %v", r.Resolver, ref, r.Err)
+			break
+		}
+	}
+	relativizeGraph(g, tmp)
+	return g, nil
+}
+
+func relativizeGraph(g *graph.Graph, root string) {
+	rel := func(p string) string {
+		if p == "" {
+			return p
+		}
+		if r, err := filepath.Rel(root, p); err == nil {
+			return filepath.ToSlash(r)
+		}
+		return p
+	}
+	for _, n := range g.Nodes {
+		if n == nil || n.Unit == nil {
+			continue
+		}
+		n.Unit.Filepath = rel(n.Unit.Filepath)
+		for i := range n.Unit.Relations {
+			n.Unit.Relations[i].Evidence.Filepath = rel(n.Unit.Relations[i].Evidence.Filepath)
+		}
+	}
+	for i := range g.Edges {
+		g.Edges[i].Evidence.Filepath = rel(g.Edges[i].Evidence.Filepath)
+	}
+	for i := range g.Unresolved {
+		g.Unresolved[i].Evidence.Filepath = rel(g.Unresolved[i].Evidence.Filepath)
+	}
+}
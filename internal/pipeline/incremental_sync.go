@@ -20,6 +20,7 @@ import (
 	"docod/internal/index"
 	"docod/internal/knowledge"
 	"docod/internal/planner"
+	"docod/internal/profiling"
 	"docod/internal/resolver"
 	"docod/internal/retrieval"
 	"docod/internal/storage"
@@ -29,24 +30,55 @@ type IncrementalSync struct {
 	DBPath      string
 	ProjectRoot string
 	DocPath     string
+	// Output locates the doc model and the impact reports next to DocPath.
+	Output config.OutputPaths
+	// PreciseCalls enables the go/packages + SSA call graph resolver.
+	PreciseCalls bool
+	// StrictEdges keeps only high-confidence edges in the graph.
+	StrictEdges bool
+	// PlanOnly stops after documentation planning and prints a preview
+	// without writing the graph, caches or docs.
+	PlanOnly bool
+	// DryRun extends the plan preview with the embeddings and LLM calls the
+	// sync would make and their estimated cost. It implies PlanOnly.
+	DryRun bool
+	// Since diffs the working tree against this revision instead of HEAD;
+	// SinceLastDocumented uses the commit recorded by the previous update.
+	Since string
+	// Range diffs a revision range (A..B or A...B) instead of the working
+	// tree.
+	Range string
 }
 
+// SinceLastDocumented is the Since value naming the commit the docs were
+// last updated at.
+const SinceLastDocumented = "last"
+
 type updatePlan struct {
 	Changes    []git.ChangedFile
 	FullResync bool
+	// Head is the revision the changes lead to; empty means HEAD.
+	Head string
+	// FromCommits is set when Changes come from git, so the docs are
+	// recorded as documenting Head. Paths from a file watcher are not.
+	FromCommits bool
 }
 
 type graphUpdateResult struct {
 	Graph        *graph.Graph
 	UpdatedFiles []string
 	DeletedFiles []string
+	// Changes classifies the symbol changes of an incremental update.
+	Changes []graph.ClassifiedChange
 }
 
 func NewIncrementalSync(dbPath string) *IncrementalSync {
+	output := config.ResolveOutputPaths()
 	return &IncrementalSync{
 		DBPath:      dbPath,
 		ProjectRoot: ".",
-		DocPath:     "docs/documentation.md",
+		DocPath:     output.Doc(),
+		Output:      output,
 	}
 }
 
@@ -55,6 +87,41 @@ func (s *IncrementalSync) Run(ctx context.Context, force bool) error {
 	if err != nil {
 		return err
 	}
+	return s.runPlan(ctx, plan)
+}
+
+// RunForPaths syncs an explicit set of changed paths (e.g. from a file
+// watcher) without consulting git or walking the project tree.
+func (s *IncrementalSync) RunForPaths(ctx context.Context, paths []string) error {
+	changes := make([]git.ChangedFile, 0, len(paths))
+	for _, p := range paths {
+		changes = append(changes, git.ChangedFile{Path: p, WholeFile: true})
+	}
+	return s.runPlan(ctx, changesPlan(changes, false))
+}
+
+// RunForChanges syncs the diff of a commit range ending at HEAD, detected by
+// the caller, keeping the changed lines for impact analysis. HEAD is then
+// recorded as documented.
+func (s *IncrementalSync) RunForChanges(ctx context.Context, changes []git.ChangedFile) error {
+	return s.runPlan(ctx, changesPlan(changes, true))
+}
+
+func changesPlan(changes []git.ChangedFile, fromCommits bool) *updatePlan {
+	plan := &updatePlan{FromCommits: fromCommits}
+	seen := make(map[string]bool)
+	for _, c := range changes {
+		c.Path = filepath.Clean(c.Path)
+		if seen[c.Path] {
+			continue
+		}
+		seen[c.Path] = true
+		plan.Changes = append(plan.Changes, c)
+	}
+	return plan
+}
+
+func (s *IncrementalSync) runPlan(ctx context.Context, plan *updatePlan) error {
 	if len(plan.Changes) == 0 && !plan.FullResync {
 		fmt.Println("✅ No changes detected.")
 		return nil
@@ -66,33 +133,104 @@ func (s *IncrementalSync) Run(ctx context.Context, force bool) error {
 	}
 	defer store.Close()
 
+	endStage := profiling.Stage("graph_update")
 	graphResult, err := s.graphUpdateStage(ctx, store, plan)
+	endStage()
 	if err != nil {
 		return err
 	}
 
-	if err := store.SaveGraph(ctx, graphResult.Graph); err != nil {
-		return fmt.Errorf("failed to save updated graph: %w", err)
+	if s.PlanOnly || s.DryRun {
+		return s.planPreviewStage(ctx, store, graphResult, plan)
 	}
 
-	if len(plan.Changes) > 0 {
-		s.impactAnalysisStage(graphResult.Graph, plan.Changes)
+	cfg, _ := config.LoadConfig("config.yaml")
+	meter := NewUsageMeter(cfg)
+	ctx = knowledge.WithUsageMeter(ctx, meter)
+	defer func() {
+		if err := RecordUsageRun(context.WithoutCancel(ctx), store, meter, "incremental_sync"); err != nil {
+			log.Printf("Warning: failed to record model usage: %v", err)
+		}
+	}()
+
+	endStage = profiling.Stage("save_graph")
+	err = store.SaveGraph(ctx, graphResult.Graph)
+	endStage()
+	if err != nil {
+		return fmt.Errorf("failed to save updated graph: %w", err)
+	}
+	if err := store.SetMeta(ctx, storage.MetaSymbolIDVersion, extractor.SymbolIDVersion); err != nil {
+		return fmt.Errorf("failed to record symbol ID version: %w", err)
 	}
 
 	var docPlan *planner.DocUpdatePlan
+	var impact *analysis.ImpactReport
 	if len(plan.Changes) > 0 {
-		docPlan = s.retrievalPlanningStage(graphResult.Graph, plan.Changes)
+		endStage = profiling.Stage("impact_analysis")
+		impact = s.impactAnalysisStage(graphResult.Graph, plan.Changes)
+		endStage()
+		endStage = profiling.Stage("retrieval_planning")
+		docPlan = s.retrievalPlanningStage(graphResult.Graph, plan.Changes, graphResult.Changes)
+		endStage()
+	}
+
+	feedCfg := s.feedConfig()
+	var before *generator.DocModel
+	if feedCfg != nil {
+		// A missing model means the docs are generated for the first time;
+		// every section is then reported as added.
+		before, _ = generator.LoadDocModel(generator.ModelPathFor(s.DocPath))
 	}
 
-	if err := s.documentationStage(ctx, store, graphResult, plan.FullResync, docPlan); err != nil {
+	endStage = profiling.Stage("documentation")
+	err = s.documentationStage(ctx, store, graphResult, plan.FullResync, docPlan)
+	endStage()
+	if err != nil {
 		return err
 	}
+	if len(plan.Changes) > 0 {
+		s.writeImpactReportStage(impact, plan.Changes, docPlan)
+	}
+
+	if len(graphResult.Changes) > 0 {
+		s.apiChangesStage(graphResult.Changes)
+		s.apiReferenceStage(graphResult.Graph)
+	}
+	if len(plan.Changes) > 0 {
+		s.changelogStage(ctx, store, plan, graphResult.Changes)
+	}
+	if feedCfg != nil {
+		s.feedStage(feedCfg, before)
+	}
+	if plan.FromCommits {
+		if err := RecordDocumentedCommit(ctx, store, plan.Head); err != nil {
+			return fmt.Errorf("failed to record documented commit: %w", err)
+		}
+	}
 
 	return nil
 }
 
 func (s *IncrementalSync) detectChangesStage(force bool) (*updatePlan, error) {
-	changes, err := git.GetChangedFiles("HEAD")
+	var changes []git.ChangedFile
+	var head string
+	var err error
+	switch {
+	case s.Range != "":
+		changes, err = git.GetChangedFilesForRange(s.Range)
+		head = git.RangeHead(s.Range)
+	case s.Since != "":
+		since := s.Since
+		if since == SinceLastDocumented {
+			if since, err = s.lastDocumentedCommit(); err != nil {
+				return nil, err
+			}
+			fmt.Printf("📌 Updating since last documented commit %s\n", shortSHA(since))
+		}
+		changes, err = git.GetChangedFiles(since)
+	default:
+		changes, err = git.GetChangedFiles("HEAD")
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get git changes: %w", err)
 	}
@@ -105,11 +243,50 @@ func (s *IncrementalSync) detectChangesStage(force bool) (*updatePlan, error) {
 	}
 
 	return &updatePlan{
-		Changes:    changes,
-		FullResync: fullResync,
+		Changes:     changes,
+		FullResync:  fullResync,
+		Head:        head,
+		FromCommits: true,
 	}, nil
 }
 
+func (s *IncrementalSync) lastDocumentedCommit() (string, error) {
+	store, err := s.initStoreStage()
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer store.Close()
+	sha, err := store.GetMeta(context.Background(), storage.MetaDocumentedCommit)
+	if err != nil {
+		return "", err
+	}
+	if sha == "" {
+		return "", fmt.Errorf("no documented commit recorded in %s yet; pass --since <rev> or --range", s.DBPath)
+	}
+	return sha, nil
+}
+
+// RecordDocumentedCommit stores the commit ref resolves to as the one the
+// documentation was last updated at, for update --since last. Outside a git
+// repository nothing is recorded.
+func RecordDocumentedCommit(ctx context.Context, store *storage.SQLiteStore, ref string) error {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	sha, err := git.ResolveCommit(ref)
+	if err != nil {
+		return nil
+	}
+	return store.SetMeta(ctx, storage.MetaDocumentedCommit, sha)
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
 func (s *IncrementalSync) initStoreStage() (*storage.SQLiteStore, error) {
 	_, _ = config.LoadConfig("config.yaml")
 	return storage.NewSQLiteStore(s.DBPath)
@@ -118,11 +295,11 @@ func (s *IncrementalSync) initStoreStage() (*storage.SQLiteStore, error) {
 func (s *IncrementalSync) graphUpdateStage(ctx context.Context, store *storage.SQLiteStore, plan *updatePlan) (*graphUpdateResult, error) {
 	if plan.FullResync {
 		start := time.Now()
-		g, err := s.buildFullGraph()
+		g, err := s.buildFullGraph(ctx, store)
 		if err != nil {
 			return nil, fmt.Errorf("full sync graph build failed: %w", err)
 		}
-		s.runResolverChainStage(g)
+		s.runResolverChainStage(store, g)
 		fmt.Printf("📊 Graph Update: full rebuild completed in %v. Nodes=%d\n", time.Since(start), len(g.Nodes))
 		fmt.Printf("  -> Linked edges: %d, unresolved relations: %d\n", len(g.Edges), len(g.Unresolved))
 		s.printUnresolvedReasonMetrics(g)
@@ -133,55 +310,132 @@ func (s *IncrementalSync) graphUpdateStage(ctx context.Context, store *storage.S
 		}, nil
 	}
 
+	if !s.PlanOnly && !s.DryRun {
+		if migrated, err := s.symbolIDMigrationStage(ctx, store, plan); err != nil || migrated != nil {
+			return migrated, err
+		}
+	}
+
 	fmt.Println("🔄 Loading existing knowledge graph...")
 	g, err := store.LoadGraph(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load graph: %w", err)
 	}
 
-	ext, err := extractor.NewExtractor("go")
+	cr, err := s.newCrawler()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create extractor: %w", err)
+		return nil, fmt.Errorf("failed to create crawler: %w", err)
 	}
 
-	nodesUpdated := 0
-	nodesRemoved := 0
+	paths := make([]string, 0, len(plan.Changes))
 	for _, change := range plan.Changes {
-		if !strings.HasSuffix(change.Path, ".go") {
-			continue
-		}
+		paths = append(paths, change.Path)
+	}
 
-		var toRemove []string
-		for id, node := range g.Nodes {
-			if node.Unit.Filepath == change.Path {
-				toRemove = append(toRemove, id)
-			}
-		}
-		for _, id := range toRemove {
-			delete(g.Nodes, id)
-			nodesRemoved++
+	nodesUpdated := 0
+	nodesRemoved := 0
+	before := graph.NewGraph()
+	scanned := make(map[string]bool)
+	err = cr.ScanFiles(ctx, paths, func(change crawler.PathChange) {
+		copyFileNodes(before, g, change.Path)
+		scanned[change.Path] = true
+		nodesRemoved += removeFileNodes(g, change.Path)
+		if change.Err != nil {
+			// The old symbols no longer match the file; they come back
+			// once it parses again.
+			log.Printf("⚠️ Failed to parse file %s, dropping its symbols: %v", change.Path, change.Err)
+			return
 		}
-
-		if _, err := os.Stat(change.Path); err == nil {
-			units, err := ext.ExtractFromFile(change.Path)
-			if err != nil {
-				log.Printf("⚠️ Failed to parse file %s: %v", change.Path, err)
-				continue
-			}
-			for _, u := range units {
-				g.AddUnit(u)
-				nodesUpdated++
-			}
+		for _, u := range change.Units {
+			g.AddUnit(u)
+			nodesUpdated++
 		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("incremental scan failed: %w", err)
 	}
 
 	fmt.Printf("📊 Graph Update: %d nodes removed, %d nodes added/updated.\n", nodesRemoved, nodesUpdated)
 	g.RebuildIndices()
-	s.runResolverChainStage(g)
+	s.runResolverChainStage(store, g)
 	fmt.Printf("  -> Linked edges: %d, unresolved relations: %d\n", len(g.Edges), len(g.Unresolved))
 	s.printUnresolvedReasonMetrics(g)
 	updatedFiles, deletedFiles := splitUpdatedDeleted(plan.Changes)
 
+	after := graph.NewGraph()
+	for path := range scanned {
+		copyFileNodes(after, g, path)
+	}
+	changes := graph.ClassifyChanges(before, after)
+	if len(changes) > 0 {
+		counts := graph.CountChangeClasses(changes)
+		fmt.Printf("  -> Symbol changes: %d breaking, %d additive, %d internal\n",
+			counts[graph.ChangeBreaking], counts[graph.ChangeAdditive], counts[graph.ChangeInternal])
+	}
+
+	return &graphUpdateResult{
+		Graph:        g,
+		UpdatedFiles: updatedFiles,
+		DeletedFiles: deletedFiles,
+		Changes:      changes,
+	}, nil
+}
+
+// copyFileNodes adds the symbols of one file in src to dst.
+func copyFileNodes(dst, src *graph.Graph, path string) {
+	for _, node := range src.Nodes {
+		if node != nil && node.Unit != nil && node.Unit.Filepath == path {
+			dst.AddSymbol(node.Unit)
+		}
+	}
+}
+
+// symbolIDMigrationStage rebuilds the graph when the stored graph was written
+// with an older symbol ID scheme, and rewrites indexed chunks and doc model
+// sources to the new IDs so existing documentation keeps its provenance.
+// It returns nil when no migration is needed.
+func (s *IncrementalSync) symbolIDMigrationStage(ctx context.Context, store *storage.SQLiteStore, plan *updatePlan) (*graphUpdateResult, error) {
+	version, err := store.GetMeta(ctx, storage.MetaSymbolIDVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symbol ID version: %w", err)
+	}
+	if version == extractor.SymbolIDVersion {
+		return nil, nil
+	}
+	old, err := store.LoadGraph(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load graph: %w", err)
+	}
+	if len(old.Nodes) == 0 {
+		return nil, nil
+	}
+
+	fmt.Printf("🪪 Migrating symbol IDs to scheme v%s...\n", extractor.SymbolIDVersion)
+	g, err := s.buildFullGraph(ctx, store)
+	if err != nil {
+		return nil, fmt.Errorf("symbol ID migration graph build failed: %w", err)
+	}
+	s.runResolverChainStage(store, g)
+
+	mapping := graph.MatchSymbolIDs(old, g)
+	chunks, err := store.RemapSymbolIDs(ctx, mapping)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remap indexed chunks: %w", err)
+	}
+	refs := 0
+	modelPath := generator.ModelPathFor(s.DocPath)
+	if model, err := generator.LoadDocModel(modelPath); err == nil {
+		if refs = generator.RemapSourceSymbolIDs(model, mapping); refs > 0 {
+			if err := generator.SaveDocModel(modelPath, model); err != nil {
+				return nil, fmt.Errorf("failed to save remapped doc model: %w", err)
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		log.Printf("Warning: failed to load doc model for ID migration: %v", err)
+	}
+	fmt.Printf("  -> Mapped %d/%d symbols, rewrote %d chunks and %d doc sources\n", len(mapping), len(old.Nodes), chunks, refs)
+
+	updatedFiles, deletedFiles := splitUpdatedDeleted(plan.Changes)
 	return &graphUpdateResult{
 		Graph:        g,
 		UpdatedFiles: updatedFiles,
@@ -189,19 +443,31 @@ func (s *IncrementalSync) graphUpdateStage(ctx context.Context, store *storage.S
 	}, nil
 }
 
-func (s *IncrementalSync) runResolverChainStage(g *graph.Graph) {
+func (s *IncrementalSync) runResolverChainStage(store *storage.SQLiteStore, g *graph.Graph) {
 	if g == nil {
 		return
 	}
+	defer profiling.Stage("resolve")()
 
-	chain := resolver.NewDefaultChain()
+	cfg, _ := config.LoadConfig("config.yaml")
+	var cache resolver.FactsCache = store
+	if s.PlanOnly || s.DryRun {
+		cache = readOnlyFactsCache{store}
+	}
+	base := resolver.ChainOptions{Dir: s.ProjectRoot, Precise: s.PreciseCalls, StrictEdges: s.StrictEdges, Cache: cache}
+	chain, err := NewResolverChain(cfg, base)
+	if err != nil {
+		log.Printf("Warning: invalid resolver chain config, using defaults: %v", err)
+		chain, _ = NewResolverChain(nil, base)
+	}
+	fmt.Printf("  -> Resolver chain: %s\n", strings.Join(chain.Names(), " -> "))
 	results := chain.Run(g)
 	for _, r := range results {
 		if r.Err != nil {
 			log.Printf("Warning: %s resolver failed: %v", r.Resolver, r.Err)
 			break
 		}
-		fmt.Printf("  -> Resolver[%s]: attempted=%d resolved=%d skipped=%d unresolved=%d->%d edges=%d\n",
+		fmt.Printf("  -> Resolver[%s]: attempted=%d resolved=%d skipped=%d unresolved=%d->%d edges=%d dropped=%d\n",
 			r.Resolver,
 			r.Stats.Attempted,
 			r.Stats.Resolved,
@@ -209,8 +475,41 @@ func (s *IncrementalSync) runResolverChainStage(g *graph.Graph) {
 			r.UnresolvedBefore,
 			r.UnresolvedAfter,
 			r.EdgeCount,
+			r.Dropped,
 		)
 	}
+	if fs := chain.FactsStats(); fs.Reused+fs.Checked > 0 {
+		fmt.Printf("  -> Type-checked packages: %d (reused %d unchanged, %d failed)\n", fs.Checked, fs.Reused, fs.Failed)
+	}
+	for _, line := range FormatEdgeConfidence(g) {
+		fmt.Println("     " + line)
+	}
+}
+
+// FormatEdgeConfidence renders the per-resolver edge confidence distribution,
+// one line per resolver.
+func FormatEdgeConfidence(g *graph.Graph) []string {
+	dist := g.EdgeConfidenceByResolver()
+	names := make([]string, 0, len(dist))
+	for name := range dist {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		st := dist[name]
+		buckets := make([]string, 0, len(st.Buckets))
+		for i, n := range st.Buckets {
+			upper := "1.0"
+			if i+1 < len(graph.ConfidenceBuckets) {
+				upper = fmt.Sprintf("%.1f", graph.ConfidenceBuckets[i+1])
+			}
+			buckets = append(buckets, fmt.Sprintf("[%.1f,%s)=%d", graph.ConfidenceBuckets[i], upper, n))
+		}
+		lines = append(lines, fmt.Sprintf("confidence[%s]: edges=%d min=%.2f mean=%.2f max=%.2f %s",
+			name, st.Edges, st.Min, st.Mean, st.Max, strings.Join(buckets, " ")))
+	}
+	return lines
 }
 
 func (s *IncrementalSync) printUnresolvedReasonMetrics(g *graph.Graph) {
@@ -223,31 +522,94 @@ func (s *IncrementalSync) printUnresolvedReasonMetrics(g *graph.Graph) {
 	}
 }
 
-func (s *IncrementalSync) impactAnalysisStage(g *graph.Graph, changes []git.ChangedFile) {
+func (s *IncrementalSync) impactAnalysisStage(g *graph.Graph, changes []git.ChangedFile) *analysis.ImpactReport {
 	fmt.Println("🔍 Analyzing impact...")
 	analyzer := analysis.NewAnalyzer(g)
 	report, err := analyzer.AnalyzeImpact(changes)
 	if err != nil {
 		log.Printf("Analysis warning: %v", err)
-		return
+		return nil
 	}
 
 	fmt.Printf("  -> %d symbols directly affected\n", len(report.DirectlyAffected))
 	fmt.Printf("  -> %d symbols indirectly affected (callers)\n", len(report.IndirectlyAffected))
+	fmt.Printf("  -> %d tests exercise the change\n", len(report.Tests))
+	if len(report.Untested) > 0 {
+		fmt.Printf("  -> %d changed functions have no tests (consider adding tests):\n", len(report.Untested))
+		for i, node := range report.Untested {
+			if i >= 10 {
+				fmt.Printf("     ... and %d more\n", len(report.Untested)-i)
+				break
+			}
+			fmt.Printf("     - %s.%s (%s)\n", node.Unit.Package, node.Unit.Name, node.Unit.Filepath)
+		}
+	}
+	return report
+}
+
+// writeImpactReportStage persists the impact report next to the documentation
+// as impact_report.json, with a Markdown summary for PR comments. It runs
+// after the documentation stage so sections carry their final evidence state,
+// and attaches CODEOWNERS owners to changed files and sections.
+func (s *IncrementalSync) writeImpactReportStage(report *analysis.ImpactReport, changes []git.ChangedFile, docPlan *planner.DocUpdatePlan) {
+	artifact := analysis.NewImpactArtifact(report, changes)
+	owners, err := git.LoadCodeOwners(s.ProjectRoot)
+	if err != nil {
+		log.Printf("Warning: failed to read CODEOWNERS: %v", err)
+	}
+	for i := range artifact.Changes {
+		artifact.Changes[i].Owners = owners.Owners(artifact.Changes[i].Path)
+	}
+	model, _ := s.loadDocModelForPlanning()
+	if docPlan != nil {
+		for _, sec := range docPlan.AffectedSections {
+			entry := analysis.ArtifactSection{
+				ID:             sec.SectionID,
+				Score:          sec.Score,
+				Confidence:     sec.Confidence,
+				Reasons:        sec.Reasons,
+				TriggerSymbols: sec.TriggerSymbols,
+			}
+			files := append([]string(nil), sec.TriggerFiles...)
+			if model != nil {
+				if ms := model.SectionByID(sec.SectionID); ms != nil {
+					entry.LowEvidence = ms.Evidence != nil && ms.Evidence.LowEvidence
+					for _, src := range ms.Sources {
+						files = append(files, src.FilePath)
+					}
+				}
+			}
+			entry.Owners = owners.OwnersOf(files)
+			artifact.Sections = append(artifact.Sections, entry)
+		}
+	}
+	path := s.Output.ImpactReport()
+	if err := analysis.WriteImpactArtifact(path, artifact); err != nil {
+		log.Printf("Warning: failed to write impact report: %v", err)
+		return
+	}
+	mdPath := s.Output.ImpactSummary()
+	if err := os.WriteFile(mdPath, []byte(analysis.RenderImpactMarkdown(artifact)), 0644); err != nil {
+		log.Printf("Warning: failed to write impact summary: %v", err)
+	}
+	fmt.Printf("  -> Impact report written to %s (PR summary: %s)\n", path, mdPath)
 }
 
-func (s *IncrementalSync) retrievalPlanningStage(g *graph.Graph, changes []git.ChangedFile) *planner.DocUpdatePlan {
+func (s *IncrementalSync) retrievalPlanningStage(g *graph.Graph, changes []git.ChangedFile, classified []graph.ClassifiedChange) *planner.DocUpdatePlan {
 	fmt.Println("🧩 Extracting retrieval subgraph...")
-	sg := retrieval.ExtractFromChanges(g, changes, retrieval.DefaultConfig())
+	cfg, _ := config.LoadConfig("config.yaml")
+	retrievalCfg, weights := PlannerSettings(cfg)
+	sg := retrieval.ExtractFromChanges(g, changes, retrievalCfg)
 	fmt.Printf("  -> Retrieval seeds=%d nodes=%d edges=%d files=%d\n", len(sg.SeedIDs), len(sg.NodeIDs), len(sg.Edges), len(sg.UpdatedFiles))
 
 	model, err := s.loadDocModelForPlanning()
 	if err != nil {
 		fmt.Printf("  -> Doc planning skipped: %v\n", err)
-		return planner.BuildDocUpdatePlan(nil, sg)
+		return planner.BuildDocUpdatePlanWithWeights(nil, sg, weights)
 	}
 
-	plan := planner.BuildDocUpdatePlan(model, sg)
+	plan := planner.BuildDocUpdatePlanWithWeights(model, sg, weights)
+	planner.ApplyChangeClasses(plan, model, classified)
 	if len(plan.AffectedSections) == 0 {
 		fmt.Printf("  -> No section-source match. unmatched_symbols=%d\n", len(plan.UnmatchedSymbols))
 		return plan
@@ -264,8 +626,120 @@ func (s *IncrementalSync) retrievalPlanningStage(g *graph.Graph, changes []git.C
 	return plan
 }
 
+// apiChangesStage records exported symbol changes in the API Changes section
+// without calling the LLM.
+func (s *IncrementalSync) apiChangesStage(changes []graph.ClassifiedChange) {
+	commit, err := git.ResolveCommit("HEAD")
+	if err != nil {
+		commit = ""
+	}
+	updated, err := generator.ApplyAPIChanges(s.DocPath, changes, commit)
+	if err != nil {
+		log.Printf("Warning: failed to update API changes section: %v", err)
+		return
+	}
+	if updated {
+		fmt.Println("  -> API Changes section updated.")
+	}
+}
+
+// apiReferenceStage re-renders the API Reference section when it is enabled.
+func (s *IncrementalSync) apiReferenceStage(g *graph.Graph) {
+	cfg, err := config.LoadConfig("config.yaml")
+	if err != nil || cfg == nil || !cfg.Docs.APIReference {
+		return
+	}
+	updated, err := generator.ApplyAPIReference(s.DocPath, g, s.ProjectRoot)
+	if err != nil {
+		log.Printf("Warning: failed to update API reference section: %v", err)
+		return
+	}
+	if updated {
+		fmt.Println("  -> API Reference section updated.")
+	}
+}
+
+// maxChangelogCommits bounds the commits one changelog entry lists, e.g. on
+// the first update after a long time.
+const maxChangelogCommits = 50
+
+// changelogStage records the commits since the docs were last updated, with
+// the symbols they changed, in the What's New section when it is enabled.
+func (s *IncrementalSync) changelogStage(ctx context.Context, store *storage.SQLiteStore, plan *updatePlan, changes []graph.ClassifiedChange) {
+	cfg, err := config.LoadConfig("config.yaml")
+	if err != nil || cfg == nil || !cfg.Docs.Changelog {
+		return
+	}
+	base, err := store.GetMeta(ctx, storage.MetaDocumentedCommit)
+	if err != nil {
+		log.Printf("Warning: failed to read the documented commit: %v", err)
+		return
+	}
+	head := plan.Head
+	if head == "" {
+		head = "HEAD"
+	}
+	var commits []git.Commit
+	if base != "" {
+		// Outside a git repository the entry lists only the symbol changes.
+		commits, _ = git.GetCommits(base, head, maxChangelogCommits)
+	}
+	commit, err := git.ResolveCommit(head)
+	if err != nil {
+		commit = ""
+	}
+	updated, err := generator.ApplyChangelog(s.DocPath, generator.ChangelogInput{
+		Commits: commits,
+		Changes: changes,
+		Root:    s.ProjectRoot,
+	}, commit)
+	if err != nil {
+		log.Printf("Warning: failed to update changelog section: %v", err)
+		return
+	}
+	if updated {
+		fmt.Printf("  -> What's New section updated (%d commits).\n", len(commits))
+	}
+}
+
+// feedConfig returns the feed settings, or nil when the feed is disabled.
+func (s *IncrementalSync) feedConfig() *config.Config {
+	cfg, err := config.LoadConfig("config.yaml")
+	if err != nil || cfg == nil || !cfg.Feed.Enabled {
+		return nil
+	}
+	return cfg
+}
+
+// feedStage appends the sections this sync changed to the Atom feed.
+func (s *IncrementalSync) feedStage(cfg *config.Config, before *generator.DocModel) {
+	after, err := generator.LoadDocModel(generator.ModelPathFor(s.DocPath))
+	if err != nil {
+		log.Printf("Warning: failed to load doc model for the change feed: %v", err)
+		return
+	}
+	commit, err := git.ResolveCommit("HEAD")
+	if err != nil {
+		commit = ""
+	}
+	path := s.Output.WithDir(filepath.Dir(s.DocPath)).Feed()
+	added, err := generator.AppendFeed(path, after, generator.DiffSections(before, after), generator.FeedOptions{
+		Title:      cfg.Feed.Title,
+		Link:       cfg.Feed.Link,
+		MaxEntries: cfg.Feed.MaxEntries,
+		Commit:     commit,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to update change feed: %v", err)
+		return
+	}
+	if added > 0 {
+		fmt.Printf("  -> Change feed: %d entries added to %s\n", added, path)
+	}
+}
+
 func (s *IncrementalSync) loadDocModelForPlanning() (*generator.DocModel, error) {
-	modelPath := filepath.Join(filepath.Dir(s.DocPath), "doc_model.json")
+	modelPath := generator.ModelPathFor(s.DocPath)
 	model, err := generator.LoadDocModel(modelPath)
 	if err == nil {
 		return model, nil
@@ -283,7 +757,7 @@ func (s *IncrementalSync) loadDocModelForPlanning() (*generator.DocModel, error)
 
 func (s *IncrementalSync) documentationStage(ctx context.Context, store *storage.SQLiteStore, graphResult *graphUpdateResult, fullResync bool, docPlan *planner.DocUpdatePlan) error {
 	fmt.Println("✍️  Regenerating documentation...")
-	engine, summarizer, err := initEngine(ctx, graphResult.Graph, store)
+	engine, summarizer, err := initEngine(ctx, graphResult.Graph, store, false)
 	if err != nil {
 		fmt.Printf("⚠️  Skipping documentation generation: %v\n", err)
 		return nil
@@ -321,26 +795,29 @@ func (s *IncrementalSync) documentationStage(ctx context.Context, store *storage
 				StrictSectionScope:  false,
 				SectionConfidence:   sectionConfidenceByImpact(docPlan),
 				MinConfidenceForLLM: s.minConfidenceForLLM(),
+				BreakingChanges:     breakingChangesByImpact(docPlan),
 			}
 		}
 		if err := docUpdater.UpdateDocsWithPlan(ctx, s.DocPath, targetFiles, updatePlan); err != nil {
 			log.Printf("Warning: Failed to update docs incrementally, falling back to full gen: %v", err)
 		} else {
-			fmt.Println("✅ Documentation updated incrementally in 'docs/'.")
+			fmt.Printf("✅ Documentation updated incrementally in '%s'.\n", s.DocPath)
 			return nil
 		}
 	}
 
 	fmt.Println("📄 Documentation not found or incremental update failed, generating from scratch...")
 	gen := generator.NewMarkdownGenerator(engine, summarizer)
-	if err := gen.GenerateDocs(ctx, "docs"); err != nil {
+	if err := gen.GenerateDocs(ctx, s.Output.Dir); err != nil {
 		return fmt.Errorf("failed to generate docs: %w", err)
 	}
-	fmt.Println("✅ Documentation generated in 'docs/'.")
+	fmt.Printf("✅ Documentation generated in '%s/'.\n", s.Output.Dir)
 	return nil
 }
 
-func initEngine(ctx context.Context, g *graph.Graph, store *storage.SQLiteStore) (*knowledge.Engine, knowledge.Summarizer, error) {
+// initEngine builds the engine and summarizer from config.yaml. A dry-run
+// engine and summarizer estimate their model calls instead of making them.
+func initEngine(ctx context.Context, g *graph.Graph, store *storage.SQLiteStore, dryRun bool) (*knowledge.Engine, knowledge.Summarizer, error) {
 	cfg, err := config.LoadConfig("config.yaml")
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to load config: %w", err)
@@ -352,20 +829,32 @@ func initEngine(ctx context.Context, g *graph.Graph, store *storage.SQLiteStore)
 	switch embeddingProvider {
 	case "openai":
 		baseURL = cfg.AI.OpenAIBaseURL
+	case "azure-openai":
+		baseURL = cfg.AI.Azure.Endpoint
 	case "ollama":
 		embedKey = ""
 		baseURL = cfg.AI.OllamaBaseURL
+	case "local":
+		embedKey = ""
 	}
-	if embeddingProvider != "ollama" && strings.TrimSpace(embedKey) == "" {
+	if embeddingProvider != "ollama" && embeddingProvider != "local" && strings.TrimSpace(embedKey) == "" {
 		return nil, nil, fmt.Errorf("embedding API key not configured for provider=%s", cfg.AI.EmbeddingProvider)
 	}
 
+	// One limiter spaces embedding and LLM requests alike.
+	limiter := knowledge.NewRateLimiter(cfg.AI.RequestsPerMinute)
 	embedder, err := knowledge.NewEmbedder(ctx, knowledge.EmbedderOptions{
-		Provider:  cfg.AI.EmbeddingProvider,
-		APIKey:    embedKey,
-		Model:     cfg.AI.EmbeddingModel,
-		Dimension: cfg.AI.EmbeddingDim,
-		BaseURL:   baseURL,
+		Provider:          cfg.AI.EmbeddingProvider,
+		APIKey:            embedKey,
+		Model:             cfg.AI.EmbeddingModel,
+		Dimension:         cfg.AI.EmbeddingDim,
+		BaseURL:           baseURL,
+		Deployment:        cfg.AI.Azure.EmbeddingDeployment,
+		APIVersion:        cfg.AI.Azure.APIVersion,
+		RuntimeLibrary:    cfg.AI.Local.RuntimeLibrary,
+		MaxSequenceLength: cfg.AI.Local.MaxSequenceLength,
+		Tokenizer:         cfg.AI.EmbeddingTokenizer,
+		RateLimiter:       limiter,
 	})
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create embedder: %w", err)
@@ -374,31 +863,140 @@ func initEngine(ctx context.Context, g *graph.Graph, store *storage.SQLiteStore)
 	llmProvider := strings.ToLower(strings.TrimSpace(cfg.AI.LLMProvider))
 	llmKey := strings.TrimSpace(cfg.AI.LLMAPIKey)
 	llmBaseURL := strings.TrimSpace(cfg.AI.LLMBaseURL)
-	if (llmProvider == "gemini" || llmProvider == "openai") && llmKey == "" {
+	if llmProvider == "azure-openai" {
+		llmBaseURL = strings.TrimSpace(cfg.AI.Azure.Endpoint)
+	}
+	if (llmProvider == "gemini" || llmProvider == "openai" || llmProvider == "azure-openai" || llmProvider == "anthropic") && llmKey == "" && !dryRun {
 		return nil, nil, fmt.Errorf("LLM API key not configured for provider=%s", cfg.AI.LLMProvider)
 	}
 	summarizer, err := knowledge.NewSummarizer(ctx, knowledge.SummarizerOptions{
-		Provider: cfg.AI.LLMProvider,
-		APIKey:   llmKey,
-		Model:    cfg.AI.LLMModel,
-		BaseURL:  llmBaseURL,
+		Provider:        cfg.AI.LLMProvider,
+		APIKey:          llmKey,
+		Model:           cfg.AI.LLMModel,
+		BaseURL:         llmBaseURL,
+		ContextWindow:   cfg.AI.LLMContextWindow,
+		MaxOutputTokens: cfg.AI.LLMMaxOutput,
+		Deployment:      cfg.AI.Azure.LLMDeployment,
+		APIVersion:      cfg.AI.Azure.APIVersion,
+		Tokenizer:       cfg.AI.Tokenizer,
+		DryRun:          dryRun,
+		RateLimiter:     limiter,
 	})
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create llm summarizer: %w", err)
 	}
 
-	engine := knowledge.NewEngine(g, embedder, store)
+	index, err := storage.OpenVectorIndex(ctx, cfg.VectorStore.Driver, storage.VectorIndexConfig{
+		SQLite: store,
+		Search: storage.SearchOptions{
+			PageSize:         cfg.VectorSearch.PageSize,
+			MemoryLimitBytes: int64(cfg.VectorSearch.MemoryLimitMB) << 20,
+			Workers:          cfg.VectorSearch.Workers,
+			Mmap:             cfg.VectorSearch.Mmap,
+			VectorFile:       cfg.VectorSearch.VectorFile,
+			Index:            cfg.VectorSearch.Index,
+			HNSWM:            cfg.VectorSearch.HNSWM,
+			EfSearch:         cfg.VectorSearch.EfSearch,
+		},
+		URL:        cfg.VectorStore.URL,
+		Collection: cfg.VectorStore.Collection,
+		APIKey:     strings.TrimSpace(cfg.VectorStore.APIKey),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open vector store: %w", err)
+	}
+	engine := knowledge.NewEngine(g, embedder, index)
+	engine.SetDryRun(dryRun)
+	reranker, err := knowledge.NewReranker(knowledge.RerankerOptions{
+		Provider: cfg.AI.RerankProvider,
+		APIKey:   strings.TrimSpace(cfg.AI.RerankAPIKey),
+		Model:    cfg.AI.RerankModel,
+		BaseURL:  cfg.AI.RerankBaseURL,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create reranker: %w", err)
+	}
+	engine.SetReranker(reranker)
+	engine.SetDualEmbeddings(cfg.AI.DualEmbeddings)
+	engine.SetQueryVectorCache(store, knowledge.QueryCacheOptions{
+		Model:      fmt.Sprintf("%s/%s/%d", embeddingProvider, cfg.AI.EmbeddingModel, cfg.AI.EmbeddingDim),
+		MaxEntries: cfg.AI.QueryCacheMax,
+		TTL:        time.Duration(cfg.AI.QueryCacheTTLHrs) * time.Hour,
+	})
+	engine.SetEmbeddingCache(store, embeddingProvider, fmt.Sprintf("%s/%d", cfg.AI.EmbeddingModel, cfg.AI.EmbeddingDim))
+	embedTokenizer := cfg.AI.EmbeddingTokenizer
+	if strings.TrimSpace(embedTokenizer) == "" {
+		embedTokenizer = knowledge.TokenizerFor(embeddingProvider, cfg.AI.EmbeddingModel)
+	}
+	tokenizer, err := knowledge.NewTokenizer(embedTokenizer)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create embedding tokenizer: %w", err)
+	}
+	engine.SetTokenizer(tokenizer, cfg.AI.EmbeddingMaxTokens)
+	if cfg.Docs.HierarchicalSearch {
+		if us, ok := summarizer.(knowledge.UnitSummarizer); ok {
+			engine.SetCoarseLayer(us, store, knowledge.CoarseOptions{MaxSummariesPerRun: cfg.Docs.MaxSummariesPerRun})
+		}
+	}
+	engine.SetScopeFilter(knowledge.SearchFilter{
+		ExcludeTests: cfg.Scope.ExcludeTests,
+		ExcludePaths: cfg.Scope.ExcludePaths,
+	})
+	engine.SetChunkingOptions(knowledge.ChunkingOptions{
+		Strategy:      cfg.Chunking.Strategy,
+		WindowLines:   cfg.Chunking.WindowLines,
+		OverlapLines:  cfg.Chunking.OverlapLines,
+		WindowTokens:  cfg.Chunking.WindowTokens,
+		OverlapTokens: cfg.Chunking.OverlapTokens,
+		MaxSegments:   cfg.Chunking.MaxSegments,
+		Languages:     cfg.Chunking.Languages,
+	})
 	return engine, summarizer, nil
 }
 
-func (s *IncrementalSync) buildFullGraph() (*graph.Graph, error) {
+// buildFullGraph scans the whole project, reusing the symbols cached in
+// store for files whose contents did not change since the last scan.
+func (s *IncrementalSync) buildFullGraph(ctx context.Context, store *storage.SQLiteStore) (*graph.Graph, error) {
+	cr, err := s.newCrawler()
+	if err != nil {
+		return nil, err
+	}
+	idx := index.NewIndexer(cr)
+	g, stats, err := idx.BuildGraphCached(ctx, s.ProjectRoot, store, false)
+	if err != nil {
+		return nil, err
+	}
+	if stats.Reused > 0 {
+		fmt.Printf("  -> Parsed %d files, reused %d unchanged.\n", stats.Parsed, stats.Reused)
+	}
+	return g, nil
+}
+
+func (s *IncrementalSync) newCrawler() (*crawler.Crawler, error) {
 	ext, err := extractor.NewExtractor("go")
 	if err != nil {
 		return nil, err
 	}
 	cr := crawler.NewCrawler(ext)
-	idx := index.NewIndexer(cr)
-	return idx.BuildGraph(s.ProjectRoot)
+	cr.SetRoot(s.ProjectRoot)
+	if cfg, err := config.LoadConfig("config.yaml"); err == nil && cfg != nil {
+		cr.SetSymlinkPolicy(crawler.ParseSymlinkPolicy(cfg.Project.SymlinkPolicy))
+		cr.SetConcurrency(cfg.Project.ScanConcurrency)
+	}
+	return cr, nil
+}
+
+func removeFileNodes(g *graph.Graph, path string) int {
+	var toRemove []string
+	for id, node := range g.Nodes {
+		if node.Unit.Filepath == path {
+			toRemove = append(toRemove, id)
+		}
+	}
+	for _, id := range toRemove {
+		delete(g.Nodes, id)
+	}
+	return len(toRemove)
 }
 
 func splitUpdatedDeleted(changes []git.ChangedFile) ([]string, []string) {
@@ -473,6 +1071,16 @@ func sectionConfidenceByImpact(plan *planner.DocUpdatePlan) map[string]float64 {
 	return out
 }
 
+func breakingChangesByImpact(plan *planner.DocUpdatePlan) map[string][]string {
+	out := make(map[string][]string)
+	for _, impact := range plan.AffectedSections {
+		if impact.SectionID != "" && len(impact.Breaking) > 0 {
+			out[impact.SectionID] = impact.Breaking
+		}
+	}
+	return out
+}
+
 func (s *IncrementalSync) minConfidenceForLLM() float64 {
 	cfg, err := config.LoadConfig("config.yaml")
 	if err != nil || cfg == nil {
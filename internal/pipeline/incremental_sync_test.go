@@ -0,0 +1,50 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/git"
+	"docod/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunForPaths_DoesNotRecordDocumentedCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	t.Chdir(dir)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644))
+	dbPath := filepath.Join(dir, "docod.db")
+	documented := func() string {
+		store, err := storage.NewSQLiteStore(dbPath)
+		require.NoError(t, err)
+		defer store.Close()
+		sha, err := store.GetMeta(context.Background(), storage.MetaDocumentedCommit)
+		require.NoError(t, err)
+		return sha
+	}
+
+	// A watcher's paths are working-tree edits, not the state of HEAD.
+	require.NoError(t, NewIncrementalSync(dbPath).RunForPaths(context.Background(), []string{"main.go"}))
+	assert.Empty(t, documented())
+
+	head, err := git.ResolveCommit("HEAD")
+	require.NoError(t, err)
+	changes := []git.ChangedFile{{Path: "main.go", WholeFile: true}}
+	require.NoError(t, NewIncrementalSync(dbPath).RunForChanges(context.Background(), changes))
+	assert.Equal(t, head, documented())
+}
@@ -0,0 +1,222 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"docod/internal/config"
+	"docod/internal/generator"
+	"docod/internal/graph"
+	"docod/internal/knowledge"
+	"docod/internal/planner"
+	"docod/internal/resolver"
+	"docod/internal/storage"
+)
+
+const (
+	// previewPromptOverheadTokens approximates the fixed instructions sent
+	// with every section rewrite.
+	previewPromptOverheadTokens = 600
+	// previewMinOutputTokens is the smallest expected rewrite.
+	previewMinOutputTokens = 200
+	previewMaxSymbols      = 5
+)
+
+// SectionPreview is one section a sync would touch.
+type SectionPreview struct {
+	SectionID      string
+	Title          string
+	Score          float64
+	Confidence     float64
+	Reasons        []string
+	TriggerSymbols []string
+	Breaking       []string
+	// Rewrite is true when the section would be sent to the LLM.
+	Rewrite      bool
+	SkipReason   string
+	InputTokens  int
+	OutputTokens int
+}
+
+// PlanPreview is what `update --plan-only` and `update --dry-run` report.
+type PlanPreview struct {
+	Sections         []SectionPreview
+	UnmatchedSymbols int
+	InputTokens      int
+	OutputTokens     int
+
+	// DryRun marks a preview carrying the full spend estimate: LLM is the
+	// section rewrites plus the coarse summaries of the updated files, and
+	// Embedding the chunks that would be embedded again.
+	DryRun    bool
+	LLM       knowledge.Usage
+	Embedding knowledge.Usage
+}
+
+// BuildPlanPreview estimates which planned sections would be rewritten and
+// the token cost. Sections are taken in plan order; the first maxLLM that
+// reach minConfidence are rewritten, as in the doc updater. Token counts are
+// estimates from section and evidence sizes.
+func BuildPlanPreview(g *graph.Graph, model *generator.DocModel, plan *planner.DocUpdatePlan, maxLLM int, minConfidence float64) *PlanPreview {
+	preview := &PlanPreview{}
+	if plan == nil {
+		return preview
+	}
+	preview.UnmatchedSymbols = len(plan.UnmatchedSymbols)
+	rewrites := 0
+	for _, impact := range plan.AffectedSections {
+		sp := SectionPreview{
+			SectionID:      impact.SectionID,
+			Score:          impact.Score,
+			Confidence:     impact.Confidence,
+			Reasons:        impact.Reasons,
+			TriggerSymbols: impact.TriggerSymbols,
+			Breaking:       impact.Breaking,
+		}
+		content := ""
+		if model != nil {
+			if sec := model.SectionByID(impact.SectionID); sec != nil {
+				sp.Title = sec.Title
+				content = sec.ContentMD
+			}
+		}
+		switch {
+		case impact.SectionID == generator.APIChangesSectionID:
+			sp.SkipReason = "generated from graph diff"
+		case rewrites >= maxLLM:
+			sp.SkipReason = fmt.Sprintf("LLM section budget (%d) reached", maxLLM)
+		case minConfidence > 0 && impact.Confidence < minConfidence:
+			sp.SkipReason = fmt.Sprintf("confidence below %.2f", minConfidence)
+		default:
+			sp.Rewrite = true
+			rewrites++
+			sp.InputTokens = previewPromptOverheadTokens + knowledge.EstimateTokens(content)
+			for _, id := range impact.TriggerSymbols {
+				if n, ok := g.Nodes[id]; ok && n.Unit != nil {
+					sp.InputTokens += knowledge.EstimateTokens(n.Unit.Content)
+				}
+			}
+			sp.OutputTokens = knowledge.EstimateTokens(content)
+			if sp.OutputTokens < previewMinOutputTokens {
+				sp.OutputTokens = previewMinOutputTokens
+			}
+			preview.InputTokens += sp.InputTokens
+			preview.OutputTokens += sp.OutputTokens
+		}
+		preview.Sections = append(preview.Sections, sp)
+	}
+	return preview
+}
+
+// Print writes the preview in the sync log style.
+func (p *PlanPreview) Print() {
+	fmt.Println("📋 Plan preview (nothing written):")
+	if len(p.Sections) == 0 {
+		fmt.Println("  -> No documentation sections would be updated.")
+	}
+	for _, sp := range p.Sections {
+		title := sp.SectionID
+		if sp.Title != "" {
+			title = fmt.Sprintf("%s (%s)", sp.SectionID, sp.Title)
+		}
+		action := "keep (" + sp.SkipReason + ")"
+		if sp.Rewrite {
+			action = fmt.Sprintf("rewrite ~%d in / ~%d out tokens", sp.InputTokens, sp.OutputTokens)
+		}
+		fmt.Printf("  -> Section[%s] score=%.2f conf=%.2f: %s\n", title, sp.Score, sp.Confidence, action)
+		if len(sp.Reasons) > 0 {
+			fmt.Printf("     reasons: %s\n", strings.Join(sp.Reasons, ", "))
+		}
+		if len(sp.TriggerSymbols) > 0 {
+			symbols := sp.TriggerSymbols
+			more := ""
+			if len(symbols) > previewMaxSymbols {
+				more = fmt.Sprintf(" (+%d more)", len(symbols)-previewMaxSymbols)
+				symbols = symbols[:previewMaxSymbols]
+			}
+			fmt.Printf("     evidence: %s%s\n", strings.Join(symbols, ", "), more)
+		}
+		for _, b := range sp.Breaking {
+			fmt.Printf("     breaking: %s\n", b)
+		}
+	}
+	if p.UnmatchedSymbols > 0 {
+		fmt.Printf("  -> %d changed symbols match no section and may start a new one.\n", p.UnmatchedSymbols)
+	}
+	if p.DryRun {
+		p.printSpend()
+		return
+	}
+	fmt.Printf("  -> Estimated LLM cost: ~%d input + ~%d output tokens\n", p.InputTokens, p.OutputTokens)
+}
+
+// printSpend writes the dry-run spend estimate.
+func (p *PlanPreview) printSpend() {
+	fmt.Printf("  -> Estimated LLM calls: %d (~%d input + ~%d output tokens)\n", p.LLM.Requests, p.LLM.InputTokens, p.LLM.OutputTokens)
+	fmt.Printf("  -> Estimated embedding requests: %d (~%d tokens)\n", p.Embedding.Requests, p.Embedding.InputTokens)
+	fmt.Printf("  -> Estimated cost: ~$%.4f\n", p.LLM.Cost+p.Embedding.Cost)
+}
+
+func (s *IncrementalSync) planPreviewStage(ctx context.Context, store *storage.SQLiteStore, graphResult *graphUpdateResult, plan *updatePlan) error {
+	if len(plan.Changes) == 0 {
+		fmt.Println("📋 Plan preview: a full sync would regenerate all documentation.")
+		if s.DryRun {
+			preview := &PlanPreview{}
+			s.dryRunEstimateStage(ctx, store, graphResult, true, preview)
+			preview.printSpend()
+		}
+		return nil
+	}
+	s.impactAnalysisStage(graphResult.Graph, plan.Changes)
+	docPlan := s.retrievalPlanningStage(graphResult.Graph, plan.Changes, graphResult.Changes)
+	model, _ := s.loadDocModelForPlanning()
+	preview := BuildPlanPreview(graphResult.Graph, model, docPlan, generator.MaxLLMSections(), s.minConfidenceForLLM())
+	if s.DryRun {
+		s.dryRunEstimateStage(ctx, store, graphResult, false, preview)
+	}
+	preview.Print()
+	return nil
+}
+
+// dryRunEstimateStage indexes the updated files with a dry-run engine, which
+// counts the embeddings and coarse summaries the sync would request without
+// making them, and prices them together with the preview's rewrites.
+func (s *IncrementalSync) dryRunEstimateStage(ctx context.Context, store *storage.SQLiteStore, graphResult *graphUpdateResult, fullResync bool, preview *PlanPreview) {
+	cfg, _ := config.LoadConfig("config.yaml")
+	meter := NewUsageMeter(cfg)
+	ctx = knowledge.WithUsageMeter(ctx, meter)
+	engine, _, err := initEngine(ctx, graphResult.Graph, store, true)
+	if err != nil {
+		fmt.Printf("⚠️  Skipping embedding estimate: %v\n", err)
+	} else {
+		opts := knowledge.IndexingOptions{MaxChunksPerRun: s.maxEmbedChunksPerRun()}
+		if fullResync {
+			err = engine.IndexAllWithOptions(ctx, opts)
+		} else {
+			err = engine.IndexIncrementalWithOptions(ctx, graphResult.UpdatedFiles, graphResult.DeletedFiles, opts)
+		}
+		if err != nil {
+			log.Printf("Warning: embedding estimate failed: %v", err)
+		}
+	}
+	if cfg != nil {
+		for _, sp := range preview.Sections {
+			if sp.Rewrite {
+				meter.Record(knowledge.UsageLLM, cfg.AI.LLMProvider, cfg.AI.LLMModel, sp.SectionID, sp.InputTokens, sp.OutputTokens)
+			}
+		}
+	}
+	preview.DryRun = true
+	preview.LLM, preview.Embedding = usageByKind(meter)
+}
+
+// readOnlyFactsCache reuses cached package facts without storing new ones.
+type readOnlyFactsCache struct {
+	resolver.FactsCache
+}
+
+func (readOnlyFactsCache) PutPackageFacts(context.Context, string, []byte) error {
+	return nil
+}
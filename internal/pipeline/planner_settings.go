@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"docod/internal/config"
+	"docod/internal/planner"
+	"docod/internal/retrieval"
+)
+
+// PlannerSettings builds the retrieval config and section scoring weights
+// from the planner config. Zero values keep the defaults and negative values
+// turn a bonus or weight off.
+func PlannerSettings(cfg *config.Config) (retrieval.Config, planner.Weights) {
+	rc := retrieval.DefaultConfig()
+	w := planner.DefaultWeights()
+	if cfg == nil {
+		return rc, w
+	}
+	p := cfg.Planner
+	if p.MaxHops > 0 {
+		rc.MaxHops = p.MaxHops
+	}
+	if p.SeedScore > 0 {
+		rc.SeedScore = p.SeedScore
+	}
+	if p.HopDecay > 0 {
+		rc.HopDecay = p.HopDecay
+	}
+	w.SymbolMatch = plannerWeight(p.SymbolMatchBonus, w.SymbolMatch)
+	w.FileMatch = plannerWeight(p.FileMatchBonus, w.FileMatch)
+	w.Centrality = plannerWeight(p.CentralityWeight, w.Centrality)
+	w.Dependents = plannerWeight(p.DependentsWeight, w.Dependents)
+	return rc, w
+}
+
+func plannerWeight(v, fallback float64) float64 {
+	switch {
+	case v < 0:
+		return 0
+	case v == 0:
+		return fallback
+	}
+	return v
+}
@@ -0,0 +1,22 @@
+package pipeline
+
+import (
+	"docod/internal/config"
+	"docod/internal/resolver"
+)
+
+// NewResolverChain builds the resolver chain configured under `resolvers`.
+// base carries the run options (module root, --precise-calls,
+// --strict-edges, facts cache); cfg adds the resolver order and confidence
+// floors.
+func NewResolverChain(cfg *config.Config, base resolver.ChainOptions) (*resolver.ResolverChain, error) {
+	opts := base
+	if cfg != nil {
+		opts.Resolvers = cfg.Resolvers.Chain
+		opts.Disable = cfg.Resolvers.Disable
+		opts.MinConfidence = cfg.Resolvers.MinConfidence
+		opts.MinEdgeConfidence = cfg.Resolvers.MinEdgeConfidence
+		opts.StrictEdges = opts.StrictEdges || cfg.Resolvers.StrictEdges
+	}
+	return resolver.NewConfiguredChain(opts)
+}
@@ -0,0 +1,270 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"docod/internal/config"
+	"docod/internal/generator"
+	"docod/internal/graph"
+	"docod/internal/knowledge"
+	"docod/internal/shard"
+	"docod/internal/storage"
+)
+
+const (
+	// ShardsDir holds one documentation directory per shard inside the
+	// output directory.
+	ShardsDir = "shards"
+	// ShardManifestFile lists the shards of the last sharded run next to the
+	// global overview.
+	ShardManifestFile = "shards.json"
+)
+
+// ShardedGenerate documents a large repository shard by shard. Every shard
+// gets its own engine, summarizer and chunk budget over the shared store, and
+// up to Workers shards run at once; the global overview links the shard docs
+// and shows how the shards depend on each other.
+type ShardedGenerate struct {
+	Store       *storage.SQLiteStore
+	ProjectRoot string
+	Output      config.OutputPaths
+	Options     shard.Options
+	// Workers is the number of shards documented at once; 0 means 1.
+	Workers int
+	// MaxChunksPerShard bounds the chunks each shard embeds; 0 is unbounded.
+	MaxChunksPerShard int
+}
+
+// NewShardedGenerate reads the sharding settings of cfg.
+func NewShardedGenerate(store *storage.SQLiteStore, cfg *config.Config) *ShardedGenerate {
+	s := &ShardedGenerate{
+		Store:       store,
+		ProjectRoot: ".",
+		Output:      config.ResolveOutputPaths(),
+	}
+	if cfg != nil {
+		s.Output = cfg.OutputPaths()
+		s.Options = shard.Options{
+			By:         strings.ToLower(strings.TrimSpace(cfg.Sharding.By)),
+			Depth:      cfg.Sharding.Depth,
+			MinSymbols: cfg.Sharding.MinSymbols,
+		}
+		s.Workers = cfg.Sharding.Workers
+		s.MaxChunksPerShard = cfg.Sharding.MaxChunksPerShard
+	}
+	return s
+}
+
+// ShardResult records how one shard was documented.
+type ShardResult struct {
+	Name    string `json:"name"`
+	Dir     string `json:"dir"`
+	Symbols int    `json:"symbols"`
+	Files   int    `json:"files"`
+	// Doc is the shard's documentation, relative to the output directory.
+	Doc        string `json:"doc"`
+	Summary    string `json:"summary,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	Err        string `json:"error,omitempty"`
+}
+
+// ShardManifest is written to ShardManifestFile.
+type ShardManifest struct {
+	GeneratedAt  string             `json:"generated_at"`
+	By           string             `json:"by"`
+	Shards       []ShardResult      `json:"shards"`
+	Dependencies []shard.Dependency `json:"dependencies"`
+}
+
+// Failed counts the shards that could not be documented.
+func (m *ShardManifest) Failed() int {
+	n := 0
+	for _, r := range m.Shards {
+		if r.Err != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Run partitions g, documents every shard and writes the global overview to
+// the output doc path along with the manifest. A failing shard is recorded
+// in the manifest and does not stop the others.
+func (s *ShardedGenerate) Run(ctx context.Context, g *graph.Graph) (*ShardManifest, error) {
+	opts := s.Options
+	if opts.By == "" {
+		opts.By = shard.ByDirectory
+	}
+	if opts.By == shard.ByModule && len(opts.Modules) == 0 {
+		modules, err := shard.FindModules(s.ProjectRoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find modules: %w", err)
+		}
+		opts.Modules = modules
+	}
+	shards := shard.Partition(g, s.ProjectRoot, opts)
+	fmt.Printf("🧩 Partitioned %d symbols into %d shards by %s.\n", countSymbols(shards), len(shards), opts.By)
+
+	manifest := &ShardManifest{
+		GeneratedAt:  time.Now().UTC().Format(time.RFC3339),
+		By:           opts.By,
+		Shards:       make([]ShardResult, len(shards)),
+		Dependencies: shard.Dependencies(g, shards),
+	}
+	workers := s.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, len(shards)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				manifest.Shards[i] = s.generateShard(ctx, g, shards[i])
+			}
+		}()
+	}
+	for i := range shards {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := os.MkdirAll(s.Output.Dir, 0755); err != nil {
+		return manifest, err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return manifest, err
+	}
+	if err := os.WriteFile(filepath.Join(s.Output.Dir, ShardManifestFile), data, 0644); err != nil {
+		return manifest, err
+	}
+	if err := os.WriteFile(s.Output.Doc(), []byte(RenderShardOverview(manifest)), 0644); err != nil {
+		return manifest, err
+	}
+	return manifest, nil
+}
+
+func (s *ShardedGenerate) generateShard(ctx context.Context, g *graph.Graph, sh *shard.Shard) ShardResult {
+	started := time.Now()
+	dir := filepath.Join(s.Output.Dir, ShardsDir, filepath.FromSlash(sh.Name))
+	paths := s.Output.WithDir(dir)
+	res := ShardResult{
+		Name:    sh.Name,
+		Dir:     sh.Dir,
+		Symbols: sh.Symbols,
+		Files:   len(sh.Files),
+		Doc:     filepath.ToSlash(filepath.Join(ShardsDir, sh.Name, s.Output.DocFile)),
+	}
+	err := func() error {
+		fmt.Printf("📦 Shard %s: %d symbols in %d files\n", sh.Name, sh.Symbols, len(sh.Files))
+		engine, summarizer, err := initEngine(ctx, sh.Graph(g), s.Store, false)
+		if err != nil {
+			return err
+		}
+		engine.SetPathScope(sh.Prefixes, sh.Exclude)
+		if err := engine.IndexAllWithOptions(ctx, knowledge.IndexingOptions{MaxChunksPerRun: s.MaxChunksPerShard}); err != nil {
+			return fmt.Errorf("indexing failed: %w", err)
+		}
+		if err := generator.NewMarkdownGenerator(engine, summarizer).GenerateDocs(ctx, dir); err != nil {
+			return err
+		}
+		if model, err := generator.LoadDocModel(paths.Model()); err == nil {
+			res.Summary = overviewSummary(model)
+		}
+		return nil
+	}()
+	if err != nil {
+		res.Err = err.Error()
+		fmt.Printf("⚠️  Shard %s failed: %v\n", sh.Name, err)
+	}
+	res.DurationMS = time.Since(started).Milliseconds()
+	return res
+}
+
+// overviewSummary is the summary of the shard's overview section, or its
+// first paragraph.
+func overviewSummary(model *generator.DocModel) string {
+	for _, sec := range model.Sections {
+		if sec.ID != "overview" {
+			continue
+		}
+		if summary := strings.TrimSpace(sec.Summary); summary != "" {
+			return summary
+		}
+		for _, para := range strings.Split(sec.ContentMD, "\n\n") {
+			para = strings.TrimSpace(para)
+			if para != "" && !strings.HasPrefix(para, "#") {
+				return para
+			}
+		}
+	}
+	return ""
+}
+
+// RenderShardOverview renders the global overview of a sharded run: a table
+// linking every shard, the dependencies between shards and each shard's
+// summary.
+func RenderShardOverview(m *ShardManifest) string {
+	var b strings.Builder
+	b.WriteString("# Documentation\n\n")
+	fmt.Fprintf(&b, "This repository is documented in %d shards by %s. Each shard links to its own documentation.\n\n", len(m.Shards), m.By)
+
+	b.WriteString("## Shards\n\n")
+	b.WriteString("| Shard | Directory | Symbols | Files | Documentation |\n")
+	b.WriteString("|---|---|---:|---:|---|\n")
+	for _, r := range m.Shards {
+		dir := r.Dir
+		if dir == "" {
+			dir = "."
+		}
+		doc := fmt.Sprintf("[%s](%s)", r.Doc, r.Doc)
+		if r.Err != "" {
+			doc = "failed: " + strings.ReplaceAll(r.Err, "|", "\\|")
+		}
+		fmt.Fprintf(&b, "| %s | `%s` | %d | %d | %s |\n", r.Name, dir, r.Symbols, r.Files, doc)
+	}
+
+	if len(m.Dependencies) > 0 {
+		ids := make(map[string]string, len(m.Shards))
+		for i, r := range m.Shards {
+			ids[r.Name] = fmt.Sprintf("s%d", i)
+		}
+		b.WriteString("\n## Shard Dependencies\n\n")
+		b.WriteString("Arrows point from a shard to the shards it references, labelled with the number of edges.\n\n")
+		b.WriteString("```mermaid\ngraph LR\n")
+		for _, r := range m.Shards {
+			fmt.Fprintf(&b, "  %s[\"%s\"]\n", ids[r.Name], r.Name)
+		}
+		for _, d := range m.Dependencies {
+			fmt.Fprintf(&b, "  %s -->|%d| %s\n", ids[d.From], d.Edges, ids[d.To])
+		}
+		b.WriteString("```\n")
+	}
+
+	for _, r := range m.Shards {
+		if r.Summary == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n%s\n\n[Full documentation](%s)\n", r.Name, r.Summary, r.Doc)
+	}
+	return b.String()
+}
+
+func countSymbols(shards []*shard.Shard) int {
+	n := 0
+	for _, s := range shards {
+		n += s.Symbols
+	}
+	return n
+}
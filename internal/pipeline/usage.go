@@ -0,0 +1,50 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+
+	"docod/internal/config"
+	"docod/internal/knowledge"
+	"docod/internal/storage"
+)
+
+// NewUsageMeter returns a meter pricing models with the configured prices
+// over the built-in list prices.
+func NewUsageMeter(cfg *config.Config) *knowledge.UsageMeter {
+	var prices map[string]knowledge.ModelPrice
+	if cfg != nil && len(cfg.AI.Pricing) > 0 {
+		prices = make(map[string]knowledge.ModelPrice, len(cfg.AI.Pricing))
+		for model, p := range cfg.AI.Pricing {
+			prices[model] = knowledge.ModelPrice{Input: p.InputPerMillion, Output: p.OutputPerMillion}
+		}
+	}
+	return knowledge.NewUsageMeter(prices)
+}
+
+// RecordUsageRun stores the usage recorded on meter as a run of mode, for
+// docod report cost, and prints its total. Runs that made no model calls
+// are not stored.
+func RecordUsageRun(ctx context.Context, store *storage.SQLiteStore, meter *knowledge.UsageMeter, mode string) error {
+	run := meter.Run(mode)
+	total := run.Total()
+	if total.Requests == 0 {
+		return nil
+	}
+	fmt.Printf("💰 Model usage: %d requests, %d input / %d output tokens, ~$%.4f\n", total.Requests, total.InputTokens, total.OutputTokens, total.Cost)
+	_, err := store.SaveUsageRun(ctx, run)
+	return err
+}
+
+// usageByKind sums the usage recorded on meter into LLM and embedding usage.
+func usageByKind(meter *knowledge.UsageMeter) (llm, embedding knowledge.Usage) {
+	for _, rec := range meter.Run("").Records {
+		switch rec.Kind {
+		case knowledge.UsageLLM:
+			llm.Add(rec.Usage)
+		case knowledge.UsageEmbedding:
+			embedding.Add(rec.Usage)
+		}
+	}
+	return llm, embedding
+}
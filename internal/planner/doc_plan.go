@@ -1,9 +1,11 @@
 package planner
 
 import (
+	"math"
 	"sort"
 
 	"docod/internal/generator"
+	"docod/internal/graph"
 	"docod/internal/retrieval"
 )
 
@@ -23,9 +25,48 @@ type SectionImpact struct {
 	Reasons        []string
 	TriggerSymbols []string
 	TriggerFiles   []string
+	// Breaking describes breaking API changes to symbols the section covers.
+	Breaking []string
 }
 
+// Weights tunes how sections are scored against the retrieval subgraph.
+type Weights struct {
+	// SymbolMatch is added per section source symbol in the subgraph.
+	SymbolMatch float64
+	// SymbolConfidence scales the combined symbol/source confidence of a
+	// symbol match into extra score.
+	SymbolConfidence float64
+	// FileMatch is added per section source file among the changed files.
+	FileMatch float64
+	// Centrality scales a matched symbol's PageRank into extra score.
+	Centrality float64
+	// Dependents scales log2(1+transitive dependents), capped at
+	// MaxDependentsBonus, so hub symbols outrank leaf utilities.
+	Dependents         float64
+	MaxDependentsBonus float64
+}
+
+// DefaultWeights returns the built-in scoring weights.
+func DefaultWeights() Weights {
+	return Weights{
+		SymbolMatch:        1.0,
+		SymbolConfidence:   0.2,
+		FileMatch:          0.35,
+		Centrality:         0.5,
+		Dependents:         0.1,
+		MaxDependentsBonus: 0.5,
+	}
+}
+
+// hubBonusThreshold marks a match as a hub symbol in the reasons.
+const hubBonusThreshold = 0.3
+
 func BuildDocUpdatePlan(model *generator.DocModel, sg *retrieval.Subgraph) *DocUpdatePlan {
+	return BuildDocUpdatePlanWithWeights(model, sg, DefaultWeights())
+}
+
+// BuildDocUpdatePlanWithWeights scores sections with custom weights.
+func BuildDocUpdatePlanWithWeights(model *generator.DocModel, sg *retrieval.Subgraph, w Weights) *DocUpdatePlan {
 	plan := &DocUpdatePlan{}
 	if sg == nil {
 		return plan
@@ -58,15 +99,21 @@ func BuildDocUpdatePlan(model *generator.DocModel, sg *retrieval.Subgraph) *DocU
 				symConf := normalizeConfidence(sg.NodeScores[src.SymbolID], 0.45)
 				srcConf := normalizeConfidence(src.Confidence, symConf)
 				combined := (symConf + srcConf) / 2.0
-				impact.Score += 1.0 + (0.2 * combined)
+				impact.Score += w.SymbolMatch + (w.SymbolConfidence * combined)
 				reasonSet["symbol_source_match"] = true
+				if bonus := hubBonus(sg, src.SymbolID, w); bonus > 0 {
+					impact.Score += bonus
+					if bonus >= hubBonusThreshold {
+						reasonSet["hub_symbol"] = true
+					}
+				}
 				symbolHit[src.SymbolID] = true
 				matchedSymbolSet[src.SymbolID] = true
 				confSum += combined
 				confCount++
 			}
 			if src.FilePath != "" && fileSet[src.FilePath] {
-				impact.Score += 0.35
+				impact.Score += w.FileMatch
 				reasonSet["file_source_match"] = true
 				fileHit[src.FilePath] = true
 				confSum += 0.3
@@ -110,6 +157,92 @@ func BuildDocUpdatePlan(model *generator.DocModel, sg *retrieval.Subgraph) *DocU
 	return plan
 }
 
+// hubBonus rewards symbols that much of the graph depends on.
+func hubBonus(sg *retrieval.Subgraph, id string, w Weights) float64 {
+	bonus := w.Centrality * sg.Centrality[id]
+	if deps := sg.Dependents[id]; deps > 0 {
+		bonus += math.Min(w.MaxDependentsBonus, w.Dependents*math.Log2(1+float64(deps)))
+	}
+	return bonus
+}
+
+const (
+	// breakingBonus is added to sections covering a breaking change.
+	breakingBonus = 2.0
+	// breakingConfidence is the confidence floor for such sections, so they
+	// pass the LLM rewrite threshold.
+	breakingConfidence = 0.9
+)
+
+// ApplyChangeClasses marks sections that document symbols with breaking
+// changes and moves them to the front of the plan. Removed symbols are no
+// longer in the graph, so sections are matched against the model's sources
+// by old and new symbol IDs.
+func ApplyChangeClasses(plan *DocUpdatePlan, model *generator.DocModel, changes []graph.ClassifiedChange) {
+	if plan == nil || model == nil {
+		return
+	}
+	breaking := make(map[string][]string) // symbol ID -> descriptions
+	for _, c := range changes {
+		if c.Class != graph.ChangeBreaking {
+			continue
+		}
+		desc := "`" + c.Package + "." + c.Name + "`: " + c.Detail
+		breaking[c.ID] = append(breaking[c.ID], desc)
+		if c.OldID != "" && c.OldID != c.ID {
+			breaking[c.OldID] = append(breaking[c.OldID], desc)
+		}
+	}
+	if len(breaking) == 0 {
+		return
+	}
+
+	index := make(map[string]int, len(plan.AffectedSections))
+	for i, sec := range plan.AffectedSections {
+		index[sec.SectionID] = i
+	}
+	for _, section := range model.Sections {
+		seen := make(map[string]bool)
+		var descs, symbols []string
+		for _, src := range section.Sources {
+			for _, d := range breaking[src.SymbolID] {
+				if !seen[d] {
+					seen[d] = true
+					descs = append(descs, d)
+				}
+			}
+			if len(breaking[src.SymbolID]) > 0 {
+				symbols = append(symbols, src.SymbolID)
+			}
+		}
+		if len(descs) == 0 {
+			continue
+		}
+		sort.Strings(descs)
+		i, ok := index[section.ID]
+		if !ok {
+			plan.AffectedSections = append(plan.AffectedSections, SectionImpact{SectionID: section.ID})
+			i = len(plan.AffectedSections) - 1
+			index[section.ID] = i
+		}
+		impact := &plan.AffectedSections[i]
+		impact.Breaking = descs
+		impact.Score += breakingBonus
+		if impact.Confidence < breakingConfidence {
+			impact.Confidence = breakingConfidence
+		}
+		reasons := toSet(impact.Reasons)
+		reasons["breaking_change"] = true
+		impact.Reasons = sortedSetKeys(reasons)
+		impact.TriggerSymbols = sortedSetKeys(toSet(append(impact.TriggerSymbols, symbols...)))
+	}
+
+	sort.SliceStable(plan.AffectedSections, func(i, j int) bool {
+		bi, bj := len(plan.AffectedSections[i].Breaking) > 0, len(plan.AffectedSections[j].Breaking) > 0
+		return bi && !bj
+	})
+}
+
 func normalizeConfidence(value float64, fallback float64) float64 {
 	if value <= 0 {
 		return fallback
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"docod/internal/generator"
+	"docod/internal/graph"
 	"docod/internal/retrieval"
 
 	"github.com/stretchr/testify/assert"
@@ -92,3 +93,72 @@ func TestBuildDocUpdatePlan_ConfidenceFirstOrdering(t *testing.T) {
 		assert.Greater(t, plan.AffectedSections[0].Confidence, plan.AffectedSections[1].Confidence)
 	}
 }
+
+func TestBuildDocUpdatePlan_HubSymbolsRankHigher(t *testing.T) {
+	model := &generator.DocModel{
+		Sections: []generator.ModelSect{
+			{ID: "leaf", Sources: []generator.SourceRef{{SymbolID: "sym.Leaf"}}},
+			{ID: "hub", Sources: []generator.SourceRef{{SymbolID: "sym.Hub"}}},
+		},
+	}
+	sg := &retrieval.Subgraph{
+		NodeIDs:    []string{"sym.Hub", "sym.Leaf"},
+		NodeScores: map[string]float64{"sym.Hub": 1.0, "sym.Leaf": 1.0},
+		Centrality: map[string]float64{"sym.Hub": 1.0, "sym.Leaf": 0.1},
+		Dependents: map[string]int{"sym.Hub": 40},
+	}
+
+	plan := BuildDocUpdatePlan(model, sg)
+
+	if assert.Len(t, plan.AffectedSections, 2) {
+		assert.Equal(t, "hub", plan.AffectedSections[0].SectionID)
+		assert.Greater(t, plan.AffectedSections[0].Score, plan.AffectedSections[1].Score)
+		assert.Contains(t, plan.AffectedSections[0].Reasons, "hub_symbol")
+		assert.NotContains(t, plan.AffectedSections[1].Reasons, "hub_symbol")
+	}
+}
+
+func TestApplyChangeClasses_PrioritizesBreakingSections(t *testing.T) {
+	model := &generator.DocModel{
+		Sections: []generator.ModelSect{
+			{ID: "usage", Sources: []generator.SourceRef{{SymbolID: "sym.Run"}}},
+			{ID: "api", Sources: []generator.SourceRef{{SymbolID: "sym.Gone"}}},
+		},
+	}
+	plan := &DocUpdatePlan{
+		AffectedSections: []SectionImpact{{SectionID: "usage", Score: 1.2, Confidence: 0.5}},
+	}
+	changes := []graph.ClassifiedChange{
+		{NodeRef: graph.NodeRef{ID: "sym.Gone", Name: "Gone", Package: "p"}, Class: graph.ChangeBreaking, Reason: "removed", Detail: "removed function Gone"},
+		{NodeRef: graph.NodeRef{ID: "sym.Run", Name: "Run", Package: "p"}, Class: graph.ChangeInternal, Reason: "body_changed"},
+	}
+
+	ApplyChangeClasses(plan, model, changes)
+
+	if assert.Len(t, plan.AffectedSections, 2) {
+		assert.Equal(t, "api", plan.AffectedSections[0].SectionID)
+		assert.Equal(t, []string{"`p.Gone`: removed function Gone"}, plan.AffectedSections[0].Breaking)
+		assert.Contains(t, plan.AffectedSections[0].Reasons, "breaking_change")
+		assert.GreaterOrEqual(t, plan.AffectedSections[0].Confidence, 0.9)
+		assert.Empty(t, plan.AffectedSections[1].Breaking)
+	}
+}
+
+func TestBuildDocUpdatePlanWithWeights(t *testing.T) {
+	model := &generator.DocModel{
+		Sections: []generator.ModelSect{
+			{ID: "files", Sources: []generator.SourceRef{{FilePath: "a.go"}}},
+		},
+	}
+	sg := &retrieval.Subgraph{NodeIDs: []string{"sym.A"}, UpdatedFiles: []string{"a.go"}}
+
+	w := DefaultWeights()
+	w.FileMatch = 2.5
+	plan := BuildDocUpdatePlanWithWeights(model, sg, w)
+	if assert.Len(t, plan.AffectedSections, 1) {
+		assert.InDelta(t, 2.5, plan.AffectedSections[0].Score, 0.001)
+	}
+
+	w.FileMatch = 0
+	assert.Empty(t, BuildDocUpdatePlanWithWeights(model, sg, w).AffectedSections)
+}
@@ -0,0 +1,177 @@
+// Package profiling writes CPU and heap profiles per pipeline stage when
+// docod runs with --pprof.
+package profiling
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SummaryFile lists the stages profiled in a run, in the profile directory.
+const SummaryFile = "stages.json"
+
+// StageProfile describes the profiles of one stage.
+type StageProfile struct {
+	Name       string `json:"name"`
+	DurationMS int64  `json:"duration_ms"`
+	// AllocBytes is the heap allocated while the stage ran.
+	AllocBytes uint64 `json:"alloc_bytes"`
+	// CPU is empty for stages nested in another, whose samples land in the
+	// outer stage's profile.
+	CPU  string `json:"cpu,omitempty"`
+	Heap string `json:"heap"`
+	Err  string `json:"error,omitempty"`
+
+	seq int
+}
+
+type profiler struct {
+	dir    string
+	seq    int
+	depth  int
+	cpu    *os.File
+	stages []StageProfile
+}
+
+var (
+	mu     sync.Mutex
+	active *profiler
+)
+
+// Start profiles later stages into dir, which is created if needed. Until
+// Stop, every Stage writes <n>-<stage>.cpu.pprof and <n>-<stage>.heap.pprof
+// there.
+func Start(dir string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if active != nil {
+		return fmt.Errorf("profiling already started in %s", active.dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	active = &profiler{dir: dir}
+	return nil
+}
+
+// Enabled reports whether Start was called.
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return active != nil
+}
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// Stage begins profiling the stage name and returns the function ending it.
+// It is a no-op when profiling is off, so callers can always write
+//
+//	defer profiling.Stage("save_graph")()
+func Stage(name string) func() {
+	mu.Lock()
+	defer mu.Unlock()
+	p := active
+	if p == nil {
+		return func() {}
+	}
+	p.seq++
+	base := fmt.Sprintf("%02d-%s", p.seq, unsafeName.ReplaceAllString(name, "_"))
+	st := StageProfile{Name: name, Heap: base + ".heap.pprof", seq: p.seq}
+
+	// Go records one CPU profile at a time; nested stages share the
+	// outermost one.
+	ownsCPU := false
+	if p.depth == 0 {
+		if f, err := os.Create(filepath.Join(p.dir, base+".cpu.pprof")); err != nil {
+			st.Err = err.Error()
+		} else if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			os.Remove(f.Name())
+			st.Err = err.Error()
+		} else {
+			p.cpu = f
+			ownsCPU = true
+			st.CPU = filepath.Base(f.Name())
+		}
+	}
+	p.depth++
+
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	started := time.Now()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			duration := time.Since(started)
+			var after runtime.MemStats
+			runtime.ReadMemStats(&after)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if active != p {
+				return
+			}
+			p.depth--
+			if ownsCPU {
+				pprof.StopCPUProfile()
+				if err := p.cpu.Close(); err != nil && st.Err == "" {
+					st.Err = err.Error()
+				}
+				p.cpu = nil
+			}
+			st.DurationMS = duration.Milliseconds()
+			st.AllocBytes = after.TotalAlloc - before.TotalAlloc
+			if err := writeHeap(filepath.Join(p.dir, st.Heap)); err != nil && st.Err == "" {
+				st.Err = err.Error()
+			}
+			p.stages = append(p.stages, st)
+		})
+	}
+}
+
+// Stop ends profiling, stopping a stage still running, and writes the stage
+// summary in the order the stages began. It is a no-op when profiling is off.
+func Stop() error {
+	mu.Lock()
+	defer mu.Unlock()
+	p := active
+	if p == nil {
+		return nil
+	}
+	active = nil
+	if p.cpu != nil {
+		pprof.StopCPUProfile()
+		p.cpu.Close()
+	}
+	// Stages end in any order when nested or concurrent; list them in the
+	// order they began, which is also the order of their file names.
+	sort.Slice(p.stages, func(i, j int) bool { return p.stages[i].seq < p.stages[j].seq })
+	data, err := json.MarshalIndent(p.stages, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(p.dir, SummaryFile), data, 0644)
+}
+
+// writeHeap writes a heap profile after a collection, so it shows live
+// memory rather than garbage.
+func writeHeap(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
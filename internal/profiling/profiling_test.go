@@ -0,0 +1,51 @@
+package profiling
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStage_NoopWhenDisabled(t *testing.T) {
+	assert.False(t, Enabled())
+	Stage("idle")()
+	assert.NoError(t, Stop())
+}
+
+func TestStage_WritesProfilesPerStage(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "pprof")
+	require.NoError(t, Start(dir))
+	assert.Error(t, Start(dir), "one profiler per process")
+
+	endOuter := Stage("graph update")
+	endInner := Stage("resolve")
+	endInner()
+	endInner() // ending twice is harmless
+	endOuter()
+	Stage("save_graph")()
+	require.NoError(t, Stop())
+	assert.False(t, Enabled())
+
+	data, err := os.ReadFile(filepath.Join(dir, SummaryFile))
+	require.NoError(t, err)
+	var stages []StageProfile
+	require.NoError(t, json.Unmarshal(data, &stages))
+	require.Len(t, stages, 3)
+
+	assert.Equal(t, "graph update", stages[0].Name, "listed in start order")
+	assert.Equal(t, "01-graph_update.cpu.pprof", stages[0].CPU)
+	assert.Equal(t, "resolve", stages[1].Name)
+	assert.Empty(t, stages[1].CPU, "nested stages share the outer CPU profile")
+	assert.Equal(t, "03-save_graph.cpu.pprof", stages[2].CPU)
+	for _, st := range stages {
+		assert.Empty(t, st.Err)
+		assert.FileExists(t, filepath.Join(dir, st.Heap))
+		if st.CPU != "" {
+			assert.FileExists(t, filepath.Join(dir, st.CPU))
+		}
+	}
+}
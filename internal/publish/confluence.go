@@ -0,0 +1,341 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"docod/internal/generator"
+)
+
+// TargetConfluence is the StateStore target name of Confluence pages.
+const TargetConfluence = "confluence"
+
+// ConfluenceOptions locates the space pages are published to.
+type ConfluenceOptions struct {
+	// BaseURL is the site root including the context path, e.g.
+	// https://example.atlassian.net/wiki.
+	BaseURL  string
+	SpaceKey string
+	// ParentPageID places root sections under an existing page; empty
+	// publishes them at the top of the space.
+	ParentPageID string
+	// User and APIToken authenticate with basic auth (Confluence Cloud).
+	// Without a user the token is sent as a bearer personal access token
+	// (Data Center).
+	User     string
+	APIToken string
+	// TitlePrefix is prepended to page titles, which must be unique per
+	// space, e.g. "payments: ".
+	TitlePrefix string
+}
+
+// ConfluencePublisher publishes one page per section through the Confluence
+// REST API.
+type ConfluencePublisher struct {
+	opts   ConfluenceOptions
+	state  StateStore
+	client *http.Client
+}
+
+// NewConfluencePublisher returns a publisher for opts. A nil client uses
+// http.DefaultClient.
+func NewConfluencePublisher(opts ConfluenceOptions, state StateStore, client *http.Client) (*ConfluencePublisher, error) {
+	opts.BaseURL = strings.TrimRight(strings.TrimSpace(opts.BaseURL), "/")
+	switch {
+	case opts.BaseURL == "":
+		return nil, fmt.Errorf("confluence: base_url is required")
+	case opts.SpaceKey == "":
+		return nil, fmt.Errorf("confluence: space_key is required")
+	case opts.APIToken == "":
+		return nil, fmt.Errorf("confluence: api_token is required")
+	case state == nil:
+		return nil, fmt.Errorf("confluence: state store is required")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &ConfluencePublisher{opts: opts, state: state, client: client}, nil
+}
+
+type confluencePage struct {
+	ID        string               `json:"id,omitempty"`
+	Type      string               `json:"type"`
+	Title     string               `json:"title"`
+	Space     confluenceSpace      `json:"space"`
+	Version   *confluenceVersion   `json:"version,omitempty"`
+	Ancestors []confluenceAncestor `json:"ancestors,omitempty"`
+	Body      *confluenceBody      `json:"body,omitempty"`
+}
+
+type confluenceSpace struct {
+	Key string `json:"key"`
+}
+
+type confluenceVersion struct {
+	Number int `json:"number"`
+}
+
+type confluenceAncestor struct {
+	ID string `json:"id"`
+}
+
+type confluenceBody struct {
+	Storage struct {
+		Value          string `json:"value"`
+		Representation string `json:"representation"`
+	} `json:"storage"`
+}
+
+// confluenceError is a non-2xx API response.
+type confluenceError struct {
+	Status int
+	Body   string
+}
+
+func (e *confluenceError) Error() string {
+	return fmt.Sprintf("confluence: HTTP %d: %s", e.Status, e.Body)
+}
+
+// Publish creates or updates the page of every section. Sections whose
+// rendition is unchanged since the last run are skipped without an API call.
+func (p *ConfluencePublisher) Publish(ctx context.Context, model *generator.DocModel) (*Report, error) {
+	records, err := p.state.PublishedPages(ctx, TargetConfluence)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{}
+	pageOf := make(map[string]string, len(model.Sections))
+	for _, sec := range orderedSections(model) {
+		parent := p.opts.ParentPageID
+		if sec.ParentID != nil {
+			if id, ok := pageOf[*sec.ParentID]; ok {
+				parent = id
+			}
+		}
+		title := p.opts.TitlePrefix + sec.Title
+		hash := renditionHash(sec, title, parent)
+		rec, known := records[sec.ID]
+		if known && rec.Hash == hash {
+			pageOf[sec.ID] = rec.PageID
+			report.Unchanged = append(report.Unchanged, sec.ID)
+			continue
+		}
+
+		body := confluenceStorage(pageBody(sec))
+		pageID := ""
+		if known {
+			pageID = rec.PageID
+		} else if existing, err := p.findPage(ctx, title); err != nil {
+			return report, err
+		} else if existing != nil {
+			// Adopt a page published before the state was recorded.
+			pageID = existing.ID
+		}
+		created := false
+		if pageID != "" {
+			err = p.updatePage(ctx, pageID, title, parent, body)
+			var apiErr *confluenceError
+			if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+				// The page was deleted in Confluence; publish it again.
+				pageID, err = "", nil
+			}
+			if err != nil {
+				return report, fmt.Errorf("section %s: %w", sec.ID, err)
+			}
+		}
+		if pageID == "" {
+			if pageID, err = p.createPage(ctx, title, parent, body); err != nil {
+				return report, fmt.Errorf("section %s: %w", sec.ID, err)
+			}
+			created = true
+		}
+		if err := p.state.SavePublishedPage(ctx, TargetConfluence, PageRecord{SectionID: sec.ID, PageID: pageID, Hash: hash}); err != nil {
+			return report, err
+		}
+		pageOf[sec.ID] = pageID
+		if created {
+			report.Created = append(report.Created, sec.ID)
+		} else {
+			report.Updated = append(report.Updated, sec.ID)
+		}
+	}
+	return report, nil
+}
+
+func (p *ConfluencePublisher) findPage(ctx context.Context, title string) (*confluencePage, error) {
+	q := url.Values{"spaceKey": {p.opts.SpaceKey}, "title": {title}, "type": {"page"}, "expand": {"version"}}
+	var resp struct {
+		Results []confluencePage `json:"results"`
+	}
+	if err := p.do(ctx, http.MethodGet, "/rest/api/content?"+q.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Results) == 0 {
+		return nil, nil
+	}
+	return &resp.Results[0], nil
+}
+
+func (p *ConfluencePublisher) createPage(ctx context.Context, title, parent, body string) (string, error) {
+	page := p.newPage(title, parent, body)
+	var created confluencePage
+	if err := p.do(ctx, http.MethodPost, "/rest/api/content", page, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// updatePage replaces the page body; Confluence requires the next version
+// number, so the current one is read first.
+func (p *ConfluencePublisher) updatePage(ctx context.Context, id, title, parent, body string) error {
+	var current confluencePage
+	if err := p.do(ctx, http.MethodGet, "/rest/api/content/"+url.PathEscape(id)+"?expand=version", nil, &current); err != nil {
+		return err
+	}
+	page := p.newPage(title, parent, body)
+	page.ID = id
+	page.Version = &confluenceVersion{Number: 1}
+	if current.Version != nil {
+		page.Version.Number = current.Version.Number + 1
+	}
+	return p.do(ctx, http.MethodPut, "/rest/api/content/"+url.PathEscape(id), page, nil)
+}
+
+func (p *ConfluencePublisher) newPage(title, parent, body string) *confluencePage {
+	page := &confluencePage{Type: "page", Title: title, Space: confluenceSpace{Key: p.opts.SpaceKey}, Body: &confluenceBody{}}
+	if parent != "" {
+		page.Ancestors = []confluenceAncestor{{ID: parent}}
+	}
+	page.Body.Storage.Value = body
+	page.Body.Storage.Representation = "storage"
+	return page
+}
+
+func (p *ConfluencePublisher) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, p.opts.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if p.opts.User != "" {
+		req.SetBasicAuth(p.opts.User, p.opts.APIToken)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+p.opts.APIToken)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("confluence: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &confluenceError{Status: resp.StatusCode, Body: strings.TrimSpace(string(msg))}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("confluence: decode response: %w", err)
+	}
+	return nil
+}
+
+// confluenceLanguages are the code macro languages Confluence highlights;
+// other fences, such as mermaid, are shown as plain code.
+var confluenceLanguages = map[string]string{
+	"go": "go", "bash": "bash", "sh": "bash", "shell": "bash", "json": "json",
+	"yaml": "yaml", "yml": "yaml", "sql": "sql", "java": "java", "python": "py",
+	"py": "py", "js": "js", "javascript": "js", "ts": "js", "typescript": "js",
+	"xml": "xml", "html": "html", "diff": "diff",
+}
+
+// confluenceStorage renders markdown as Confluence storage format (XHTML).
+func confluenceStorage(md string) string {
+	var b strings.Builder
+	for _, blk := range parseBlocks(md) {
+		switch blk.Kind {
+		case blockHeading:
+			fmt.Fprintf(&b, "<h%d>%s</h%d>", blk.Level, storageInline(blk.Text), blk.Level)
+		case blockCode:
+			b.WriteString(`<ac:structured-macro ac:name="code">`)
+			if lang, ok := confluenceLanguages[strings.ToLower(blk.Lang)]; ok {
+				fmt.Fprintf(&b, `<ac:parameter ac:name="language">%s</ac:parameter>`, lang)
+			} else if blk.Lang != "" {
+				fmt.Fprintf(&b, `<ac:parameter ac:name="title">%s</ac:parameter>`, html.EscapeString(blk.Lang))
+			}
+			// "]]>" cannot appear inside CDATA; split it across sections.
+			code := strings.ReplaceAll(blk.Text, "]]>", "]]]]><![CDATA[>")
+			fmt.Fprintf(&b, "<ac:plain-text-body><![CDATA[%s]]></ac:plain-text-body></ac:structured-macro>", code)
+		case blockBullet, blockNumbered:
+			tag := "ul"
+			if blk.Kind == blockNumbered {
+				tag = "ol"
+			}
+			b.WriteString("<" + tag + ">")
+			for _, item := range blk.Items {
+				b.WriteString("<li>" + storageInline(item) + "</li>")
+			}
+			b.WriteString("</" + tag + ">")
+		case blockQuote:
+			b.WriteString("<blockquote><p>" + storageInline(blk.Text) + "</p></blockquote>")
+		case blockTable:
+			b.WriteString("<table><tbody>")
+			for i, row := range blk.Rows {
+				cell := "td"
+				if i == 0 {
+					cell = "th"
+				}
+				b.WriteString("<tr>")
+				for _, c := range row {
+					b.WriteString("<" + cell + ">" + storageInline(c) + "</" + cell + ">")
+				}
+				b.WriteString("</tr>")
+			}
+			b.WriteString("</tbody></table>")
+		case blockRule:
+			b.WriteString("<hr />")
+		default:
+			b.WriteString("<p>" + storageInline(blk.Text) + "</p>")
+		}
+	}
+	return b.String()
+}
+
+func storageInline(text string) string {
+	var b strings.Builder
+	for _, s := range parseInline(text) {
+		t := html.EscapeString(s.Text)
+		switch {
+		case s.Code:
+			b.WriteString("<code>" + t + "</code>")
+		case s.Bold:
+			b.WriteString("<strong>" + t + "</strong>")
+		case s.Italic:
+			b.WriteString("<em>" + t + "</em>")
+		case s.Link != "":
+			fmt.Fprintf(&b, `<a href="%s">%s</a>`, html.EscapeString(s.Link), t)
+		default:
+			b.WriteString(t)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,166 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"docod/internal/generator"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryState struct {
+	pages map[string]map[string]PageRecord
+}
+
+func (m *memoryState) PublishedPages(_ context.Context, target string) (map[string]PageRecord, error) {
+	out := make(map[string]PageRecord)
+	for id, rec := range m.pages[target] {
+		out[id] = rec
+	}
+	return out, nil
+}
+
+func (m *memoryState) SavePublishedPage(_ context.Context, target string, rec PageRecord) error {
+	if m.pages == nil {
+		m.pages = make(map[string]map[string]PageRecord)
+	}
+	if m.pages[target] == nil {
+		m.pages[target] = make(map[string]PageRecord)
+	}
+	m.pages[target][rec.SectionID] = rec
+	return nil
+}
+
+// fakeConfluence stores pages in memory and records the calls it served.
+type fakeConfluence struct {
+	mu    sync.Mutex
+	pages map[string]*confluencePage
+	calls []string
+}
+
+func (f *fakeConfluence) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, r.Method+" "+r.URL.Path)
+	if user, token, ok := r.BasicAuth(); !ok || user != "me@example.com" || token != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/wiki/rest/api/content/")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/wiki/rest/api/content":
+		var results []confluencePage
+		for _, p := range f.pages {
+			if p.Title == r.URL.Query().Get("title") {
+				results = append(results, *p)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"results": results})
+	case r.Method == http.MethodPost:
+		var p confluencePage
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		p.ID = fmt.Sprintf("%d", 100+len(f.pages))
+		p.Version = &confluenceVersion{Number: 1}
+		f.pages[p.ID] = &p
+		_ = json.NewEncoder(w).Encode(p)
+	case f.pages[id] == nil:
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodGet:
+		_ = json.NewEncoder(w).Encode(f.pages[id])
+	case r.Method == http.MethodPut:
+		var p confluencePage
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		if p.Version == nil || p.Version.Number != f.pages[id].Version.Number+1 {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.pages[id] = &p
+		_ = json.NewEncoder(w).Encode(p)
+	}
+}
+
+func testModel() *generator.DocModel {
+	parent := "overview"
+	return &generator.DocModel{Sections: []generator.ModelSect{
+		{ID: "arch", Title: "Architecture", Level: 2, Order: 0, ParentID: &parent, ContentMD: "## Architecture\n\nThe `Engine` wires **everything**.", Hash: "h-arch"},
+		{ID: "overview", Title: "Overview", Level: 1, Order: 0, ContentMD: "# Overview\n\nA tool.\n\n```go\nfunc main() {}\n```", Hash: "h-overview"},
+	}}
+}
+
+func TestConfluencePublisher_CreatesThenSkipsUnchanged(t *testing.T) {
+	fake := &fakeConfluence{pages: map[string]*confluencePage{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	state := &memoryState{}
+	pub, err := NewConfluencePublisher(ConfluenceOptions{BaseURL: srv.URL + "/wiki/", SpaceKey: "DOC", ParentPageID: "1", User: "me@example.com", APIToken: "secret", TitlePrefix: "svc: "}, state, srv.Client())
+	require.NoError(t, err)
+
+	report, err := pub.Publish(context.Background(), testModel())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"overview", "arch"}, report.Created, "parents are published first")
+	overview := fake.pages[state.pages[TargetConfluence]["overview"].PageID]
+	arch := fake.pages[state.pages[TargetConfluence]["arch"].PageID]
+	assert.Equal(t, "svc: Overview", overview.Title)
+	assert.Equal(t, "1", overview.Ancestors[0].ID)
+	assert.Equal(t, overview.ID, arch.Ancestors[0].ID)
+	assert.Equal(t, "<p>The <code>Engine</code> wires <strong>everything</strong>.</p>", arch.Body.Storage.Value)
+	assert.Contains(t, overview.Body.Storage.Value, `<ac:parameter ac:name="language">go</ac:parameter>`)
+
+	fake.calls = nil
+	report, err = pub.Publish(context.Background(), testModel())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"overview", "arch"}, report.Unchanged)
+	assert.Empty(t, fake.calls, "unchanged sections make no API calls")
+
+	model := testModel()
+	model.Sections[0].ContentMD, model.Sections[0].Hash = "Rewritten.", "h-arch-2"
+	report, err = pub.Publish(context.Background(), model)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"arch"}, report.Updated)
+	arch = fake.pages[arch.ID]
+	assert.Equal(t, "<p>Rewritten.</p>", arch.Body.Storage.Value)
+	assert.Equal(t, 2, arch.Version.Number)
+}
+
+func TestConfluencePublisher_AdoptsAndRecreatesPages(t *testing.T) {
+	existing := &confluencePage{ID: "42", Title: "Overview", Version: &confluenceVersion{Number: 7}}
+	fake := &fakeConfluence{pages: map[string]*confluencePage{"42": existing}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	state := &memoryState{}
+	require.NoError(t, state.SavePublishedPage(context.Background(), TargetConfluence, PageRecord{SectionID: "arch", PageID: "999", Hash: "old"}))
+	pub, err := NewConfluencePublisher(ConfluenceOptions{BaseURL: srv.URL + "/wiki", SpaceKey: "DOC", User: "me@example.com", APIToken: "secret"}, state, srv.Client())
+	require.NoError(t, err)
+
+	report, err := pub.Publish(context.Background(), testModel())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"overview"}, report.Updated, "a page with the same title is adopted")
+	assert.Equal(t, 8, fake.pages["42"].Version.Number)
+	assert.Equal(t, []string{"arch"}, report.Created, "a page deleted in Confluence is created again")
+	assert.NotEqual(t, "999", state.pages[TargetConfluence]["arch"].PageID)
+}
+
+func TestNewConfluencePublisher_RequiresSettings(t *testing.T) {
+	_, err := NewConfluencePublisher(ConfluenceOptions{BaseURL: "https://x", APIToken: "t"}, &memoryState{}, nil)
+	assert.ErrorContains(t, err, "space_key")
+}
+
+func TestConfluenceStorage(t *testing.T) {
+	md := "Intro with [docs](https://x.dev?a=1&b=2).\n\n- one\n- *two*\n\n1. first\n2. second\n\n| A | B |\n|---|---|\n| 1 | <2> |\n\n> note\n\n```mermaid\ngraph TD\n  A-->B\n```"
+	got := confluenceStorage(md)
+	assert.Contains(t, got, `<a href="https://x.dev?a=1&amp;b=2">docs</a>`)
+	assert.Contains(t, got, "<ul><li>one</li><li><em>two</em></li></ul>")
+	assert.Contains(t, got, "<ol><li>first</li><li>second</li></ol>")
+	assert.Contains(t, got, "<tr><th>A</th><th>B</th></tr><tr><td>1</td><td>&lt;2&gt;</td></tr>")
+	assert.Contains(t, got, "<blockquote><p>note</p></blockquote>")
+	assert.Contains(t, got, `<ac:parameter ac:name="title">mermaid</ac:parameter><ac:plain-text-body><![CDATA[graph TD
+  A-->B]]>`)
+}
@@ -0,0 +1,170 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	// DefaultGitHubAPIURL is the REST API root of github.com.
+	DefaultGitHubAPIURL = "https://api.github.com"
+	// DefaultCommentMarker tags the comment docod owns on a pull request,
+	// so later runs edit it instead of adding another.
+	DefaultCommentMarker = "<!-- docod:doc-drift -->"
+
+	githubAPIVersion = "2022-11-28"
+	githubPageSize   = 100
+)
+
+// GitHubCommentOptions locates the pull request to comment on.
+type GitHubCommentOptions struct {
+	// BaseURL defaults to DefaultGitHubAPIURL; GitHub Enterprise runners
+	// set GITHUB_API_URL.
+	BaseURL string
+	Token   string
+	// Repo is owner/name.
+	Repo string
+	// Number is the pull request (issue) number.
+	Number int
+	// Marker defaults to DefaultCommentMarker.
+	Marker string
+}
+
+// GitHubCommenter keeps one comment of a pull request up to date.
+type GitHubCommenter struct {
+	opts   GitHubCommentOptions
+	client *http.Client
+}
+
+type githubComment struct {
+	ID      int64  `json:"id"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+}
+
+// NewGitHubCommenter returns a commenter for opts. A nil client uses
+// http.DefaultClient.
+func NewGitHubCommenter(opts GitHubCommentOptions, client *http.Client) (*GitHubCommenter, error) {
+	opts.BaseURL = strings.TrimRight(strings.TrimSpace(opts.BaseURL), "/")
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultGitHubAPIURL
+	}
+	if opts.Marker == "" {
+		opts.Marker = DefaultCommentMarker
+	}
+	switch {
+	case opts.Token == "":
+		return nil, fmt.Errorf("github: token is required (GITHUB_TOKEN)")
+	case !strings.Contains(opts.Repo, "/"):
+		return nil, fmt.Errorf("github: repository must be owner/name, got %q", opts.Repo)
+	case opts.Number <= 0:
+		return nil, fmt.Errorf("github: pull request number is required")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &GitHubCommenter{opts: opts, client: client}, nil
+}
+
+// Upsert edits the marked comment to body, or creates it on the first run,
+// and returns the comment's URL.
+func (c *GitHubCommenter) Upsert(ctx context.Context, body string) (string, error) {
+	body = c.opts.Marker + "\n" + body
+	existing, err := c.findComment(ctx)
+	if err != nil {
+		return "", err
+	}
+	var out githubComment
+	if existing != nil {
+		err = c.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/issues/comments/%d", c.opts.Repo, existing.ID), map[string]string{"body": body}, &out)
+	} else {
+		err = c.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", c.opts.Repo, c.opts.Number), map[string]string{"body": body}, &out)
+	}
+	if err != nil {
+		return "", err
+	}
+	return out.HTMLURL, nil
+}
+
+func (c *GitHubCommenter) findComment(ctx context.Context) (*githubComment, error) {
+	for page := 1; ; page++ {
+		var comments []githubComment
+		path := fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=%d&page=%d", c.opts.Repo, c.opts.Number, githubPageSize, page)
+		if err := c.do(ctx, http.MethodGet, path, nil, &comments); err != nil {
+			return nil, err
+		}
+		for i := range comments {
+			if strings.Contains(comments[i].Body, c.opts.Marker) {
+				return &comments[i], nil
+			}
+		}
+		if len(comments) < githubPageSize {
+			return nil, nil
+		}
+	}
+}
+
+func (c *GitHubCommenter) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.opts.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", githubAPIVersion)
+	req.Header.Set("Authorization", "Bearer "+c.opts.Token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("github: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("github: %s %s: HTTP %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("github: decode response: %w", err)
+	}
+	return nil
+}
+
+// PullRequestFromEvent reads the pull request number from a GitHub Actions
+// event payload (GITHUB_EVENT_PATH). It returns 0 when the event is not
+// about a pull request.
+func PullRequestFromEvent(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var event struct {
+		PullRequest *struct {
+			Number int `json:"number"`
+		} `json:"pull_request"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return 0, fmt.Errorf("github: parse event %s: %w", path, err)
+	}
+	if event.PullRequest == nil {
+		return 0, nil
+	}
+	return event.PullRequest.Number, nil
+}
@@ -0,0 +1,109 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitHub serves the issue comment endpoints of one pull request.
+type fakeGitHub struct {
+	mu       sync.Mutex
+	comments []githubComment
+	calls    []string
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, r.Method+" "+r.URL.Path)
+	if r.Header.Get("Authorization") != "Bearer tok" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var in struct {
+		Body string `json:"body"`
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/repos/acme/app/issues/7/comments":
+		page := f.comments
+		if r.URL.Query().Get("page") != "1" {
+			page = nil
+		}
+		json.NewEncoder(w).Encode(page)
+	case r.Method == http.MethodPost && r.URL.Path == "/repos/acme/app/issues/7/comments":
+		json.NewDecoder(r.Body).Decode(&in)
+		c := githubComment{ID: int64(100 + len(f.comments)), Body: in.Body}
+		c.HTMLURL = fmt.Sprintf("https://github.com/acme/app/pull/7#issuecomment-%d", c.ID)
+		f.comments = append(f.comments, c)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(c)
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/repos/acme/app/issues/comments/"):
+		json.NewDecoder(r.Body).Decode(&in)
+		for i := range f.comments {
+			if fmt.Sprint(f.comments[i].ID) == strings.TrimPrefix(r.URL.Path, "/repos/acme/app/issues/comments/") {
+				f.comments[i].Body = in.Body
+				json.NewEncoder(w).Encode(f.comments[i])
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestGitHubCommenter_Upsert(t *testing.T) {
+	fake := &fakeGitHub{comments: []githubComment{{ID: 100, Body: "LGTM"}}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	c, err := NewGitHubCommenter(GitHubCommentOptions{BaseURL: srv.URL, Token: "tok", Repo: "acme/app", Number: 7}, srv.Client())
+	require.NoError(t, err)
+
+	url, err := c.Upsert(context.Background(), "first report")
+	require.NoError(t, err)
+	assert.Contains(t, url, "#issuecomment-")
+	require.Len(t, fake.comments, 2)
+
+	_, err = c.Upsert(context.Background(), "second report")
+	require.NoError(t, err)
+	require.Len(t, fake.comments, 2, "the marked comment is edited, not duplicated")
+	assert.Equal(t, DefaultCommentMarker+"\nsecond report", fake.comments[1].Body)
+	assert.Equal(t, "LGTM", fake.comments[0].Body)
+	assert.Contains(t, fake.calls, "PATCH /repos/acme/app/issues/comments/101")
+}
+
+func TestNewGitHubCommenter_Validates(t *testing.T) {
+	_, err := NewGitHubCommenter(GitHubCommentOptions{Repo: "acme/app", Number: 1}, nil)
+	assert.ErrorContains(t, err, "token")
+	_, err = NewGitHubCommenter(GitHubCommentOptions{Token: "t", Repo: "app", Number: 1}, nil)
+	assert.ErrorContains(t, err, "owner/name")
+	_, err = NewGitHubCommenter(GitHubCommentOptions{Token: "t", Repo: "acme/app"}, nil)
+	assert.ErrorContains(t, err, "number")
+}
+
+func TestPullRequestFromEvent(t *testing.T) {
+	dir := t.TempDir()
+	pr := filepath.Join(dir, "pr.json")
+	require.NoError(t, os.WriteFile(pr, []byte(`{"action":"synchronize","pull_request":{"number":42}}`), 0o644))
+	n, err := PullRequestFromEvent(pr)
+	require.NoError(t, err)
+	assert.Equal(t, 42, n)
+
+	push := filepath.Join(dir, "push.json")
+	require.NoError(t, os.WriteFile(push, []byte(`{"ref":"refs/heads/main"}`), 0o644))
+	n, err = PullRequestFromEvent(push)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
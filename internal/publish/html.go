@@ -0,0 +1,287 @@
+package publish
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"docod/internal/generator"
+)
+
+// DefaultMermaidScript is the Mermaid ES module the HTML export loads to
+// render diagrams in the browser.
+const DefaultMermaidScript = "https://cdn.jsdelivr.net/npm/mermaid@11/dist/mermaid.esm.min.mjs"
+
+var anchorPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// HTMLOptions controls the standalone HTML rendition of a doc model.
+type HTMLOptions struct {
+	// Title defaults to the document title.
+	Title string
+	// Root is the project root; absolute source paths are made relative to
+	// it before they are linked.
+	Root string
+	// SourceBaseURL prefixes source links, e.g.
+	// https://github.com/owner/repo/blob/main. Links to a hosted source get
+	// a #L<start>-L<end> fragment; without a base they are plain relative
+	// paths.
+	SourceBaseURL string
+	// MermaidScript defaults to DefaultMermaidScript; point it at a local
+	// copy to view diagrams offline.
+	MermaidScript string
+}
+
+// tocEntry is one line of the table of contents.
+type tocEntry struct {
+	ID    string
+	Text  string
+	Level int
+}
+
+// htmlRenderer renders the sections of one document, handing out unique
+// anchors and collecting the table of contents as it goes.
+type htmlRenderer struct {
+	opts    HTMLOptions
+	anchors map[string]bool
+	toc     []tocEntry
+	mermaid bool
+}
+
+// RenderHTML renders model as a single standalone HTML page: a table of
+// contents, every section with anchors, source links after each section,
+// and Mermaid fences drawn as diagrams.
+func RenderHTML(model *generator.DocModel, opts HTMLOptions) string {
+	generator.NormalizeDocModel(model)
+	if opts.MermaidScript == "" {
+		opts.MermaidScript = DefaultMermaidScript
+	}
+	title := strings.TrimSpace(opts.Title)
+	if title == "" {
+		title = strings.TrimSpace(model.Document.Title)
+	}
+	if title == "" {
+		title = "Project Documentation"
+	}
+
+	sections := append([]generator.ModelSect(nil), model.Sections...)
+	sort.SliceStable(sections, func(i, j int) bool {
+		if sections[i].Order != sections[j].Order {
+			return sections[i].Order < sections[j].Order
+		}
+		return sections[i].ID < sections[j].ID
+	})
+	r := &htmlRenderer{opts: opts, anchors: make(map[string]bool)}
+	// Section IDs come first so "#<section id>" links keep working.
+	for _, sec := range sections {
+		r.anchors[sec.ID] = true
+	}
+	var body strings.Builder
+	for _, sec := range sections {
+		if strings.TrimSpace(sec.ContentMD) == "" {
+			continue
+		}
+		r.section(&body, sec)
+	}
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n")
+	b.WriteString("<meta name=\"generator\" content=\"docod\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n<style>%s</style>\n</head>\n<body>\n", html.EscapeString(title), htmlStyle)
+	b.WriteString("<nav class=\"toc\">\n<h2>Contents</h2>\n<ul>\n")
+	for _, e := range r.toc {
+		fmt.Fprintf(&b, "<li class=\"toc-%d\"><a href=\"#%s\">%s</a></li>\n", e.Level, html.EscapeString(e.ID), html.EscapeString(e.Text))
+	}
+	b.WriteString("</ul>\n</nav>\n<main>\n")
+	fmt.Fprintf(&b, "<h1 class=\"doc-title\">%s</h1>\n", html.EscapeString(title))
+	if at := strings.TrimSpace(model.Meta.GeneratedAt); at != "" {
+		fmt.Fprintf(&b, "<p class=\"meta\">Auto-generated by <code>docod</code> at %s.</p>\n", html.EscapeString(at))
+	}
+	b.WriteString(body.String())
+	b.WriteString("</main>\n")
+	if r.mermaid {
+		fmt.Fprintf(&b, "<script type=\"module\">\nimport mermaid from %q;\nmermaid.initialize({ startOnLoad: true });\n</script>\n", opts.MermaidScript)
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+func (r *htmlRenderer) section(b *strings.Builder, sec generator.ModelSect) {
+	level := sec.Level + 1 // the page title is the only h1
+	if level > 6 {
+		level = 6
+	}
+	b.WriteString("<section>\n")
+	r.heading(b, level, sec.Title, sec.ID)
+	for _, blk := range parseBlocks(pageBody(sec)) {
+		switch blk.Kind {
+		case blockHeading:
+			lvl := blk.Level + 1
+			if lvl <= level {
+				lvl = level + 1
+			}
+			if lvl > 6 {
+				lvl = 6
+			}
+			r.heading(b, lvl, blk.Text, "")
+		case blockCode:
+			if strings.EqualFold(blk.Lang, "mermaid") {
+				r.mermaid = true
+				fmt.Fprintf(b, "<pre class=\"mermaid\">%s</pre>\n", html.EscapeString(blk.Text))
+				continue
+			}
+			class := ""
+			if blk.Lang != "" {
+				class = fmt.Sprintf(" class=\"language-%s\"", html.EscapeString(blk.Lang))
+			}
+			fmt.Fprintf(b, "<pre><code%s>%s</code></pre>\n", class, html.EscapeString(blk.Text))
+		case blockBullet, blockNumbered:
+			tag := "ul"
+			if blk.Kind == blockNumbered {
+				tag = "ol"
+			}
+			b.WriteString("<" + tag + ">\n")
+			for _, item := range blk.Items {
+				b.WriteString("<li>" + storageInline(item) + "</li>\n")
+			}
+			b.WriteString("</" + tag + ">\n")
+		case blockQuote:
+			b.WriteString("<blockquote><p>" + storageInline(blk.Text) + "</p></blockquote>\n")
+		case blockTable:
+			b.WriteString("<table>\n")
+			for i, row := range blk.Rows {
+				cell := "td"
+				if i == 0 {
+					cell = "th"
+				}
+				b.WriteString("<tr>")
+				for _, c := range row {
+					b.WriteString("<" + cell + ">" + storageInline(c) + "</" + cell + ">")
+				}
+				b.WriteString("</tr>\n")
+			}
+			b.WriteString("</table>\n")
+		case blockRule:
+			b.WriteString("<hr>\n")
+		default:
+			b.WriteString("<p>" + storageInline(blk.Text) + "</p>\n")
+		}
+	}
+	r.sources(b, sec.Sources)
+	b.WriteString("</section>\n")
+}
+
+// heading writes a heading with an anchor link. An empty id is derived from
+// the text; headings down to h4 enter the table of contents.
+func (r *htmlRenderer) heading(b *strings.Builder, level int, text, id string) {
+	if id == "" {
+		id = r.anchor(text)
+	}
+	if level <= 4 {
+		r.toc = append(r.toc, tocEntry{ID: id, Text: text, Level: level - 1})
+	}
+	fmt.Fprintf(b, "<h%d id=\"%s\">%s <a class=\"anchor\" href=\"#%s\">#</a></h%d>\n",
+		level, html.EscapeString(id), storageInline(text), html.EscapeString(id), level)
+}
+
+// anchor returns a unique anchor for a heading, GitHub style: lower case,
+// dashes for everything else, -2, -3… for repeats.
+func (r *htmlRenderer) anchor(text string) string {
+	base := strings.Trim(anchorPattern.ReplaceAllString(strings.ToLower(text), "-"), "-")
+	if base == "" {
+		base = "section"
+	}
+	id := base
+	for n := 2; r.anchors[id]; n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	r.anchors[id] = true
+	return id
+}
+
+func (r *htmlRenderer) sources(b *strings.Builder, sources []generator.SourceRef) {
+	seen := make(map[string]bool)
+	var links []string
+	for _, src := range sources {
+		path := r.relative(src.FilePath)
+		if path == "" {
+			continue
+		}
+		label := path
+		if src.StartLine > 0 {
+			label = fmt.Sprintf("%s:%d", path, src.StartLine)
+		}
+		if seen[label] {
+			continue
+		}
+		seen[label] = true
+		links = append(links, fmt.Sprintf("<a href=\"%s\"><code>%s</code></a>", html.EscapeString(r.sourceURL(path, src)), html.EscapeString(label)))
+	}
+	if len(links) == 0 {
+		return
+	}
+	sort.Strings(links)
+	b.WriteString("<p class=\"sources\">Sources: " + strings.Join(links, ", ") + "</p>\n")
+}
+
+func (r *htmlRenderer) sourceURL(path string, src generator.SourceRef) string {
+	return SourceURL(r.opts.SourceBaseURL, path, src.StartLine, src.EndLine)
+}
+
+// SourceURL links lines start..end of path under base the way
+// HTMLOptions.SourceBaseURL describes.
+func SourceURL(base, path string, start, end int) string {
+	base = strings.TrimRight(base, "/")
+	if base == "" {
+		return path
+	}
+	url := base + "/" + path
+	if strings.Contains(base, "://") && start > 0 {
+		url += fmt.Sprintf("#L%d", start)
+		if end > start {
+			url += fmt.Sprintf("-L%d", end)
+		}
+	}
+	return url
+}
+
+func (r *htmlRenderer) relative(path string) string {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return ""
+	}
+	if filepath.IsAbs(path) && r.opts.Root != "" {
+		if root, err := filepath.Abs(r.opts.Root); err == nil {
+			if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
+
+const htmlStyle = `
+body{margin:0;font:16px/1.6 -apple-system,BlinkMacSystemFont,"Segoe UI",Helvetica,Arial,sans-serif;color:#1f2328;display:flex}
+nav.toc{position:sticky;top:0;align-self:flex-start;width:18rem;max-height:100vh;overflow:auto;padding:1rem;border-right:1px solid #d0d7de;box-sizing:border-box;font-size:14px}
+nav.toc h2{font-size:1rem;margin-top:0}
+nav.toc ul{list-style:none;padding:0;margin:0}
+nav.toc a{color:#1f2328;text-decoration:none}
+nav.toc a:hover{text-decoration:underline}
+.toc-2{padding-left:1rem}.toc-3{padding-left:2rem}
+main{flex:1;max-width:56rem;padding:1rem 2rem 4rem}
+a{color:#0969da}
+a.anchor{visibility:hidden;text-decoration:none;color:#8c959f}
+h2:hover a.anchor,h3:hover a.anchor,h4:hover a.anchor,h5:hover a.anchor,h6:hover a.anchor{visibility:visible}
+code{font-family:ui-monospace,SFMono-Regular,Menlo,monospace;font-size:85%;background:#f6f8fa;padding:.1em .3em;border-radius:4px}
+pre{background:#f6f8fa;padding:1rem;overflow:auto;border-radius:6px}
+pre code{background:none;padding:0}
+pre.mermaid{background:none;text-align:center}
+table{border-collapse:collapse;margin:1rem 0}
+th,td{border:1px solid #d0d7de;padding:.3rem .7rem;text-align:left}
+blockquote{margin:0;padding:0 1rem;color:#59636e;border-left:.25em solid #d0d7de}
+.meta,.sources{color:#59636e;font-size:14px}
+@media (max-width:800px){body{display:block}nav.toc{position:static;width:auto;max-height:none;border-right:none;border-bottom:1px solid #d0d7de}}
+`
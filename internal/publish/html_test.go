@@ -0,0 +1,45 @@
+package publish
+
+import (
+	"testing"
+
+	"docod/internal/generator"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func htmlModel() *generator.DocModel {
+	return &generator.DocModel{
+		Document: generator.ModelDoc{Title: "Demo <docs>"},
+		Policies: generator.ModelPolicy{RequiredSectionIDs: []string{"overview", "architecture"}},
+		Sections: []generator.ModelSect{
+			{ID: "overview", Title: "Overview", Level: 1, Order: 0, ContentMD: "# Overview\n\nSee [architecture](#architecture).\n\n## Usage\n\nRun it.\n\n## Usage\n\nAgain.",
+				Sources: []generator.SourceRef{{FilePath: "/repo/cmd/main.go", StartLine: 10, EndLine: 20}, {FilePath: "/repo/cmd/main.go", StartLine: 10}}},
+			{ID: "architecture", Title: "Architecture", Level: 1, Order: 1, ContentMD: "Flow:\n\n```mermaid\ngraph TD\n  A-->B\n```\n\n| A | B |\n|---|---|\n| `x` | y |"},
+		},
+	}
+}
+
+func TestRenderHTML(t *testing.T) {
+	page := RenderHTML(htmlModel(), HTMLOptions{Root: "/repo", SourceBaseURL: "https://github.com/o/r/blob/main/"})
+
+	assert.Contains(t, page, "<title>Demo &lt;docs&gt;</title>")
+	assert.Contains(t, page, "<li class=\"toc-1\"><a href=\"#overview\">Overview</a></li>\n<li class=\"toc-2\"><a href=\"#usage\">Usage</a></li>\n<li class=\"toc-2\"><a href=\"#usage-2\">Usage</a></li>\n<li class=\"toc-1\"><a href=\"#architecture\">Architecture</a></li>")
+	assert.Contains(t, page, "<h2 id=\"overview\">Overview <a class=\"anchor\" href=\"#overview\">#</a></h2>")
+	assert.Contains(t, page, "<h3 id=\"usage-2\">Usage")
+	assert.Contains(t, page, `<p>See <a href="#architecture">architecture</a>.</p>`)
+	assert.Contains(t, page, `<p class="sources">Sources: <a href="https://github.com/o/r/blob/main/cmd/main.go#L10-L20"><code>cmd/main.go:10</code></a></p>`,
+		"sources are relative to the root and deduplicated")
+	assert.Contains(t, page, "<pre class=\"mermaid\">graph TD\n  A--&gt;B</pre>")
+	assert.Contains(t, page, "import mermaid from \""+DefaultMermaidScript+"\";")
+	assert.Contains(t, page, "<tr><th>A</th><th>B</th></tr>\n<tr><td><code>x</code></td><td>y</td></tr>")
+}
+
+func TestRenderHTML_RelativeSourcesWithoutMermaid(t *testing.T) {
+	model := htmlModel()
+	model.Sections = model.Sections[:1]
+	page := RenderHTML(model, HTMLOptions{Root: "/repo", SourceBaseURL: ".."})
+
+	assert.Contains(t, page, `<a href="../cmd/main.go"><code>cmd/main.go:10</code></a>`)
+	assert.NotContains(t, page, "<script", "pages without diagrams load no script")
+}
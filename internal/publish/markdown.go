@@ -0,0 +1,166 @@
+package publish
+
+import (
+	"regexp"
+	"strings"
+)
+
+// blockKind is the kind of a markdown block understood by the publishers.
+type blockKind int
+
+const (
+	blockParagraph blockKind = iota
+	blockHeading
+	blockCode
+	blockBullet
+	blockNumbered
+	blockQuote
+	blockTable
+	blockRule
+)
+
+// block is one markdown block. Lists hold one item per entry in Items, and
+// tables one row per entry in Rows with the header first.
+type block struct {
+	Kind  blockKind
+	Level int    // heading level
+	Lang  string // code fence language
+	Text  string // paragraph, heading, quote or code text
+	Items []string
+	Rows  [][]string
+}
+
+var (
+	numberedItem = regexp.MustCompile(`^\d+[.)]\s+`)
+	tableDivider = regexp.MustCompile(`^\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?$`)
+)
+
+// parseBlocks splits the markdown the generator writes into blocks. It covers
+// headings, fences, flat lists, quotes, pipe tables and rules; anything else
+// is kept as paragraph text.
+func parseBlocks(md string) []block {
+	lines := strings.Split(strings.ReplaceAll(md, "\r\n", "\n"), "\n")
+	var blocks []block
+	var para []string
+	flush := func() {
+		if len(para) > 0 {
+			blocks = append(blocks, block{Kind: blockParagraph, Text: strings.Join(para, " ")})
+			para = nil
+		}
+	}
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			lang := strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, block{Kind: blockCode, Lang: lang, Text: strings.Join(code, "\n")})
+		case strings.HasPrefix(trimmed, "#"):
+			flush()
+			level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			if level > 6 || !strings.HasPrefix(trimmed[level:], " ") {
+				para = append(para, trimmed)
+				continue
+			}
+			blocks = append(blocks, block{Kind: blockHeading, Level: level, Text: strings.TrimSpace(trimmed[level:])})
+		case trimmed == "---" || trimmed == "***" || trimmed == "___":
+			flush()
+			blocks = append(blocks, block{Kind: blockRule})
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") || strings.HasPrefix(trimmed, "+ "):
+			flush()
+			b := block{Kind: blockBullet}
+			for ; i < len(lines) && isBullet(lines[i]); i++ {
+				b.Items = append(b.Items, strings.TrimSpace(strings.TrimSpace(lines[i])[2:]))
+			}
+			i--
+			blocks = append(blocks, b)
+		case numberedItem.MatchString(trimmed):
+			flush()
+			b := block{Kind: blockNumbered}
+			for ; i < len(lines) && numberedItem.MatchString(strings.TrimSpace(lines[i])); i++ {
+				b.Items = append(b.Items, numberedItem.ReplaceAllString(strings.TrimSpace(lines[i]), ""))
+			}
+			i--
+			blocks = append(blocks, b)
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			var quote []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				quote = append(quote, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")))
+			}
+			i--
+			blocks = append(blocks, block{Kind: blockQuote, Text: strings.Join(quote, " ")})
+		case strings.HasPrefix(trimmed, "|") && i+1 < len(lines) && tableDivider.MatchString(strings.TrimSpace(lines[i+1])):
+			flush()
+			b := block{Kind: blockTable, Rows: [][]string{tableCells(trimmed)}}
+			for i += 2; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				b.Rows = append(b.Rows, tableCells(strings.TrimSpace(lines[i])))
+			}
+			i--
+			blocks = append(blocks, b)
+		default:
+			para = append(para, trimmed)
+		}
+	}
+	flush()
+	return blocks
+}
+
+func isBullet(line string) bool {
+	t := strings.TrimSpace(line)
+	return strings.HasPrefix(t, "- ") || strings.HasPrefix(t, "* ") || strings.HasPrefix(t, "+ ")
+}
+
+func tableCells(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	cells := strings.Split(row, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// span is a run of inline text with one style.
+type span struct {
+	Text   string
+	Code   bool
+	Bold   bool
+	Italic bool
+	Link   string
+}
+
+var inlinePattern = regexp.MustCompile("`([^`]+)`|\\*\\*([^*]+)\\*\\*|\\[([^\\]]+)\\]\\(([^)\\s]+)\\)|\\*([^*\\s][^*]*)\\*")
+
+// parseInline splits text into code, bold, italic, link and plain spans.
+// Styles do not nest.
+func parseInline(text string) []span {
+	var spans []span
+	last := 0
+	for _, m := range inlinePattern.FindAllStringSubmatchIndex(text, -1) {
+		if m[0] > last {
+			spans = append(spans, span{Text: text[last:m[0]]})
+		}
+		switch {
+		case m[2] >= 0:
+			spans = append(spans, span{Text: text[m[2]:m[3]], Code: true})
+		case m[4] >= 0:
+			spans = append(spans, span{Text: text[m[4]:m[5]], Bold: true})
+		case m[6] >= 0:
+			spans = append(spans, span{Text: text[m[6]:m[7]], Link: text[m[8]:m[9]]})
+		default:
+			spans = append(spans, span{Text: text[m[10]:m[11]], Italic: true})
+		}
+		last = m[1]
+	}
+	if last < len(text) {
+		spans = append(spans, span{Text: text[last:]})
+	}
+	return spans
+}
@@ -0,0 +1,425 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"docod/internal/generator"
+)
+
+const (
+	// TargetNotion is the StateStore target name of Notion pages.
+	TargetNotion = "notion"
+
+	// DefaultNotionBaseURL is the Notion API root.
+	DefaultNotionBaseURL = "https://api.notion.com"
+	// DefaultDiagramImageURL renders a base64url-encoded Mermaid source as
+	// an image.
+	DefaultDiagramImageURL = "https://mermaid.ink/img/"
+
+	notionVersion = "2022-06-28"
+	// notionMaxBlocks is the most children one request may carry.
+	notionMaxBlocks = 100
+	// notionMaxText is the most characters one rich text object may hold.
+	notionMaxText = 2000
+	// notionMaxURL is the longest URL Notion accepts for links and images.
+	notionMaxURL  = 2000
+	notionRetries = 3
+)
+
+// NotionOptions locates where pages are published.
+type NotionOptions struct {
+	// BaseURL defaults to DefaultNotionBaseURL.
+	BaseURL string
+	// Token is the secret of an internal integration that has been shared
+	// with the database or parent page.
+	Token string
+	// DatabaseID makes root sections rows of a database; otherwise they are
+	// created under ParentPageID. Child sections are always subpages of
+	// their parent section's page.
+	DatabaseID   string
+	ParentPageID string
+	// TitleProperty is the database's title column; "Name" by default.
+	TitleProperty string
+	TitlePrefix   string
+	// DiagramImageURL is the prefix Mermaid sources are appended to,
+	// base64url-encoded, to embed diagrams as images. "none" keeps them as
+	// mermaid code blocks, which Notion renders itself.
+	DiagramImageURL string
+}
+
+// NotionPublisher publishes one page per section through the Notion API.
+type NotionPublisher struct {
+	opts   NotionOptions
+	state  StateStore
+	client *http.Client
+}
+
+// NewNotionPublisher returns a publisher for opts. A nil client uses
+// http.DefaultClient.
+func NewNotionPublisher(opts NotionOptions, state StateStore, client *http.Client) (*NotionPublisher, error) {
+	opts.BaseURL = strings.TrimRight(strings.TrimSpace(opts.BaseURL), "/")
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultNotionBaseURL
+	}
+	if opts.TitleProperty == "" {
+		opts.TitleProperty = "Name"
+	}
+	if opts.DiagramImageURL == "" {
+		opts.DiagramImageURL = DefaultDiagramImageURL
+	}
+	switch {
+	case opts.Token == "":
+		return nil, fmt.Errorf("notion: token is required")
+	case opts.DatabaseID == "" && opts.ParentPageID == "":
+		return nil, fmt.Errorf("notion: database_id or parent_page_id is required")
+	case state == nil:
+		return nil, fmt.Errorf("notion: state store is required")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &NotionPublisher{opts: opts, state: state, client: client}, nil
+}
+
+// notionError is a non-2xx API response.
+type notionError struct {
+	Status int
+	Body   string
+}
+
+func (e *notionError) Error() string {
+	return fmt.Sprintf("notion: HTTP %d: %s", e.Status, e.Body)
+}
+
+// Publish creates or updates the page of every section. Sections whose
+// rendition is unchanged since the last run are skipped without an API call.
+// Notion cannot move pages, so a section whose parent changed keeps its page
+// where it is and only gets new content.
+func (p *NotionPublisher) Publish(ctx context.Context, model *generator.DocModel) (*Report, error) {
+	records, err := p.state.PublishedPages(ctx, TargetNotion)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{}
+	pageOf := make(map[string]string, len(model.Sections))
+	for _, sec := range orderedSections(model) {
+		parent := ""
+		if sec.ParentID != nil {
+			parent = pageOf[*sec.ParentID]
+		}
+		title := p.opts.TitlePrefix + sec.Title
+		hash := renditionHash(sec, title, parent)
+		rec, known := records[sec.ID]
+		if known && rec.Hash == hash {
+			pageOf[sec.ID] = rec.PageID
+			report.Unchanged = append(report.Unchanged, sec.ID)
+			continue
+		}
+
+		blocks := p.notionBlocks(pageBody(sec))
+		pageID := ""
+		if known {
+			pageID = rec.PageID
+			err = p.updatePage(ctx, pageID, title, parent == "", blocks)
+			var apiErr *notionError
+			if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+				// The page was deleted or unshared; publish it again.
+				pageID, err = "", nil
+			}
+			if err != nil {
+				return report, fmt.Errorf("section %s: %w", sec.ID, err)
+			}
+		}
+		created := false
+		if pageID == "" {
+			if pageID, err = p.createPage(ctx, title, parent, blocks); err != nil {
+				return report, fmt.Errorf("section %s: %w", sec.ID, err)
+			}
+			created = true
+		}
+		if err := p.state.SavePublishedPage(ctx, TargetNotion, PageRecord{SectionID: sec.ID, PageID: pageID, Hash: hash}); err != nil {
+			return report, err
+		}
+		pageOf[sec.ID] = pageID
+		if created {
+			report.Created = append(report.Created, sec.ID)
+		} else {
+			report.Updated = append(report.Updated, sec.ID)
+		}
+	}
+	return report, nil
+}
+
+// createPage creates a page under parentPageID, or at the configured root
+// when it is empty, and appends the blocks that did not fit the request.
+func (p *NotionPublisher) createPage(ctx context.Context, title, parentPageID string, blocks []map[string]any) (string, error) {
+	inDatabase := parentPageID == "" && p.opts.DatabaseID != ""
+	page := map[string]any{"properties": p.titleProperty(title, inDatabase)}
+	switch {
+	case parentPageID != "":
+		page["parent"] = map[string]string{"page_id": parentPageID}
+	case inDatabase:
+		page["parent"] = map[string]string{"database_id": p.opts.DatabaseID}
+	default:
+		page["parent"] = map[string]string{"page_id": p.opts.ParentPageID}
+	}
+	first := blocks[:min(len(blocks), notionMaxBlocks)]
+	page["children"] = first
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := p.do(ctx, http.MethodPost, "/v1/pages", page, &created); err != nil {
+		return "", err
+	}
+	return created.ID, p.appendBlocks(ctx, created.ID, blocks[len(first):])
+}
+
+// updatePage renames the page and replaces its content. The API has no
+// content replace, so the old top-level blocks are deleted one by one.
+func (p *NotionPublisher) updatePage(ctx context.Context, id, title string, root bool, blocks []map[string]any) error {
+	props := map[string]any{"properties": p.titleProperty(title, root && p.opts.DatabaseID != "")}
+	if err := p.do(ctx, http.MethodPatch, "/v1/pages/"+url.PathEscape(id), props, nil); err != nil {
+		return err
+	}
+	var old []string
+	cursor := ""
+	for {
+		path := "/v1/blocks/" + url.PathEscape(id) + "/children?page_size=100"
+		if cursor != "" {
+			path += "&start_cursor=" + url.QueryEscape(cursor)
+		}
+		var page struct {
+			Results []struct {
+				ID string `json:"id"`
+			} `json:"results"`
+			HasMore    bool   `json:"has_more"`
+			NextCursor string `json:"next_cursor"`
+		}
+		if err := p.do(ctx, http.MethodGet, path, nil, &page); err != nil {
+			return err
+		}
+		for _, b := range page.Results {
+			old = append(old, b.ID)
+		}
+		if !page.HasMore || page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	for _, blockID := range old {
+		if err := p.do(ctx, http.MethodDelete, "/v1/blocks/"+url.PathEscape(blockID), nil, nil); err != nil {
+			return err
+		}
+	}
+	return p.appendBlocks(ctx, id, blocks)
+}
+
+func (p *NotionPublisher) appendBlocks(ctx context.Context, id string, blocks []map[string]any) error {
+	for len(blocks) > 0 {
+		n := min(len(blocks), notionMaxBlocks)
+		body := map[string]any{"children": blocks[:n]}
+		if err := p.do(ctx, http.MethodPatch, "/v1/blocks/"+url.PathEscape(id)+"/children", body, nil); err != nil {
+			return err
+		}
+		blocks = blocks[n:]
+	}
+	return nil
+}
+
+// titleProperty sets the page title. Database rows use the database's title
+// column; plain pages always call it "title".
+func (p *NotionPublisher) titleProperty(title string, inDatabase bool) map[string]any {
+	name := "title"
+	if inDatabase {
+		name = p.opts.TitleProperty
+	}
+	return map[string]any{name: map[string]any{"title": notionText(title)}}
+}
+
+// do sends one API request, waiting out rate limits as Retry-After asks.
+func (p *NotionPublisher) do(ctx context.Context, method, path string, in, out any) error {
+	var data []byte
+	if in != nil {
+		var err error
+		if data, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, p.opts.BaseURL+path, bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+p.opts.Token)
+		req.Header.Set("Notion-Version", notionVersion)
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("notion: %w", err)
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < notionRetries {
+			resp.Body.Close()
+			delay := time.Second
+			if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				delay = time.Duration(secs) * time.Second
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			continue
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return &notionError{Status: resp.StatusCode, Body: strings.TrimSpace(string(msg))}
+		}
+		if out == nil {
+			return nil
+		}
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("notion: decode response: %w", err)
+		}
+		return nil
+	}
+}
+
+// notionLanguages maps fence languages to Notion code block languages;
+// others are shown as plain text.
+var notionLanguages = map[string]string{
+	"go": "go", "bash": "bash", "sh": "shell", "shell": "shell", "json": "json",
+	"yaml": "yaml", "yml": "yaml", "sql": "sql", "java": "java", "python": "python",
+	"py": "python", "js": "javascript", "javascript": "javascript", "ts": "typescript",
+	"typescript": "typescript", "xml": "xml", "html": "html", "diff": "diff",
+	"mermaid": "mermaid", "protobuf": "protobuf", "proto": "protobuf",
+}
+
+// notionBlocks renders markdown as Notion blocks. Mermaid fences become
+// images unless the options keep them as code.
+func (p *NotionPublisher) notionBlocks(md string) []map[string]any {
+	var blocks []map[string]any
+	add := func(kind string, value map[string]any) {
+		blocks = append(blocks, map[string]any{"object": "block", "type": kind, kind: value})
+	}
+	for _, blk := range parseBlocks(md) {
+		switch blk.Kind {
+		case blockHeading:
+			add(fmt.Sprintf("heading_%d", min(blk.Level, 3)), map[string]any{"rich_text": notionInline(blk.Text)})
+		case blockCode:
+			if strings.EqualFold(blk.Lang, "mermaid") {
+				if img := p.diagramURL(blk.Text); img != "" {
+					add("image", map[string]any{"type": "external", "external": map[string]string{"url": img}})
+					continue
+				}
+			}
+			lang, ok := notionLanguages[strings.ToLower(blk.Lang)]
+			if !ok {
+				lang = "plain text"
+			}
+			add("code", map[string]any{"rich_text": notionText(blk.Text), "language": lang})
+		case blockBullet, blockNumbered:
+			kind := "bulleted_list_item"
+			if blk.Kind == blockNumbered {
+				kind = "numbered_list_item"
+			}
+			for _, item := range blk.Items {
+				add(kind, map[string]any{"rich_text": notionInline(item)})
+			}
+		case blockQuote:
+			add("quote", map[string]any{"rich_text": notionInline(blk.Text)})
+		case blockTable:
+			width := 0
+			for _, row := range blk.Rows {
+				width = max(width, len(row))
+			}
+			var rows []map[string]any
+			for _, row := range blk.Rows {
+				cells := make([][]map[string]any, width)
+				for i := range cells {
+					cells[i] = []map[string]any{}
+					if i < len(row) {
+						cells[i] = notionInline(row[i])
+					}
+				}
+				rows = append(rows, map[string]any{"object": "block", "type": "table_row", "table_row": map[string]any{"cells": cells}})
+			}
+			add("table", map[string]any{"table_width": width, "has_column_header": true, "children": rows})
+		case blockRule:
+			add("divider", map[string]any{})
+		default:
+			add("paragraph", map[string]any{"rich_text": notionInline(blk.Text)})
+		}
+	}
+	if len(blocks) == 0 {
+		blocks = []map[string]any{}
+	}
+	return blocks
+}
+
+// diagramURL returns the image URL of a Mermaid source, or "" when diagrams
+// stay code or the URL would be too long for Notion.
+func (p *NotionPublisher) diagramURL(src string) string {
+	if p.opts.DiagramImageURL == "none" {
+		return ""
+	}
+	u := p.opts.DiagramImageURL + base64.URLEncoding.EncodeToString([]byte(src))
+	if len(u) > notionMaxURL {
+		return ""
+	}
+	return u
+}
+
+// notionInline converts inline markdown to rich text. Only absolute links
+// are kept; Notion rejects relative ones.
+func notionInline(text string) []map[string]any {
+	rich := []map[string]any{}
+	for _, s := range parseInline(text) {
+		for _, part := range notionText(s.Text) {
+			annotations := map[string]bool{}
+			if s.Code {
+				annotations["code"] = true
+			}
+			if s.Bold {
+				annotations["bold"] = true
+			}
+			if s.Italic {
+				annotations["italic"] = true
+			}
+			if len(annotations) > 0 {
+				part["annotations"] = annotations
+			}
+			if (strings.HasPrefix(s.Link, "http://") || strings.HasPrefix(s.Link, "https://")) && len(s.Link) <= notionMaxURL {
+				part["text"].(map[string]any)["link"] = map[string]string{"url": s.Link}
+			}
+			rich = append(rich, part)
+		}
+	}
+	return rich
+}
+
+// notionText splits plain text into rich text objects within Notion's
+// length limit.
+func notionText(text string) []map[string]any {
+	rich := []map[string]any{}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		n := min(len(runes), notionMaxText)
+		rich = append(rich, map[string]any{"type": "text", "text": map[string]any{"content": string(runes[:n])}})
+		runes = runes[n:]
+	}
+	return rich
+}
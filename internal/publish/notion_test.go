@@ -0,0 +1,174 @@
+package publish
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type notionPage struct {
+	Parent     map[string]string
+	Properties map[string]any
+	Blocks     []map[string]any
+}
+
+// fakeNotion keeps pages and their blocks in memory. The first request is
+// rate limited to exercise Retry-After.
+type fakeNotion struct {
+	mu          sync.Mutex
+	pages       map[string]*notionPage
+	owner       map[string]string // block ID -> page ID
+	calls       []string
+	rateLimited bool
+}
+
+func newFakeNotion() *fakeNotion {
+	return &fakeNotion{pages: map[string]*notionPage{}, owner: map[string]string{}}
+}
+
+func (f *fakeNotion) addBlocks(pageID string, blocks []map[string]any) {
+	for _, b := range blocks {
+		id := fmt.Sprintf("b%d", len(f.owner))
+		b["id"] = id
+		f.owner[id] = pageID
+		f.pages[pageID].Blocks = append(f.pages[pageID].Blocks, b)
+	}
+}
+
+func (f *fakeNotion) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.rateLimited {
+		f.rateLimited = true
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+	f.calls = append(f.calls, r.Method+" "+r.URL.Path)
+	if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("Notion-Version") == "" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var body struct {
+		Parent     map[string]string `json:"parent"`
+		Properties map[string]any    `json:"properties"`
+		Children   []map[string]any  `json:"children"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/"), "/")
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/v1/pages":
+		id := fmt.Sprintf("page-%d", len(f.pages)+1)
+		f.pages[id] = &notionPage{Parent: body.Parent, Properties: body.Properties}
+		f.addBlocks(id, body.Children)
+		_ = json.NewEncoder(w).Encode(map[string]string{"id": id})
+	case parts[0] == "pages" && f.pages[parts[1]] != nil && r.Method == http.MethodPatch:
+		f.pages[parts[1]].Properties = body.Properties
+		_, _ = w.Write([]byte(`{}`))
+	case parts[0] == "blocks" && len(parts) == 3 && f.pages[parts[1]] != nil && r.Method == http.MethodGet:
+		var results []map[string]any
+		for _, b := range f.pages[parts[1]].Blocks {
+			results = append(results, map[string]any{"id": b["id"]})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"results": results, "has_more": false})
+	case parts[0] == "blocks" && len(parts) == 3 && f.pages[parts[1]] != nil && r.Method == http.MethodPatch:
+		f.addBlocks(parts[1], body.Children)
+		_, _ = w.Write([]byte(`{}`))
+	case parts[0] == "blocks" && len(parts) == 2 && r.Method == http.MethodDelete && f.owner[parts[1]] != "":
+		page := f.pages[f.owner[parts[1]]]
+		for i, b := range page.Blocks {
+			if b["id"] == parts[1] {
+				page.Blocks = append(page.Blocks[:i], page.Blocks[i+1:]...)
+				break
+			}
+		}
+		_, _ = w.Write([]byte(`{}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestNotionPublisher_CreatesUpdatesAndSkips(t *testing.T) {
+	fake := newFakeNotion()
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	state := &memoryState{}
+	pub, err := NewNotionPublisher(NotionOptions{BaseURL: srv.URL, Token: "secret", DatabaseID: "db1"}, state, srv.Client())
+	require.NoError(t, err)
+
+	report, err := pub.Publish(context.Background(), testModel())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"overview", "arch"}, report.Created)
+	overviewID := state.pages[TargetNotion]["overview"].PageID
+	archID := state.pages[TargetNotion]["arch"].PageID
+	assert.Equal(t, map[string]string{"database_id": "db1"}, fake.pages[overviewID].Parent)
+	assert.Contains(t, fake.pages[overviewID].Properties, "Name", "database rows use the title column")
+	assert.Equal(t, map[string]string{"page_id": overviewID}, fake.pages[archID].Parent, "child sections are subpages")
+	assert.Contains(t, fake.pages[archID].Properties, "title")
+
+	fake.calls = nil
+	report, err = pub.Publish(context.Background(), testModel())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"overview", "arch"}, report.Unchanged)
+	assert.Empty(t, fake.calls)
+
+	model := testModel()
+	model.Sections[0].ContentMD, model.Sections[0].Hash = "Rewritten.\n\n---", "h-arch-2"
+	report, err = pub.Publish(context.Background(), model)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"arch"}, report.Updated)
+	blocks := fake.pages[archID].Blocks
+	require.Len(t, blocks, 2, "old blocks are replaced")
+	assert.Equal(t, "paragraph", blocks[0]["type"])
+	assert.Equal(t, "divider", blocks[1]["type"])
+
+	delete(fake.pages, archID)
+	model.Sections[0].Hash = "h-arch-3"
+	report, err = pub.Publish(context.Background(), model)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"arch"}, report.Created, "a deleted page is created again")
+}
+
+func TestNewNotionPublisher_RequiresParent(t *testing.T) {
+	_, err := NewNotionPublisher(NotionOptions{Token: "t"}, &memoryState{}, nil)
+	assert.ErrorContains(t, err, "database_id or parent_page_id")
+}
+
+func TestNotionBlocks(t *testing.T) {
+	pub, err := NewNotionPublisher(NotionOptions{Token: "t", ParentPageID: "p"}, &memoryState{}, nil)
+	require.NoError(t, err)
+	md := "#### Deep\n\nSee [docs](https://x.dev) and [local](guide.md), **bold** `code`.\n\n1. one\n\n| A | B |\n|---|---|\n| 1 |\n\n```mermaid\ngraph TD\n```\n\n```text\n" + strings.Repeat("x", 2500) + "\n```"
+	data, err := json.Marshal(pub.notionBlocks(md))
+	require.NoError(t, err)
+	var blocks []map[string]any
+	require.NoError(t, json.Unmarshal(data, &blocks))
+	require.Len(t, blocks, 6)
+
+	assert.Equal(t, "heading_3", blocks[0]["type"])
+	rich := blocks[1]["paragraph"].(map[string]any)["rich_text"].([]any)
+	assert.Equal(t, map[string]any{"url": "https://x.dev"}, rich[1].(map[string]any)["text"].(map[string]any)["link"])
+	assert.NotContains(t, rich[3].(map[string]any)["text"], "link", "relative links are dropped")
+	assert.Equal(t, map[string]any{"bold": true}, rich[5].(map[string]any)["annotations"])
+	assert.Equal(t, "numbered_list_item", blocks[2]["type"])
+
+	table := blocks[3]["table"].(map[string]any)
+	assert.EqualValues(t, 2, table["table_width"])
+	row := table["children"].([]any)[1].(map[string]any)["table_row"].(map[string]any)
+	assert.Len(t, row["cells"], 2, "short rows are padded")
+
+	image := blocks[4]["image"].(map[string]any)["external"].(map[string]any)
+	assert.Equal(t, DefaultDiagramImageURL+base64.URLEncoding.EncodeToString([]byte("graph TD")), image["url"])
+
+	code := blocks[5]["code"].(map[string]any)
+	assert.Equal(t, "plain text", code["language"])
+	assert.Len(t, code["rich_text"], 2, "long text is split")
+}
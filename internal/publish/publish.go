@@ -0,0 +1,73 @@
+// Package publish pushes the generated DocModel to external documentation
+// systems. Each target keeps the page it created for every section in a
+// StateStore, so republishing only touches sections whose content changed.
+package publish
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"docod/internal/generator"
+)
+
+// PageRecord maps a section to the page published for it. Hash identifies the
+// published rendition; see renditionHash.
+type PageRecord struct {
+	SectionID string
+	PageID    string
+	Hash      string
+}
+
+// StateStore remembers published pages per target; *storage.SQLiteStore
+// implements it.
+type StateStore interface {
+	PublishedPages(ctx context.Context, target string) (map[string]PageRecord, error)
+	SavePublishedPage(ctx context.Context, target string, rec PageRecord) error
+}
+
+// Report lists the section IDs a publish run touched.
+type Report struct {
+	Created   []string
+	Updated   []string
+	Unchanged []string
+}
+
+// Publisher pushes a doc model to one target.
+type Publisher interface {
+	Publish(ctx context.Context, model *generator.DocModel) (*Report, error)
+}
+
+// orderedSections returns the sections parents first, then by order, so a
+// child page can be placed under its parent's page.
+func orderedSections(model *generator.DocModel) []generator.ModelSect {
+	sections := append([]generator.ModelSect(nil), model.Sections...)
+	sort.SliceStable(sections, func(i, j int) bool {
+		if sections[i].Level != sections[j].Level {
+			return sections[i].Level < sections[j].Level
+		}
+		return sections[i].Order < sections[j].Order
+	})
+	return sections
+}
+
+// renditionHash changes whenever the page for sec would look different: the
+// section content (its hash covers title, body and sources), the page title
+// or the page it is placed under.
+func renditionHash(sec generator.ModelSect, title, parentPageID string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{sec.Hash, title, parentPageID}, "\n")))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// pageBody drops a leading heading that repeats the section title, since
+// the page title shows it already.
+func pageBody(sec generator.ModelSect) string {
+	body := strings.TrimSpace(sec.ContentMD)
+	first, rest, _ := strings.Cut(body, "\n")
+	if strings.HasPrefix(first, "#") && strings.EqualFold(strings.TrimSpace(strings.TrimLeft(first, "#")), strings.TrimSpace(sec.Title)) {
+		return strings.TrimSpace(rest)
+	}
+	return body
+}
@@ -0,0 +1,179 @@
+package resolver
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"docod/internal/graph"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// CallGraphResolver builds a whole-module call graph with go/packages + SSA
+// (class hierarchy analysis) and adds precise `calls` edges. It loads and
+// type-checks every package under Dir, so it is opt-in per run.
+type CallGraphResolver struct {
+	Dir string
+}
+
+func NewCallGraphResolver(dir string) *CallGraphResolver {
+	if strings.TrimSpace(dir) == "" {
+		dir = "."
+	}
+	return &CallGraphResolver{Dir: dir}
+}
+
+func (r *CallGraphResolver) Name() string {
+	return "callgraph"
+}
+
+func (r *CallGraphResolver) Resolve(g *graph.Graph) (ResolveStats, error) {
+	stats := ResolveStats{}
+	if g == nil || len(g.Nodes) == 0 {
+		return stats, nil
+	}
+
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedTypes | packages.NeedTypesInfo | packages.NeedDeps | packages.NeedImports,
+		Dir: r.Dir,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return stats, fmt.Errorf("failed to load packages: %w", err)
+	}
+	if len(pkgs) == 0 {
+		return stats, nil
+	}
+
+	prog, _ := ssautil.AllPackages(pkgs, ssa.InstantiateGenerics)
+	prog.Build()
+	cg := cha.CallGraph(prog)
+	cg.DeleteSyntheticNodes()
+
+	funcIdx := buildFuncIndex(g)
+	edgeSet := make(map[string]bool, len(g.Edges))
+	for _, e := range g.Edges {
+		edgeSet[edgeKey(e.From, e.To, e.Kind)] = true
+	}
+	resolvedTargets := make(map[string]map[string]bool)
+
+	err = callgraph.GraphVisitEdges(cg, func(edge *callgraph.Edge) error {
+		if edge.Caller == nil || edge.Callee == nil || edge.Site == nil {
+			return nil
+		}
+		stats.Attempted++
+		fromID := funcIdx.lookup(prog.Fset, edge.Caller.Func)
+		toID := funcIdx.lookup(prog.Fset, edge.Callee.Func)
+		if fromID == "" || toID == "" || fromID == toID {
+			stats.Skipped++
+			return nil
+		}
+
+		confidence := 0.97
+		if edge.Site.Common().IsInvoke() {
+			// Interface dispatch: CHA lists every possible implementation.
+			confidence = 0.7
+		}
+		pos := prog.Fset.Position(edge.Site.Pos())
+		key := edgeKey(fromID, toID, graph.RelationCalls)
+		if !edgeSet[key] {
+			edgeSet[key] = true
+			g.Edges = append(g.Edges, graph.Edge{
+				From:       fromID,
+				To:         toID,
+				Kind:       graph.RelationCalls,
+				Resolver:   "callgraph",
+				Confidence: confidence,
+				Evidence: graph.Evidence{
+					Filepath:  g.Nodes[fromID].Unit.Filepath,
+					StartLine: pos.Line,
+					EndLine:   pos.Line,
+				},
+			})
+		}
+		if resolvedTargets[fromID] == nil {
+			resolvedTargets[fromID] = make(map[string]bool)
+		}
+		resolvedTargets[fromID][g.Nodes[toID].Unit.Name] = true
+		stats.Resolved++
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	// Drop unresolved call candidates that the call graph has now linked.
+	var still []graph.UnresolvedRelation
+	for _, ur := range g.Unresolved {
+		if ur.Kind == graph.RelationCalls && resolvedTargets[ur.From][lastSegment(ur.Target)] {
+			continue
+		}
+		still = append(still, ur)
+	}
+	g.Unresolved = still
+	return stats, nil
+}
+
+type funcIndex struct {
+	// abs file path | name | receiver -> node ID
+	byKey map[string]string
+}
+
+func buildFuncIndex(g *graph.Graph) funcIndex {
+	idx := funcIndex{byKey: make(map[string]string)}
+	for id, n := range g.Nodes {
+		if n == nil || n.Unit == nil {
+			continue
+		}
+		if n.Unit.UnitType != "function" && n.Unit.UnitType != "method" {
+			continue
+		}
+		abs, err := filepath.Abs(n.Unit.Filepath)
+		if err != nil {
+			continue
+		}
+		idx.byKey[funcKey(abs, n.Unit.Name, receiverFromUnit(n.Unit))] = id
+	}
+	return idx
+}
+
+func (idx funcIndex) lookup(fset *token.FileSet, fn *ssa.Function) string {
+	// Closures are attributed to the declared function that encloses them.
+	for fn != nil && fn.Parent() != nil {
+		fn = fn.Parent()
+	}
+	if fn == nil || !fn.Pos().IsValid() {
+		return ""
+	}
+	if origin := fn.Origin(); origin != nil {
+		fn = origin
+	}
+	file := fset.Position(fn.Pos()).Filename
+	if file == "" {
+		return ""
+	}
+	recv := ""
+	if sig := fn.Signature; sig != nil && sig.Recv() != nil {
+		recv = typeName(sig.Recv().Type())
+	}
+	return idx.byKey[funcKey(file, fn.Name(), recv)]
+}
+
+func funcKey(absPath, name, recv string) string {
+	return canonicalPath(absPath) + "|" + name + "|" + recv
+}
+
+func lastSegment(target string) string {
+	target = stripTypeArgs(strings.TrimPrefix(strings.TrimSpace(target), "*"))
+	if i := strings.LastIndex(target, "."); i >= 0 {
+		return target[i+1:]
+	}
+	return target
+}
@@ -0,0 +1,75 @@
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/extractor"
+	"docod/internal/graph"
+)
+
+func TestCallGraphResolver_Resolve(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module sample\n\ngo 1.21\n",
+		"util/util.go": `package util
+
+func Helper() int { return 1 }
+`,
+		"main.go": `package main
+
+import "sample/util"
+
+func run() int {
+	f := func() int { return util.Helper() }
+	return f()
+}
+
+func main() { _ = run() }
+`,
+	}
+	ext, err := extractor.NewExtractor("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := graph.NewGraph()
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Ext(name) != ".go" {
+			continue
+		}
+		units, err := ext.ExtractFromFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, u := range units {
+			g.AddUnit(u)
+		}
+	}
+
+	if _, err := NewCallGraphResolver(dir).Resolve(g); err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+
+	found := map[string]bool{}
+	for _, e := range g.Edges {
+		if e.Kind != graph.RelationCalls || e.Resolver != "callgraph" {
+			continue
+		}
+		found[g.Nodes[e.From].Unit.Name+"->"+g.Nodes[e.To].Unit.Name] = true
+	}
+	// The closure call is attributed to its enclosing function.
+	if !found["run->Helper"] {
+		t.Fatalf("expected run->Helper edge, got %v", found)
+	}
+	if !found["main->run"] {
+		t.Fatalf("expected main->run edge, got %v", found)
+	}
+}
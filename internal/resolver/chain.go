@@ -1,6 +1,11 @@
 package resolver
 
-import "docod/internal/graph"
+import (
+	"fmt"
+	"strings"
+
+	"docod/internal/graph"
+)
 
 type ResolveStats struct {
 	Attempted int
@@ -19,11 +24,16 @@ type StageResult struct {
 	UnresolvedBefore int
 	UnresolvedAfter  int
 	EdgeCount        int
-	Err              error
+	// Dropped counts edges added by the stage below its confidence floor.
+	Dropped int
+	Err     error
 }
 
 type ResolverChain struct {
 	resolvers []GraphResolver
+	floors    map[string]float64
+	minEdge   float64
+	facts     *PackageFactsLoader
 }
 
 func NewResolverChain(resolvers ...GraphResolver) *ResolverChain {
@@ -31,18 +41,237 @@ func NewResolverChain(resolvers ...GraphResolver) *ResolverChain {
 }
 
 func NewDefaultChain() *ResolverChain {
-	return NewResolverChain(NewHeuristicResolver(), NewGoTypesResolver())
+	return NewResolverChain(NewTestsResolver(), NewHeuristicResolver(), NewModuleResolver(), NewGoTypesResolver(), NewExternalResolver(), NewImplementsResolver(), NewDispatchResolver()).withFacts(nil)
+}
+
+// NewPreciseChain extends the default chain with whole-module type checking
+// and the call graph resolver rooted at dir. It is noticeably slower on large
+// modules.
+func NewPreciseChain(dir string) *ResolverChain {
+	return NewResolverChain(NewTestsResolver(), NewHeuristicResolver(), NewModuleResolver(), NewPackagesResolver(dir), NewGoTypesResolver(), NewCallGraphResolver(dir), NewExternalResolver(), NewImplementsResolver(), NewDispatchResolver()).withFacts(nil)
+}
+
+// withFacts makes the type-checking resolvers of the chain share one package
+// facts loader backed by cache, so each package is type-checked at most once
+// per run and unchanged packages are not type-checked at all.
+func (c *ResolverChain) withFacts(cache FactsCache) *ResolverChain {
+	c.facts = NewPackageFactsLoader(cache)
+	for _, r := range c.resolvers {
+		switch rr := r.(type) {
+		case *GoTypesResolver:
+			rr.Facts = c.facts
+		case *ImplementsResolver:
+			rr.Facts = c.facts
+		case *DispatchResolver:
+			rr.Facts = c.facts
+		}
+	}
+	return c
+}
+
+// FactsStats reports how package facts were obtained by the chain's
+// type-checking resolvers.
+func (c *ResolverChain) FactsStats() FactsStats {
+	if c.facts == nil {
+		return FactsStats{}
+	}
+	return c.facts.Stats()
+}
+
+// ChainOptions selects and orders the resolvers of a configured chain.
+type ChainOptions struct {
+	// Dir is the module root for the packages and callgraph resolvers.
+	Dir string
+	// Resolvers lists resolver names in run order. Empty uses the default
+	// chain, or the precise chain when Precise is set.
+	Resolvers []string
+	Precise   bool
+	// Disable removes resolvers from the selected chain.
+	Disable []string
+	// MinConfidence drops edges a resolver adds below the given floor.
+	MinConfidence map[string]float64
+	// MinEdgeConfidence drops edges below it once all resolvers have run.
+	MinEdgeConfidence float64
+	// StrictEdges raises MinEdgeConfidence to StrictEdgeConfidence, trading
+	// recall for precision.
+	StrictEdges bool
+	// Cache persists package facts between runs; nil keeps them in memory.
+	Cache FactsCache
+}
+
+// StrictEdgeConfidence is the minimum edge confidence in strict mode. It keeps
+// type-checked, import-resolved and declaration (belongs_to) edges and drops
+// name-based guesses and interface dispatch candidates.
+const StrictEdgeConfidence = 0.75
+
+// minConfidenceStage names the final pruning step in chain results.
+const minConfidenceStage = "min_confidence"
+
+// packageNodesStage names the step that rebuilds package nodes from the
+// resolved graph, after every resolver and the pruning have run.
+const packageNodesStage = "package_nodes"
+
+// ResolverNames lists the names accepted by NewResolverByName. "naive" is an
+// alias of "heuristic".
+var ResolverNames = []string{"tests", "heuristic", "module", "packages", "types", "callgraph", "external", "implements", "dispatch"}
+
+// NewResolverByName builds a resolver by its Name. dir roots the resolvers
+// that load the whole module.
+func NewResolverByName(name, dir string) (GraphResolver, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "tests":
+		return NewTestsResolver(), nil
+	case "heuristic", "naive":
+		return NewHeuristicResolver(), nil
+	case "module":
+		return NewModuleResolver(), nil
+	case "packages":
+		return NewPackagesResolver(dir), nil
+	case "types":
+		return NewGoTypesResolver(), nil
+	case "callgraph":
+		return NewCallGraphResolver(dir), nil
+	case "external":
+		return NewExternalResolver(), nil
+	case "implements":
+		return NewImplementsResolver(), nil
+	case "dispatch":
+		return NewDispatchResolver(), nil
+	}
+	return nil, fmt.Errorf("unknown resolver %q (known: %s)", name, strings.Join(ResolverNames, ", "))
+}
+
+// NewConfiguredChain builds the chain described by opts.
+func NewConfiguredChain(opts ChainOptions) (*ResolverChain, error) {
+	var chain *ResolverChain
+	switch {
+	case len(opts.Resolvers) > 0:
+		chain = NewResolverChain()
+		seen := make(map[string]bool)
+		for _, name := range opts.Resolvers {
+			r, err := NewResolverByName(name, opts.Dir)
+			if err != nil {
+				return nil, err
+			}
+			if seen[r.Name()] {
+				return nil, fmt.Errorf("resolver %q listed more than once", r.Name())
+			}
+			seen[r.Name()] = true
+			chain.resolvers = append(chain.resolvers, r)
+		}
+		chain.withFacts(opts.Cache)
+	case opts.Precise:
+		chain = NewPreciseChain(opts.Dir).withFacts(opts.Cache)
+	default:
+		chain = NewDefaultChain().withFacts(opts.Cache)
+	}
+
+	if len(opts.Disable) > 0 {
+		disabled := make(map[string]bool)
+		for _, name := range opts.Disable {
+			r, err := NewResolverByName(name, opts.Dir)
+			if err != nil {
+				return nil, err
+			}
+			disabled[r.Name()] = true
+		}
+		kept := chain.resolvers[:0]
+		for _, r := range chain.resolvers {
+			if !disabled[r.Name()] {
+				kept = append(kept, r)
+			}
+		}
+		chain.resolvers = kept
+	}
+
+	for name, floor := range opts.MinConfidence {
+		r, err := NewResolverByName(name, opts.Dir)
+		if err != nil {
+			return nil, err
+		}
+		if floor < 0 || floor > 1 {
+			return nil, fmt.Errorf("min confidence for %q must be within 0..1, got %g", name, floor)
+		}
+		chain.SetMinConfidence(r.Name(), floor)
+	}
+
+	if opts.MinEdgeConfidence < 0 || opts.MinEdgeConfidence > 1 {
+		return nil, fmt.Errorf("min edge confidence must be within 0..1, got %g", opts.MinEdgeConfidence)
+	}
+	chain.minEdge = opts.MinEdgeConfidence
+	if opts.StrictEdges && chain.minEdge < StrictEdgeConfidence {
+		chain.minEdge = StrictEdgeConfidence
+	}
+	return chain, nil
+}
+
+// SetMinEdgeConfidence sets the confidence every edge must reach once all
+// resolvers have run. Dropped edges are kept as unresolved relations.
+func (c *ResolverChain) SetMinEdgeConfidence(floor float64) {
+	c.minEdge = floor
+}
+
+// MinEdgeConfidence returns the final edge confidence floor.
+func (c *ResolverChain) MinEdgeConfidence() float64 {
+	return c.minEdge
+}
+
+// SetMinConfidence sets the confidence floor for edges added by the named
+// resolver. Resolvers that relink the whole graph re-create edges dropped by
+// earlier stages, so floors apply to the stage output, not the final graph.
+func (c *ResolverChain) SetMinConfidence(name string, floor float64) {
+	if c.floors == nil {
+		c.floors = make(map[string]float64)
+	}
+	c.floors[name] = floor
+}
+
+// MinConfidence returns the confidence floor of the named resolver.
+func (c *ResolverChain) MinConfidence(name string) float64 {
+	return c.floors[name]
+}
+
+// Names returns the resolver names in run order.
+func (c *ResolverChain) Names() []string {
+	names := make([]string, 0, len(c.resolvers))
+	for _, r := range c.resolvers {
+		names = append(names, r.Name())
+	}
+	return names
 }
 
 func (c *ResolverChain) Run(g *graph.Graph) []StageResult {
 	if g == nil {
 		return nil
 	}
+	// Package nodes are derived from the resolved edges; resolvers work on
+	// symbols only.
+	g.RemovePackageNodes()
 
 	var out []StageResult
 	for _, r := range c.resolvers {
 		before := len(g.Unresolved)
+		floor := c.floors[r.Name()]
+		var existing map[string]bool
+		if floor > 0 {
+			existing = make(map[string]bool, len(g.Edges))
+			for _, e := range g.Edges {
+				existing[edgeKey(e.From, e.To, e.Kind)] = true
+			}
+		}
 		stats, err := r.Resolve(g)
+		dropped := 0
+		if floor > 0 {
+			kept := g.Edges[:0]
+			for _, e := range g.Edges {
+				if e.Confidence < floor && !existing[edgeKey(e.From, e.To, e.Kind)] {
+					dropped++
+					continue
+				}
+				kept = append(kept, e)
+			}
+			g.Edges = kept
+		}
 		after := len(g.Unresolved)
 		out = append(out, StageResult{
 			Resolver:         r.Name(),
@@ -50,12 +279,33 @@ func (c *ResolverChain) Run(g *graph.Graph) []StageResult {
 			UnresolvedBefore: before,
 			UnresolvedAfter:  after,
 			EdgeCount:        len(g.Edges),
+			Dropped:          dropped,
 			Err:              err,
 		})
 		if err != nil {
-			break
+			return out
 		}
 	}
+	if c.minEdge > 0 {
+		before := len(g.Unresolved)
+		dropped := g.DropLowConfidenceEdges(c.minEdge)
+		out = append(out, StageResult{
+			Resolver:         minConfidenceStage,
+			UnresolvedBefore: before,
+			UnresolvedAfter:  len(g.Unresolved),
+			EdgeCount:        len(g.Edges),
+			Dropped:          dropped,
+		})
+	}
+	if pkgs := g.BuildPackageNodes(); pkgs.Packages > 0 {
+		out = append(out, StageResult{
+			Resolver:         packageNodesStage,
+			Stats:            ResolveStats{Attempted: pkgs.Packages, Resolved: pkgs.Packages},
+			UnresolvedBefore: len(g.Unresolved),
+			UnresolvedAfter:  len(g.Unresolved),
+			EdgeCount:        len(g.Edges),
+		})
+	}
 	return out
 }
 
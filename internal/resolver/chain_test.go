@@ -58,3 +58,106 @@ func TestResolverChain_Run(t *testing.T) {
 		t.Fatalf("unexpected unresolved transition for r2: %+v", results[1])
 	}
 }
+
+func TestNewConfiguredChain(t *testing.T) {
+	chain, err := NewConfiguredChain(ChainOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := chain.Names(); len(got) != len(NewDefaultChain().Names()) {
+		t.Fatalf("expected default chain, got %v", got)
+	}
+
+	chain, err = NewConfiguredChain(ChainOptions{
+		Resolvers:     []string{"naive", "types", "implements", "dispatch"},
+		Disable:       []string{"dispatch"},
+		MinConfidence: map[string]float64{"naive": 0.5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := chain.Names()
+	want := []string{"heuristic", "types", "implements"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+	if chain.MinConfidence("heuristic") != 0.5 {
+		t.Fatalf("expected heuristic floor 0.5, got %v", chain.MinConfidence("heuristic"))
+	}
+
+	if _, err := NewConfiguredChain(ChainOptions{Resolvers: []string{"bogus"}}); err == nil {
+		t.Fatal("expected unknown resolver to fail")
+	}
+	if _, err := NewConfiguredChain(ChainOptions{Resolvers: []string{"types", "types"}}); err == nil {
+		t.Fatal("expected duplicate resolver to fail")
+	}
+}
+
+func TestResolverChain_MinConfidence(t *testing.T) {
+	g := graph.NewGraph()
+	g.Edges = []graph.Edge{{From: "a", To: "b", Kind: graph.RelationCalls, Confidence: 0.2}}
+	r := fakeResolver{
+		name: "r",
+		fn: func(g *graph.Graph) (ResolveStats, error) {
+			g.Edges = append(g.Edges,
+				graph.Edge{From: "a", To: "c", Kind: graph.RelationCalls, Confidence: 0.4},
+				graph.Edge{From: "a", To: "d", Kind: graph.RelationCalls, Confidence: 0.9},
+			)
+			return ResolveStats{Attempted: 2, Resolved: 2}, nil
+		},
+	}
+	chain := NewResolverChain(r)
+	chain.SetMinConfidence("r", 0.5)
+	results := chain.Run(g)
+
+	if results[0].Dropped != 1 {
+		t.Fatalf("expected one dropped edge, got %+v", results[0])
+	}
+	// Edges that existed before the stage are kept regardless of the floor.
+	if len(g.Edges) != 2 || g.Edges[0].To != "b" || g.Edges[1].To != "d" {
+		t.Fatalf("unexpected edges after floor: %+v", g.Edges)
+	}
+}
+
+func TestResolverChain_StrictEdges(t *testing.T) {
+	chain, err := NewConfiguredChain(ChainOptions{Resolvers: []string{"heuristic"}, StrictEdges: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chain.MinEdgeConfidence() != StrictEdgeConfidence {
+		t.Fatalf("expected strict floor %v, got %v", StrictEdgeConfidence, chain.MinEdgeConfidence())
+	}
+	if _, err := NewConfiguredChain(ChainOptions{MinEdgeConfidence: 1.5}); err == nil {
+		t.Fatal("expected out-of-range min edge confidence to fail")
+	}
+
+	g := graph.NewGraph()
+	r := fakeResolver{
+		name: "r",
+		fn: func(g *graph.Graph) (ResolveStats, error) {
+			g.Edges = append(g.Edges,
+				graph.Edge{From: "a", To: "b", Kind: graph.RelationCalls, Resolver: "r", Confidence: 0.5},
+				graph.Edge{From: "a", To: "c", Kind: graph.RelationCalls, Resolver: "r", Confidence: 0.95},
+			)
+			return ResolveStats{}, nil
+		},
+	}
+	chain = NewResolverChain(r)
+	chain.SetMinEdgeConfidence(StrictEdgeConfidence)
+	results := chain.Run(g)
+	last := results[len(results)-1]
+	if last.Resolver != minConfidenceStage || last.Dropped != 1 {
+		t.Fatalf("expected a min_confidence stage dropping 1 edge, got %+v", last)
+	}
+	if len(g.Edges) != 1 || g.Edges[0].To != "c" {
+		t.Fatalf("expected only the confident edge to remain, got %+v", g.Edges)
+	}
+	if len(g.Unresolved) != 1 || g.Unresolved[0].Reason != graph.ReasonLowConfidence {
+		t.Fatalf("expected the dropped edge recorded as low_confidence, got %+v", g.Unresolved)
+	}
+}
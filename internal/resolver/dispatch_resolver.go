@@ -0,0 +1,167 @@
+package resolver
+
+import (
+	"sort"
+
+	"docod/internal/graph"
+)
+
+// mayCallConfidence is lower than any direct call edge: the call site only
+// proves that one of the implementers runs, not which one.
+const mayCallConfidence = 0.6
+
+// DispatchResolver adds `may_call` edges for method calls made through
+// interface values. Every concrete type already linked to the interface by an
+// `implements` edge contributes its matching method as a candidate callee, so
+// impact analysis reaches the callers of an interface when one implementation
+// changes. It must run after ImplementsResolver.
+type DispatchResolver struct {
+	// Facts supplies type-checked package facts; nil type-checks every
+	// package on each run.
+	Facts *PackageFactsLoader
+}
+
+func NewDispatchResolver() *DispatchResolver {
+	return &DispatchResolver{}
+}
+
+func (r *DispatchResolver) Name() string {
+	return "dispatch"
+}
+
+func (r *DispatchResolver) facts() *PackageFactsLoader {
+	if r.Facts == nil {
+		r.Facts = NewPackageFactsLoader(nil)
+	}
+	return r.Facts
+}
+
+func (r *DispatchResolver) Resolve(g *graph.Graph) (ResolveStats, error) {
+	stats := ResolveStats{}
+	if g == nil || len(g.Nodes) == 0 {
+		return stats, nil
+	}
+
+	implementers := make(map[string][]string) // interface ID -> concrete type IDs
+	edgeSet := make(map[string]bool, len(g.Edges))
+	for _, e := range g.Edges {
+		edgeSet[edgeKey(e.From, e.To, e.Kind)] = true
+		if e.Kind == graph.RelationImplements {
+			implementers[e.To] = append(implementers[e.To], e.From)
+		}
+	}
+	if len(implementers) == 0 {
+		return stats, nil
+	}
+
+	pkgs := r.facts().Load(g)
+
+	ifaceByGroup := make(map[string][]string)   // group|name -> interface IDs
+	ifaceByPackage := make(map[string][]string) // package.name -> interface IDs
+	methods := make(map[string]string)          // group|receiver|method -> method ID
+	funcsByFile := make(map[string][]string)    // canonical path -> function/method IDs
+	for id, n := range g.Nodes {
+		if n == nil || n.Unit == nil {
+			continue
+		}
+		u := n.Unit
+		group := pkgGroupKey(u.Filepath, u.Package)
+		switch u.UnitType {
+		case "interface":
+			ifaceByGroup[group+"|"+u.Name] = append(ifaceByGroup[group+"|"+u.Name], id)
+			ifaceByPackage[u.Package+"."+u.Name] = append(ifaceByPackage[u.Package+"."+u.Name], id)
+		case "method":
+			methods[group+"|"+receiverFromUnit(u)+"|"+u.Name] = id
+			funcsByFile[canonicalPath(u.Filepath)] = append(funcsByFile[canonicalPath(u.Filepath)], id)
+		case "function":
+			funcsByFile[canonicalPath(u.Filepath)] = append(funcsByFile[canonicalPath(u.Filepath)], id)
+		}
+	}
+
+	groups := make([]string, 0, len(pkgs))
+	for key := range pkgs {
+		groups = append(groups, key)
+	}
+	sort.Strings(groups)
+
+	for _, group := range groups {
+		for _, site := range pkgs[group].Dispatch {
+			callers := funcsByFile[site.File]
+			if len(callers) == 0 {
+				continue
+			}
+			stats.Attempted++
+
+			var ifaceIDs []string
+			if site.Local {
+				ifaceIDs = ifaceByGroup[group+"|"+site.Interface]
+			} else {
+				ifaceIDs = ifaceByPackage[site.Package+"."+site.Interface]
+			}
+			fromID := enclosingFunc(g, callers, site.Line)
+			if len(ifaceIDs) != 1 || fromID == "" || len(implementers[ifaceIDs[0]]) == 0 {
+				stats.Skipped++
+				continue
+			}
+
+			linked := false
+			for _, implID := range implementers[ifaceIDs[0]] {
+				impl := g.Nodes[implID]
+				if impl == nil || impl.Unit == nil {
+					continue
+				}
+				implGroup := pkgGroupKey(impl.Unit.Filepath, impl.Unit.Package)
+				toID := methods[implGroup+"|"+impl.Unit.Name+"|"+site.Method]
+				if toID == "" || toID == fromID {
+					continue
+				}
+				linked = true
+				// A precise call edge already covers this callee.
+				if edgeSet[edgeKey(fromID, toID, graph.RelationCalls)] {
+					continue
+				}
+				key := edgeKey(fromID, toID, graph.RelationMayCall)
+				if edgeSet[key] {
+					continue
+				}
+				edgeSet[key] = true
+				g.Edges = append(g.Edges, graph.Edge{
+					From:       fromID,
+					To:         toID,
+					Kind:       graph.RelationMayCall,
+					Resolver:   "dispatch",
+					Confidence: mayCallConfidence,
+					Evidence: graph.Evidence{
+						Filepath:  g.Nodes[fromID].Unit.Filepath,
+						StartLine: site.Line,
+						EndLine:   site.Line,
+					},
+				})
+			}
+			if linked {
+				stats.Resolved++
+			} else {
+				stats.Skipped++
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// enclosingFunc returns the innermost function or method among ids whose span
+// covers line.
+func enclosingFunc(g *graph.Graph, ids []string, line int) string {
+	best, bestSpan := "", 0
+	for _, id := range ids {
+		u := g.Nodes[id].Unit
+		if line < u.StartLine || line > u.EndLine {
+			continue
+		}
+		span := u.EndLine - u.StartLine
+		if best == "" || span < bestSpan || (span == bestSpan && id < best) {
+			best, bestSpan = id, span
+		}
+	}
+	return best
+}
@@ -0,0 +1,103 @@
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/extractor"
+	"docod/internal/graph"
+)
+
+func TestDispatchResolver_Resolve(t *testing.T) {
+	dir := t.TempDir()
+	src := `package store
+
+type Store interface {
+	Get(key string) (int, error)
+}
+
+type Memory struct{}
+
+func (m *Memory) Get(key string) (int, error) { return 0, nil }
+
+type Disk struct{}
+
+func (d *Disk) Get(key string) (int, error) { return 1, nil }
+
+func Lookup(s Store) int {
+	v, _ := s.Get("k")
+	return v
+}
+`
+	path := filepath.Join(dir, "store.go")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ext, err := extractor.NewExtractor("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	units, err := ext.ExtractFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := graph.NewGraph()
+	for _, u := range units {
+		g.AddUnit(u)
+	}
+
+	if _, err := NewImplementsResolver().Resolve(g); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := NewDispatchResolver().Resolve(g)
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if stats.Resolved != 1 {
+		t.Fatalf("expected one dispatched call site, got %+v", stats)
+	}
+
+	callees := make(map[string]bool)
+	for _, e := range g.Edges {
+		if e.Kind != graph.RelationMayCall {
+			continue
+		}
+		if g.Nodes[e.From].Unit.Name != "Lookup" {
+			t.Fatalf("unexpected caller %s", g.Nodes[e.From].Unit.Name)
+		}
+		if e.Confidence >= 0.7 {
+			t.Fatalf("expected reduced confidence, got %v", e.Confidence)
+		}
+		callees[g.Nodes[e.To].Unit.Metadata.Receiver] = true
+	}
+	if len(callees) != 2 {
+		t.Fatalf("expected may_call edges to both implementations, got %v", callees)
+	}
+
+	// Changing one implementation reaches the interface caller.
+	for id, n := range g.Nodes {
+		if n.Unit.UnitType != "method" || cleanReceiver(n.Unit.Metadata.Receiver) != "Disk" {
+			continue
+		}
+		found := false
+		for _, dep := range g.GetDependents(id) {
+			if dep.Unit.Name == "Lookup" {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatal("expected Lookup among dependents of Disk.Get")
+		}
+	}
+
+	// Re-running must not duplicate edges.
+	before := len(g.Edges)
+	if _, err := NewDispatchResolver().Resolve(g); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Edges) != before {
+		t.Fatalf("expected edges to be deduplicated, got %d -> %d", before, len(g.Edges))
+	}
+}
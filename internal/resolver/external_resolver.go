@@ -0,0 +1,182 @@
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+
+	"docod/internal/graph"
+)
+
+// externalConfidence is high because the import path names the package; the
+// symbol inside it is not verified.
+const externalConfidence = 0.9
+
+// ExternalResolver links relations into third-party modules required by
+// go.mod to lightweight external nodes, one per imported package, carrying the
+// module path, version and a pkg.go.dev link. Standard library references stay
+// unresolved as external. It rebuilds external nodes on every run, so it must
+// run after resolvers that re-link the whole graph.
+type ExternalResolver struct{}
+
+func NewExternalResolver() *ExternalResolver {
+	return &ExternalResolver{}
+}
+
+func (r *ExternalResolver) Name() string {
+	return "external"
+}
+
+// ExternalNodeID returns the node ID of an external package.
+func ExternalNodeID(importPath string) string {
+	return "external:" + importPath
+}
+
+type requiredModule struct {
+	path    string
+	version string
+}
+
+func (r *ExternalResolver) Resolve(g *graph.Graph) (ResolveStats, error) {
+	stats := ResolveStats{}
+	if g == nil || len(g.Nodes) == 0 {
+		return stats, nil
+	}
+	removeExternalNodes(g)
+
+	idx := newModuleIndex(g)
+	requires := make(map[string][]requiredModule) // module root -> requirements
+
+	edgeSet := make(map[string]bool, len(g.Edges))
+	for _, e := range g.Edges {
+		edgeSet[edgeKey(e.From, e.To, e.Kind)] = true
+	}
+
+	var still []graph.UnresolvedRelation
+	for _, ur := range g.Unresolved {
+		if ur.Reason != graph.ReasonExternal {
+			still = append(still, ur)
+			continue
+		}
+		stats.Attempted++
+		source, ok := g.Nodes[ur.From]
+		if !ok || source.Unit == nil {
+			stats.Skipped++
+			still = append(still, ur)
+			continue
+		}
+		clean := strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(ur.Target), "*"), "[]")
+		qualifier, _, ok := strings.Cut(strings.TrimPrefix(clean, "*"), ".")
+		importPath := idx.fileImports(source.Unit.Filepath)[qualifier]
+		mod := idx.moduleFor(absDir(source.Unit.Filepath))
+		if !ok || importPath == "" || mod == nil {
+			stats.Skipped++
+			still = append(still, ur)
+			continue
+		}
+		if _, ok := requires[mod.root]; !ok {
+			requires[mod.root] = readRequires(mod.root)
+		}
+		req, ok := requiringModule(requires[mod.root], importPath)
+		if !ok {
+			// Standard library or an unlisted module.
+			stats.Skipped++
+			still = append(still, ur)
+			continue
+		}
+
+		id := ExternalNodeID(importPath)
+		if _, exists := g.Nodes[id]; !exists {
+			g.AddSymbol(&graph.Symbol{
+				ID:          id,
+				Package:     importLocalName(importPath),
+				Language:    "go",
+				UnitType:    graph.UnitTypeExternal,
+				Name:        importPath,
+				Description: "External package " + importPath + " from module " + req.path + " " + req.version,
+				Metadata: graph.SymbolMetadata{
+					Module:  req.path,
+					Version: req.version,
+					DocURL:  PkgGoDevURL(importPath, req.version),
+				},
+			})
+		}
+		stats.Resolved++
+		key := edgeKey(ur.From, id, ur.Kind)
+		if edgeSet[key] {
+			continue
+		}
+		edgeSet[key] = true
+		g.Edges = append(g.Edges, graph.Edge{
+			From:       ur.From,
+			To:         id,
+			Kind:       ur.Kind,
+			Resolver:   "external",
+			Confidence: externalConfidence,
+			Evidence:   ur.Evidence,
+		})
+	}
+	g.Unresolved = still
+	return stats, nil
+}
+
+// PkgGoDevURL links the documentation of importPath at version.
+func PkgGoDevURL(importPath, version string) string {
+	if version == "" {
+		return "https://pkg.go.dev/" + importPath
+	}
+	return "https://pkg.go.dev/" + importPath + "@" + version
+}
+
+// removeExternalNodes drops external nodes and the edges into them, so stale
+// dependencies disappear when they are no longer referenced.
+func removeExternalNodes(g *graph.Graph) {
+	removed := false
+	for id, n := range g.Nodes {
+		if n != nil && n.Unit != nil && n.Unit.UnitType == graph.UnitTypeExternal {
+			delete(g.Nodes, id)
+			removed = true
+		}
+	}
+	if !removed {
+		return
+	}
+	kept := g.Edges[:0]
+	for _, e := range g.Edges {
+		if _, ok := g.Nodes[e.To]; ok || !strings.HasPrefix(e.To, "external:") {
+			kept = append(kept, e)
+		}
+	}
+	g.Edges = kept
+	g.RebuildIndices()
+}
+
+func readRequires(root string) []requiredModule {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return nil
+	}
+	f, err := modfile.ParseLax("go.mod", data, nil)
+	if err != nil {
+		return nil
+	}
+	out := make([]requiredModule, 0, len(f.Require))
+	for _, req := range f.Require {
+		out = append(out, requiredModule{path: req.Mod.Path, version: req.Mod.Version})
+	}
+	// Longest module path first, so nested modules win over their parents.
+	sort.Slice(out, func(i, j int) bool { return len(out[i].path) > len(out[j].path) })
+	return out
+}
+
+func requiringModule(requires []requiredModule, importPath string) (requiredModule, bool) {
+	for _, req := range requires {
+		if importPath == req.path || strings.HasPrefix(importPath, req.path+"/") {
+			return req, true
+		}
+	}
+	return requiredModule{}, false
+}
@@ -0,0 +1,114 @@
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/extractor"
+	"docod/internal/graph"
+)
+
+func TestExternalResolver_LinksRequiredModules(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.21\n\nrequire github.com/acme/kit v1.4.2\n",
+		"cmd/main.go": `package main
+
+import (
+	"sort"
+
+	"github.com/acme/kit/client"
+)
+
+func run() {
+	c := client.New()
+	c.Do()
+	sort.Slice(nil, nil)
+}
+`,
+	}
+	ext, err := extractor.NewExtractor("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := graph.NewGraph()
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Ext(name) != ".go" {
+			continue
+		}
+		units, err := ext.ExtractFromFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, u := range units {
+			g.AddUnit(u)
+		}
+	}
+
+	if _, err := NewModuleResolver().Resolve(g); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := NewExternalResolver().Resolve(g)
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if stats.Resolved != 1 {
+		t.Fatalf("expected one external relation, got %+v", stats)
+	}
+
+	node, ok := g.Nodes[ExternalNodeID("github.com/acme/kit/client")]
+	if !ok {
+		t.Fatal("expected an external node for the client package")
+	}
+	meta := node.Unit.Metadata
+	if node.Unit.UnitType != graph.UnitTypeExternal || meta.Module != "github.com/acme/kit" || meta.Version != "v1.4.2" {
+		t.Fatalf("unexpected external node: %+v", node.Unit)
+	}
+	if meta.DocURL != "https://pkg.go.dev/github.com/acme/kit/client@v1.4.2" {
+		t.Fatalf("unexpected doc link %q", meta.DocURL)
+	}
+
+	linked := false
+	for _, e := range g.Edges {
+		if e.To == node.Unit.ID && g.Nodes[e.From].Unit.Name == "run" && e.Resolver == "external" {
+			linked = true
+		}
+	}
+	if !linked {
+		t.Fatal("expected run to link to the external package")
+	}
+	stdlib := false
+	for _, ur := range g.Unresolved {
+		if ur.Target == "sort.Slice" && ur.Reason == graph.ReasonExternal {
+			stdlib = true
+		}
+	}
+	if !stdlib {
+		t.Fatal("expected standard library references to stay unresolved")
+	}
+
+	// Re-running rebuilds the same single node without duplicating edges.
+	if _, err := NewModuleResolver().Resolve(g); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewExternalResolver().Resolve(g); err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, e := range g.Edges {
+		if e.To == node.Unit.ID {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Fatalf("expected one edge into the external node, got %d", count)
+	}
+}
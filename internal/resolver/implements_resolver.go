@@ -0,0 +1,205 @@
+package resolver
+
+import (
+	"go/types"
+	"sort"
+	"strings"
+
+	"docod/internal/graph"
+)
+
+// ImplementsResolver adds `implements` edges from concrete types to the
+// interfaces whose method sets they satisfy. Packages are type-checked
+// independently, so cross-package satisfaction is decided by comparing
+// method names and package-qualified signatures rather than type identity.
+type ImplementsResolver struct {
+	// Facts supplies type-checked package facts; nil type-checks every
+	// package on each run.
+	Facts *PackageFactsLoader
+}
+
+func NewImplementsResolver() *ImplementsResolver {
+	return &ImplementsResolver{}
+}
+
+func (r *ImplementsResolver) Name() string {
+	return "implements"
+}
+
+func (r *ImplementsResolver) facts() *PackageFactsLoader {
+	if r.Facts == nil {
+		r.Facts = NewPackageFactsLoader(nil)
+	}
+	return r.Facts
+}
+
+type methodSig struct {
+	sig      string
+	exported bool
+}
+
+type typeCandidate struct {
+	group   string
+	name    string
+	methods map[string]methodSig
+}
+
+func (r *ImplementsResolver) Resolve(g *graph.Graph) (ResolveStats, error) {
+	stats := ResolveStats{}
+	if g == nil || len(g.Nodes) == 0 {
+		return stats, nil
+	}
+
+	pkgs := r.facts().Load(g)
+
+	idsByGroupName := make(map[string][]string)
+	for id, n := range g.Nodes {
+		if n == nil || n.Unit == nil {
+			continue
+		}
+		if n.Unit.UnitType == "function" || n.Unit.UnitType == "method" {
+			continue
+		}
+		k := pkgGroupKey(n.Unit.Filepath, n.Unit.Package) + "|" + n.Unit.Name
+		idsByGroupName[k] = append(idsByGroupName[k], id)
+	}
+
+	var ifaces, concretes []typeCandidate
+	groups := make([]string, 0, len(pkgs))
+	for key := range pkgs {
+		groups = append(groups, key)
+	}
+	sort.Strings(groups)
+	for _, key := range groups {
+		ifaces = append(ifaces, typeCandidates(key, pkgs[key].Interfaces)...)
+		concretes = append(concretes, typeCandidates(key, pkgs[key].Concretes)...)
+	}
+
+	edgeSet := make(map[string]bool, len(g.Edges))
+	for _, e := range g.Edges {
+		edgeSet[edgeKey(e.From, e.To, e.Kind)] = true
+	}
+
+	for _, c := range concretes {
+		fromIDs := idsByGroupName[c.group+"|"+c.name]
+		if len(fromIDs) != 1 {
+			stats.Skipped++
+			continue
+		}
+		stats.Attempted++
+		from := g.Nodes[fromIDs[0]].Unit
+		matched := false
+		for _, iface := range ifaces {
+			if !satisfies(c, iface) {
+				continue
+			}
+			toIDs := idsByGroupName[iface.group+"|"+iface.name]
+			if len(toIDs) != 1 || toIDs[0] == fromIDs[0] {
+				continue
+			}
+			key := edgeKey(fromIDs[0], toIDs[0], graph.RelationImplements)
+			if edgeSet[key] {
+				matched = true
+				continue
+			}
+			edgeSet[key] = true
+			confidence := 0.85
+			if iface.group == c.group {
+				confidence = 0.95
+			}
+			g.Edges = append(g.Edges, graph.Edge{
+				From:       fromIDs[0],
+				To:         toIDs[0],
+				Kind:       graph.RelationImplements,
+				Resolver:   "types",
+				Confidence: confidence,
+				Evidence: graph.Evidence{
+					Filepath:  from.Filepath,
+					StartLine: from.StartLine,
+					EndLine:   from.EndLine,
+				},
+			})
+			matched = true
+		}
+		if matched {
+			stats.Resolved++
+		}
+	}
+
+	return stats, nil
+}
+
+func collectTypeCandidates(group string, tp *typedPackage) ([]typeCandidate, []typeCandidate) {
+	var ifaces, concretes []typeCandidate
+	seen := make(map[string]bool)
+	for _, obj := range tp.info.Defs {
+		tn, ok := obj.(*types.TypeName)
+		if !ok || tn.Pkg() == nil || tn.Parent() != tn.Pkg().Scope() || seen[tn.Name()] {
+			continue
+		}
+		named, ok := tn.Type().(*types.Named)
+		if !ok || named.TypeParams().Len() > 0 {
+			continue
+		}
+		seen[tn.Name()] = true
+
+		if iface, ok := named.Underlying().(*types.Interface); ok {
+			if iface.NumMethods() == 0 {
+				continue
+			}
+			methods := make(map[string]methodSig, iface.NumMethods())
+			valid := true
+			for i := 0; i < iface.NumMethods(); i++ {
+				m := iface.Method(i)
+				sig, ok := signatureString(m.Type())
+				if !ok {
+					valid = false
+					break
+				}
+				methods[m.Name()] = methodSig{sig: sig, exported: m.Exported()}
+			}
+			if valid {
+				ifaces = append(ifaces, typeCandidate{group: group, name: tn.Name(), methods: methods})
+			}
+			continue
+		}
+
+		mset := types.NewMethodSet(types.NewPointer(named))
+		if mset.Len() == 0 {
+			continue
+		}
+		methods := make(map[string]methodSig, mset.Len())
+		for i := 0; i < mset.Len(); i++ {
+			fn := mset.At(i).Obj()
+			if sig, ok := signatureString(fn.Type()); ok {
+				methods[fn.Name()] = methodSig{sig: sig, exported: fn.Exported()}
+			}
+		}
+		concretes = append(concretes, typeCandidate{group: group, name: tn.Name(), methods: methods})
+	}
+	sort.Slice(ifaces, func(i, j int) bool { return ifaces[i].name < ifaces[j].name })
+	sort.Slice(concretes, func(i, j int) bool { return concretes[i].name < concretes[j].name })
+	return ifaces, concretes
+}
+
+func satisfies(c, iface typeCandidate) bool {
+	for name, want := range iface.methods {
+		// Unexported interface methods can only be satisfied inside the same package.
+		if !want.exported && c.group != iface.group {
+			return false
+		}
+		got, ok := c.methods[name]
+		if !ok || got.sig != want.sig {
+			return false
+		}
+	}
+	return true
+}
+
+func signatureString(t types.Type) (string, bool) {
+	s := types.TypeString(t, func(p *types.Package) string { return p.Name() })
+	if strings.Contains(s, "invalid type") {
+		return "", false
+	}
+	return s, true
+}
@@ -0,0 +1,81 @@
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/extractor"
+	"docod/internal/graph"
+)
+
+func TestImplementsResolver_Resolve(t *testing.T) {
+	dir := t.TempDir()
+	src := `package store
+
+type Store interface {
+	Get(key string) (int, error)
+}
+
+type Memory struct{}
+
+func (m *Memory) Get(key string) (int, error) { return 0, nil }
+
+type Broken struct{}
+
+func (b Broken) Get(key string) int { return 0 }
+`
+	path := filepath.Join(dir, "store.go")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ext, err := extractor.NewExtractor("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	units, err := ext.ExtractFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := graph.NewGraph()
+	for _, u := range units {
+		g.AddUnit(u)
+	}
+
+	stats, err := NewImplementsResolver().Resolve(g)
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if stats.Resolved != 1 {
+		t.Fatalf("expected one implementer, got %+v", stats)
+	}
+
+	var implements []graph.Edge
+	for _, e := range g.Edges {
+		if e.Kind == graph.RelationImplements {
+			implements = append(implements, e)
+		}
+	}
+	if len(implements) != 1 {
+		t.Fatalf("expected 1 implements edge, got %d", len(implements))
+	}
+	from, to := g.Nodes[implements[0].From].Unit, g.Nodes[implements[0].To].Unit
+	if from.Name != "Memory" || to.Name != "Store" {
+		t.Fatalf("unexpected edge %s -> %s", from.Name, to.Name)
+	}
+
+	// Re-running must not duplicate edges.
+	if _, err := NewImplementsResolver().Resolve(g); err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, e := range g.Edges {
+		if e.Kind == graph.RelationImplements {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Fatalf("expected edges to be deduplicated, got %d", count)
+	}
+}
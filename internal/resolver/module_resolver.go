@@ -0,0 +1,191 @@
+package resolver
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+
+	"docod/internal/graph"
+)
+
+// ModuleResolver re-links relations using a module-wide symbol index keyed by
+// import path, so qualified references such as `storage.NewSQLiteStore`
+// resolve to the package actually imported by the source file (including
+// aliased imports). Qualifiers that name non-module imports are marked external
+// instead of being matched by bare name.
+type ModuleResolver struct{}
+
+func NewModuleResolver() *ModuleResolver {
+	return &ModuleResolver{}
+}
+
+func (r *ModuleResolver) Name() string {
+	return "module"
+}
+
+func (r *ModuleResolver) Resolve(g *graph.Graph) (ResolveStats, error) {
+	if g == nil || len(g.Nodes) == 0 {
+		return ResolveStats{}, nil
+	}
+	idx := newModuleIndex(g)
+	resolved := 0
+	lookup := func(source *graph.Symbol, target string) ([]string, bool) {
+		ids, handled := idx.lookup(source, target)
+		if handled && len(ids) > 0 {
+			resolved++
+		}
+		return ids, handled
+	}
+	g.LinkRelationsWith(lookup, "module_index")
+	return ResolveStats{
+		Attempted: len(g.Edges) + len(g.Unresolved),
+		Resolved:  resolved,
+		Skipped:   len(g.Unresolved),
+	}, nil
+}
+
+type moduleInfo struct {
+	root string // canonical absolute directory containing go.mod
+	path string // module path
+}
+
+type moduleIndex struct {
+	// canonical abs dir -> symbol name -> node IDs (methods excluded)
+	byDir   map[string]map[string][]string
+	modules map[string]*moduleInfo // dir -> owning module (nil if none)
+	imports map[string]map[string]string
+}
+
+func newModuleIndex(g *graph.Graph) *moduleIndex {
+	idx := &moduleIndex{
+		byDir:   make(map[string]map[string][]string),
+		modules: make(map[string]*moduleInfo),
+		imports: make(map[string]map[string]string),
+	}
+	for id, n := range g.Nodes {
+		if n == nil || n.Unit == nil || n.Unit.UnitType == "method" {
+			continue
+		}
+		dir := absDir(n.Unit.Filepath)
+		if dir == "" {
+			continue
+		}
+		if idx.byDir[dir] == nil {
+			idx.byDir[dir] = make(map[string][]string)
+		}
+		idx.byDir[dir][n.Unit.Name] = append(idx.byDir[dir][n.Unit.Name], id)
+	}
+	return idx
+}
+
+func (idx *moduleIndex) lookup(source *graph.Symbol, target string) ([]string, bool) {
+	if source == nil {
+		return nil, false
+	}
+	clean := strings.TrimPrefix(strings.TrimSpace(target), "*")
+	clean = strings.TrimPrefix(clean, "[]")
+	clean = stripTypeArgs(strings.TrimPrefix(clean, "*"))
+	qualifier, name, ok := strings.Cut(clean, ".")
+	if !ok || qualifier == "" || name == "" || strings.ContainsAny(name, ".()[]") {
+		return nil, false
+	}
+
+	importPath, ok := idx.fileImports(source.Filepath)[qualifier]
+	if !ok {
+		// Not an import qualifier (e.g. a local variable selector).
+		return nil, false
+	}
+
+	mod := idx.moduleFor(absDir(source.Filepath))
+	if mod == nil {
+		return nil, false
+	}
+	if importPath != mod.path && !strings.HasPrefix(importPath, mod.path+"/") {
+		return nil, true
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(importPath, mod.path), "/")
+	dir := canonicalPath(filepath.Join(mod.root, filepath.FromSlash(rel)))
+	ids := idx.byDir[dir][name]
+	if len(ids) == 0 {
+		return nil, false
+	}
+	return ids, true
+}
+
+// fileImports maps the local import name of every import in file to its path.
+func (idx *moduleIndex) fileImports(file string) map[string]string {
+	if m, ok := idx.imports[file]; ok {
+		return m
+	}
+	m := make(map[string]string)
+	idx.imports[file] = m
+
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ImportsOnly)
+	if err != nil {
+		return m
+	}
+	for _, spec := range f.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		local := importLocalName(p)
+		if spec.Name != nil {
+			local = spec.Name.Name
+		}
+		if local == "_" || local == "." {
+			continue
+		}
+		m[local] = p
+	}
+	return m
+}
+
+func (idx *moduleIndex) moduleFor(dir string) *moduleInfo {
+	if dir == "" {
+		return nil
+	}
+	if mod, ok := idx.modules[dir]; ok {
+		return mod
+	}
+	var mod *moduleInfo
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		if mp := modfile.ModulePath(data); mp != "" {
+			mod = &moduleInfo{root: dir, path: mp}
+		}
+	} else if parent := canonicalPath(filepath.Dir(dir)); parent != dir {
+		mod = idx.moduleFor(parent)
+	}
+	idx.modules[dir] = mod
+	return mod
+}
+
+// importLocalName guesses the package name of an import path, skipping
+// major-version suffixes such as /v5.
+func importLocalName(importPath string) string {
+	base := path.Base(importPath)
+	if len(base) > 1 && base[0] == 'v' {
+		if _, err := strconv.Atoi(base[1:]); err == nil {
+			base = path.Base(path.Dir(importPath))
+		}
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if i := strings.IndexAny(base, ".-"); i > 0 {
+		base = base[:i]
+	}
+	return base
+}
+
+func absDir(file string) string {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return ""
+	}
+	return canonicalPath(filepath.Dir(abs))
+}
@@ -0,0 +1,94 @@
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/extractor"
+	"docod/internal/graph"
+)
+
+func TestModuleResolver_QualifiedAcrossPackages(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.21\n",
+		"store/store.go": `package store
+
+func Open() int { return 1 }
+`,
+		"other/store.go": `package store
+
+func Open() string { return "" }
+`,
+		"cmd/main.go": `package main
+
+import (
+	db "example.com/app/store"
+	"sort"
+)
+
+func Slice() {}
+
+func run() {
+	_ = db.Open()
+	sort.Slice(nil, nil)
+}
+`,
+	}
+	ext, err := extractor.NewExtractor("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := graph.NewGraph()
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Ext(name) != ".go" {
+			continue
+		}
+		units, err := ext.ExtractFromFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, u := range units {
+			g.AddUnit(u)
+		}
+	}
+
+	if _, err := NewModuleResolver().Resolve(g); err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+
+	var runCalls []graph.Edge
+	for _, e := range g.Edges {
+		if g.Nodes[e.From].Unit.Name == "run" && e.Kind == graph.RelationCalls {
+			runCalls = append(runCalls, e)
+		}
+	}
+	if len(runCalls) != 1 {
+		t.Fatalf("expected exactly one call edge from run, got %d", len(runCalls))
+	}
+	target := g.Nodes[runCalls[0].To].Unit
+	if target.Name != "Open" || filepath.Base(filepath.Dir(target.Filepath)) != "store" {
+		t.Fatalf("expected edge to store.Open, got %s in %s", target.Name, target.Filepath)
+	}
+	if runCalls[0].Resolver != "module_index" || runCalls[0].Confidence < 0.95 {
+		t.Fatalf("unexpected edge attribution: %+v", runCalls[0])
+	}
+
+	external := false
+	for _, ur := range g.Unresolved {
+		if ur.Target == "sort.Slice" && ur.Reason == graph.ReasonExternal {
+			external = true
+		}
+	}
+	if !external {
+		t.Fatalf("expected sort.Slice to be classified as external")
+	}
+}
@@ -0,0 +1,359 @@
+package resolver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go/ast"
+	"go/types"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"docod/internal/graph"
+)
+
+// factsVersion invalidates cached facts whenever their derivation changes.
+const factsVersion = "1"
+
+// FactsCache persists package facts between runs. Entries are opaque JSON
+// keyed by package group; a nil result means no entry.
+type FactsCache interface {
+	GetPackageFacts(ctx context.Context, key string) ([]byte, error)
+	PutPackageFacts(ctx context.Context, key string, data []byte) error
+}
+
+// PackageFacts is what the type-checking resolvers need from one package.
+// It depends only on the package's own files (and the toolchain), so it is
+// reused for as long as their content hashes match Fingerprint.
+type PackageFacts struct {
+	Fingerprint string `json:"fingerprint"`
+	// Files lists the canonical paths of the package files.
+	Files []string `json:"files"`
+	// Calls and Composites map file -> line -> object keys of the callees and
+	// composite literal types found on that line.
+	Calls      map[string]map[int][]string `json:"calls,omitempty"`
+	Composites map[string]map[int][]string `json:"composites,omitempty"`
+	Interfaces []TypeFacts                 `json:"interfaces,omitempty"`
+	Concretes  []TypeFacts                 `json:"concretes,omitempty"`
+	Dispatch   []DispatchSite              `json:"dispatch,omitempty"`
+}
+
+// TypeFacts is the method set of a named type.
+type TypeFacts struct {
+	Name    string                 `json:"name"`
+	Methods map[string]MethodFacts `json:"methods"`
+}
+
+type MethodFacts struct {
+	Sig      string `json:"sig"`
+	Exported bool   `json:"exported,omitempty"`
+}
+
+// DispatchSite is a method call made through an interface value.
+type DispatchSite struct {
+	File      string `json:"file"`
+	Line      int    `json:"line"`
+	Package   string `json:"package"`
+	Interface string `json:"interface"`
+	Method    string `json:"method"`
+	// Local marks interfaces declared in the calling package.
+	Local bool `json:"local,omitempty"`
+}
+
+// FactsStats counts how package facts were obtained during a run.
+type FactsStats struct {
+	Reused  int
+	Checked int
+	Failed  int
+}
+
+// PackageFactsLoader type-checks package groups on demand. Facts whose
+// fingerprint is unchanged are reused from memory or from the persistent
+// cache, so only changed packages are type-checked again. Resolvers in one
+// chain share a loader.
+type PackageFactsLoader struct {
+	cache FactsCache
+	memo  map[string]*PackageFacts
+	stats FactsStats
+	// workers bounds concurrent type-checking; 0 means GOMAXPROCS.
+	workers int
+}
+
+func NewPackageFactsLoader(cache FactsCache) *PackageFactsLoader {
+	return &PackageFactsLoader{cache: cache, memo: make(map[string]*PackageFacts)}
+}
+
+// Stats returns the reuse counters accumulated so far.
+func (l *PackageFactsLoader) Stats() FactsStats {
+	return l.stats
+}
+
+// pendingGroup is a package group that has to be type-checked.
+type pendingGroup struct {
+	key         string
+	paths       []string
+	fingerprint string
+	facts       *PackageFacts
+}
+
+// Load returns facts for every non-test Go package group in g. Groups that
+// fail to parse are left out. Changed groups are type-checked concurrently;
+// the memo and the cache are only touched from the calling goroutine.
+func (l *PackageFactsLoader) Load(g *graph.Graph) map[string]*PackageFacts {
+	byGroup := make(map[string][]string)
+	for _, node := range g.Nodes {
+		if node == nil || node.Unit == nil {
+			continue
+		}
+		if !strings.HasSuffix(node.Unit.Filepath, ".go") || strings.HasSuffix(node.Unit.Filepath, "_test.go") {
+			continue
+		}
+		key := pkgGroupKey(node.Unit.Filepath, node.Unit.Package)
+		byGroup[key] = append(byGroup[key], node.Unit.Filepath)
+	}
+	keys := make([]string, 0, len(byGroup))
+	for key := range byGroup {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make(map[string]*PackageFacts)
+	var pending []*pendingGroup
+	for _, key := range keys {
+		uniq := dedupeStrings(byGroup[key])
+		sort.Strings(uniq)
+		fingerprint, ok := filesFingerprint(uniq)
+		if !ok {
+			l.stats.Failed++
+			continue
+		}
+		if facts := l.reuse(key, fingerprint); facts != nil {
+			result[key] = facts
+			continue
+		}
+		pending = append(pending, &pendingGroup{key: key, paths: uniq, fingerprint: fingerprint})
+	}
+
+	l.typeCheck(pending)
+
+	for _, p := range pending {
+		if p.facts == nil {
+			// Best effort: skip failing groups.
+			l.stats.Failed++
+			continue
+		}
+		p.facts.Fingerprint = p.fingerprint
+		l.memo[p.key] = p.facts
+		l.stats.Checked++
+		result[p.key] = p.facts
+		if l.cache != nil {
+			if data, err := json.Marshal(p.facts); err == nil {
+				_ = l.cache.PutPackageFacts(context.Background(), p.key, data)
+			}
+		}
+	}
+	return result
+}
+
+// reuse returns facts matching fingerprint from memory or the cache.
+func (l *PackageFactsLoader) reuse(key, fingerprint string) *PackageFacts {
+	if facts := l.memo[key]; facts != nil && facts.Fingerprint == fingerprint {
+		l.stats.Reused++
+		return facts
+	}
+	if facts := l.cached(key, fingerprint); facts != nil {
+		l.memo[key] = facts
+		l.stats.Reused++
+		return facts
+	}
+	return nil
+}
+
+// typeCheck fills in the facts of each pending group using a worker pool
+// bounded by GOMAXPROCS. Each group gets its own file set and importer.
+func (l *PackageFactsLoader) typeCheck(pending []*pendingGroup) {
+	workers := l.workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+	jobs := make(chan *pendingGroup)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				if tp, err := loadOneTypedPackage(p.paths); err == nil {
+					p.facts = buildPackageFacts(tp)
+				}
+			}
+		}()
+	}
+	for _, p := range pending {
+		jobs <- p
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func (l *PackageFactsLoader) cached(key, fingerprint string) *PackageFacts {
+	if l.cache == nil {
+		return nil
+	}
+	data, err := l.cache.GetPackageFacts(context.Background(), key)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	var facts PackageFacts
+	if err := json.Unmarshal(data, &facts); err != nil || facts.Fingerprint != fingerprint {
+		return nil
+	}
+	return &facts
+}
+
+// filesFingerprint hashes the package files' paths and contents together with
+// the toolchain version, which decides how standard library imports check.
+func filesFingerprint(paths []string) (string, bool) {
+	h := sha256.New()
+	h.Write([]byte(factsVersion + "\x00" + runtime.Version() + "\x00"))
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return "", false
+		}
+		sum := sha256.Sum256(data)
+		h.Write([]byte(canonicalPath(p) + "\x00"))
+		h.Write(sum[:])
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+func buildPackageFacts(tp *typedPackage) *PackageFacts {
+	facts := &PackageFacts{
+		Calls:      make(map[string]map[int][]string),
+		Composites: make(map[string]map[int][]string),
+	}
+	add := func(m map[string]map[int][]string, file string, line int, key string) {
+		if key == "" {
+			return
+		}
+		if m[file] == nil {
+			m[file] = make(map[int][]string)
+		}
+		for _, existing := range m[file][line] {
+			if existing == key {
+				return
+			}
+		}
+		m[file][line] = append(m[file][line], key)
+	}
+
+	for _, f := range tp.files {
+		file := canonicalPath(tp.fset.Position(f.Pos()).Filename)
+		facts.Files = append(facts.Files, file)
+		for _, n := range tp.byFile[file] {
+			line := tp.fset.Position(n.Pos()).Line
+			switch node := n.(type) {
+			case *ast.CallExpr:
+				add(facts.Calls, file, line, objectKeyFromCall(tp.info, node))
+				if site, ok := dispatchSite(tp, node); ok {
+					site.File, site.Line = file, line
+					facts.Dispatch = append(facts.Dispatch, site)
+				}
+			case *ast.CompositeLit:
+				add(facts.Composites, file, line, objectKeyFromCompositeLit(tp.info, node))
+			}
+		}
+	}
+
+	ifaces, concretes := collectTypeCandidates("", tp)
+	facts.Interfaces = typeFacts(ifaces)
+	facts.Concretes = typeFacts(concretes)
+	return facts
+}
+
+// dispatchSite reports whether call invokes a method through a named
+// interface value.
+func dispatchSite(tp *typedPackage, call *ast.CallExpr) (DispatchSite, bool) {
+	sel, ok := uninstantiated(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return DispatchSite{}, false
+	}
+	selection := tp.info.Selections[sel]
+	if selection == nil || selection.Kind() != types.MethodVal || !types.IsInterface(selection.Recv()) {
+		return DispatchSite{}, false
+	}
+	named := namedOf(selection.Recv())
+	if named == nil || named.Obj().Pkg() == nil {
+		return DispatchSite{}, false
+	}
+	pkg := named.Obj().Pkg()
+	return DispatchSite{
+		Package:   pkg.Name(),
+		Interface: named.Obj().Name(),
+		Method:    sel.Sel.Name,
+		Local:     pkg == tp.pkg,
+	}, true
+}
+
+func typeFacts(candidates []typeCandidate) []TypeFacts {
+	out := make([]TypeFacts, 0, len(candidates))
+	for _, c := range candidates {
+		methods := make(map[string]MethodFacts, len(c.methods))
+		for name, m := range c.methods {
+			methods[name] = MethodFacts{Sig: m.sig, Exported: m.exported}
+		}
+		out = append(out, TypeFacts{Name: c.name, Methods: methods})
+	}
+	return out
+}
+
+func typeCandidates(group string, facts []TypeFacts) []typeCandidate {
+	out := make([]typeCandidate, 0, len(facts))
+	for _, t := range facts {
+		methods := make(map[string]methodSig, len(t.Methods))
+		for name, m := range t.Methods {
+			methods[name] = methodSig{sig: m.Sig, exported: m.Exported}
+		}
+		out = append(out, typeCandidate{group: group, name: t.Name, methods: methods})
+	}
+	return out
+}
+
+// hasFile reports whether the canonical path belongs to the package.
+func (f *PackageFacts) hasFile(path string) bool {
+	for _, p := range f.Files {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// keysInRange returns the object keys recorded in file between the lines,
+// ordered by line.
+func keysInRange(m map[string]map[int][]string, file string, start, end int) []string {
+	byLine := m[file]
+	if len(byLine) == 0 {
+		return nil
+	}
+	lines := make([]int, 0, len(byLine))
+	for line := range byLine {
+		if line >= start && line <= end {
+			lines = append(lines, line)
+		}
+	}
+	sort.Ints(lines)
+	var keys []string
+	for _, line := range lines {
+		keys = append(keys, byLine[line]...)
+	}
+	return keys
+}
@@ -0,0 +1,191 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/extractor"
+	"docod/internal/graph"
+)
+
+type memFactsCache map[string][]byte
+
+func (c memFactsCache) GetPackageFacts(_ context.Context, key string) ([]byte, error) {
+	return c[key], nil
+}
+
+func (c memFactsCache) PutPackageFacts(_ context.Context, key string, data []byte) error {
+	c[key] = data
+	return nil
+}
+
+func TestPackageFactsLoader_ReusesUnchangedPackages(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) string {
+		path := filepath.Join(dir, name, name+".go")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	alpha := write("alpha", "package alpha\n\nfunc A() int { return B() }\n\nfunc B() int { return 1 }\n")
+	write("beta", "package beta\n\nfunc C() {}\n")
+
+	buildGraph := func() *graph.Graph {
+		ext, err := extractor.NewExtractor("go")
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := graph.NewGraph()
+		for _, name := range []string{"alpha", "beta"} {
+			units, err := ext.ExtractFromFile(filepath.Join(dir, name, name+".go"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, u := range units {
+				g.AddUnit(u)
+			}
+		}
+		return g
+	}
+
+	cache := memFactsCache{}
+	first := NewPackageFactsLoader(cache)
+	facts := first.Load(buildGraph())
+	if got := first.Stats(); got.Checked != 2 || got.Reused != 0 {
+		t.Fatalf("expected both packages type-checked, got %+v", got)
+	}
+	if len(cache) != 2 {
+		t.Fatalf("expected facts for 2 packages to be cached, got %d", len(cache))
+	}
+	var alphaFacts *PackageFacts
+	for _, f := range facts {
+		if f.hasFile(canonicalPath(alpha)) {
+			alphaFacts = f
+		}
+	}
+	if alphaFacts == nil || len(keysInRange(alphaFacts.Calls, canonicalPath(alpha), 1, 10)) == 0 {
+		t.Fatalf("expected call keys recorded for alpha, got %+v", alphaFacts)
+	}
+
+	// A new run with an unchanged tree type-checks nothing.
+	second := NewPackageFactsLoader(cache)
+	second.Load(buildGraph())
+	if got := second.Stats(); got.Checked != 0 || got.Reused != 2 {
+		t.Fatalf("expected both packages reused, got %+v", got)
+	}
+
+	// Changing one package only invalidates that package.
+	write("alpha", "package alpha\n\nfunc A() int { return B() + 1 }\n\nfunc B() int { return 1 }\n")
+	third := NewPackageFactsLoader(cache)
+	third.Load(buildGraph())
+	if got := third.Stats(); got.Checked != 1 || got.Reused != 1 {
+		t.Fatalf("expected only alpha type-checked, got %+v", got)
+	}
+}
+
+func TestPackageFactsLoader_ParallelMatchesSerial(t *testing.T) {
+	dir := t.TempDir()
+	ext, err := extractor.NewExtractor("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := graph.NewGraph()
+	for i := 0; i < 6; i++ {
+		name := fmt.Sprintf("p%d", i)
+		path := filepath.Join(dir, name, name+".go")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		src := fmt.Sprintf("package %s\n\nfunc F() int { return G() }\n\nfunc G() int { return %d }\n", name, i)
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		units, err := ext.ExtractFromFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, u := range units {
+			g.AddUnit(u)
+		}
+	}
+
+	serial := NewPackageFactsLoader(nil)
+	serial.workers = 1
+	want := serial.Load(g)
+	parallel := NewPackageFactsLoader(nil)
+	parallel.workers = 4
+	got := parallel.Load(g)
+
+	if parallel.Stats() != serial.Stats() || parallel.Stats().Checked != 6 {
+		t.Fatalf("expected matching stats for 6 packages, got %+v vs %+v", parallel.Stats(), serial.Stats())
+	}
+	for key, w := range want {
+		gotJSON, _ := json.Marshal(got[key])
+		wantJSON, _ := json.Marshal(w)
+		if string(gotJSON) != string(wantJSON) {
+			t.Fatalf("facts for %s differ:\n%s\n%s", key, gotJSON, wantJSON)
+		}
+	}
+}
+
+func TestResolverChain_SharesPackageFacts(t *testing.T) {
+	dir := t.TempDir()
+	src := `package store
+
+type Store interface {
+	Get() int
+}
+
+type Memory struct{}
+
+func (m *Memory) Get() int { return 0 }
+
+func Lookup(s Store) int { return s.Get() }
+`
+	path := filepath.Join(dir, "store.go")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ext, err := extractor.NewExtractor("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	units, err := ext.ExtractFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := graph.NewGraph()
+	for _, u := range units {
+		g.AddUnit(u)
+	}
+
+	chain, err := NewConfiguredChain(ChainOptions{Resolvers: []string{"heuristic", "types", "implements", "dispatch"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range chain.Run(g) {
+		if r.Err != nil {
+			t.Fatalf("%s failed: %v", r.Resolver, r.Err)
+		}
+	}
+	if got := chain.FactsStats(); got.Checked != 1 {
+		t.Fatalf("expected the package to be type-checked once, got %+v", got)
+	}
+	found := false
+	for _, e := range g.Edges {
+		if e.Kind == graph.RelationImplements {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected implements to resolve from the shared facts")
+	}
+}
@@ -0,0 +1,297 @@
+package resolver
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"docod/internal/graph"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// PackagesResolver re-links relations with type information for the whole
+// module, loaded through go/packages. Unlike GoTypesResolver it type-checks
+// packages against their real imports, so references into other module
+// packages resolve to the declaring node by source position instead of by
+// name, and references into other modules are marked external. Relations it
+// cannot type fall back to the ModuleResolver lookup. It loads every package
+// under Dir, so it is opt-in per run.
+type PackagesResolver struct {
+	Dir string
+}
+
+func NewPackagesResolver(dir string) *PackagesResolver {
+	if strings.TrimSpace(dir) == "" {
+		dir = "."
+	}
+	return &PackagesResolver{Dir: dir}
+}
+
+func (r *PackagesResolver) Name() string {
+	return "packages"
+}
+
+func (r *PackagesResolver) Resolve(g *graph.Graph) (ResolveStats, error) {
+	if g == nil || len(g.Nodes) == 0 {
+		return ResolveStats{}, nil
+	}
+
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax |
+			packages.NeedTypes | packages.NeedTypesInfo | packages.NeedImports | packages.NeedDeps,
+		Dir:  r.Dir,
+		Fset: token.NewFileSet(),
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return ResolveStats{}, fmt.Errorf("failed to load packages: %w", err)
+	}
+	mod := newModuleTypes(cfg.Fset, pkgs)
+	if mod == nil {
+		return ResolveStats{}, nil
+	}
+	decls := buildDeclIndex(g)
+	fallback := newModuleIndex(g)
+	refsBySource := make(map[*graph.Symbol]map[string][]types.Object)
+
+	resolved := 0
+	lookup := func(source *graph.Symbol, target string) ([]string, bool) {
+		refs, ok := refsBySource[source]
+		if !ok {
+			refs = mod.references(source)
+			refsBySource[source] = refs
+		}
+		ids, handled := mod.resolve(decls, refs, target)
+		if !handled {
+			// Files outside the loaded packages (e.g. tests) keep the
+			// import-aware module lookup.
+			return fallback.lookup(source, target)
+		}
+		if len(ids) > 0 {
+			resolved++
+		}
+		return ids, true
+	}
+	g.LinkRelationsWith(lookup, "packages")
+	return ResolveStats{
+		Attempted: len(g.Edges) + len(g.Unresolved),
+		Resolved:  resolved,
+		Skipped:   len(g.Unresolved),
+	}, nil
+}
+
+// moduleTypes indexes type-checked syntax of the loaded module packages.
+type moduleTypes struct {
+	fset   *token.FileSet
+	files  map[string]*ast.File    // canonical abs path -> syntax
+	infos  map[string]*types.Info  // canonical abs path -> owning package info
+	module map[*types.Package]bool // packages loaded from the module itself
+}
+
+func newModuleTypes(fset *token.FileSet, pkgs []*packages.Package) *moduleTypes {
+	if fset == nil || len(pkgs) == 0 {
+		return nil
+	}
+	m := &moduleTypes{
+		fset:   fset,
+		files:  make(map[string]*ast.File),
+		infos:  make(map[string]*types.Info),
+		module: make(map[*types.Package]bool),
+	}
+	for _, p := range pkgs {
+		if p.Types == nil || p.TypesInfo == nil {
+			continue
+		}
+		m.module[p.Types] = true
+		for _, f := range p.Syntax {
+			path := canonicalPath(fset.Position(f.Pos()).Filename)
+			m.files[path] = f
+			m.infos[path] = p.TypesInfo
+		}
+	}
+	return m
+}
+
+func (m *moduleTypes) file(path string) (*ast.File, *types.Info) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, nil
+	}
+	key := canonicalPath(abs)
+	return m.files[key], m.infos[key]
+}
+
+func (m *moduleTypes) inModule(obj types.Object) bool {
+	return obj != nil && obj.Pkg() != nil && m.module[obj.Pkg()]
+}
+
+// references collects the objects referenced inside source's declaration,
+// keyed by the referring expression ("db.Close", "store.Open") and by bare
+// name. Callees, composite literal types and named types are recorded.
+func (m *moduleTypes) references(source *graph.Symbol) map[string][]types.Object {
+	out := make(map[string][]types.Object)
+	file, info := m.file(source.Filepath)
+	if file == nil || info == nil {
+		return out
+	}
+	add := func(key string, obj types.Object) {
+		if obj == nil || key == "" {
+			return
+		}
+		for _, existing := range out[key] {
+			if existing == obj {
+				return
+			}
+		}
+		out[key] = append(out[key], obj)
+	}
+	record := func(expr ast.Expr, obj types.Object) {
+		if obj == nil {
+			return
+		}
+		add(obj.Name(), obj)
+		if sel, ok := uninstantiated(expr).(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				add(x.Name+"."+sel.Sel.Name, obj)
+			}
+		}
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil {
+			return true
+		}
+		start, end := m.fset.Position(n.Pos()).Line, m.fset.Position(n.End()).Line
+		if end < source.StartLine || (source.EndLine > 0 && start > source.EndLine) {
+			return false
+		}
+		switch node := n.(type) {
+		case *ast.CallExpr:
+			record(node.Fun, calleeObject(info, node))
+		case *ast.CompositeLit:
+			if named := namedOf(info.TypeOf(node)); named != nil {
+				record(node.Type, named.Obj())
+			}
+		case *ast.SelectorExpr:
+			if tn, ok := info.Uses[node.Sel].(*types.TypeName); ok {
+				record(node, tn)
+			}
+		case *ast.Ident:
+			if tn, ok := info.Uses[node].(*types.TypeName); ok {
+				record(node, tn)
+			}
+		}
+		return true
+	})
+	return out
+}
+
+// resolve maps target to node IDs through the objects source references.
+// handled is false when no referenced object matches the target; an empty
+// handled result marks targets that only name objects outside the module.
+func (m *moduleTypes) resolve(decls declIndex, refs map[string][]types.Object, target string) ([]string, bool) {
+	clean := strings.TrimPrefix(strings.TrimSpace(target), "*")
+	clean = stripTypeArgs(strings.TrimPrefix(strings.TrimPrefix(clean, "[]"), "*"))
+	objs, ok := refs[clean]
+	if !ok {
+		objs, ok = refs[lastSegment(clean)]
+	}
+	if !ok {
+		return nil, false
+	}
+	var ids []string
+	external := false
+	for _, obj := range objs {
+		if obj.Pkg() == nil {
+			continue // builtins and universe types
+		}
+		if !m.inModule(obj) {
+			external = true
+			continue
+		}
+		if id := decls.lookup(m.fset, obj); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	ids = dedupeStrings(ids)
+	if len(ids) == 0 && !external {
+		return nil, false
+	}
+	return ids, true
+}
+
+func calleeObject(info *types.Info, call *ast.CallExpr) types.Object {
+	switch f := uninstantiated(call.Fun).(type) {
+	case *ast.Ident:
+		return info.Uses[f]
+	case *ast.SelectorExpr:
+		if sel := info.Selections[f]; sel != nil {
+			return sel.Obj()
+		}
+		return info.Uses[f.Sel]
+	}
+	return nil
+}
+
+func namedOf(t types.Type) *types.Named {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, _ := t.(*types.Named)
+	return named
+}
+
+// declIndex finds graph nodes by the source position of a declaration.
+type declIndex struct {
+	// canonical abs path -> nodes declared in that file
+	byFile map[string][]declEntry
+}
+
+type declEntry struct {
+	id         string
+	name       string
+	start, end int
+}
+
+func buildDeclIndex(g *graph.Graph) declIndex {
+	idx := declIndex{byFile: make(map[string][]declEntry)}
+	for id, n := range g.Nodes {
+		if n == nil || n.Unit == nil {
+			continue
+		}
+		abs, err := filepath.Abs(n.Unit.Filepath)
+		if err != nil {
+			continue
+		}
+		key := canonicalPath(abs)
+		idx.byFile[key] = append(idx.byFile[key], declEntry{id: id, name: n.Unit.Name, start: n.Unit.StartLine, end: n.Unit.EndLine})
+	}
+	for _, entries := range idx.byFile {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].id < entries[j].id })
+	}
+	return idx
+}
+
+// lookup returns the node declaring obj: same file, same name, and a line
+// span covering the declaration. Instantiated generics resolve through their
+// origin declaration.
+func (idx declIndex) lookup(fset *token.FileSet, obj types.Object) string {
+	obj = originObject(obj)
+	if obj == nil || !obj.Pos().IsValid() {
+		return ""
+	}
+	pos := fset.Position(obj.Pos())
+	for _, e := range idx.byFile[canonicalPath(pos.Filename)] {
+		if e.name != obj.Name() {
+			continue
+		}
+		if e.start <= 0 || (pos.Line >= e.start && (e.end < e.start || pos.Line <= e.end)) {
+			return e.id
+		}
+	}
+	return ""
+}
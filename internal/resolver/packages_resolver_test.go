@@ -0,0 +1,97 @@
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/extractor"
+	"docod/internal/graph"
+)
+
+func TestPackagesResolver_ResolvesAcrossModulePackages(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module sample\n\ngo 1.21\n",
+		"store/store.go": `package store
+
+type DB struct{}
+
+func (d *DB) Close() error { return nil }
+
+func Open() *DB { return &DB{} }
+`,
+		"other/store.go": `package store
+
+func Open() string { return "" }
+`,
+		"main.go": `package main
+
+import (
+	"strings"
+
+	"sample/store"
+)
+
+func run() {
+	db := store.Open()
+	_ = db.Close()
+	_ = strings.TrimSpace(" ")
+}
+
+func main() { run() }
+`,
+	}
+	ext, err := extractor.NewExtractor("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := graph.NewGraph()
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Ext(name) != ".go" {
+			continue
+		}
+		units, err := ext.ExtractFromFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, u := range units {
+			g.AddUnit(u)
+		}
+	}
+	g.LinkRelations()
+
+	if _, err := NewPackagesResolver(dir).Resolve(g); err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+
+	found := map[string]bool{}
+	for _, e := range g.Edges {
+		if e.Kind != graph.RelationCalls || e.Resolver != "packages" {
+			continue
+		}
+		to := g.Nodes[e.To].Unit
+		found[g.Nodes[e.From].Unit.Name+"->"+filepath.Base(filepath.Dir(to.Filepath))+"."+to.Name] = true
+	}
+	if !found["run->store.Open"] {
+		t.Fatalf("expected run->store.Open edge, got %v", found)
+	}
+	if !found["run->store.Close"] {
+		t.Fatalf("expected run->store.Close edge, got %v", found)
+	}
+	if found["run->other.Open"] {
+		t.Fatalf("unexpected edge to the same-named function in another package: %v", found)
+	}
+	for _, ur := range g.Unresolved {
+		if ur.Target == "strings.TrimSpace" && ur.Reason != graph.ReasonExternal {
+			t.Fatalf("expected strings.TrimSpace to be external, got %q", ur.Reason)
+		}
+	}
+}
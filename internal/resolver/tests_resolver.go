@@ -0,0 +1,120 @@
+package resolver
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"docod/internal/extractor"
+	"docod/internal/graph"
+)
+
+// testFuncPrefixes are the function name prefixes `go test` runs.
+var testFuncPrefixes = []string{"Test", "Benchmark", "Fuzz", "Example"}
+
+// TestsResolver adds the test functions found in _test.go files next to the
+// graph's sources as test nodes whose references become `tests` relations.
+// It must run before the heuristic resolver, which links those relations.
+// Previously added test nodes are replaced, so every run reflects the
+// current test files.
+type TestsResolver struct{}
+
+func NewTestsResolver() *TestsResolver {
+	return &TestsResolver{}
+}
+
+func (r *TestsResolver) Name() string {
+	return "tests"
+}
+
+func (r *TestsResolver) Resolve(g *graph.Graph) (ResolveStats, error) {
+	if g == nil || len(g.Nodes) == 0 {
+		return ResolveStats{}, nil
+	}
+	dirs := make(map[string]bool)
+	for id, n := range g.Nodes {
+		if n == nil || n.Unit == nil {
+			continue
+		}
+		if n.Unit.UnitType == graph.UnitTypeTest {
+			delete(g.Nodes, id)
+			continue
+		}
+		dirs[filepath.Dir(n.Unit.Filepath)] = true
+	}
+
+	ext, err := extractor.NewExtractor("go")
+	if err != nil {
+		return ResolveStats{}, err
+	}
+	sortedDirs := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sortedDirs = append(sortedDirs, dir)
+	}
+	sort.Strings(sortedDirs)
+
+	stats := ResolveStats{}
+	for _, dir := range sortedDirs {
+		files, _ := filepath.Glob(filepath.Join(dir, "*_test.go"))
+		for _, file := range files {
+			units, err := ext.ExtractFromFile(file)
+			if err != nil {
+				stats.Skipped++
+				continue
+			}
+			for _, unit := range units {
+				sym := graph.FromCodeUnit(unit)
+				if !isTestFunc(sym) {
+					continue
+				}
+				stats.Attempted++
+				sym.UnitType = graph.UnitTypeTest
+				sym.Relations = testRelations(sym.Relations)
+				if len(sym.Relations) > 0 {
+					stats.Resolved++
+				}
+				g.AddSymbol(sym)
+			}
+		}
+	}
+	g.RebuildIndices()
+	return stats, nil
+}
+
+func isTestFunc(s *graph.Symbol) bool {
+	if s == nil || s.UnitType != "function" {
+		return false
+	}
+	for _, prefix := range testFuncPrefixes {
+		if strings.HasPrefix(s.Name, prefix) && !isLowerStart(s.Name[len(prefix):]) {
+			return true
+		}
+	}
+	return false
+}
+
+func isLowerStart(s string) bool {
+	return s != "" && s[0] >= 'a' && s[0] <= 'z'
+}
+
+// testRelations turns the references of a test into deduplicated tests
+// relations, dropping calls on the testing handle (t.Run, b.ReportAllocs...).
+func testRelations(rels []graph.Relation) []graph.Relation {
+	out := make([]graph.Relation, 0, len(rels))
+	seen := make(map[string]bool)
+	for _, rel := range rels {
+		if rel.Kind == graph.RelationBelongsTo || seen[rel.Target] {
+			continue
+		}
+		if qualifier, _, ok := strings.Cut(rel.Target, "."); ok {
+			switch qualifier {
+			case "t", "b", "f", "tb", "testing", "*testing":
+				continue
+			}
+		}
+		seen[rel.Target] = true
+		rel.Kind = graph.RelationTests
+		out = append(out, rel)
+	}
+	return out
+}
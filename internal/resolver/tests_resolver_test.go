@@ -0,0 +1,99 @@
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/extractor"
+	"docod/internal/graph"
+)
+
+func TestTestsResolver_LinksTestsToProductionSymbols(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/app\n\ngo 1.21\n",
+		"calc/calc.go": `package calc
+
+func Add(a, b int) int { return a + b }
+
+func Sub(a, b int) int { return a - b }
+`,
+		"calc/calc_test.go": `package calc
+
+import "testing"
+
+func helper() {}
+
+func TestAdd(t *testing.T) {
+	helper()
+	if Add(1, 2) != 3 {
+		t.Fatal("bad sum")
+	}
+}
+`,
+	}
+	ext, err := extractor.NewExtractor("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := graph.NewGraph()
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if name != "calc/calc.go" {
+			continue
+		}
+		units, err := ext.ExtractFromFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, u := range units {
+			g.AddUnit(u)
+		}
+	}
+
+	chain := NewResolverChain(NewTestsResolver(), NewHeuristicResolver())
+	for _, r := range chain.Run(g) {
+		if r.Err != nil {
+			t.Fatalf("%s failed: %v", r.Resolver, r.Err)
+		}
+	}
+	// A second run must replace, not duplicate, test nodes.
+	chain.Run(g)
+
+	var tests []*graph.Symbol
+	for _, n := range g.Nodes {
+		if n.Unit.UnitType == graph.UnitTypeTest {
+			tests = append(tests, n.Unit)
+		}
+	}
+	if len(tests) != 1 || tests[0].Name != "TestAdd" {
+		t.Fatalf("expected only TestAdd as test node, got %+v", tests)
+	}
+
+	var addID, subID string
+	for id, n := range g.Nodes {
+		switch n.Unit.Name {
+		case "Add":
+			addID = id
+		case "Sub":
+			subID = id
+		}
+	}
+	covering := g.TestsOf(addID)
+	if len(covering) != 1 || covering[0].Unit.Name != "TestAdd" {
+		t.Fatalf("expected TestAdd to exercise Add, got %d tests", len(covering))
+	}
+	if len(g.TestsOf(subID)) != 0 {
+		t.Fatalf("expected Sub to be untested")
+	}
+	if len(g.GetDependents(addID)) != 0 {
+		t.Fatalf("tests must not be reported as dependents")
+	}
+}
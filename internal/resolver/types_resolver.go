@@ -22,12 +22,23 @@ type TypeResolutionStats struct {
 
 // GoTypesResolver resolves unresolved graph relations using go/types on local source files.
 // It runs best-effort; failures in one package do not abort the entire resolution pass.
-type GoTypesResolver struct{}
+type GoTypesResolver struct {
+	// Facts supplies type-checked package facts; nil type-checks every
+	// package on each run.
+	Facts *PackageFactsLoader
+}
 
 func NewGoTypesResolver() *GoTypesResolver {
 	return &GoTypesResolver{}
 }
 
+func (r *GoTypesResolver) facts() *PackageFactsLoader {
+	if r.Facts == nil {
+		r.Facts = NewPackageFactsLoader(nil)
+	}
+	return r.Facts
+}
+
 func (r *GoTypesResolver) Name() string {
 	return "types"
 }
@@ -47,10 +58,7 @@ func (r *GoTypesResolver) ResolveGraphRelations(g *graph.Graph) (TypeResolutionS
 		return stats, nil
 	}
 
-	pkgs, err := r.loadTypedPackages(g)
-	if err != nil {
-		return stats, err
-	}
+	pkgs := r.facts().Load(g)
 
 	nodeIdx := buildNodeIndex(g)
 	edgeSet := make(map[string]bool, len(g.Edges))
@@ -60,6 +68,12 @@ func (r *GoTypesResolver) ResolveGraphRelations(g *graph.Graph) (TypeResolutionS
 
 	var still []graph.UnresolvedRelation
 	for _, ur := range g.Unresolved {
+		if ur.Reason == graph.ReasonExternal {
+			// Already classified as a non-module dependency.
+			stats.Skipped++
+			still = append(still, ur)
+			continue
+		}
 		stats.Attempted++
 		sourceNode, ok := g.Nodes[ur.From]
 		if !ok || sourceNode.Unit == nil {
@@ -116,6 +130,7 @@ func (r *GoTypesResolver) ResolveGraphRelations(g *graph.Graph) (TypeResolutionS
 }
 
 type typedPackage struct {
+	pkg       *types.Package
 	fset      *token.FileSet
 	files     []*ast.File
 	info      *types.Info
@@ -123,33 +138,6 @@ type typedPackage struct {
 	objToKeys map[types.Object][]string
 }
 
-func (r *GoTypesResolver) loadTypedPackages(g *graph.Graph) (map[string]*typedPackage, error) {
-	byGroup := make(map[string][]string)
-	for _, node := range g.Nodes {
-		if node == nil || node.Unit == nil {
-			continue
-		}
-		if !strings.HasSuffix(node.Unit.Filepath, ".go") || strings.HasSuffix(node.Unit.Filepath, "_test.go") {
-			continue
-		}
-		key := pkgGroupKey(node.Unit.Filepath, node.Unit.Package)
-		byGroup[key] = append(byGroup[key], node.Unit.Filepath)
-	}
-
-	result := make(map[string]*typedPackage)
-	for key, files := range byGroup {
-		uniq := dedupeStrings(files)
-		sort.Strings(uniq)
-		tp, err := loadOneTypedPackage(uniq)
-		if err != nil {
-			// Best effort: skip failing groups.
-			continue
-		}
-		result[key] = tp
-	}
-	return result, nil
-}
-
 func loadOneTypedPackage(paths []string) (*typedPackage, error) {
 	if len(paths) == 0 {
 		return nil, fmt.Errorf("empty package files")
@@ -178,7 +166,7 @@ func loadOneTypedPackage(paths []string) (*typedPackage, error) {
 	}
 
 	pkgName := parsed[0].Name.Name
-	_, err := conf.Check(pkgName, fset, parsed, info)
+	pkg, err := conf.Check(pkgName, fset, parsed, info)
 	if err != nil {
 		// Keep partial info if available.
 	}
@@ -221,6 +209,7 @@ func loadOneTypedPackage(paths []string) (*typedPackage, error) {
 	}
 
 	return &typedPackage{
+		pkg:       pkg,
 		fset:      fset,
 		files:     parsed,
 		info:      info,
@@ -280,15 +269,14 @@ func buildNodeIndex(g *graph.Graph) nodeIndex {
 	return idx
 }
 
-func (r *GoTypesResolver) resolveUnresolvedWithTypes(tp *typedPackage, idx nodeIndex, source *graph.Symbol, ur graph.UnresolvedRelation) ([]string, graph.UnresolvedReason) {
+func (r *GoTypesResolver) resolveUnresolvedWithTypes(facts *PackageFacts, idx nodeIndex, source *graph.Symbol, ur graph.UnresolvedRelation) ([]string, graph.UnresolvedReason) {
 	file := canonicalPath(source.Filepath)
-	nodes := tp.byFile[file]
-	if len(nodes) == 0 {
+	if !facts.hasFile(file) {
 		return nil, graph.ReasonSourceMissing
 	}
 
 	// Try line-local resolution first.
-	ids, reason := r.resolveByEvidence(tp, idx, ur, nodes)
+	ids, reason := r.resolveByEvidence(facts, idx, ur, file)
 	if len(ids) > 0 {
 		return ids, ""
 	}
@@ -308,7 +296,7 @@ func (r *GoTypesResolver) resolveUnresolvedWithTypes(tp *typedPackage, idx nodeI
 	return nil, graph.ReasonNoCandidate
 }
 
-func (r *GoTypesResolver) resolveByEvidence(tp *typedPackage, idx nodeIndex, ur graph.UnresolvedRelation, nodes []ast.Node) ([]string, graph.UnresolvedReason) {
+func (r *GoTypesResolver) resolveByEvidence(facts *PackageFacts, idx nodeIndex, ur graph.UnresolvedRelation, file string) ([]string, graph.UnresolvedReason) {
 	lineStart := ur.Evidence.StartLine
 	lineEnd := ur.Evidence.EndLine
 	if lineStart <= 0 {
@@ -319,33 +307,28 @@ func (r *GoTypesResolver) resolveByEvidence(tp *typedPackage, idx nodeIndex, ur
 	}
 
 	var keys []string
-	for _, n := range nodes {
-		pos := tp.fset.Position(n.Pos())
-		if pos.Line < lineStart || pos.Line > lineEnd {
-			continue
-		}
-		switch node := n.(type) {
-		case *ast.CallExpr:
-			if ur.Kind != graph.RelationCalls {
-				continue
-			}
-			if k := objectKeyFromCall(tp.info, node); k != "" {
-				keys = append(keys, k)
-			}
-		case *ast.CompositeLit:
-			if ur.Kind != graph.RelationInstantiates {
-				continue
-			}
-			if k := objectKeyFromCompositeLit(tp.info, node); k != "" {
-				keys = append(keys, k)
-			}
-		}
+	switch ur.Kind {
+	case graph.RelationCalls:
+		keys = keysInRange(facts.Calls, file, lineStart, lineEnd)
+	case graph.RelationInstantiates:
+		keys = keysInRange(facts.Composites, file, lineStart, lineEnd)
 	}
 
 	keys = dedupeStrings(keys)
 	if len(keys) == 0 {
 		return nil, graph.ReasonNoCandidate
 	}
+	// Evidence may span the whole declaration; keep the keys naming the target.
+	name := lastSegment(ur.Target)
+	var named []string
+	for _, k := range keys {
+		if lastSegment(k) == name {
+			named = append(named, k)
+		}
+	}
+	if len(named) > 0 {
+		keys = named
+	}
 	ids, ambiguous := resolveKeysToIDs(idx, keys)
 	if len(ids) > 0 {
 		return ids, ""
@@ -357,22 +340,47 @@ func (r *GoTypesResolver) resolveByEvidence(tp *typedPackage, idx nodeIndex, ur
 }
 
 func objectKeyFromCall(info *types.Info, call *ast.CallExpr) string {
-	switch fun := call.Fun.(type) {
+	switch fun := uninstantiated(call.Fun).(type) {
 	case *ast.Ident:
 		if obj := info.Uses[fun]; obj != nil {
-			return bestObjectKey(obj)
+			return bestObjectKey(originObject(obj))
 		}
 	case *ast.SelectorExpr:
 		if sel := info.Selections[fun]; sel != nil && sel.Obj() != nil {
-			return bestObjectKey(sel.Obj())
+			return bestObjectKey(originObject(sel.Obj()))
 		}
 		if obj := info.Uses[fun.Sel]; obj != nil {
-			return bestObjectKey(obj)
+			return bestObjectKey(originObject(obj))
 		}
 	}
 	return ""
 }
 
+// uninstantiated strips explicit type arguments from a call target, so
+// F[T](...) and pkg.F[K, V](...) are looked up as F and pkg.F.
+func uninstantiated(expr ast.Expr) ast.Expr {
+	expr = ast.Unparen(expr)
+	switch ix := expr.(type) {
+	case *ast.IndexExpr:
+		return ast.Unparen(ix.X)
+	case *ast.IndexListExpr:
+		return ast.Unparen(ix.X)
+	}
+	return expr
+}
+
+// originObject maps instantiated generic functions and methods of
+// instantiated types back to their generic declaration.
+func originObject(obj types.Object) types.Object {
+	switch o := obj.(type) {
+	case *types.Func:
+		return o.Origin()
+	case *types.Var:
+		return o.Origin()
+	}
+	return obj
+}
+
 func objectKeyFromCompositeLit(info *types.Info, lit *ast.CompositeLit) string {
 	tv, ok := info.Types[lit.Type]
 	if !ok || tv.Type == nil {
@@ -460,7 +468,7 @@ func targetKeys(target string, sourcePkg string) []string {
 		return nil
 	}
 	clean := strings.TrimPrefix(target, "*")
-	clean = strings.TrimPrefix(clean, "[]")
+	clean = stripTypeArgs(strings.TrimPrefix(clean, "[]"))
 	keys := []string{clean}
 	if sourcePkg != "" {
 		keys = append(keys, sourcePkg+"."+clean)
@@ -472,6 +480,36 @@ func targetKeys(target string, sourcePkg string) []string {
 	return dedupeStrings(keys)
 }
 
+// stripTypeArgs removes type argument lists from a target expression:
+// "Box[int]" becomes "Box" and "store.Cache[K, V]" becomes "store.Cache".
+// Brackets that do not follow an identifier (slice and array prefixes) stay.
+func stripTypeArgs(target string) string {
+	if !strings.Contains(target, "[") {
+		return target
+	}
+	var sb strings.Builder
+	depth := 0
+	for i, r := range target {
+		switch {
+		case depth > 0:
+			if r == '[' {
+				depth++
+			} else if r == ']' {
+				depth--
+			}
+		case r == '[' && i > 0 && isIdentByte(target[i-1]):
+			depth = 1
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
 func typeName(t types.Type) string {
 	switch tt := t.(type) {
 	case *types.Pointer:
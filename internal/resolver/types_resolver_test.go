@@ -0,0 +1,74 @@
+package resolver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/extractor"
+	"docod/internal/graph"
+)
+
+func TestGoTypesResolver_ResolvesGenericInstantiations(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "gen.go")
+	src := `package gen
+
+type Box[T any] struct{ v T }
+
+func (b *Box[T]) Get() T { return b.v }
+
+func NewBox[T any](v T) *Box[T] { return &Box[T]{v: v} }
+
+func use() int {
+	b := NewBox[int](1)
+	_ = Box[string]{}
+	return b.Get()
+}
+`
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ext, err := extractor.NewExtractor("go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	units, err := ext.ExtractFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := graph.NewGraph()
+	for _, u := range units {
+		g.AddUnit(u)
+	}
+	g.LinkRelations()
+
+	if _, err := NewGoTypesResolver().Resolve(g); err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+
+	found := map[string]bool{}
+	for _, e := range g.Edges {
+		found[g.Nodes[e.From].Unit.Name+" "+string(e.Kind)+" "+g.Nodes[e.To].Unit.Name] = true
+	}
+	for _, want := range []string{"use calls NewBox", "use instantiates Box", "use calls Get", "Get belongs_to Box"} {
+		if !found[want] {
+			t.Errorf("missing edge %q, got %v", want, found)
+		}
+	}
+}
+
+func TestStripTypeArgs(t *testing.T) {
+	cases := map[string]string{
+		"Box[int]":          "Box",
+		"store.Cache[K, V]": "store.Cache",
+		"Outer[Inner[int]]": "Outer",
+		"[]Box[T]":          "[]Box",
+		"NewSQLiteStore":    "NewSQLiteStore",
+	}
+	for in, want := range cases {
+		if got := stripTypeArgs(in); got != want {
+			t.Errorf("stripTypeArgs(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -12,6 +12,11 @@ type Config struct {
 	MaxHops       int
 	MinConfidence float64
 	AllowedKinds  map[graph.RelationKind]bool
+	// SeedScore is the score of changed symbols; 0 means 1.0.
+	SeedScore float64
+	// HopDecay multiplies the score on every hop, on top of edge
+	// confidence; 0 means 1.0 (no extra decay).
+	HopDecay float64
 }
 
 func DefaultConfig() Config {
@@ -19,6 +24,8 @@ func DefaultConfig() Config {
 		MaxHops:       2,
 		MinConfidence: 0.0,
 		AllowedKinds:  nil,
+		SeedScore:     1.0,
+		HopDecay:      1.0,
 	}
 }
 
@@ -30,6 +37,10 @@ type Subgraph struct {
 	NodeIDs      []string
 	NodeScores   map[string]float64
 	Edges        []graph.Edge
+	// Centrality holds the graph-wide PageRank (0..1) of each node.
+	Centrality map[string]float64
+	// Dependents counts the symbols that transitively depend on each node.
+	Dependents map[string]int
 }
 
 func ExtractFromChanges(g *graph.Graph, changes []git.ChangedFile, cfg Config) *Subgraph {
@@ -39,6 +50,12 @@ func ExtractFromChanges(g *graph.Graph, changes []git.ChangedFile, cfg Config) *
 	if cfg.MaxHops < 0 {
 		cfg.MaxHops = 0
 	}
+	if cfg.SeedScore <= 0 {
+		cfg.SeedScore = 1.0
+	}
+	if cfg.HopDecay <= 0 {
+		cfg.HopDecay = 1.0
+	}
 
 	seedSet := findSeedNodeIDs(g, changes)
 	seedIDs := sortedKeys(seedSet)
@@ -69,7 +86,7 @@ func ExtractFromChanges(g *graph.Graph, changes []git.ChangedFile, cfg Config) *
 	queue := make([]queueItem, 0, len(seedIDs))
 	for _, id := range seedIDs {
 		visitedDepth[id] = 0
-		nodeScores[id] = 1.0
+		nodeScores[id] = cfg.SeedScore
 		queue = append(queue, queueItem{id: id, depth: 0})
 	}
 
@@ -92,7 +109,7 @@ func ExtractFromChanges(g *graph.Graph, changes []git.ChangedFile, cfg Config) *
 			}
 
 			nextDepth := cur.depth + 1
-			candidateScore := nodeScores[cur.id] * normalizedEdgeConfidence(next.edge.Confidence)
+			candidateScore := nodeScores[cur.id] * normalizedEdgeConfidence(next.edge.Confidence) * cfg.HopDecay
 			if candidateScore > nodeScores[next.to] {
 				nodeScores[next.to] = candidateScore
 			}
@@ -122,7 +139,47 @@ func ExtractFromChanges(g *graph.Graph, changes []git.ChangedFile, cfg Config) *
 		NodeIDs:      nodeIDs,
 		NodeScores:   nodeScores,
 		Edges:        edges,
+		Centrality:   nodeCentrality(g, nodeIDs),
+		Dependents:   transitiveDependents(g, nodeIDs),
+	}
+}
+
+func nodeCentrality(g *graph.Graph, ids []string) map[string]float64 {
+	rank := g.PageRank(0.85, 30)
+	out := make(map[string]float64, len(ids))
+	for _, id := range ids {
+		out[id] = rank[id]
+	}
+	return out
+}
+
+// transitiveDependents counts, for each id, the nodes that reach it through
+// non-test edges.
+func transitiveDependents(g *graph.Graph, ids []string) map[string]int {
+	reverse := make(map[string][]string)
+	for _, e := range g.Edges {
+		if e.Kind == graph.RelationTests || graph.IsPackageRelation(e.Kind) || e.From == e.To {
+			continue
+		}
+		reverse[e.To] = append(reverse[e.To], e.From)
 	}
+	out := make(map[string]int, len(ids))
+	for _, id := range ids {
+		seen := map[string]bool{id: true}
+		stack := []string{id}
+		for len(stack) > 0 {
+			cur := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, from := range reverse[cur] {
+				if !seen[from] {
+					seen[from] = true
+					stack = append(stack, from)
+				}
+			}
+		}
+		out[id] = len(seen) - 1
+	}
+	return out
 }
 
 type queueItem struct {
@@ -145,7 +202,7 @@ func findSeedNodeIDs(g *graph.Graph, changes []git.ChangedFile) map[string]int {
 			if node.Unit.Filepath != ch.Path {
 				continue
 			}
-			if !lineRangeOverlaps(node.Unit.StartLine, node.Unit.EndLine, ch.ChangedLines) {
+			if !ch.Touches(node.Unit.StartLine, node.Unit.EndLine) {
 				continue
 			}
 			out[id] = 0
@@ -154,19 +211,10 @@ func findSeedNodeIDs(g *graph.Graph, changes []git.ChangedFile) map[string]int {
 	return out
 }
 
-func lineRangeOverlaps(start, end int, changed []int) bool {
-	if len(changed) == 0 {
-		return true
-	}
-	for _, line := range changed {
-		if line >= start && line <= end {
-			return true
-		}
-	}
-	return false
-}
-
 func edgeAllowed(e graph.Edge, cfg Config) bool {
+	if graph.IsPackageRelation(e.Kind) {
+		return false
+	}
 	if cfg.MinConfidence > 0 && e.Confidence < cfg.MinConfidence {
 		return false
 	}
@@ -70,3 +70,35 @@ func TestExtractFromChanges_FiltersByRelationKind(t *testing.T) {
 	assert.Len(t, sg.Edges, 1)
 	assert.Equal(t, graph.RelationUsesType, sg.Edges[0].Kind)
 }
+
+func TestExtractFromChanges_CentralityAndDependents(t *testing.T) {
+	g := graph.NewGraph()
+	for _, id := range []string{"Hub", "A", "B", "C"} {
+		g.AddSymbol(&graph.Symbol{ID: id, Filepath: id + ".go", StartLine: 1, EndLine: 10, Name: id})
+	}
+	g.Edges = []graph.Edge{
+		{From: "A", To: "Hub", Kind: graph.RelationCalls, Confidence: 0.9},
+		{From: "B", To: "Hub", Kind: graph.RelationCalls, Confidence: 0.9},
+		{From: "C", To: "A", Kind: graph.RelationCalls, Confidence: 0.9},
+		{From: "C", To: "Hub", Kind: graph.RelationTests, Confidence: 0.9},
+	}
+
+	sg := ExtractFromChanges(g, []git.ChangedFile{{Path: "Hub.go", WholeFile: true}}, Config{MaxHops: 1})
+
+	assert.Equal(t, 3, sg.Dependents["Hub"])
+	assert.Equal(t, 1, sg.Dependents["A"])
+	assert.InDelta(t, 1.0, sg.Centrality["Hub"], 0.001)
+	assert.Less(t, sg.Centrality["A"], sg.Centrality["Hub"])
+}
+
+func TestExtractFromChanges_SeedScoreAndHopDecay(t *testing.T) {
+	g := graph.NewGraph()
+	g.AddSymbol(&graph.Symbol{ID: "A", Filepath: "a.go", StartLine: 1, EndLine: 10, Name: "A"})
+	g.AddSymbol(&graph.Symbol{ID: "B", Filepath: "b.go", StartLine: 1, EndLine: 10, Name: "B"})
+	g.Edges = []graph.Edge{{From: "A", To: "B", Kind: graph.RelationCalls, Confidence: 0.8}}
+
+	sg := ExtractFromChanges(g, []git.ChangedFile{{Path: "a.go", WholeFile: true}}, Config{MaxHops: 1, SeedScore: 0.9, HopDecay: 0.5})
+
+	assert.InDelta(t, 0.9, sg.NodeScores["A"], 0.001)
+	assert.InDelta(t, 0.36, sg.NodeScores["B"], 0.001)
+}
@@ -0,0 +1,330 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"docod/internal/graph"
+)
+
+const (
+	defaultNeighborDepth = 1
+	maxNeighborDepth     = 3
+	defaultPathDepth     = 6
+	maxPathDepth         = 12
+)
+
+// NodeSummary is the compact node representation returned by graph queries.
+type NodeSummary struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Package   string `json:"package"`
+	UnitType  string `json:"unit_type"`
+	Filepath  string `json:"filepath"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// NodeResponse is returned by /graph/node/{id}.
+type NodeResponse struct {
+	Node     *graph.Symbol `json:"node"`
+	Outgoing []graph.Edge  `json:"outgoing"`
+	Incoming []graph.Edge  `json:"incoming"`
+}
+
+// NeighborsResponse is returned by /graph/neighbors.
+type NeighborsResponse struct {
+	ID    string        `json:"id"`
+	Depth int           `json:"depth"`
+	Nodes []NodeSummary `json:"nodes"`
+	Edges []graph.Edge  `json:"edges"`
+}
+
+// PathResponse is returned by /graph/path.
+type PathResponse struct {
+	From  string        `json:"from"`
+	To    string        `json:"to"`
+	Found bool          `json:"found"`
+	Nodes []NodeSummary `json:"nodes"`
+	Edges []graph.Edge  `json:"edges"`
+}
+
+// GraphAPI serves read-only queries over a knowledge graph. SetGraph swaps
+// in a new graph, e.g. after a sync; requests in flight finish on the old one.
+type GraphAPI struct {
+	index atomic.Pointer[graphIndex]
+}
+
+// graphIndex is a graph with its edges indexed by endpoint.
+type graphIndex struct {
+	g   *graph.Graph
+	out map[string][]int // node ID -> indices into g.Edges
+	in  map[string][]int
+}
+
+// NewGraphAPI indexes g for serving.
+func NewGraphAPI(g *graph.Graph) *GraphAPI {
+	api := &GraphAPI{}
+	api.SetGraph(g)
+	return api
+}
+
+// SetGraph indexes g and serves it to later requests. The graph must not be
+// mutated afterwards.
+func (a *GraphAPI) SetGraph(g *graph.Graph) {
+	if g == nil {
+		g = graph.NewGraph()
+	}
+	ix := &graphIndex{g: g, out: make(map[string][]int), in: make(map[string][]int)}
+	for i, e := range g.Edges {
+		ix.out[e.From] = append(ix.out[e.From], i)
+		ix.in[e.To] = append(ix.in[e.To], i)
+	}
+	a.index.Store(ix)
+}
+
+// Graph returns the graph serving requests now.
+func (a *GraphAPI) Graph() *graph.Graph {
+	return a.index.Load().g
+}
+
+// Register mounts the graph routes on mux, each wrapped by auth if not nil.
+func (a *GraphAPI) Register(mux *http.ServeMux, auth func(http.HandlerFunc) http.HandlerFunc) {
+	if auth == nil {
+		auth = func(h http.HandlerFunc) http.HandlerFunc { return h }
+	}
+	mux.HandleFunc("GET /graph/node/{id...}", auth(a.handleNode))
+	mux.HandleFunc("GET /graph/neighbors", auth(a.handleNeighbors))
+	mux.HandleFunc("GET /graph/path", auth(a.handlePath))
+}
+
+func (a *GraphAPI) handleNode(w http.ResponseWriter, r *http.Request) {
+	ix := a.index.Load()
+	id := r.PathValue("id")
+	node, ok := ix.g.Nodes[id]
+	if !ok || node == nil || node.Unit == nil {
+		writeError(w, http.StatusNotFound, "node not found: "+id)
+		return
+	}
+	unit := node.Unit
+	if ix.g.Partial() {
+		hydrated := *unit
+		hydrated.Content = ix.g.Content(unit)
+		unit = &hydrated
+	}
+	resp := NodeResponse{Node: unit, Outgoing: []graph.Edge{}, Incoming: []graph.Edge{}}
+	for _, i := range ix.out[id] {
+		resp.Outgoing = append(resp.Outgoing, ix.g.Edges[i])
+	}
+	for _, i := range ix.in[id] {
+		resp.Incoming = append(resp.Incoming, ix.g.Edges[i])
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleNeighbors serves ?id=&depth=&direction=out|in|both&kind=calls,uses_type.
+func (a *GraphAPI) handleNeighbors(w http.ResponseWriter, r *http.Request) {
+	ix := a.index.Load()
+	q := r.URL.Query()
+	id := q.Get("id")
+	if _, ok := ix.g.Nodes[id]; !ok {
+		writeError(w, http.StatusNotFound, "node not found: "+id)
+		return
+	}
+	depth, err := intParam(q.Get("depth"), defaultNeighborDepth, maxNeighborDepth)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid depth")
+		return
+	}
+	direction := strings.ToLower(q.Get("direction"))
+	if direction == "" {
+		direction = "both"
+	}
+	if direction != "out" && direction != "in" && direction != "both" {
+		writeError(w, http.StatusBadRequest, "direction must be out, in or both")
+		return
+	}
+	kinds := kindFilter(q.Get("kind"))
+
+	visited := map[string]bool{id: true}
+	edgeSeen := make(map[int]bool)
+	frontier := []string{id}
+	resp := NeighborsResponse{ID: id, Depth: depth, Nodes: []NodeSummary{}, Edges: []graph.Edge{}}
+	for d := 0; d < depth && len(frontier) > 0; d++ {
+		var next []string
+		for _, cur := range frontier {
+			for _, step := range ix.steps(cur, direction, kinds) {
+				if !edgeSeen[step.edge] {
+					edgeSeen[step.edge] = true
+					resp.Edges = append(resp.Edges, ix.g.Edges[step.edge])
+				}
+				if visited[step.to] {
+					continue
+				}
+				visited[step.to] = true
+				next = append(next, step.to)
+				if n, ok := ix.g.Nodes[step.to]; ok && n != nil && n.Unit != nil {
+					resp.Nodes = append(resp.Nodes, SummarizeNode(n.Unit))
+				}
+			}
+		}
+		frontier = next
+	}
+	sort.Slice(resp.Nodes, func(i, j int) bool { return resp.Nodes[i].ID < resp.Nodes[j].ID })
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handlePath serves ?from=&to=&max_depth=&directed=true, returning the
+// shortest path. Paths follow edges in either direction unless directed is set.
+func (a *GraphAPI) handlePath(w http.ResponseWriter, r *http.Request) {
+	ix := a.index.Load()
+	q := r.URL.Query()
+	from, to := q.Get("from"), q.Get("to")
+	for _, id := range []string{from, to} {
+		if _, ok := ix.g.Nodes[id]; !ok {
+			writeError(w, http.StatusNotFound, "node not found: "+id)
+			return
+		}
+	}
+	maxDepth, err := intParam(q.Get("max_depth"), defaultPathDepth, maxPathDepth)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid max_depth")
+		return
+	}
+	direction := "both"
+	if directed, _ := strconv.ParseBool(q.Get("directed")); directed {
+		direction = "out"
+	}
+	kinds := kindFilter(q.Get("kind"))
+
+	type prevStep struct {
+		node string
+		edge int
+	}
+	prev := map[string]prevStep{from: {edge: -1}}
+	frontier := []string{from}
+	for d := 0; d < maxDepth && len(frontier) > 0 && from != to; d++ {
+		var next []string
+		for _, cur := range frontier {
+			for _, step := range ix.steps(cur, direction, kinds) {
+				if _, seen := prev[step.to]; seen {
+					continue
+				}
+				prev[step.to] = prevStep{node: cur, edge: step.edge}
+				next = append(next, step.to)
+			}
+		}
+		if _, ok := prev[to]; ok {
+			break
+		}
+		frontier = next
+	}
+
+	resp := PathResponse{From: from, To: to, Nodes: []NodeSummary{}, Edges: []graph.Edge{}}
+	if _, ok := prev[to]; !ok {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	resp.Found = true
+	var ids []string
+	var edges []graph.Edge
+	for cur := to; ; {
+		ids = append(ids, cur)
+		p := prev[cur]
+		if p.edge < 0 {
+			break
+		}
+		edges = append(edges, ix.g.Edges[p.edge])
+		cur = p.node
+	}
+	for i := len(ids) - 1; i >= 0; i-- {
+		resp.Nodes = append(resp.Nodes, SummarizeNode(ix.g.Nodes[ids[i]].Unit))
+	}
+	for i := len(edges) - 1; i >= 0; i-- {
+		resp.Edges = append(resp.Edges, edges[i])
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type step struct {
+	to   string
+	edge int
+}
+
+// steps lists the edges leaving id in the requested direction, in a
+// deterministic order.
+func (a *graphIndex) steps(id, direction string, kinds map[graph.RelationKind]bool) []step {
+	var out []step
+	if direction == "out" || direction == "both" {
+		for _, i := range a.out[id] {
+			if e := a.g.Edges[i]; kinds == nil || kinds[e.Kind] {
+				out = append(out, step{to: e.To, edge: i})
+			}
+		}
+	}
+	if direction == "in" || direction == "both" {
+		for _, i := range a.in[id] {
+			if e := a.g.Edges[i]; kinds == nil || kinds[e.Kind] {
+				out = append(out, step{to: e.From, edge: i})
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].to < out[j].to })
+	return out
+}
+
+func kindFilter(raw string) map[graph.RelationKind]bool {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	kinds := make(map[graph.RelationKind]bool)
+	for _, k := range strings.Split(raw, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			kinds[graph.RelationKind(k)] = true
+		}
+	}
+	return kinds
+}
+
+func intParam(raw string, def, max int) (int, error) {
+	if raw == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, strconv.ErrSyntax
+	}
+	if n > max {
+		n = max
+	}
+	return n, nil
+}
+
+// SummarizeNode returns the compact form of u used in query results.
+func SummarizeNode(u *graph.Symbol) NodeSummary {
+	return NodeSummary{
+		ID:        u.ID,
+		Name:      u.Name,
+		Package:   u.Package,
+		UnitType:  u.UnitType,
+		Filepath:  u.Filepath,
+		StartLine: u.StartLine,
+		EndLine:   u.EndLine,
+		Signature: u.Metadata.Signature,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, ErrorResponse{Error: msg})
+}
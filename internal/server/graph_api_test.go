@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testGraph() *graph.Graph {
+	g := graph.NewGraph()
+	for _, id := range []string{"go/cli:function:Run:1", "go/store:function:Open:2", "go/store:struct:DB:3", "go/x:function:Lonely:4"} {
+		g.AddSymbol(&graph.Symbol{ID: id, Name: id, UnitType: "function"})
+	}
+	g.Edges = []graph.Edge{
+		{From: "go/cli:function:Run:1", To: "go/store:function:Open:2", Kind: graph.RelationCalls, Confidence: 0.9},
+		{From: "go/store:function:Open:2", To: "go/store:struct:DB:3", Kind: graph.RelationUsesType, Confidence: 0.95},
+	}
+	return g
+}
+
+func get(t *testing.T, h http.Handler, path string, out interface{}) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if out != nil && rec.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), out))
+	}
+	return rec.Code
+}
+
+func TestGraphAPI_Node(t *testing.T) {
+	h := NewMux(NewGraphAPI(testGraph()), nil)
+
+	var resp NodeResponse
+	assert.Equal(t, http.StatusOK, get(t, h, "/graph/node/go/store:function:Open:2", &resp))
+	assert.Equal(t, "go/store:function:Open:2", resp.Node.ID)
+	assert.Len(t, resp.Outgoing, 1)
+	assert.Len(t, resp.Incoming, 1)
+
+	assert.Equal(t, http.StatusNotFound, get(t, h, "/graph/node/missing", nil))
+}
+
+func TestGraphAPI_Neighbors(t *testing.T) {
+	h := NewMux(NewGraphAPI(testGraph()), nil)
+
+	var resp NeighborsResponse
+	path := "/graph/neighbors?id=" + url.QueryEscape("go/cli:function:Run:1") + "&depth=2&direction=out"
+	assert.Equal(t, http.StatusOK, get(t, h, path, &resp))
+	assert.Len(t, resp.Nodes, 2)
+	assert.Len(t, resp.Edges, 2)
+
+	resp = NeighborsResponse{}
+	path = "/graph/neighbors?id=" + url.QueryEscape("go/store:function:Open:2") + "&kind=uses_type"
+	assert.Equal(t, http.StatusOK, get(t, h, path, &resp))
+	require.Len(t, resp.Nodes, 1)
+	assert.Equal(t, "go/store:struct:DB:3", resp.Nodes[0].ID)
+
+	assert.Equal(t, http.StatusBadRequest, get(t, h, "/graph/neighbors?id="+url.QueryEscape("go/cli:function:Run:1")+"&direction=up", nil))
+}
+
+func TestGraphAPI_Path(t *testing.T) {
+	h := NewMux(NewGraphAPI(testGraph()), nil)
+
+	var resp PathResponse
+	path := "/graph/path?from=" + url.QueryEscape("go/store:struct:DB:3") + "&to=" + url.QueryEscape("go/cli:function:Run:1")
+	assert.Equal(t, http.StatusOK, get(t, h, path, &resp))
+	assert.True(t, resp.Found)
+	require.Len(t, resp.Nodes, 3)
+	assert.Equal(t, "go/store:struct:DB:3", resp.Nodes[0].ID)
+	assert.Equal(t, "go/cli:function:Run:1", resp.Nodes[2].ID)
+	assert.Len(t, resp.Edges, 2)
+
+	// Against edge direction there is no directed path.
+	resp = PathResponse{}
+	assert.Equal(t, http.StatusOK, get(t, h, path+"&directed=true", &resp))
+	assert.False(t, resp.Found)
+
+	resp = PathResponse{}
+	path = "/graph/path?from=" + url.QueryEscape("go/cli:function:Run:1") + "&to=" + url.QueryEscape("go/x:function:Lonely:4")
+	assert.Equal(t, http.StatusOK, get(t, h, path, &resp))
+	assert.False(t, resp.Found)
+}
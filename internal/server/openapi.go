@@ -0,0 +1,298 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"docod/internal/generator"
+)
+
+// OpenAPIVersion is the version of the API contract described by OpenAPI.
+// Bump it when a response type or route changes incompatibly.
+const OpenAPIVersion = "1.0.0"
+
+// Health is returned by /healthz.
+type Health struct {
+	Status string `json:"status"`
+	Nodes  int    `json:"nodes"`
+	Edges  int    `json:"edges"`
+}
+
+// ErrorResponse is the body of every non-2xx response.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// OpenAPI returns the OpenAPI 3.1 document of the `docod serve` HTTP API.
+// Schemas are derived from the Go response types by their json tags, so the
+// contract follows the handlers; docs/openapi.json is the published copy.
+func OpenAPI() map[string]any {
+	b := &openAPIBuilder{schemas: make(map[string]any), names: make(map[string]reflect.Type)}
+	apiAuth := []map[string][]string{{"bearerAuth": {}}, {"apiKeyHeader": {}}}
+	errors := func(op map[string]any, codes ...int) map[string]any {
+		responses := op["responses"].(map[string]any)
+		for _, code := range codes {
+			responses[strconv.Itoa(code)] = b.response(http.StatusText(code), reflect.TypeOf(ErrorResponse{}))
+		}
+		return op
+	}
+	secured := func(op map[string]any) map[string]any {
+		op["security"] = apiAuth
+		return errors(op, http.StatusUnauthorized)
+	}
+
+	paths := map[string]any{
+		"/healthz": map[string]any{"get": b.op("health", "Report liveness and graph size", nil, nil, reflect.TypeOf(Health{}))},
+		"/graph/node/{id}": map[string]any{"get": secured(errors(b.op("getNode", "Get a node with its incoming and outgoing edges",
+			[]any{pathParam("id", "Node ID. IDs contain slashes, which are sent unescaped.")}, nil, reflect.TypeOf(NodeResponse{})), http.StatusNotFound))},
+		"/graph/neighbors": map[string]any{"get": secured(errors(b.op("getNeighbors", "List the nodes within depth edges of a node",
+			[]any{
+				queryParam("id", "string", "Node ID.", true),
+				queryParam("depth", "integer", "Hops to follow, 1 to 3; default 1.", false),
+				enumParam("direction", "Edge direction to follow; default both.", "out", "in", "both"),
+				queryParam("kind", "string", "Comma-separated relation kinds to follow, e.g. calls,uses_type.", false),
+			}, nil, reflect.TypeOf(NeighborsResponse{})), http.StatusBadRequest, http.StatusNotFound))},
+		"/graph/path": map[string]any{"get": secured(errors(b.op("getPath", "Find the shortest path between two nodes",
+			[]any{
+				queryParam("from", "string", "Start node ID.", true),
+				queryParam("to", "string", "End node ID.", true),
+				queryParam("max_depth", "integer", "Longest path searched, 1 to 12; default 6.", false),
+				queryParam("directed", "boolean", "Follow edges only in their direction.", false),
+				queryParam("kind", "string", "Comma-separated relation kinds to follow.", false),
+			}, nil, reflect.TypeOf(PathResponse{})), http.StatusBadRequest, http.StatusNotFound))},
+		"/api/search": map[string]any{"get": secured(errors(b.op("search", "Semantic code search",
+			[]any{
+				queryParam("q", "string", "Search text.", true),
+				queryParam("top_k", "integer", "Results to return, 1 to 50; default 10.", false),
+			}, nil, reflect.TypeOf(SearchResponse{})), http.StatusBadRequest, http.StatusBadGateway, http.StatusServiceUnavailable))},
+		"/api/ask": map[string]any{"post": secured(errors(b.op("ask", "Answer a question from retrieved code with cited sources",
+			nil, reflect.TypeOf(AskRequest{}), reflect.TypeOf(AskResponse{})), http.StatusBadRequest, http.StatusBadGateway, http.StatusServiceUnavailable))},
+		"/api/sections": map[string]any{"get": secured(errors(b.op("listSections", "List the generated documentation sections",
+			nil, nil, reflect.TypeOf([]SectionSummary{})), http.StatusNotFound, http.StatusInternalServerError))},
+		"/api/sections/{id}": map[string]any{"get": secured(errors(b.op("getSection", "Get one section with its content and sources",
+			[]any{pathParam("id", "Section ID.")}, nil, reflect.TypeOf(generator.ModelSect{})), http.StatusNotFound, http.StatusInternalServerError))},
+		"/api/report": map[string]any{"get": secured(errors(b.op("getReport", "Get the pipeline report of the last full generation",
+			nil, nil, reflect.TypeOf(map[string]any{})), http.StatusNotFound, http.StatusInternalServerError))},
+		"/api/sync": map[string]any{"post": secured(errors(b.op("startSync", "Start a documentation sync in the background",
+			nil, nil, nil), http.StatusNotImplemented))},
+		"/api/sync/{id}": map[string]any{"get": secured(errors(b.op("getSyncJob", "Get the status of a sync job",
+			[]any{pathParam("id", "Sync job ID.")}, nil, reflect.TypeOf(SyncJob{})), http.StatusNotFound))},
+		"/api/openapi.json": map[string]any{"get": map[string]any{
+			"operationId": "getOpenAPI",
+			"summary":     "Get this OpenAPI document",
+			"responses":   map[string]any{"200": map[string]any{"description": "OK", "content": map[string]any{"application/json": map[string]any{"schema": map[string]any{"type": "object"}}}}},
+		}},
+	}
+	startSync := paths["/api/sync"].(map[string]any)["post"].(map[string]any)
+	startSync["responses"].(map[string]any)["202"] = b.response("Accepted; poll the job at the Location header", reflect.TypeOf(SyncJob{}))
+	startSync["responses"].(map[string]any)["409"] = b.response("A sync is already running; the body is that job", reflect.TypeOf(SyncJob{}))
+	for alias, route := range map[string]string{
+		"/health":             "/healthz",
+		"/search":             "/api/search",
+		"/docs/sections":      "/api/sections",
+		"/docs/sections/{id}": "/api/sections/{id}",
+	} {
+		op := make(map[string]any)
+		for k, v := range paths[route].(map[string]any)["get"].(map[string]any) {
+			op[k] = v
+		}
+		op["operationId"] = op["operationId"].(string) + "Alias"
+		op["summary"] = op["summary"].(string) + " (alias of " + route + ")"
+		paths[alias] = map[string]any{"get": op}
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "docod serve API",
+			"version":     OpenAPIVersion,
+			"description": "Graph queries, semantic search, Q&A, generated documentation and sync jobs served by `docod serve`. /api and /graph routes require an API key when server.api_keys is set, and always when serve listens beyond loopback.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": b.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth":   map[string]any{"type": "http", "scheme": "bearer"},
+				"apiKeyHeader": map[string]any{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+		},
+	}
+}
+
+// OpenAPIJSON renders OpenAPI as indented JSON, as published in
+// docs/openapi.json.
+func OpenAPIJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(OpenAPI()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	data, err := OpenAPIJSON()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// openAPIBuilder collects the component schemas of the types it references.
+type openAPIBuilder struct {
+	schemas map[string]any
+	names   map[string]reflect.Type
+}
+
+func (b *openAPIBuilder) op(id, summary string, params []any, body, result reflect.Type) map[string]any {
+	op := map[string]any{"operationId": id, "summary": summary, "responses": map[string]any{}}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	if body != nil {
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{"application/json": map[string]any{"schema": b.schema(body)}},
+		}
+	}
+	if result != nil {
+		op["responses"].(map[string]any)["200"] = b.response("OK", result)
+	}
+	return op
+}
+
+func (b *openAPIBuilder) response(description string, t reflect.Type) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content":     map[string]any{"application/json": map[string]any{"schema": b.schema(t)}},
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the JSON Schema of t; named structs become components and
+// are referenced.
+func (b *openAPIBuilder) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		name := b.componentName(t)
+		if _, done := b.schemas[name]; !done {
+			b.schemas[name] = nil // break cycles
+			b.schemas[name] = b.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// json.RawMessage and []byte hold arbitrary JSON or base64.
+			return map[string]any{}
+		}
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// componentName is the type name, qualified by its package when two packages
+// use the same name.
+func (b *openAPIBuilder) componentName(t reflect.Type) string {
+	name := t.Name()
+	if name == "" {
+		name = "Anonymous"
+	}
+	if prev, ok := b.names[name]; ok && prev != t {
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	b.names[name] = t
+	return name
+}
+
+func (b *openAPIBuilder) structSchema(t reflect.Type) map[string]any {
+	props := make(map[string]any)
+	var required []string
+	b.addFields(t, props, &required)
+	s := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		s["required"] = required
+	}
+	return s
+}
+
+func (b *openAPIBuilder) addFields(t reflect.Type, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				b.addFields(ft, props, required)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s := b.schema(f.Type)
+		omitempty := strings.Contains(opts, "omitempty")
+		if f.Type.Kind() == reflect.Pointer && !omitempty {
+			// Nil pointers are written as null.
+			s = map[string]any{"anyOf": []any{s, map[string]any{"type": "null"}}}
+		}
+		props[name] = s
+		if !omitempty {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func pathParam(name, description string) map[string]any {
+	return map[string]any{"name": name, "in": "path", "required": true, "description": description, "schema": map[string]any{"type": "string"}}
+}
+
+func queryParam(name, typ, description string, required bool) map[string]any {
+	p := map[string]any{"name": name, "in": "query", "description": description, "schema": map[string]any{"type": typ}}
+	if required {
+		p["required"] = true
+	}
+	return p
+}
+
+func enumParam(name, description string, values ...string) map[string]any {
+	return map[string]any{"name": name, "in": "query", "description": description, "schema": map[string]any{"type": "string", "enum": values}}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIJSON_MatchesPublishedDocument(t *testing.T) {
+	data, err := OpenAPIJSON()
+	require.NoError(t, err)
+	published, err := os.ReadFile(filepath.Join("..", "..", "docs", "openapi.json"))
+	require.NoError(t, err)
+	assert.Equal(t, string(published), string(data), "run `docod serve openapi -o docs/openapi.json`")
+}
+
+func TestOpenAPI_CoversRoutesAndResolvesRefs(t *testing.T) {
+	data, err := OpenAPIJSON()
+	require.NoError(t, err)
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(data, &doc))
+
+	for _, route := range []string{
+		"GET /healthz", "GET /graph/node/{id}", "GET /graph/neighbors", "GET /graph/path",
+		"GET /api/search", "POST /api/ask", "GET /api/sections", "GET /api/sections/{id}",
+		"GET /api/report", "POST /api/sync", "GET /api/sync/{id}", "GET /api/openapi.json",
+		"GET /health", "GET /search", "GET /docs/sections", "GET /docs/sections/{id}",
+	} {
+		method, path, _ := strings.Cut(route, " ")
+		assert.Contains(t, doc.Paths[path], strings.ToLower(method), route)
+	}
+	for _, ref := range regexp.MustCompile(`"#/components/schemas/(\w+)"`).FindAllStringSubmatch(string(data), -1) {
+		assert.Contains(t, doc.Components.Schemas, ref[1])
+	}
+
+	var job map[string]any
+	require.NoError(t, json.Unmarshal(doc.Components.Schemas["SyncJob"], &job))
+	assert.ElementsMatch(t, []any{"created_at", "id", "status"}, job["required"])
+	assert.Equal(t, "date-time", job["properties"].(map[string]any)["finished_at"].(map[string]any)["format"])
+}
+
+func TestNewMux_ServesOpenAPIWithoutKey(t *testing.T) {
+	h, _ := newTestAPI(t, APIOptions{APIKeys: func() []string { return []string{"secret"} }})
+	var doc map[string]any
+	require.Equal(t, http.StatusOK, get(t, h, "/api/openapi.json", &doc))
+	assert.Equal(t, "3.1.0", doc["openapi"])
+}
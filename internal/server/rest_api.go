@@ -0,0 +1,450 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"docod/internal/generator"
+	"docod/internal/graph"
+	"docod/internal/knowledge"
+)
+
+const (
+	defaultSearchTopK = 10
+	maxSearchTopK     = 50
+	defaultAskTopK    = 8
+	maxAskTopK        = 20
+	// maxSyncJobs bounds the finished jobs kept for status polling.
+	maxSyncJobs = 20
+)
+
+// Searcher runs semantic code search; *knowledge.Engine implements it.
+type Searcher interface {
+	SearchByText(ctx context.Context, query string, topK int, excludeID string) ([]knowledge.SearchChunk, error)
+}
+
+// Backend serves the search and ask endpoints.
+type Backend struct {
+	Search     Searcher
+	Summarizer knowledge.Summarizer
+	// Err explains why Search is nil, e.g. a missing embedding key.
+	Err error
+	// Graph is the graph the backend was built from; when set, the graph
+	// routes of APIOptions.Graphs switch to it with the backend.
+	Graph *graph.Graph
+}
+
+// SyncFunc runs one documentation sync and returns the backend to serve
+// afterwards, or nil to keep the current one.
+type SyncFunc func(ctx context.Context) (*Backend, error)
+
+// APIOptions configures the /api routes.
+type APIOptions struct {
+	// ModelPath and ReportPath locate doc_model.json and pipeline_report.json;
+	// both are read per request, so a sync is visible immediately.
+	ModelPath  string
+	ReportPath string
+	Sync       SyncFunc
+	// APIKeys returns the accepted keys. It is called per request so that
+	// keys rotated in the config apply without a restart; no keys leaves the
+	// API open unless RequireKey is set.
+	APIKeys func() []string
+	// RequireKey refuses every request while no key is configured, for an
+	// API reachable beyond the loopback interface.
+	RequireKey bool
+	// Graphs serves the graph routes; SetBackend moves it to the graph of
+	// the new backend.
+	Graphs *GraphAPI
+}
+
+// IsLoopback reports whether the listen address addr only accepts local
+// connections. An address without a host listens on every interface.
+func IsLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// Sync job states.
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// SyncJob is the status of an asynchronous /api/sync run.
+type SyncJob struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// SearchResult is one hit returned by /api/search.
+type SearchResult struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Package     string  `json:"package"`
+	UnitType    string  `json:"unit_type"`
+	Location    string  `json:"location"`
+	Signature   string  `json:"signature,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Score       float64 `json:"score"`
+}
+
+// NewSearchResult converts a search hit for clients.
+func NewSearchResult(c knowledge.SearchChunk) SearchResult {
+	return SearchResult{
+		ID:          c.ID,
+		Name:        c.Name,
+		Package:     c.Package,
+		UnitType:    c.UnitType,
+		Location:    knowledge.ChunkLocation(c),
+		Signature:   c.Signature,
+		Description: c.Description,
+		Score:       c.Score,
+	}
+}
+
+// SearchResponse is returned by /api/search.
+type SearchResponse struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results"`
+}
+
+// AskRequest is the body of /api/ask.
+type AskRequest struct {
+	Question string `json:"question"`
+	TopK     int    `json:"top_k,omitempty"`
+}
+
+// AskResponse is returned by /api/ask. The answer cites Sources by location.
+type AskResponse struct {
+	Question string                `json:"question"`
+	Answer   string                `json:"answer"`
+	Sources  []generator.SourceRef `json:"sources"`
+}
+
+// SectionSummary lists a section in /api/sections.
+type SectionSummary struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Summary string `json:"summary,omitempty"`
+}
+
+// RESTAPI serves search, Q&A, generated docs and sync jobs under /api for
+// portals that embed docod.
+type RESTAPI struct {
+	opts    APIOptions
+	backend atomic.Pointer[Backend]
+
+	mu      sync.Mutex
+	jobs    map[string]*SyncJob
+	order   []string
+	running string
+	seq     int
+}
+
+// NewRESTAPI serves b; a nil backend answers search and ask with 503.
+func NewRESTAPI(b *Backend, opts APIOptions) *RESTAPI {
+	a := &RESTAPI{opts: opts, jobs: make(map[string]*SyncJob)}
+	a.SetBackend(b)
+	return a
+}
+
+// SetBackend swaps the backend used by later requests.
+func (a *RESTAPI) SetBackend(b *Backend) {
+	if b == nil {
+		b = &Backend{Err: errors.New("search backend not initialized")}
+	}
+	if b.Graph != nil && a.opts.Graphs != nil {
+		a.opts.Graphs.SetGraph(b.Graph)
+	}
+	a.backend.Store(b)
+}
+
+// Backend returns the backend serving requests now.
+func (a *RESTAPI) Backend() *Backend {
+	return a.backend.Load()
+}
+
+// Register mounts the /api routes on mux, with /search, /docs/sections and
+// /docs/sections/{id} as aliases of their /api routes.
+func (a *RESTAPI) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/search", a.Auth(a.handleSearch))
+	mux.HandleFunc("POST /api/ask", a.Auth(a.handleAsk))
+	mux.HandleFunc("GET /api/sections", a.Auth(a.handleSections))
+	mux.HandleFunc("GET /api/sections/{id}", a.Auth(a.handleSection))
+	mux.HandleFunc("GET /api/report", a.Auth(a.handleReport))
+	mux.HandleFunc("POST /api/sync", a.Auth(a.handleSync))
+	mux.HandleFunc("GET /api/sync/{id}", a.Auth(a.handleSyncStatus))
+
+	mux.HandleFunc("GET /search", a.Auth(a.handleSearch))
+	mux.HandleFunc("GET /docs/sections", a.Auth(a.handleSections))
+	mux.HandleFunc("GET /docs/sections/{id}", a.Auth(a.handleSection))
+}
+
+// Auth guards next with the API keys, accepting "Authorization: Bearer KEY"
+// or "X-API-Key: KEY".
+func (a *RESTAPI) Auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var keys []string
+		if a.opts.APIKeys != nil {
+			keys = a.opts.APIKeys()
+		}
+		if len(keys) == 0 {
+			if a.opts.RequireKey {
+				writeError(w, http.StatusServiceUnavailable, "no API key is configured; set server.api_keys")
+				return
+			}
+			next(w, r)
+			return
+		}
+		given := r.Header.Get("X-API-Key")
+		if v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			given = v
+		}
+		given = strings.TrimSpace(given)
+		for _, k := range keys {
+			if k != "" && subtle.ConstantTimeCompare([]byte(given), []byte(k)) == 1 {
+				next(w, r)
+				return
+			}
+		}
+		w.Header().Set("WWW-Authenticate", `Bearer realm="docod"`)
+		writeError(w, http.StatusUnauthorized, "missing or invalid API key")
+	}
+}
+
+// handleSearch serves ?q=&top_k=.
+func (a *RESTAPI) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := strings.TrimSpace(q.Get("q"))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	topK, err := intParam(q.Get("top_k"), defaultSearchTopK, maxSearchTopK)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid top_k")
+		return
+	}
+	b := a.backend.Load()
+	if b.Search == nil {
+		writeError(w, http.StatusServiceUnavailable, "search unavailable: "+errString(b.Err))
+		return
+	}
+	chunks, err := b.Search.SearchByText(r.Context(), query, topK, "")
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "search failed: "+err.Error())
+		return
+	}
+	resp := SearchResponse{Query: query, Results: make([]SearchResult, 0, len(chunks))}
+	for _, c := range chunks {
+		resp.Results = append(resp.Results, NewSearchResult(c))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (a *RESTAPI) handleAsk(w http.ResponseWriter, r *http.Request) {
+	var req AskRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	req.Question = strings.TrimSpace(req.Question)
+	if req.Question == "" {
+		writeError(w, http.StatusBadRequest, "question is required")
+		return
+	}
+	topK := req.TopK
+	if topK <= 0 {
+		topK = defaultAskTopK
+	}
+	topK = min(topK, maxAskTopK)
+
+	b := a.backend.Load()
+	answerer, ok := b.Summarizer.(knowledge.QuestionAnswerer)
+	if b.Search == nil || !ok {
+		writeError(w, http.StatusServiceUnavailable, "ask unavailable: "+errString(b.Err))
+		return
+	}
+	chunks, err := b.Search.SearchByText(r.Context(), req.Question, topK, "")
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "search failed: "+err.Error())
+		return
+	}
+	answer, err := answerer.AnswerQuestion(r.Context(), req.Question, chunks)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "answer failed: "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, AskResponse{
+		Question: req.Question,
+		Answer:   answer,
+		Sources:  generator.MergeSources(nil, chunks),
+	})
+}
+
+func (a *RESTAPI) handleSections(w http.ResponseWriter, r *http.Request) {
+	model, ok := a.loadModel(w)
+	if !ok {
+		return
+	}
+	out := make([]SectionSummary, 0, len(model.Sections))
+	for _, s := range model.Sections {
+		out = append(out, SectionSummary{ID: s.ID, Title: s.Title, Summary: s.Summary})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (a *RESTAPI) handleSection(w http.ResponseWriter, r *http.Request) {
+	model, ok := a.loadModel(w)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	sec := model.SectionByID(id)
+	if sec == nil {
+		writeError(w, http.StatusNotFound, "section not found: "+id)
+		return
+	}
+	writeJSON(w, http.StatusOK, sec)
+}
+
+func (a *RESTAPI) loadModel(w http.ResponseWriter) (*generator.DocModel, bool) {
+	model, err := generator.LoadDocModel(a.opts.ModelPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		writeError(w, http.StatusNotFound, "no documentation generated yet; run a sync")
+		return nil, false
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load doc model: "+err.Error())
+		return nil, false
+	}
+	return model, true
+}
+
+func (a *RESTAPI) handleReport(w http.ResponseWriter, r *http.Request) {
+	data, err := os.ReadFile(a.opts.ReportPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		writeError(w, http.StatusNotFound, "no pipeline report yet; run a full generate")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(data)
+}
+
+// handleSync starts a sync in the background and returns its job with 202.
+// Only one sync runs at a time; a request during a run gets 409 and the
+// running job.
+func (a *RESTAPI) handleSync(w http.ResponseWriter, r *http.Request) {
+	if a.opts.Sync == nil {
+		writeError(w, http.StatusNotImplemented, "sync is not enabled on this server")
+		return
+	}
+	a.mu.Lock()
+	if a.running != "" {
+		job := *a.jobs[a.running]
+		a.mu.Unlock()
+		writeJSON(w, http.StatusConflict, job)
+		return
+	}
+	a.seq++
+	job := &SyncJob{ID: fmt.Sprintf("sync-%d", a.seq), Status: JobQueued, CreatedAt: time.Now().UTC()}
+	a.jobs[job.ID] = job
+	a.order = append(a.order, job.ID)
+	a.running = job.ID
+	a.pruneJobsLocked()
+	snapshot := *job
+	a.mu.Unlock()
+
+	go a.runSync(job.ID)
+	w.Header().Set("Location", "/api/sync/"+job.ID)
+	writeJSON(w, http.StatusAccepted, snapshot)
+}
+
+func (a *RESTAPI) runSync(id string) {
+	a.setJob(id, func(j *SyncJob) { j.Status = JobRunning })
+	backend, err := a.opts.Sync(context.Background())
+	if err == nil && backend != nil {
+		a.SetBackend(backend)
+	}
+	a.setJob(id, func(j *SyncJob) {
+		now := time.Now().UTC()
+		j.FinishedAt = &now
+		j.Status = JobSucceeded
+		if err != nil {
+			j.Status, j.Error = JobFailed, err.Error()
+		}
+	})
+	a.mu.Lock()
+	a.running = ""
+	a.mu.Unlock()
+}
+
+func (a *RESTAPI) setJob(id string, fn func(*SyncJob)) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if j, ok := a.jobs[id]; ok {
+		fn(j)
+	}
+}
+
+// pruneJobsLocked forgets the oldest finished jobs beyond maxSyncJobs.
+func (a *RESTAPI) pruneJobsLocked() {
+	for len(a.order) > maxSyncJobs {
+		oldest := a.order[0]
+		if oldest == a.running {
+			return
+		}
+		delete(a.jobs, oldest)
+		a.order = a.order[1:]
+	}
+}
+
+func (a *RESTAPI) handleSyncStatus(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	job, ok := a.jobs[r.PathValue("id")]
+	var snapshot SyncJob
+	if ok {
+		snapshot = *job
+	}
+	a.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "sync job not found: "+r.PathValue("id"))
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot)
+}
+
+func errString(err error) string {
+	if err == nil {
+		return "not configured"
+	}
+	return err.Error()
+}
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"docod/internal/generator"
+	"docod/internal/graph"
+	"docod/internal/knowledge"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubSearcher []knowledge.SearchChunk
+
+func (s stubSearcher) SearchByText(_ context.Context, _ string, topK int, _ string) ([]knowledge.SearchChunk, error) {
+	return s[:min(topK, len(s))], nil
+}
+
+type stubAnswerer struct {
+	knowledge.Summarizer
+	question string
+}
+
+func (s *stubAnswerer) AnswerQuestion(_ context.Context, question string, evidence []knowledge.SearchChunk) (string, error) {
+	s.question = question
+	return "Open connects [" + knowledge.ChunkLocation(evidence[0]) + "].", nil
+}
+
+func apiRequest(t *testing.T, h http.Handler, method, path, key, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func newTestAPI(t *testing.T, opts APIOptions) (http.Handler, *RESTAPI) {
+	t.Helper()
+	chunks := stubSearcher{{
+		ID:       "go/store:function:Open:2",
+		Name:     "Open",
+		UnitType: "function",
+		Sources:  []knowledge.ChunkSource{{SymbolID: "go/store:function:Open:2", FilePath: "store/db.go", StartLine: 10, EndLine: 20, Relation: "primary"}},
+	}}
+	if opts.Graphs == nil {
+		opts.Graphs = NewGraphAPI(testGraph())
+	}
+	api := NewRESTAPI(&Backend{Search: chunks, Summarizer: &stubAnswerer{}}, opts)
+	mux := NewMux(opts.Graphs, api.Auth)
+	api.Register(mux)
+	return mux, api
+}
+
+func TestRESTAPI_RequiresAPIKey(t *testing.T) {
+	h, _ := newTestAPI(t, APIOptions{APIKeys: func() []string { return []string{"secret"} }})
+
+	assert.Equal(t, http.StatusUnauthorized, apiRequest(t, h, http.MethodGet, "/api/search?q=open", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, apiRequest(t, h, http.MethodGet, "/api/search?q=open", "wrong", "").Code)
+	assert.Equal(t, http.StatusOK, apiRequest(t, h, http.MethodGet, "/api/search?q=open", "secret", "").Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search?q=open", nil)
+	req.Header.Set("X-API-Key", "secret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, http.StatusUnauthorized, apiRequest(t, h, http.MethodGet, "/graph/node/go/store:function:Open:2", "", "").Code)
+	assert.Equal(t, http.StatusOK, apiRequest(t, h, http.MethodGet, "/graph/node/go/store:function:Open:2", "secret", "").Code)
+	assert.Equal(t, http.StatusOK, apiRequest(t, h, http.MethodGet, "/healthz", "", "").Code, "health stays public")
+}
+
+func TestRESTAPI_RequireKeyWithoutKeys(t *testing.T) {
+	h, _ := newTestAPI(t, APIOptions{RequireKey: true, APIKeys: func() []string { return nil }})
+	assert.Equal(t, http.StatusServiceUnavailable, apiRequest(t, h, http.MethodGet, "/api/search?q=open", "", "").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, apiRequest(t, h, http.MethodPost, "/api/sync", "", "").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, apiRequest(t, h, http.MethodGet, "/graph/node/go/store:function:Open:2", "", "").Code, "graph routes return source")
+	assert.Equal(t, http.StatusServiceUnavailable, apiRequest(t, h, http.MethodGet, "/graph/neighbors?id=x", "", "").Code)
+	assert.Equal(t, http.StatusOK, apiRequest(t, h, http.MethodGet, "/healthz", "", "").Code)
+}
+
+func TestIsLoopback(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"127.0.0.1:8765": true,
+		"localhost:8765": true,
+		"[::1]:8765":     true,
+		":8765":          false,
+		"0.0.0.0:8765":   false,
+		"10.0.0.5:8765":  false,
+		"example.com:80": false,
+	} {
+		assert.Equal(t, want, IsLoopback(addr), addr)
+	}
+}
+
+func TestRESTAPI_SearchAndAsk(t *testing.T) {
+	h, api := newTestAPI(t, APIOptions{})
+
+	rec := apiRequest(t, h, http.MethodGet, "/api/search?q=open+db&top_k=5", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var search SearchResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &search))
+	require.Len(t, search.Results, 1)
+	assert.Equal(t, "store/db.go:10-20", search.Results[0].Location)
+	assert.Equal(t, http.StatusBadRequest, apiRequest(t, h, http.MethodGet, "/api/search", "", "").Code)
+
+	rec = apiRequest(t, h, http.MethodPost, "/api/ask", "", `{"question":"How is the store opened?"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var ask AskResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ask))
+	assert.Contains(t, ask.Answer, "[store/db.go:10-20]")
+	require.Len(t, ask.Sources, 1)
+	assert.Equal(t, 10, ask.Sources[0].StartLine)
+	assert.Equal(t, http.StatusBadRequest, apiRequest(t, h, http.MethodPost, "/api/ask", "", `{}`).Code)
+
+	api.SetBackend(&Backend{Err: errors.New("embedding API key not configured")})
+	rec = apiRequest(t, h, http.MethodGet, "/api/search?q=open", "", "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "embedding API key")
+}
+
+func TestRESTAPI_SectionsAndReport(t *testing.T) {
+	dir := t.TempDir()
+	opts := APIOptions{ModelPath: filepath.Join(dir, "doc_model.json"), ReportPath: filepath.Join(dir, "pipeline_report.json")}
+	h, _ := newTestAPI(t, opts)
+
+	assert.Equal(t, http.StatusNotFound, apiRequest(t, h, http.MethodGet, "/api/sections/overview", "", "").Code)
+	assert.Equal(t, http.StatusNotFound, apiRequest(t, h, http.MethodGet, "/api/report", "", "").Code)
+
+	require.NoError(t, os.WriteFile(opts.ModelPath, []byte(`{"sections":[{"id":"overview","title":"Overview","content_md":"# Overview"}]}`), 0o644))
+	require.NoError(t, os.WriteFile(opts.ReportPath, []byte(`{"mode":"full_generate"}`), 0o644))
+
+	rec := apiRequest(t, h, http.MethodGet, "/api/sections/overview", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var sec generator.ModelSect
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sec))
+	assert.Equal(t, "# Overview", sec.ContentMD)
+	assert.Equal(t, http.StatusNotFound, apiRequest(t, h, http.MethodGet, "/api/sections/missing", "", "").Code)
+
+	rec = apiRequest(t, h, http.MethodGet, "/api/report", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"mode":"full_generate"}`, rec.Body.String())
+}
+
+func TestRESTAPI_Aliases(t *testing.T) {
+	dir := t.TempDir()
+	opts := APIOptions{ModelPath: filepath.Join(dir, "doc_model.json"), APIKeys: func() []string { return []string{"secret"} }}
+	require.NoError(t, os.WriteFile(opts.ModelPath, []byte(`{"sections":[{"id":"overview","title":"Overview","content_md":"# Overview"}]}`), 0o644))
+	h, _ := newTestAPI(t, opts)
+
+	for alias, route := range map[string]string{
+		"/search?q=open":          "/api/search?q=open",
+		"/docs/sections":          "/api/sections",
+		"/docs/sections/overview": "/api/sections/overview",
+	} {
+		assert.Equal(t, http.StatusUnauthorized, apiRequest(t, h, http.MethodGet, alias, "", "").Code, alias)
+		rec := apiRequest(t, h, http.MethodGet, alias, "secret", "")
+		require.Equal(t, http.StatusOK, rec.Code, alias)
+		assert.Equal(t, apiRequest(t, h, http.MethodGet, route, "secret", "").Body.String(), rec.Body.String(), alias)
+	}
+	rec := apiRequest(t, h, http.MethodGet, "/health", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, apiRequest(t, h, http.MethodGet, "/healthz", "", "").Body.String(), rec.Body.String())
+}
+
+func TestRESTAPI_SyncRunsAsJob(t *testing.T) {
+	release := make(chan struct{})
+	h, _ := newTestAPI(t, APIOptions{Sync: func(ctx context.Context) (*Backend, error) {
+		<-release
+		return nil, errors.New("git not available")
+	}})
+
+	rec := apiRequest(t, h, http.MethodPost, "/api/sync", "", "")
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	var job SyncJob
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+	assert.Equal(t, "/api/sync/"+job.ID, rec.Header().Get("Location"))
+
+	assert.Equal(t, http.StatusConflict, apiRequest(t, h, http.MethodPost, "/api/sync", "", "").Code, "one sync at a time")
+	close(release)
+
+	require.Eventually(t, func() bool {
+		rec := apiRequest(t, h, http.MethodGet, "/api/sync/"+job.ID, "", "")
+		_ = json.Unmarshal(rec.Body.Bytes(), &job)
+		return job.Status == JobFailed
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "git not available", job.Error)
+	assert.NotNil(t, job.FinishedAt)
+	assert.Equal(t, http.StatusNotFound, apiRequest(t, h, http.MethodGet, "/api/sync/sync-99", "", "").Code)
+}
+
+func TestRESTAPI_SyncSwapsGraph(t *testing.T) {
+	synced := testGraph()
+	synced.AddSymbol(&graph.Symbol{ID: "go/store:function:Close:5", Name: "Close", UnitType: "function"})
+	h, _ := newTestAPI(t, APIOptions{Sync: func(ctx context.Context) (*Backend, error) {
+		return &Backend{Search: stubSearcher{}, Graph: synced}, nil
+	}})
+	assert.Equal(t, http.StatusNotFound, get(t, h, "/graph/node/go/store:function:Close:5", nil))
+
+	rec := apiRequest(t, h, http.MethodPost, "/api/sync", "", "")
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	var job SyncJob
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+	require.Eventually(t, func() bool {
+		rec := apiRequest(t, h, http.MethodGet, "/api/sync/"+job.ID, "", "")
+		_ = json.Unmarshal(rec.Body.Bytes(), &job)
+		return job.Status == JobSucceeded
+	}, 2*time.Second, 10*time.Millisecond)
+
+	var node NodeResponse
+	assert.Equal(t, http.StatusOK, get(t, h, "/graph/node/go/store:function:Close:5", &node))
+	assert.Equal(t, "Close", node.Node.Name)
+	var health Health
+	require.Equal(t, http.StatusOK, get(t, h, "/healthz", &health))
+	assert.Equal(t, 5, health.Nodes)
+}
@@ -0,0 +1,22 @@
+package server
+
+import (
+	"net/http"
+)
+
+// NewMux builds the HTTP routes served by `docod serve` over the graph of
+// graphs. auth guards the graph routes, which return symbol source, e.g.
+// with RESTAPI.Auth; nil leaves them open. /healthz (also /health) and the
+// OpenAPI document stay public.
+func NewMux(graphs *GraphAPI, auth func(http.HandlerFunc) http.HandlerFunc) *http.ServeMux {
+	mux := http.NewServeMux()
+	health := func(w http.ResponseWriter, r *http.Request) {
+		g := graphs.Graph()
+		writeJSON(w, http.StatusOK, Health{Status: "ok", Nodes: len(g.Nodes), Edges: len(g.Edges)})
+	}
+	mux.HandleFunc("GET /healthz", health)
+	mux.HandleFunc("GET /health", health)
+	mux.HandleFunc("GET /api/openapi.json", handleOpenAPI)
+	graphs.Register(mux, auth)
+	return mux
+}
@@ -0,0 +1,316 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"docod/internal/generator"
+	"docod/internal/knowledge"
+)
+
+const (
+	// slackMaxSkew rejects signed requests older than this, against replay.
+	slackMaxSkew = 5 * time.Minute
+	// slackMaxSources caps the sources cited under an answer.
+	slackMaxSources = 8
+	// slackAnswerTimeout bounds the search and LLM call behind one command.
+	slackAnswerTimeout = 2 * time.Minute
+	// slackMaxBlockText is the longest text a section block may hold.
+	slackMaxBlockText = 3000
+	slackUsage        = "Usage: `/docod ask <question>` answers from the documented code of %s with cited sources."
+)
+
+// SlackOptions configures the Slack slash command endpoint.
+type SlackOptions struct {
+	// SigningSecret returns the app's signing secret. It is called per request
+	// so a rotated secret applies without a restart.
+	SigningSecret func() string
+	// Repo names the project this server documents.
+	Repo string
+	// Channels maps Slack channel IDs to the repo they ask about. Commands
+	// from channels mapped to another repo are declined; with no mapping at
+	// all every channel may ask.
+	Channels func() map[string]string
+	// Backend returns the current search and answer backend.
+	Backend func() *Backend
+	// Client posts answers to Slack's response_url; nil uses
+	// http.DefaultClient.
+	Client *http.Client
+	// Now is the clock used for signature timestamps; nil uses time.Now.
+	Now func() time.Time
+}
+
+// SlackBot answers `/docod ask <question>` slash commands with the RAG
+// pipeline behind /api/ask. Slack expects a reply within three seconds, so
+// the command is acknowledged at once and the answer is posted to the
+// command's response_url when ready.
+type SlackBot struct {
+	opts SlackOptions
+}
+
+// NewSlackBot returns a bot for opts.
+func NewSlackBot(opts SlackOptions) *SlackBot {
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &SlackBot{opts: opts}
+}
+
+// Register mounts POST /slack/commands on mux.
+func (s *SlackBot) Register(mux *http.ServeMux) {
+	mux.HandleFunc("POST /slack/commands", s.handleCommand)
+}
+
+// slackMessage is a slash command response.
+type slackMessage struct {
+	ResponseType    string       `json:"response_type"`
+	Text            string       `json:"text"`
+	Blocks          []slackBlock `json:"blocks,omitempty"`
+	ReplaceOriginal bool         `json:"replace_original,omitempty"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func (s *SlackBot) handleCommand(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<16))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if !s.verify(r.Header, body) {
+		writeError(w, http.StatusUnauthorized, "invalid Slack signature")
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid form body")
+		return
+	}
+
+	verb, question, _ := strings.Cut(strings.TrimSpace(form.Get("text")), " ")
+	question = strings.TrimSpace(question)
+	if !strings.EqualFold(verb, "ask") || question == "" {
+		writeJSON(w, http.StatusOK, ephemeral(fmt.Sprintf(slackUsage, s.repoLabel())))
+		return
+	}
+	if msg := s.channelDenied(form.Get("channel_id")); msg != "" {
+		writeJSON(w, http.StatusOK, ephemeral(msg))
+		return
+	}
+	responseURL := form.Get("response_url")
+	if responseURL == "" {
+		writeError(w, http.StatusBadRequest, "response_url is required")
+		return
+	}
+
+	go s.answer(responseURL, form.Get("user_id"), question)
+	writeJSON(w, http.StatusOK, ephemeral(fmt.Sprintf("Looking through %s for: _%s_", s.repoLabel(), slackEscape(question))))
+}
+
+// verify checks Slack's v0 request signature over the raw body.
+func (s *SlackBot) verify(h http.Header, body []byte) bool {
+	secret := ""
+	if s.opts.SigningSecret != nil {
+		secret = s.opts.SigningSecret()
+	}
+	if secret == "" {
+		return false
+	}
+	ts, err := strconv.ParseInt(h.Get("X-Slack-Request-Timestamp"), 10, 64)
+	if err != nil {
+		return false
+	}
+	if skew := s.opts.Now().Sub(time.Unix(ts, 0)); skew > slackMaxSkew || skew < -slackMaxSkew {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%d:", ts)
+	mac.Write(body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(want), []byte(h.Get("X-Slack-Signature")))
+}
+
+// channelDenied explains why a channel may not ask, or returns "".
+func (s *SlackBot) channelDenied(channelID string) string {
+	var channels map[string]string
+	if s.opts.Channels != nil {
+		channels = s.opts.Channels()
+	}
+	if len(channels) == 0 {
+		return ""
+	}
+	repo, ok := channels[channelID]
+	switch {
+	case !ok:
+		return "docod is not enabled in this channel; add it to server.slack.channels."
+	case s.opts.Repo != "" && !strings.EqualFold(repo, s.opts.Repo):
+		return fmt.Sprintf("This channel asks about %s, but this docod server documents %s.", repo, s.opts.Repo)
+	}
+	return ""
+}
+
+func (s *SlackBot) repoLabel() string {
+	if s.opts.Repo == "" {
+		return "this project"
+	}
+	return "*" + s.opts.Repo + "*"
+}
+
+// answer runs the question through search and the LLM and posts the result
+// to responseURL in the channel.
+func (s *SlackBot) answer(responseURL, userID, question string) {
+	ctx, cancel := context.WithTimeout(context.Background(), slackAnswerTimeout)
+	defer cancel()
+
+	msg, err := s.buildAnswer(ctx, userID, question)
+	if err != nil {
+		msg = ephemeral("Sorry, I could not answer that: " + err.Error())
+	}
+	if err := s.post(ctx, responseURL, msg); err != nil {
+		log.Printf("Warning: failed to post Slack answer: %v", err)
+	}
+}
+
+func (s *SlackBot) buildAnswer(ctx context.Context, userID, question string) (*slackMessage, error) {
+	var b *Backend
+	if s.opts.Backend != nil {
+		b = s.opts.Backend()
+	}
+	if b == nil {
+		return nil, fmt.Errorf("ask unavailable: not configured")
+	}
+	answerer, ok := b.Summarizer.(knowledge.QuestionAnswerer)
+	if b.Search == nil || !ok {
+		return nil, fmt.Errorf("ask unavailable: %s", errString(b.Err))
+	}
+	chunks, err := b.Search.SearchByText(ctx, question, defaultAskTopK, "")
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
+	answer, err := answerer.AnswerQuestion(ctx, question, chunks)
+	if err != nil {
+		return nil, fmt.Errorf("answer failed: %w", err)
+	}
+	text := slackMrkdwn(answer)
+	if r := []rune(text); len(r) > slackMaxBlockText {
+		text = string(r[:slackMaxBlockText-1]) + "…"
+	}
+
+	asked := "Q: " + slackEscape(question)
+	if userID != "" {
+		asked = fmt.Sprintf("<@%s> asked: %s", userID, slackEscape(question))
+	}
+	msg := &slackMessage{
+		ResponseType:    "in_channel",
+		ReplaceOriginal: true,
+		Text:            answer,
+		Blocks: []slackBlock{
+			{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: asked}}},
+			{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}},
+		},
+	}
+	if cited := formatSlackSources(generator.MergeSources(nil, chunks)); cited != "" {
+		msg.Blocks = append(msg.Blocks, slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: cited}}})
+	}
+	return msg, nil
+}
+
+func (s *SlackBot) post(ctx context.Context, responseURL string, msg *slackMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(text)))
+	}
+	return nil
+}
+
+func ephemeral(text string) *slackMessage {
+	return &slackMessage{ResponseType: "ephemeral", Text: text}
+}
+
+// formatSlackSources lists cited locations as inline code.
+func formatSlackSources(sources []generator.SourceRef) string {
+	var parts []string
+	seen := make(map[string]bool)
+	for _, src := range sources {
+		if src.FilePath == "" {
+			continue
+		}
+		loc := src.FilePath
+		switch {
+		case src.StartLine > 0 && src.EndLine > src.StartLine:
+			loc = fmt.Sprintf("%s:%d-%d", src.FilePath, src.StartLine, src.EndLine)
+		case src.StartLine > 0:
+			loc = fmt.Sprintf("%s:%d", src.FilePath, src.StartLine)
+		}
+		if seen[loc] {
+			continue
+		}
+		seen[loc] = true
+		parts = append(parts, "`"+loc+"`")
+		if len(parts) == slackMaxSources {
+			break
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "Sources: " + strings.Join(parts, ", ")
+}
+
+var (
+	mdBold    = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	mdLink    = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdHeading = regexp.MustCompile(`(?m)^#{1,6}\s+(.+)$`)
+)
+
+// slackEscape escapes the characters Slack treats as control sequences.
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// slackMrkdwn rewrites the Markdown answer into Slack's mrkdwn dialect.
+func slackMrkdwn(md string) string {
+	md = slackEscape(md)
+	md = mdHeading.ReplaceAllString(md, "*$1*")
+	md = mdBold.ReplaceAllString(md, "*$1*")
+	return mdLink.ReplaceAllString(md, "<$2|$1>")
+}
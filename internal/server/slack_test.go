@@ -0,0 +1,117 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"docod/internal/generator"
+	"docod/internal/knowledge"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var slackNow = time.Unix(1_700_000_000, 0)
+
+func slackRequest(t *testing.T, h http.Handler, secret string, form url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	body := form.Encode()
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%d:%s", slackNow.Unix(), body)
+	req := httptest.NewRequest(http.MethodPost, "/slack/commands", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", fmt.Sprint(slackNow.Unix()))
+	req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func newSlackMux(backend *Backend, channels map[string]string) *http.ServeMux {
+	mux := http.NewServeMux()
+	NewSlackBot(SlackOptions{
+		SigningSecret: func() string { return "shh" },
+		Repo:          "payments",
+		Channels:      func() map[string]string { return channels },
+		Backend:       func() *Backend { return backend },
+		Now:           func() time.Time { return slackNow },
+	}).Register(mux)
+	return mux
+}
+
+func TestSlackBot_AnswersInChannelWithSources(t *testing.T) {
+	posted := make(chan slackMessage, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slackMessage
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&msg))
+		posted <- msg
+	}))
+	defer hook.Close()
+
+	answerer := &stubAnswerer{}
+	backend := &Backend{Search: stubSearcher{
+		{ID: "go/store:function:Open", Name: "Open", Sources: []knowledge.ChunkSource{{SymbolID: "go/store:function:Open", FilePath: "store/db.go", StartLine: 3, EndLine: 5, Relation: "primary"}}},
+	}, Summarizer: answerer}
+	mux := newSlackMux(backend, map[string]string{"C1": "payments"})
+
+	rec := slackRequest(t, mux, "shh", url.Values{
+		"command": {"/docod"}, "text": {"ask how is the <db> opened?"}, "channel_id": {"C1"},
+		"user_id": {"U9"}, "response_url": {hook.URL},
+	})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"response_type":"ephemeral"`)
+	assert.Contains(t, rec.Body.String(), "how is the \\u0026lt;db\\u0026gt; opened?")
+
+	select {
+	case msg := <-posted:
+		assert.Equal(t, "in_channel", msg.ResponseType)
+		assert.Equal(t, "how is the <db> opened?", answerer.question)
+		require.Len(t, msg.Blocks, 3)
+		assert.Equal(t, "<@U9> asked: how is the &lt;db&gt; opened?", msg.Blocks[0].Elements[0].Text)
+		assert.Equal(t, "Open connects [store/db.go:3-5].", msg.Blocks[1].Text.Text)
+		assert.Equal(t, "Sources: `store/db.go:3-5`", msg.Blocks[2].Elements[0].Text)
+	case <-time.After(5 * time.Second):
+		t.Fatal("answer was not posted to response_url")
+	}
+}
+
+func TestSlackBot_RejectsBadSignatureAndForeignChannels(t *testing.T) {
+	mux := newSlackMux(&Backend{}, map[string]string{"C1": "payments", "C2": "billing"})
+	form := url.Values{"text": {"ask anything"}, "channel_id": {"C2"}, "response_url": {"http://unused"}}
+
+	assert.Equal(t, http.StatusUnauthorized, slackRequest(t, mux, "wrong", form).Code)
+
+	rec := slackRequest(t, mux, "shh", form)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "This channel asks about billing, but this docod server documents payments.")
+
+	form.Set("channel_id", "C3")
+	assert.Contains(t, slackRequest(t, mux, "shh", form).Body.String(), "not enabled in this channel")
+
+	form.Set("text", "help")
+	assert.Contains(t, slackRequest(t, mux, "shh", form).Body.String(), "Usage: `/docod ask \\u003cquestion\\u003e`")
+}
+
+func TestSlackBot_ReportsUnavailableBackend(t *testing.T) {
+	bot := NewSlackBot(SlackOptions{Backend: func() *Backend { return &Backend{Err: fmt.Errorf("no embedding key")} }})
+	_, err := bot.buildAnswer(t.Context(), "", "why?")
+	assert.EqualError(t, err, "ask unavailable: no embedding key")
+}
+
+func TestSlackMrkdwn(t *testing.T) {
+	assert.Equal(t, "*Setup*\nUse *config* &amp; see <https://x.dev|docs> for a &lt;b&gt;.",
+		slackMrkdwn("## Setup\nUse **config** & see [docs](https://x.dev) for a <b>."))
+	assert.Equal(t, "Sources: `a.go:1-2`, `b.go:7`, `c.go`", formatSlackSources([]generator.SourceRef{
+		{FilePath: "a.go", StartLine: 1, EndLine: 2}, {FilePath: "a.go", StartLine: 1, EndLine: 2},
+		{FilePath: "b.go", StartLine: 7, EndLine: 7}, {FilePath: "c.go"}, {SymbolID: "no-file"},
+	}))
+}
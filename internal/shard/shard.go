@@ -0,0 +1,306 @@
+// Package shard partitions the knowledge graph of a large repository by
+// top-level directory or Go module, so each part can be indexed and
+// documented on its own.
+package shard
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"docod/internal/graph"
+)
+
+// Partition strategies.
+const (
+	ByDirectory = "directory"
+	ByModule    = "module"
+)
+
+// RootName names the shard holding the files no other shard claims. Go
+// ignores directories starting with an underscore, so no directory shard
+// takes the name.
+const RootName = "_root"
+
+// Options controls Partition.
+type Options struct {
+	// By is ByDirectory or ByModule; empty means ByDirectory.
+	By string
+	// Depth is the number of leading directories that name a directory
+	// shard; 0 means 1. Files in shallower directories join the root shard.
+	Depth int
+	// MinSymbols folds shards with fewer symbols into the root shard.
+	MinSymbols int
+	// Modules lists the module directories, relative to the root, that name
+	// module shards. The root module maps to the root shard.
+	Modules []string
+}
+
+// Shard is a part of the graph documented on its own.
+type Shard struct {
+	// Name is Dir, or RootName.
+	Name string
+	// Dir is the slash-separated directory of the shard relative to the
+	// project root; empty for the root shard.
+	Dir     string
+	Symbols int
+	Files   []string
+	// Prefixes and Exclude scope searches to the shard, in the form of the
+	// graph's file paths. A shard never contains another, so only the root
+	// shard excludes anything.
+	Prefixes []string
+	Exclude  []string
+
+	nodes map[string]bool
+}
+
+// Contains reports whether the node id belongs to the shard.
+func (s *Shard) Contains(id string) bool {
+	return s.nodes[id]
+}
+
+// Graph returns the shard's part of g: its symbols with the edges among them
+// and the external dependencies they reference.
+func (s *Shard) Graph(g *graph.Graph) *graph.Graph {
+	externals := make(map[string]bool)
+	for _, e := range g.Edges {
+		if to := g.Nodes[e.To]; s.nodes[e.From] && to != nil && to.Unit != nil && to.Unit.UnitType == graph.UnitTypeExternal {
+			externals[e.To] = true
+		}
+	}
+	return g.Subgraph(func(n *graph.Node) bool {
+		return s.nodes[n.Unit.ID] || externals[n.Unit.ID]
+	})
+}
+
+// Partition splits the file-backed symbols of g into shards. root is the
+// directory the graph's file paths were scanned from, so absolute and
+// relative paths both map to project directories. Shards are sorted by name
+// with the root shard, which always exists, first.
+func Partition(g *graph.Graph, root string, opts Options) []*Shard {
+	if opts.Depth <= 0 {
+		opts.Depth = 1
+	}
+	modules := make([]string, 0, len(opts.Modules))
+	for _, m := range opts.Modules {
+		if m = cleanDir(m); m != "" {
+			modules = append(modules, m)
+		}
+	}
+	// Longest first, so nested modules win over their parents.
+	sort.Slice(modules, func(i, j int) bool { return len(modules[i]) > len(modules[j]) })
+
+	var nodes []*graph.Symbol
+	absolute := false
+	for _, n := range g.Nodes {
+		if n == nil || n.Unit == nil || n.Unit.Filepath == "" || n.Unit.UnitType == graph.UnitTypeExternal {
+			continue
+		}
+		nodes = append(nodes, n.Unit)
+		absolute = absolute || filepath.IsAbs(n.Unit.Filepath)
+	}
+	if absolute {
+		root = baseDir(absRoot(root), nodes)
+	}
+
+	byDir := map[string]*Shard{"": {Name: RootName, nodes: make(map[string]bool)}}
+	files := map[string]map[string]bool{"": {}}
+	for _, u := range nodes {
+		rel := relPath(root, u.Filepath)
+		dir := shardDir(path.Dir(rel), opts, modules)
+		s := byDir[dir]
+		if s == nil {
+			s = &Shard{Name: dir, Dir: dir, nodes: make(map[string]bool)}
+			byDir[dir] = s
+			files[dir] = make(map[string]bool)
+		}
+		s.nodes[u.ID] = true
+		s.Symbols++
+		files[dir][u.Filepath] = true
+	}
+
+	rootShard := byDir[""]
+	for dir, s := range byDir {
+		if dir == "" || s.Symbols >= opts.MinSymbols {
+			continue
+		}
+		for id := range s.nodes {
+			rootShard.nodes[id] = true
+		}
+		rootShard.Symbols += s.Symbols
+		for f := range files[dir] {
+			files[""][f] = true
+		}
+		delete(byDir, dir)
+	}
+
+	out := make([]*Shard, 0, len(byDir))
+	for dir, s := range byDir {
+		for f := range files[dir] {
+			s.Files = append(s.Files, f)
+		}
+		sort.Strings(s.Files)
+		if dir != "" {
+			s.Prefixes = []string{pathForm(root, dir, absolute) + "/"}
+			rootShard.Exclude = append(rootShard.Exclude, pathForm(root, dir, absolute))
+		}
+		out = append(out, s)
+	}
+	if absolute {
+		rootShard.Prefixes = []string{strings.TrimSuffix(filepath.ToSlash(root), "/") + "/"}
+	}
+	sort.Strings(rootShard.Exclude)
+	sort.Slice(out, func(i, j int) bool {
+		if (out[i].Dir == "") != (out[j].Dir == "") {
+			return out[i].Dir == ""
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// shardDir maps the directory of a file to the directory of its shard.
+func shardDir(dir string, opts Options, modules []string) string {
+	dir = cleanDir(dir)
+	if opts.By == ByModule {
+		for _, m := range modules {
+			if dir == m || strings.HasPrefix(dir, m+"/") {
+				return m
+			}
+		}
+		return ""
+	}
+	parts := strings.Split(dir, "/")
+	if dir == "" || len(parts) < opts.Depth {
+		return ""
+	}
+	return strings.Join(parts[:opts.Depth], "/")
+}
+
+// FindModules lists the directories under root holding a go.mod, relative
+// to root, skipping hidden, vendor and testdata directories.
+func FindModules(root string) ([]string, error) {
+	var out []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if p != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() == "go.mod" {
+			rel, err := filepath.Rel(root, filepath.Dir(p))
+			if err != nil {
+				return err
+			}
+			out = append(out, cleanDir(rel))
+		}
+		return nil
+	})
+	sort.Strings(out)
+	return out, err
+}
+
+// Dependency counts the edges from symbols of one shard to another.
+type Dependency struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Edges int    `json:"edges"`
+}
+
+// Dependencies counts the edges of g that cross shards, sorted by shard
+// names.
+func Dependencies(g *graph.Graph, shards []*Shard) []Dependency {
+	owner := make(map[string]string)
+	for _, s := range shards {
+		for id := range s.nodes {
+			owner[id] = s.Name
+		}
+	}
+	counts := make(map[[2]string]int)
+	for _, e := range g.Edges {
+		from, to := owner[e.From], owner[e.To]
+		if from == "" || to == "" || from == to {
+			continue
+		}
+		counts[[2]string{from, to}]++
+	}
+	out := make([]Dependency, 0, len(counts))
+	for k, n := range counts {
+		out = append(out, Dependency{From: k[0], To: k[1], Edges: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].From != out[j].From {
+			return out[i].From < out[j].From
+		}
+		return out[i].To < out[j].To
+	})
+	return out
+}
+
+func cleanDir(dir string) string {
+	dir = path.Clean(filepath.ToSlash(dir))
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	return strings.TrimPrefix(dir, "./")
+}
+
+// baseDir is root when every absolute path lies under it, else the deepest
+// directory the paths share: a database scanned on another machine keeps
+// that machine's paths.
+func baseDir(root string, nodes []*graph.Symbol) string {
+	var common string
+	outside := false
+	for i, u := range nodes {
+		if !filepath.IsAbs(u.Filepath) {
+			continue
+		}
+		if rel, err := filepath.Rel(root, u.Filepath); err != nil || strings.HasPrefix(rel, "..") {
+			outside = true
+		}
+		dir := filepath.Dir(u.Filepath)
+		if i == 0 || common == "" {
+			common = dir
+			continue
+		}
+		for common != filepath.Dir(common) && dir != common && !strings.HasPrefix(dir, common+string(filepath.Separator)) {
+			common = filepath.Dir(common)
+		}
+	}
+	if outside && common != "" {
+		return common
+	}
+	return root
+}
+
+// relPath returns p relative to root in slash form.
+func relPath(root, p string) string {
+	if filepath.IsAbs(p) {
+		if rel, err := filepath.Rel(root, p); err == nil && !strings.HasPrefix(rel, "..") {
+			p = rel
+		}
+	}
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(p)), "./")
+}
+
+// pathForm writes the project directory dir the way the graph writes paths.
+func pathForm(root, dir string, absolute bool) string {
+	if absolute {
+		return filepath.ToSlash(filepath.Join(root, dir))
+	}
+	return dir
+}
+
+func absRoot(root string) string {
+	if abs, err := filepath.Abs(root); err == nil {
+		return abs
+	}
+	return root
+}
@@ -0,0 +1,131 @@
+package shard
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/extractor"
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testGraph holds a command, two internal packages, a top-level file and an
+// external import; run calls store and cmd calls run.
+func testGraph(root string) *graph.Graph {
+	g := graph.NewGraph()
+	unit := func(file, name string, relations ...extractor.Relation) {
+		if root != "" {
+			file = filepath.Join(root, file)
+		}
+		g.AddUnit(&extractor.CodeUnit{ID: file + ":" + name, Name: name, Filepath: file, UnitType: "function", Relations: relations})
+	}
+	unit("cmd/app/main.go", "main", extractor.Relation{Target: "Run", Kind: "calls"})
+	unit("internal/run/run.go", "Run", extractor.Relation{Target: "Save", Kind: "calls"})
+	unit("internal/store/store.go", "Save")
+	unit("internal/store/load.go", "Load")
+	unit("version.go", "Version")
+	g.AddSymbol(&graph.Symbol{ID: "fmt", Name: "fmt", UnitType: graph.UnitTypeExternal})
+	g.LinkRelations()
+	g.Edges = append(g.Edges, graph.Edge{From: id(g, "Run"), To: "fmt", Kind: graph.RelationUsesType})
+	return g
+}
+
+func id(g *graph.Graph, name string) string {
+	for id, n := range g.Nodes {
+		if n.Unit.Name == name {
+			return id
+		}
+	}
+	return ""
+}
+
+func names(shards []*Shard) []string {
+	out := make([]string, 0, len(shards))
+	for _, s := range shards {
+		out = append(out, s.Name)
+	}
+	return out
+}
+
+func TestPartition_ByDirectory(t *testing.T) {
+	g := testGraph("")
+	shards := Partition(g, ".", Options{})
+	require.Equal(t, []string{RootName, "cmd", "internal"}, names(shards))
+
+	root, internal := shards[0], shards[2]
+	assert.Equal(t, 1, root.Symbols)
+	assert.Equal(t, []string{"version.go"}, root.Files)
+	assert.Equal(t, []string{"cmd", "internal"}, root.Exclude)
+	assert.Empty(t, root.Prefixes)
+	assert.Equal(t, 3, internal.Symbols)
+	assert.Equal(t, []string{"internal/"}, internal.Prefixes)
+	assert.Empty(t, internal.Exclude)
+	assert.False(t, internal.Contains("fmt"), "externals belong to no shard")
+
+	deeper := Partition(g, ".", Options{Depth: 2})
+	assert.Equal(t, []string{RootName, "cmd/app", "internal/run", "internal/store"}, names(deeper))
+}
+
+func TestPartition_FoldsSmallShardsIntoRoot(t *testing.T) {
+	shards := Partition(testGraph(""), ".", Options{MinSymbols: 2})
+	require.Equal(t, []string{RootName, "internal"}, names(shards))
+	assert.Equal(t, 2, shards[0].Symbols)
+	assert.Equal(t, []string{"cmd/app/main.go", "version.go"}, shards[0].Files)
+	assert.Equal(t, []string{"internal"}, shards[0].Exclude)
+}
+
+func TestPartition_AbsolutePaths(t *testing.T) {
+	root := t.TempDir()
+	shards := Partition(testGraph(root), root, Options{})
+	require.Equal(t, []string{RootName, "cmd", "internal"}, names(shards))
+	slash := filepath.ToSlash(root)
+	assert.Equal(t, []string{slash + "/"}, shards[0].Prefixes)
+	assert.Equal(t, []string{slash + "/cmd", slash + "/internal"}, shards[0].Exclude)
+	assert.Equal(t, []string{slash + "/internal/"}, shards[2].Prefixes)
+
+	// A database scanned elsewhere partitions from the directory its paths
+	// share.
+	moved := Partition(testGraph(root), t.TempDir(), Options{})
+	assert.Equal(t, []string{RootName, "cmd", "internal"}, names(moved))
+}
+
+func TestPartition_ByModule(t *testing.T) {
+	shards := Partition(testGraph(""), ".", Options{By: ByModule, Modules: []string{".", "internal", "internal/store"}})
+	require.Equal(t, []string{RootName, "internal", "internal/store"}, names(shards))
+	assert.Equal(t, 2, shards[0].Symbols, "the root module and files outside modules")
+	assert.Equal(t, 1, shards[1].Symbols)
+	assert.Equal(t, 2, shards[2].Symbols, "nested modules win over their parents")
+}
+
+func TestShard_GraphKeepsReferencedExternals(t *testing.T) {
+	g := testGraph("")
+	shards := Partition(g, ".", Options{})
+	sub := shards[2].Graph(g)
+	assert.Len(t, sub.Nodes, 4)
+	assert.Contains(t, sub.Nodes, "fmt")
+	assert.NotContains(t, sub.Nodes, id(g, "main"))
+	for _, e := range sub.Edges {
+		assert.NotNil(t, sub.Nodes[e.From])
+		assert.NotNil(t, sub.Nodes[e.To])
+	}
+}
+
+func TestDependencies_CountsCrossShardEdges(t *testing.T) {
+	g := testGraph("")
+	deps := Dependencies(g, Partition(g, ".", Options{}))
+	assert.Equal(t, []Dependency{{From: "cmd", To: "internal", Edges: 1}}, deps)
+}
+
+func TestFindModules(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{".", "tools", "services/api", "vendor/x", ".git/y"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, dir, "go.mod"), []byte("module m\n"), 0644))
+	}
+	modules, err := FindModules(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "services/api", "tools"}, modules)
+}
@@ -0,0 +1,366 @@
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"docod/internal/generator"
+	"docod/internal/graph"
+)
+
+// Static site generators docod can export to.
+const (
+	FormatMkDocs     = "mkdocs"
+	FormatDocusaurus = "docusaurus"
+)
+
+// SiteFormats lists the supported export formats.
+var SiteFormats = []string{FormatMkDocs, FormatDocusaurus}
+
+var (
+	pageNamePattern    = regexp.MustCompile(`[^a-z0-9_-]+`)
+	leadingHeading     = regexp.MustCompile(`^#{1,6}[ \t]+[^\n]*\n*`)
+	sectionLinkPattern = regexp.MustCompile(`\]\(#([^)\s]+)\)`)
+)
+
+// SiteExportOptions controls a static site export.
+type SiteExportOptions struct {
+	// Format is FormatMkDocs or FormatDocusaurus.
+	Format string
+	// SiteName defaults to the document title.
+	SiteName string
+	// Graph adds its exported symbols to the search index; it may be nil.
+	Graph *graph.Graph
+}
+
+// SitePage is one page of an exported site.
+type SitePage struct {
+	SectionID string
+	Title     string
+	// Path is the page file relative to the docs directory, slash separated.
+	Path     string
+	Position int
+	Children []*SitePage
+}
+
+// SiteExport is the result of ExportSite.
+type SiteExport struct {
+	// Config is the generator's navigation file: mkdocs.yml or sidebars.js.
+	Config string
+	// Pages are the top-level pages in navigation order.
+	Pages []*SitePage
+	// Files counts the Markdown files written, the home page included.
+	Files int
+	// SearchIndex is the search index written among the site's static files.
+	SearchIndex string
+}
+
+// PlanSitePages lays the sections of model out as a page tree: every root
+// section is a page, and a section with subsections becomes a directory
+// whose index.md is the section itself. Empty leaf sections are left out,
+// as in the rendered Markdown.
+func PlanSitePages(model *generator.DocModel) []*SitePage {
+	children := make(map[string][]generator.ModelSect)
+	var roots []generator.ModelSect
+	for _, sec := range model.Sections {
+		if sec.ParentID == nil || *sec.ParentID == "" {
+			roots = append(roots, sec)
+		} else {
+			children[*sec.ParentID] = append(children[*sec.ParentID], sec)
+		}
+	}
+	var build func(secs []generator.ModelSect, dir string) []*SitePage
+	build = func(secs []generator.ModelSect, dir string) []*SitePage {
+		sortSections(secs)
+		used := map[string]bool{"index": true}
+		pages := make([]*SitePage, 0, len(secs))
+		for _, sec := range secs {
+			kids := children[sec.ID]
+			if strings.TrimSpace(sec.ContentMD) == "" && len(kids) == 0 {
+				continue
+			}
+			name := pageName(sec.ID)
+			for base, n := name, 2; used[name]; n++ {
+				name = fmt.Sprintf("%s-%d", base, n)
+			}
+			used[name] = true
+			page := &SitePage{SectionID: sec.ID, Title: sec.Title, Position: len(pages) + 1}
+			if len(kids) > 0 {
+				page.Path = path.Join(dir, name, "index.md")
+				page.Children = build(kids, path.Join(dir, name))
+			} else {
+				page.Path = path.Join(dir, name+".md")
+			}
+			pages = append(pages, page)
+		}
+		return pages
+	}
+	return build(roots, "")
+}
+
+func sortSections(secs []generator.ModelSect) {
+	sort.SliceStable(secs, func(i, j int) bool {
+		if secs[i].Order != secs[j].Order {
+			return secs[i].Order < secs[j].Order
+		}
+		return secs[i].ID < secs[j].ID
+	})
+}
+
+func pageName(id string) string {
+	name := strings.Trim(pageNamePattern.ReplaceAllString(strings.ToLower(id), "-"), "-")
+	if name == "" {
+		return "section"
+	}
+	return name
+}
+
+// ExportSite writes model as a static site source tree under outDir: the
+// pages with front matter under outDir/docs, and mkdocs.yml or sidebars.js
+// next to it. Links to "#<section id>" are rewritten to the page of that
+// section.
+func ExportSite(model *generator.DocModel, outDir string, opts SiteExportOptions) (*SiteExport, error) {
+	format := strings.ToLower(strings.TrimSpace(opts.Format))
+	if format != FormatMkDocs && format != FormatDocusaurus {
+		return nil, fmt.Errorf("unsupported site format %q (use %s)", opts.Format, strings.Join(SiteFormats, " or "))
+	}
+	generator.NormalizeDocModel(model)
+	siteName := strings.TrimSpace(opts.SiteName)
+	if siteName == "" {
+		siteName = strings.TrimSpace(model.Document.Title)
+	}
+	if siteName == "" {
+		siteName = "Project Documentation"
+	}
+
+	pages := PlanSitePages(model)
+	byID := make(map[string]*SitePage)
+	walkPages(pages, func(p *SitePage) { byID[p.SectionID] = p })
+	sections := make(map[string]generator.ModelSect, len(model.Sections))
+	for _, sec := range model.Sections {
+		sections[sec.ID] = sec
+	}
+
+	docsDir := filepath.Join(outDir, "docs")
+	out := &SiteExport{Pages: pages}
+	write := func(rel, content string) error {
+		target := filepath.Join(docsDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		out.Files++
+		return os.WriteFile(target, []byte(content), 0644)
+	}
+
+	if err := write("index.md", homePage(format, siteName, pages)); err != nil {
+		return nil, err
+	}
+	var err error
+	walkPages(pages, func(p *SitePage) {
+		if err == nil {
+			err = write(p.Path, sitePage(format, p, sections[p.SectionID], byID))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var config, name string
+	if format == FormatMkDocs {
+		config, name = mkdocsConfig(siteName, pages), "mkdocs.yml"
+	} else {
+		config, name = docusaurusSidebars(pages), "sidebars.js"
+	}
+	out.Config = filepath.Join(outDir, name)
+	if err := os.WriteFile(out.Config, []byte(config), 0644); err != nil {
+		return nil, err
+	}
+
+	// Index only the sections that got a page, linked to it. MkDocs copies
+	// docs/ and Docusaurus static/ to the site root.
+	indexed := *model
+	indexed.Sections = nil
+	for _, sec := range model.Sections {
+		if _, ok := byID[sec.ID]; ok {
+			indexed.Sections = append(indexed.Sections, sec)
+		}
+	}
+	idx := BuildSearchIndex(&indexed, opts.Graph, SearchIndexOptions{
+		SectionURL: func(sec generator.ModelSect) string { return pageURL(format, byID[sec.ID]) },
+	})
+	out.SearchIndex = filepath.Join(docsDir, SearchIndexFile)
+	if format == FormatDocusaurus {
+		out.SearchIndex = filepath.Join(outDir, "static", SearchIndexFile)
+	}
+	if err := WriteSearchIndex(out.SearchIndex, idx); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// pageURL is the URL of page relative to the site root: MkDocs serves
+// a/b.md at a/b/, Docusaurus serves it at docs/a/b.
+func pageURL(format string, page *SitePage) string {
+	route := strings.TrimSuffix(strings.TrimSuffix(page.Path, ".md"), "index")
+	if format == FormatDocusaurus {
+		return "docs/" + route
+	}
+	if route != "" && !strings.HasSuffix(route, "/") {
+		route += "/"
+	}
+	return route
+}
+
+func walkPages(pages []*SitePage, fn func(*SitePage)) {
+	for _, p := range pages {
+		fn(p)
+		walkPages(p.Children, fn)
+	}
+}
+
+func homePage(format, siteName string, pages []*SitePage) string {
+	var sb strings.Builder
+	if format == FormatDocusaurus {
+		sb.WriteString(frontMatter([][2]string{{"id", "index"}, {"title", siteName}, {"slug", "/"}, {"sidebar_position", "0"}}))
+	} else {
+		sb.WriteString(frontMatter([][2]string{{"title", siteName}}))
+	}
+	sb.WriteString("# " + siteName + "\n\nAuto-generated by `docod`.\n\n")
+	for _, p := range pages {
+		sb.WriteString(fmt.Sprintf("- [%s](%s)\n", p.Title, p.Path))
+	}
+	return sb.String()
+}
+
+func sitePage(format string, page *SitePage, sec generator.ModelSect, byID map[string]*SitePage) string {
+	var sb strings.Builder
+	fields := [][2]string{{"title", page.Title}}
+	if format == FormatDocusaurus {
+		fields = append(fields, [2]string{"sidebar_position", fmt.Sprint(page.Position)})
+	}
+	if summary := excerpt(plainText(sec.Summary)); summary != "" {
+		fields = append(fields, [2]string{"description", summary})
+	}
+	sb.WriteString(frontMatter(fields))
+	sb.WriteString("# " + page.Title + "\n\n")
+
+	body := leadingHeading.ReplaceAllString(strings.TrimSpace(sec.ContentMD), "")
+	body = sectionLinkPattern.ReplaceAllStringFunc(body, func(link string) string {
+		target, ok := byID[sectionLinkPattern.FindStringSubmatch(link)[1]]
+		if !ok {
+			return link
+		}
+		return "](" + relativePageLink(page.Path, target.Path) + ")"
+	})
+	if body != "" {
+		sb.WriteString(body + "\n")
+	}
+	if len(page.Children) > 0 {
+		sb.WriteString("\n## In this section\n\n")
+		for _, child := range page.Children {
+			sb.WriteString(fmt.Sprintf("- [%s](%s)\n", child.Title, relativePageLink(page.Path, child.Path)))
+		}
+	}
+	return sb.String()
+}
+
+// relativePageLink returns the link from the page at from to the page at to,
+// both relative to the docs directory.
+func relativePageLink(from, to string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(from)), filepath.FromSlash(to))
+	if err != nil {
+		return to
+	}
+	return filepath.ToSlash(rel)
+}
+
+// frontMatter renders YAML front matter. Values are JSON strings, which YAML
+// reads unchanged, except for integers.
+func frontMatter(fields [][2]string) string {
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	for _, f := range fields {
+		sb.WriteString(f[0] + ": " + yamlScalar(f[1]) + "\n")
+	}
+	sb.WriteString("---\n\n")
+	return sb.String()
+}
+
+func yamlScalar(s string) string {
+	if s != "" && strings.Trim(s, "0123456789") == "" {
+		return s
+	}
+	return jsonString(s)
+}
+
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+func mkdocsConfig(siteName string, pages []*SitePage) string {
+	var sb strings.Builder
+	sb.WriteString("# Generated by `docod export --format mkdocs`.\n")
+	sb.WriteString("site_name: " + jsonString(siteName) + "\n")
+	sb.WriteString("docs_dir: docs\n")
+	sb.WriteString("nav:\n")
+	sb.WriteString("  - Home: index.md\n")
+	var writeNav func(pages []*SitePage, indent string)
+	writeNav = func(pages []*SitePage, indent string) {
+		for _, p := range pages {
+			if len(p.Children) == 0 {
+				sb.WriteString(fmt.Sprintf("%s- %s: %s\n", indent, jsonString(p.Title), p.Path))
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("%s- %s:\n", indent, jsonString(p.Title)))
+			sb.WriteString(fmt.Sprintf("%s    - %s\n", indent, p.Path))
+			writeNav(p.Children, indent+"    ")
+		}
+	}
+	writeNav(pages, "  ")
+	// Mermaid diagrams render through superfences, as with Material for MkDocs.
+	sb.WriteString("markdown_extensions:\n")
+	sb.WriteString("  - tables\n")
+	sb.WriteString("  - pymdownx.superfences:\n")
+	sb.WriteString("      custom_fences:\n")
+	sb.WriteString("        - name: mermaid\n")
+	sb.WriteString("          class: mermaid\n")
+	sb.WriteString("          format: !!python/name:pymdownx.superfences.fence_code_format\n")
+	return sb.String()
+}
+
+// docusaurusSidebars renders a sidebars.js whose "docs" sidebar mirrors the
+// page tree. Doc IDs are page paths without the extension.
+func docusaurusSidebars(pages []*SitePage) string {
+	var sb strings.Builder
+	sb.WriteString("// Generated by `docod export --format docusaurus`.\n")
+	sb.WriteString("/** @type {import('@docusaurus/plugin-content-docs').SidebarsConfig} */\n")
+	sb.WriteString("const sidebars = {\n  docs: [\n    'index',\n")
+	var writeItems func(pages []*SitePage, indent string)
+	writeItems = func(pages []*SitePage, indent string) {
+		for _, p := range pages {
+			id := jsonString(strings.TrimSuffix(p.Path, ".md"))
+			if len(p.Children) == 0 {
+				sb.WriteString(indent + id + ",\n")
+				continue
+			}
+			sb.WriteString(indent + "{\n")
+			sb.WriteString(indent + "  type: 'category',\n")
+			sb.WriteString(indent + "  label: " + jsonString(p.Title) + ",\n")
+			sb.WriteString(indent + "  link: {type: 'doc', id: " + id + "},\n")
+			sb.WriteString(indent + "  items: [\n")
+			writeItems(p.Children, indent+"    ")
+			sb.WriteString(indent + "  ],\n")
+			sb.WriteString(indent + "},\n")
+		}
+	}
+	writeItems(pages, "    ")
+	sb.WriteString("  ],\n};\n\nmodule.exports = sidebars;\n")
+	return sb.String()
+}
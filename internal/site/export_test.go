@@ -0,0 +1,106 @@
+package site
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/generator"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func siteModel() *generator.DocModel {
+	parent := "architecture"
+	return &generator.DocModel{
+		Document: generator.ModelDoc{Title: "Demo"},
+		Policies: generator.ModelPolicy{RequiredSectionIDs: []string{"overview", "architecture"}},
+		Sections: []generator.ModelSect{
+			{ID: "overview", Title: "Overview", Level: 1, Order: 0, ContentMD: "# Overview\n\nSee [the graph](#graph)."},
+			{ID: "architecture", Title: "Architecture", Level: 1, Order: 1, ContentMD: "Layers."},
+			{ID: "graph", Title: "Graph", Level: 2, Order: 2, ParentID: &parent, ContentMD: "## Graph\n\nNodes. Back to [overview](#overview)."},
+		},
+	}
+}
+
+func TestPlanSitePages(t *testing.T) {
+	model := siteModel()
+	model.Sections = append(model.Sections, generator.ModelSect{ID: "notes", Title: "Notes", Level: 1, Order: 3})
+	pages := PlanSitePages(model)
+	require.Len(t, pages, 2, "empty leaf sections get no page")
+	assert.Equal(t, "overview.md", pages[0].Path)
+	assert.Equal(t, "architecture/index.md", pages[1].Path)
+	require.Len(t, pages[1].Children, 1)
+	assert.Equal(t, "architecture/graph.md", pages[1].Children[0].Path)
+}
+
+func TestExportSite_MkDocs(t *testing.T) {
+	dir := t.TempDir()
+	export, err := ExportSite(siteModel(), dir, SiteExportOptions{Format: FormatMkDocs})
+	require.NoError(t, err)
+	assert.Equal(t, 4, export.Files)
+
+	cfg, err := os.ReadFile(filepath.Join(dir, "mkdocs.yml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(cfg), "site_name: \"Demo\"\n")
+	assert.Contains(t, string(cfg), "  - Home: index.md\n  - \"Overview\": overview.md\n  - \"Architecture\":\n      - architecture/index.md\n      - \"Graph\": architecture/graph.md\n")
+
+	overview, err := os.ReadFile(filepath.Join(dir, "docs", "overview.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "---\ntitle: \"Overview\"\ndescription: \"See the graph.\"\n---\n\n# Overview\n\nSee [the graph](architecture/graph.md).\n", string(overview))
+
+	graph, err := os.ReadFile(filepath.Join(dir, "docs", "architecture", "graph.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(graph), "# Graph\n\nNodes. Back to [overview](../overview.md).")
+
+	index, err := os.ReadFile(filepath.Join(dir, "docs", "architecture", "index.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(index), "## In this section\n\n- [Graph](graph.md)\n")
+
+	assert.Equal(t, filepath.Join(dir, "docs", SearchIndexFile), export.SearchIndex)
+	idx := readSearchIndex(t, export.SearchIndex)
+	assert.Len(t, idx.Documents, 3)
+	assert.Equal(t, "overview/", idx.Documents[DocSection+":overview"].URL)
+	assert.Equal(t, "architecture/", idx.Documents[DocSection+":architecture"].URL)
+	assert.Equal(t, "architecture/graph/", idx.Documents[DocSection+":graph"].URL)
+}
+
+func TestExportSite_Docusaurus(t *testing.T) {
+	dir := t.TempDir()
+	export, err := ExportSite(siteModel(), dir, SiteExportOptions{Format: "Docusaurus", SiteName: "Docs"})
+	require.NoError(t, err)
+
+	sidebars, err := os.ReadFile(filepath.Join(dir, "sidebars.js"))
+	require.NoError(t, err)
+	assert.Contains(t, string(sidebars), "    \"overview\",\n    {\n      type: 'category',\n      label: \"Architecture\",\n      link: {type: 'doc', id: \"architecture/index\"},\n      items: [\n        \"architecture/graph\",\n      ],\n    },\n")
+
+	home, err := os.ReadFile(filepath.Join(dir, "docs", "index.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(home), "slug: \"/\"\n")
+	assert.Contains(t, string(home), "# Docs\n")
+
+	graph, err := os.ReadFile(filepath.Join(dir, "docs", "architecture", "graph.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(graph), "sidebar_position: 1\n")
+
+	assert.Equal(t, filepath.Join(dir, "static", SearchIndexFile), export.SearchIndex)
+	idx := readSearchIndex(t, export.SearchIndex)
+	assert.Equal(t, "docs/architecture/", idx.Documents[DocSection+":architecture"].URL)
+	assert.Equal(t, "docs/architecture/graph", idx.Documents[DocSection+":graph"].URL)
+}
+
+func readSearchIndex(t *testing.T, path string) SearchIndex {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var idx SearchIndex
+	require.NoError(t, json.Unmarshal(data, &idx))
+	return idx
+}
+
+func TestExportSite_UnknownFormat(t *testing.T) {
+	_, err := ExportSite(siteModel(), t.TempDir(), SiteExportOptions{Format: "hugo"})
+	assert.ErrorContains(t, err, "unsupported site format")
+}
@@ -0,0 +1,313 @@
+// Package site builds static documentation sites from the doc model: the
+// MkDocs and Docusaurus source trees and the files served next to the pages.
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"docod/internal/generator"
+	"docod/internal/graph"
+)
+
+const (
+	// SearchIndexFile is the default name of the search index in a site.
+	SearchIndexFile = "search_index.json"
+	// SearchIndexVersion changes when the document store layout changes.
+	SearchIndexVersion = 1
+
+	// lunrVersion is the lunr.js release whose serialization the index
+	// follows; lunr warns when loading an index from another version.
+	lunrVersion = "2.3.9"
+	// BM25 parameters, lunr's defaults.
+	lunrK1 = 1.2
+	lunrB  = 0.75
+	// excerptRunes bounds the excerpt stored per document.
+	excerptRunes = 200
+)
+
+// Kinds of search documents.
+const (
+	DocSection = "section"
+	DocSymbol  = "symbol"
+)
+
+// SearchDoc is what a search hit displays. The lunr index only returns refs;
+// clients look the ref up in SearchIndex.Documents.
+type SearchDoc struct {
+	Kind    string `json:"kind"`
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Package string `json:"package,omitempty"`
+	Excerpt string `json:"excerpt,omitempty"`
+}
+
+// SearchIndex is a prebuilt client-side search index: Index loads with
+// lunr.Index.load and Documents resolves the refs it returns.
+type SearchIndex struct {
+	Version   int                  `json:"version"`
+	Index     *LunrIndex           `json:"index"`
+	Documents map[string]SearchDoc `json:"documents"`
+}
+
+// LunrIndex is the serialized form of a lunr.js 2.x index.
+type LunrIndex struct {
+	Version       string   `json:"version"`
+	Fields        []string `json:"fields"`
+	FieldVectors  [][2]any `json:"fieldVectors"`
+	InvertedIndex [][2]any `json:"invertedIndex"`
+	Pipeline      []string `json:"pipeline"`
+}
+
+// SearchIndexOptions maps documents to the URLs of the site they belong to.
+type SearchIndexOptions struct {
+	// SectionURL defaults to "#<section id>".
+	SectionURL func(sec generator.ModelSect) string
+	// SymbolURL defaults to "<file>#L<line>".
+	SymbolURL func(sym *graph.Symbol) string
+}
+
+// searchField is an indexed field with its lunr boost.
+type searchField struct {
+	name  string
+	boost float64
+}
+
+var searchFields = []searchField{{"title", 10}, {"body", 1}}
+
+type searchEntry struct {
+	ref    string
+	fields map[string]string
+}
+
+// BuildSearchIndex indexes the sections of model and the exported symbols of
+// g. Either may be nil.
+func BuildSearchIndex(model *generator.DocModel, g *graph.Graph, opts SearchIndexOptions) *SearchIndex {
+	if opts.SectionURL == nil {
+		opts.SectionURL = func(sec generator.ModelSect) string { return "#" + sec.ID }
+	}
+	if opts.SymbolURL == nil {
+		opts.SymbolURL = func(sym *graph.Symbol) string {
+			return fmt.Sprintf("%s#L%d", filepath.ToSlash(sym.Filepath), sym.StartLine)
+		}
+	}
+	idx := &SearchIndex{Version: SearchIndexVersion, Documents: make(map[string]SearchDoc)}
+	var entries []searchEntry
+	if model != nil {
+		for _, sec := range model.Sections {
+			ref := DocSection + ":" + sec.ID
+			body := plainText(sec.ContentMD)
+			idx.Documents[ref] = SearchDoc{Kind: DocSection, Title: sec.Title, URL: opts.SectionURL(sec), Excerpt: excerpt(firstNonEmpty(sec.Summary, body))}
+			entries = append(entries, searchEntry{ref: ref, fields: map[string]string{"title": sec.Title, "body": body}})
+		}
+	}
+	for _, sym := range referenceSymbols(g) {
+		ref := DocSymbol + ":" + sym.ID
+		title := sym.Name
+		if sym.Metadata.Receiver != "" {
+			title = strings.TrimLeft(sym.Metadata.Receiver, "*") + "." + sym.Name
+		}
+		body := strings.Join([]string{sym.Metadata.Signature, sym.Description, sym.Package}, "\n")
+		idx.Documents[ref] = SearchDoc{Kind: DocSymbol, Title: title, URL: opts.SymbolURL(sym), Package: sym.Package, Excerpt: excerpt(firstNonEmpty(sym.Description, sym.Metadata.Signature))}
+		entries = append(entries, searchEntry{ref: ref, fields: map[string]string{"title": title, "body": body}})
+	}
+	idx.Index = buildLunr(entries)
+	return idx
+}
+
+// referenceSymbols returns the exported, documented kinds of symbols in g
+// sorted by ID.
+func referenceSymbols(g *graph.Graph) []*graph.Symbol {
+	if g == nil {
+		return nil
+	}
+	var out []*graph.Symbol
+	for _, n := range g.Nodes {
+		if n == nil || n.Unit == nil {
+			continue
+		}
+		switch n.Unit.UnitType {
+		case graph.UnitTypeTest, graph.UnitTypeExternal, graph.UnitTypePackage, "file_module":
+			continue
+		}
+		if !token.IsExported(n.Unit.Name) {
+			continue
+		}
+		out = append(out, n.Unit)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// buildLunr builds the index lunr.Builder would for the same documents with
+// a tokenizer and trimmer but no stemmer or stop word filter, so the search
+// pipeline only needs the trimmer.
+func buildLunr(entries []searchEntry) *LunrIndex {
+	type posting struct {
+		index int
+		docs  map[string]map[string]bool // field -> refs
+	}
+	inverted := make(map[string]*posting)
+	tf := make(map[string]map[string]int) // fieldRef -> term -> count
+	fieldLen := make(map[string]int)
+	totalLen := make(map[string]int)
+	var fieldRefs []string
+	var order []string // terms in first-seen order; _index follows it
+
+	for _, e := range entries {
+		for _, f := range searchFields {
+			fieldRef := f.name + "/" + e.ref
+			fieldRefs = append(fieldRefs, fieldRef)
+			terms := lunrTokens(e.fields[f.name])
+			fieldLen[fieldRef] = len(terms)
+			totalLen[f.name] += len(terms)
+			counts := make(map[string]int)
+			for _, t := range terms {
+				counts[t]++
+				p, ok := inverted[t]
+				if !ok {
+					p = &posting{index: len(order), docs: make(map[string]map[string]bool)}
+					for _, ff := range searchFields {
+						p.docs[ff.name] = make(map[string]bool)
+					}
+					inverted[t] = p
+					order = append(order, t)
+				}
+				p.docs[f.name][e.ref] = true
+			}
+			tf[fieldRef] = counts
+		}
+	}
+
+	n := float64(len(entries))
+	idf := make(map[string]float64, len(inverted))
+	for term, p := range inverted {
+		withTerm := 0
+		for _, refs := range p.docs {
+			withTerm += len(refs)
+		}
+		x := (n - float64(withTerm) + 0.5) / (float64(withTerm) + 0.5)
+		idf[term] = math.Log(1 + math.Abs(x))
+	}
+
+	out := &LunrIndex{Version: lunrVersion, Pipeline: []string{"trimmer"}}
+	for _, f := range searchFields {
+		out.Fields = append(out.Fields, f.name)
+	}
+	boost := make(map[string]float64)
+	for _, f := range searchFields {
+		boost[f.name] = f.boost
+	}
+	for _, fieldRef := range fieldRefs {
+		field, _, _ := strings.Cut(fieldRef, "/")
+		avg := float64(totalLen[field]) / n
+		type elem struct {
+			index int
+			score float64
+		}
+		var elems []elem
+		for term, count := range tf[fieldRef] {
+			c := float64(count)
+			norm := 1 - lunrB
+			if avg > 0 {
+				norm += lunrB * float64(fieldLen[fieldRef]) / avg
+			}
+			score := idf[term] * ((lunrK1 + 1) * c) / (lunrK1*norm + c) * boost[field]
+			elems = append(elems, elem{inverted[term].index, math.Round(score*1000) / 1000})
+		}
+		sort.Slice(elems, func(i, j int) bool { return elems[i].index < elems[j].index })
+		vector := make([]float64, 0, 2*len(elems))
+		for _, e := range elems {
+			vector = append(vector, float64(e.index), e.score)
+		}
+		out.FieldVectors = append(out.FieldVectors, [2]any{fieldRef, vector})
+	}
+
+	terms := make([]string, 0, len(inverted))
+	for t := range inverted {
+		terms = append(terms, t)
+	}
+	sort.Strings(terms)
+	for _, t := range terms {
+		p := inverted[t]
+		entry := map[string]any{"_index": p.index}
+		for field, refs := range p.docs {
+			docs := make(map[string]struct{}, len(refs))
+			for ref := range refs {
+				docs[ref] = struct{}{}
+			}
+			entry[field] = docs
+		}
+		out.InvertedIndex = append(out.InvertedIndex, [2]any{t, entry})
+	}
+	return out
+}
+
+var (
+	lunrSeparator = regexp.MustCompile(`[\s\-]+`)
+	lunrTrimStart = regexp.MustCompile(`^\W+`)
+	lunrTrimEnd   = regexp.MustCompile(`\W+$`)
+)
+
+// lunrTokens splits and trims text the way lunr.tokenizer and lunr.trimmer
+// do, so that queries tokenized by lunr match.
+func lunrTokens(text string) []string {
+	var out []string
+	for _, tok := range lunrSeparator.Split(strings.ToLower(text), -1) {
+		tok = lunrTrimEnd.ReplaceAllString(lunrTrimStart.ReplaceAllString(tok, ""), "")
+		if tok != "" {
+			out = append(out, tok)
+		}
+	}
+	return out
+}
+
+var (
+	mdFence  = regexp.MustCompile("(?m)^```.*$")
+	mdLink   = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	mdMarkup = regexp.MustCompile("[*`#>|]+")
+)
+
+// plainText strips Markdown markup, keeping link text and code.
+func plainText(md string) string {
+	md = mdFence.ReplaceAllString(md, "")
+	md = mdLink.ReplaceAllString(md, "$1")
+	md = mdMarkup.ReplaceAllString(md, " ")
+	return strings.Join(strings.Fields(md), " ")
+}
+
+func excerpt(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if r := []rune(text); len(r) > excerptRunes {
+		return strings.TrimSpace(string(r[:excerptRunes])) + "…"
+	}
+	return text
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// WriteSearchIndex writes idx as JSON to path.
+func WriteSearchIndex(path string, idx *SearchIndex) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
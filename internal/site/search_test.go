@@ -0,0 +1,70 @@
+package site
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/generator"
+	"docod/internal/graph"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSearchIndex_LunrScores(t *testing.T) {
+	model := &generator.DocModel{Sections: []generator.ModelSect{
+		{ID: "a", Title: "Sync", ContentMD: "**Sync** runs."},
+		{ID: "b", Title: "Config", ContentMD: "## Config\n\n[loads](x.md)"},
+	}}
+	idx := BuildSearchIndex(model, nil, SearchIndexOptions{})
+
+	lunr := idx.Index
+	assert.Equal(t, "2.3.9", lunr.Version)
+	assert.Equal(t, []string{"title", "body"}, lunr.Fields)
+	assert.Equal(t, []string{"trimmer"}, lunr.Pipeline)
+	// idf(sync) = ln(1 + 0.5/2.5); the title boost is 10.
+	assert.Equal(t, [2]any{"title/section:a", []float64{0, 1.823}}, lunr.FieldVectors[0])
+	// idf(runs) = ln(1 + 1.5/1.5).
+	assert.Equal(t, [2]any{"body/section:a", []float64{0, 0.182, 1, 0.693}}, lunr.FieldVectors[1])
+
+	var terms []string
+	for _, entry := range lunr.InvertedIndex {
+		terms = append(terms, entry[0].(string))
+	}
+	assert.Equal(t, []string{"config", "loads", "runs", "sync"}, terms, "terms are sorted for lunr's token set")
+	data, err := json.Marshal(lunr.InvertedIndex[3])
+	require.NoError(t, err)
+	assert.JSONEq(t, `["sync", {"_index": 0, "title": {"section:a": {}}, "body": {"section:a": {}}}]`, string(data))
+
+	assert.Equal(t, SearchDoc{Kind: DocSection, Title: "Config", URL: "#b", Excerpt: "Config loads"}, idx.Documents["section:b"])
+}
+
+func TestBuildSearchIndex_SymbolReferenceEntries(t *testing.T) {
+	g := graph.NewGraph()
+	g.AddSymbol(&graph.Symbol{ID: "go/store:method:DB.Open", Name: "Open", UnitType: "method", Package: "store", Filepath: "store/db.go", StartLine: 12,
+		Description: "Open connects to the database.", Metadata: graph.SymbolMetadata{Receiver: "*DB", Signature: "func (d *DB) Open() error"}})
+	g.AddSymbol(&graph.Symbol{ID: "go/store:function:dial", Name: "dial", UnitType: "function", Filepath: "store/db.go"})
+	g.AddSymbol(&graph.Symbol{ID: "go/store:test:TestOpen", Name: "TestOpen", UnitType: graph.UnitTypeTest, Filepath: "store/db_test.go"})
+	g.AddSymbol(&graph.Symbol{ID: "go/store:file", Name: "DB", UnitType: "file_module", Filepath: "store/db.go"})
+
+	idx := BuildSearchIndex(nil, g, SearchIndexOptions{})
+	assert.Equal(t, map[string]SearchDoc{"symbol:go/store:method:DB.Open": {
+		Kind: DocSymbol, Title: "DB.Open", URL: "store/db.go#L12", Package: "store", Excerpt: "Open connects to the database.",
+	}}, idx.Documents, "only exported declarations are reference entries")
+	assert.Equal(t, "body/symbol:go/store:method:DB.Open", idx.Index.FieldVectors[1][0])
+}
+
+func TestLunrTokens(t *testing.T) {
+	assert.Equal(t, []string{"docod", "sync", "re", "runs", "the", "graph.go", "max_entries"},
+		lunrTokens("`docod sync` re-runs (the) graph.go; max_entries"))
+}
+
+func TestWriteSearchIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "site", SearchIndexFile)
+	require.NoError(t, WriteSearchIndex(path, BuildSearchIndex(&generator.DocModel{}, nil, SearchIndexOptions{})))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"version":1`)
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+
+	"docod/internal/graph"
+)
+
+// upsertNodeSQL writes one node row with the hash of its fields.
+const upsertNodeSQL = `
+	INSERT INTO nodes (id, name, package, unit_type, filepath, start_line, end_line, content, content_hash, description, details, row_hash)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(id) DO UPDATE SET
+		name=excluded.name,
+		package=excluded.package,
+		unit_type=excluded.unit_type,
+		filepath=excluded.filepath,
+		start_line=excluded.start_line,
+		end_line=excluded.end_line,
+		content=excluded.content,
+		content_hash=excluded.content_hash,
+		description=excluded.description,
+		details=excluded.details,
+		row_hash=excluded.row_hash
+`
+
+// nodeRow returns the upsertNodeSQL arguments for u.
+func nodeRow(u *graph.Symbol) []interface{} {
+	details, _ := json.Marshal(u.Metadata)
+	h := sha256.New()
+	for _, field := range []string{u.ID, u.Name, u.Package, u.UnitType, u.Filepath, strconv.Itoa(u.StartLine), strconv.Itoa(u.EndLine), u.Content, u.ContentHash, u.Description, string(details)} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	rowHash := hex.EncodeToString(h.Sum(nil))
+	return []interface{}{u.ID, u.Name, u.Package, u.UnitType, u.Filepath, u.StartLine, u.EndLine, u.Content, u.ContentHash, u.Description, details, rowHash}
+}
+
+type edgeKey struct {
+	from, to, kind string
+}
+
+type edgeValue struct {
+	resolver   string
+	confidence float64
+}
+
+// graphDelta counts the rows a SaveGraph wrote.
+type graphDelta struct {
+	NodesWritten, NodesDeleted int
+	EdgesWritten, EdgesDeleted int
+}
+
+// saveGraphDelta makes the nodes and edges tables match g, writing only the
+// rows that differ: nodes whose field hash changed and edges added, removed
+// or re-resolved since the last save. Rows saved before hashes were recorded
+// are rewritten once.
+func (s *SQLiteStore) saveGraphDelta(ctx context.Context, g *graph.Graph) (graphDelta, error) {
+	var delta graphDelta
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return delta, err
+	}
+	defer tx.Rollback()
+
+	stored := make(map[string]string)
+	rows, err := tx.QueryContext(ctx, "SELECT id, COALESCE(row_hash, '') FROM nodes")
+	if err != nil {
+		return delta, err
+	}
+	for rows.Next() {
+		var id, hash string
+		if err := rows.Scan(&id, &hash); err != nil {
+			rows.Close()
+			return delta, err
+		}
+		stored[id] = hash
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return delta, err
+	}
+
+	upsert, err := tx.PrepareContext(ctx, upsertNodeSQL)
+	if err != nil {
+		return delta, err
+	}
+	defer upsert.Close()
+	for id, node := range g.Nodes {
+		args := nodeRow(node.Unit)
+		if hash, ok := stored[id]; ok && hash == args[len(args)-1] {
+			continue
+		}
+		if _, err := upsert.ExecContext(ctx, args...); err != nil {
+			return delta, err
+		}
+		delta.NodesWritten++
+	}
+
+	deleteNode, err := tx.PrepareContext(ctx, "DELETE FROM nodes WHERE id = ?")
+	if err != nil {
+		return delta, err
+	}
+	defer deleteNode.Close()
+	for id := range stored {
+		if _, ok := g.Nodes[id]; ok {
+			continue
+		}
+		if _, err := deleteNode.ExecContext(ctx, id); err != nil {
+			return delta, err
+		}
+		delta.NodesDeleted++
+	}
+
+	// Duplicate edges collapse to one row keeping the highest confidence and
+	// the resolver that produced it.
+	want := make(map[edgeKey]edgeValue, len(g.Edges))
+	for _, e := range g.Edges {
+		k := edgeKey{e.From, e.To, string(e.Kind)}
+		if cur, ok := want[k]; ok && e.Confidence <= cur.confidence {
+			continue
+		}
+		want[k] = edgeValue{resolver: e.Resolver, confidence: e.Confidence}
+	}
+
+	have := make(map[edgeKey]edgeValue)
+	rows, err = tx.QueryContext(ctx, "SELECT from_id, to_id, kind, COALESCE(resolver, ''), COALESCE(confidence, 0) FROM edges")
+	if err != nil {
+		return delta, err
+	}
+	for rows.Next() {
+		var k edgeKey
+		var v edgeValue
+		if err := rows.Scan(&k.from, &k.to, &k.kind, &v.resolver, &v.confidence); err != nil {
+			rows.Close()
+			return delta, err
+		}
+		have[k] = v
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return delta, err
+	}
+
+	deleteEdge, err := tx.PrepareContext(ctx, "DELETE FROM edges WHERE from_id = ? AND to_id = ? AND kind = ?")
+	if err != nil {
+		return delta, err
+	}
+	defer deleteEdge.Close()
+	for k := range have {
+		if _, ok := want[k]; ok {
+			continue
+		}
+		if _, err := deleteEdge.ExecContext(ctx, k.from, k.to, k.kind); err != nil {
+			return delta, err
+		}
+		delta.EdgesDeleted++
+	}
+
+	upsertEdge, err := tx.PrepareContext(ctx, `
+		INSERT INTO edges (from_id, to_id, kind, resolver, confidence) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(from_id, to_id, kind) DO UPDATE SET
+			resolver=excluded.resolver,
+			confidence=excluded.confidence
+	`)
+	if err != nil {
+		return delta, err
+	}
+	defer upsertEdge.Close()
+	for k, v := range want {
+		if cur, ok := have[k]; ok && cur == v {
+			continue
+		}
+		if _, err := upsertEdge.ExecContext(ctx, k.from, k.to, k.kind, v.resolver, v.confidence); err != nil {
+			return delta, err
+		}
+		delta.EdgesWritten++
+	}
+
+	return delta, tx.Commit()
+}
@@ -0,0 +1,415 @@
+package storage
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// IndexFlat scores every stored vector on each search.
+	IndexFlat = "flat"
+	// IndexHNSW searches an in-memory HNSW graph built from the stored
+	// vectors, trading exactness for sub-linear search time.
+	IndexHNSW = "hnsw"
+
+	// DefaultHNSWM is the number of neighbours a point links to on each
+	// upper layer; layer 0 keeps twice as many.
+	DefaultHNSWM = 16
+	// DefaultHNSWEfSearch is the minimum candidate list size of a search.
+	DefaultHNSWEfSearch = 64
+	hnswEfConstruction  = 200
+	// hnswSeed fixes the layer assignment so rebuilt indexes answer alike.
+	hnswSeed = 1
+)
+
+// hnswIndex is a hierarchical navigable small world graph (Malkov &
+// Yashunin) over unit-length vectors, so similarity is a dot product. Each
+// chunk contributes its text vector and, with dual embeddings, its code
+// vector; a search keeps the best point of each chunk.
+type hnswIndex struct {
+	generation uint64
+	dim        int
+	m          int
+	levelMult  float64
+	rng        *rand.Rand
+
+	ids     []string    // point -> chunk ID
+	vectors [][]float32 // point -> normalised vector
+	links   [][][]int32 // point -> layer -> neighbours
+	entry   int32
+	top     int
+}
+
+func newHNSWIndex(dim, m int) *hnswIndex {
+	if m < 2 {
+		m = DefaultHNSWM
+	}
+	return &hnswIndex{
+		dim:       dim,
+		m:         m,
+		levelMult: 1 / math.Log(float64(m)),
+		rng:       rand.New(rand.NewSource(hnswSeed)),
+		entry:     -1,
+	}
+}
+
+// len returns the number of points.
+func (h *hnswIndex) len() int {
+	return len(h.vectors)
+}
+
+func (h *hnswIndex) maxLinks(layer int) int {
+	if layer == 0 {
+		return 2 * h.m
+	}
+	return h.m
+}
+
+// insert adds vec as a point of chunk id.
+func (h *hnswIndex) insert(id string, vec []float32) {
+	v := unitVector(vec)
+	node := int32(len(h.vectors))
+	level := int(-math.Log(1-h.rng.Float64()) * h.levelMult)
+	h.ids = append(h.ids, id)
+	h.vectors = append(h.vectors, v)
+	h.links = append(h.links, make([][]int32, level+1))
+	if h.entry < 0 {
+		h.entry, h.top = node, level
+		return
+	}
+
+	ep := h.entry
+	for layer := h.top; layer > level; layer-- {
+		ep = h.greedy(v, ep, layer)
+	}
+	entries := []hnswCandidate{{node: ep, sim: dot(v, h.vectors[ep])}}
+	for layer := min(level, h.top); layer >= 0; layer-- {
+		found := h.searchLayer(v, entries, hnswEfConstruction, layer)
+		neighbours := h.selectNeighbours(found, h.m)
+		h.links[node][layer] = neighbours
+		for _, nb := range neighbours {
+			h.link(nb, node, layer)
+		}
+		entries = found
+	}
+	if level > h.top {
+		h.entry, h.top = node, level
+	}
+}
+
+// link adds node to the neighbours of nb, pruning them when full.
+func (h *hnswIndex) link(nb, node int32, layer int) {
+	links := append(h.links[nb][layer], node)
+	if len(links) > h.maxLinks(layer) {
+		cands := make([]hnswCandidate, len(links))
+		for i, l := range links {
+			cands[i] = hnswCandidate{node: l, sim: dot(h.vectors[nb], h.vectors[l])}
+		}
+		sortCandidates(cands)
+		links = h.selectNeighbours(cands, h.maxLinks(layer))
+	}
+	h.links[nb][layer] = links
+}
+
+// selectNeighbours picks up to m of the candidates, best first, skipping
+// those closer to an already chosen neighbour than to the query so links
+// spread in every direction; skipped candidates fill any remaining slots.
+func (h *hnswIndex) selectNeighbours(cands []hnswCandidate, m int) []int32 {
+	out := make([]int32, 0, m)
+	var skipped []int32
+	for _, c := range cands {
+		if len(out) == m {
+			break
+		}
+		diverse := true
+		for _, o := range out {
+			if dot(h.vectors[c.node], h.vectors[o]) > c.sim {
+				diverse = false
+				break
+			}
+		}
+		if diverse {
+			out = append(out, c.node)
+		} else {
+			skipped = append(skipped, c.node)
+		}
+	}
+	for _, s := range skipped {
+		if len(out) == m {
+			break
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// greedy walks layer from ep to the point most similar to v.
+func (h *hnswIndex) greedy(v []float32, ep int32, layer int) int32 {
+	best := dot(v, h.vectors[ep])
+	for changed := true; changed; {
+		changed = false
+		for _, nb := range h.links[ep][layer] {
+			if s := dot(v, h.vectors[nb]); s > best {
+				best, ep, changed = s, nb, true
+			}
+		}
+	}
+	return ep
+}
+
+// searchLayer returns up to ef points of layer closest to v, best first.
+func (h *hnswIndex) searchLayer(v []float32, entries []hnswCandidate, ef, layer int) []hnswCandidate {
+	visited := make([]uint64, (len(h.vectors)+63)/64)
+	seen := func(n int32) bool {
+		word, bit := n/64, uint64(1)<<(n%64)
+		if visited[word]&bit != 0 {
+			return true
+		}
+		visited[word] |= bit
+		return false
+	}
+	cands := &candidateHeap{best: true}
+	results := &candidateHeap{}
+	for _, e := range entries {
+		if !seen(e.node) {
+			heap.Push(cands, e)
+			heap.Push(results, e)
+		}
+	}
+	for results.Len() > ef {
+		heap.Pop(results)
+	}
+	for cands.Len() > 0 {
+		c := heap.Pop(cands).(hnswCandidate)
+		if results.Len() >= ef && c.sim < results.items[0].sim {
+			break
+		}
+		for _, nb := range h.links[c.node][layer] {
+			if seen(nb) {
+				continue
+			}
+			s := dot(v, h.vectors[nb])
+			if results.Len() < ef || s > results.items[0].sim {
+				heap.Push(cands, hnswCandidate{node: nb, sim: s})
+				heap.Push(results, hnswCandidate{node: nb, sim: s})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+	out := results.items
+	sortCandidates(out)
+	return out
+}
+
+// search returns the best k chunks for query, exploring at least ef points
+// on the bottom layer.
+func (h *hnswIndex) search(query []float32, k, ef int) []scored {
+	if h.entry < 0 || k <= 0 {
+		return nil
+	}
+	v := unitVector(query)
+	ep := h.entry
+	for layer := h.top; layer > 0; layer-- {
+		ep = h.greedy(v, ep, layer)
+	}
+	found := h.searchLayer(v, []hnswCandidate{{node: ep, sim: dot(v, h.vectors[ep])}}, max(ef, k), 0)
+	out := make([]scored, 0, k)
+	seen := make(map[string]bool, len(found))
+	for _, c := range found {
+		id := h.ids[c.node]
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, scored{id: id, score: c.sim})
+		if len(out) == k {
+			break
+		}
+	}
+	return out
+}
+
+type hnswCandidate struct {
+	node int32
+	sim  float32
+}
+
+// sortCandidates orders candidates best first, breaking ties by point.
+func sortCandidates(c []hnswCandidate) {
+	sort.Slice(c, func(i, j int) bool {
+		if c[i].sim != c[j].sim {
+			return c[i].sim > c[j].sim
+		}
+		return c[i].node < c[j].node
+	})
+}
+
+// candidateHeap is a max-heap by similarity when best is set, else a
+// min-heap whose root is the worst candidate kept.
+type candidateHeap struct {
+	items []hnswCandidate
+	best  bool
+}
+
+func (h *candidateHeap) Len() int { return len(h.items) }
+func (h *candidateHeap) Less(i, j int) bool {
+	if h.best {
+		return h.items[i].sim > h.items[j].sim
+	}
+	return h.items[i].sim < h.items[j].sim
+}
+func (h *candidateHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *candidateHeap) Push(x any)    { h.items = append(h.items, x.(hnswCandidate)) }
+func (h *candidateHeap) Pop() any {
+	old := h.items
+	x := old[len(old)-1]
+	h.items = old[:len(old)-1]
+	return x
+}
+
+// unitVector returns a copy of v scaled to length 1; zero vectors stay zero.
+func unitVector(v []float32) []float32 {
+	out := make([]float32, len(v))
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return out
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i, x := range v {
+		out[i] = x * scale
+	}
+	return out
+}
+
+func dot(a, b []float32) float32 {
+	var s float32
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
+
+// searchHNSW answers the queries from the HNSW index, rebuilding it first
+// when the chunks changed since it was built. It reports false when the
+// index cannot serve the queries, so the caller scans instead.
+func (s *SQLiteStore) searchHNSW(ctx context.Context, opts SearchOptions, queryVectors [][]float32, keep int, scorer *parallelScorer) (bool, error) {
+	generation, err := s.vectorGeneration(ctx)
+	if err != nil {
+		return false, err
+	}
+	idx, err := s.hnswIndexFor(ctx, opts, generation)
+	if errors.Is(err, errMixedDimensions) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("hnsw index: %w", err)
+	}
+	if idx.len() == 0 || idx.dim != len(queryVectors[0]) {
+		return false, nil
+	}
+	ef := opts.EfSearch
+	if ef <= 0 {
+		ef = DefaultHNSWEfSearch
+	}
+	for q, queryVector := range queryVectors {
+		for _, c := range idx.search(queryVector, keep, max(ef, keep)) {
+			scorer.sets[0][q].add(c.id, c.score)
+		}
+	}
+	return true, nil
+}
+
+// hnswIndexFor returns the index of generation, building it from the chunks
+// table when the cached one is stale. Builds are serialised so concurrent
+// searches after a write build once.
+func (s *SQLiteStore) hnswIndexFor(ctx context.Context, opts SearchOptions, generation uint64) (*hnswIndex, error) {
+	m := opts.HNSWM
+	if m < 2 {
+		m = DefaultHNSWM
+	}
+	s.vecMu.RLock()
+	idx := s.hnsw
+	s.vecMu.RUnlock()
+	if idx != nil && idx.generation == generation && idx.m == m {
+		return idx, nil
+	}
+
+	s.hnswBuild.Lock()
+	defer s.hnswBuild.Unlock()
+	s.vecMu.RLock()
+	idx = s.hnsw
+	s.vecMu.RUnlock()
+	if idx != nil && idx.generation == generation && idx.m == m {
+		return idx, nil
+	}
+	idx, err := s.buildHNSW(ctx, opts, m)
+	if err != nil {
+		return nil, err
+	}
+	idx.generation = generation
+	s.vecMu.Lock()
+	s.hnsw = idx
+	s.vecMu.Unlock()
+	return idx, nil
+}
+
+// buildHNSW inserts every stored embedding, paging through the chunks table.
+func (s *SQLiteStore) buildHNSW(ctx context.Context, opts SearchOptions, m int) (*hnswIndex, error) {
+	pageSize, _ := opts.scanPlan(0, 0)
+	var (
+		idx  *hnswIndex
+		last int64
+		vec  []float32
+	)
+	for {
+		rows, err := s.db.QueryContext(ctx, "SELECT rowid, id, embedding, code_embedding FROM chunks WHERE rowid > ? ORDER BY rowid LIMIT ?", last, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		n := 0
+		for rows.Next() {
+			var id string
+			var embeddingBlob, codeBlob []byte
+			if err := rows.Scan(&last, &id, &embeddingBlob, &codeBlob); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			n++
+			if idx == nil {
+				idx = newHNSWIndex(len(embeddingBlob)/4, m)
+			}
+			if len(embeddingBlob) != 4*idx.dim || (len(codeBlob) != 0 && len(codeBlob) != 4*idx.dim) {
+				rows.Close()
+				return nil, errMixedDimensions
+			}
+			vec = decodeVector(vec, embeddingBlob)
+			idx.insert(id, vec)
+			if len(codeBlob) > 0 {
+				vec = decodeVector(vec, codeBlob)
+				idx.insert(id, vec)
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+		if n < pageSize {
+			break
+		}
+	}
+	if idx == nil {
+		idx = newHNSWIndex(0, m)
+	}
+	return idx, nil
+}
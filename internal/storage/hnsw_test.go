@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"docod/internal/knowledge"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomVectors(rng *rand.Rand, n, dim int) [][]float32 {
+	out := make([][]float32, n)
+	for i := range out {
+		out[i] = make([]float32, dim)
+		for d := range out[i] {
+			out[i][d] = float32(rng.NormFloat64())
+		}
+	}
+	return out
+}
+
+func TestHNSWIndex_RecallMatchesFlatScan(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	vectors := randomVectors(rng, 2000, 24)
+	idx := newHNSWIndex(24, 0)
+	for i, v := range vectors {
+		idx.insert(fmt.Sprintf("c%04d", i), v)
+	}
+
+	const k = 10
+	hits, total := 0, 0
+	for _, q := range randomVectors(rng, 50, 24) {
+		exact := make([]scored, len(vectors))
+		for i, v := range vectors {
+			exact[i] = scored{id: fmt.Sprintf("c%04d", i), score: cosineSimilarity(q, v)}
+		}
+		sort.Slice(exact, func(i, j int) bool { return worse(exact[j], exact[i]) })
+		want := make(map[string]bool, k)
+		for _, s := range exact[:k] {
+			want[s.id] = true
+		}
+		got := idx.search(q, k, DefaultHNSWEfSearch)
+		require.Len(t, got, k)
+		for _, s := range got {
+			if want[s.id] {
+				hits++
+			}
+		}
+		total += k
+	}
+	assert.GreaterOrEqual(t, float64(hits)/float64(total), 0.95)
+}
+
+func TestHNSWIndex_KeepsBestPointPerChunk(t *testing.T) {
+	idx := newHNSWIndex(2, 0)
+	idx.insert("dual", []float32{0, 1})
+	idx.insert("dual", []float32{1, 0})
+	idx.insert("other", []float32{1, 1})
+
+	got := idx.search([]float32{1, 0}, 2, 0)
+	require.Len(t, got, 2)
+	assert.Equal(t, "dual", got[0].id)
+	assert.InDelta(t, 1, got[0].score, 1e-6)
+	assert.Equal(t, "other", got[1].id)
+	assert.Empty(t, newHNSWIndex(2, 0).search([]float32{1, 0}, 2, 0))
+}
+
+func TestSQLiteStore_HNSWSearch(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	seedVectors(t, store, 50)
+
+	store.SetSearchOptions(SearchOptions{Index: IndexHNSW})
+	assert.Equal(t, []string{"c000", "c001", "c002", "c003", "c004"}, searchIDs(t, store, 5, knowledge.SearchFilter{}))
+	first := store.hnsw
+	require.NotNil(t, first)
+	assert.Equal(t, 50, first.len())
+
+	// Filters the SQL clause cannot express are applied while resolving.
+	assert.Equal(t, []string{"c001", "c002"}, searchIDs(t, store, 2, knowledge.SearchFilter{ExcludePaths: []string{"pkg/f0.go"}}))
+	assert.Same(t, first, store.hnsw, "unchanged chunks reuse the index")
+
+	// A write makes the index stale; the next search rebuilds it.
+	require.NoError(t, store.SaveEmbeddings(context.Background(), []knowledge.VectorItem{
+		{Chunk: knowledge.SearchChunk{ID: "best", Name: "Best"}, Embedding: []float32{0, 1}, CodeEmbedding: []float32{1, 0}},
+	}))
+	assert.Equal(t, []string{"best", "c000"}, searchIDs(t, store, 2, knowledge.SearchFilter{}))
+	assert.NotSame(t, first, store.hnsw)
+	assert.Equal(t, 52, store.hnsw.len(), "dual embeddings add both vectors")
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"docod/internal/knowledge"
+)
+
+// MemoryIndex keeps chunks and vectors in process memory and scans them on
+// every search. Nothing is persisted, so every run re-embeds; it suits tests
+// and one-off runs.
+type MemoryIndex struct {
+	mu    sync.RWMutex
+	items map[string]knowledge.VectorItem
+}
+
+func NewMemoryIndex() *MemoryIndex {
+	return &MemoryIndex{items: make(map[string]knowledge.VectorItem)}
+}
+
+func (m *MemoryIndex) Add(_ context.Context, items []knowledge.VectorItem) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, item := range items {
+		item.Score = 0
+		m.items[item.Chunk.ID] = item
+	}
+	return nil
+}
+
+func (m *MemoryIndex) Delete(_ context.Context, ids []string) error {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, item := range m.items {
+		if deleteMatches(set, item.Chunk) {
+			delete(m.items, id)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryIndex) Search(ctx context.Context, queryVector []float32, topK int, filter knowledge.SearchFilter) ([]knowledge.VectorItem, error) {
+	results, err := m.SearchBatch(ctx, [][]float32{queryVector}, topK, filter)
+	if err != nil {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// SearchBatch implements knowledge.BatchSearcher.
+func (m *MemoryIndex) SearchBatch(_ context.Context, queryVectors [][]float32, topK int, filter knowledge.SearchFilter) ([][]knowledge.VectorItem, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	results := make([][]knowledge.VectorItem, len(queryVectors))
+	for q, queryVector := range queryVectors {
+		set := newCandidateSet(topK)
+		for id, item := range m.items {
+			if filter.Match(item.Chunk) {
+				set.add(id, bestScore(queryVector, item.Embedding, item.CodeEmbedding))
+			}
+		}
+		for _, c := range set.ranked() {
+			item := m.items[c.id]
+			results[q] = append(results[q], knowledge.VectorItem{Chunk: item.Chunk, Score: c.score})
+		}
+	}
+	return results, nil
+}
+
+// GetContentHashes implements knowledge.IndexContentHashReader.
+func (m *MemoryIndex) GetContentHashes(_ context.Context, ids []string) (map[string]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make(map[string]string, len(ids))
+	for _, id := range ids {
+		if item, ok := m.items[id]; ok {
+			out[id] = item.Chunk.ContentHash
+		}
+	}
+	return out, nil
+}
+
+// KeywordSearch implements knowledge.KeywordSearcher with BM25 over all chunks.
+func (m *MemoryIndex) KeywordSearch(_ context.Context, query string, topK int, filter knowledge.SearchFilter) ([]knowledge.VectorItem, error) {
+	m.mu.RLock()
+	chunks := make([]knowledge.SearchChunk, 0, len(m.items))
+	for _, item := range m.items {
+		if filter.Match(item.Chunk) {
+			chunks = append(chunks, item.Chunk)
+		}
+	}
+	m.mu.RUnlock()
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].ID < chunks[j].ID })
+	return knowledge.BM25Rank(chunks, query, topK), nil
+}
+
+func (m *MemoryIndex) Close() error {
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package storage
+
+// mmapFile is unavailable here; vector search falls back to the paged
+// database scan.
+func mmapFile(path string) ([]byte, func() error, error) {
+	return nil, nil, errMmapUnsupported
+}
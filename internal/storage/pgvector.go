@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"docod/internal/knowledge"
+)
+
+// PGVectorSQLDriver is the database/sql driver the pgvector store opens. No
+// Postgres driver is linked by default; build with -tags pgvector to link
+// pgx (see pgvector_pgx.go) or register another driver under this name.
+var PGVectorSQLDriver = "pgx"
+
+var sqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// PGVectorIndex stores chunks in a Postgres table with pgvector columns and
+// ranks them with the cosine distance operator.
+type PGVectorIndex struct {
+	db    *sql.DB
+	table string
+}
+
+func openPGVectorIndex(ctx context.Context, cfg VectorIndexConfig) (VectorIndex, error) {
+	dsn := strings.TrimSpace(cfg.URL)
+	if dsn == "" {
+		return nil, fmt.Errorf("pgvector vector store needs a url (Postgres DSN)")
+	}
+	table := cfg.collection()
+	if !sqlIdentifier.MatchString(table) {
+		return nil, fmt.Errorf("pgvector table %q is not a plain SQL identifier", table)
+	}
+	db, err := sql.Open(PGVectorSQLDriver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("pgvector: %w (build docod with -tags pgvector)", err)
+	}
+	p := &PGVectorIndex{db: db, table: table}
+	if err := p.initSchema(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *PGVectorIndex) initSchema(ctx context.Context) error {
+	stmts := []string{
+		"CREATE EXTENSION IF NOT EXISTS vector",
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			file_path TEXT NOT NULL DEFAULT '',
+			content_hash TEXT NOT NULL DEFAULT '',
+			chunk JSONB NOT NULL,
+			embedding vector NOT NULL,
+			code_embedding vector
+		)`, p.table),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_file_path ON %s (file_path)", p.table, p.table),
+	}
+	for _, stmt := range stmts {
+		if _, err := p.db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("pgvector schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// vectorLiteral formats v in pgvector's text input form, e.g. [0.5,1].
+func vectorLiteral(v []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
+
+func (p *PGVectorIndex) Add(ctx context.Context, items []knowledge.VectorItem) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (id, file_path, content_hash, chunk, embedding, code_embedding)
+		VALUES ($1, $2, $3, $4, $5::vector, $6::vector)
+		ON CONFLICT (id) DO UPDATE SET file_path = excluded.file_path, content_hash = excluded.content_hash,
+			chunk = excluded.chunk, embedding = excluded.embedding, code_embedding = excluded.code_embedding
+	`, p.table))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, item := range items {
+		chunkJSON, err := json.Marshal(item.Chunk)
+		if err != nil {
+			continue
+		}
+		var code any
+		if len(item.CodeEmbedding) > 0 {
+			code = vectorLiteral(item.CodeEmbedding)
+		}
+		if _, err := stmt.ExecContext(ctx, item.Chunk.ID, item.Chunk.FilePath, item.Chunk.ContentHash, string(chunkJSON), vectorLiteral(item.Embedding), code); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (p *PGVectorIndex) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE id = $1 OR file_path = $1", p.table))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, id := range ids {
+		if _, err := stmt.ExecContext(ctx, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Search ranks by the better of the text and code cosine similarities,
+// over-fetching when filter drops candidates client-side.
+func (p *PGVectorIndex) Search(ctx context.Context, queryVector []float32, topK int, filter knowledge.SearchFilter) ([]knowledge.VectorItem, error) {
+	if topK <= 0 {
+		return nil, nil
+	}
+	limit := topK
+	if !filter.IsZero() {
+		limit = max(4*topK, topK+resolveBatch)
+	}
+	rows, err := p.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT chunk, score FROM (
+			SELECT chunk, GREATEST(1 - (embedding <=> $1::vector), COALESCE(1 - (code_embedding <=> $1::vector), -1)) AS score
+			FROM %s
+		) ranked ORDER BY score DESC, chunk->>'id' LIMIT $2
+	`, p.table), vectorLiteral(queryVector), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []knowledge.VectorItem
+	for rows.Next() && len(out) < topK {
+		var chunkJSON []byte
+		var score float64
+		if err := rows.Scan(&chunkJSON, &score); err != nil {
+			return nil, err
+		}
+		var chunk knowledge.SearchChunk
+		if err := json.Unmarshal(chunkJSON, &chunk); err != nil || !filter.Match(chunk) {
+			continue
+		}
+		out = append(out, knowledge.VectorItem{Chunk: chunk, Score: float32(score)})
+	}
+	return out, rows.Err()
+}
+
+// GetContentHashes implements knowledge.IndexContentHashReader.
+func (p *PGVectorIndex) GetContentHashes(ctx context.Context, ids []string) (map[string]string, error) {
+	out := make(map[string]string, len(ids))
+	stmt, err := p.db.PrepareContext(ctx, fmt.Sprintf("SELECT content_hash FROM %s WHERE id = $1", p.table))
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	for _, id := range ids {
+		var hash string
+		switch err := stmt.QueryRowContext(ctx, id).Scan(&hash); err {
+		case nil:
+			out[id] = hash
+		case sql.ErrNoRows:
+		default:
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (p *PGVectorIndex) Close() error {
+	return p.db.Close()
+}
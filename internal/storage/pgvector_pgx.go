@@ -0,0 +1,6 @@
+//go:build pgvector
+
+package storage
+
+// Links the pgx database/sql driver the pgvector store opens.
+import _ "github.com/jackc/pgx/v5/stdlib"
@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"docod/internal/knowledge"
+)
+
+// qdrantBatch is the number of points written per upsert request.
+const qdrantBatch = 128
+
+// QdrantIndex stores chunks as points of a Qdrant collection through its
+// REST API. Each point carries a "text" and, with dual embeddings, a "code"
+// named vector; the chunk travels in the payload.
+type QdrantIndex struct {
+	client     *http.Client
+	baseURL    string
+	collection string
+	apiKey     string
+
+	mu      sync.Mutex
+	created bool
+}
+
+type qdrantPoint struct {
+	ID      string               `json:"id"`
+	Vector  map[string][]float32 `json:"vector"`
+	Payload qdrantPayload        `json:"payload"`
+}
+
+type qdrantPayload struct {
+	ChunkID     string                `json:"chunk_id"`
+	FilePath    string                `json:"file_path"`
+	ContentHash string                `json:"content_hash"`
+	Chunk       knowledge.SearchChunk `json:"chunk"`
+}
+
+type qdrantScoredPoint struct {
+	Score   float32       `json:"score"`
+	Payload qdrantPayload `json:"payload"`
+}
+
+func openQdrantIndex(_ context.Context, cfg VectorIndexConfig) (VectorIndex, error) {
+	base := strings.TrimRight(strings.TrimSpace(cfg.URL), "/")
+	if base == "" {
+		return nil, fmt.Errorf("qdrant vector store needs a url, e.g. http://localhost:6333")
+	}
+	return &QdrantIndex{
+		client:     &http.Client{Timeout: 60 * time.Second},
+		baseURL:    base,
+		collection: cfg.collection(),
+		apiKey:     cfg.APIKey,
+	}, nil
+}
+
+// qdrantPointID maps a chunk ID to the UUID Qdrant requires as point ID.
+func qdrantPointID(chunkID string) string {
+	sum := sha1.Sum([]byte(chunkID))
+	sum[6] = sum[6]&0x0f | 0x50 // version 5
+	sum[8] = sum[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// do sends body as JSON and decodes the "result" field of the reply into
+// out. It returns the status code so callers can treat 404 specially.
+func (q *QdrantIndex) do(ctx context.Context, method, path string, body, out any) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, q.baseURL+path, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if q.apiKey != "" {
+		req.Header.Set("api-key", q.apiKey)
+	}
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("qdrant %s %s failed (%d): %s", method, path, resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	if out == nil {
+		return resp.StatusCode, nil
+	}
+	var envelope struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(raw, &envelope); err != nil {
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, json.Unmarshal(envelope.Result, out)
+}
+
+func (q *QdrantIndex) collectionPath() string {
+	return "/collections/" + url.PathEscape(q.collection)
+}
+
+// ensureCollection creates the collection, sized by the first vectors
+// written, unless it exists.
+func (q *QdrantIndex) ensureCollection(ctx context.Context, dim int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.created {
+		return nil
+	}
+	status, err := q.do(ctx, http.MethodGet, q.collectionPath(), nil, nil)
+	if status == http.StatusNotFound {
+		params := map[string]any{"size": dim, "distance": "Cosine"}
+		_, err = q.do(ctx, http.MethodPut, q.collectionPath(), map[string]any{
+			"vectors": map[string]any{"text": params, "code": params},
+		}, nil)
+	}
+	if err != nil {
+		return err
+	}
+	q.created = true
+	return nil
+}
+
+func (q *QdrantIndex) Add(ctx context.Context, items []knowledge.VectorItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	if err := q.ensureCollection(ctx, len(items[0].Embedding)); err != nil {
+		return err
+	}
+	for start := 0; start < len(items); start += qdrantBatch {
+		batch := items[start:min(start+qdrantBatch, len(items))]
+		points := make([]qdrantPoint, len(batch))
+		for i, item := range batch {
+			vectors := map[string][]float32{"text": item.Embedding}
+			if len(item.CodeEmbedding) > 0 {
+				vectors["code"] = item.CodeEmbedding
+			}
+			points[i] = qdrantPoint{
+				ID:     qdrantPointID(item.Chunk.ID),
+				Vector: vectors,
+				Payload: qdrantPayload{
+					ChunkID:     item.Chunk.ID,
+					FilePath:    item.Chunk.FilePath,
+					ContentHash: item.Chunk.ContentHash,
+					Chunk:       item.Chunk,
+				},
+			}
+		}
+		if _, err := q.do(ctx, http.MethodPut, q.collectionPath()+"/points?wait=true", map[string]any{"points": points}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes the points of the chunk IDs and of every chunk in a file
+// named by one of them.
+func (q *QdrantIndex) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	filter := map[string]any{"should": []any{
+		map[string]any{"key": "chunk_id", "match": map[string]any{"any": ids}},
+		map[string]any{"key": "file_path", "match": map[string]any{"any": ids}},
+	}}
+	status, err := q.do(ctx, http.MethodPost, q.collectionPath()+"/points/delete?wait=true", map[string]any{"filter": filter}, nil)
+	if status == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// Search queries the text vectors and, when any point has one, the code
+// vectors, keeping each chunk's better score. Candidates are over-fetched
+// and filtered client-side.
+func (q *QdrantIndex) Search(ctx context.Context, queryVector []float32, topK int, filter knowledge.SearchFilter) ([]knowledge.VectorItem, error) {
+	if topK <= 0 {
+		return nil, nil
+	}
+	limit := topK
+	if !filter.IsZero() {
+		limit = max(4*topK, topK+resolveBatch)
+	}
+	best := make(map[string]qdrantScoredPoint)
+	for _, name := range []string{"text", "code"} {
+		var hits []qdrantScoredPoint
+		status, err := q.do(ctx, http.MethodPost, q.collectionPath()+"/points/search", map[string]any{
+			"vector":       map[string]any{"name": name, "vector": queryVector},
+			"limit":        limit,
+			"with_payload": true,
+		}, &hits)
+		if status == http.StatusNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range hits {
+			if cur, ok := best[h.Payload.ChunkID]; !ok || h.Score > cur.Score {
+				best[h.Payload.ChunkID] = h
+			}
+		}
+	}
+
+	set := newCandidateSet(len(best))
+	for id, h := range best {
+		if filter.Match(h.Payload.Chunk) {
+			set.add(id, h.Score)
+		}
+	}
+	var out []knowledge.VectorItem
+	for _, c := range set.ranked() {
+		if len(out) == topK {
+			break
+		}
+		out = append(out, knowledge.VectorItem{Chunk: best[c.id].Payload.Chunk, Score: c.score})
+	}
+	return out, nil
+}
+
+// GetContentHashes implements knowledge.IndexContentHashReader.
+func (q *QdrantIndex) GetContentHashes(ctx context.Context, ids []string) (map[string]string, error) {
+	out := make(map[string]string, len(ids))
+	for start := 0; start < len(ids); start += qdrantBatch {
+		batch := ids[start:min(start+qdrantBatch, len(ids))]
+		pointIDs := make([]string, len(batch))
+		for i, id := range batch {
+			pointIDs[i] = qdrantPointID(id)
+		}
+		var points []struct {
+			Payload qdrantPayload `json:"payload"`
+		}
+		status, err := q.do(ctx, http.MethodPost, q.collectionPath()+"/points", map[string]any{
+			"ids":          pointIDs,
+			"with_payload": []string{"chunk_id", "content_hash"},
+		}, &points)
+		if status == http.StatusNotFound {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range points {
+			out[p.Payload.ChunkID] = p.Payload.ContentHash
+		}
+	}
+	return out, nil
+}
+
+func (q *QdrantIndex) Close() error {
+	q.client.CloseIdleConnections()
+	return nil
+}
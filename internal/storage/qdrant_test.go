@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"docod/internal/knowledge"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQdrant implements the few REST endpoints QdrantIndex uses.
+type fakeQdrant struct {
+	mu      sync.Mutex
+	created map[string]any
+	points  map[string]qdrantPoint
+}
+
+func (f *fakeQdrant) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	reply := func(result any) { json.NewEncoder(w).Encode(map[string]any{"result": result, "status": "ok"}) }
+	var body map[string]json.RawMessage
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	switch path := strings.TrimPrefix(r.URL.Path, "/collections/chunks"); {
+	case path == "" && r.Method == http.MethodGet:
+		if f.created == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		reply(map[string]any{})
+	case path == "" && r.Method == http.MethodPut:
+		json.Unmarshal(body["vectors"], &f.created)
+		reply(true)
+	case path == "/points" && r.Method == http.MethodPut:
+		var points []qdrantPoint
+		json.Unmarshal(body["points"], &points)
+		for _, p := range points {
+			f.points[p.ID] = p
+		}
+		reply(map[string]any{"status": "completed"})
+	case path == "/points/search":
+		var query struct {
+			Name   string    `json:"name"`
+			Vector []float32 `json:"vector"`
+		}
+		var limit int
+		json.Unmarshal(body["vector"], &query)
+		json.Unmarshal(body["limit"], &limit)
+		var hits []map[string]any
+		for _, p := range f.points {
+			if v, ok := p.Vector[query.Name]; ok {
+				hits = append(hits, map[string]any{"id": p.ID, "score": cosineSimilarity(query.Vector, v), "payload": p.Payload})
+			}
+		}
+		sort.Slice(hits, func(i, j int) bool { return hits[i]["score"].(float32) > hits[j]["score"].(float32) })
+		reply(hits[:min(limit, len(hits))])
+	case path == "/points/delete":
+		var filter struct {
+			Should []struct {
+				Key   string `json:"key"`
+				Match struct {
+					Any []string `json:"any"`
+				} `json:"match"`
+			} `json:"should"`
+		}
+		json.Unmarshal(body["filter"], &filter)
+		for id, p := range f.points {
+			for _, cond := range filter.Should {
+				value := p.Payload.ChunkID
+				if cond.Key == "file_path" {
+					value = p.Payload.FilePath
+				}
+				for _, v := range cond.Match.Any {
+					if v == value {
+						delete(f.points, id)
+					}
+				}
+			}
+		}
+		reply(map[string]any{"status": "completed"})
+	case path == "/points" && r.Method == http.MethodPost:
+		var ids []string
+		json.Unmarshal(body["ids"], &ids)
+		var out []map[string]any
+		for _, id := range ids {
+			if p, ok := f.points[id]; ok {
+				out = append(out, map[string]any{"id": id, "payload": p.Payload})
+			}
+		}
+		reply(out)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func TestQdrantIndex_RoundTrip(t *testing.T) {
+	fake := &fakeQdrant{points: make(map[string]qdrantPoint)}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	ctx := context.Background()
+
+	idx, err := OpenVectorIndex(ctx, "qdrant", VectorIndexConfig{URL: srv.URL + "/", Collection: "chunks"})
+	require.NoError(t, err)
+	defer idx.Close()
+
+	items, err := idx.Search(ctx, []float32{1, 0}, 3, knowledge.SearchFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, items, "a missing collection has no results")
+
+	require.NoError(t, idx.Add(ctx, []knowledge.VectorItem{
+		{Chunk: knowledge.SearchChunk{ID: "a", FilePath: "store/db.go", ContentHash: "h1"}, Embedding: []float32{1, 0}},
+		{Chunk: knowledge.SearchChunk{ID: "b", FilePath: "cli/run.go"}, Embedding: []float32{0, 1}, CodeEmbedding: []float32{0.9, 0.1}},
+		{Chunk: knowledge.SearchChunk{ID: "c", FilePath: "store/db.go"}, Embedding: []float32{-1, 0}},
+	}))
+	assert.Contains(t, fake.created, "code")
+	assert.Contains(t, fake.points, qdrantPointID("a"))
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, qdrantPointID("a"))
+
+	items, err = idx.Search(ctx, []float32{1, 0}, 2, knowledge.SearchFilter{})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "a", items[0].Chunk.ID)
+	assert.Equal(t, "b", items[1].Chunk.ID, "matched through its code vector")
+
+	items, err = idx.Search(ctx, []float32{1, 0}, 2, knowledge.SearchFilter{PathPrefixes: []string{"store/"}})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "c", items[1].Chunk.ID)
+
+	hashes, err := idx.(knowledge.IndexContentHashReader).GetContentHashes(ctx, []string{"a", "zzz"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "h1"}, hashes)
+
+	require.NoError(t, idx.Delete(ctx, []string{"store/db.go"}))
+	assert.Len(t, fake.points, 1)
+}
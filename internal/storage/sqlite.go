@@ -9,15 +9,28 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"sync"
+	"time"
 
 	"docod/internal/graph"
 	"docod/internal/knowledge"
+	"docod/internal/publish"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type SQLiteStore struct {
-	db *sql.DB
+	db   *sql.DB
+	path string
+
+	// vecMu guards the search options, the mapped vector file and the
+	// HNSW index.
+	vecMu      sync.RWMutex
+	searchOpts SearchOptions
+	vecFile    *vectorFile
+	hnsw       *hnswIndex
+	// hnswBuild serialises index builds.
+	hnswBuild sync.Mutex
 }
 
 // NewSQLiteStore creates or opens a SQLite database.
@@ -31,7 +44,7 @@ func NewSQLiteStore(path string) (*SQLiteStore, error) {
 		return nil, err
 	}
 
-	s := &SQLiteStore{db: db}
+	s := &SQLiteStore{db: db, path: path}
 	if err := s.initSchema(); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to init schema: %w", err)
@@ -41,6 +54,13 @@ func NewSQLiteStore(path string) (*SQLiteStore, error) {
 }
 
 func (s *SQLiteStore) Close() error {
+	s.vecMu.Lock()
+	if s.vecFile != nil {
+		_ = s.vecFile.Close()
+		s.vecFile = nil
+	}
+	s.hnsw = nil
+	s.vecMu.Unlock()
 	return s.db.Close()
 }
 
@@ -71,6 +91,63 @@ func (s *SQLiteStore) initSchema() error {
 			embedding BLOB
 		);`,
 		`CREATE INDEX IF NOT EXISTS idx_nodes_file ON nodes(filepath);`,
+		`CREATE TABLE IF NOT EXISTS meta (
+			key TEXT PRIMARY KEY,
+			value TEXT
+		);`,
+		`CREATE TABLE IF NOT EXISTS summaries (
+			id TEXT PRIMARY KEY,
+			content_hash TEXT,
+			summary TEXT
+		);`,
+		`CREATE TABLE IF NOT EXISTS query_vectors (
+			model TEXT,
+			query TEXT,
+			embedding BLOB,
+			created_at INTEGER,
+			last_used INTEGER,
+			PRIMARY KEY (model, query)
+		);`,
+		`CREATE TABLE IF NOT EXISTS embedding_cache (
+			provider TEXT,
+			model TEXT,
+			content_hash TEXT,
+			embedding BLOB,
+			PRIMARY KEY (provider, model, content_hash)
+		);`,
+		`CREATE TABLE IF NOT EXISTS package_facts (
+			pkg_key TEXT PRIMARY KEY,
+			facts BLOB
+		);`,
+		`CREATE TABLE IF NOT EXISTS file_cache (
+			path TEXT PRIMARY KEY,
+			hash TEXT,
+			symbols BLOB
+		);`,
+		`CREATE TABLE IF NOT EXISTS published_pages (
+			target TEXT,
+			section_id TEXT,
+			page_id TEXT,
+			hash TEXT,
+			PRIMARY KEY (target, section_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS usage_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			mode TEXT,
+			started_at INTEGER
+		);`,
+		`CREATE TABLE IF NOT EXISTS usage_records (
+			run_id INTEGER,
+			kind TEXT,
+			provider TEXT,
+			model TEXT,
+			section TEXT,
+			requests INTEGER,
+			input_tokens INTEGER,
+			output_tokens INTEGER,
+			cost REAL
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_usage_records_run ON usage_records(run_id);`,
 	}
 
 	for _, q := range queries {
@@ -78,115 +155,179 @@ func (s *SQLiteStore) initSchema() error {
 			return err
 		}
 	}
-	return nil
+	// Columns added after the initial schema; older databases are migrated in place.
+	if err := s.ensureColumn("edges", "resolver", "TEXT"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn("chunks", "code_embedding", "BLOB"); err != nil {
+		return err
+	}
+	if err := s.ensureColumn("nodes", "row_hash", "TEXT"); err != nil {
+		return err
+	}
+	return s.ensureColumn("edges", "confidence", "REAL")
 }
 
-// --- CodeGraphStore Implementation ---
+func (s *SQLiteStore) ensureColumn(table, column, decl string) error {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cid     int
+			name    string
+			typ     string
+			notNull int
+			dflt    sql.NullString
+			pk      int
+		)
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, decl))
+	return err
+}
 
-func (s *SQLiteStore) SaveNode(ctx context.Context, node *graph.Node) error {
-	u := node.Unit
-	details, _ := json.Marshal(u.Metadata)
+// MetaSymbolIDVersion records the symbol ID scheme of the stored graph.
+const MetaSymbolIDVersion = "symbol_id_version"
 
-	_, err := s.db.ExecContext(ctx, `
-		INSERT INTO nodes (id, name, package, unit_type, filepath, start_line, end_line, content, content_hash, description, details)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(id) DO UPDATE SET
-			name=excluded.name,
-			package=excluded.package,
-			unit_type=excluded.unit_type,
-			filepath=excluded.filepath,
-			start_line=excluded.start_line,
-			end_line=excluded.end_line,
-			content=excluded.content,
-			content_hash=excluded.content_hash,
-			description=excluded.description,
-			details=excluded.details
-	`, u.ID, u.Name, u.Package, u.UnitType, u.Filepath, u.StartLine, u.EndLine, u.Content, u.ContentHash, u.Description, details)
+// MetaDocumentedCommit records the commit the documentation was last
+// generated or updated at.
+const MetaDocumentedCommit = "documented_commit"
 
+// GetMeta returns a stored metadata value, or "" when the key is unset.
+func (s *SQLiteStore) GetMeta(ctx context.Context, key string) (string, error) {
+	var value string
+	err := s.db.QueryRowContext(ctx, "SELECT value FROM meta WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value, err
+}
+
+// SetMeta stores a metadata value.
+func (s *SQLiteStore) SetMeta(ctx context.Context, key, value string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO meta (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value=excluded.value
+	`, key, value)
 	return err
 }
 
-func (s *SQLiteStore) SaveGraph(ctx context.Context, g *graph.Graph) error {
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
+// RemapSymbolIDs rewrites indexed chunks so that chunk IDs and chunk sources
+// follow mapping (old symbol ID -> new symbol ID). Embeddings are kept, so a
+// pure ID migration does not require re-embedding. It returns the number of
+// chunks rewritten.
+func (s *SQLiteStore) RemapSymbolIDs(ctx context.Context, mapping map[string]string) (int, error) {
+	if len(mapping) == 0 {
+		return 0, nil
 	}
-	defer tx.Rollback()
-
-	// 1. Save Nodes
-	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO nodes (id, name, package, unit_type, filepath, start_line, end_line, content, content_hash, description, details)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(id) DO UPDATE SET
-			name=excluded.name,
-			package=excluded.package,
-			unit_type=excluded.unit_type,
-			filepath=excluded.filepath,
-			start_line=excluded.start_line,
-			end_line=excluded.end_line,
-			content=excluded.content,
-			content_hash=excluded.content_hash,
-			description=excluded.description,
-			details=excluded.details
-	`)
+	rows, err := s.db.QueryContext(ctx, "SELECT id, content FROM chunks")
 	if err != nil {
-		return err
+		return 0, err
 	}
-	defer stmt.Close()
-
-	for _, node := range g.Nodes {
-		u := node.Unit
-		details, _ := json.Marshal(u.Metadata)
-		if _, err := stmt.Exec(u.ID, u.Name, u.Package, u.UnitType, u.Filepath, u.StartLine, u.EndLine, u.Content, u.ContentHash, u.Description, details); err != nil {
-			return err
-		}
+	type rewrite struct {
+		oldID, newID string
+		content      []byte
 	}
-
-	// 1-1. Remove stale nodes that are not in the latest graph snapshot.
-	// SaveGraph is used as a full graph snapshot write; stale rows must be deleted.
-	nodeIDs := make([]string, 0, len(g.Nodes))
-	for id := range g.Nodes {
-		nodeIDs = append(nodeIDs, id)
-	}
-	if len(nodeIDs) == 0 {
-		if _, err := tx.ExecContext(ctx, `DELETE FROM nodes`); err != nil {
-			return err
+	var rewrites []rewrite
+	for rows.Next() {
+		var id string
+		var contentJSON []byte
+		if err := rows.Scan(&id, &contentJSON); err != nil {
+			rows.Close()
+			return 0, err
 		}
-	} else {
-		placeholders := strings.Repeat("?,", len(nodeIDs))
-		placeholders = strings.TrimSuffix(placeholders, ",")
-		query := fmt.Sprintf(`DELETE FROM nodes WHERE id NOT IN (%s)`, placeholders)
-		args := make([]interface{}, 0, len(nodeIDs))
-		for _, id := range nodeIDs {
-			args = append(args, id)
+		var chunk knowledge.SearchChunk
+		if err := json.Unmarshal(contentJSON, &chunk); err != nil {
+			continue
 		}
-		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
-			return err
+		changed := false
+		newID := remapChunkID(id, mapping)
+		if newID != id {
+			chunk.ID = newID
+			changed = true
+		}
+		for i, src := range chunk.Sources {
+			if to, ok := mapping[src.SymbolID]; ok {
+				chunk.Sources[i].SymbolID = to
+				changed = true
+			}
+		}
+		if !changed {
+			continue
 		}
+		b, err := json.Marshal(chunk)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		rewrites = append(rewrites, rewrite{oldID: id, newID: newID, content: b})
 	}
-
-	// 2. Save Edges
-	// Edge set must also match the latest snapshot exactly.
-	if _, err := tx.ExecContext(ctx, `DELETE FROM edges`); err != nil {
-		return err
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
 	}
 
-	edgeStmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO edges (from_id, to_id, kind) VALUES (?, ?, ?)
-		ON CONFLICT(from_id, to_id, kind) DO NOTHING
-	`)
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	defer edgeStmt.Close()
-
-	for _, edge := range g.Edges {
-		if _, err := edgeStmt.Exec(edge.From, edge.To, edge.Kind); err != nil {
-			return err
+	defer tx.Rollback()
+	for _, r := range rewrites {
+		if r.newID != r.oldID {
+			// A chunk already indexed under the new ID wins.
+			if _, err := tx.ExecContext(ctx, "DELETE FROM chunks WHERE id = ? AND EXISTS (SELECT 1 FROM chunks WHERE id = ?)", r.oldID, r.newID); err != nil {
+				return 0, err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE chunks SET id = ?, content = ? WHERE id = ?", r.newID, r.content, r.oldID); err != nil {
+			return 0, err
 		}
 	}
+	if err := bumpVectorGeneration(ctx, tx); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(rewrites), nil
+}
 
-	return tx.Commit()
+// remapChunkID maps a symbol chunk ID, including "::seg:N" segment IDs.
+func remapChunkID(id string, mapping map[string]string) string {
+	base, suffix := id, ""
+	if i := strings.Index(id, "::seg:"); i >= 0 {
+		base, suffix = id[:i], id[i:]
+	}
+	if to, ok := mapping[base]; ok {
+		return to + suffix
+	}
+	return id
+}
+
+// --- CodeGraphStore Implementation ---
+
+func (s *SQLiteStore) SaveNode(ctx context.Context, node *graph.Node) error {
+	_, err := s.db.ExecContext(ctx, upsertNodeSQL, nodeRow(node.Unit)...)
+	return err
+}
+
+// SaveGraph makes the stored graph match g. Only changed rows are written,
+// so a sync that touched a few files costs a few writes however large the
+// graph is.
+func (s *SQLiteStore) SaveGraph(ctx context.Context, g *graph.Graph) error {
+	_, err := s.saveGraphDelta(ctx, g)
+	return err
 }
 
 func (s *SQLiteStore) LoadGraph(ctx context.Context) (*graph.Graph, error) {
@@ -215,21 +356,67 @@ func (s *SQLiteStore) LoadGraph(ctx context.Context) (*graph.Graph, error) {
 	g.RebuildIndices()
 
 	// 2. Load Edges
-	edgeRows, err := s.db.QueryContext(ctx, "SELECT from_id, to_id, kind FROM edges")
+	if err := s.loadEdges(ctx, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// LoadGraphMetadata loads the graph without symbol bodies, which dominate
+// its size on large repositories. Rows are streamed into the graph, and
+// g.Content reads a body on demand through GetNode, so the store must stay
+// open while the graph is in use.
+func (s *SQLiteStore) LoadGraphMetadata(ctx context.Context) (*graph.Graph, error) {
+	g := graph.NewGraph()
+	rows, err := s.db.QueryContext(ctx, "SELECT id, name, package, unit_type, filepath, start_line, end_line, content_hash, description, details FROM nodes")
 	if err != nil {
-		return nil, fmt.Errorf("failed to query edges: %w", err)
+		return nil, fmt.Errorf("failed to query nodes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u graph.Symbol
+		var details []byte
+		if err := rows.Scan(&u.ID, &u.Name, &u.Package, &u.UnitType, &u.Filepath, &u.StartLine, &u.EndLine, &u.ContentHash, &u.Description, &details); err != nil {
+			return nil, fmt.Errorf("failed to scan node: %w", err)
+		}
+		if len(details) > 0 {
+			_ = json.Unmarshal(details, &u.Metadata)
+		}
+		g.AddSymbol(&u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read nodes: %w", err)
+	}
+
+	if err := s.loadEdges(ctx, g); err != nil {
+		return nil, err
+	}
+	g.SetContentLoader(func(id string) (string, error) {
+		node, err := s.GetNode(context.Background(), id)
+		if err != nil {
+			return "", err
+		}
+		return node.Unit.Content, nil
+	})
+	return g, nil
+}
+
+func (s *SQLiteStore) loadEdges(ctx context.Context, g *graph.Graph) error {
+	edgeRows, err := s.db.QueryContext(ctx, "SELECT from_id, to_id, kind, COALESCE(resolver, ''), COALESCE(confidence, 0) FROM edges")
+	if err != nil {
+		return fmt.Errorf("failed to query edges: %w", err)
 	}
 	defer edgeRows.Close()
 
 	for edgeRows.Next() {
 		var edge graph.Edge
-		if err := edgeRows.Scan(&edge.From, &edge.To, &edge.Kind); err != nil {
-			return nil, fmt.Errorf("failed to scan edge: %w", err)
+		if err := edgeRows.Scan(&edge.From, &edge.To, &edge.Kind, &edge.Resolver, &edge.Confidence); err != nil {
+			return fmt.Errorf("failed to scan edge: %w", err)
 		}
 		g.Edges = append(g.Edges, edge)
 	}
-
-	return g, nil
+	return edgeRows.Err()
 }
 
 func (s *SQLiteStore) GetNode(ctx context.Context, id string) (*graph.Node, error) {
@@ -279,8 +466,8 @@ func (s *SQLiteStore) SaveEmbeddings(ctx context.Context, items []knowledge.Vect
 	defer tx.Rollback()
 
 	stmt, err := tx.PrepareContext(ctx, `
-		INSERT INTO chunks (id, content, embedding) VALUES (?, ?, ?)
-		ON CONFLICT(id) DO UPDATE SET content=excluded.content, embedding=excluded.embedding
+		INSERT INTO chunks (id, content, embedding, code_embedding) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET content=excluded.content, embedding=excluded.embedding, code_embedding=excluded.code_embedding
 	`)
 	if err != nil {
 		return err
@@ -299,75 +486,517 @@ func (s *SQLiteStore) SaveEmbeddings(ctx context.Context, items []knowledge.Vect
 			return err
 		}
 
-		if _, err := stmt.Exec(item.Chunk.ID, contentJSON, buf.Bytes()); err != nil {
+		var codeBlob []byte
+		if len(item.CodeEmbedding) > 0 {
+			codeBuf := new(bytes.Buffer)
+			if err := binary.Write(codeBuf, binary.LittleEndian, item.CodeEmbedding); err != nil {
+				return err
+			}
+			codeBlob = codeBuf.Bytes()
+		}
+
+		if _, err := stmt.Exec(item.Chunk.ID, contentJSON, buf.Bytes(), codeBlob); err != nil {
 			return err
 		}
 	}
+	if err := bumpVectorGeneration(ctx, tx); err != nil {
+		return err
+	}
 
 	return tx.Commit()
 }
 
 func (s *SQLiteStore) SearchSimilar(ctx context.Context, queryVector []float32, topK int) ([]knowledge.SearchChunk, error) {
-	// Naive In-Memory Cosine Similarity
-	// For small to medium codebases (up to 10k chunks), this is fast enough (ms range).
+	items, err := s.searchScored(ctx, queryVector, topK, knowledge.SearchFilter{})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]knowledge.SearchChunk, len(items))
+	for i, item := range items {
+		result[i] = item.Chunk
+		result[i].Score = float64(item.Score)
+	}
+	return result, nil
+}
 
-	rows, err := s.db.QueryContext(ctx, "SELECT content, embedding FROM chunks")
+func (s *SQLiteStore) searchScored(ctx context.Context, queryVector []float32, topK int, filter knowledge.SearchFilter) ([]knowledge.VectorItem, error) {
+	results, err := s.searchScoredBatch(ctx, [][]float32{queryVector}, topK, filter)
+	if err != nil || len(results) == 0 {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// SearchBatch implements knowledge.BatchSearcher: every query vector is
+// scored during a single scan of the chunks table.
+func (s *SQLiteStore) SearchBatch(ctx context.Context, queryVectors [][]float32, topK int, filter knowledge.SearchFilter) ([][]knowledge.VectorItem, error) {
+	return s.searchScoredBatch(ctx, queryVectors, topK, filter)
+}
+
+// GetEmbeddings implements knowledge.EmbeddingReader.
+func (s *SQLiteStore) GetEmbeddings(ctx context.Context, ids []string) (map[string][]float32, error) {
+	out := make(map[string][]float32, len(ids))
+	stmt, err := s.db.PrepareContext(ctx, "SELECT embedding FROM chunks WHERE id = ?")
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	defer stmt.Close()
+	for _, id := range ids {
+		var blob []byte
+		if err := stmt.QueryRowContext(ctx, id).Scan(&blob); err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return nil, err
+		}
+		embedding := make([]float32, len(blob)/4)
+		if err := binary.Read(bytes.NewReader(blob), binary.LittleEndian, &embedding); err != nil {
+			continue
+		}
+		out[id] = embedding
+	}
+	return out, nil
+}
+
+// GetEmbeddingsByContentHash implements knowledge.EmbeddingHashReader.
+func (s *SQLiteStore) GetEmbeddingsByContentHash(ctx context.Context, hashes []string) (map[string][]float32, error) {
+	return s.embeddingsByContentHash(ctx, "embedding", hashes)
+}
+
+// GetCodeEmbeddingsByContentHash implements knowledge.CodeEmbeddingHashReader.
+func (s *SQLiteStore) GetCodeEmbeddingsByContentHash(ctx context.Context, hashes []string) (map[string][]float32, error) {
+	return s.embeddingsByContentHash(ctx, "code_embedding", hashes)
+}
+
+func (s *SQLiteStore) embeddingsByContentHash(ctx context.Context, column string, hashes []string) (map[string][]float32, error) {
+	const batch = 500
+	out := make(map[string][]float32, len(hashes))
+	for start := 0; start < len(hashes); start += batch {
+		part := hashes[start:min(start+batch, len(hashes))]
+		args := make([]any, len(part))
+		for i, h := range part {
+			args[i] = h
+		}
+		rows, err := s.db.QueryContext(ctx,
+			"SELECT json_extract(content, '$.content_hash'), "+column+" FROM chunks WHERE json_extract(content, '$.content_hash') IN (?"+strings.Repeat(",?", len(part)-1)+")",
+			args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var (
+				hash string
+				blob []byte
+			)
+			if err := rows.Scan(&hash, &blob); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if _, ok := out[hash]; ok || len(blob) == 0 {
+				continue
+			}
+			embedding := make([]float32, len(blob)/4)
+			if err := binary.Read(bytes.NewReader(blob), binary.LittleEndian, &embedding); err != nil {
+				continue
+			}
+			out[hash] = embedding
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// GetSummaries implements knowledge.SummaryCache.
+func (s *SQLiteStore) GetSummaries(ctx context.Context, ids []string) (map[string]knowledge.CachedSummary, error) {
+	out := make(map[string]knowledge.CachedSummary, len(ids))
+	stmt, err := s.db.PrepareContext(ctx, "SELECT content_hash, summary FROM summaries WHERE id = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	for _, id := range ids {
+		var c knowledge.CachedSummary
+		if err := stmt.QueryRowContext(ctx, id).Scan(&c.ContentHash, &c.Summary); err != nil {
+			if err == sql.ErrNoRows {
+				continue
+			}
+			return nil, err
+		}
+		out[id] = c
+	}
+	return out, nil
+}
 
-	type candidate struct {
-		chunk knowledge.SearchChunk
-		score float32
+// PutSummary implements knowledge.SummaryCache.
+func (s *SQLiteStore) PutSummary(ctx context.Context, id, contentHash, summary string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO summaries (id, content_hash, summary) VALUES (?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET content_hash=excluded.content_hash, summary=excluded.summary
+	`, id, contentHash, summary)
+	return err
+}
+
+// GetQueryVector implements knowledge.QueryVectorCache.
+func (s *SQLiteStore) GetQueryVector(ctx context.Context, model, query string, maxAge time.Duration) ([]float32, bool, error) {
+	var (
+		blob      []byte
+		createdAt int64
+	)
+	err := s.db.QueryRowContext(ctx, "SELECT embedding, created_at FROM query_vectors WHERE model = ? AND query = ?", model, query).Scan(&blob, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	now := time.Now()
+	if maxAge > 0 && now.Sub(time.Unix(createdAt, 0)) > maxAge {
+		_, err := s.db.ExecContext(ctx, "DELETE FROM query_vectors WHERE model = ? AND query = ?", model, query)
+		return nil, false, err
+	}
+	vector := make([]float32, len(blob)/4)
+	if err := binary.Read(bytes.NewReader(blob), binary.LittleEndian, &vector); err != nil {
+		return nil, false, nil
+	}
+	if _, err := s.db.ExecContext(ctx, "UPDATE query_vectors SET last_used = ? WHERE model = ? AND query = ?", now.UnixNano(), model, query); err != nil {
+		return nil, false, err
+	}
+	return vector, true, nil
+}
+
+// PutQueryVector implements knowledge.QueryVectorCache. Entries beyond
+// maxEntries are evicted least recently used first.
+func (s *SQLiteStore) PutQueryVector(ctx context.Context, model, query string, vector []float32, maxEntries int) error {
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, binary.LittleEndian, vector); err != nil {
+		return err
+	}
+	now := time.Now()
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO query_vectors (model, query, embedding, created_at, last_used) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(model, query) DO UPDATE SET embedding=excluded.embedding, created_at=excluded.created_at, last_used=excluded.last_used
+	`, model, query, buf.Bytes(), now.Unix(), now.UnixNano()); err != nil {
+		return err
+	}
+	if maxEntries <= 0 {
+		return nil
+	}
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM query_vectors WHERE rowid IN (
+			SELECT rowid FROM query_vectors ORDER BY last_used DESC LIMIT -1 OFFSET ?
+		)
+	`, maxEntries)
+	return err
+}
+
+// GetCachedEmbeddings implements knowledge.EmbeddingCache.
+func (s *SQLiteStore) GetCachedEmbeddings(ctx context.Context, provider, model string, hashes []string) (map[string][]float32, error) {
+	const batch = 500
+	out := make(map[string][]float32, len(hashes))
+	for start := 0; start < len(hashes); start += batch {
+		part := hashes[start:min(start+batch, len(hashes))]
+		args := []any{provider, model}
+		for _, h := range part {
+			args = append(args, h)
+		}
+		rows, err := s.db.QueryContext(ctx,
+			"SELECT content_hash, embedding FROM embedding_cache WHERE provider = ? AND model = ? AND content_hash IN (?"+strings.Repeat(",?", len(part)-1)+")",
+			args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var (
+				hash string
+				blob []byte
+			)
+			if err := rows.Scan(&hash, &blob); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			embedding := make([]float32, len(blob)/4)
+			if err := binary.Read(bytes.NewReader(blob), binary.LittleEndian, &embedding); err != nil {
+				continue
+			}
+			out[hash] = embedding
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
 	}
-	var candidates []candidate
+	return out, nil
+}
 
+// PutCachedEmbeddings implements knowledge.EmbeddingCache.
+func (s *SQLiteStore) PutCachedEmbeddings(ctx context.Context, provider, model string, vectors map[string][]float32) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO embedding_cache (provider, model, content_hash, embedding) VALUES (?, ?, ?, ?)
+		ON CONFLICT(provider, model, content_hash) DO UPDATE SET embedding=excluded.embedding
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for hash, vector := range vectors {
+		buf := new(bytes.Buffer)
+		if err := binary.Write(buf, binary.LittleEndian, vector); err != nil {
+			return err
+		}
+		if _, err := stmt.ExecContext(ctx, provider, model, hash, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetPackageFacts implements resolver.FactsCache.
+func (s *SQLiteStore) GetPackageFacts(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, "SELECT facts FROM package_facts WHERE pkg_key = ?", key).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return data, err
+}
+
+// PutPackageFacts implements resolver.FactsCache.
+func (s *SQLiteStore) PutPackageFacts(ctx context.Context, key string, data []byte) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO package_facts (pkg_key, facts) VALUES (?, ?)
+		ON CONFLICT(pkg_key) DO UPDATE SET facts=excluded.facts
+	`, key, data)
+	return err
+}
+
+// FileHashes implements index.FileCache.
+func (s *SQLiteStore) FileHashes(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT path, hash FROM file_cache")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]string)
 	for rows.Next() {
-		var contentJSON []byte
-		var embeddingBlob []byte
-		if err := rows.Scan(&contentJSON, &embeddingBlob); err != nil {
+		var path, hash string
+		if err := rows.Scan(&path, &hash); err != nil {
 			return nil, err
 		}
+		out[path] = hash
+	}
+	return out, rows.Err()
+}
 
-		// Decode Chunk
-		var chunk knowledge.SearchChunk
-		if err := json.Unmarshal(contentJSON, &chunk); err != nil {
-			continue
+// CachedSymbols implements index.FileCache.
+func (s *SQLiteStore) CachedSymbols(ctx context.Context, path string) ([]*graph.Symbol, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, "SELECT symbols FROM file_cache WHERE path = ?", path).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var symbols []*graph.Symbol
+	if err := json.Unmarshal(data, &symbols); err != nil {
+		return nil, err
+	}
+	return symbols, nil
+}
+
+// ReplaceFileCache implements index.FileCache in one transaction.
+func (s *SQLiteStore) ReplaceFileCache(ctx context.Context, hashes map[string]string, parsed map[string][]*graph.Symbol) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, "SELECT path FROM file_cache")
+	if err != nil {
+		return err
+	}
+	var stale []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return err
+		}
+		if _, ok := hashes[path]; !ok {
+			stale = append(stale, path)
 		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, path := range stale {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM file_cache WHERE path = ?", path); err != nil {
+			return err
+		}
+	}
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO file_cache (path, hash, symbols) VALUES (?, ?, ?)
+		ON CONFLICT(path) DO UPDATE SET hash=excluded.hash, symbols=excluded.symbols
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for path, symbols := range parsed {
+		data, err := json.Marshal(symbols)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.ExecContext(ctx, path, hashes[path], data); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
 
-		// Decode Embedding
-		embedding := make([]float32, len(embeddingBlob)/4)
-		if err := binary.Read(bytes.NewReader(embeddingBlob), binary.LittleEndian, &embedding); err != nil {
-			continue
+// PublishedPages implements publish.StateStore.
+func (s *SQLiteStore) PublishedPages(ctx context.Context, target string) (map[string]publish.PageRecord, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT section_id, page_id, hash FROM published_pages WHERE target = ?", target)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]publish.PageRecord)
+	for rows.Next() {
+		var rec publish.PageRecord
+		if err := rows.Scan(&rec.SectionID, &rec.PageID, &rec.Hash); err != nil {
+			return nil, err
 		}
+		out[rec.SectionID] = rec
+	}
+	return out, rows.Err()
+}
+
+// SavePublishedPage implements publish.StateStore.
+func (s *SQLiteStore) SavePublishedPage(ctx context.Context, target string, rec publish.PageRecord) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO published_pages (target, section_id, page_id, hash) VALUES (?, ?, ?, ?)
+		ON CONFLICT(target, section_id) DO UPDATE SET page_id=excluded.page_id, hash=excluded.hash
+	`, target, rec.SectionID, rec.PageID, rec.Hash)
+	return err
+}
 
-		score := cosineSimilarity(queryVector, embedding)
-		candidates = append(candidates, candidate{chunk: chunk, score: score})
+// SaveUsageRun stores the model usage of a pipeline run and returns its ID.
+func (s *SQLiteStore) SaveUsageRun(ctx context.Context, run knowledge.UsageRun) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
 	}
+	defer tx.Rollback()
 
-	// Sort by score descending
-	// Simple insertion sort for TopK or full sort
-	// Using generic slice sort for simplicity
-	// Note: In a real prod environment, use a heap for TopK
-	for i := 0; i < len(candidates); i++ {
-		for j := i + 1; j < len(candidates); j++ {
-			if candidates[i].score < candidates[j].score {
-				candidates[i], candidates[j] = candidates[j], candidates[i]
-			}
+	res, err := tx.ExecContext(ctx, "INSERT INTO usage_runs (mode, started_at) VALUES (?, ?)", run.Mode, run.StartedAt.Unix())
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO usage_records (run_id, kind, provider, model, section, requests, input_tokens, output_tokens, cost)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for _, rec := range run.Records {
+		if _, err := stmt.ExecContext(ctx, id, rec.Kind, rec.Provider, rec.Model, rec.Section, rec.Requests, rec.InputTokens, rec.OutputTokens, rec.Cost); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+// UsageRuns returns the latest limit usage runs, newest first (limit <= 0
+// returns all of them).
+func (s *SQLiteStore) UsageRuns(ctx context.Context, limit int) ([]knowledge.UsageRun, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.QueryContext(ctx, "SELECT id, mode, started_at FROM usage_runs ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, err
+	}
+	var runs []knowledge.UsageRun
+	for rows.Next() {
+		var (
+			run     knowledge.UsageRun
+			started int64
+		)
+		if err := rows.Scan(&run.ID, &run.Mode, &started); err != nil {
+			rows.Close()
+			return nil, err
 		}
+		run.StartedAt = time.Unix(started, 0).UTC()
+		runs = append(runs, run)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
 	}
 
-	if len(candidates) > topK {
-		candidates = candidates[:topK]
+	for i := range runs {
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT kind, provider, model, section, requests, input_tokens, output_tokens, cost
+			FROM usage_records WHERE run_id = ? ORDER BY section, kind, provider, model
+		`, runs[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var rec knowledge.UsageRecord
+			if err := rows.Scan(&rec.Kind, &rec.Provider, &rec.Model, &rec.Section, &rec.Requests, &rec.InputTokens, &rec.OutputTokens, &rec.Cost); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			runs[i].Records = append(runs[i].Records, rec)
+		}
+		if err := rows.Close(); err != nil {
+			return nil, err
+		}
 	}
+	return runs, nil
+}
 
-	result := make([]knowledge.SearchChunk, len(candidates))
-	for i, c := range candidates {
-		result[i] = c.chunk
+// KeywordSearch implements knowledge.KeywordSearcher with BM25 over all chunks.
+func (s *SQLiteStore) KeywordSearch(ctx context.Context, query string, topK int, filter knowledge.SearchFilter) ([]knowledge.VectorItem, error) {
+	where, args := chunkFilterClause(filter)
+	rows, err := s.db.QueryContext(ctx, "SELECT content FROM chunks"+where, args...)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return result, nil
+	var chunks []knowledge.SearchChunk
+	for rows.Next() {
+		var contentJSON []byte
+		if err := rows.Scan(&contentJSON); err != nil {
+			return nil, err
+		}
+		var chunk knowledge.SearchChunk
+		if err := json.Unmarshal(contentJSON, &chunk); err != nil || !filter.Match(chunk) {
+			continue
+		}
+		chunks = append(chunks, chunk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return knowledge.BM25Rank(chunks, query, topK), nil
 }
 
 // Add implements knowledge.Indexer interface
@@ -399,6 +1028,9 @@ func (s *SQLiteStore) Delete(ctx context.Context, ids []string) error {
 			return err
 		}
 	}
+	if err := bumpVectorGeneration(ctx, tx); err != nil {
+		return err
+	}
 
 	return tx.Commit()
 }
@@ -466,18 +1098,53 @@ func (s *SQLiteStore) GetContentHashes(ctx context.Context, ids []string) (map[s
 }
 
 // Search implements knowledge.Indexer interface
-func (s *SQLiteStore) Search(ctx context.Context, queryVector []float32, topK int) ([]knowledge.VectorItem, error) {
-	chunks, err := s.SearchSimilar(ctx, queryVector, topK)
-	if err != nil {
-		return nil, err
-	}
+func (s *SQLiteStore) Search(ctx context.Context, queryVector []float32, topK int, filter knowledge.SearchFilter) ([]knowledge.VectorItem, error) {
+	return s.searchScored(ctx, queryVector, topK, filter)
+}
 
-	// Convert SearchChunk to VectorItem.
-	var items []knowledge.VectorItem
-	for _, c := range chunks {
-		items = append(items, knowledge.VectorItem{Chunk: c})
+// chunkFilterClause narrows chunk scans in SQL. Callers still apply
+// filter.Match to the decoded chunks for the name-based test heuristic.
+func chunkFilterClause(f knowledge.SearchFilter) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	in := func(expr string, values []string, negate bool) {
+		if len(values) == 0 {
+			return
+		}
+		marks := strings.TrimSuffix(strings.Repeat("?,", len(values)), ",")
+		op := " IN "
+		if negate {
+			op = " NOT IN "
+		}
+		conds = append(conds, expr+op+"("+marks+")")
+		for _, v := range values {
+			args = append(args, v)
+		}
+	}
+	const (
+		pkgExpr  = "COALESCE(json_extract(content, '$.package'), '')"
+		typeExpr = "COALESCE(json_extract(content, '$.unit_type'), '')"
+		pathExpr = "COALESCE(json_extract(content, '$.file_path'), '')"
+	)
+	in(pkgExpr, f.Packages, false)
+	in(typeExpr, f.UnitTypes, false)
+	in(typeExpr, f.ExcludeUnitTypes, true)
+	if len(f.PathPrefixes) > 0 {
+		var ors []string
+		for _, p := range f.PathPrefixes {
+			ors = append(ors, "substr("+pathExpr+", 1, ?) = ?")
+			args = append(args, len(p), p)
+		}
+		conds = append(conds, "("+strings.Join(ors, " OR ")+")")
+	}
+	if f.ExcludeTests {
+		conds = append(conds, typeExpr+" != ?", "substr("+pathExpr+", -8) != ?")
+		args = append(args, graph.UnitTypeTest, "_test.go")
+	}
+	if len(conds) == 0 {
+		return "", nil
 	}
-	return items, nil
+	return " WHERE " + strings.Join(conds, " AND "), args
 }
 
 // CountChunks returns total number of indexed chunks.
@@ -4,9 +4,12 @@ import (
 	"context"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"docod/internal/extractor"
 	"docod/internal/graph"
+	"docod/internal/knowledge"
+	"docod/internal/publish"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -34,7 +37,7 @@ func TestSQLiteStore_SaveGraph_SnapshotSync(t *testing.T) {
 	c := testUnit("c:FuncC:1", "FuncC", "file_c.go", 1, 10)
 	g2.AddUnit(b)
 	g2.AddUnit(c)
-	g2.Edges = []graph.Edge{{From: c.ID, To: b.ID, Kind: "calls"}}
+	g2.Edges = []graph.Edge{{From: c.ID, To: b.ID, Kind: "calls", Resolver: "types", Confidence: 0.9}}
 	require.NoError(t, store.SaveGraph(ctx, g2))
 
 	loaded, err := store.LoadGraph(ctx)
@@ -54,6 +57,8 @@ func TestSQLiteStore_SaveGraph_SnapshotSync(t *testing.T) {
 	assert.Equal(t, c.ID, loaded.Edges[0].From)
 	assert.Equal(t, b.ID, loaded.Edges[0].To)
 	assert.Equal(t, "calls", loaded.Edges[0].Kind)
+	assert.Equal(t, "types", loaded.Edges[0].Resolver)
+	assert.InDelta(t, 0.9, loaded.Edges[0].Confidence, 1e-9)
 }
 
 func TestSQLiteStore_SaveGraph_EmptySnapshotClearsData(t *testing.T) {
@@ -78,6 +83,57 @@ func TestSQLiteStore_SaveGraph_EmptySnapshotClearsData(t *testing.T) {
 	assert.Empty(t, loaded.Edges)
 }
 
+func TestSQLiteStore_SaveGraph_WritesOnlyDeltas(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	g := graph.NewGraph()
+	a := testUnit("a:FuncA:1", "FuncA", "file_a.go", 1, 10)
+	b := testUnit("b:FuncB:1", "FuncB", "file_b.go", 1, 10)
+	c := testUnit("c:FuncC:1", "FuncC", "file_c.go", 1, 10)
+	g.AddUnit(a)
+	g.AddUnit(b)
+	g.AddUnit(c)
+	g.Edges = []graph.Edge{
+		{From: a.ID, To: b.ID, Kind: "calls", Resolver: "name", Confidence: 0.5},
+		{From: a.ID, To: b.ID, Kind: "calls", Resolver: "types", Confidence: 0.9},
+		{From: b.ID, To: c.ID, Kind: "calls"},
+	}
+	delta, err := store.saveGraphDelta(ctx, g)
+	require.NoError(t, err)
+	assert.Equal(t, graphDelta{NodesWritten: 3, EdgesWritten: 2}, delta)
+
+	delta, err = store.saveGraphDelta(ctx, g)
+	require.NoError(t, err)
+	assert.Equal(t, graphDelta{}, delta, "an unchanged graph writes nothing")
+
+	// Edit B's body, drop C and its edge, and re-resolve A->B.
+	g.Nodes[b.ID].Unit.Content = "func FuncB() { return }"
+	delete(g.Nodes, c.ID)
+	g.Edges = []graph.Edge{{From: a.ID, To: b.ID, Kind: "calls", Resolver: "lsp", Confidence: 1}}
+	delta, err = store.saveGraphDelta(ctx, g)
+	require.NoError(t, err)
+	assert.Equal(t, graphDelta{NodesWritten: 1, NodesDeleted: 1, EdgesWritten: 1, EdgesDeleted: 1}, delta)
+
+	loaded, err := store.LoadGraph(ctx)
+	require.NoError(t, err)
+	assert.Len(t, loaded.Nodes, 2)
+	assert.Equal(t, "func FuncB() { return }", loaded.Nodes[b.ID].Unit.Content)
+	require.Len(t, loaded.Edges, 1)
+	assert.Equal(t, "lsp", loaded.Edges[0].Resolver)
+
+	// SaveNode keeps the recorded hash in step, so a later save of the old
+	// graph restores the row.
+	edited := *loaded.Nodes[a.ID].Unit
+	edited.Description = "edited"
+	require.NoError(t, store.SaveNode(ctx, &graph.Node{Unit: &edited}))
+	delta, err = store.saveGraphDelta(ctx, g)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delta.NodesWritten)
+}
+
 func testUnit(id, name, path string, startLine, endLine int) *extractor.CodeUnit {
 	return &extractor.CodeUnit{
 		ID:        id,
@@ -89,3 +145,327 @@ func testUnit(id, name, path string, startLine, endLine int) *extractor.CodeUnit
 		Language:  "go",
 	}
 }
+
+func TestSQLiteStore_LoadGraphMetadata_LoadsContentOnDemand(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	g := graph.NewGraph()
+	a := testUnit("a:FuncA:1", "FuncA", "file_a.go", 1, 3)
+	a.Content = "func FuncA() {\n\tFuncB()\n}"
+	a.ContentHash = "hash-a"
+	b := testUnit("b:FuncB:1", "FuncB", "file_b.go", 1, 1)
+	b.Content = "func FuncB() {}"
+	g.AddUnit(a)
+	g.AddUnit(b)
+	g.Nodes[a.ID].Unit.Metadata.Signature = "func FuncA()"
+	g.Edges = []graph.Edge{{From: a.ID, To: b.ID, Kind: graph.RelationCalls, Confidence: 0.9}}
+	require.NoError(t, store.SaveGraph(ctx, g))
+
+	loaded, err := store.LoadGraphMetadata(ctx)
+	require.NoError(t, err)
+	require.True(t, loaded.Partial())
+	require.Len(t, loaded.Nodes, 2)
+	require.Len(t, loaded.Edges, 1)
+
+	unit := loaded.Nodes[a.ID].Unit
+	assert.Empty(t, unit.Content, "bodies are not held in memory")
+	assert.Equal(t, "hash-a", unit.ContentHash)
+	assert.Equal(t, "func FuncA()", unit.Metadata.Signature)
+	assert.Equal(t, a.Content, loaded.Content(unit))
+	assert.Empty(t, unit.Content, "on-demand reads do not hydrate the node")
+
+	full, err := store.LoadGraph(ctx)
+	require.NoError(t, err)
+	assert.False(t, full.Partial())
+	assert.Equal(t, a.Content, full.Nodes[a.ID].Unit.Content)
+}
+
+func TestSQLiteStore_SaveGraph_PackageNodes(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	g := graph.NewGraph()
+	a := testUnit("a:FuncA:1", "FuncA", "pkg/a/a.go", 1, 3)
+	a.Package = "a"
+	b := testUnit("b:FuncB:1", "FuncB", "pkg/b/b.go", 1, 1)
+	b.Package = "b"
+	g.AddUnit(a)
+	g.AddUnit(b)
+	g.Edges = []graph.Edge{{From: a.ID, To: b.ID, Kind: graph.RelationCalls, Confidence: 0.9}}
+	g.BuildPackageNodes()
+	require.NoError(t, store.SaveGraph(ctx, g))
+
+	loaded, err := store.LoadGraph(ctx)
+	require.NoError(t, err)
+	pkgA := loaded.Nodes[graph.PackageSummaryID("pkg/a")]
+	require.NotNil(t, pkgA)
+	assert.Equal(t, graph.UnitTypePackage, pkgA.Unit.UnitType)
+	assert.Equal(t, "pkg/a", pkgA.Unit.Name)
+	assert.Equal(t, pkgA.Unit.ID, loaded.PackageOf(a.ID).ID)
+	assert.Contains(t, loaded.Edges, graph.Edge{From: pkgA.Unit.ID, To: graph.PackageSummaryID("pkg/b"), Kind: graph.RelationImports, Resolver: "package_nodes", Confidence: 1})
+}
+
+func TestSQLiteStore_RemapSymbolIDs(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	version, err := store.GetMeta(ctx, MetaSymbolIDVersion)
+	require.NoError(t, err)
+	assert.Equal(t, "", version)
+	require.NoError(t, store.SetMeta(ctx, MetaSymbolIDVersion, "2"))
+	version, err = store.GetMeta(ctx, MetaSymbolIDVersion)
+	require.NoError(t, err)
+	assert.Equal(t, "2", version)
+
+	chunk := func(id, source string) knowledge.VectorItem {
+		return knowledge.VectorItem{
+			Chunk:     knowledge.SearchChunk{ID: id, Name: id, Sources: []knowledge.ChunkSource{{SymbolID: source}}},
+			Embedding: []float32{1, 0},
+		}
+	}
+	require.NoError(t, store.SaveEmbeddings(ctx, []knowledge.VectorItem{
+		chunk("old:Open:12", "old:Open:12"),
+		chunk("old:Open:12::seg:1", "old:Open:12"),
+		chunk("file:store/db.go", "old:Close:30"),
+		chunk("untouched", "untouched"),
+	}))
+
+	n, err := store.RemapSymbolIDs(ctx, map[string]string{
+		"old:Open:12":  "go/store:function:Open:aa",
+		"old:Close:30": "go/store:function:Close:bb",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	ids, err := store.ListChunkIDs(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"go/store:function:Open:aa",
+		"go/store:function:Open:aa::seg:1",
+		"file:store/db.go",
+		"untouched",
+	}, ids)
+
+	results, err := store.Search(ctx, []float32{1, 0}, 10, knowledge.SearchFilter{})
+	require.NoError(t, err)
+	for _, r := range results {
+		if r.Chunk.ID == "file:store/db.go" {
+			assert.Equal(t, "go/store:function:Close:bb", r.Chunk.Sources[0].SymbolID)
+		}
+	}
+}
+
+func TestSQLiteStore_SearchWithFilter(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	require.NoError(t, store.SaveEmbeddings(ctx, []knowledge.VectorItem{
+		{Chunk: knowledge.SearchChunk{ID: "cfg", Name: "LoadConfig", Package: "config", UnitType: "function", FilePath: "internal/config/config.go"}, Embedding: []float32{1, 0}},
+		{Chunk: knowledge.SearchChunk{ID: "test", Name: "TestLoadConfig", Package: "config", UnitType: graph.UnitTypeTest, FilePath: "internal/config/config_test.go"}, Embedding: []float32{1, 0}},
+		{Chunk: knowledge.SearchChunk{ID: "run", Name: "Run", Package: "cli", UnitType: "function", FilePath: "cmd/cli/run.go"}, Embedding: []float32{1, 0}},
+	}))
+
+	ids := func(items []knowledge.VectorItem) []string {
+		out := make([]string, 0, len(items))
+		for _, it := range items {
+			out = append(out, it.Chunk.ID)
+		}
+		return out
+	}
+
+	items, err := store.Search(ctx, []float32{1, 0}, 10, knowledge.SearchFilter{PathPrefixes: []string{"internal/config"}, ExcludeTests: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cfg"}, ids(items))
+
+	items, err = store.Search(ctx, []float32{1, 0}, 10, knowledge.SearchFilter{Packages: []string{"config"}, ExcludeUnitTypes: []string{"function"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test"}, ids(items))
+
+	items, err = store.KeywordSearch(ctx, "LoadConfig", 10, knowledge.SearchFilter{ExcludeTests: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cfg"}, ids(items))
+}
+
+func TestSQLiteStore_SearchBatch(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	require.NoError(t, store.SaveEmbeddings(ctx, []knowledge.VectorItem{
+		{Chunk: knowledge.SearchChunk{ID: "x", Name: "X"}, Embedding: []float32{1, 0}},
+		{Chunk: knowledge.SearchChunk{ID: "y", Name: "Y"}, Embedding: []float32{0, 1}},
+	}))
+
+	results, err := store.SearchBatch(ctx, [][]float32{{1, 0}, {0, 1}}, 1, knowledge.SearchFilter{})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Len(t, results[0], 1)
+	require.Len(t, results[1], 1)
+	assert.Equal(t, "x", results[0][0].Chunk.ID)
+	assert.Equal(t, "y", results[1][0].Chunk.ID)
+	assert.InDelta(t, 1.0, results[1][0].Score, 1e-6)
+}
+
+func TestSQLiteStore_QueryVectorCache(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	require.NoError(t, store.PutQueryVector(ctx, "m", "alpha", []float32{1, 2}, 2))
+	require.NoError(t, store.PutQueryVector(ctx, "m", "beta", []float32{3, 4}, 2))
+	vec, ok, err := store.GetQueryVector(ctx, "m", "alpha", time.Hour)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []float32{1, 2}, vec)
+
+	_, ok, err = store.GetQueryVector(ctx, "other", "alpha", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, ok, "entries are scoped by model")
+
+	// alpha was used more recently than beta, so beta is evicted.
+	require.NoError(t, store.PutQueryVector(ctx, "m", "gamma", []float32{5, 6}, 2))
+	_, ok, _ = store.GetQueryVector(ctx, "m", "beta", time.Hour)
+	assert.False(t, ok)
+	_, ok, _ = store.GetQueryVector(ctx, "m", "alpha", time.Hour)
+	assert.True(t, ok)
+
+	// Expired entries are dropped on read.
+	_, err = store.db.ExecContext(ctx, "UPDATE query_vectors SET created_at = ? WHERE query = 'gamma'", time.Now().Add(-2*time.Hour).Unix())
+	require.NoError(t, err)
+	_, ok, err = store.GetQueryVector(ctx, "m", "gamma", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestSQLiteStore_SummaryCache(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	require.NoError(t, store.PutSummary(ctx, "summary:a.go", "h1", "first"))
+	require.NoError(t, store.PutSummary(ctx, "summary:a.go", "h2", "second"))
+	got, err := store.GetSummaries(ctx, []string{"summary:a.go", "summary:missing"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]knowledge.CachedSummary{"summary:a.go": {ContentHash: "h2", Summary: "second"}}, got)
+}
+
+func TestSQLiteStore_PackageFacts(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	got, err := store.GetPackageFacts(ctx, "pkg|store")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	require.NoError(t, store.PutPackageFacts(ctx, "pkg|store", []byte(`{"fingerprint":"a"}`)))
+	require.NoError(t, store.PutPackageFacts(ctx, "pkg|store", []byte(`{"fingerprint":"b"}`)))
+	got, err = store.GetPackageFacts(ctx, "pkg|store")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"fingerprint":"b"}`, string(got))
+}
+
+func TestSQLiteStore_EmbeddingCache(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	require.NoError(t, store.PutCachedEmbeddings(ctx, "openai", "small/1536", map[string][]float32{"h1": {1, 2}, "h2": {3, 4}}))
+	require.NoError(t, store.PutCachedEmbeddings(ctx, "openai", "small/1536", map[string][]float32{"h1": {5, 6}}))
+	require.NoError(t, store.PutCachedEmbeddings(ctx, "ollama", "small/1536", map[string][]float32{"h3": {7, 8}}))
+
+	got, err := store.GetCachedEmbeddings(ctx, "openai", "small/1536", []string{"h1", "h2", "h3"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]float32{"h1": {5, 6}, "h2": {3, 4}}, got)
+	got, err = store.GetCachedEmbeddings(ctx, "openai", "large/3072", []string{"h1"})
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestSQLiteStore_FileCache(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	open := &graph.Symbol{ID: "open", Name: "Open", Filepath: "a.go", Relations: []graph.Relation{{Target: "DB", Kind: graph.RelationUsesType}}}
+	require.NoError(t, store.ReplaceFileCache(ctx,
+		map[string]string{"a.go": "h1", "b.go": "h2"},
+		map[string][]*graph.Symbol{"a.go": {open}, "b.go": nil}))
+
+	hashes, err := store.FileHashes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a.go": "h1", "b.go": "h2"}, hashes)
+	symbols, err := store.CachedSymbols(ctx, "a.go")
+	require.NoError(t, err)
+	require.Len(t, symbols, 1)
+	assert.Equal(t, open, symbols[0])
+
+	// b.go is gone and a.go unchanged: a.go keeps its symbols.
+	require.NoError(t, store.ReplaceFileCache(ctx, map[string]string{"a.go": "h1"}, nil))
+	hashes, err = store.FileHashes(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a.go": "h1"}, hashes)
+	symbols, err = store.CachedSymbols(ctx, "a.go")
+	require.NoError(t, err)
+	assert.Len(t, symbols, 1)
+}
+
+func TestSQLiteStore_PublishedPages(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	require.NoError(t, store.SavePublishedPage(ctx, "confluence", publish.PageRecord{SectionID: "overview", PageID: "1", Hash: "a"}))
+	require.NoError(t, store.SavePublishedPage(ctx, "confluence", publish.PageRecord{SectionID: "overview", PageID: "1", Hash: "b"}))
+	require.NoError(t, store.SavePublishedPage(ctx, "notion", publish.PageRecord{SectionID: "overview", PageID: "n1", Hash: "c"}))
+	got, err := store.PublishedPages(ctx, "confluence")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]publish.PageRecord{"overview": {SectionID: "overview", PageID: "1", Hash: "b"}}, got)
+}
+
+func TestSQLiteStore_UsageRuns(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	ctx := context.Background()
+
+	started := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	first, err := store.SaveUsageRun(ctx, knowledge.UsageRun{Mode: "full_generate", StartedAt: started, Records: []knowledge.UsageRecord{
+		{Kind: knowledge.UsageLLM, Provider: "openai", Model: "gpt-4o", Section: "overview", Usage: knowledge.Usage{Requests: 2, InputTokens: 1000, OutputTokens: 200, Cost: 0.0045}},
+		{Kind: knowledge.UsageEmbedding, Provider: "openai", Model: "text-embedding-3-small", Usage: knowledge.Usage{Requests: 1, InputTokens: 5000, Cost: 0.0001}},
+	}})
+	require.NoError(t, err)
+	second, err := store.SaveUsageRun(ctx, knowledge.UsageRun{Mode: "incremental_sync", StartedAt: started.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Greater(t, second, first)
+
+	runs, err := store.UsageRuns(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "incremental_sync", runs[0].Mode)
+	assert.Empty(t, runs[0].Records)
+	assert.Equal(t, started, runs[1].StartedAt)
+	require.Len(t, runs[1].Records, 2)
+	assert.Equal(t, "", runs[1].Records[0].Section)
+	assert.Equal(t, knowledge.Usage{Requests: 3, InputTokens: 6000, OutputTokens: 200, Cost: 0.0046}, runs[1].Total())
+
+	runs, err = store.UsageRuns(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+}
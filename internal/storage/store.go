@@ -18,7 +18,8 @@ type CodeGraphStore interface {
 	// SaveNode upserts a node into the database.
 	SaveNode(ctx context.Context, node *graph.Node) error
 
-	// SaveGraph persists the entire graph structure (nodes and edges).
+	// SaveGraph makes the stored nodes and edges match the graph, writing
+	// only the rows that changed.
 	SaveGraph(ctx context.Context, g *graph.Graph) error
 
 	// LoadGraph retrieves the entire graph structure from the database.
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"container/heap"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// minRowsPerWorker keeps scans of small pages on one goroutine, where the
+// cost of starting workers outweighs the scoring.
+const minRowsPerWorker = 256
+
+// scored is a chunk ID with its best similarity to one query.
+type scored struct {
+	id    string
+	score float32
+}
+
+// worse orders candidates by score, breaking ties by ID so that results do
+// not depend on how the scan was split across workers.
+func worse(a, b scored) bool {
+	if a.score != b.score {
+		return a.score < b.score
+	}
+	return a.id > b.id
+}
+
+// scoreHeap is a min-heap of candidates: the root is the worst one kept.
+type scoreHeap []scored
+
+func (h scoreHeap) Len() int           { return len(h) }
+func (h scoreHeap) Less(i, j int) bool { return worse(h[i], h[j]) }
+func (h scoreHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *scoreHeap) Push(x any)        { *h = append(*h, x.(scored)) }
+func (h *scoreHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// candidateSet keeps the best keep candidates of one query in a bounded
+// min-heap, so a scan costs O(n log keep) and holds keep entries however
+// many chunks it reads.
+type candidateSet struct {
+	h    scoreHeap
+	keep int
+}
+
+func newCandidateSet(keep int) *candidateSet {
+	return &candidateSet{h: make(scoreHeap, 0, keep), keep: keep}
+}
+
+func (c *candidateSet) add(id string, score float32) {
+	s := scored{id: id, score: score}
+	if len(c.h) < c.keep {
+		heap.Push(&c.h, s)
+		return
+	}
+	if c.keep > 0 && worse(c.h[0], s) {
+		c.h[0] = s
+		heap.Fix(&c.h, 0)
+	}
+}
+
+// merge adds the candidates of o.
+func (c *candidateSet) merge(o *candidateSet) {
+	for _, s := range o.h {
+		c.add(s.id, s.score)
+	}
+}
+
+// ranked returns the candidates best first.
+func (c *candidateSet) ranked() []scored {
+	out := append([]scored(nil), c.h...)
+	sort.Slice(out, func(i, j int) bool { return worse(out[j], out[i]) })
+	return out
+}
+
+// parallelScorer splits a scan across goroutines. Each worker fills its own
+// candidate sets, merged once the scan ends, so workers never contend.
+type parallelScorer struct {
+	sets [][]*candidateSet // worker -> query
+}
+
+func newParallelScorer(workers, queries, keep int) *parallelScorer {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	p := &parallelScorer{sets: make([][]*candidateSet, workers)}
+	for w := range p.sets {
+		p.sets[w] = make([]*candidateSet, queries)
+		for q := range p.sets[w] {
+			p.sets[w][q] = newCandidateSet(keep)
+		}
+	}
+	return p
+}
+
+// run scores rows [0, n) in contiguous ranges, one per worker. score must
+// only read shared state; it returns the first error of any range.
+func (p *parallelScorer) run(n int, score func(sets []*candidateSet, lo, hi int) error) error {
+	workers := min(len(p.sets), max(1, n/minRowsPerWorker))
+	if workers == 1 {
+		return score(p.sets[0], 0, n)
+	}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	step := (n + workers - 1) / workers
+	for w := 0; w < workers; w++ {
+		lo, hi := w*step, min((w+1)*step, n)
+		if lo >= hi {
+			break
+		}
+		wg.Add(1)
+		go func(sets []*candidateSet) {
+			defer wg.Done()
+			if err := score(sets, lo, hi); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(p.sets[w])
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// results merges the workers' candidates into one set per query.
+func (p *parallelScorer) results() []*candidateSet {
+	out := p.sets[0]
+	for _, sets := range p.sets[1:] {
+		for q, set := range sets {
+			out[q].merge(set)
+		}
+	}
+	return out
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"docod/internal/knowledge"
+)
+
+// DefaultVectorDriver keeps embeddings in the project database.
+const DefaultVectorDriver = "sqlite"
+
+// VectorIndex is a knowledge.Indexer opened by a registered driver. Drivers
+// may also implement the optional knowledge capabilities (content hashes,
+// keyword and batch search), which the engine detects by type assertion.
+type VectorIndex interface {
+	knowledge.Indexer
+	Close() error
+}
+
+// VectorIndexConfig is what a driver needs to open its index.
+type VectorIndexConfig struct {
+	// SQLite is the project database; the sqlite driver indexes into it.
+	SQLite *SQLiteStore
+	// Search tunes the sqlite driver's scans.
+	Search SearchOptions
+	// URL locates a remote index: the Qdrant base URL or a Postgres DSN.
+	URL string
+	// Collection is the Qdrant collection or pgvector table; empty uses
+	// "docod_chunks".
+	Collection string
+	APIKey     string
+}
+
+func (c VectorIndexConfig) collection() string {
+	if name := strings.TrimSpace(c.Collection); name != "" {
+		return name
+	}
+	return "docod_chunks"
+}
+
+// VectorDriver opens a vector index.
+type VectorDriver func(ctx context.Context, cfg VectorIndexConfig) (VectorIndex, error)
+
+var (
+	vectorDriversMu sync.RWMutex
+	vectorDrivers   = make(map[string]VectorDriver)
+)
+
+// RegisterVectorDriver makes a driver available by name. Like database/sql,
+// it panics when the name is taken or the driver is nil.
+func RegisterVectorDriver(name string, driver VectorDriver) {
+	vectorDriversMu.Lock()
+	defer vectorDriversMu.Unlock()
+	if driver == nil {
+		panic("storage: RegisterVectorDriver driver is nil")
+	}
+	if _, dup := vectorDrivers[name]; dup {
+		panic("storage: RegisterVectorDriver called twice for driver " + name)
+	}
+	vectorDrivers[name] = driver
+}
+
+// VectorDrivers returns the registered driver names, sorted.
+func VectorDrivers() []string {
+	vectorDriversMu.RLock()
+	defer vectorDriversMu.RUnlock()
+	names := make([]string, 0, len(vectorDrivers))
+	for name := range vectorDrivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OpenVectorIndex opens the index of the named driver; empty uses
+// DefaultVectorDriver.
+func OpenVectorIndex(ctx context.Context, name string, cfg VectorIndexConfig) (VectorIndex, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = DefaultVectorDriver
+	}
+	vectorDriversMu.RLock()
+	driver, ok := vectorDrivers[name]
+	vectorDriversMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown vector store driver %q (registered: %s)", name, strings.Join(VectorDrivers(), ", "))
+	}
+	return driver(ctx, cfg)
+}
+
+func init() {
+	RegisterVectorDriver("sqlite", openSQLiteIndex)
+	RegisterVectorDriver("memory", func(context.Context, VectorIndexConfig) (VectorIndex, error) {
+		return NewMemoryIndex(), nil
+	})
+	RegisterVectorDriver("qdrant", openQdrantIndex)
+	RegisterVectorDriver("pgvector", openPGVectorIndex)
+}
+
+// sharedSQLite indexes into the project database, which its opener closes.
+type sharedSQLite struct {
+	*SQLiteStore
+}
+
+func (sharedSQLite) Close() error {
+	return nil
+}
+
+func openSQLiteIndex(_ context.Context, cfg VectorIndexConfig) (VectorIndex, error) {
+	if cfg.SQLite == nil {
+		return nil, fmt.Errorf("sqlite vector store needs the project database")
+	}
+	cfg.SQLite.SetSearchOptions(cfg.Search)
+	return sharedSQLite{cfg.SQLite}, nil
+}
+
+// deleteMatches reports whether Delete(ids) removes chunk: like the sqlite
+// driver, an ID also names every chunk of that file.
+func deleteMatches(ids map[string]bool, chunk knowledge.SearchChunk) bool {
+	return ids[chunk.ID] || (chunk.FilePath != "" && ids[chunk.FilePath])
+}
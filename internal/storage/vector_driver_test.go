@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"docod/internal/knowledge"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenVectorIndex_ResolvesRegisteredDrivers(t *testing.T) {
+	assert.Subset(t, VectorDrivers(), []string{"memory", "pgvector", "qdrant", "sqlite"})
+
+	_, err := OpenVectorIndex(context.Background(), "faiss", VectorIndexConfig{})
+	assert.ErrorContains(t, err, `unknown vector store driver "faiss"`)
+	_, err = OpenVectorIndex(context.Background(), "qdrant", VectorIndexConfig{})
+	assert.ErrorContains(t, err, "needs a url")
+	assert.Panics(t, func() { RegisterVectorDriver("memory", openSQLiteIndex) })
+
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	seedVectors(t, store, 10)
+
+	idx, err := OpenVectorIndex(context.Background(), "", VectorIndexConfig{SQLite: store, Search: SearchOptions{PageSize: 3}})
+	require.NoError(t, err)
+	assert.Equal(t, 3, store.searchOpts.PageSize)
+	assert.Implements(t, (*knowledge.KeywordSearcher)(nil), idx, "optional capabilities survive the driver")
+	require.NoError(t, idx.Close())
+	items, err := idx.Search(context.Background(), []float32{1, 0}, 1, knowledge.SearchFilter{})
+	require.NoError(t, err, "closing the index leaves the project database open")
+	assert.Equal(t, "c000", items[0].Chunk.ID)
+}
+
+func TestMemoryIndex_SearchesAndDeletes(t *testing.T) {
+	ctx := context.Background()
+	idx, err := OpenVectorIndex(ctx, "memory", VectorIndexConfig{})
+	require.NoError(t, err)
+	require.NoError(t, idx.Add(ctx, []knowledge.VectorItem{
+		{Chunk: knowledge.SearchChunk{ID: "a", Name: "OpenStore", FilePath: "store/db.go", ContentHash: "h1"}, Embedding: []float32{1, 0}},
+		{Chunk: knowledge.SearchChunk{ID: "b", Name: "Run", FilePath: "cli/run.go"}, Embedding: []float32{0, 1}, CodeEmbedding: []float32{0.9, 0.1}},
+		{Chunk: knowledge.SearchChunk{ID: "c", Name: "Close", FilePath: "store/db.go"}, Embedding: []float32{-1, 0}},
+	}))
+
+	items, err := idx.Search(ctx, []float32{1, 0}, 2, knowledge.SearchFilter{})
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "a", items[0].Chunk.ID)
+	assert.Equal(t, "b", items[1].Chunk.ID, "matched through its code vector")
+
+	items, err = idx.Search(ctx, []float32{1, 0}, 2, knowledge.SearchFilter{PathPrefixes: []string{"store/"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "c"}, []string{items[0].Chunk.ID, items[1].Chunk.ID})
+
+	hashes, err := idx.(knowledge.IndexContentHashReader).GetContentHashes(ctx, []string{"a", "zzz"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "h1"}, hashes)
+
+	keyword, err := idx.(knowledge.KeywordSearcher).KeywordSearch(ctx, "open store", 5, knowledge.SearchFilter{})
+	require.NoError(t, err)
+	require.NotEmpty(t, keyword)
+	assert.Equal(t, "a", keyword[0].Chunk.ID)
+
+	require.NoError(t, idx.Delete(ctx, []string{"store/db.go"}))
+	items, err = idx.Search(ctx, []float32{1, 0}, 5, knowledge.SearchFilter{})
+	require.NoError(t, err)
+	require.Len(t, items, 1, "deleting a file path drops its chunks")
+	assert.Equal(t, "b", items[0].Chunk.ID)
+}
+
+func TestVectorLiteral(t *testing.T) {
+	assert.Equal(t, "[0.5,-1,0.1]", vectorLiteral([]float32{0.5, -1, 0.1}))
+	_, err := OpenVectorIndex(context.Background(), "pgvector", VectorIndexConfig{URL: "postgres://x", Collection: "chunks; DROP TABLE x"})
+	assert.ErrorContains(t, err, "not a plain SQL identifier")
+}
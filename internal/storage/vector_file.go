@@ -0,0 +1,297 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// Vector file layout, all little-endian:
+//
+//	header  magic[8] generation:u64 dim:u32 count:u32 reserved[8]
+//	records count × (hasCode:u32, text:[dim]f32, code:[dim]f32)
+//	ids     count × (len:u32, bytes)
+const (
+	vectorFileMagic      = "DOCODVF1"
+	vectorFileHeaderSize = 32
+)
+
+// errMmapUnsupported means the platform cannot map files.
+var errMmapUnsupported = errors.New("memory-mapped files are not supported on this platform")
+
+// errMixedDimensions means the chunks were embedded with different models,
+// which the fixed-size records cannot hold.
+var errMixedDimensions = errors.New("embeddings have mixed dimensions")
+
+// vectorFile is a mapped vector file.
+type vectorFile struct {
+	generation uint64
+	dim        int
+	ids        []string
+	records    []byte
+	unmap      func() error
+}
+
+func (v *vectorFile) recordSize() int {
+	return 4 + 8*v.dim
+}
+
+// Close unmaps the file.
+func (v *vectorFile) Close() error {
+	if v.unmap == nil {
+		return nil
+	}
+	return v.unmap()
+}
+
+// openVectorFile maps the vector file at path.
+func openVectorFile(path string) (*vectorFile, error) {
+	data, unmap, err := mmapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	v, err := parseVectorFile(data)
+	if err != nil {
+		_ = unmap()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	v.unmap = unmap
+	return v, nil
+}
+
+func parseVectorFile(data []byte) (*vectorFile, error) {
+	if len(data) < vectorFileHeaderSize || string(data[:8]) != vectorFileMagic {
+		return nil, errors.New("not a docod vector file")
+	}
+	v := &vectorFile{
+		generation: binary.LittleEndian.Uint64(data[8:]),
+		dim:        int(binary.LittleEndian.Uint32(data[16:])),
+	}
+	count := int(binary.LittleEndian.Uint32(data[20:]))
+	end := vectorFileHeaderSize + count*v.recordSize()
+	if end > len(data) {
+		return nil, errors.New("truncated vector records")
+	}
+	v.records = data[vectorFileHeaderSize:end]
+	v.ids = make([]string, 0, count)
+	for off := end; len(v.ids) < count; {
+		if off+4 > len(data) {
+			return nil, errors.New("truncated vector ids")
+		}
+		n := int(binary.LittleEndian.Uint32(data[off:]))
+		off += 4
+		if off+n > len(data) {
+			return nil, errors.New("truncated vector ids")
+		}
+		v.ids = append(v.ids, string(data[off:off+n]))
+		off += n
+	}
+	return v, nil
+}
+
+// scan scores every record against the queries, each worker taking a
+// contiguous range of records and decoding into its own buffers; pages of
+// the file are loaded by the OS as they are read.
+func (v *vectorFile) scan(ctx context.Context, queryVectors [][]float32, scorer *parallelScorer) error {
+	size := v.recordSize()
+	return scorer.run(len(v.ids), func(sets []*candidateSet, lo, hi int) error {
+		text := make([]float32, v.dim)
+		code := make([]float32, v.dim)
+		for i := lo; i < hi; i++ {
+			if (i-lo)%4096 == 0 && ctx.Err() != nil {
+				return ctx.Err()
+			}
+			rec := v.records[i*size : (i+1)*size]
+			hasCode := binary.LittleEndian.Uint32(rec) != 0
+			text = decodeVector(text, rec[4:4+4*v.dim])
+			codeVec := code[:0]
+			if hasCode {
+				codeVec = decodeVector(code, rec[4+4*v.dim:])
+			}
+			for q, queryVector := range queryVectors {
+				sets[q].add(v.ids[i], bestScore(queryVector, text, codeVec))
+			}
+		}
+		return nil
+	})
+}
+
+// scanVectorFile scores the queries against the vector file, rebuilding it
+// first when the chunks changed since it was written. It reports false when
+// the file cannot be used, so the caller scans the database instead.
+func (s *SQLiteStore) scanVectorFile(ctx context.Context, opts SearchOptions, queryVectors [][]float32, scorer *parallelScorer) (bool, error) {
+	generation, err := s.vectorGeneration(ctx)
+	if err != nil {
+		return false, err
+	}
+	s.vecMu.RLock()
+	fresh := s.vecFile != nil && s.vecFile.generation == generation
+	s.vecMu.RUnlock()
+	if !fresh {
+		if err := s.refreshVectorFile(ctx, opts, generation); err != nil {
+			if errors.Is(err, errMmapUnsupported) || errors.Is(err, errMixedDimensions) {
+				return false, nil
+			}
+			return false, fmt.Errorf("vector file: %w", err)
+		}
+	}
+
+	s.vecMu.RLock()
+	defer s.vecMu.RUnlock()
+	if s.vecFile == nil || s.vecFile.dim != len(queryVectors[0]) {
+		return false, nil
+	}
+	return true, s.vecFile.scan(ctx, queryVectors, scorer)
+}
+
+// refreshVectorFile maps the file at opts.VectorFile, rewriting it when it
+// is older than generation.
+func (s *SQLiteStore) refreshVectorFile(ctx context.Context, opts SearchOptions, generation uint64) error {
+	s.vecMu.Lock()
+	defer s.vecMu.Unlock()
+	if s.vecFile != nil {
+		if s.vecFile.generation == generation {
+			return nil
+		}
+		_ = s.vecFile.Close()
+		s.vecFile = nil
+	}
+	if v, err := openVectorFile(opts.VectorFile); err == nil {
+		if v.generation == generation {
+			s.vecFile = v
+			return nil
+		}
+		_ = v.Close()
+	} else if errors.Is(err, errMmapUnsupported) {
+		return err
+	}
+	if err := s.writeVectorFile(ctx, opts, generation); err != nil {
+		return err
+	}
+	v, err := openVectorFile(opts.VectorFile)
+	if err != nil {
+		return err
+	}
+	s.vecFile = v
+	return nil
+}
+
+// writeVectorFile copies every embedding into a new vector file, paging
+// through the chunks table, and renames it over path.
+func (s *SQLiteStore) writeVectorFile(ctx context.Context, opts SearchOptions, generation uint64) (err error) {
+	if err := os.MkdirAll(filepath.Dir(opts.VectorFile), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(opts.VectorFile), filepath.Base(opts.VectorFile)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	w := bufio.NewWriter(tmp)
+	header := make([]byte, vectorFileHeaderSize)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	pageSize, _ := opts.scanPlan(0, 0)
+	var (
+		ids  []string
+		dim  = -1
+		last int64
+		word [4]byte
+	)
+	writeFloats := func(blob []byte) error {
+		if len(blob) == 0 {
+			_, err := w.Write(make([]byte, 4*dim))
+			return err
+		}
+		_, err := w.Write(blob)
+		return err
+	}
+	for {
+		rows, err := s.db.QueryContext(ctx, "SELECT rowid, id, embedding, code_embedding FROM chunks WHERE rowid > ? ORDER BY rowid LIMIT ?", last, pageSize)
+		if err != nil {
+			return err
+		}
+		n := 0
+		for rows.Next() {
+			var id string
+			var embeddingBlob, codeBlob []byte
+			if err := rows.Scan(&last, &id, &embeddingBlob, &codeBlob); err != nil {
+				rows.Close()
+				return err
+			}
+			n++
+			if dim < 0 {
+				dim = len(embeddingBlob) / 4
+			}
+			if len(embeddingBlob) != 4*dim || (len(codeBlob) != 0 && len(codeBlob) != 4*dim) {
+				rows.Close()
+				return errMixedDimensions
+			}
+			hasCode := uint32(0)
+			if len(codeBlob) > 0 {
+				hasCode = 1
+			}
+			binary.LittleEndian.PutUint32(word[:], hasCode)
+			if _, err := w.Write(word[:]); err != nil {
+				rows.Close()
+				return err
+			}
+			if err := writeFloats(embeddingBlob); err != nil {
+				rows.Close()
+				return err
+			}
+			if err := writeFloats(codeBlob); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if n < pageSize {
+			break
+		}
+	}
+	for _, id := range ids {
+		binary.LittleEndian.PutUint32(word[:], uint32(len(id)))
+		if _, err := w.Write(word[:]); err != nil {
+			return err
+		}
+		if _, err := w.WriteString(id); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	copy(header, vectorFileMagic)
+	binary.LittleEndian.PutUint64(header[8:], generation)
+	binary.LittleEndian.PutUint32(header[16:], uint32(max(dim, 0)))
+	if len(ids) > math.MaxUint32 {
+		return errors.New("too many chunks for a vector file")
+	}
+	binary.LittleEndian.PutUint32(header[20:], uint32(len(ids)))
+	if _, err := tmp.WriteAt(header, 0); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), opts.VectorFile)
+}
@@ -0,0 +1,282 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"docod/internal/knowledge"
+)
+
+const (
+	// DefaultScanPageSize is the number of chunks read per page of a vector
+	// scan when no memory limit narrows it.
+	DefaultScanPageSize = 1024
+	// metaVectorGeneration counts writes to the chunks table, so a vector
+	// file can tell whether it still matches the database.
+	metaVectorGeneration = "vector_generation"
+	// resolveBatch is the number of candidates whose chunks are read at once
+	// while resolving results.
+	resolveBatch = 64
+)
+
+// SearchOptions bounds the memory used by vector search.
+type SearchOptions struct {
+	// PageSize is the number of chunks read per query during a scan; 0 uses
+	// DefaultScanPageSize.
+	PageSize int
+	// MemoryLimitBytes caps the rows read per page; 0 leaves pages at
+	// PageSize. Candidates live in bounded heaps whatever the limit.
+	MemoryLimitBytes int64
+	// Workers is the number of goroutines scoring each page; 0 uses
+	// GOMAXPROCS.
+	Workers int
+	// Mmap mirrors the embeddings into a memory-mapped vector file and scans
+	// it instead of the database. The file is rebuilt whenever the chunks
+	// change; platforms without mmap use the paged scan.
+	Mmap bool
+	// VectorFile is the path of that file; empty uses the database path
+	// with a ".vectors" suffix.
+	VectorFile string
+	// Index selects how unfiltered searches find candidates: IndexFlat
+	// (empty) scans every vector, IndexHNSW walks an in-memory graph that
+	// is rebuilt after the chunks change.
+	Index string
+	// HNSWM is the number of links per point; 0 uses DefaultHNSWM.
+	HNSWM int
+	// EfSearch is the candidate list size of an HNSW search, raised to the
+	// candidates a search keeps; 0 uses DefaultHNSWEfSearch.
+	EfSearch int
+}
+
+// SetSearchOptions configures later vector searches.
+func (s *SQLiteStore) SetSearchOptions(opts SearchOptions) {
+	s.vecMu.Lock()
+	defer s.vecMu.Unlock()
+	if opts.Mmap && opts.VectorFile == "" {
+		opts.VectorFile = s.path + ".vectors"
+	}
+	if !opts.Mmap {
+		opts.VectorFile = ""
+	}
+	if opts.VectorFile != s.searchOpts.VectorFile && s.vecFile != nil {
+		_ = s.vecFile.Close()
+		s.vecFile = nil
+	}
+	s.searchOpts = opts
+}
+
+// scanPlan sizes a scan for vectors of dim floats.
+func (o SearchOptions) scanPlan(dim, topK int) (pageSize int, keep int) {
+	pageSize = o.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultScanPageSize
+	}
+	// Candidates are kept generously past topK, since filters applied while
+	// resolving may drop some of them.
+	keep = max(4*topK, topK+resolveBatch)
+	if o.MemoryLimitBytes > 0 {
+		rowBytes := int64(2*4*max(dim, 1) + 512)
+		pageSize = max(1, min(pageSize, int(o.MemoryLimitBytes/rowBytes)))
+	}
+	return pageSize, keep
+}
+
+// decodeVector decodes a little-endian float32 blob into buf, growing it only
+// when needed, so a scan reuses one buffer for every row.
+func decodeVector(buf []float32, blob []byte) []float32 {
+	n := len(blob) / 4
+	if cap(buf) < n {
+		buf = make([]float32, n)
+	}
+	buf = buf[:n]
+	for i := range buf {
+		buf[i] = math.Float32frombits(binary.LittleEndian.Uint32(blob[4*i:]))
+	}
+	return buf
+}
+
+// bestScore is the better of the text and code similarities, for dual
+// embeddings.
+func bestScore(query, embedding, code []float32) float32 {
+	score := cosineSimilarity(query, embedding)
+	if len(code) > 0 {
+		if cs := cosineSimilarity(query, code); cs > score {
+			score = cs
+		}
+	}
+	return score
+}
+
+// searchScoredBatch scores every query vector in one pass over the stored
+// vectors, spreading each page across workers that keep only the top IDs and
+// scores, then reads the chunks of the best candidates.
+func (s *SQLiteStore) searchScoredBatch(ctx context.Context, queryVectors [][]float32, topK int, filter knowledge.SearchFilter) ([][]knowledge.VectorItem, error) {
+	if len(queryVectors) == 0 {
+		return nil, nil
+	}
+	s.vecMu.RLock()
+	opts := s.searchOpts
+	s.vecMu.RUnlock()
+	_, keep := opts.scanPlan(len(queryVectors[0]), topK)
+	scorer := newParallelScorer(opts.Workers, len(queryVectors), keep)
+
+	where, args := chunkFilterClause(filter)
+	scanned := false
+	if opts.Index == IndexHNSW && where == "" {
+		ok, err := s.searchHNSW(ctx, opts, queryVectors, keep, scorer)
+		if err != nil {
+			return nil, err
+		}
+		scanned = ok
+	}
+	if !scanned && opts.VectorFile != "" && where == "" {
+		// The vector file holds no chunk fields, so only searches the SQL
+		// clause would not narrow can use it.
+		ok, err := s.scanVectorFile(ctx, opts, queryVectors, scorer)
+		if err != nil {
+			return nil, err
+		}
+		scanned = ok
+	}
+	if !scanned {
+		if err := s.scanPaged(ctx, opts, where, args, queryVectors, scorer); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([][]knowledge.VectorItem, len(queryVectors))
+	for q, set := range scorer.results() {
+		items, err := s.resolveCandidates(ctx, set.ranked(), topK, filter)
+		if err != nil {
+			return nil, err
+		}
+		results[q] = items
+	}
+	return results, nil
+}
+
+// pageRow is a decoded row of a scan page. Rows are reused from page to
+// page, so their vectors only grow.
+type pageRow struct {
+	id         string
+	text, code []float32
+}
+
+// scanPaged reads the chunks table a page at a time by rowid, decoding each
+// page into reused rows and scoring it in parallel.
+func (s *SQLiteStore) scanPaged(ctx context.Context, opts SearchOptions, where string, args []interface{}, queryVectors [][]float32, scorer *parallelScorer) error {
+	pageSize, _ := opts.scanPlan(len(queryVectors[0]), 0)
+	cond := " WHERE rowid > ?"
+	if where != "" {
+		cond = where + " AND rowid > ?"
+	}
+	query := "SELECT rowid, id, embedding, code_embedding FROM chunks" + cond + " ORDER BY rowid LIMIT ?"
+	page := make([]pageRow, pageSize)
+	var last int64
+	for {
+		rows, err := s.db.QueryContext(ctx, query, append(append([]interface{}{}, args...), last, pageSize)...)
+		if err != nil {
+			return err
+		}
+		n := 0
+		for rows.Next() {
+			var embeddingBlob, codeBlob []byte
+			row := &page[n]
+			if err := rows.Scan(&last, &row.id, &embeddingBlob, &codeBlob); err != nil {
+				rows.Close()
+				return err
+			}
+			row.text = decodeVector(row.text, embeddingBlob)
+			row.code = decodeVector(row.code, codeBlob)
+			n++
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		err = scorer.run(n, func(sets []*candidateSet, lo, hi int) error {
+			for _, row := range page[lo:hi] {
+				for q, queryVector := range queryVectors {
+					sets[q].add(row.id, bestScore(queryVector, row.text, row.code))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if n < pageSize {
+			return nil
+		}
+	}
+}
+
+// resolveCandidates reads the chunks of ranked candidates in order until
+// topK pass filter.
+func (s *SQLiteStore) resolveCandidates(ctx context.Context, ranked []scored, topK int, filter knowledge.SearchFilter) ([]knowledge.VectorItem, error) {
+	out := make([]knowledge.VectorItem, 0, min(topK, len(ranked)))
+	for start := 0; start < len(ranked) && len(out) < topK; start += resolveBatch {
+		batch := ranked[start:min(start+resolveBatch, len(ranked))]
+		ids := make([]interface{}, len(batch))
+		for i, c := range batch {
+			ids[i] = c.id
+		}
+		marks := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+		rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT id, content FROM chunks WHERE id IN (%s)", marks), ids...)
+		if err != nil {
+			return nil, err
+		}
+		contents := make(map[string][]byte, len(batch))
+		for rows.Next() {
+			var id string
+			var contentJSON []byte
+			if err := rows.Scan(&id, &contentJSON); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			contents[id] = contentJSON
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range batch {
+			var chunk knowledge.SearchChunk
+			if err := json.Unmarshal(contents[c.id], &chunk); err != nil || !filter.Match(chunk) {
+				continue
+			}
+			out = append(out, knowledge.VectorItem{Chunk: chunk, Score: c.score})
+			if len(out) == topK {
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// vectorGeneration returns the current chunk write counter.
+func (s *SQLiteStore) vectorGeneration(ctx context.Context) (uint64, error) {
+	var v uint64
+	err := s.db.QueryRowContext(ctx, "SELECT COALESCE((SELECT CAST(value AS INTEGER) FROM meta WHERE key = ?), 0)", metaVectorGeneration).Scan(&v)
+	return v, err
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// bumpVectorGeneration records a write to the chunks table within tx.
+func bumpVectorGeneration(ctx context.Context, tx execer) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO meta (key, value) VALUES (?, '1')
+		ON CONFLICT(key) DO UPDATE SET value = CAST(CAST(value AS INTEGER) + 1 AS TEXT)
+	`, metaVectorGeneration)
+	return err
+}
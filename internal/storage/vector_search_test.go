@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"docod/internal/knowledge"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedVectors stores n chunks whose embeddings turn away from {1, 0} as the
+// index grows, so that query ranks them by index.
+func seedVectors(t *testing.T, store *SQLiteStore, n int) {
+	t.Helper()
+	items := make([]knowledge.VectorItem, n)
+	for i := range items {
+		angle := float64(i+1) / float64(n+1) * math.Pi / 2
+		items[i] = knowledge.VectorItem{
+			Chunk:     knowledge.SearchChunk{ID: fmt.Sprintf("c%03d", i), Name: fmt.Sprintf("C%d", i), FilePath: fmt.Sprintf("pkg/f%d.go", i%3)},
+			Embedding: []float32{float32(math.Cos(angle)), float32(math.Sin(angle))},
+		}
+	}
+	require.NoError(t, store.SaveEmbeddings(context.Background(), items))
+}
+
+func searchIDs(t *testing.T, store *SQLiteStore, topK int, filter knowledge.SearchFilter) []string {
+	t.Helper()
+	items, err := store.Search(context.Background(), []float32{1, 0}, topK, filter)
+	require.NoError(t, err)
+	out := make([]string, len(items))
+	for i, it := range items {
+		out[i] = it.Chunk.ID
+	}
+	return out
+}
+
+func TestSQLiteStore_PagedSearchMatchesAcrossPageSizes(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	seedVectors(t, store, 50)
+
+	want := []string{"c000", "c001", "c002", "c003", "c004"}
+	assert.Equal(t, want, searchIDs(t, store, 5, knowledge.SearchFilter{}))
+
+	store.SetSearchOptions(SearchOptions{PageSize: 7})
+	assert.Equal(t, want, searchIDs(t, store, 5, knowledge.SearchFilter{}))
+
+	// A tiny ceiling shrinks pages to one row; the ranking is unchanged.
+	store.SetSearchOptions(SearchOptions{MemoryLimitBytes: 1})
+	assert.Equal(t, want, searchIDs(t, store, 5, knowledge.SearchFilter{}))
+	assert.Equal(t, []string{"c000", "c003", "c006"}, searchIDs(t, store, 3, knowledge.SearchFilter{PathPrefixes: []string{"pkg/f0.go"}}))
+	assert.Equal(t, []string{"c001", "c002", "c004"}, searchIDs(t, store, 3, knowledge.SearchFilter{ExcludePaths: []string{"pkg/f0.go"}}))
+}
+
+func TestSearchOptions_ScanPlanHonorsMemoryLimit(t *testing.T) {
+	page, keep := SearchOptions{}.scanPlan(768, 10)
+	assert.Equal(t, DefaultScanPageSize, page)
+	assert.GreaterOrEqual(t, keep, 10)
+
+	page, _ = SearchOptions{MemoryLimitBytes: 1 << 20}.scanPlan(768, 10)
+	assert.Equal(t, (1<<20)/(2*4*768+512), page)
+}
+
+func TestCandidateSet_KeepsBestInOrder(t *testing.T) {
+	set := newCandidateSet(3)
+	for i, score := range []float32{0.1, 0.9, 0.5, 0.7, 0.2, 0.9, 0.3} {
+		set.add(fmt.Sprintf("c%d", i), score)
+	}
+	assert.Len(t, set.h, 3, "the heap never grows past keep")
+	// Ties rank by ID.
+	assert.Equal(t, []scored{{"c1", 0.9}, {"c5", 0.9}, {"c3", 0.7}}, set.ranked())
+}
+
+func TestParallelScorer_MatchesSingleWorker(t *testing.T) {
+	const n = 4 * minRowsPerWorker
+	scores := make([]float32, n)
+	for i := range scores {
+		scores[i] = float32((i * 7919) % 1000)
+	}
+	rank := func(workers int) []scored {
+		p := newParallelScorer(workers, 1, 10)
+		require.NoError(t, p.run(n, func(sets []*candidateSet, lo, hi int) error {
+			for i := lo; i < hi; i++ {
+				sets[0].add(fmt.Sprintf("c%04d", i), scores[i])
+			}
+			return nil
+		}))
+		return p.results()[0].ranked()
+	}
+	assert.Equal(t, rank(1), rank(4))
+}
+
+func TestSQLiteStore_ParallelSearch(t *testing.T) {
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer store.Close()
+	seedVectors(t, store, 3*minRowsPerWorker)
+
+	store.SetSearchOptions(SearchOptions{Workers: 1})
+	serial := searchIDs(t, store, 8, knowledge.SearchFilter{})
+	store.SetSearchOptions(SearchOptions{Workers: 4})
+	assert.Equal(t, serial, searchIDs(t, store, 8, knowledge.SearchFilter{}))
+	assert.Equal(t, "c000", serial[0])
+}
+
+func TestSQLiteStore_MmapVectorFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("memory-mapped vector files need mmap")
+	}
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	store, err := NewSQLiteStore(dbPath)
+	require.NoError(t, err)
+	defer store.Close()
+	seedVectors(t, store, 20)
+
+	store.SetSearchOptions(SearchOptions{Mmap: true, PageSize: 4})
+	assert.Equal(t, []string{"c000", "c001", "c002"}, searchIDs(t, store, 3, knowledge.SearchFilter{}))
+	info, err := os.Stat(dbPath + ".vectors")
+	require.NoError(t, err, "file is written next to the database")
+	first := store.vecFile
+	require.NotNil(t, first)
+	assert.Len(t, first.ids, 20)
+
+	// Filters the SQL clause cannot express are applied while resolving.
+	assert.Equal(t, []string{"c001", "c002"}, searchIDs(t, store, 2, knowledge.SearchFilter{ExcludePaths: []string{"pkg/f0.go"}}))
+	assert.Same(t, first, store.vecFile, "unchanged chunks reuse the mapping")
+
+	// A write makes the file stale; the next search rebuilds it.
+	require.NoError(t, store.SaveEmbeddings(context.Background(), []knowledge.VectorItem{
+		{Chunk: knowledge.SearchChunk{ID: "best", Name: "Best"}, Embedding: []float32{0, 1}, CodeEmbedding: []float32{1, 0}},
+	}))
+	assert.Equal(t, []string{"best", "c000"}, searchIDs(t, store, 2, knowledge.SearchFilter{}))
+	assert.NotSame(t, first, store.vecFile)
+	assert.Len(t, store.vecFile.ids, 21)
+	rebuilt, err := os.Stat(dbPath + ".vectors")
+	require.NoError(t, err)
+	assert.Greater(t, rebuilt.Size(), info.Size())
+
+	// A reopened store maps the existing file instead of rewriting it.
+	require.NoError(t, store.Close())
+	store, err = NewSQLiteStore(dbPath)
+	require.NoError(t, err)
+	store.SetSearchOptions(SearchOptions{Mmap: true})
+	assert.Equal(t, []string{"best"}, searchIDs(t, store, 1, knowledge.SearchFilter{}))
+	again, err := os.Stat(dbPath + ".vectors")
+	require.NoError(t, err)
+	assert.Equal(t, rebuilt.ModTime(), again.ModTime())
+}
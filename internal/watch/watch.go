@@ -0,0 +1,229 @@
+// Package watch reports batches of changed source files under a project
+// root. It subscribes to OS file events with fsnotify: every directory of
+// the tree is watched once at start and new directories as they appear, so
+// the tree is never rescanned.
+package watch
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const DefaultDebounce = 2 * time.Second
+
+// Options configures a Watcher. Zero values use the defaults.
+type Options struct {
+	// Debounce is how long the tree must stay quiet before a batch is
+	// delivered, so an editor's save-all or a branch switch syncs once.
+	Debounce time.Duration
+	// Accept reports whether a file is watched; nil watches every file.
+	Accept func(path string) bool
+	// SkipDirs are directory names never descended into, in addition to
+	// hidden directories; nil uses vendor, node_modules and testdata.
+	SkipDirs []string
+}
+
+// Watcher watches a directory tree for changed files.
+type Watcher struct {
+	root string
+	opts Options
+	fsw  *fsnotify.Watcher
+	// dirs and files are the watched directories and accepted files,
+	// relative to the root, so removing a directory reports its files.
+	dirs  map[string]bool
+	files map[string]bool
+}
+
+func New(root string, opts Options) *Watcher {
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+	if opts.SkipDirs == nil {
+		opts.SkipDirs = []string{"vendor", "node_modules", "testdata"}
+	}
+	return &Watcher{root: root, opts: opts}
+}
+
+// Run watches until ctx is done, calling onBatch with the sorted paths
+// (relative to the root) created, modified or removed since the previous
+// batch. Files present when Run starts are the baseline, not changes.
+// Events queue while onBatch runs; its errors stop Run.
+func (w *Watcher) Run(ctx context.Context, onBatch func(ctx context.Context, paths []string) error) error {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("start file watcher: %w", err)
+	}
+	defer fsw.Close()
+	w.fsw = fsw
+	w.dirs = make(map[string]bool)
+	w.files = make(map[string]bool)
+	if _, err := w.addTree("."); err != nil {
+		return err
+	}
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(w.opts.Debounce)
+	timer.Stop()
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("file watcher: %w", err)
+		case ev, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			changed, err := w.handle(ev)
+			if err != nil {
+				return err
+			}
+			if len(changed) == 0 {
+				continue
+			}
+			for _, p := range changed {
+				pending[p] = true
+			}
+			timer.Reset(w.opts.Debounce)
+		case <-timer.C:
+			if len(pending) == 0 {
+				continue
+			}
+			batch := make([]string, 0, len(pending))
+			for p := range pending {
+				batch = append(batch, p)
+			}
+			sort.Strings(batch)
+			clear(pending)
+			if err := onBatch(ctx, batch); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handle returns the accepted files an event changes.
+func (w *Watcher) handle(ev fsnotify.Event) ([]string, error) {
+	if ev.Op == fsnotify.Chmod {
+		return nil, nil
+	}
+	rel, err := filepath.Rel(w.root, ev.Name)
+	if err != nil {
+		return nil, err
+	}
+	if ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename) {
+		if w.dirs[rel] {
+			return w.forgetDir(rel), nil
+		}
+		if w.files[rel] {
+			delete(w.files, rel)
+			return []string{rel}, nil
+		}
+		return nil, nil
+	}
+	if ev.Has(fsnotify.Create) {
+		info, err := os.Lstat(ev.Name)
+		if err != nil {
+			// Gone again before it could be read; nothing to report.
+			return nil, nil
+		}
+		if info.IsDir() {
+			if w.skipDir(info.Name()) {
+				return nil, nil
+			}
+			// Files written before the watch was added have no events of
+			// their own, so everything found in the new directory counts.
+			return w.addTree(rel)
+		}
+	}
+	if w.dirs[rel] || !w.accept(rel) {
+		return nil, nil
+	}
+	w.files[rel] = true
+	return []string{rel}, nil
+}
+
+// addTree watches dir and its subdirectories and returns the accepted files
+// found in them.
+func (w *Watcher) addTree(dir string) ([]string, error) {
+	var found []string
+	err := filepath.WalkDir(filepath.Join(w.root, dir), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files may vanish mid-walk; their removal has its own event.
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		rel, relErr := filepath.Rel(w.root, path)
+		if relErr != nil {
+			return relErr
+		}
+		if d.IsDir() {
+			if rel != "." && w.skipDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			if err := w.fsw.Add(path); err != nil {
+				if os.IsNotExist(err) {
+					return filepath.SkipDir
+				}
+				return fmt.Errorf("watch %s: %w", rel, err)
+			}
+			w.dirs[rel] = true
+			return nil
+		}
+		if d.Type().IsRegular() && w.accept(rel) {
+			w.files[rel] = true
+			found = append(found, rel)
+		}
+		return nil
+	})
+	return found, err
+}
+
+// forgetDir drops a removed directory and returns the files it held.
+func (w *Watcher) forgetDir(dir string) []string {
+	prefix := dir + string(filepath.Separator)
+	var gone []string
+	for d := range w.dirs {
+		if d == dir || strings.HasPrefix(d, prefix) {
+			delete(w.dirs, d)
+			_ = w.fsw.Remove(filepath.Join(w.root, d))
+		}
+	}
+	for f := range w.files {
+		if strings.HasPrefix(f, prefix) {
+			delete(w.files, f)
+			gone = append(gone, f)
+		}
+	}
+	return gone
+}
+
+func (w *Watcher) accept(rel string) bool {
+	return w.opts.Accept == nil || w.opts.Accept(rel)
+}
+
+func (w *Watcher) skipDir(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return true
+	}
+	for _, skip := range w.opts.SkipDirs {
+		if name == skip {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+// runWatcher runs w in the background and returns its batches and the
+// function stopping it.
+func runWatcher(t *testing.T, w *Watcher) (<-chan []string, func()) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	batches := make(chan []string, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Run(ctx, func(_ context.Context, paths []string) error {
+			batches <- paths
+			return nil
+		})
+	}()
+	// Give Run time to register its watches.
+	time.Sleep(50 * time.Millisecond)
+	return batches, func() {
+		cancel()
+		require.NoError(t, <-done)
+	}
+}
+
+func nextBatch(t *testing.T, batches <-chan []string) []string {
+	t.Helper()
+	select {
+	case batch := <-batches:
+		return batch
+	case <-time.After(3 * time.Second):
+		t.Fatal("no batch delivered")
+		return nil
+	}
+}
+
+func TestWatcher_ReportsChangedFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.go"), "package a")
+	writeFile(t, filepath.Join(root, "b.go"), "package a")
+	writeFile(t, filepath.Join(root, "old", "o.go"), "package old")
+	writeFile(t, filepath.Join(root, "vendor", "v.go"), "package v")
+
+	w := New(root, Options{
+		Debounce: 80 * time.Millisecond,
+		Accept:   func(p string) bool { return strings.HasSuffix(p, ".go") },
+	})
+	batches, stop := runWatcher(t, w)
+	defer stop()
+
+	writeFile(t, filepath.Join(root, "a.go"), "package a // edited")
+	require.NoError(t, os.Remove(filepath.Join(root, "b.go")))
+	writeFile(t, filepath.Join(root, "pkg", "c.go"), "package pkg")
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "old")))
+	writeFile(t, filepath.Join(root, "notes.txt"), "ignored")
+	writeFile(t, filepath.Join(root, "vendor", "v.go"), "package v // skipped")
+	writeFile(t, filepath.Join(root, ".git", "x.go"), "package x")
+
+	assert.Equal(t, []string{"a.go", "b.go", filepath.Join("old", "o.go"), filepath.Join("pkg", "c.go")}, nextBatch(t, batches))
+
+	// Directories created after start are watched too.
+	writeFile(t, filepath.Join(root, "pkg", "d.go"), "package pkg")
+	assert.Equal(t, []string{filepath.Join("pkg", "d.go")}, nextBatch(t, batches))
+}
+
+func TestWatcher_RunDebouncesIntoOneBatch(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.go"), "package a")
+
+	w := New(root, Options{Debounce: 80 * time.Millisecond})
+	batches, stop := runWatcher(t, w)
+
+	// Save twice in quick succession.
+	writeFile(t, filepath.Join(root, "a.go"), "package a // one")
+	time.Sleep(20 * time.Millisecond)
+	writeFile(t, filepath.Join(root, "b.go"), "package a")
+
+	assert.Equal(t, []string{"a.go", "b.go"}, nextBatch(t, batches))
+	stop()
+	assert.Empty(t, batches, "both saves belong to one batch")
+}